@@ -8,7 +8,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/modelx"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/usagex"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/usagex/usagexpg"
+	"github.com/Abraxas-365/manifesto/internal/ai/providers/aiopenai"
+	"github.com/Abraxas-365/manifesto/internal/asyncx"
 	"github.com/Abraxas-365/manifesto/internal/config"
 	"github.com/Abraxas-365/manifesto/internal/fsx"
 	"github.com/Abraxas-365/manifesto/internal/fsx/fsxlocal"
@@ -31,8 +40,28 @@ type Container struct {
 	FileSystem fsx.FileSystem
 	S3Client   *s3.Client
 
+	// Tenant-scoped presigned URLs, nil when the storage backend cannot
+	// presign (local); a nil presigner answers PRESIGN_NOT_SUPPORTED
+	Presigner *fsx.TenantPresigner
+
+	// LLM provider, nil when OPENAI_API_KEY is not set. Both resolve model
+	// aliases with ModelResolver and are metered per tenant unless
+	// LLM_USAGE_METERING is off.
+	LLM           *llm.Client
+	Embedder      embedding.Embedder
+	ModelResolver *modelx.Resolver
+
+	// LLM usage store behind GET /usage/llm, nil when metering is off
+	LLMUsage usagex.Store
+
 	// Bounded-context containers
 	// Add your module containers here
+
+	// Background workers, waited on during graceful shutdown
+	workers asyncx.Workers
+
+	// Lifecycle state reported by /readyz
+	ready readiness
 }
 
 func NewContainer(cfg *config.Config) *Container {
@@ -88,6 +117,17 @@ func (c *Container) initInfrastructure() {
 	// 3. File storage
 	c.initFileStorage()
 
+	// 4. LLM provider (optional), metered per tenant
+	if c.Config.AI.UsageMetering {
+		c.LLMUsage = usagexpg.NewStore(c.DB)
+	}
+	if apiKey := getEnv("OPENAI_API_KEY", ""); apiKey != "" {
+		c.initLLM(aiopenai.NewOpenAIProvider(apiKey))
+		logx.Info("  ✅ OpenAI provider configured")
+	} else {
+		logx.Warn("  ⚠️  OPENAI_API_KEY not set, LLM provider disabled")
+	}
+
 	logx.Info("✅ Infrastructure initialized")
 }
 
@@ -119,6 +159,59 @@ func (c *Container) initFileStorage() {
 	default:
 		logx.Fatalf("Unknown STORAGE_MODE: %s (use 'local' or 's3')", storageMode)
 	}
+
+	if generator, err := fsx.Presigner(c.FileSystem); err == nil {
+		c.Presigner = fsx.NewTenantPresigner(generator, c.Config.Storage.PresignExpiration, c.Config.Storage.PresignMaxExpiration)
+		logx.Info("  ✅ Presigned URLs enabled")
+	} else {
+		logx.Warn("  ⚠️  Storage backend cannot presign URLs, files go through the API")
+	}
+}
+
+// initLLM exposes provider as c.LLM and c.Embedder. Calls name a model alias
+// (or none, for chat-default / embed-default) that c.ModelResolver maps to a
+// concrete model, and calls made on behalf of a tenant are recorded in
+// llm_usage with that model.
+func (c *Container) initLLM(provider *aiopenai.OpenAIProvider) {
+	cfg := c.Config.AI
+
+	var chat llm.LLM = provider
+	var embedder embedding.Embedder = provider
+	if c.LLMUsage != nil {
+		pricing, err := usagex.ParsePricing(usagex.DefaultPricing, cfg.Pricing)
+		if err != nil {
+			logx.Fatalf("Invalid LLM_PRICING: %v", err)
+		}
+		// Metered tokens also count against the tenant's monthly quota once
+		// the IAM module is composed:
+		// usagex.WithQuota(func(ctx context.Context, tenantID kernel.TenantID, tokens int64) error {
+		// 	if c.IAM.QuotaService == nil { // no Redis
+		// 		return nil
+		// 	}
+		// 	_, err := c.IAM.QuotaService.Consume(ctx, tenantID, tenant.QuotaLLMTokensMonthly, tokens)
+		// 	return err
+		// })
+		meter := usagex.NewMeter(c.LLMUsage, pricing)
+		chat = usagex.NewMeteredLLM(chat, meter, cfg.ChatModel)
+		embedder = usagex.NewMeteredEmbedder(embedder, meter, cfg.EmbeddingModel)
+		logx.Info("  ✅ LLM usage metering enabled")
+	}
+
+	aliases := map[string]string{
+		modelx.AliasChatDefault:  cfg.ChatModel,
+		modelx.AliasEmbedDefault: cfg.EmbeddingModel,
+	}
+	for alias, model := range cfg.ModelAliases {
+		aliases[alias] = model
+	}
+	// Per-tenant aliases come from the IAM module once it is composed:
+	// modelx.WithTenantAliases(func(ctx context.Context, tenantID string) (map[string]string, error) {
+	// 	return c.IAM.TenantService.ModelAliases(ctx, kernel.TenantID(tenantID))
+	// })
+	c.ModelResolver = modelx.NewResolver(aliases)
+
+	c.LLM = llm.NewClient(modelx.NewResolvingLLM(chat, c.ModelResolver))
+	c.Embedder = modelx.NewResolvingEmbedder(embedder, c.ModelResolver)
 }
 
 // ---------------------------------------------------------------------------
@@ -136,7 +229,24 @@ func (c *Container) initModules() {
 
 func (c *Container) StartBackgroundServices(ctx context.Context) {
 	logx.Info("🔄 Starting background services...")
-	// Add your background services here
+	// Add your background services here, registered in c.workers:
+	// c.IAM.StartBackgroundServices(ctx, &c.workers)
+
+	c.ready.markStarted()
+}
+
+// WaitBackgroundServices blocks until every background worker returns or
+// timeout elapses, logging the workers that did not finish. The context
+// passed to StartBackgroundServices must be cancelled first.
+func (c *Container) WaitBackgroundServices(timeout time.Duration) {
+	logx.Info("⏳ Waiting for background services...")
+
+	if pending := c.workers.Wait(timeout); len(pending) > 0 {
+		logx.Warnf("⚠️  Background services did not finish within %s: %s", timeout, strings.Join(pending, ", "))
+		return
+	}
+
+	logx.Info("✅ Background services stopped")
 }
 
 func (c *Container) Cleanup() {
@@ -0,0 +1,179 @@
+// cmd/health.go
+//
+// Optional health probes for external dependencies (OAuth providers, LLM).
+// They are opt-in via query flags on /health so the default check stays fast,
+// and their results are reused for externalProbeTTL because /health is
+// public: otherwise every hit would call the IdPs and make a billed
+// embedding call.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+	"github.com/Abraxas-365/manifesto/internal/config"
+)
+
+// dependencyProbeTimeout bounds each external dependency probe
+const dependencyProbeTimeout = 5 * time.Second
+
+// externalProbeTTL is how long the result of an external probe is reused
+const externalProbeTTL = time.Minute
+
+// cachedProbe runs a probe at most once per externalProbeTTL. Concurrent
+// callers wait for the running probe and share its result.
+type cachedProbe[T any] struct {
+	mu        sync.Mutex
+	result    T
+	checkedAt time.Time
+}
+
+func (p *cachedProbe[T]) get(probe func() T) T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.checkedAt.IsZero() || time.Since(p.checkedAt) >= externalProbeTTL {
+		p.result = probe()
+		p.checkedAt = time.Now()
+	}
+	return p.result
+}
+
+// dependencyCheck is the result of probing one external dependency
+type dependencyCheck struct {
+	Status    string `json:"status"` // "healthy" or "degraded"
+	LatencyMS int64  `json:"latency_ms"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func newDependencyCheck(endpoint string, started time.Time, err error) dependencyCheck {
+	check := dependencyCheck{
+		Status:    "healthy",
+		LatencyMS: time.Since(started).Milliseconds(),
+		Endpoint:  endpoint,
+	}
+	if err != nil {
+		check.Status = "degraded"
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// checkOAuthProviders probes the token endpoint of every enabled OAuth
+// provider concurrently. OIDC token endpoints are taken from the discovery
+// document of each issuer.
+func checkOAuthProviders(ctx context.Context, cfg config.OAuthConfig) map[string]dependencyCheck {
+	client := &http.Client{Timeout: dependencyProbeTimeout}
+
+	probes := map[string]func() dependencyCheck{}
+	if cfg.Google.Enabled {
+		probes["google"] = func() dependencyCheck {
+			started := time.Now()
+			return newDependencyCheck(cfg.Google.TokenURL, started, probeEndpoint(ctx, client, cfg.Google.TokenURL))
+		}
+	}
+	if cfg.Microsoft.Enabled {
+		probes["microsoft"] = func() dependencyCheck {
+			started := time.Now()
+			return newDependencyCheck(cfg.Microsoft.TokenURL, started, probeEndpoint(ctx, client, cfg.Microsoft.TokenURL))
+		}
+	}
+	for _, oidc := range cfg.OIDC {
+		if oidc.IssuerURL == "" {
+			continue
+		}
+		probes["oidc_"+strings.ToLower(oidc.Key)] = func() dependencyCheck {
+			started := time.Now()
+			tokenURL, err := discoverTokenEndpoint(ctx, client, oidc.IssuerURL)
+			if err == nil {
+				err = probeEndpoint(ctx, client, tokenURL)
+			}
+			return newDependencyCheck(tokenURL, started, err)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]dependencyCheck, len(probes))
+	)
+	for name, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check := probe()
+			mu.Lock()
+			results[name] = check
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// probeEndpoint checks that an endpoint answers. Token endpoints reject a bare
+// GET with 4xx, which still proves they are reachable; only transport errors
+// and 5xx count as unhealthy.
+func probeEndpoint(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// discoverTokenEndpoint reads token_endpoint from the issuer's discovery document
+func discoverTokenEndpoint(ctx context.Context, client *http.Client, issuerURL string) (string, error) {
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("discovery returned status %d", resp.StatusCode)
+	}
+
+	var discovery struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return "", fmt.Errorf("invalid discovery document: %w", err)
+	}
+	if discovery.TokenEndpoint == "" {
+		return "", fmt.Errorf("discovery document has no token_endpoint")
+	}
+	return discovery.TokenEndpoint, nil
+}
+
+// checkLLM embeds a one-word text, the cheapest call that proves the
+// provider accepts our credentials and responds
+func checkLLM(ctx context.Context, embedder embedding.Embedder) dependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, dependencyProbeTimeout)
+	defer cancel()
+
+	started := time.Now()
+	_, err := embedder.EmbedQuery(ctx, "ping")
+	return newDependencyCheck("", started, err)
+}
@@ -0,0 +1,80 @@
+// cmd/readiness.go
+//
+// Liveness and readiness probes. /livez only proves the process answers;
+// /readyz tells load balancers whether to route traffic here: not before the
+// background services have started and DB and Redis are reachable, and no
+// longer once graceful shutdown has begun.
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// readinessProbeTimeout bounds the DB and Redis pings of /readyz, well under
+// the probe timeouts orchestrators use by default
+const readinessProbeTimeout = 2 * time.Second
+
+// readiness is the lifecycle half of /readyz: started once background
+// services are running, draining once shutdown begins
+type readiness struct {
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+func (r *readiness) markStarted() { r.started.Store(true) }
+
+func (r *readiness) markDraining() { r.draining.Store(true) }
+
+// state reports "starting", "draining" or "ready"
+func (r *readiness) state() string {
+	switch {
+	case r.draining.Load():
+		return "draining"
+	case !r.started.Load():
+		return "starting"
+	default:
+		return "ready"
+	}
+}
+
+// livezHandler answers 200 as long as the process can serve requests. It
+// checks no dependency, so an outage of DB or Redis never gets pods restarted.
+func livezHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "alive"})
+}
+
+// readyzHandler answers 200 only when the instance is started, not draining
+// and both DB and Redis answer a ping; otherwise 503 with the reasons.
+func readyzHandler(container *Container) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		state := container.ready.state()
+		if state != "ready" {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": state})
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), readinessProbeTimeout)
+		defer cancel()
+
+		result := fiber.Map{"status": "ready", "db": "healthy", "redis": "healthy"}
+		status := fiber.StatusOK
+
+		if err := container.DB.PingContext(ctx); err != nil {
+			result["db"] = "unhealthy"
+			result["db_error"] = err.Error()
+			result["status"] = "not_ready"
+			status = fiber.StatusServiceUnavailable
+		}
+		if err := container.Redis.Ping(ctx).Err(); err != nil {
+			result["redis"] = "unhealthy"
+			result["redis_error"] = err.Error()
+			result["status"] = "not_ready"
+			status = fiber.StatusServiceUnavailable
+		}
+
+		return c.Status(status).JSON(result)
+	}
+}
@@ -7,13 +7,19 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/config"
 	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/httpx"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth/authinfra"
 	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/Abraxas-365/manifesto/internal/logx/logxfiber"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
+	"github.com/Abraxas-365/manifesto/internal/tracex/tracexfiber"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
@@ -37,6 +43,27 @@ func main() {
 		logx.SetLevel(logx.LevelInfo)
 	}
 
+	// Tracing (no-op tracer unless TRACING_ENABLED)
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := tracex.Setup(context.Background(), tracex.Config{
+			ServiceName: cfg.Tracing.ServiceName,
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logx.Warnf("Tracing disabled: %v", err)
+		} else {
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := shutdownTracing(ctx); err != nil {
+					logx.Errorf("Error flushing traces: %v", err)
+				}
+			}()
+		}
+	}
+
 	logx.Info("🚀 Starting Manifesto API Server...")
 	logx.Infof("Environment: %s", cfg.Server.Environment)
 
@@ -54,15 +81,27 @@ func main() {
 		AppName:               "Manifesto API",
 		DisableStartupMessage: true,
 		ErrorHandler:          globalErrorHandler(cfg),
-		BodyLimit:             10 * 1024 * 1024, // 10MB for file uploads
-		IdleTimeout:           120,
-		EnablePrintRoutes:     false,
+		BodyLimit:             cfg.Server.MaxBodyLimit(), // per-group limits in setupMiddleware
+		// Bodies are read from the connection as handlers consume them, so
+		// uploads never sit in memory whole; httpx.BodyLimit enforces sizes
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		// c.IP() (rate limits, audit logs) reads ProxyHeader only on
+		// requests from the trusted load balancers
+		ProxyHeader:             cfg.Server.ProxyHeader,
+		EnableTrustedProxyCheck: len(cfg.Server.TrustedProxies) > 0,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		EnableIPValidation:      true,
+		IdleTimeout:             120,
+		EnablePrintRoutes:       false,
 	})
 
 	// 6. Global Middleware
-	setupMiddleware(app, cfg)
+	setupMiddleware(app, cfg, container)
 
 	// 7. Health Check & Info Endpoints
+	app.Get("/livez", livezHandler)
+	app.Get("/readyz", readyzHandler(container))
 	app.Get("/health", healthCheckHandler(container))
 	app.Get("/", infoHandler(cfg))
 	app.Get("/api/v1/docs", apiDocsHandler(cfg))
@@ -77,14 +116,14 @@ func main() {
 	printRouteSummary()
 
 	// 11. Start Server with Graceful Shutdown
-	startServer(app, cfg, cancel)
+	startServer(app, cfg, container, cancel)
 }
 
 // ============================================================================
 // Setup Functions
 // ============================================================================
 
-func setupMiddleware(app *fiber.App, cfg *config.Config) {
+func setupMiddleware(app *fiber.App, cfg *config.Config, container *Container) {
 	// Panic recovery
 	app.Use(recover.New(recover.Config{
 		EnableStackTrace: cfg.IsDevelopment(),
@@ -98,39 +137,68 @@ func setupMiddleware(app *fiber.App, cfg *config.Config) {
 		},
 	}))
 
-	// CORS
-	corsOrigins := "*"
-	if len(cfg.Server.CORSOrigins) > 0 {
-		corsOrigins = ""
-		for i, origin := range cfg.Server.CORSOrigins {
-			if i > 0 {
-				corsOrigins += ","
-			}
-			corsOrigins += origin
-		}
-	}
+	// HTTP server span (only when tracing is enabled)
+	app.Use(tracexfiber.New())
 
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     corsOrigins,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Request-ID",
-		AllowMethods:     "GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS",
-		AllowCredentials: true,
-		ExposeHeaders:    "X-Request-ID",
-	}))
+	// Request-scoped log fields (request_id; auth adds tenant_id / user_id)
+	app.Use(logxfiber.ContextLogger("X-Request-ID"))
 
-	// Request logger
-	logFormat := "${time} | ${status} | ${latency} | ${method} ${path}"
-	if cfg.IsDevelopment() {
-		logFormat += " | ${ip} | ${reqHeader:X-Request-ID}\n"
+	// CORS: "*" only without credentials (enforced by config validation);
+	// otherwise the request Origin is echoed back when it matches an allowed
+	// origin or wildcard subdomain
+	corsConfig := cors.Config{
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Request-ID, Idempotency-Key",
+		AllowMethods:     "GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS",
+		AllowCredentials: cfg.Server.CORSAllowCredentials,
+		ExposeHeaders:    "X-Request-ID, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Quota-Limit, X-Quota-Remaining, X-Quota-Reset, Retry-After, Idempotent-Replayed",
+	}
+	if cfg.Server.CORSAllowsAnyOrigin() {
+		corsConfig.AllowOrigins = "*"
 	} else {
-		logFormat += "\n"
+		corsConfig.AllowOriginsFunc = cfg.Server.CORSOriginMatcher()
 	}
-
-	app.Use(logger.New(logger.Config{
-		Format:     logFormat,
-		TimeFormat: "2006-01-02 15:04:05",
-		TimeZone:   "Local",
+	app.Use(cors.New(corsConfig))
+
+	// Request logger (structured, with sensitive headers and body fields redacted)
+	app.Use(logxfiber.New(logxfiber.Config{
+		Identity:   requestIdentity,
+		LogHeaders: cfg.IsDevelopment(),
+		LogBodies:  cfg.Server.LogHTTPBodies,
+		Skip: func(c *fiber.Ctx) bool {
+			switch c.Path() {
+			case "/health", "/livez", "/readyz":
+				return true
+			}
+			return false
+		},
 	}))
+
+	// Body limits and Content-Type per route group. With streamed bodies
+	// Fiber's own limit no longer applies, so every request is held to the
+	// JSON limit except multipart uploads, which get the upload limit. Auth
+	// endpoints take small JSON or form bodies and get a tighter one.
+	jsonLimit := httpx.BodyLimit(cfg.Server.JSONBodyLimit)
+	uploadLimit := httpx.BodyLimit(cfg.Server.UploadBodyLimit)
+	app.Use(func(c *fiber.Ctx) error {
+		if httpx.MediaType(c.Get(fiber.HeaderContentType)) == fiber.MIMEMultipartForm {
+			return uploadLimit(c)
+		}
+		return jsonLimit(c)
+	})
+	app.Use("/auth",
+		httpx.BodyLimit(cfg.Server.AuthBodyLimit),
+		httpx.RequireContentType(fiber.MIMEApplicationJSON, fiber.MIMEApplicationForm),
+	)
+
+	// Rate limiting (Redis sliding window, per IP / user)
+	if cfg.Auth.RateLimit.Enabled {
+		rateLimiter := auth.NewRateLimitMiddleware(
+			authinfra.NewRedisRateLimiter(container.Redis),
+			cfg.Auth.RateLimit.FailOpen,
+			auth.DefaultRateLimitRules(cfg.Auth.RateLimit)...,
+		)
+		app.Use(rateLimiter.Handler())
+	}
 }
 
 func registerRoutes(app *fiber.App, container *Container) {
@@ -138,8 +206,23 @@ func registerRoutes(app *fiber.App, container *Container) {
 
 	// Add your module routes here
 	// Example:
-	// api := app.Group("/api/v1")
+	// api := app.Group("/api/v1", httpx.RequireContentType(fiber.MIMEApplicationJSON))
 	// myModule.RegisterRoutes(api)
+	//
+	// File uploads stay outside the JSON group; multipart bodies get
+	// UploadBodyLimit and the uploader enforces STORAGE_UPLOAD_MAX_SIZE.
+	// The body streams from the connection; a "key" field needs files:write:
+	// uploader := fsx.NewUploader(container.FileSystem, nil, // or a ClamAV fsx.Scanner
+	// 	container.Config.Storage.UploadMaxSize, container.Config.Storage.UploadAllowedTypes)
+	// fsxapi.NewUploadHandlers(uploader).RegisterRoutes(app, authMiddleware) // POST /files/upload
+	//
+	// Large downloads go straight to S3 through presigned URLs:
+	// fsxapi.NewPresignHandlers(container.Presigner).RegisterRoutes(app, authMiddleware) // POST /files/presign/*
+	//
+	// LLM spend of the caller's tenant, when metering is on:
+	// if container.LLMUsage != nil {
+	// 	usagexapi.NewUsageHandlers(container.LLMUsage).RegisterRoutes(api, authMiddleware) // GET /usage/llm
+	// }
 
 	logx.Info("✅ All routes registered")
 }
@@ -150,6 +233,9 @@ func registerRoutes(app *fiber.App, container *Container) {
 
 // healthCheckHandler returns a health check handler
 func healthCheckHandler(container *Container) fiber.Handler {
+	var oauthProbe cachedProbe[map[string]dependencyCheck]
+	var llmProbe cachedProbe[dependencyCheck]
+
 	return func(c *fiber.Ctx) error {
 		health := fiber.Map{
 			"status":      "healthy",
@@ -157,6 +243,7 @@ func healthCheckHandler(container *Container) fiber.Handler {
 			"version":     container.Config.Server.BaseURL,
 			"environment": container.Config.Server.Environment,
 			"timestamp":   fmt.Sprintf("%d", c.Context().Time().Unix()),
+			"readiness":   container.ready.state(),
 		}
 
 		// Check database
@@ -189,6 +276,36 @@ func healthCheckHandler(container *Container) fiber.Handler {
 			}
 		}
 
+		// Check OAuth providers (optional - calls each IdP token endpoint at
+		// most once per externalProbeTTL)
+		if c.QueryBool("check_oauth", false) {
+			providers := oauthProbe.get(func() map[string]dependencyCheck {
+				return checkOAuthProviders(c.Context(), container.Config.OAuth)
+			})
+			for _, check := range providers {
+				if check.Status != "healthy" {
+					health["status"] = "degraded"
+				}
+			}
+			health["oauth"] = providers
+		}
+
+		// Check LLM provider (optional - makes a billed embedding call at most
+		// once per externalProbeTTL)
+		if c.QueryBool("check_llm", false) {
+			if container.Embedder == nil {
+				health["llm"] = dependencyCheck{Status: "not_configured"}
+			} else {
+				check := llmProbe.get(func() dependencyCheck {
+					return checkLLM(c.Context(), container.Embedder)
+				})
+				if check.Status != "healthy" {
+					health["status"] = "degraded"
+				}
+				health["llm"] = check
+			}
+		}
+
 		status := fiber.StatusOK
 		if health["status"] == "degraded" {
 			status = fiber.StatusServiceUnavailable
@@ -208,6 +325,8 @@ func infoHandler(cfg *config.Config) fiber.Handler {
 			"endpoints": fiber.Map{
 				"docs":   "/api/v1/docs",
 				"health": "/health",
+				"livez":  "/livez",
+				"readyz": "/readyz",
 			},
 		})
 	}
@@ -250,7 +369,7 @@ func globalErrorHandler(cfg *config.Config) fiber.ErrorHandler {
 			"ip":         c.IP(),
 			"request_id": c.Get("X-Request-ID"),
 			"user_agent": c.Get("User-Agent"),
-		}).Errorf("Request error: %v", err)
+		}).Errorf("Request error: %s", logx.Redact(err.Error()))
 
 		// If it's a Fiber error
 		if e, ok := err.(*fiber.Error); ok {
@@ -300,6 +419,20 @@ func globalErrorHandler(cfg *config.Config) fiber.ErrorHandler {
 // Utility Functions
 // ============================================================================
 
+// requestIdentity returns the tenant and user of an authenticated request for
+// the request log
+func requestIdentity(c *fiber.Ctx) (string, string) {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return "", ""
+	}
+	userID := ""
+	if authContext.UserID != nil {
+		userID = authContext.UserID.String()
+	}
+	return authContext.TenantID.String(), userID
+}
+
 // generateRequestID generates a unique request ID
 func generateRequestID() string {
 	return "req-" + randomString(16)
@@ -319,12 +452,14 @@ func randomString(n int) string {
 func printRouteSummary() {
 	logx.Info("📋 Route Summary:")
 	logx.Info("   ├─ Health: /health")
+	logx.Info("   ├─ Liveness: /livez")
+	logx.Info("   ├─ Readiness: /readyz")
 	logx.Info("   ├─ Info: /")
 	logx.Info("   └─ Docs: /api/v1/docs")
 }
 
 // startServer starts the server with graceful shutdown
-func startServer(app *fiber.App, cfg *config.Config, cancel context.CancelFunc) {
+func startServer(app *fiber.App, cfg *config.Config, container *Container, cancel context.CancelFunc) {
 	port := fmt.Sprintf("%d", cfg.Server.Port)
 
 	// Run server in a goroutine
@@ -342,11 +477,11 @@ func startServer(app *fiber.App, cfg *config.Config, cancel context.CancelFunc)
 	}()
 
 	// Graceful shutdown
-	gracefulShutdown(app, cancel)
+	gracefulShutdown(app, cfg, container, cancel)
 }
 
 // gracefulShutdown handles graceful server shutdown
-func gracefulShutdown(app *fiber.App, cancel context.CancelFunc) {
+func gracefulShutdown(app *fiber.App, cfg *config.Config, container *Container, cancel context.CancelFunc) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
@@ -355,13 +490,24 @@ func gracefulShutdown(app *fiber.App, cancel context.CancelFunc) {
 	logx.Infof("🛑 Received signal: %v", sig)
 	logx.Info("Shutting down gracefully...")
 
+	// Fail /readyz first and keep serving while load balancers notice and
+	// stop routing new requests here
+	container.ready.markDraining()
+	if delay := cfg.Server.ShutdownDrainDelay; delay > 0 {
+		logx.Infof("⏳ Draining for %s before closing listeners...", delay)
+		time.Sleep(delay)
+	}
+
 	// Cancel context to stop background services
 	cancel()
 
 	// Shutdown the server with timeout
-	if err := app.ShutdownWithTimeout(30); err != nil {
+	if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
 		logx.Errorf("Server forced to shutdown: %v", err)
 	}
 
+	// Wait for background services to finish their in-flight work
+	container.WaitBackgroundServices(cfg.Server.ShutdownTimeout)
+
 	logx.Info("✅ Server exited successfully")
 }
@@ -245,10 +245,13 @@ func exampleAgent(ctx context.Context, provider *aiopenai.OpenAIProvider) {
 	agent2 := agentx.New(*client, memoryx.NewInMemoryMemory("You are helpful."),
 		agentx.WithTools(tools),
 		agentx.WithOptions(llm.WithModel("gpt-4o-mini")),
+		agentx.WithStepEvents(),
 	)
 
 	err = agent2.StreamWithTools(ctx, "Calculate 10 + 5 using the calculator.", func(event agentx.StreamEvent) {
 		switch event.Type {
+		case agentx.EventStepStarted:
+			fmt.Printf("\n  [step %d]\n", event.Step)
 		case agentx.EventText:
 			fmt.Print(event.Content)
 		case agentx.EventToolCall:
@@ -257,6 +260,8 @@ func exampleAgent(ctx context.Context, provider *aiopenai.OpenAIProvider) {
 			fmt.Printf("  [tool_result] %s -> %s\n", event.ToolName, event.ToolOutput)
 		case agentx.EventError:
 			fmt.Printf("  [error] %v\n", event.Err)
+		case agentx.EventDone:
+			fmt.Printf("\n  [done] after %d steps\n", event.Step)
 		}
 	})
 	if err != nil {
@@ -219,6 +219,10 @@ func main() {
 	fmt.Println("  // Presigned URLs (S3 only):")
 	fmt.Println("  // url, _ := s3FS.GetPresignedDownloadURL(ctx, \"file.txt\", 15*time.Minute)")
 	fmt.Println("  // url, _ := s3FS.GetPresignedUploadURL(ctx, \"file.txt\", 15*time.Minute)")
+	fmt.Println("  //")
+	fmt.Println("  // Tenant-scoped presigned URLs (keys live under tenants/{tenantID}/):")
+	fmt.Println("  // presigner := fsx.NewTenantPresigner(s3FS, 15*time.Minute, time.Hour)")
+	fmt.Println("  // url, key, _ := presigner.DownloadURL(ctx, tenantID, \"invoices/1.pdf\", 0)")
 
 	fmt.Println("\nDone!")
 }
@@ -0,0 +1,106 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+// retryPolicy is the backoff of retried batches; the attempts come from
+// EmbeddingOptions.MaxRetries
+var retryPolicy = llm.DefaultRetryPolicy()
+
+// BatchFunc embeds a single batch of documents with one provider request.
+// It must return one embedding per document, in order.
+type BatchFunc func(ctx context.Context, batch []string) ([]Embedding, error)
+
+// EmbedInBatches splits documents into batches of options.BatchSize, embeds
+// each one with embed and returns the embeddings in the order of documents.
+//
+// Batches failing with a retryable error (see IsRetryable) are retried up to
+// options.MaxRetries times by llm.Retry with the default backoff; once retries
+// run out the error is llm's RETRIES_EXHAUSTED or RATE_LIMIT_EXHAUSTED
+// wrapping the last failure. The Usage of every batch is summed, and each
+// returned Embedding carries that total so callers read the cost of the whole
+// call from any of them.
+func EmbedInBatches(ctx context.Context, documents []string, options *EmbeddingOptions, embed BatchFunc) ([]Embedding, error) {
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(documents)
+	}
+
+	embeddings := make([]Embedding, 0, len(documents))
+	var usage Usage
+
+	for start := 0; start < len(documents); start += batchSize {
+		end := min(start+batchSize, len(documents))
+
+		batch, err := embedWithRetry(ctx, documents[start:end], options.MaxRetries, embed)
+		if err != nil {
+			return nil, err
+		}
+
+		// Providers report the usage of the whole request on every embedding
+		if len(batch) > 0 {
+			usage.PromptTokens += batch[0].Usage.PromptTokens
+			usage.TotalTokens += batch[0].Usage.TotalTokens
+		}
+		embeddings = append(embeddings, batch...)
+	}
+
+	for i := range embeddings {
+		embeddings[i].Usage = usage
+	}
+
+	return embeddings, nil
+}
+
+// embedWithRetry embeds one batch, retrying it with llm.Retry
+func embedWithRetry(ctx context.Context, batch []string, maxRetries int, embed BatchFunc) ([]Embedding, error) {
+	if maxRetries <= 0 {
+		return embed(ctx, batch)
+	}
+
+	policy := retryPolicy
+	policy.MaxAttempts = maxRetries + 1
+
+	var embeddings []Embedding
+	err := llm.Retry(ctx, policy, classifyRetry, func() error {
+		var err error
+		embeddings, err = embed(ctx, batch)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// classifyRetry is the llm.ClassifyFunc for IsRetryable errors
+func classifyRetry(err error) (llm.RetryClass, time.Duration) {
+	if !IsRetryable(err) {
+		return llm.RetryNever, 0
+	}
+	if e, ok := errx.AsError(err); ok && e.HTTPStatus == http.StatusTooManyRequests {
+		return llm.RetryRateLimited, 0
+	}
+	return llm.RetryTransient, 0
+}
+
+// IsRetryable reports whether err is a transient provider failure: a rate
+// limit (429) or a server-side error (5xx). Context cancellation is not.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var e *errx.Error
+	if !errx.As(err, &e) {
+		return false
+	}
+	return e.HTTPStatus == http.StatusTooManyRequests || e.HTTPStatus >= http.StatusInternalServerError
+}
@@ -0,0 +1,182 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+var testErrors = errx.NewRegistry("EMBEDDING_TEST")
+
+var (
+	errRateLimited = testErrors.Register("RATE_LIMITED", errx.TypeExternal, http.StatusTooManyRequests, "rate limited")
+	errUnavailable = testErrors.Register("UNAVAILABLE", errx.TypeExternal, http.StatusServiceUnavailable, "unavailable")
+	errBadRequest  = testErrors.Register("BAD_REQUEST", errx.TypeValidation, http.StatusBadRequest, "bad request")
+)
+
+func fastRetries(t *testing.T) {
+	saved := retryPolicy
+	retryPolicy = llm.RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	t.Cleanup(func() { retryPolicy = saved })
+}
+
+// embedLengths embeds each document as its length, reporting one token per
+// document as the usage of the request
+func embedLengths(batches *[][]string) BatchFunc {
+	return func(_ context.Context, batch []string) ([]Embedding, error) {
+		*batches = append(*batches, batch)
+		embeddings := make([]Embedding, len(batch))
+		for i, doc := range batch {
+			embeddings[i] = Embedding{
+				Vector: []float32{float32(len(doc))},
+				Usage:  Usage{PromptTokens: len(batch), TotalTokens: len(batch)},
+			}
+		}
+		return embeddings, nil
+	}
+}
+
+func TestEmbedInBatches(t *testing.T) {
+	documents := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+
+	tests := []struct {
+		batchSize   int
+		wantBatches int
+	}{
+		{2, 3},
+		{5, 1},
+		{10, 1},
+		{0, 1},
+	}
+
+	for _, tt := range tests {
+		var batches [][]string
+		embeddings, err := EmbedInBatches(context.Background(), documents, &EmbeddingOptions{BatchSize: tt.batchSize}, embedLengths(&batches))
+		if err != nil {
+			t.Fatalf("batchSize=%d: %v", tt.batchSize, err)
+		}
+		if len(batches) != tt.wantBatches {
+			t.Errorf("batchSize=%d: %d requests, want %d", tt.batchSize, len(batches), tt.wantBatches)
+		}
+		if len(embeddings) != len(documents) {
+			t.Fatalf("batchSize=%d: %d embeddings, want %d", tt.batchSize, len(embeddings), len(documents))
+		}
+		for i, e := range embeddings {
+			if int(e.Vector[0]) != len(documents[i]) {
+				t.Errorf("batchSize=%d: embedding %d = %v, want the order of documents", tt.batchSize, i, e.Vector)
+			}
+			if e.Usage.TotalTokens != len(documents) {
+				t.Errorf("batchSize=%d: embedding %d usage = %+v, want the total of every request", tt.batchSize, i, e.Usage)
+			}
+		}
+	}
+}
+
+func TestEmbedInBatchesRetries(t *testing.T) {
+	fastRetries(t)
+	ctx := context.Background()
+	documents := []string{"a", "bb", "ccc"}
+
+	// A transient failure of the second batch is retried, the first batch is not resent
+	var batches [][]string
+	embed := embedLengths(&batches)
+	failures := 2
+	flaky := func(ctx context.Context, batch []string) ([]Embedding, error) {
+		if batch[0] == "ccc" && failures > 0 {
+			failures--
+			return nil, testErrors.New(errUnavailable)
+		}
+		return embed(ctx, batch)
+	}
+	embeddings, err := EmbedInBatches(ctx, documents, &EmbeddingOptions{BatchSize: 2, MaxRetries: 2}, flaky)
+	if err != nil || len(embeddings) != 3 {
+		t.Fatalf("EmbedInBatches = %d embeddings, %v; want the retried batch to succeed", len(embeddings), err)
+	}
+	if len(batches) != 2 {
+		t.Errorf("successful requests = %v, want each batch once", batches)
+	}
+
+	tests := []struct {
+		name       string
+		err        *errx.ErrorCode
+		maxRetries int
+		wantCalls  int
+		wantCode   *errx.ErrorCode
+	}{
+		{"rate limited", errRateLimited, 2, 3, llm.ErrRateLimitExhausted},
+		{"unavailable", errUnavailable, 1, 2, llm.ErrRetriesExhausted},
+		{"not retryable", errBadRequest, 2, 1, errBadRequest},
+		{"no retries", errUnavailable, 0, 1, errUnavailable},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		failing := func(context.Context, []string) ([]Embedding, error) {
+			calls++
+			return nil, testErrors.New(tt.err)
+		}
+		_, err := EmbedInBatches(ctx, documents, &EmbeddingOptions{MaxRetries: tt.maxRetries}, failing)
+		if calls != tt.wantCalls {
+			t.Errorf("%s: %d calls, want %d", tt.name, calls, tt.wantCalls)
+		}
+		if !errors.Is(err, tt.wantCode) || !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %s wrapping %s", tt.name, err, tt.wantCode.Code, tt.err.Code)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{testErrors.New(errRateLimited), true},
+		{testErrors.New(errUnavailable), true},
+		{fmt.Errorf("batch 2: %w", testErrors.New(errUnavailable)), true},
+		{testErrors.New(errBadRequest), false},
+		{errors.New("connection reset"), false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{testErrors.NewWithCause(errUnavailable, context.DeadlineExceeded), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestEmbedInBatchesStopsOnCancel(t *testing.T) {
+	saved := retryPolicy
+	retryPolicy = llm.RetryPolicy{BaseDelay: time.Hour, MaxDelay: time.Hour}
+	t.Cleanup(func() { retryPolicy = saved })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	failing := func(context.Context, []string) ([]Embedding, error) {
+		calls++
+		cancel()
+		return nil, testErrors.New(errUnavailable)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := EmbedInBatches(ctx, []string{"a"}, &EmbeddingOptions{MaxRetries: 3}, failing)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || calls != 1 || errors.Is(err, llm.ErrRetriesExhausted) {
+			t.Errorf("cancelled EmbedInBatches = %v after %d calls, want the batch error without retries", err, calls)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("EmbedInBatches kept waiting after the context was cancelled")
+	}
+}
@@ -0,0 +1,18 @@
+package embedding
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+var (
+	errorRegistry = errx.NewRegistry("EMBEDDING")
+
+	ErrUnknownModel = errorRegistry.Register(
+		"UNKNOWN_MODEL",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"No price configured for embedding model",
+	)
+)
@@ -0,0 +1,56 @@
+package embedding
+
+// PricePerMillionTokens maps embedding models to their price in USD per
+// 1M input tokens. Add entries for other models as needed.
+var PricePerMillionTokens = map[string]float64{
+	// OpenAI
+	"text-embedding-3-small": 0.02,
+	"text-embedding-3-large": 0.13,
+	"text-embedding-ada-002": 0.10,
+
+	// Mistral
+	"mistral-embed": 0.10,
+
+	// Bedrock
+	"amazon.titan-embed-text-v1":   0.10,
+	"amazon.titan-embed-text-v2:0": 0.02,
+}
+
+// Estimate returns the estimated token count and cost in USD of embedding
+// documents with model, without calling the provider API. Use it to warn or
+// ask for confirmation before large batch jobs. Tokens are counted with a
+// heuristic (1 token ≈ 4 chars), good enough for budgeting but not for exact
+// billing; use EstimateWithCounter for exact figures.
+//
+// A model missing from PricePerMillionTokens returns ErrUnknownModel.
+func Estimate(documents []string, model string) (tokens int, costUSD float64, err error) {
+	return EstimateWithCounter(documents, model, nil)
+}
+
+// EstimateWithCounter is Estimate with counter counting the tokens of a single
+// document; pass the tokenizer used for chunking (e.g. a
+// document.TokenCounter backed by tiktoken). A nil counter falls back to the
+// heuristic of Estimate.
+func EstimateWithCounter(documents []string, model string, counter func(text string) int) (tokens int, costUSD float64, err error) {
+	price, ok := PricePerMillionTokens[model]
+	if !ok {
+		return 0, 0, errorRegistry.New(ErrUnknownModel).WithDetail("model", model)
+	}
+
+	if counter == nil {
+		counter = charBasedTokenCount
+	}
+	for _, doc := range documents {
+		tokens += counter(doc)
+	}
+
+	return tokens, float64(tokens) * price / 1_000_000, nil
+}
+
+// charBasedTokenCount estimates tokens as 1 per 4 characters
+func charBasedTokenCount(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,54 @@
+package embedding
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+func TestEstimate(t *testing.T) {
+	documents := []string{"", "abc", "abcd", "abcde", strings.Repeat("x", 4000)}
+
+	tokens, cost, err := Estimate(documents, "text-embedding-3-small")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0 + 1 + 1 + 2 + 1000
+	if tokens != 1004 {
+		t.Errorf("tokens = %d, want 1004", tokens)
+	}
+	if want := 1004 * 0.02 / 1_000_000; math.Abs(cost-want) > 1e-12 {
+		t.Errorf("cost = %g, want %g", cost, want)
+	}
+
+	if tokens, cost, err := Estimate(nil, "text-embedding-3-large"); err != nil || tokens != 0 || cost != 0 {
+		t.Errorf("Estimate(nil) = %d, %g, %v; want zero", tokens, cost, err)
+	}
+}
+
+func TestEstimateWithCounter(t *testing.T) {
+	words := func(text string) int { return len(strings.Fields(text)) }
+
+	tokens, cost, err := EstimateWithCounter([]string{"one two", "three"}, "text-embedding-3-large", words)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 3 {
+		t.Errorf("tokens = %d, want the counter's 3", tokens)
+	}
+	if want := 3 * 0.13 / 1_000_000; math.Abs(cost-want) > 1e-12 {
+		t.Errorf("cost = %g, want %g", cost, want)
+	}
+}
+
+func TestEstimateUnknownModel(t *testing.T) {
+	_, _, err := Estimate([]string{"text"}, "no-such-model")
+	if !errx.Is(err, ErrUnknownModel) {
+		t.Fatalf("error = %v, want %s", err, ErrUnknownModel.Code)
+	}
+	if e, _ := errx.AsError(err); e.Details["model"] != "no-such-model" {
+		t.Errorf("details = %v, want the model", e.Details)
+	}
+}
@@ -10,6 +10,14 @@ type EmbeddingOptions struct {
 
 	// User is an optional user identifier for tracking and rate limiting
 	User string
+
+	// BatchSize is the maximum number of documents sent per provider request;
+	// 0 sends all documents in a single request
+	BatchSize int
+
+	// MaxRetries is how many times a batch is retried on rate limits and
+	// transient server errors
+	MaxRetries int
 }
 
 // Option is a function type to modify EmbeddingOptions
@@ -36,10 +44,26 @@ func WithUser(user string) Option {
 	}
 }
 
+// WithBatchSize sets the maximum number of documents per provider request
+func WithBatchSize(size int) Option {
+	return func(o *EmbeddingOptions) {
+		o.BatchSize = size
+	}
+}
+
+// WithMaxRetries sets how many times a failed batch is retried
+func WithMaxRetries(retries int) Option {
+	return func(o *EmbeddingOptions) {
+		o.MaxRetries = retries
+	}
+}
+
 // DefaultOptions returns the default embedding options
 func DefaultOptions() *EmbeddingOptions {
 	return &EmbeddingOptions{
 		// Default model will be provider-specific
 		Dimensions: 0, // Default to model's default dimensions
+		BatchSize:  100,
+		MaxRetries: 3,
 	}
 }
@@ -0,0 +1,70 @@
+package embedding
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ErrDimensionMismatch is returned when comparing vectors of different lengths
+var ErrDimensionMismatch = errors.New("embedding dimensions do not match")
+
+// ErrEmptyVector is returned when comparing an embedding without a vector
+var ErrEmptyVector = errors.New("embedding vector is empty")
+
+// ScoredIndex is a candidate position with its similarity to the query
+type ScoredIndex struct {
+	Index int
+	Score float32
+}
+
+// CosineSimilarity returns the cosine similarity of a and b, in [-1, 1].
+// A zero vector has no direction and scores 0 against anything.
+func CosineSimilarity(a, b Embedding) (float32, error) {
+	if len(a.Vector) == 0 || len(b.Vector) == 0 {
+		return 0, ErrEmptyVector
+	}
+	if len(a.Vector) != len(b.Vector) {
+		return 0, fmt.Errorf("%w: %d != %d", ErrDimensionMismatch, len(a.Vector), len(b.Vector))
+	}
+
+	// Accumulate in float64 to keep precision on high-dimensional vectors
+	var dot, normA, normB float64
+	for i := range a.Vector {
+		x, y := float64(a.Vector[i]), float64(b.Vector[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB))), nil
+}
+
+// TopK returns the k candidates most similar to query, sorted by descending
+// similarity; ties keep candidate order. k <= 0 or larger than the number of
+// candidates returns every candidate.
+func TopK(query Embedding, candidates []Embedding, k int) ([]ScoredIndex, error) {
+	scored := make([]ScoredIndex, len(candidates))
+	for i, candidate := range candidates {
+		score, err := CosineSimilarity(query, candidate)
+		if err != nil {
+			return nil, fmt.Errorf("candidate %d: %w", i, err)
+		}
+		scored[i] = ScoredIndex{Index: i, Score: score}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	if k > 0 && k < len(scored) {
+		scored = scored[:k]
+	}
+
+	return scored, nil
+}
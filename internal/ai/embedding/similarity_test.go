@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func vec(values ...float32) Embedding {
+	return Embedding{Vector: values}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Embedding
+		want float32
+	}{
+		{name: "identical", a: vec(1, 2, 3), b: vec(1, 2, 3), want: 1},
+		{name: "scaled", a: vec(1, 2, 3), b: vec(2, 4, 6), want: 1},
+		{name: "orthogonal", a: vec(1, 0), b: vec(0, 1), want: 0},
+		{name: "opposite", a: vec(1, 1), b: vec(-1, -1), want: -1},
+		{name: "zero vector", a: vec(0, 0), b: vec(1, 1), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CosineSimilarity(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(float64(got-tt.want)) > 1e-6 {
+				t.Errorf("CosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCosineSimilarityErrors(t *testing.T) {
+	if _, err := CosineSimilarity(vec(1, 2), vec(1, 2, 3)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if _, err := CosineSimilarity(vec(), vec(1)); !errors.Is(err, ErrEmptyVector) {
+		t.Errorf("expected ErrEmptyVector, got %v", err)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	query := vec(1, 0)
+	candidates := []Embedding{
+		vec(0, 1),  // 0
+		vec(1, 0),  // 1
+		vec(1, 1),  // ~0.707
+		vec(-1, 0), // -1
+		vec(2, 0),  // 1, ties with index 1
+	}
+
+	got, err := TopK(query, candidates, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantIndices := []int{1, 4, 2}
+	if len(got) != len(wantIndices) {
+		t.Fatalf("TopK() returned %d results, want %d", len(got), len(wantIndices))
+	}
+	for i, want := range wantIndices {
+		if got[i].Index != want {
+			t.Errorf("result %d: index = %d, want %d", i, got[i].Index, want)
+		}
+	}
+
+	all, err := TopK(query, candidates, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != len(candidates) {
+		t.Errorf("TopK(k=0) returned %d results, want %d", len(all), len(candidates))
+	}
+
+	if _, err := TopK(query, []Embedding{vec(1, 2, 3)}, 1); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+}
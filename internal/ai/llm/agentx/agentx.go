@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/ai/llm"
 	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
@@ -18,8 +20,17 @@ type Agent struct {
 	tools              *toolx.ToolxClient
 	memory             memoryx.Memory
 	options            []llm.Option
-	maxAutoIterations  int // Max iterations with "auto" tool choice
-	maxTotalIterations int // Hard limit to prevent infinite loops
+	maxAutoIterations  int  // Max iterations with "auto" tool choice
+	maxTotalIterations int  // Hard limit to prevent infinite loops
+	stepEvents         bool // Emit EventStepStarted/EventDone in StreamWithTools
+	toolConcurrency    int  // Tool calls of one turn run concurrently, see WithToolConcurrency
+	toolFailFast       bool // Cancel the other tool calls of a turn on the first failure
+
+	systemPrompt    string            // Template set with WithSystemPrompt
+	promptVariables map[string]string // Values for the system prompt placeholders
+
+	usageMu      sync.Mutex
+	lastRunUsage llm.Usage // Usage of the most recent run, see LastRunUsage
 }
 
 // AgentOption configures an Agent
@@ -53,6 +64,15 @@ func WithMaxTotalIterations(max int) AgentOption {
 	}
 }
 
+// WithStepEvents makes StreamWithTools also emit EventStepStarted at the start
+// of every LLM turn and EventDone with the final answer, so UIs can render the
+// agent's progress step by step
+func WithStepEvents() AgentOption {
+	return func(a *Agent) {
+		a.stepEvents = true
+	}
+}
+
 // New creates a new agent
 func New(client llm.Client, memory memoryx.Memory, opts ...AgentOption) *Agent {
 	agent := &Agent{
@@ -60,17 +80,27 @@ func New(client llm.Client, memory memoryx.Memory, opts ...AgentOption) *Agent {
 		memory:             memory,
 		maxAutoIterations:  3,  // Default: 3 "auto" iterations
 		maxTotalIterations: 10, // Hard limit for safety
+		toolConcurrency:    defaultToolConcurrency,
 	}
 
 	for _, opt := range opts {
 		opt(agent)
 	}
 
+	if agent.systemPrompt != "" {
+		agent.memory = &systemPromptMemory{
+			inner:  memory,
+			prompt: RenderPrompt(agent.systemPrompt, agent.promptVariables),
+		}
+	}
+
 	return agent
 }
 
 // Run processes a user message and returns the final response
 func (a *Agent) Run(ctx context.Context, userInput string) (string, error) {
+	a.resetRunUsage()
+
 	// Add user message to memory
 	if err := a.memory.Add(llm.NewUserMessage(userInput)); err != nil {
 		return "", fmt.Errorf("failed to add user message: %w", err)
@@ -97,6 +127,7 @@ func (a *Agent) Run(ctx context.Context, userInput string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("LLM error: %w", err)
 	}
+	a.addRunUsage(response.Usage)
 
 	// Add the response to memory
 	if err := a.memory.Add(response.Message); err != nil {
@@ -111,9 +142,12 @@ func (a *Agent) Run(ctx context.Context, userInput string) (string, error) {
 	return response.Message.Content, nil
 }
 
-// RunStream streams the agent's initial response
-// Note: This doesn't handle tool calls in streaming mode
+// RunStream streams the agent's initial response.
+// Note: This doesn't handle tool calls in streaming mode; use StreamWithTools
+// to run the full tool loop while streaming.
 func (a *Agent) RunStream(ctx context.Context, userInput string) (llm.Stream, error) {
+	a.resetRunUsage()
+
 	// Add user message to memory
 	if err := a.memory.Add(llm.NewUserMessage(userInput)); err != nil {
 		return nil, fmt.Errorf("failed to add user message: %w", err)
@@ -150,18 +184,21 @@ func (a *Agent) handleToolCallsWithLimit(ctx context.Context, toolCalls []llm.To
 		return "", fmt.Errorf("maximum total iterations (%d) exceeded", a.maxTotalIterations)
 	}
 
-	// Process each tool call
-	for _, tc := range toolCalls {
-		// Call the tool
-		toolResponse, err := a.tools.Call(ctx, tc)
-		if err != nil {
-			return "", fmt.Errorf("tool execution error: %w", err)
+	// Run the tool calls and add their responses to memory in request order
+	err := traceToolRound(ctx, iteration, toolCalls, func(ctx context.Context) error {
+		results, toolErr := a.executeToolCalls(ctx, toolCalls)
+		for _, toolResponse := range toolResponses(results) {
+			if err := a.memory.Add(toolResponse); err != nil {
+				return fmt.Errorf("failed to add tool response: %w", err)
+			}
 		}
-
-		// Add tool response to memory
-		if err := a.memory.Add(toolResponse); err != nil {
-			return "", fmt.Errorf("failed to add tool response: %w", err)
+		if toolErr != nil {
+			return fmt.Errorf("tool execution error: %w", toolErr)
 		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
 	// Get messages from memory
@@ -192,6 +229,7 @@ func (a *Agent) handleToolCallsWithLimit(ctx context.Context, toolCalls []llm.To
 	if err != nil {
 		return "", fmt.Errorf("LLM error: %w", err)
 	}
+	a.addRunUsage(response.Usage)
 
 	// Add the response to memory
 	if err := a.memory.Add(response.Message); err != nil {
@@ -206,12 +244,37 @@ func (a *Agent) handleToolCallsWithLimit(ctx context.Context, toolCalls []llm.To
 	return response.Message.Content, nil
 }
 
+// LastRunUsage returns the token usage summed over every LLM call of the most
+// recent Run, EvaluateWithTools, RunStream or StreamWithTools call.
+//
+// Usage is only known for non-streaming calls: llm.Stream does not report it
+// (and OpenAI streams often omit it), so streamed runs report zero counts
+// rather than an error. Fields a provider does not return also stay at zero.
+func (a *Agent) LastRunUsage() llm.Usage {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	return a.lastRunUsage
+}
+
+func (a *Agent) resetRunUsage() {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	a.lastRunUsage = llm.Usage{}
+}
+
+func (a *Agent) addRunUsage(u llm.Usage) {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	a.lastRunUsage = a.lastRunUsage.Add(u)
+}
+
 // getToolsList converts the tools to LLM-compatible format
 func (a *Agent) getToolsList() []llm.Tool {
 	return a.tools.GetTools()
 }
 
-// ClearMemory resets the conversation but keeps the system prompt
+// ClearMemory resets the conversation but keeps the system prompt: the one set
+// with WithSystemPrompt, or else the one the memory keeps on Clear
 func (a *Agent) ClearMemory() error {
 	return a.memory.Clear()
 }
@@ -229,7 +292,19 @@ func (a *Agent) Messages() ([]llm.Message, error) {
 // StreamWithTools streams the full agent loop including tool calls.
 // The handler receives structured StreamEvents so the caller can react to
 // text chunks, tool invocations, and tool results independently.
+//
+// Each turn is streamed; when the assembled assistant message contains tool
+// calls they are executed, their results appended to memory, and a new stream
+// is started. The loop ends on the first turn without tool calls. Tool choice
+// is forced to "none" after maxAutoIterations and the whole run is capped at
+// maxTotalIterations.
+//
+// Every event carries the 1-based Step it belongs to. With WithStepEvents the
+// handler also receives EventStepStarted before each turn and EventDone once
+// the final answer is complete.
 func (a *Agent) StreamWithTools(ctx context.Context, userInput string, handler StreamHandler) error {
+	a.resetRunUsage()
+
 	if err := a.memory.Add(llm.NewUserMessage(userInput)); err != nil {
 		return fmt.Errorf("failed to add user message: %w", err)
 	}
@@ -242,6 +317,11 @@ func (a *Agent) StreamWithTools(ctx context.Context, userInput string, handler S
 
 		// Build options — force tools off after maxAutoIterations
 		options := a.buildOptions(iteration)
+		step := iteration + 1
+
+		if a.stepEvents {
+			handler(StreamEvent{Type: EventStepStarted, Step: step})
+		}
 
 		// ── 1. Stream the LLM response ────────────────────────────────────
 		stream, err := a.client.ChatStream(ctx, messages, options...)
@@ -249,7 +329,7 @@ func (a *Agent) StreamWithTools(ctx context.Context, userInput string, handler S
 			return fmt.Errorf("stream error: %w", err)
 		}
 
-		assistantMsg, err := a.consumeStream(ctx, stream, handler)
+		assistantMsg, err := a.consumeStream(ctx, stream, step, handler)
 		stream.Close()
 		if err != nil {
 			return err
@@ -261,16 +341,16 @@ func (a *Agent) StreamWithTools(ctx context.Context, userInput string, handler S
 		}
 
 		// ── 2. No tool calls → we're done ─────────────────────────────────
-		if len(assistantMsg.ToolCalls) == 0 {
+		if len(assistantMsg.ToolCalls) == 0 || a.tools == nil {
+			if a.stepEvents {
+				usage := a.LastRunUsage()
+				handler(StreamEvent{Type: EventDone, Step: step, Content: assistantMsg.Content, Usage: &usage})
+			}
 			return nil
 		}
 
 		// ── 3. Execute tools, emit events for each ────────────────────────
-		if a.tools == nil {
-			return nil
-		}
-
-		if err := a.executeAndEmitTools(ctx, assistantMsg.ToolCalls, handler); err != nil {
+		if err := a.executeAndEmitTools(ctx, assistantMsg.ToolCalls, step, handler); err != nil {
 			return err
 		}
 
@@ -282,13 +362,17 @@ func (a *Agent) StreamWithTools(ctx context.Context, userInput string, handler S
 
 // consumeStream drains a Stream, forwards text chunks as EventText events,
 // accumulates tool call deltas, and returns the fully-assembled Message.
-func (a *Agent) consumeStream(ctx context.Context, stream llm.Stream, handler StreamHandler) (llm.Message, error) {
+func (a *Agent) consumeStream(ctx context.Context, stream llm.Stream, step int, handler StreamHandler) (llm.Message, error) {
 	var (
 		contentBuf strings.Builder
-		toolCalls  []llm.ToolCall // final snapshot from the stream's internal accumulator
+		toolCalls  []llm.ToolCall // accumulated by index across chunks
 	)
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return llm.Message{}, err
+		}
+
 		chunk, err := stream.Next()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
@@ -302,12 +386,13 @@ func (a *Agent) consumeStream(ctx context.Context, stream llm.Stream, handler St
 			contentBuf.WriteString(chunk.Content)
 			handler(StreamEvent{
 				Type:    EventText,
+				Step:    step,
 				Content: chunk.Content,
 			})
 		}
 
-		if len(chunk.ToolCalls) > 0 {
-			toolCalls = chunk.ToolCalls
+		for i, tc := range chunk.ToolCalls {
+			toolCalls = mergeToolCallDelta(toolCalls, i, tc)
 		}
 	}
 
@@ -318,28 +403,43 @@ func (a *Agent) consumeStream(ctx context.Context, stream llm.Stream, handler St
 	}, nil
 }
 
-// executeAndEmitTools runs every tool call sequentially, emits before/after events,
-// and adds each result to memory so the next LLM call has full context.
-func (a *Agent) executeAndEmitTools(ctx context.Context, toolCalls []llm.ToolCall, handler StreamHandler) error {
+// executeAndEmitTools runs the tool calls (concurrently when there are several),
+// emits before/after events, and adds each result to memory so the next LLM
+// call has full context.
+func (a *Agent) executeAndEmitTools(ctx context.Context, toolCalls []llm.ToolCall, step int, handler StreamHandler) error {
+	return traceToolRound(ctx, step, toolCalls, func(ctx context.Context) error {
+		return a.emitTools(ctx, toolCalls, step, handler)
+	})
+}
+
+// emitTools is the body of executeAndEmitTools
+func (a *Agent) emitTools(ctx context.Context, toolCalls []llm.ToolCall, step int, handler StreamHandler) error {
+	if len(toolCalls) > 1 {
+		return a.emitParallelTools(ctx, toolCalls, step, handler)
+	}
+
 	for _, tc := range toolCalls {
 		// Notify caller: tool is about to run
 		handler(StreamEvent{
 			Type:       EventToolCall,
+			Step:       step,
 			ToolCallID: tc.ID,
 			ToolName:   tc.Function.Name,
 			ToolInput:  tc.Function.Arguments,
 		})
 
 		// Execute
-		toolMsg, err := a.tools.Call(ctx, tc)
+		result := a.timedCallTool(ctx, tc)
+		toolMsg, err := result.msg, result.err
 		if err != nil {
-			handler(StreamEvent{Type: EventError, Err: err})
+			handler(StreamEvent{Type: EventError, Step: step, Err: err})
 			return fmt.Errorf("tool %q failed: %w", tc.Function.Name, err)
 		}
 
 		// Notify caller: tool finished
 		handler(StreamEvent{
 			Type:       EventToolResult,
+			Step:       step,
 			ToolCallID: tc.ID,
 			ToolName:   tc.Function.Name,
 			ToolOutput: toolMsg.Content,
@@ -353,6 +453,46 @@ func (a *Agent) executeAndEmitTools(ctx context.Context, toolCalls []llm.ToolCal
 	return nil
 }
 
+// emitParallelTools runs several tool calls concurrently. The handler is only
+// called from this goroutine: all EventToolCall events first, then one
+// EventToolResult or EventError per call in request order.
+func (a *Agent) emitParallelTools(ctx context.Context, toolCalls []llm.ToolCall, step int, handler StreamHandler) error {
+	for _, tc := range toolCalls {
+		handler(StreamEvent{
+			Type:       EventToolCall,
+			Step:       step,
+			ToolCallID: tc.ID,
+			ToolName:   tc.Function.Name,
+			ToolInput:  tc.Function.Arguments,
+		})
+	}
+
+	results := a.runToolCalls(ctx, toolCalls)
+	for i, r := range results {
+		tc := toolCalls[i]
+		switch {
+		case r.skipped:
+			continue
+		case r.err != nil:
+			handler(StreamEvent{Type: EventError, Step: step, Err: fmt.Errorf("tool %q failed: %w", tc.Function.Name, r.err)})
+			continue
+		}
+
+		handler(StreamEvent{
+			Type:       EventToolResult,
+			Step:       step,
+			ToolCallID: tc.ID,
+			ToolName:   tc.Function.Name,
+			ToolOutput: r.msg.Content,
+		})
+		if err := a.memory.Add(r.msg); err != nil {
+			return fmt.Errorf("failed to add tool result: %w", err)
+		}
+	}
+
+	return toolRoundError(toolCalls, results)
+}
+
 // buildOptions constructs the LLM option slice for a given iteration.
 // After maxAutoIterations it forces tool_choice=none to break the loop.
 func (a *Agent) buildOptions(iteration int) []llm.Option {
@@ -378,34 +518,32 @@ func (a *Agent) buildOptions(iteration int) []llm.Option {
 	return options
 }
 
-// mergeToolCallDelta assembles streaming tool-call deltas into complete ToolCalls.
-// OpenAI streams tool call arguments across multiple chunks identified by index/ID.
-func mergeToolCallDelta(existing []llm.ToolCall, delta llm.ToolCall) []llm.ToolCall {
-	// If we already have a call with this ID, append to its arguments
-	for i, tc := range existing {
-		if tc.ID == delta.ID {
-			existing[i].Function.Name += delta.Function.Name
-			existing[i].Function.Arguments += delta.Function.Arguments
-			return existing
-		}
+// mergeToolCallDelta folds the tool call at position index of a stream chunk
+// into the accumulated calls. Providers report tool calls on each chunk as the
+// calls accumulated so far (by index), so a later chunk supersedes the entry at
+// the same index; the result is copied so providers can keep mutating their
+// own buffers.
+func mergeToolCallDelta(existing []llm.ToolCall, index int, delta llm.ToolCall) []llm.ToolCall {
+	for len(existing) <= index {
+		existing = append(existing, llm.ToolCall{Type: "function"})
 	}
-	// New tool call — only start one if it has an ID (first delta for that call)
+
+	current := &existing[index]
 	if delta.ID != "" {
-		return append(existing, llm.ToolCall{
-			ID:   delta.ID,
-			Type: "function",
-			Function: llm.FunctionCall{
-				Name:      delta.Function.Name,
-				Arguments: delta.Function.Arguments,
-			},
-		})
+		current.ID = delta.ID
+	}
+	if delta.Type != "" {
+		current.Type = delta.Type
 	}
-	// Delta without ID means it's appending to the last call (index-based streaming)
-	if len(existing) > 0 {
-		last := len(existing) - 1
-		existing[last].Function.Name += delta.Function.Name
-		existing[last].Function.Arguments += delta.Function.Arguments
+	if delta.Function.Name != "" {
+		current.Function.Name = delta.Function.Name
 	}
+	// A chunk may carry fewer arguments than already seen (e.g. a provider that
+	// only reports finished calls); never let a stale chunk truncate them
+	if len(delta.Function.Arguments) >= len(current.Function.Arguments) {
+		current.Function.Arguments = delta.Function.Arguments
+	}
+
 	return existing
 }
 
@@ -426,6 +564,8 @@ func (a *Agent) RunConversation(ctx context.Context, userInputs []string) ([]str
 
 // EvaluateWithTools runs the agent with tools and returns detailed execution info
 func (a *Agent) EvaluateWithTools(ctx context.Context, userInput string) (*AgentEvaluation, error) {
+	a.resetRunUsage()
+
 	eval := &AgentEvaluation{
 		UserInput: userInput,
 		Steps:     []AgentStep{},
@@ -485,6 +625,11 @@ func (a *Agent) EvaluateWithTools(ctx context.Context, userInput string) (*Agent
 		eval.FinalResponse = response.Message.Content
 	}
 
+	for _, step := range eval.Steps {
+		eval.TotalUsage = eval.TotalUsage.Add(step.TokenUsage)
+	}
+	a.addRunUsage(eval.TotalUsage)
+
 	return eval, nil
 }
 
@@ -508,23 +653,26 @@ func (a *Agent) evaluateToolCallsWithLimit(ctx context.Context, toolCalls []llm.
 		ToolCalls: toolCalls,
 	}
 
-	var toolResponses []llm.Message
-	for _, tc := range toolCalls {
-		// Call the tool
-		toolResponse, err := a.tools.Call(ctx, tc)
-		if err != nil {
-			return "", steps, fmt.Errorf("tool execution error: %w", err)
+	var results []toolResult
+	err := traceToolRound(ctx, iteration, toolCalls, func(ctx context.Context) error {
+		var toolErr error
+		results, toolErr = a.executeToolCalls(ctx, toolCalls)
+		for _, toolResponse := range toolResponses(results) {
+			if err := a.memory.Add(toolResponse); err != nil {
+				return fmt.Errorf("failed to add tool response: %w", err)
+			}
 		}
-
-		toolResponses = append(toolResponses, toolResponse)
-
-		// Add tool response to memory
-		if err := a.memory.Add(toolResponse); err != nil {
-			return "", steps, fmt.Errorf("failed to add tool response: %w", err)
+		if toolErr != nil {
+			return fmt.Errorf("tool execution error: %w", toolErr)
 		}
+		return nil
+	})
+	if err != nil {
+		return "", steps, err
 	}
 
-	toolStep.ToolResponses = toolResponses
+	toolStep.ToolResponses = toolResponses(results)
+	toolStep.ToolExecutions = toolExecutions(toolCalls, results)
 	steps = append(steps, toolStep)
 
 	// Get messages from memory
@@ -589,6 +737,7 @@ type AgentEvaluation struct {
 	UserInput     string      `json:"user_input"`
 	Steps         []AgentStep `json:"steps"`
 	FinalResponse string      `json:"final_response"`
+	TotalUsage    llm.Usage   `json:"total_usage"` // Sum of TokenUsage over all steps; zero when the provider reports none
 }
 
 type AgentStep struct {
@@ -598,4 +747,40 @@ type AgentStep struct {
 	ToolCalls     []llm.ToolCall `json:"tool_calls"`     // Tool calls made
 	ToolResponses []llm.Message  `json:"tool_responses"` // Responses from the tools
 	TokenUsage    llm.Usage      `json:"token_usage"`    // Token usage information
+
+	// ToolExecutions has one entry per tool call of a "tool_execution" step,
+	// in the order of ToolCalls
+	ToolExecutions []ToolExecution `json:"tool_executions,omitempty"`
+}
+
+// ToolExecution records how one tool call of a step went
+type ToolExecution struct {
+	ToolCallID string        `json:"tool_call_id"`
+	ToolName   string        `json:"tool_name"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"` // Why the call failed, including failures reported back to the model
+}
+
+// toolExecutions builds the ToolExecutions of a step from the results of its
+// tool calls; calls skipped by fail-fast are left out
+func toolExecutions(toolCalls []llm.ToolCall, results []toolResult) []ToolExecution {
+	executions := make([]ToolExecution, 0, len(results))
+	for i, r := range results {
+		if r.skipped {
+			continue
+		}
+		execution := ToolExecution{
+			ToolCallID: toolCalls[i].ID,
+			ToolName:   toolCalls[i].Function.Name,
+			StartedAt:  r.startedAt,
+			Duration:   r.duration,
+			Error:      toolx.ToolError(r.msg),
+		}
+		if r.err != nil {
+			execution.Error = r.err.Error()
+		}
+		executions = append(executions, execution)
+	}
+	return executions
 }
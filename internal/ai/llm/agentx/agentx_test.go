@@ -0,0 +1,179 @@
+package agentx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/llmtest"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/toolx"
+)
+
+// weatherTool reports the weather it was asked about
+type weatherTool struct{}
+
+func (weatherTool) Name() string { return "get_weather" }
+
+func (weatherTool) GetTool() llm.Tool {
+	return llm.Tool{Type: "function", Function: llm.Function{Name: "get_weather"}}
+}
+
+func (weatherTool) Call(_ context.Context, args string) (any, error) {
+	return "sunny, args " + args, nil
+}
+
+func weatherAgent() (*Agent, *llmtest.MockProvider) {
+	mock := llmtest.NewMockProvider().
+		On(llmtest.LastMessageContains("weather"), llmtest.CallTool("get_weather", map[string]any{"city": "Lima"})).
+		On(llmtest.LastRole(llm.RoleTool), llmtest.Reply("It is sunny in Lima."))
+	agent := New(*llm.NewClient(mock), memoryx.NewInMemoryMemory("You are a weather bot."),
+		WithTools(toolx.FromToolx(weatherTool{})))
+	return agent, mock
+}
+
+func TestRunWithMockProvider(t *testing.T) {
+	agent, mock := weatherAgent()
+
+	answer, err := agent.Run(context.Background(), "What is the weather in Lima?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "It is sunny in Lima." {
+		t.Errorf("answer = %q", answer)
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("LLM called %d times, want 2", len(calls))
+	}
+	last := calls[1].Messages[len(calls[1].Messages)-1]
+	if last.ToolCallID != "call_1" || !strings.Contains(last.Content, `{"city":"Lima"}`) {
+		t.Errorf("tool result message = %+v", last)
+	}
+	if agent.LastRunUsage().TotalTokens == 0 {
+		t.Error("expected run usage from both turns")
+	}
+}
+
+func TestStreamWithToolsWithMockProvider(t *testing.T) {
+	agent, _ := weatherAgent()
+
+	var text strings.Builder
+	var types []StreamEventType
+	err := agent.StreamWithTools(context.Background(), "What is the weather in Lima?", func(event StreamEvent) {
+		if event.Type == EventText {
+			text.WriteString(event.Content)
+			return
+		}
+		types = append(types, event.Type)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text.String() != "It is sunny in Lima." {
+		t.Errorf("streamed text = %q", text.String())
+	}
+	if len(types) != 2 || types[0] != EventToolCall || types[1] != EventToolResult {
+		t.Errorf("events = %v, want tool_call then tool_result", types)
+	}
+}
+
+func TestMergeToolCallDelta(t *testing.T) {
+	call := func(id, name, args string) llm.ToolCall {
+		return llm.ToolCall{ID: id, Type: "function", Function: llm.FunctionCall{Name: name, Arguments: args}}
+	}
+
+	tests := []struct {
+		name   string
+		chunks [][]llm.ToolCall
+		want   []llm.ToolCall
+	}{
+		{
+			// Chat Completions (Azure): the snapshot of a call grows chunk by chunk
+			name: "growing arguments",
+			chunks: [][]llm.ToolCall{
+				{call("call_1", "get_weather", "")},
+				{call("call_1", "get_weather", `{"city":`)},
+				{call("call_1", "get_weather", `{"city":"Lima"}`)},
+			},
+			want: []llm.ToolCall{call("call_1", "get_weather", `{"city":"Lima"}`)},
+		},
+		{
+			// Responses API (OpenAI): finished calls are appended one by one
+			name: "finished calls appended",
+			chunks: [][]llm.ToolCall{
+				{call("call_1", "get_weather", `{"city":"Lima"}`)},
+				{call("call_1", "get_weather", `{"city":"Lima"}`), call("call_2", "get_time", `{"tz":"UTC"}`)},
+			},
+			want: []llm.ToolCall{
+				call("call_1", "get_weather", `{"city":"Lima"}`),
+				call("call_2", "get_time", `{"tz":"UTC"}`),
+			},
+		},
+		{
+			// Anthropic and Bedrock repeat the snapshot on text chunks and flush
+			// it again on message stop
+			name: "repeated snapshot",
+			chunks: [][]llm.ToolCall{
+				{call("toolu_1", "get_weather", `{"city":"Lima"}`)},
+				{call("toolu_1", "get_weather", `{"city":"Lima"}`)},
+				{call("toolu_1", "get_weather", `{"city":"Lima"}`)},
+			},
+			want: []llm.ToolCall{call("toolu_1", "get_weather", `{"city":"Lima"}`)},
+		},
+		{
+			name: "stale chunk does not truncate arguments",
+			chunks: [][]llm.ToolCall{
+				{call("call_1", "get_weather", `{"city":"Lima"}`)},
+				{call("call_1", "get_weather", `{"city":`)},
+			},
+			want: []llm.ToolCall{call("call_1", "get_weather", `{"city":"Lima"}`)},
+		},
+		{
+			name: "empty fields keep what was seen",
+			chunks: [][]llm.ToolCall{
+				{call("call_1", "get_weather", `{}`)},
+				{{Function: llm.FunctionCall{Arguments: `{"city":"Lima"}`}}},
+			},
+			want: []llm.ToolCall{call("call_1", "get_weather", `{"city":"Lima"}`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []llm.ToolCall
+			for _, chunk := range tt.chunks {
+				for i, tc := range chunk {
+					got = mergeToolCallDelta(got, i, tc)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeToolCallDeltaCopiesProviderBuffer(t *testing.T) {
+	buffer := []llm.ToolCall{{ID: "call_1", Type: "function", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{}`}}}
+
+	var got []llm.ToolCall
+	for i, tc := range buffer {
+		got = mergeToolCallDelta(got, i, tc)
+	}
+	buffer[0].Function.Arguments = `{"city":"Lima"}`
+
+	if got[0].Function.Arguments != `{}` {
+		t.Errorf("merged arguments = %s, want them unaffected by the provider's buffer", got[0].Function.Arguments)
+	}
+}
+
+func TestMergeToolCallDeltaOutOfOrderIndex(t *testing.T) {
+	got := mergeToolCallDelta(nil, 1, llm.ToolCall{ID: "call_2", Function: llm.FunctionCall{Name: "get_time"}})
+	if len(got) != 2 || got[0].Type != "function" || got[1].ID != "call_2" || got[1].Type != "function" {
+		t.Errorf("merged = %+v, want a placeholder at index 0 and call_2 at index 1", got)
+	}
+}
@@ -0,0 +1,227 @@
+// Package agentxapi exposes agentx agents over HTTP. A run is streamed to the
+// client as Server-Sent Events written by agentx.SSEWriter:
+//
+//	event: text         {"type":"text","step":1,"content":"Hel"}
+//	event: tool_call    {"type":"tool_call","step":1,"tool_call_id":"call_1","tool_name":"search","tool_input":"{...}"}
+//	event: tool_result  {"type":"tool_result","step":1,"tool_call_id":"call_1","tool_name":"search","tool_output":"..."}
+//	event: done         {"type":"done","step":2,"usage":{"prompt_tokens":0,...}}
+//	event: error        {"type":"error","step":2,"error":"agent run failed"}
+//
+// A run ends with exactly one done or error event. Errors are not detailed to
+// the client; the cause is logged with the tenant and step.
+package agentxapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/agentx"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/toolx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+)
+
+// errRunFailed is what clients see when a run fails after the stream
+// started; the cause is only logged, since it may carry provider details
+var errRunFailed = errors.New("agent run failed")
+
+// defaultRunTimeout bounds a run when WithRunTimeout is not set
+const defaultRunTimeout = 5 * time.Minute
+
+// AgentFactory builds the agent for a single run. Agents keep the conversation
+// in their memory, so each run needs its own. systemPrompt is the handler's
+// prompt followed by the caller's tenant and user; use it as the agent's
+// system prompt:
+//
+//	func(ctx context.Context, systemPrompt string) (*agentx.Agent, error) {
+//		return agentx.New(client, memoryx.NewInMemoryMemory(),
+//			agentx.WithSystemPrompt(systemPrompt),
+//			agentx.WithTools(tools),
+//		), nil
+//	}
+type AgentFactory func(ctx context.Context, systemPrompt string) (*agentx.Agent, error)
+
+// RunRequest is the body of POST /agents/run/stream
+type RunRequest struct {
+	Input string `json:"input"`
+}
+
+// AgentHandlers streams agent runs to HTTP clients
+type AgentHandlers struct {
+	factory      AgentFactory
+	systemPrompt string
+	limiter      agentx.RunLimiter
+	runTimeout   time.Duration
+}
+
+// HandlerOption configures AgentHandlers
+type HandlerOption func(*AgentHandlers)
+
+// WithRunLimiter bounds concurrent runs per tenant. Runs beyond the limit get
+// 429 TOO_MANY_CONCURRENT_RUNS before the stream starts.
+func WithRunLimiter(limiter agentx.RunLimiter) HandlerOption {
+	return func(h *AgentHandlers) {
+		h.limiter = limiter
+	}
+}
+
+// WithRunTimeout sets the maximum duration of a run (default 5 minutes)
+func WithRunTimeout(timeout time.Duration) HandlerOption {
+	return func(h *AgentHandlers) {
+		if timeout > 0 {
+			h.runTimeout = timeout
+		}
+	}
+}
+
+// NewAgentHandlers creates the handlers. systemPrompt is the base prompt of
+// every run; the caller's tenant and user are appended to it.
+func NewAgentHandlers(factory AgentFactory, systemPrompt string, opts ...HandlerOption) *AgentHandlers {
+	h := &AgentHandlers{
+		factory:      factory,
+		systemPrompt: systemPrompt,
+		runTimeout:   defaultRunTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes mounts the agent routes. Runs require "agents:run" or admin.
+func (h *AgentHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	agents := router.Group("/agents", authMiddleware.Authenticate())
+
+	agents.Post("/run/stream", authMiddleware.RequireAdminOrScope(scopes.ScopeAgentsRun), h.StreamRun)
+}
+
+// StreamRun runs the agent on the request input and streams the run as
+// Server-Sent Events, flushing after every event.
+//
+// The fiber context is released once the handler returns, so the run uses its
+// own context. A client that disconnects shows up as a failed flush, which
+// cancels that context: the LLM stream is closed and no further tools run.
+// The context carries the caller's Authorization header (toolx.WithAuthorization),
+// so HTTP tools call the API as the caller (only on their allowed origins,
+// see toolx.WithAllowedOrigins), and its auth context
+// (kernel.WithAuthContext), so metered LLM calls are charged to its tenant.
+func (h *AgentHandlers) StreamRun(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req RunRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	input := strings.TrimSpace(req.Input)
+	if input == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "input is required"})
+	}
+
+	// Limits and agent errors are returned before the stream starts, so they
+	// keep their HTTP status
+	release := func() {}
+	if h.limiter != nil {
+		var err error
+		release, err = h.limiter.Acquire(c.UserContext(), authContext.TenantID.String())
+		if err != nil {
+			if retryAfter, ok := agentx.RetryAfter(err); ok {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())))
+			}
+			return err
+		}
+	}
+
+	agent, err := h.factory(c.UserContext(), systemContext(h.systemPrompt, authContext))
+	if err != nil {
+		release()
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	tenantID := authContext.TenantID
+	runTimeout := h.runTimeout
+	authorization := strings.Clone(c.Get(fiber.HeaderAuthorization)) // c is released before the run ends
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer release()
+
+		ctx := kernel.WithAuthContext(context.Background(), authContext)
+		ctx, cancel := context.WithTimeout(toolx.WithAuthorization(ctx, authorization), runTimeout)
+		defer cancel()
+
+		sse := agentx.NewSSEWriter(w)
+		lastStep := 0
+
+		err := agent.StreamWithTools(ctx, input, func(event agentx.StreamEvent) {
+			lastStep = max(lastStep, event.Step)
+
+			// The run's outcome is reported once, from the error
+			// StreamWithTools returns
+			if event.Type == agentx.EventError || event.Type == agentx.EventDone {
+				return
+			}
+			// A failed write means the client is gone: stop the run
+			if sse.Write(event) != nil {
+				cancel()
+			}
+		})
+
+		if sse.Err() != nil {
+			logx.WithFields(logx.Fields{
+				"tenant_id": tenantID.String(),
+				"step":      lastStep,
+			}).Info("agent stream closed by the client")
+			return
+		}
+		if err != nil {
+			logx.WithFields(logx.Fields{
+				"tenant_id": tenantID.String(),
+				"step":      lastStep,
+			}).Warnf("agent run failed: %v", err)
+			sse.Write(agentx.StreamEvent{Type: agentx.EventError, Step: lastStep, Err: errRunFailed})
+			return
+		}
+
+		usage := agent.LastRunUsage()
+		sse.Write(agentx.StreamEvent{Type: agentx.EventDone, Step: lastStep, Usage: &usage})
+	})
+
+	return nil
+}
+
+// systemContext appends the caller's tenant and user IDs to the base prompt,
+// so the agent (and its tools) know on whose behalf they act. Only IDs go in:
+// profile fields such as the name are user-controlled and would let a caller
+// inject instructions into the system prompt.
+func systemContext(base string, authContext *kernel.AuthContext) string {
+	var b strings.Builder
+	if base != "" {
+		b.WriteString(base)
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString("Request context:\n")
+	fmt.Fprintf(&b, "- Tenant ID: %s\n", authContext.TenantID)
+	if authContext.IsAPIKey {
+		b.WriteString("- Caller: API key\n")
+	} else if authContext.UserID != nil {
+		fmt.Fprintf(&b, "- User ID: %s\n", *authContext.UserID)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
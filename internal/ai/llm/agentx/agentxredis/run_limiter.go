@@ -0,0 +1,119 @@
+// Package agentxredis provides Redis-backed agentx components for
+// multi-instance deployments.
+package agentxredis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/agentx"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const runLimiterKeyPrefix = "agentx:runs:"
+
+// acquireScript drops expired leases and adds a new one if the key is below
+// its limit. Returns 1 when the slot was taken, 0 otherwise.
+var acquireScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[3], ARGV[4])
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return 1
+`)
+
+// RunLimiter is a distributed semaphore per key. Each run holds a lease in a
+// sorted set scored by its expiry; a heartbeat extends the lease while the
+// run is alive, so slots of crashed instances free themselves after leaseTTL.
+type RunLimiter struct {
+	client   *redis.Client
+	limit    agentx.LimitFunc
+	leaseTTL time.Duration
+	cfg      agentx.RunLimiterConfig
+}
+
+// NewRunLimiter creates a Redis-backed limiter. leaseTTL bounds how long a
+// slot survives its holder; defaults to 1 minute.
+func NewRunLimiter(client *redis.Client, limit agentx.LimitFunc, leaseTTL time.Duration, opts ...agentx.RunLimiterOption) *RunLimiter {
+	if leaseTTL <= 0 {
+		leaseTTL = time.Minute
+	}
+	return &RunLimiter{
+		client:   client,
+		limit:    limit,
+		leaseTTL: leaseTTL,
+		cfg:      agentx.NewRunLimiterConfig(opts...),
+	}
+}
+
+// Acquire takes a run slot for key
+func (l *RunLimiter) Acquire(ctx context.Context, key string) (func(), error) {
+	limit, err := l.limit(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	redisKey := runLimiterKeyPrefix + key
+	lease := uuid.NewString()
+
+	err = agentx.AcquireWithRetry(ctx, l.cfg, key, limit, func() (bool, error) {
+		now := time.Now()
+		ok, err := acquireScript.Run(ctx, l.client, []string{redisKey},
+			now.UnixMilli(),
+			limit,
+			now.Add(l.leaseTTL).UnixMilli(),
+			lease,
+			(2 * l.leaseTTL).Milliseconds(),
+		).Int()
+		if err != nil {
+			return false, agentx.NewRunLimiterUnavailableError(err)
+		}
+		return ok == 1, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	go l.heartbeat(redisKey, lease, stop)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			// Use a fresh context: the run's ctx is often already cancelled here
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			l.client.ZRem(releaseCtx, redisKey, lease)
+		})
+	}, nil
+}
+
+// heartbeat extends the lease until stop is closed
+func (l *RunLimiter) heartbeat(redisKey, lease string, stop <-chan struct{}) {
+	ticker := time.NewTicker(l.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.leaseTTL/3)
+			// XX: only refresh a lease that still exists
+			l.client.ZAddXX(ctx, redisKey, redis.Z{
+				Score:  float64(time.Now().Add(l.leaseTTL).UnixMilli()),
+				Member: lease,
+			})
+			l.client.PExpire(ctx, redisKey, 2*l.leaseTTL)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package agentxredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/agentx"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/testx"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRunLimiter(t *testing.T) {
+	ctx := context.Background()
+	client := testx.Redis(t)
+	limiter := NewRunLimiter(client, agentx.StaticLimit(2), time.Minute)
+
+	release1, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.Acquire(ctx, "t1"); !errx.Is(err, agentx.ErrTooManyConcurrentRuns) {
+		t.Fatalf("third Acquire = %v, want TOO_MANY_CONCURRENT_RUNS", err)
+	}
+
+	// Keys are limited independently
+	release, err := limiter.Acquire(ctx, "t2")
+	if err != nil {
+		t.Fatalf("Acquire for another key = %v", err)
+	}
+	release()
+
+	release1()
+	release1()
+	release3, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Acquire after release = %v", err)
+	}
+	if n := client.ZCard(ctx, runLimiterKeyPrefix+"t1").Val(); n != 2 {
+		t.Errorf("leases = %d, want 2", n)
+	}
+	release2()
+	release3()
+	if n := client.ZCard(ctx, runLimiterKeyPrefix+"t1").Val(); n != 0 {
+		t.Errorf("leases after release = %d, want 0", n)
+	}
+}
+
+func TestRunLimiterExpiresLeases(t *testing.T) {
+	ctx := context.Background()
+	client := testx.Redis(t)
+	limiter := NewRunLimiter(client, agentx.StaticLimit(1), time.Minute)
+
+	// A lease left by a crashed instance: already past its expiry
+	expired := time.Now().Add(-time.Second).UnixMilli()
+	if err := client.ZAdd(ctx, runLimiterKeyPrefix+"t1", redis.Z{Score: float64(expired), Member: "crashed"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Acquire with an expired lease = %v, want the slot", err)
+	}
+	defer release()
+	if members := client.ZRange(ctx, runLimiterKeyPrefix+"t1", 0, -1).Val(); len(members) != 1 || members[0] == "crashed" {
+		t.Errorf("leases = %v, want only the new one", members)
+	}
+}
+
+func TestRunLimiterHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	client := testx.Redis(t)
+	leaseTTL := 150 * time.Millisecond
+	limiter := NewRunLimiter(client, agentx.StaticLimit(1), leaseTTL)
+
+	release, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// The heartbeat keeps a live run's lease beyond leaseTTL
+	time.Sleep(3 * leaseTTL)
+	if _, err := limiter.Acquire(ctx, "t1"); !errx.Is(err, agentx.ErrTooManyConcurrentRuns) {
+		t.Fatalf("Acquire while the run is alive = %v, want TOO_MANY_CONCURRENT_RUNS", err)
+	}
+}
@@ -0,0 +1,51 @@
+package agentx
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+var (
+	errorRegistry = errx.NewRegistry("AGENTX")
+
+	ErrTooManyConcurrentRuns = errorRegistry.Register(
+		"TOO_MANY_CONCURRENT_RUNS",
+		errx.TypeBusiness,
+		http.StatusTooManyRequests,
+		"Too many concurrent agent runs",
+	)
+
+	ErrRunLimiterUnavailable = errorRegistry.Register(
+		"RUN_LIMITER_UNAVAILABLE",
+		errx.TypeInternal,
+		http.StatusServiceUnavailable,
+		"Agent run limiter is unavailable",
+	)
+)
+
+// NewTooManyConcurrentRunsError builds the error returned when a key has no free
+// run slot; retry_after_seconds tells the client how long to wait
+func NewTooManyConcurrentRunsError(key string, limit int, retryAfter time.Duration) *errx.Error {
+	return errorRegistry.New(ErrTooManyConcurrentRuns).
+		WithDetail("key", key).
+		WithDetail("limit", limit).
+		WithDetail("retry_after_seconds", int(math.Ceil(retryAfter.Seconds())))
+}
+
+// NewRunLimiterUnavailableError wraps a failure of the limiter backend
+func NewRunLimiterUnavailableError(cause error) *errx.Error {
+	return errorRegistry.NewWithCause(ErrRunLimiterUnavailable, cause)
+}
+
+// RetryAfter returns the wait time carried by a TOO_MANY_CONCURRENT_RUNS error
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != ErrTooManyConcurrentRuns.Code {
+		return 0, false
+	}
+	seconds, _ := e.Details["retry_after_seconds"].(int)
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,398 @@
+package agentx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Evaluation harness
+// ============================================================================
+
+// EvalCase is a single regression case: an input and the assertions its
+// evaluation must satisfy
+type EvalCase struct {
+	Name       string
+	Input      string
+	Assertions []Assertion
+}
+
+// Assertion checks one property of an AgentEvaluation
+type Assertion struct {
+	Description string
+	Check       func(eval *AgentEvaluation) error
+}
+
+// EvalResult is the outcome of one EvalCase
+type EvalResult struct {
+	Case       string           `json:"case"`
+	Passed     bool             `json:"passed"`
+	Failures   []string         `json:"failures,omitempty"` // Failed assertions, "description: reason"
+	Error      string           `json:"error,omitempty"`    // Set when the agent itself failed
+	Duration   time.Duration    `json:"duration"`
+	Evaluation *AgentEvaluation `json:"evaluation,omitempty"`
+}
+
+// EvalReport aggregates the results of a run
+type EvalReport struct {
+	Results []EvalResult `json:"results"`
+	Passed  int          `json:"passed"`
+	Failed  int          `json:"failed"`
+}
+
+// OK reports whether every case passed
+func (r *EvalReport) OK() bool {
+	return r.Failed == 0
+}
+
+// Summary renders a human-readable pass/fail report
+func (r *EvalReport) Summary() string {
+	var b strings.Builder
+	for _, res := range r.Results {
+		status := "PASS"
+		if !res.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "[%s] %s (%s)\n", status, res.Case, res.Duration.Round(time.Millisecond))
+		if res.Error != "" {
+			fmt.Fprintf(&b, "    error: %s\n", res.Error)
+		}
+		for _, f := range res.Failures {
+			fmt.Fprintf(&b, "    - %s\n", f)
+		}
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed, %d total\n", r.Passed, r.Failed, len(r.Results))
+	return b.String()
+}
+
+// EvalOption configures RunEvals
+type EvalOption func(*evalConfig)
+
+type evalConfig struct {
+	concurrency int
+	timeout     time.Duration
+}
+
+// WithEvalConcurrency runs up to n cases in parallel (default 1)
+func WithEvalConcurrency(n int) EvalOption {
+	return func(c *evalConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithEvalTimeout bounds the duration of each case
+func WithEvalTimeout(timeout time.Duration) EvalOption {
+	return func(c *evalConfig) {
+		c.timeout = timeout
+	}
+}
+
+// RunEvals runs every case against a fresh agent built by newAgent, so memory
+// never leaks between cases, and checks its assertions against the
+// EvaluateWithTools output
+func RunEvals(ctx context.Context, newAgent func() *Agent, cases []EvalCase, opts ...EvalOption) *EvalReport {
+	cfg := evalConfig{concurrency: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	results := make([]EvalResult, len(cases))
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+
+	for i, c := range cases {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = runEvalCase(ctx, newAgent(), c, cfg.timeout)
+		}()
+	}
+	wg.Wait()
+
+	report := &EvalReport{Results: results}
+	for _, res := range results {
+		if res.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	return report
+}
+
+func runEvalCase(ctx context.Context, agent *Agent, c EvalCase, timeout time.Duration) EvalResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	name := c.Name
+	if name == "" {
+		name = c.Input
+	}
+	result := EvalResult{Case: name}
+
+	start := time.Now()
+	eval, err := agent.EvaluateWithTools(ctx, c.Input)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Evaluation = eval
+
+	for _, a := range c.Assertions {
+		if err := a.Check(eval); err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s: %v", a.Description, err))
+		}
+	}
+	result.Passed = len(result.Failures) == 0
+
+	return result
+}
+
+// ============================================================================
+// Assertions
+// ============================================================================
+
+// AnswerContains asserts that the final answer contains substr
+func AnswerContains(substr string) Assertion {
+	return Assertion{
+		Description: fmt.Sprintf("answer contains %q", substr),
+		Check: func(eval *AgentEvaluation) error {
+			if !strings.Contains(eval.FinalResponse, substr) {
+				return fmt.Errorf("got %q", truncateForReport(eval.FinalResponse))
+			}
+			return nil
+		},
+	}
+}
+
+// AnswerNotContains asserts that the final answer does not contain substr
+func AnswerNotContains(substr string) Assertion {
+	return Assertion{
+		Description: fmt.Sprintf("answer does not contain %q", substr),
+		Check: func(eval *AgentEvaluation) error {
+			if strings.Contains(eval.FinalResponse, substr) {
+				return fmt.Errorf("got %q", truncateForReport(eval.FinalResponse))
+			}
+			return nil
+		},
+	}
+}
+
+// AnswerMatches asserts that the final answer matches the regular expression.
+// It panics if pattern does not compile, like regexp.MustCompile.
+func AnswerMatches(pattern string) Assertion {
+	re := regexp.MustCompile(pattern)
+	return Assertion{
+		Description: fmt.Sprintf("answer matches /%s/", pattern),
+		Check: func(eval *AgentEvaluation) error {
+			if !re.MatchString(eval.FinalResponse) {
+				return fmt.Errorf("got %q", truncateForReport(eval.FinalResponse))
+			}
+			return nil
+		},
+	}
+}
+
+// AnswerJSONPath asserts that the final answer is JSON and that the value at
+// path equals expected. Paths use dots and indexes, e.g. "items[0].id".
+// Markdown code fences around the JSON are ignored.
+func AnswerJSONPath(path string, expected any) Assertion {
+	return Assertion{
+		Description: fmt.Sprintf("answer JSON %s == %v", path, expected),
+		Check: func(eval *AgentEvaluation) error {
+			var doc any
+			if err := json.Unmarshal([]byte(stripCodeFence(eval.FinalResponse)), &doc); err != nil {
+				return fmt.Errorf("answer is not valid JSON: %w", err)
+			}
+
+			got, err := lookupJSONPath(doc, path)
+			if err != nil {
+				return err
+			}
+
+			// Compare canonical encodings (json.Marshal sorts map keys),
+			// so 3 matches 3.0 and struct values match decoded objects
+			want, err := canonicalJSON(expected)
+			if err != nil {
+				return fmt.Errorf("invalid expected value: %w", err)
+			}
+			gotRaw, _ := canonicalJSON(got)
+			if gotRaw != want {
+				return fmt.Errorf("got %s", gotRaw)
+			}
+			return nil
+		},
+	}
+}
+
+// ToolCalled asserts that the agent called the tool at least once
+func ToolCalled(name string) Assertion {
+	return Assertion{
+		Description: fmt.Sprintf("tool %q called", name),
+		Check: func(eval *AgentEvaluation) error {
+			for _, called := range CalledTools(eval) {
+				if called == name {
+					return nil
+				}
+			}
+			return fmt.Errorf("called %v", CalledTools(eval))
+		},
+	}
+}
+
+// ToolNotCalled asserts that the agent never called the tool
+func ToolNotCalled(name string) Assertion {
+	return Assertion{
+		Description: fmt.Sprintf("tool %q not called", name),
+		Check: func(eval *AgentEvaluation) error {
+			for _, called := range CalledTools(eval) {
+				if called == name {
+					return fmt.Errorf("called %v", CalledTools(eval))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// ToolsCalledInOrder asserts that the tools were called in this relative
+// order; other calls may appear in between
+func ToolsCalledInOrder(names ...string) Assertion {
+	return Assertion{
+		Description: fmt.Sprintf("tools called in order %v", names),
+		Check: func(eval *AgentEvaluation) error {
+			called := CalledTools(eval)
+			next := 0
+			for _, c := range called {
+				if next < len(names) && c == names[next] {
+					next++
+				}
+			}
+			if next < len(names) {
+				return fmt.Errorf("called %v", called)
+			}
+			return nil
+		},
+	}
+}
+
+// MaxToolCalls asserts that the agent made at most n tool calls
+func MaxToolCalls(n int) Assertion {
+	return Assertion{
+		Description: fmt.Sprintf("at most %d tool calls", n),
+		Check: func(eval *AgentEvaluation) error {
+			if called := CalledTools(eval); len(called) > n {
+				return fmt.Errorf("made %d calls: %v", len(called), called)
+			}
+			return nil
+		},
+	}
+}
+
+// CalledTools returns the names of the tools called during an evaluation, in order
+func CalledTools(eval *AgentEvaluation) []string {
+	var names []string
+	for _, step := range eval.Steps {
+		if step.StepType != "tool_execution" {
+			continue
+		}
+		for _, tc := range step.ToolCalls {
+			names = append(names, tc.Function.Name)
+		}
+	}
+	return names
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+var jsonPathSegment = regexp.MustCompile(`^([^\[\]]*)((?:\[\d+\])*)$`)
+
+// lookupJSONPath resolves a dotted path with array indexes in decoded JSON
+func lookupJSONPath(doc any, path string) (any, error) {
+	current := doc
+	if path == "" || path == "$" {
+		return current, nil
+	}
+
+	for _, part := range strings.Split(strings.TrimPrefix(path, "$."), ".") {
+		m := jsonPathSegment.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("invalid path segment %q", part)
+		}
+
+		if key := m[1]; key != "" {
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%q is not an object", key)
+			}
+			if current, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("key %q not found", key)
+			}
+		}
+
+		for _, idx := range strings.Split(strings.Trim(m[2], "[]"), "][") {
+			if idx == "" {
+				continue
+			}
+			i, _ := strconv.Atoi(idx)
+			arr, ok := current.([]any)
+			if !ok || i >= len(arr) {
+				return nil, fmt.Errorf("index [%d] out of range in %q", i, part)
+			}
+			current = arr[i]
+		}
+	}
+
+	return current, nil
+}
+
+// canonicalJSON encodes v after a decode round-trip, normalizing numbers and structs
+func canonicalJSON(v any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return "", err
+	}
+	raw, err = json.Marshal(decoded)
+	return string(raw), err
+}
+
+// stripCodeFence removes a surrounding ``` fence, as models often wrap JSON in one
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+		s = s[nl+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+func truncateForReport(s string) string {
+	const max = 200
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
@@ -0,0 +1,189 @@
+package agentx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+func TestRunEvals(t *testing.T) {
+	newAgent := func() *Agent {
+		agent, _ := weatherAgent()
+		return agent
+	}
+	cases := []EvalCase{
+		{
+			Name:  "weather",
+			Input: "What is the weather in Lima?",
+			Assertions: []Assertion{
+				ToolCalled("get_weather"),
+				AnswerContains("sunny"),
+				MaxToolCalls(1),
+			},
+		},
+		{
+			Input: "Tell me the weather without tools",
+			Assertions: []Assertion{
+				ToolNotCalled("get_weather"),
+				AnswerNotContains("sunny"),
+				AnswerContains("Lima"),
+			},
+		},
+		{
+			Name:  "no rule",
+			Input: "Hello",
+		},
+	}
+
+	report := RunEvals(context.Background(), newAgent, cases, WithEvalConcurrency(2))
+
+	if report.Passed != 1 || report.Failed != 2 || report.OK() {
+		t.Fatalf("report = %d passed, %d failed; want 1 and 2", report.Passed, report.Failed)
+	}
+	if len(report.Results) != 3 || report.Results[0].Case != "weather" || report.Results[2].Case != "no rule" {
+		t.Fatalf("results = %+v, want them in case order", report.Results)
+	}
+	if r := report.Results[0]; !r.Passed || r.Evaluation == nil || r.Evaluation.FinalResponse != "It is sunny in Lima." {
+		t.Errorf("passing case = %+v", r)
+	}
+
+	failing := report.Results[1]
+	if failing.Case != cases[1].Input {
+		t.Errorf("unnamed case = %q, want it named after its input", failing.Case)
+	}
+	if len(failing.Failures) != 2 ||
+		!strings.HasPrefix(failing.Failures[0], `tool "get_weather" not called: called [get_weather]`) ||
+		!strings.HasPrefix(failing.Failures[1], `answer does not contain "sunny"`) {
+		t.Errorf("failures = %q, want the two failed assertions", failing.Failures)
+	}
+
+	if r := report.Results[2]; r.Passed || r.Error == "" || r.Evaluation != nil {
+		t.Errorf("agent failure = %+v, want it reported as an error", r)
+	}
+
+	summary := report.Summary()
+	for _, want := range []string{"[PASS] weather", "[FAIL] no rule", "    error: ", "1 passed, 2 failed, 3 total"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestEvalAssertions(t *testing.T) {
+	call := func(name string) llm.ToolCall {
+		return llm.ToolCall{Function: llm.FunctionCall{Name: name}}
+	}
+	eval := &AgentEvaluation{
+		Steps: []AgentStep{
+			{StepType: "initial", ToolCalls: []llm.ToolCall{call("ignored")}},
+			{StepType: "tool_execution", ToolCalls: []llm.ToolCall{call("search"), call("fetch")}},
+			{StepType: "tool_execution", ToolCalls: []llm.ToolCall{call("search")}},
+			{StepType: "response"},
+		},
+		FinalResponse: "Order 42 ships on Monday.",
+	}
+
+	tests := []struct {
+		assertion Assertion
+		pass      bool
+	}{
+		{AnswerContains("Monday"), true},
+		{AnswerContains("Friday"), false},
+		{AnswerNotContains("Friday"), true},
+		{AnswerNotContains("Order"), false},
+		{AnswerMatches(`^Order \d+ ships`), true},
+		{AnswerMatches(`Tuesday$`), false},
+		{ToolCalled("fetch"), true},
+		{ToolCalled("ignored"), false},
+		{ToolNotCalled("delete"), true},
+		{ToolNotCalled("search"), false},
+		{ToolsCalledInOrder("search", "fetch"), true},
+		{ToolsCalledInOrder("fetch", "search"), true},
+		{ToolsCalledInOrder("fetch", "fetch"), false},
+		{ToolsCalledInOrder(), true},
+		{MaxToolCalls(3), true},
+		{MaxToolCalls(2), false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.assertion.Check(eval); (err == nil) != tt.pass {
+			t.Errorf("%s: error = %v, want pass=%v", tt.assertion.Description, err, tt.pass)
+		}
+	}
+
+	if got := CalledTools(eval); strings.Join(got, ",") != "search,fetch,search" {
+		t.Errorf("CalledTools = %v", got)
+	}
+}
+
+func TestAnswerJSONPath(t *testing.T) {
+	answer := "```json\n{\"order\": {\"id\": 42, \"items\": [{\"sku\": \"a\"}, {\"sku\": \"b\", \"tags\": [[\"x\", \"y\"]]}]}, \"ok\": true}\n```"
+	eval := &AgentEvaluation{FinalResponse: answer}
+
+	tests := []struct {
+		path     string
+		expected any
+		pass     bool
+	}{
+		{"order.id", 42, true},
+		{"order.id", 42.0, true},
+		{"$.order.id", 42, true},
+		{"order.id", "42", false},
+		{"order.items[1].sku", "b", true},
+		{"order.items[1].tags[0][1]", "y", true},
+		{"order.items[2].sku", "c", false},
+		{"order.items[0]", map[string]any{"sku": "a"}, true},
+		{"order.items[0]", struct {
+			SKU string `json:"sku"`
+		}{"a"}, true},
+		{"order.missing", nil, false},
+		{"ok.value", true, false},
+		{"ok", true, true},
+		{"order.items[x]", "a", false},
+	}
+
+	for _, tt := range tests {
+		if err := AnswerJSONPath(tt.path, tt.expected).Check(eval); (err == nil) != tt.pass {
+			t.Errorf("AnswerJSONPath(%q, %v) error = %v, want pass=%v", tt.path, tt.expected, err, tt.pass)
+		}
+	}
+
+	if err := AnswerJSONPath("id", 1).Check(&AgentEvaluation{FinalResponse: "not json"}); err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Errorf("non-JSON answer error = %v", err)
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	doc := []any{map[string]any{"a": []any{1.0, 2.0}}}
+
+	tests := []struct {
+		path    string
+		want    any
+		wantErr bool
+	}{
+		{"", doc, false},
+		{"$", doc, false},
+		{"[0].a[1]", 2.0, false},
+		{"[1]", nil, true},
+		{"a", nil, true},
+		{"[0].a[5]", nil, true},
+		{"[0].a]", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := lookupJSONPath(doc, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("lookupJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr {
+			gotJSON, _ := canonicalJSON(got)
+			wantJSON, _ := canonicalJSON(tt.want)
+			if gotJSON != wantJSON {
+				t.Errorf("lookupJSONPath(%q) = %s, want %s", tt.path, gotJSON, wantJSON)
+			}
+		}
+	}
+}
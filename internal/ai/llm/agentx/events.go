@@ -1,9 +1,14 @@
 package agentx
 
+import "github.com/Abraxas-365/manifesto/internal/ai/llm"
+
 // StreamEventType identifies what kind of event is being emitted
 type StreamEventType string
 
 const (
+	// EventStepStarted fires at the start of every LLM turn (only with WithStepEvents)
+	EventStepStarted StreamEventType = "step_started"
+
 	// EventText is a chunk of LLM response text
 	EventText StreamEventType = "text"
 
@@ -15,13 +20,20 @@ const (
 
 	// EventError fires if something goes wrong mid-stream
 	EventError StreamEventType = "error"
+
+	// EventDone fires once the run finishes with a final answer (only with WithStepEvents)
+	EventDone StreamEventType = "done"
 )
 
 // StreamEvent is the structured payload sent to the caller on every stream tick
 type StreamEvent struct {
 	Type StreamEventType
 
+	// Step is the 1-based LLM turn the event belongs to
+	Step int
+
 	// EventText: the incremental text chunk from the LLM
+	// EventDone: the full text of the final answer
 	Content string
 
 	// EventToolCall / EventToolResult
@@ -36,6 +48,9 @@ type StreamEvent struct {
 
 	// EventError
 	Err error
+
+	// EventDone: token usage of the run (see Agent.LastRunUsage)
+	Usage *llm.Usage
 }
 
 // StreamHandler receives events as they happen
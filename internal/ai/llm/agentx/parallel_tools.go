@@ -0,0 +1,152 @@
+package agentx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/toolx"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// defaultToolConcurrency bounds how many tool calls of one turn run at once
+const defaultToolConcurrency = 4
+
+// WithToolConcurrency sets how many tool calls requested in the same turn run
+// concurrently. 1 runs them one after another.
+func WithToolConcurrency(workers int) AgentOption {
+	return func(a *Agent) {
+		a.toolConcurrency = workers
+	}
+}
+
+// WithToolFailFast cancels the remaining tool calls of a turn as soon as one
+// fails, returning an error or a tool error message (toolx.ToolError), and
+// ends the run with that failure. By default every call runs to completion
+// and failures go back to the model as tool messages.
+func WithToolFailFast() AgentOption {
+	return func(a *Agent) {
+		a.toolFailFast = true
+	}
+}
+
+// toolResult is the outcome of one tool call of a turn
+type toolResult struct {
+	msg       llm.Message
+	err       error
+	skipped   bool // Not run because a previous call failed with fail-fast on
+	startedAt time.Time
+	duration  time.Duration
+}
+
+// timedCallTool runs one tool call and records when it started and how long
+// it took. With fail-fast on, a tool error message counts as a failure.
+func (a *Agent) timedCallTool(ctx context.Context, tc llm.ToolCall) toolResult {
+	startedAt := time.Now()
+	msg, err := a.callTool(ctx, tc)
+	if reason := toolx.ToolError(msg); err == nil && reason != "" && a.toolFailFast {
+		err = errors.New(reason)
+	}
+	return toolResult{msg: msg, err: err, startedAt: startedAt, duration: time.Since(startedAt)}
+}
+
+// invokeTool runs one tool call, turning a panic in the tool into a tool
+// error message so a broken tool cannot bring the process down
+func (a *Agent) invokeTool(ctx context.Context, tc llm.ToolCall) (msg llm.Message, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			logx.Errorf("tool %q panicked: %v\n%s", tc.Function.Name, p, debug.Stack())
+			msg = llm.NewToolMessage(tc.ID, "Error calling tool: the tool failed unexpectedly")
+			msg.Metadata = map[string]any{toolx.MetadataToolError: fmt.Sprintf("panic: %v", p)}
+			err = nil
+		}
+	}()
+	return a.tools.Call(ctx, tc)
+}
+
+// executeToolCalls runs the tool calls of one turn and returns their results
+// in the order of toolCalls, plus the failures joined into one error. A single
+// call runs inline.
+func (a *Agent) executeToolCalls(ctx context.Context, toolCalls []llm.ToolCall) ([]toolResult, error) {
+	if len(toolCalls) == 1 {
+		r := a.timedCallTool(ctx, toolCalls[0])
+		return []toolResult{r}, r.err
+	}
+
+	results := a.runToolCalls(ctx, toolCalls)
+	return results, toolRoundError(toolCalls, results)
+}
+
+// toolResponses returns the messages of the calls that succeeded
+func toolResponses(results []toolResult) []llm.Message {
+	responses := make([]llm.Message, 0, len(results))
+	for _, r := range results {
+		if r.err == nil && !r.skipped {
+			responses = append(responses, r.msg)
+		}
+	}
+	return responses
+}
+
+// runToolCalls executes toolCalls on up to toolConcurrency workers. Results
+// keep the order of toolCalls, since the follow-up LLM call expects the tool
+// responses in the order the calls were requested.
+func (a *Agent) runToolCalls(ctx context.Context, toolCalls []llm.ToolCall) []toolResult {
+	results := make([]toolResult, len(toolCalls))
+
+	parent := ctx
+	cancel := func() {}
+	if a.toolFailFast {
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+	}
+	var failed sync.Once
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(a.toolConcurrency, 1), len(toolCalls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if a.toolFailFast && ctx.Err() != nil {
+					results[i].skipped = true
+					continue
+				}
+				r := a.timedCallTool(ctx, toolCalls[i])
+				if a.toolFailFast && r.err != nil {
+					if ctx.Err() != nil && parent.Err() == nil && errors.Is(r.err, context.Canceled) {
+						// Cancelled because another call failed
+						r = toolResult{skipped: true}
+					} else {
+						failed.Do(cancel)
+					}
+				}
+				results[i] = r
+			}
+		}()
+	}
+
+	for i := range toolCalls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// toolRoundError joins the failures of a turn, naming the tool of each
+func toolRoundError(toolCalls []llm.ToolCall, results []toolResult) error {
+	var errs []error
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("tool %q: %w", toolCalls[i].Function.Name, r.err))
+		}
+	}
+	return errors.Join(errs...)
+}
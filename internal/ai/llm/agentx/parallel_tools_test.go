@@ -0,0 +1,179 @@
+package agentx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/toolx"
+)
+
+// sleepTool answers with its name after delay, tracking how many calls overlap
+type sleepTool struct {
+	name    string
+	delay   time.Duration
+	err     error
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (t *sleepTool) Name() string { return t.name }
+
+func (t *sleepTool) GetTool() llm.Tool {
+	return llm.Tool{Type: "function", Function: llm.Function{Name: t.name}}
+}
+
+func (t *sleepTool) Call(ctx context.Context, _ string) (any, error) {
+	n := t.running.Add(1)
+	defer t.running.Add(-1)
+	for {
+		peak := t.peak.Load()
+		if n <= peak || t.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(t.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t.name, nil
+}
+
+func TestExecuteToolCallsKeepsOrder(t *testing.T) {
+	var running, peak atomic.Int32
+	tools := []toolx.Toolx{
+		&sleepTool{name: "slow", delay: 30 * time.Millisecond, running: &running, peak: &peak},
+		&sleepTool{name: "broken", delay: time.Millisecond, err: errors.New("boom"), running: &running, peak: &peak},
+		&sleepTool{name: "fast", delay: time.Millisecond, running: &running, peak: &peak},
+	}
+	agent := &Agent{tools: toolx.FromToolx(tools...), toolConcurrency: 3}
+
+	var toolCalls []llm.ToolCall
+	for _, tool := range tools {
+		toolCalls = append(toolCalls, llm.ToolCall{ID: "call-" + tool.Name(), Function: llm.FunctionCall{Name: tool.Name()}})
+	}
+
+	results, err := agent.executeToolCalls(context.Background(), toolCalls)
+	if err != nil {
+		t.Fatalf("tool errors are returned as tool messages, got %v", err)
+	}
+	responses := toolResponses(results)
+	if len(responses) != 3 {
+		t.Fatalf("got %d responses, want 3", len(responses))
+	}
+	for i, want := range []string{"slow", "Error calling tool: boom", "fast"} {
+		if responses[i].Content != want || responses[i].ToolCallID != toolCalls[i].ID {
+			t.Errorf("responses[%d] = %q (%s), want %q for %s", i, responses[i].Content, responses[i].ToolCallID, want, toolCalls[i].ID)
+		}
+	}
+	if peak.Load() < 2 {
+		t.Errorf("peak concurrency = %d, want the calls to overlap", peak.Load())
+	}
+
+	executions := toolExecutions(toolCalls, results)
+	if len(executions) != 3 || executions[0].ToolName != "slow" || executions[0].Duration < 30*time.Millisecond {
+		t.Errorf("executions = %+v, want the slow call timed first", executions)
+	}
+	if executions[1].Error != "boom" || executions[0].Error != "" || executions[2].Error != "" {
+		t.Errorf("execution errors = %q, %q, %q, want only the broken tool's", executions[0].Error, executions[1].Error, executions[2].Error)
+	}
+
+	// toolx reports tool failures back to the model, so the aggregate error is
+	// exercised directly
+	roundErr := toolRoundError(toolCalls, []toolResult{{}, {err: errors.New("boom")}, {err: errors.New("bang")}})
+	if roundErr == nil || !strings.Contains(roundErr.Error(), `tool "broken": boom`) || !strings.Contains(roundErr.Error(), `tool "fast": bang`) {
+		t.Errorf("toolRoundError = %v, want both failures", roundErr)
+	}
+}
+
+type panickingTool struct{}
+
+func (panickingTool) Name() string { return "panicky" }
+
+func (panickingTool) GetTool() llm.Tool {
+	return llm.Tool{Type: "function", Function: llm.Function{Name: "panicky"}}
+}
+
+func (panickingTool) Call(context.Context, string) (any, error) {
+	var m map[string]int
+	m["boom"]++ // nil map write
+	return nil, nil
+}
+
+func TestRunToolCallsBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	var tools []toolx.Toolx
+	var toolCalls []llm.ToolCall
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		tools = append(tools, &sleepTool{name: name, delay: 10 * time.Millisecond, running: &running, peak: &peak})
+		toolCalls = append(toolCalls, llm.ToolCall{ID: "call-" + name, Function: llm.FunctionCall{Name: name}})
+	}
+	agent := &Agent{tools: toolx.FromToolx(tools...), toolConcurrency: 2}
+
+	results := agent.runToolCalls(context.Background(), toolCalls)
+	if peak.Load() != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak.Load())
+	}
+	for i, r := range results {
+		if r.err != nil || r.msg.Content != toolCalls[i].Function.Name {
+			t.Errorf("results[%d] = %q, %v", i, r.msg.Content, r.err)
+		}
+	}
+}
+
+func TestRunToolCallsFailFast(t *testing.T) {
+	var running, peak atomic.Int32
+	tools := []toolx.Toolx{
+		&sleepTool{name: "broken", delay: time.Millisecond, err: errors.New("boom"), running: &running, peak: &peak},
+		&sleepTool{name: "slow", delay: time.Minute, running: &running, peak: &peak},
+		&sleepTool{name: "queued", delay: time.Minute, running: &running, peak: &peak},
+	}
+	agent := &Agent{tools: toolx.FromToolx(tools...), toolConcurrency: 2, toolFailFast: true}
+	var toolCalls []llm.ToolCall
+	for _, tool := range tools {
+		toolCalls = append(toolCalls, llm.ToolCall{ID: "call-" + tool.Name(), Function: llm.FunctionCall{Name: tool.Name()}})
+	}
+
+	started := time.Now()
+	results, err := agent.executeToolCalls(context.Background(), toolCalls)
+	if time.Since(started) > 5*time.Second {
+		t.Fatal("the slow call was not cancelled")
+	}
+	// The tool error toolx reports as a message fails the round
+	if err == nil || !strings.Contains(err.Error(), `tool "broken": boom`) || strings.Contains(err.Error(), "canceled") {
+		t.Fatalf("round error = %v, want only the broken tool's failure", err)
+	}
+	if !results[1].skipped || !results[2].skipped {
+		t.Errorf("cancelled calls not skipped: %+v", results)
+	}
+	if executions := toolExecutions(toolCalls, results); len(executions) != 1 || executions[0].ToolName != "broken" {
+		t.Errorf("executions = %+v, want only the broken call", executions)
+	}
+}
+
+func TestToolPanicBecomesToolError(t *testing.T) {
+	agent := &Agent{tools: toolx.FromToolx(panickingTool{}), toolConcurrency: 2}
+	toolCalls := []llm.ToolCall{
+		{ID: "call-1", Function: llm.FunctionCall{Name: "panicky"}},
+		{ID: "call-2", Function: llm.FunctionCall{Name: "panicky"}},
+	}
+
+	results, err := agent.executeToolCalls(context.Background(), toolCalls)
+	if err != nil {
+		t.Fatalf("panics are returned as tool messages, got %v", err)
+	}
+	for i, r := range results {
+		if r.msg.ToolCallID != toolCalls[i].ID || !strings.HasPrefix(toolx.ToolError(r.msg), "panic: ") {
+			t.Errorf("results[%d] = %+v, want a tool error for the panic", i, r.msg)
+		}
+	}
+}
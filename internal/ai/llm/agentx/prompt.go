@@ -0,0 +1,89 @@
+package agentx
+
+import (
+	"maps"
+	"regexp"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+)
+
+// WithSystemPrompt sets the agent's system prompt. It is rendered with the
+// prompt variables on construction and is always the first message sent to
+// the LLM, replacing any system message the memory starts with, so it also
+// survives ClearMemory.
+//
+//	agentx.New(client, memory,
+//		agentx.WithSystemPrompt("You assist {{user_name}} of {{tenant_name}}. Scopes: {{scopes}}."),
+//		agentx.WithPromptVariables(map[string]string{
+//			"user_name":   "Ada",
+//			"tenant_name": "Acme",
+//			"scopes":      "users:read, invitations:write",
+//		}),
+//	)
+func WithSystemPrompt(prompt string) AgentOption {
+	return func(a *Agent) {
+		a.systemPrompt = prompt
+	}
+}
+
+// WithPromptVariables sets the values substituted for {{name}} placeholders in
+// the system prompt. Repeated calls merge the maps.
+func WithPromptVariables(variables map[string]string) AgentOption {
+	return func(a *Agent) {
+		if a.promptVariables == nil {
+			a.promptVariables = make(map[string]string, len(variables))
+		}
+		maps.Copy(a.promptVariables, variables)
+	}
+}
+
+// promptPlaceholder matches {{name}}, allowing spaces inside the braces
+var promptPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// RenderPrompt replaces each {{name}} in template with variables[name].
+// Placeholders without a value are left as they are, so a missing variable
+// shows up in the prompt instead of silently disappearing.
+func RenderPrompt(template string, variables map[string]string) string {
+	return promptPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := promptPlaceholder.FindStringSubmatch(placeholder)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// SystemPrompt returns the rendered system prompt, empty without WithSystemPrompt
+func (a *Agent) SystemPrompt() string {
+	if m, ok := a.memory.(*systemPromptMemory); ok {
+		return m.prompt
+	}
+	return ""
+}
+
+// systemPromptMemory puts the agent's system prompt first in the messages of
+// inner. The prompt is not stored in inner, so Clear cannot lose it.
+type systemPromptMemory struct {
+	inner  memoryx.Memory
+	prompt string
+}
+
+func (m *systemPromptMemory) Messages() ([]llm.Message, error) {
+	messages, err := m.inner.Messages()
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) > 0 && messages[0].Role == llm.RoleSystem {
+		messages = messages[1:]
+	}
+	return append([]llm.Message{llm.NewSystemMessage(m.prompt)}, messages...), nil
+}
+
+func (m *systemPromptMemory) Add(message llm.Message) error {
+	return m.inner.Add(message)
+}
+
+func (m *systemPromptMemory) Clear() error {
+	return m.inner.Clear()
+}
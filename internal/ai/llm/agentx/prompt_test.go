@@ -0,0 +1,40 @@
+package agentx
+
+import (
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	got := RenderPrompt("Hi {{user_name}} of {{ tenant_name }}; {{unknown}}", map[string]string{
+		"user_name":   "Ada",
+		"tenant_name": "Acme",
+	})
+	if want := "Hi Ada of Acme; {{unknown}}"; got != want {
+		t.Errorf("RenderPrompt = %q, want %q", got, want)
+	}
+}
+
+func TestSystemPromptSurvivesClear(t *testing.T) {
+	memory := memoryx.NewInMemoryMemory("memory prompt")
+	agent := New(*llm.NewClient(nil), memory,
+		WithSystemPrompt("Assist {{user_name}}"),
+		WithPromptVariables(map[string]string{"user_name": "Ada"}),
+	)
+
+	agent.AddMessage(llm.NewUserMessage("hello"))
+	msgs, _ := agent.Messages()
+	if len(msgs) != 2 || msgs[0].Role != llm.RoleSystem || msgs[0].Content != "Assist Ada" {
+		t.Fatalf("messages = %+v, want the rendered prompt first", msgs)
+	}
+
+	if err := agent.ClearMemory(); err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = agent.Messages()
+	if len(msgs) != 1 || msgs[0].Content != "Assist Ada" || agent.SystemPrompt() != "Assist Ada" {
+		t.Fatalf("messages after ClearMemory = %+v, want only the system prompt", msgs)
+	}
+}
@@ -0,0 +1,172 @@
+package agentx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Concurrent run limits
+// ============================================================================
+
+// RunLimiter bounds how many agent runs may execute at once per key (usually
+// the tenant ID). Callers acquire a slot before Run/RunStream/EvaluateWithTools
+// and release it when the run ends:
+//
+//	release, err := limiter.Acquire(ctx, tenantID.String())
+//	if err != nil {
+//		return err // 429 with retry_after_seconds when the tenant is at its limit
+//	}
+//	defer release()
+type RunLimiter interface {
+	Acquire(ctx context.Context, key string) (release func(), err error)
+}
+
+// LimitFunc returns the maximum concurrent runs for a key. A limit <= 0 means
+// unlimited.
+type LimitFunc func(ctx context.Context, key string) (int, error)
+
+// StaticLimit applies the same limit to every key
+func StaticLimit(limit int) LimitFunc {
+	return func(context.Context, string) (int, error) {
+		return limit, nil
+	}
+}
+
+// RunLimiterOption configures run limiters
+type RunLimiterOption func(*RunLimiterConfig)
+
+// RunLimiterConfig holds the settings shared by RunLimiter implementations
+type RunLimiterConfig struct {
+	// WaitTimeout queues Acquire for up to this long when no slot is free.
+	// Zero rejects immediately.
+	WaitTimeout time.Duration
+
+	// PollInterval is how often a queued Acquire retries
+	PollInterval time.Duration
+
+	// RetryAfter is the hint returned to rejected callers
+	RetryAfter time.Duration
+}
+
+// NewRunLimiterConfig applies opts over the defaults
+func NewRunLimiterConfig(opts ...RunLimiterOption) RunLimiterConfig {
+	cfg := RunLimiterConfig{
+		PollInterval: 500 * time.Millisecond,
+		RetryAfter:   5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithWaitTimeout queues callers for up to timeout instead of rejecting them
+func WithWaitTimeout(timeout time.Duration) RunLimiterOption {
+	return func(c *RunLimiterConfig) {
+		c.WaitTimeout = timeout
+	}
+}
+
+// WithPollInterval sets how often a queued Acquire retries
+func WithPollInterval(interval time.Duration) RunLimiterOption {
+	return func(c *RunLimiterConfig) {
+		if interval > 0 {
+			c.PollInterval = interval
+		}
+	}
+}
+
+// WithRetryAfter sets the Retry-After hint returned to rejected callers
+func WithRetryAfter(retryAfter time.Duration) RunLimiterOption {
+	return func(c *RunLimiterConfig) {
+		if retryAfter > 0 {
+			c.RetryAfter = retryAfter
+		}
+	}
+}
+
+// AcquireWithRetry calls try until it gets a slot, ctx ends, or cfg.WaitTimeout
+// elapses. try returns ok=false when the key is at its limit. Shared by the
+// RunLimiter implementations.
+func AcquireWithRetry(ctx context.Context, cfg RunLimiterConfig, key string, limit int, try func() (bool, error)) error {
+	var deadline time.Time
+	if cfg.WaitTimeout > 0 {
+		deadline = time.Now().Add(cfg.WaitTimeout)
+	}
+
+	for {
+		ok, err := try()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		if deadline.IsZero() || time.Now().Add(cfg.PollInterval).After(deadline) {
+			return NewTooManyConcurrentRunsError(key, limit, cfg.RetryAfter)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cfg.PollInterval):
+		}
+	}
+}
+
+// LocalRunLimiter is an in-process RunLimiter for single-instance deployments.
+// Use agentxredis.RunLimiter when running several instances.
+type LocalRunLimiter struct {
+	limit LimitFunc
+	cfg   RunLimiterConfig
+
+	mu     sync.Mutex
+	active map[string]int
+}
+
+// NewLocalRunLimiter creates an in-process limiter
+func NewLocalRunLimiter(limit LimitFunc, opts ...RunLimiterOption) *LocalRunLimiter {
+	return &LocalRunLimiter{
+		limit:  limit,
+		cfg:    NewRunLimiterConfig(opts...),
+		active: make(map[string]int),
+	}
+}
+
+// Acquire takes a run slot for key
+func (l *LocalRunLimiter) Acquire(ctx context.Context, key string) (func(), error) {
+	limit, err := l.limit(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	err = AcquireWithRetry(ctx, l.cfg, key, limit, func() (bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.active[key] >= limit {
+			return false, nil
+		}
+		l.active[key]++
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.active[key]--; l.active[key] <= 0 {
+				delete(l.active, key)
+			}
+		})
+	}, nil
+}
@@ -0,0 +1,107 @@
+package agentx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+func TestLocalRunLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLocalRunLimiter(StaticLimit(2), WithRetryAfter(3*time.Second))
+
+	release1, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = limiter.Acquire(ctx, "t1")
+	if !errx.Is(err, ErrTooManyConcurrentRuns) {
+		t.Fatalf("third Acquire = %v, want TOO_MANY_CONCURRENT_RUNS", err)
+	}
+	if retryAfter, ok := RetryAfter(err); !ok || retryAfter != 3*time.Second {
+		t.Errorf("RetryAfter = %s, %v; want 3s", retryAfter, ok)
+	}
+
+	// Keys are limited independently
+	if release, err := limiter.Acquire(ctx, "t2"); err != nil {
+		t.Fatalf("Acquire for another key = %v", err)
+	} else {
+		release()
+	}
+
+	// Releasing twice frees a single slot
+	release1()
+	release1()
+	release3, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Acquire after release = %v", err)
+	}
+	if _, err := limiter.Acquire(ctx, "t1"); !errx.Is(err, ErrTooManyConcurrentRuns) {
+		t.Fatalf("Acquire after a double release = %v, want the key still at its limit", err)
+	}
+
+	release2()
+	release3()
+	if len(limiter.active) != 0 {
+		t.Errorf("active = %v, want released keys dropped", limiter.active)
+	}
+}
+
+func TestLocalRunLimiterWaits(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLocalRunLimiter(StaticLimit(1), WithWaitTimeout(time.Second), WithPollInterval(5*time.Millisecond))
+
+	release, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(20*time.Millisecond, release)
+
+	start := time.Now()
+	next, err := limiter.Acquire(ctx, "t1")
+	if err != nil {
+		t.Fatalf("queued Acquire = %v, want the released slot", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("queued Acquire returned after %s, before the slot was released", elapsed)
+	}
+
+	// A cancelled caller stops waiting
+	cancelled, cancel := context.WithCancel(ctx)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := limiter.Acquire(cancelled, "t1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Acquire = %v, want context.Canceled", err)
+	}
+	next()
+}
+
+func TestLocalRunLimiterLimitFunc(t *testing.T) {
+	ctx := context.Background()
+	lookupErr := errors.New("tenant store down")
+	limiter := NewLocalRunLimiter(func(_ context.Context, key string) (int, error) {
+		if key == "broken" {
+			return 0, lookupErr
+		}
+		return 0, nil // Unlimited
+	})
+
+	for range 5 {
+		if _, err := limiter.Acquire(ctx, "t1"); err != nil {
+			t.Fatalf("unlimited Acquire = %v", err)
+		}
+	}
+	if len(limiter.active) != 0 {
+		t.Errorf("unlimited runs were counted: %v", limiter.active)
+	}
+	if _, err := limiter.Acquire(ctx, "broken"); !errors.Is(err, lookupErr) {
+		t.Errorf("Acquire with a failing limit = %v, want the lookup error", err)
+	}
+}
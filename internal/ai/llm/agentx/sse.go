@@ -0,0 +1,88 @@
+package agentx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// sseEvent is the JSON payload written for every StreamEvent
+type sseEvent struct {
+	Type       StreamEventType `json:"type"`
+	Step       int             `json:"step"`
+	Content    string          `json:"content,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	ToolName   string          `json:"tool_name,omitempty"`
+	ToolInput  string          `json:"tool_input,omitempty"`
+	ToolOutput string          `json:"tool_output,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Usage      *llm.Usage      `json:"usage,omitempty"`
+}
+
+// SSEWriter turns StreamEvents into Server-Sent Events. Each event is written
+// as "event: <type>" followed by a JSON "data:" line, and flushed right away
+// when the writer supports it (e.g. the *bufio.Writer of fiber's
+// SetBodyStreamWriter).
+type SSEWriter struct {
+	w   io.Writer
+	err error
+}
+
+// NewSSEWriter creates an SSEWriter over w
+func NewSSEWriter(w io.Writer) *SSEWriter {
+	return &SSEWriter{w: w}
+}
+
+// Handler returns a StreamHandler to pass to StreamWithTools
+func (s *SSEWriter) Handler() StreamHandler {
+	return func(event StreamEvent) {
+		s.Write(event)
+	}
+}
+
+// Write sends a single event. After the first failure (typically the client
+// disconnected) further events are dropped; check Err.
+func (s *SSEWriter) Write(event StreamEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	payload := sseEvent{
+		Type:       event.Type,
+		Step:       event.Step,
+		Content:    event.Content,
+		ToolCallID: event.ToolCallID,
+		ToolName:   event.ToolName,
+		ToolInput:  event.ToolInput,
+		ToolOutput: event.ToolOutput,
+		Usage:      event.Usage,
+	}
+	if event.Err != nil {
+		payload.Error = event.Err.Error()
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		s.err = fmt.Errorf("failed to marshal stream event: %w", err)
+		return s.err
+	}
+
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		s.err = err
+		return s.err
+	}
+
+	if f, ok := s.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			s.err = err
+		}
+	}
+	return s.err
+}
+
+// Err returns the first write error, if any
+func (s *SSEWriter) Err() error {
+	return s.err
+}
@@ -0,0 +1,41 @@
+package agentx
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceToolRound runs fn, which executes one round of tool calls, inside an
+// "agentx.tools" span. Each tool started through callTool nests under it.
+func traceToolRound(ctx context.Context, step int, toolCalls []llm.ToolCall, fn func(ctx context.Context) error) error {
+	if !tracex.Enabled() {
+		return fn(ctx)
+	}
+
+	ctx, span := tracex.Start(ctx, "agentx.tools", trace.WithAttributes(
+		attribute.Int("agentx.step", step),
+		attribute.Int("agentx.tool_calls", len(toolCalls)),
+	))
+	err := fn(ctx)
+	tracex.End(span, err)
+	return err
+}
+
+// callTool executes a single tool call inside its own span
+func (a *Agent) callTool(ctx context.Context, tc llm.ToolCall) (llm.Message, error) {
+	if !tracex.Enabled() {
+		return a.invokeTool(ctx, tc)
+	}
+
+	ctx, span := tracex.Start(ctx, "agentx.tool "+tc.Function.Name, trace.WithAttributes(
+		attribute.String("agentx.tool.name", tc.Function.Name),
+		attribute.String("agentx.tool.call_id", tc.ID),
+	))
+	msg, err := a.invokeTool(ctx, tc)
+	tracex.End(span, err)
+	return msg, err
+}
@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+// Provider-independent error codes. Providers translate their API errors into
+// these so callers can branch on the failure without knowing the provider;
+// the provider error stays wrapped as the cause.
+var (
+	errorRegistry = errx.NewRegistry("LLM")
+
+	ErrLLMInvalidAPIKey = errorRegistry.Register(
+		"INVALID_API_KEY",
+		errx.TypeAuthorization,
+		http.StatusUnauthorized,
+		"Invalid or missing provider API key",
+	)
+
+	ErrLLMPermissionDenied = errorRegistry.Register(
+		"PERMISSION_DENIED",
+		errx.TypeAuthorization,
+		http.StatusForbidden,
+		"The API key has no access to this resource",
+	)
+
+	ErrLLMRateLimited = errorRegistry.Register(
+		"RATE_LIMITED",
+		errx.TypeExternal,
+		http.StatusTooManyRequests,
+		"Provider rate limit exceeded",
+	)
+
+	ErrLLMQuotaExceeded = errorRegistry.Register(
+		"QUOTA_EXCEEDED",
+		errx.TypeExternal,
+		http.StatusPaymentRequired,
+		"Provider quota or billing limit exceeded",
+	)
+
+	ErrLLMContextLengthExceeded = errorRegistry.Register(
+		"CONTEXT_LENGTH_EXCEEDED",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Input exceeds the model's context length",
+	)
+
+	ErrLLMContentFiltered = errorRegistry.Register(
+		"CONTENT_FILTERED",
+		errx.TypeValidation,
+		http.StatusUnprocessableEntity,
+		"Request or response was blocked by the provider's content policy",
+	)
+
+	ErrLLMModelNotFound = errorRegistry.Register(
+		"MODEL_NOT_FOUND",
+		errx.TypeValidation,
+		http.StatusNotFound,
+		"Model not found or not accessible",
+	)
+
+	ErrLLMInvalidRequest = errorRegistry.Register(
+		"INVALID_REQUEST",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Provider rejected the request parameters",
+	)
+
+	ErrLLMProviderUnavailable = errorRegistry.Register(
+		"PROVIDER_UNAVAILABLE",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Provider is unavailable",
+	)
+
+	ErrRateLimitExhausted = errorRegistry.Register(
+		"RATE_LIMIT_EXHAUSTED",
+		errx.TypeExternal,
+		http.StatusTooManyRequests,
+		"Provider rate limit still exceeded after retrying",
+	)
+
+	ErrRetriesExhausted = errorRegistry.Register(
+		"RETRIES_EXHAUSTED",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Provider request kept failing after retrying",
+	)
+)
+
+// newExhaustedError builds the error returned by Retry when the last attempt
+// still failed with a retryable error
+func newExhaustedError(class RetryClass, attempts int, retryAfter time.Duration, cause error) *errx.Error {
+	code := ErrRetriesExhausted
+	if class == RetryRateLimited {
+		code = ErrRateLimitExhausted
+	}
+
+	err := errorRegistry.NewWithCause(code, cause).WithDetail("attempts", attempts)
+	if retryAfter > 0 {
+		err.WithDetail("retry_after_seconds", int(math.Ceil(retryAfter.Seconds())))
+	}
+	return err
+}
+
+// NewError builds an llm error of code wrapping the provider error
+func NewError(code *errx.ErrorCode, cause error) *errx.Error {
+	return errorRegistry.NewWithCause(code, cause)
+}
+
+// HasCode reports whether err, or an error it wraps, has the llm code. Unlike
+// a plain errx.As it looks past wrapping errx errors, e.g. a
+// RETRIES_EXHAUSTED wrapping PROVIDER_UNAVAILABLE.
+func HasCode(err error, code *errx.ErrorCode) bool {
+	return errors.Is(err, code)
+}
+
+// IsRateLimited reports whether err is a rate limit, whether or not retries
+// were attempted
+func IsRateLimited(err error) bool {
+	return HasCode(err, ErrLLMRateLimited) || HasCode(err, ErrRateLimitExhausted)
+}
+
+// IsInvalidAPIKey reports whether the provider rejected the API key
+func IsInvalidAPIKey(err error) bool {
+	return HasCode(err, ErrLLMInvalidAPIKey)
+}
+
+// IsContextLengthExceeded reports whether the input was too long for the model
+func IsContextLengthExceeded(err error) bool {
+	return HasCode(err, ErrLLMContextLengthExceeded)
+}
+
+// IsContentFiltered reports whether the provider's content policy blocked the call
+func IsContentFiltered(err error) bool {
+	return HasCode(err, ErrLLMContentFiltered)
+}
+
+// IsRateLimitExhausted reports whether err is a RATE_LIMIT_EXHAUSTED error
+func IsRateLimitExhausted(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == ErrRateLimitExhausted.Code
+}
+
+// IsRetriesExhausted reports whether err is a RETRIES_EXHAUSTED error
+func IsRetriesExhausted(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == ErrRetriesExhausted.Code
+}
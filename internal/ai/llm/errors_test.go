@@ -0,0 +1,28 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHasCodeLooksThroughExhaustion(t *testing.T) {
+	sdkErr := errors.New("429 Too Many Requests")
+	rateLimited := NewError(ErrLLMRateLimited, sdkErr)
+	exhausted := newExhaustedError(RetryRateLimited, 3, 0, rateLimited)
+
+	if !IsRateLimited(rateLimited) || !IsRateLimited(exhausted) {
+		t.Error("IsRateLimited should match both the translated and the exhausted error")
+	}
+	if !HasCode(exhausted, ErrLLMRateLimited) {
+		t.Error("HasCode should find the wrapped LLM_RATE_LIMITED")
+	}
+	if IsContextLengthExceeded(exhausted) {
+		t.Error("IsContextLengthExceeded matched a rate limit error")
+	}
+	if !errors.Is(exhausted, sdkErr) {
+		t.Error("the provider error should stay wrapped")
+	}
+	if HasCode(sdkErr, ErrLLMRateLimited) {
+		t.Error("HasCode matched a plain error")
+	}
+}
@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+)
+
+// errNoFallbackTargets is returned by a FallbackProvider without targets
+var errNoFallbackTargets = NewError(ErrLLMProviderUnavailable, errors.New("fallback provider has no targets"))
+
+// FallbackTarget is one provider in a FallbackProvider chain
+type FallbackTarget struct {
+	// Name identifies the provider in Response.Provider and FallbackStream.Provider
+	Name string
+
+	// LLM is the provider
+	LLM LLM
+
+	// Options are applied after the caller's options, so each provider can set
+	// its own model (e.g. WithModel("claude-sonnet-4-5") for an Anthropic
+	// fallback of a gpt-4o primary)
+	Options []Option
+}
+
+// FallbackOption configures a FallbackProvider
+type FallbackOption func(*FallbackProvider)
+
+// WithFallbackCondition replaces the check deciding whether an error moves
+// the call to the next provider (default ShouldFallback)
+func WithFallbackCondition(shouldFallback func(error) bool) FallbackOption {
+	return func(f *FallbackProvider) {
+		f.shouldFallback = shouldFallback
+	}
+}
+
+// WithFallbackHook is called every time a provider fails and the call moves
+// to the next one, e.g. to log or count outages
+func WithFallbackHook(hook func(ctx context.Context, failed string, err error)) FallbackOption {
+	return func(f *FallbackProvider) {
+		f.onFallback = hook
+	}
+}
+
+// FallbackProvider is an LLM that tries an ordered list of providers. When a
+// provider fails with a retriable error (rate limit, 5xx, timeout, network
+// error) the same messages and options are sent to the next one; any other
+// error is returned right away. When every provider fails, the last error is
+// returned.
+//
+// Providers keep their own retry policies: the next provider is only tried
+// once the current one has given up.
+//
+//	client := llm.NewClient(llm.NewFallbackProvider([]llm.FallbackTarget{
+//		{Name: "openai", LLM: openaiProvider, Options: []llm.Option{llm.WithModel("gpt-4o")}},
+//		{Name: "anthropic", LLM: anthropicProvider, Options: []llm.Option{llm.WithModel("claude-sonnet-4-5")}},
+//	}))
+type FallbackProvider struct {
+	targets        []FallbackTarget
+	shouldFallback func(error) bool
+	onFallback     func(ctx context.Context, failed string, err error)
+}
+
+// NewFallbackProvider creates a provider trying targets in order
+func NewFallbackProvider(targets []FallbackTarget, opts ...FallbackOption) *FallbackProvider {
+	f := &FallbackProvider{
+		targets:        targets,
+		shouldFallback: ShouldFallback,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// ShouldFallback reports whether err is worth retrying on another provider:
+// rate limits, provider unavailability (5xx), exhausted retries, timeouts and
+// network errors. Invalid requests, auth failures and content filtering would
+// fail the same way elsewhere, or point at a bug, so they are not.
+func ShouldFallback(err error) bool {
+	if IsRateLimited(err) ||
+		HasCode(err, ErrLLMProviderUnavailable) ||
+		HasCode(err, ErrRetriesExhausted) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Chat implements LLM. Response.Provider is the name of the target that answered.
+func (f *FallbackProvider) Chat(ctx context.Context, messages []Message, opts ...Option) (Response, error) {
+	var lastErr error
+	for i, target := range f.targets {
+		response, err := target.LLM.Chat(ctx, messages, target.options(opts)...)
+		if err == nil {
+			response.Provider = target.Name
+			return response, nil
+		}
+
+		lastErr = err
+		if !f.next(ctx, i, target, err) {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = errNoFallbackTargets
+	}
+	return Response{}, lastErr
+}
+
+// ChatStream implements LLM. The returned stream is a *FallbackStream.
+//
+// Most providers report request errors on the first Next rather than from
+// ChatStream, so the first chunk is read here: a retriable failure before any
+// content was produced still moves to the next provider. Errors after the
+// first chunk are returned by Next as usual.
+func (f *FallbackProvider) ChatStream(ctx context.Context, messages []Message, opts ...Option) (Stream, error) {
+	var lastErr error
+	for i, target := range f.targets {
+		stream, err := target.LLM.ChatStream(ctx, messages, target.options(opts)...)
+		if err == nil {
+			first, nextErr := stream.Next()
+			if nextErr == nil || errors.Is(nextErr, io.EOF) {
+				return &FallbackStream{Stream: stream, Provider: target.Name, first: &first, firstErr: nextErr}, nil
+			}
+			stream.Close()
+			err = nextErr
+		}
+
+		lastErr = err
+		if !f.next(ctx, i, target, err) {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = errNoFallbackTargets
+	}
+	return nil, lastErr
+}
+
+// next reports whether the call moves on from target i after err
+func (f *FallbackProvider) next(ctx context.Context, i int, target FallbackTarget, err error) bool {
+	// The caller gave up: another provider would fail the same way
+	if ctx.Err() != nil || i == len(f.targets)-1 || !f.shouldFallback(err) {
+		return false
+	}
+	if f.onFallback != nil {
+		f.onFallback(ctx, target.Name, err)
+	}
+	return true
+}
+
+// options appends the target's options to the caller's
+func (t FallbackTarget) options(opts []Option) []Option {
+	if len(t.Options) == 0 {
+		return opts
+	}
+	return append(append([]Option(nil), opts...), t.Options...)
+}
+
+// FallbackStream is the Stream returned by FallbackProvider.ChatStream
+type FallbackStream struct {
+	Stream
+
+	// Provider is the name of the target serving the stream
+	Provider string
+
+	first    *Message
+	firstErr error
+}
+
+// Next returns the chunk read while choosing the provider, then the rest of
+// the stream
+func (s *FallbackStream) Next() (Message, error) {
+	if s.first != nil {
+		first, err := *s.first, s.firstErr
+		s.first = nil
+		return first, err
+	}
+	return s.Stream.Next()
+}
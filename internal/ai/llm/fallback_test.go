@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// scriptedLLM fails with err, or answers with its name
+type scriptedLLM struct {
+	name      string
+	err       error
+	calls     int
+	lastModel string
+}
+
+func (s *scriptedLLM) Chat(_ context.Context, _ []Message, opts ...Option) (Response, error) {
+	s.calls++
+	var o ChatOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s.lastModel = o.Model
+	if s.err != nil {
+		return Response{}, s.err
+	}
+	return Response{Message: NewAssistantMessage(s.name)}, nil
+}
+
+func (s *scriptedLLM) ChatStream(context.Context, []Message, ...Option) (Stream, error) {
+	s.calls++
+	return &scriptedStream{chunks: []string{s.name}, err: s.err}, nil
+}
+
+// scriptedStream fails on the first Next with err, or yields chunks
+type scriptedStream struct {
+	chunks []string
+	err    error
+}
+
+func (s *scriptedStream) Next() (Message, error) {
+	if s.err != nil {
+		return Message{}, s.err
+	}
+	if len(s.chunks) == 0 {
+		return Message{}, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return Message{Content: chunk}, nil
+}
+
+func (s *scriptedStream) Close() error { return nil }
+
+func TestFallbackProviderChat(t *testing.T) {
+	primary := &scriptedLLM{name: "primary", err: NewError(ErrLLMRateLimited, errors.New("429"))}
+	secondary := &scriptedLLM{name: "secondary"}
+	var fellBack string
+
+	provider := NewFallbackProvider([]FallbackTarget{
+		{Name: "primary", LLM: primary},
+		{Name: "secondary", LLM: secondary, Options: []Option{WithModel("secondary-model")}},
+	}, WithFallbackHook(func(_ context.Context, failed string, _ error) { fellBack = failed }))
+
+	response, err := provider.Chat(context.Background(), []Message{NewUserMessage("hi")}, WithModel("primary-model"))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if response.Provider != "secondary" || response.Message.Content != "secondary" {
+		t.Errorf("served by %q: %q", response.Provider, response.Message.Content)
+	}
+	if primary.lastModel != "primary-model" || secondary.lastModel != "secondary-model" {
+		t.Errorf("models: primary %q, secondary %q", primary.lastModel, secondary.lastModel)
+	}
+	if fellBack != "primary" {
+		t.Errorf("hook got %q", fellBack)
+	}
+}
+
+func TestFallbackProviderReturnsNonRetriableErrors(t *testing.T) {
+	invalid := NewError(ErrLLMInvalidRequest, errors.New("400"))
+	secondary := &scriptedLLM{name: "secondary"}
+
+	provider := NewFallbackProvider([]FallbackTarget{
+		{Name: "primary", LLM: &scriptedLLM{err: invalid}},
+		{Name: "secondary", LLM: secondary},
+	})
+
+	if _, err := provider.Chat(context.Background(), nil); !HasCode(err, ErrLLMInvalidRequest) {
+		t.Errorf("err = %v, want INVALID_REQUEST", err)
+	}
+	if secondary.calls != 0 {
+		t.Error("fell back on a non-retriable error")
+	}
+}
+
+func TestFallbackProviderChatStream(t *testing.T) {
+	provider := NewFallbackProvider([]FallbackTarget{
+		{Name: "primary", LLM: &scriptedLLM{err: NewError(ErrLLMProviderUnavailable, errors.New("503"))}},
+		{Name: "secondary", LLM: &scriptedLLM{name: "secondary"}},
+	})
+
+	stream, err := provider.ChatStream(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if served := stream.(*FallbackStream).Provider; served != "secondary" {
+		t.Errorf("served by %q", served)
+	}
+
+	chunk, err := stream.Next()
+	if err != nil || chunk.Content != "secondary" {
+		t.Fatalf("first chunk = %q, %v", chunk.Content, err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want EOF", err)
+	}
+}
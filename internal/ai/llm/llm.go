@@ -17,11 +17,17 @@ type LLM interface {
 type Response struct {
 	Message Message
 	Usage   Usage
+
+	// Provider names the provider that served the response when the call went
+	// through a FallbackProvider; empty otherwise
+	Provider string
 }
 
 // Stream represents a streaming response
 type Stream interface {
-	// Next returns the next chunk of the stream
+	// Next returns the next chunk of the stream.
+	// Content holds the incremental text delta, while ToolCalls holds every
+	// tool call accumulated so far in the response, ordered by index.
 	// Returns io.EOF when the stream is complete
 	Next() (Message, error)
 
@@ -29,6 +35,16 @@ type Stream interface {
 	Close() error
 }
 
+// UsageStream is implemented by streams that learn the token usage of the
+// response from the provider, e.g. OpenAI's response.completed event
+type UsageStream interface {
+	Stream
+
+	// Usage returns the usage of the response; it is zero until the stream
+	// reached its end
+	Usage() Usage
+}
+
 // Client represents a configured LLM client
 type Client struct {
 	llm LLM
@@ -0,0 +1,130 @@
+package llmtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+func weatherMock() *MockProvider {
+	return NewMockProvider().
+		On(LastMessageContains("weather"), CallTool("get_weather", map[string]any{"city": "Lima"})).
+		On(LastRole(llm.RoleTool), Reply("It is sunny in Lima."))
+}
+
+func readAll(t *testing.T, stream llm.Stream) (string, []llm.ToolCall) {
+	t.Helper()
+	var content strings.Builder
+	var toolCalls []llm.ToolCall
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			return content.String(), toolCalls
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		content.WriteString(chunk.Content)
+		if len(chunk.ToolCalls) > 0 {
+			toolCalls = chunk.ToolCalls
+		}
+	}
+}
+
+func TestMockProviderScriptsToolLoop(t *testing.T) {
+	ctx := context.Background()
+	mock := weatherMock()
+
+	messages := []llm.Message{llm.NewUserMessage("What is the weather in Lima?")}
+	first, err := mock.Chat(ctx, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := first.Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Function.Arguments != `{"city":"Lima"}` {
+		t.Fatalf("tool calls = %+v", calls)
+	}
+	if first.Usage.TotalTokens == 0 {
+		t.Error("expected estimated usage")
+	}
+
+	messages = append(messages, first.Message, llm.Message{Role: llm.RoleTool, ToolCallID: calls[0].ID, Content: "sunny"})
+	second, err := mock.Chat(ctx, messages)
+	if err != nil || second.Message.Content != "It is sunny in Lima." {
+		t.Fatalf("second = %+v, %v", second.Message, err)
+	}
+
+	if _, err := mock.Chat(ctx, []llm.Message{llm.NewUserMessage("hello")}); !errors.Is(err, ErrUnexpectedCall) {
+		t.Errorf("unmatched call error = %v, want ErrUnexpectedCall", err)
+	}
+	if got := len(mock.Calls()); got != 3 {
+		t.Errorf("recorded %d calls, want 3", got)
+	}
+}
+
+func TestMockProviderStreamsWords(t *testing.T) {
+	mock := NewMockProvider().On(Any(), Reply("one two three"))
+
+	stream, err := mock.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("count")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := stream.Next()
+	if first.Content != "one " {
+		t.Errorf("first chunk = %q, want a single word", first.Content)
+	}
+	if rest, _ := readAll(t, stream); first.Content+rest != "one two three" {
+		t.Errorf("content = %q", first.Content+rest)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "fixtures", "weather.json")
+	question := []llm.Message{llm.NewUserMessage("What is the weather in Lima?")}
+
+	recorder := NewRecorder(weatherMock())
+	recorded, err := recorder.Chat(ctx, question, llm.WithModel("gpt-4.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := recorder.ChatStream(ctx, question)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readAll(t, stream)
+	if err := recorder.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := replayer.Chat(ctx, question, llm.WithModel("gpt-4.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Message.ToolCalls[0].Function.Arguments != recorded.Message.ToolCalls[0].Function.Arguments ||
+		replayed.Usage != recorded.Usage {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+
+	stream, err = replayer.ChatStream(ctx, question)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, toolCalls := readAll(t, stream); len(toolCalls) != 1 || toolCalls[0].Function.Name != "get_weather" {
+		t.Errorf("replayed stream tool calls = %+v", toolCalls)
+	}
+
+	// Another model is another request
+	if _, err := replayer.Chat(ctx, question, llm.WithModel("gpt-4o")); !errors.Is(err, ErrUnexpectedCall) {
+		t.Errorf("unrecorded request error = %v, want ErrUnexpectedCall", err)
+	}
+}
@@ -0,0 +1,285 @@
+// Package llmtest runs code built on llm.LLM offline and deterministically.
+//
+// [MockProvider] answers with scripted responses chosen by the input:
+//
+//	mock := llmtest.NewMockProvider().
+//		On(llmtest.LastMessageContains("weather"), llmtest.CallTool("get_weather", map[string]any{"city": "Lima"})).
+//		On(llmtest.LastRole(llm.RoleTool), llmtest.Reply("It is sunny in Lima."))
+//	agent := agentx.New(*llm.NewClient(mock), memory, agentx.WithTools(tools))
+//
+// [Recorder] captures the interactions of a real provider to a fixture file
+// and [Replayer] serves them back; [Fixture] picks one of them from the
+// LLMTEST_RECORD environment variable, so a test records once against the
+// API and replays offline afterwards.
+package llmtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// ErrUnexpectedCall is returned for calls no rule or recording answers
+var ErrUnexpectedCall = errors.New("llmtest: unexpected call")
+
+// Matcher decides whether a rule answers a call
+type Matcher func(messages []llm.Message, options llm.ChatOptions) bool
+
+// Any matches every call
+func Any() Matcher {
+	return func([]llm.Message, llm.ChatOptions) bool { return true }
+}
+
+// LastMessageContains matches calls whose last message contains substr
+func LastMessageContains(substr string) Matcher {
+	return func(messages []llm.Message, _ llm.ChatOptions) bool {
+		return len(messages) > 0 && strings.Contains(messages[len(messages)-1].TextContent(), substr)
+	}
+}
+
+// LastRole matches calls whose last message has role, e.g. llm.RoleTool for
+// the turn after a tool ran
+func LastRole(role string) Matcher {
+	return func(messages []llm.Message, _ llm.ChatOptions) bool {
+		return len(messages) > 0 && messages[len(messages)-1].Role == role
+	}
+}
+
+// Model matches calls made with model
+func Model(model string) Matcher {
+	return func(_ []llm.Message, options llm.ChatOptions) bool {
+		return options.Model == model
+	}
+}
+
+// Reply is an assistant response with content
+func Reply(content string) llm.Response {
+	return llm.Response{Message: llm.Message{Role: llm.RoleAssistant, Content: content}}
+}
+
+// CallTool is an assistant response calling tool name with args, marshalled
+// to JSON unless it is already a string. The call ID is assigned when the
+// response is served ("call_1", "call_2", ...), so it is stable across runs.
+func CallTool(name string, args any) llm.Response {
+	arguments, ok := args.(string)
+	if !ok {
+		data, err := json.Marshal(args)
+		if err != nil {
+			panic(fmt.Sprintf("llmtest: cannot marshal arguments of %s: %v", name, err))
+		}
+		arguments = string(data)
+	}
+	return llm.Response{Message: llm.Message{
+		Role: llm.RoleAssistant,
+		ToolCalls: []llm.ToolCall{{
+			Type:     "function",
+			Function: llm.FunctionCall{Name: name, Arguments: arguments},
+		}},
+	}}
+}
+
+// Call is a call received by a MockProvider
+type Call struct {
+	Messages []llm.Message
+	Options  llm.ChatOptions
+	Stream   bool
+}
+
+type rule struct {
+	match     Matcher
+	responses []llm.Response
+	err       error
+	served    int
+}
+
+// MockProvider is an llm.LLM answering with scripted responses. Rules are
+// tried in the order they were added and the first match answers; a rule
+// with several responses serves them in order and repeats the last one.
+//
+// Responses without Usage get a deterministic estimate (1 token per 4
+// characters), so usage accounting can be tested too. ChatStream sends the
+// content word by word and the tool calls with the last chunk, as providers
+// do.
+type MockProvider struct {
+	mu     sync.Mutex
+	rules  []*rule
+	calls  []Call
+	nextID int
+}
+
+var _ llm.LLM = (*MockProvider)(nil)
+
+// NewMockProvider creates a mock without rules; every call fails with
+// ErrUnexpectedCall until rules are added
+func NewMockProvider() *MockProvider {
+	return &MockProvider{}
+}
+
+// On answers calls matching match with responses
+func (m *MockProvider) On(match Matcher, responses ...llm.Response) *MockProvider {
+	if len(responses) == 0 {
+		panic("llmtest: On needs at least one response")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, &rule{match: match, responses: responses})
+	return m
+}
+
+// OnError fails calls matching match with err, e.g. to test fallbacks and
+// retries
+func (m *MockProvider) OnError(match Matcher, err error) *MockProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, &rule{match: match, err: err})
+	return m
+}
+
+// Calls returns the calls received so far
+func (m *MockProvider) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+func (m *MockProvider) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return llm.Response{}, err
+	}
+	return m.respond(messages, opts, false)
+}
+
+func (m *MockProvider) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	response, err := m.respond(messages, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(streamChunks(response.Message)...), nil
+}
+
+func (m *MockProvider) respond(messages []llm.Message, opts []llm.Option, stream bool) (llm.Response, error) {
+	var options llm.ChatOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Messages: append([]llm.Message(nil), messages...), Options: options, Stream: stream})
+
+	for _, r := range m.rules {
+		if !r.match(messages, options) {
+			continue
+		}
+		if r.err != nil {
+			return llm.Response{}, r.err
+		}
+
+		response := r.responses[min(r.served, len(r.responses)-1)]
+		r.served++
+		return m.complete(response, messages), nil
+	}
+
+	last := ""
+	if len(messages) > 0 {
+		last = messages[len(messages)-1].TextContent()
+	}
+	return llm.Response{}, fmt.Errorf("%w: no rule matches last message %q", ErrUnexpectedCall, last)
+}
+
+// complete assigns tool call IDs and estimates usage. Callers hold m.mu.
+func (m *MockProvider) complete(response llm.Response, messages []llm.Message) llm.Response {
+	message := response.Message
+	if len(message.ToolCalls) > 0 {
+		toolCalls := make([]llm.ToolCall, len(message.ToolCalls))
+		for i, tc := range message.ToolCalls {
+			if tc.ID == "" {
+				m.nextID++
+				tc.ID = fmt.Sprintf("call_%d", m.nextID)
+			}
+			toolCalls[i] = tc
+		}
+		message.ToolCalls = toolCalls
+	}
+	response.Message = message
+
+	if response.Usage == (llm.Usage{}) {
+		prompt := 0
+		for _, msg := range messages {
+			prompt += estimateTokens(msg.TextContent())
+		}
+		completion := estimateTokens(message.Content)
+		for _, tc := range message.ToolCalls {
+			completion += estimateTokens(tc.Function.Name + tc.Function.Arguments)
+		}
+		response.Usage = llm.Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		}
+	}
+	return response
+}
+
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// streamChunks splits a message the way providers stream it: the content in
+// word-sized deltas, the tool calls with the last chunk
+func streamChunks(message llm.Message) []llm.Message {
+	var chunks []llm.Message
+	for _, word := range strings.SplitAfter(message.Content, " ") {
+		if word != "" {
+			chunks = append(chunks, llm.Message{Role: llm.RoleAssistant, Content: word})
+		}
+	}
+	if len(message.ToolCalls) > 0 {
+		if len(chunks) == 0 {
+			chunks = append(chunks, llm.Message{Role: llm.RoleAssistant})
+		}
+		chunks[len(chunks)-1].ToolCalls = message.ToolCalls
+	}
+	return chunks
+}
+
+// Stream is an llm.Stream over fixed chunks
+type Stream struct {
+	mu     sync.Mutex
+	chunks []llm.Message
+	closed bool
+}
+
+var _ llm.Stream = (*Stream)(nil)
+
+// NewStream creates a stream returning chunks, then io.EOF
+func NewStream(chunks ...llm.Message) *Stream {
+	return &Stream{chunks: chunks}
+}
+
+func (s *Stream) Next() (llm.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || len(s.chunks) == 0 {
+		return llm.Message{}, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
@@ -0,0 +1,324 @@
+package llmtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// RecordEnv makes Fixture record against the live provider when set to any
+// non-empty value, e.g. LLMTEST_RECORD=1 go test ./...
+const RecordEnv = "LLMTEST_RECORD"
+
+// Request is the part of a call that identifies it in a fixture: the model,
+// the messages and the names of the tools offered. Other options (sampling,
+// seed) do not affect matching.
+type Request struct {
+	Model    string        `json:"model,omitempty"`
+	Messages []llm.Message `json:"messages"`
+	Tools    []string      `json:"tools,omitempty"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+// Interaction is a recorded call and its outcome: the response of Chat, the
+// chunks of ChatStream or the error
+type Interaction struct {
+	Request Request       `json:"request"`
+	Message *llm.Message  `json:"message,omitempty"`
+	Usage   llm.Usage     `json:"usage"`
+	Chunks  []llm.Message `json:"chunks,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// fixtureFile is the JSON layout of a fixture
+type fixtureFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+func newRequest(messages []llm.Message, opts []llm.Option, stream bool) Request {
+	var options llm.ChatOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	req := Request{
+		Model:    options.Model,
+		Messages: append([]llm.Message(nil), messages...),
+		Stream:   stream,
+	}
+	for _, tool := range options.Tools {
+		req.Tools = append(req.Tools, tool.Function.Name)
+	}
+	return req
+}
+
+// key identifies a request; JSON sorts map keys, so equal requests give
+// equal keys
+func (r Request) key() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("llmtest: failed to encode request: %w", err)
+	}
+	return string(data), nil
+}
+
+// ============================================================================
+// Recorder
+// ============================================================================
+
+// Recorder wraps a live llm.LLM and captures every call. Streams are
+// recorded once they have been read to the end.
+type Recorder struct {
+	llm llm.LLM
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+var _ llm.LLM = (*Recorder)(nil)
+
+// NewRecorder wraps next
+func NewRecorder(next llm.LLM) *Recorder {
+	return &Recorder{llm: next}
+}
+
+func (r *Recorder) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	req := newRequest(messages, opts, false)
+	response, err := r.llm.Chat(ctx, messages, opts...)
+
+	interaction := Interaction{Request: req}
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		interaction.Message = &response.Message
+		interaction.Usage = response.Usage
+	}
+	r.add(interaction)
+	return response, err
+}
+
+func (r *Recorder) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	req := newRequest(messages, opts, true)
+	stream, err := r.llm.ChatStream(ctx, messages, opts...)
+	if err != nil {
+		r.add(Interaction{Request: req, Error: err.Error()})
+		return nil, err
+	}
+	return &recordingStream{Stream: stream, recorder: r, request: req}, nil
+}
+
+// Interactions returns the calls recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded calls to path as a fixture, creating its
+// directory
+func (r *Recorder) Save(path string) error {
+	data, err := json.MarshalIndent(fixtureFile{Interactions: r.Interactions()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("llmtest: failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("llmtest: failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("llmtest: failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) add(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+}
+
+// recordingStream collects the chunks of a live stream
+type recordingStream struct {
+	llm.Stream
+	recorder *Recorder
+	request  Request
+	chunks   []llm.Message
+	done     bool
+}
+
+func (s *recordingStream) Next() (llm.Message, error) {
+	chunk, err := s.Stream.Next()
+	if s.done {
+		return chunk, err
+	}
+	switch {
+	case err == nil:
+		s.chunks = append(s.chunks, chunk)
+	case errors.Is(err, io.EOF):
+		s.done = true
+		s.recorder.add(Interaction{Request: s.request, Chunks: s.chunks})
+	default:
+		s.done = true
+		s.recorder.add(Interaction{Request: s.request, Chunks: s.chunks, Error: err.Error()})
+	}
+	return chunk, err
+}
+
+// ============================================================================
+// Replayer
+// ============================================================================
+
+// Replayer is an llm.LLM serving recorded interactions. A call gets the
+// recording of an identical request; requests recorded several times are
+// served in recorded order, the last one repeating. Unknown requests fail
+// with ErrUnexpectedCall, so a test whose prompts changed fails loudly
+// instead of getting a stale answer.
+type Replayer struct {
+	mu     sync.Mutex
+	byKey  map[string][]Interaction
+	served map[string]int
+}
+
+var _ llm.LLM = (*Replayer)(nil)
+
+// NewReplayer serves interactions
+func NewReplayer(interactions []Interaction) (*Replayer, error) {
+	r := &Replayer{
+		byKey:  make(map[string][]Interaction),
+		served: make(map[string]int),
+	}
+	for _, interaction := range interactions {
+		key, err := interaction.Request.key()
+		if err != nil {
+			return nil, err
+		}
+		r.byKey[key] = append(r.byKey[key], interaction)
+	}
+	return r, nil
+}
+
+// LoadReplayer serves the interactions of the fixture at path
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("llmtest: failed to read fixture: %w", err)
+	}
+	var fixture fixtureFile
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("llmtest: invalid fixture %s: %w", path, err)
+	}
+	return NewReplayer(fixture.Interactions)
+}
+
+func (r *Replayer) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return llm.Response{}, err
+	}
+	interaction, err := r.next(newRequest(messages, opts, false))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	if interaction.Error != "" {
+		return llm.Response{}, errors.New(interaction.Error)
+	}
+	if interaction.Message == nil {
+		return llm.Response{}, fmt.Errorf("llmtest: recorded interaction has no response")
+	}
+	return llm.Response{Message: *interaction.Message, Usage: interaction.Usage}, nil
+}
+
+// ChatStream replays the recorded chunks. A stream that failed midway
+// replays its chunks and then fails the same way.
+func (r *Replayer) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	interaction, err := r.next(newRequest(messages, opts, true))
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" && len(interaction.Chunks) == 0 {
+		return nil, errors.New(interaction.Error)
+	}
+	stream := NewStream(interaction.Chunks...)
+	if interaction.Error != "" {
+		return &failingStream{Stream: stream, err: errors.New(interaction.Error)}, nil
+	}
+	return stream, nil
+}
+
+func (r *Replayer) next(req Request) (Interaction, error) {
+	key, err := req.key()
+	if err != nil {
+		return Interaction{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorded := r.byKey[key]
+	if len(recorded) == 0 {
+		last := ""
+		if len(req.Messages) > 0 {
+			last = req.Messages[len(req.Messages)-1].TextContent()
+		}
+		return Interaction{}, fmt.Errorf("%w: no recording for last message %q (re-record with %s=1)", ErrUnexpectedCall, last, RecordEnv)
+	}
+	i := min(r.served[key], len(recorded)-1)
+	r.served[key]++
+	return recorded[i], nil
+}
+
+// failingStream returns err where the recorded stream ended with EOF
+type failingStream struct {
+	*Stream
+	err error
+}
+
+func (s *failingStream) Next() (llm.Message, error) {
+	chunk, err := s.Stream.Next()
+	if errors.Is(err, io.EOF) {
+		return chunk, s.err
+	}
+	return chunk, err
+}
+
+// ============================================================================
+// Fixture
+// ============================================================================
+
+// Fixture returns an llm.LLM for a test backed by the fixture at path. With
+// LLMTEST_RECORD set it calls the provider built by live and writes the
+// fixture when the test ends; otherwise it replays the fixture and live is
+// not called, so the test needs neither network nor API keys.
+//
+//	model := llmtest.Fixture(t, "testdata/weather_agent.json", func() llm.LLM {
+//		return aiopenai.NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"))
+//	})
+//
+// Requests must be reproducible: keep timestamps and random IDs out of
+// prompts, or replay will not find them.
+func Fixture(t testing.TB, path string, live func() llm.LLM) llm.LLM {
+	t.Helper()
+
+	if os.Getenv(RecordEnv) != "" {
+		recorder := NewRecorder(live())
+		t.Cleanup(func() {
+			if err := recorder.Save(path); err != nil {
+				t.Errorf("failed to save LLM fixture: %v", err)
+			}
+		})
+		return recorder
+	}
+
+	replayer, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("%v (record it with %s=1)", err, RecordEnv)
+	}
+	return replayer
+}
@@ -22,6 +22,12 @@
 //	    memoryx.WithRecentToKeep(6),
 //	)
 //
+// [TokenWindowMemory] wraps any Memory and keeps it within a token budget by
+// evicting the oldest non-system messages. No LLM call is involved, so it is a
+// cheap guard against overflowing the model's context window.
+//
+//	mem := memoryx.NewTokenWindowMemory(base, 8000, nil) // nil → CharBasedEstimator
+//
 // [ContextualMemory] wraps any Memory and augments it with semantic retrieval
 // from a vector store. Every message is embedded and stored. On Messages(),
 // it retrieves the most relevant past messages and injects them as context.
@@ -32,6 +38,18 @@
 //	    memoryx.WithContextMinScore(0.7),
 //	)
 //
+// [PersistentMemory] stores the conversation in a [ConversationStore] keyed by
+// tenant and conversation ID, so it survives restarts and can be resumed by a
+// later request. Tool calls and tool results are stored like any other
+// message; the system prompt is pinned as the first message and kept by
+// Clear(). memoryxpg stores conversations in Postgres, memoryxredis in Redis.
+//
+//	store := memoryxpg.NewStore(db)
+//	mem := memoryx.NewPersistentMemory(store, memoryx.ConversationKey{
+//	    TenantID:       tenantID,
+//	    ConversationID: conversationID,
+//	}, "You are a helpful assistant.")
+//
 // # Composition
 //
 // Implementations are designed to be stacked:
@@ -55,5 +73,6 @@
 //
 // [TokenEstimator] is an interface for estimating token counts.
 // [CharBasedEstimator] provides a rough heuristic (~4 chars per token).
-// Plug in a custom implementation (e.g. tiktoken) for more accuracy.
+// Plug in a custom implementation (e.g. tiktoken) for more accuracy, or wrap a
+// plain function with [TokenEstimatorFunc].
 package memoryx
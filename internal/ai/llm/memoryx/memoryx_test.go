@@ -193,3 +193,66 @@ func TestSummarizingMemory_ClearDelegatesToInner(t *testing.T) {
 		t.Fatal("expected only system prompt after clear")
 	}
 }
+
+// --- PersistentMemory tests ---
+
+// mapStore is a ConversationStore kept in a map
+type mapStore struct {
+	conversations map[memoryx.ConversationKey][]llm.Message
+}
+
+func (s *mapStore) Load(_ context.Context, key memoryx.ConversationKey) ([]llm.Message, error) {
+	return append([]llm.Message(nil), s.conversations[key]...), nil
+}
+
+func (s *mapStore) Append(_ context.Context, key memoryx.ConversationKey, messages ...llm.Message) error {
+	s.conversations[key] = append(s.conversations[key], messages...)
+	return nil
+}
+
+func (s *mapStore) Replace(_ context.Context, key memoryx.ConversationKey, messages []llm.Message) error {
+	if len(messages) == 0 {
+		delete(s.conversations, key)
+		return nil
+	}
+	s.conversations[key] = append([]llm.Message(nil), messages...)
+	return nil
+}
+
+func TestPersistentMemory_ResumesConversation(t *testing.T) {
+	store := &mapStore{conversations: map[memoryx.ConversationKey][]llm.Message{}}
+	key := memoryx.ConversationKey{TenantID: "tenant-1", ConversationID: "conv-1"}
+
+	first := memoryx.NewPersistentMemory(store, key, "system v1")
+	first.Add(llm.NewUserMessage("what's the weather?"))
+	first.Add(llm.Message{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{ID: "call-1", Function: llm.FunctionCall{Name: "weather"}}}})
+	first.Add(llm.NewToolMessage("call-1", "sunny"))
+
+	// Another request resumes it; an empty prompt keeps the stored one
+	resumed := memoryx.NewPersistentMemory(store, key, "")
+	msgs, err := resumed.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 4 || msgs[0].Content != "system v1" || msgs[2].ToolCalls[0].ID != "call-1" || msgs[3].ToolCallID != "call-1" {
+		t.Fatalf("resumed conversation = %+v", msgs)
+	}
+
+	// Other tenants do not see it
+	other, _ := memoryx.NewPersistentMemory(store, memoryx.ConversationKey{TenantID: "tenant-2", ConversationID: "conv-1"}, "").Messages()
+	if len(other) != 0 {
+		t.Fatalf("tenant-2 sees %d messages of tenant-1", len(other))
+	}
+
+	// A new prompt replaces the stored one, and Clear keeps it
+	updated := memoryx.NewPersistentMemory(store, key, "system v2")
+	if msgs, _ := updated.Messages(); len(msgs) != 4 || msgs[0].Content != "system v2" {
+		t.Fatalf("messages with new prompt = %+v", msgs)
+	}
+	if err := updated.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if msgs, _ := resumed.Messages(); len(msgs) != 1 || msgs[0].Content != "system v2" {
+		t.Fatalf("messages after clear = %+v, want only the system prompt", msgs)
+	}
+}
@@ -0,0 +1,96 @@
+// Package memoryxpg provides a Postgres-backed memoryx.ConversationStore. The
+// table is created by migrations/017_agent_conversations.up.sql.
+package memoryxpg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store keeps one row per message in agent_conversation_messages, ordered by
+// its serial id
+type Store struct {
+	db *sqlx.DB
+}
+
+var _ memoryx.ConversationStore = (*Store)(nil)
+
+// NewStore creates a store
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Load(ctx context.Context, key memoryx.ConversationKey) ([]llm.Message, error) {
+	var rows [][]byte
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT message FROM agent_conversation_messages
+		WHERE tenant_id = $1 AND conversation_id = $2
+		ORDER BY id`, key.TenantID, key.ConversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	messages := make([]llm.Message, len(rows))
+	for i, row := range rows {
+		if err := json.Unmarshal(row, &messages[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode message %d: %w", i, err)
+		}
+	}
+	return messages, nil
+}
+
+func (s *Store) Append(ctx context.Context, key memoryx.ConversationKey, messages ...llm.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		return insert(ctx, tx, key, messages)
+	})
+}
+
+func (s *Store) Replace(ctx context.Context, key memoryx.ConversationKey, messages []llm.Message) error {
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM agent_conversation_messages
+			WHERE tenant_id = $1 AND conversation_id = $2`, key.TenantID, key.ConversationID)
+		if err != nil {
+			return fmt.Errorf("failed to clear conversation: %w", err)
+		}
+		return insert(ctx, tx, key, messages)
+	})
+}
+
+// insert adds the messages one statement at a time, so their ids follow the
+// order of messages
+func insert(ctx context.Context, tx *sqlx.Tx, key memoryx.ConversationKey, messages []llm.Message) error {
+	for i, message := range messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to encode message %d: %w", i, err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO agent_conversation_messages (tenant_id, conversation_id, message)
+			VALUES ($1, $2, $3)`, key.TenantID, key.ConversationID, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to store message: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) withTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,71 @@
+package memoryxpg
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+	"github.com/Abraxas-365/manifesto/internal/testx"
+)
+
+func TestStore(t *testing.T) {
+	db := testx.Postgres(t)
+	migration, err := os.ReadFile("../../../../../migrations/017_agent_conversations.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatalf("failed to apply migration: %v", err)
+	}
+
+	ctx := context.Background()
+	store := NewStore(db)
+	key := memoryx.ConversationKey{TenantID: "t1", ConversationID: "c1"}
+
+	if messages, err := store.Load(ctx, key); err != nil || len(messages) != 0 {
+		t.Fatalf("Load of a new conversation = %v, %v; want empty", messages, err)
+	}
+
+	call := llm.NewAssistantMessage("")
+	call.ToolCalls = []llm.ToolCall{{ID: "call-1", Type: "function", Function: llm.FunctionCall{Name: "weather", Arguments: `{"city":"Lima"}`}}}
+	if err := store.Append(ctx, key, llm.NewUserMessage("hi"), call); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(ctx, key, llm.NewToolMessage("call-1", "sunny")); err != nil {
+		t.Fatal(err)
+	}
+	// Same conversation ID in another tenant
+	if err := store.Append(ctx, memoryx.ConversationKey{TenantID: "t2", ConversationID: "c1"}, llm.NewUserMessage("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := store.Load(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || messages[0].Content != "hi" || messages[2].ToolCallID != "call-1" {
+		t.Fatalf("Load = %+v, want the 3 messages of t1 in order", messages)
+	}
+	if len(messages[1].ToolCalls) != 1 || messages[1].ToolCalls[0].Function.Arguments != `{"city":"Lima"}` {
+		t.Errorf("tool call = %+v, want it stored whole", messages[1].ToolCalls)
+	}
+
+	if err := store.Replace(ctx, key, []llm.Message{llm.NewSystemMessage("summary")}); err != nil {
+		t.Fatal(err)
+	}
+	if messages, err := store.Load(ctx, key); err != nil || len(messages) != 1 || messages[0].Content != "summary" {
+		t.Fatalf("Load after Replace = %+v, %v; want only the summary", messages, err)
+	}
+
+	if err := store.Replace(ctx, key, nil); err != nil {
+		t.Fatal(err)
+	}
+	if messages, err := store.Load(ctx, key); err != nil || len(messages) != 0 {
+		t.Fatalf("Load after an empty Replace = %+v, %v; want empty", messages, err)
+	}
+	if messages, err := store.Load(ctx, memoryx.ConversationKey{TenantID: "t2", ConversationID: "c1"}); err != nil || len(messages) != 1 {
+		t.Errorf("other tenant's conversation = %+v, %v; want it untouched", messages, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package memoryxredis provides a Redis-backed memoryx.ConversationStore.
+package memoryxredis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+	"github.com/redis/go-redis/v9"
+)
+
+const conversationKeyPrefix = "memoryx:conversation:"
+
+// Store keeps each conversation in a Redis list of JSON-encoded messages
+type Store struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+var _ memoryx.ConversationStore = (*Store)(nil)
+
+// NewStore creates a store. Each write extends the conversation's expiry to
+// ttl; 0 keeps conversations until they are cleared.
+func NewStore(client *redis.Client, ttl time.Duration) *Store {
+	return &Store{client: client, ttl: ttl}
+}
+
+func (s *Store) Load(ctx context.Context, key memoryx.ConversationKey) ([]llm.Message, error) {
+	values, err := s.client.LRange(ctx, redisKey(key), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]llm.Message, len(values))
+	for i, value := range values {
+		if err := json.Unmarshal([]byte(value), &messages[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode message %d: %w", i, err)
+		}
+	}
+	return messages, nil
+}
+
+func (s *Store) Append(ctx context.Context, key memoryx.ConversationKey, messages ...llm.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	values, err := encode(messages)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, redisKey(key), values...)
+		if s.ttl > 0 {
+			pipe.Expire(ctx, redisKey(key), s.ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *Store) Replace(ctx context.Context, key memoryx.ConversationKey, messages []llm.Message) error {
+	values, err := encode(messages)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, redisKey(key))
+		if len(values) > 0 {
+			pipe.RPush(ctx, redisKey(key), values...)
+			if s.ttl > 0 {
+				pipe.Expire(ctx, redisKey(key), s.ttl)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func redisKey(key memoryx.ConversationKey) string {
+	return conversationKeyPrefix + key.TenantID + ":" + key.ConversationID
+}
+
+func encode(messages []llm.Message) ([]any, error) {
+	values := make([]any, len(messages))
+	for i, message := range messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode message %d: %w", i, err)
+		}
+		values[i] = data
+	}
+	return values, nil
+}
@@ -0,0 +1,66 @@
+package memoryxredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+	"github.com/Abraxas-365/manifesto/internal/testx"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := testx.Redis(t)
+	store := NewStore(client, time.Hour)
+	key := memoryx.ConversationKey{TenantID: "t1", ConversationID: "c1"}
+	otherTenant := memoryx.ConversationKey{TenantID: "t2", ConversationID: "c1"}
+
+	if messages, err := store.Load(ctx, key); err != nil || len(messages) != 0 {
+		t.Fatalf("Load of a new conversation = %v, %v; want empty", messages, err)
+	}
+
+	call := llm.NewAssistantMessage("")
+	call.ToolCalls = []llm.ToolCall{{ID: "call-1", Type: "function", Function: llm.FunctionCall{Name: "weather", Arguments: `{"city":"Lima"}`}}}
+	if err := store.Append(ctx, key, llm.NewUserMessage("hi"), call); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(ctx, key, llm.NewToolMessage("call-1", "sunny")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(ctx, otherTenant, llm.NewUserMessage("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := store.Load(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || messages[0].Content != "hi" || messages[2].ToolCallID != "call-1" {
+		t.Fatalf("Load = %+v, want the 3 messages of t1 in order", messages)
+	}
+	if len(messages[1].ToolCalls) != 1 || messages[1].ToolCalls[0].Function.Arguments != `{"city":"Lima"}` {
+		t.Errorf("tool call = %+v, want it stored whole", messages[1].ToolCalls)
+	}
+	if ttl := client.TTL(ctx, redisKey(key)).Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL = %s, want the store TTL", ttl)
+	}
+
+	if err := store.Replace(ctx, key, []llm.Message{llm.NewSystemMessage("summary")}); err != nil {
+		t.Fatal(err)
+	}
+	if messages, err := store.Load(ctx, key); err != nil || len(messages) != 1 || messages[0].Content != "summary" {
+		t.Fatalf("Load after Replace = %+v, %v; want only the summary", messages, err)
+	}
+
+	if err := store.Replace(ctx, key, nil); err != nil {
+		t.Fatal(err)
+	}
+	if exists := client.Exists(ctx, redisKey(key)).Val(); exists != 0 {
+		t.Error("empty Replace left the conversation key behind")
+	}
+	if messages, err := store.Load(ctx, otherTenant); err != nil || len(messages) != 1 {
+		t.Errorf("other tenant's conversation = %+v, %v; want it untouched", messages, err)
+	}
+}
@@ -0,0 +1,183 @@
+package memoryx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// ConversationKey identifies a stored conversation. Conversation IDs are
+// scoped to the tenant, so two tenants never share a conversation.
+type ConversationKey struct {
+	TenantID       string
+	ConversationID string
+}
+
+// ConversationStore persists the messages of conversations. Messages are
+// stored whole, tool calls and tool results included, and returned in the
+// order they were appended.
+type ConversationStore interface {
+	// Load returns the messages of the conversation, empty if it does not exist
+	Load(ctx context.Context, key ConversationKey) ([]llm.Message, error)
+
+	// Append adds messages to the end of the conversation, creating it if needed
+	Append(ctx context.Context, key ConversationKey, messages ...llm.Message) error
+
+	// Replace atomically sets the messages of the conversation; an empty list
+	// deletes it
+	Replace(ctx context.Context, key ConversationKey, messages []llm.Message) error
+}
+
+// defaultStoreTimeout bounds each store operation; Memory methods take no context
+const defaultStoreTimeout = 5 * time.Second
+
+// PersistentMemory is a Memory backed by a ConversationStore, so a conversation
+// survives restarts and can be resumed by a later request with the same key.
+//
+// Every call goes to the store: Messages reloads the conversation, so several
+// instances can take turns on the same conversation. The system prompt, when
+// given, is pinned as the first message: it is stored with the conversation,
+// replaces a different stored prompt, and is kept by Clear.
+type PersistentMemory struct {
+	mu sync.Mutex
+
+	store        ConversationStore
+	key          ConversationKey
+	systemPrompt string
+	timeout      time.Duration
+	pinned       bool // The stored conversation starts with systemPrompt
+}
+
+// PersistentMemoryOption configures a PersistentMemory
+type PersistentMemoryOption func(*PersistentMemory)
+
+// WithStoreTimeout bounds each store operation (default 5s)
+func WithStoreTimeout(timeout time.Duration) PersistentMemoryOption {
+	return func(p *PersistentMemory) {
+		p.timeout = timeout
+	}
+}
+
+// NewPersistentMemory opens the conversation identified by key. An empty
+// systemPrompt keeps whatever system message the conversation was stored with.
+//
+// Example:
+//
+//	store := memoryxredis.NewStore(redisClient, 7*24*time.Hour)
+//	mem := memoryx.NewPersistentMemory(store, memoryx.ConversationKey{
+//		TenantID:       tenantID,
+//		ConversationID: conversationID,
+//	}, "You are a helpful assistant.")
+func NewPersistentMemory(store ConversationStore, key ConversationKey, systemPrompt string, opts ...PersistentMemoryOption) *PersistentMemory {
+	p := &PersistentMemory{
+		store:        store,
+		key:          key,
+		systemPrompt: systemPrompt,
+		timeout:      defaultStoreTimeout,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Key returns the key of the conversation
+func (p *PersistentMemory) Key() ConversationKey {
+	return p.key
+}
+
+func (p *PersistentMemory) Messages() ([]llm.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	return p.load(ctx)
+}
+
+func (p *PersistentMemory) Add(message llm.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	// The prompt must be stored before the first message
+	if p.systemPrompt != "" && !p.pinned {
+		if _, err := p.load(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := p.store.Append(ctx, p.key, message); err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+	return nil
+}
+
+// load returns the stored conversation with the system prompt pinned. p.mu
+// must be held.
+func (p *PersistentMemory) load(ctx context.Context) ([]llm.Message, error) {
+	messages, err := p.store.Load(ctx, p.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if p.systemPrompt == "" || startsWithPrompt(messages, p.systemPrompt) {
+		p.pinned = p.systemPrompt != ""
+		return messages, nil
+	}
+
+	// New conversation, or one stored with another prompt: pin ours
+	pinned := withSystemPrompt(messages, p.systemPrompt)
+	if err := p.store.Replace(ctx, p.key, pinned); err != nil {
+		return nil, fmt.Errorf("failed to store system prompt: %w", err)
+	}
+	p.pinned = true
+	return pinned, nil
+}
+
+// Clear deletes the stored messages but keeps the system prompt: the pinned
+// one, or else the system message the conversation starts with
+func (p *PersistentMemory) Clear() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var keep []llm.Message
+	if p.systemPrompt != "" {
+		keep = []llm.Message{llm.NewSystemMessage(p.systemPrompt)}
+	} else {
+		messages, err := p.store.Load(ctx, p.key)
+		if err != nil {
+			return fmt.Errorf("failed to load conversation: %w", err)
+		}
+		if len(messages) > 0 && messages[0].Role == llm.RoleSystem {
+			keep = messages[:1]
+		}
+	}
+
+	if err := p.store.Replace(ctx, p.key, keep); err != nil {
+		return fmt.Errorf("failed to clear conversation: %w", err)
+	}
+	p.pinned = p.systemPrompt != ""
+	return nil
+}
+
+func startsWithPrompt(messages []llm.Message, prompt string) bool {
+	return len(messages) > 0 && messages[0].Role == llm.RoleSystem && messages[0].Content == prompt
+}
+
+// withSystemPrompt returns messages with prompt as the first message,
+// replacing a stored system message
+func withSystemPrompt(messages []llm.Message, prompt string) []llm.Message {
+	if len(messages) > 0 && messages[0].Role == llm.RoleSystem {
+		messages = messages[1:]
+	}
+	return append([]llm.Message{llm.NewSystemMessage(prompt)}, messages...)
+}
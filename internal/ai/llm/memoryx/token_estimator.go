@@ -39,3 +39,10 @@ func (e *CharBasedEstimator) EstimateTokens(messages []llm.Message) int {
 	}
 	return total
 }
+
+// TokenEstimatorFunc adapts a plain function to the TokenEstimator interface.
+type TokenEstimatorFunc func(messages []llm.Message) int
+
+func (f TokenEstimatorFunc) EstimateTokens(messages []llm.Message) int {
+	return f(messages)
+}
@@ -0,0 +1,123 @@
+package memoryx
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// TokenWindowMemory wraps any Memory and keeps the conversation within a token
+// budget. When the estimate exceeds MaxTokens, the oldest non-system messages
+// are evicted from the inner memory until it fits. The system prompt is always
+// kept, and so is the most recent message even if it alone exceeds the budget.
+//
+// Unlike SummarizingMemory no LLM call is made — evicted messages are simply
+// dropped.
+type TokenWindowMemory struct {
+	mu sync.Mutex
+
+	inner     Memory
+	estimator TokenEstimator
+
+	// MaxTokens is the token budget for the messages returned by Messages().
+	MaxTokens int
+
+	// OnEvict is an optional callback invoked with the messages evicted by a trim.
+	OnEvict func(evicted []llm.Message)
+}
+
+// NewTokenWindowMemory wraps an existing Memory with a token budget.
+// If estimator is nil, a CharBasedEstimator (~4 chars per token) is used.
+//
+// Example:
+//
+//	base := memoryx.NewInMemoryMemory("You are a helpful assistant.")
+//	mem := memoryx.NewTokenWindowMemory(base, 8000, nil)
+//
+//	// Custom estimator
+//	mem := memoryx.NewTokenWindowMemory(base, 8000, memoryx.TokenEstimatorFunc(countWithTiktoken))
+func NewTokenWindowMemory(inner Memory, maxTokens int, estimator TokenEstimator) *TokenWindowMemory {
+	if estimator == nil {
+		estimator = &CharBasedEstimator{}
+	}
+	return &TokenWindowMemory{
+		inner:     inner,
+		estimator: estimator,
+		MaxTokens: maxTokens,
+	}
+}
+
+func (t *TokenWindowMemory) Add(message llm.Message) error {
+	return t.inner.Add(message)
+}
+
+func (t *TokenWindowMemory) Clear() error {
+	return t.inner.Clear()
+}
+
+// Messages returns the message list, evicting the oldest non-system messages
+// first if the token estimate exceeds MaxTokens.
+func (t *TokenWindowMemory) Messages() ([]llm.Message, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	messages, err := t.inner.Messages()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.MaxTokens <= 0 || t.estimator.EstimateTokens(messages) <= t.MaxTokens {
+		return messages, nil
+	}
+
+	// Separate system prompt from conversation
+	var system []llm.Message
+	conversation := messages
+	if len(messages) > 0 && messages[0].Role == llm.RoleSystem {
+		system = messages[:1]
+		conversation = messages[1:]
+	}
+
+	// Drop from the front until the window fits, keeping at least one message
+	start := 0
+	for start < len(conversation)-1 {
+		window := append(append([]llm.Message{}, system...), conversation[start:]...)
+		if t.estimator.EstimateTokens(window) <= t.MaxTokens {
+			break
+		}
+		start++
+	}
+
+	// A tool result without its assistant tool call is rejected by providers,
+	// so never start the window on one
+	for start < len(conversation)-1 && conversation[start].Role == llm.RoleTool {
+		start++
+	}
+
+	if start == 0 {
+		return messages, nil
+	}
+
+	evicted := conversation[:start]
+	kept := conversation[start:]
+
+	// Rebuild the inner memory so it stops growing.
+	// Clear() preserves the system prompt, so we only re-add the kept messages.
+	// A failure here leaves the inner memory partly rebuilt, so it is returned
+	// rather than hidden behind the untrimmed messages.
+	if err := t.inner.Clear(); err != nil {
+		return nil, fmt.Errorf("failed to clear memory while trimming: %w", err)
+	}
+	for _, msg := range kept {
+		if err := t.inner.Add(msg); err != nil {
+			return nil, fmt.Errorf("failed to restore message while trimming: %w", err)
+		}
+	}
+
+	if t.OnEvict != nil {
+		t.OnEvict(evicted)
+	}
+
+	return t.inner.Messages()
+}
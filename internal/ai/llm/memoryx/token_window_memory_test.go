@@ -0,0 +1,136 @@
+package memoryx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+)
+
+// oneTokenPerMessage counts every message as a single token.
+var oneTokenPerMessage = memoryx.TokenEstimatorFunc(func(msgs []llm.Message) int {
+	return len(msgs)
+})
+
+func TestTokenWindowMemory_UnderBudgetReturnsAll(t *testing.T) {
+	base := memoryx.NewInMemoryMemory("system")
+	mem := memoryx.NewTokenWindowMemory(base, 10, oneTokenPerMessage)
+
+	mem.Add(llm.NewUserMessage("hello"))
+	mem.Add(llm.NewAssistantMessage("hi"))
+
+	msgs, err := mem.Messages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+}
+
+func TestTokenWindowMemory_EvictsOldestAndPinsSystem(t *testing.T) {
+	base := memoryx.NewInMemoryMemory("system")
+	mem := memoryx.NewTokenWindowMemory(base, 3, oneTokenPerMessage)
+
+	var evicted []llm.Message
+	mem.OnEvict = func(m []llm.Message) { evicted = m }
+
+	for _, content := range []string{"m1", "m2", "m3", "m4"} {
+		mem.Add(llm.NewUserMessage(content))
+	}
+
+	msgs, _ := mem.Messages()
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d: %+v", len(msgs), msgs)
+	}
+	if msgs[0].Role != llm.RoleSystem {
+		t.Fatalf("expected system prompt first, got %s", msgs[0].Role)
+	}
+	if msgs[1].Content != "m3" || msgs[2].Content != "m4" {
+		t.Fatalf("expected m3, m4 to be kept, got %+v", msgs[1:])
+	}
+	if len(evicted) != 2 || evicted[0].Content != "m1" {
+		t.Fatalf("expected m1, m2 evicted, got %+v", evicted)
+	}
+
+	// Eviction is persisted in the inner memory
+	inner, _ := base.Messages()
+	if len(inner) != 3 {
+		t.Fatalf("expected inner memory trimmed to 3, got %d", len(inner))
+	}
+}
+
+func TestTokenWindowMemory_KeepsLatestMessageOverBudget(t *testing.T) {
+	base := memoryx.NewInMemoryMemory("system")
+	mem := memoryx.NewTokenWindowMemory(base, 1, oneTokenPerMessage)
+
+	mem.Add(llm.NewUserMessage("old"))
+	mem.Add(llm.NewUserMessage("latest"))
+
+	msgs, _ := mem.Messages()
+	if len(msgs) != 2 || msgs[1].Content != "latest" {
+		t.Fatalf("expected system + latest, got %+v", msgs)
+	}
+}
+
+func TestTokenWindowMemory_DoesNotStartWithToolResult(t *testing.T) {
+	base := memoryx.NewInMemoryMemory()
+	mem := memoryx.NewTokenWindowMemory(base, 2, oneTokenPerMessage)
+
+	call := llm.NewAssistantMessage("")
+	call.ToolCalls = []llm.ToolCall{{ID: "call_1", Type: "function"}}
+
+	mem.Add(llm.NewUserMessage("question"))
+	mem.Add(call)
+	mem.Add(llm.NewToolMessage("call_1", "result"))
+	mem.Add(llm.NewAssistantMessage("answer"))
+
+	msgs, _ := mem.Messages()
+	if len(msgs) != 1 || msgs[0].Content != "answer" {
+		t.Fatalf("expected only the final answer, got %+v", msgs)
+	}
+}
+
+func TestTokenWindowMemory_DefaultEstimator(t *testing.T) {
+	base := memoryx.NewInMemoryMemory("system")
+	mem := memoryx.NewTokenWindowMemory(base, 50, nil)
+
+	for i := 0; i < 20; i++ {
+		mem.Add(llm.NewUserMessage("a message that takes roughly ten tokens to say"))
+	}
+
+	msgs, _ := mem.Messages()
+	if len(msgs) >= 21 {
+		t.Fatalf("expected trimming with the default estimator, got %d messages", len(msgs))
+	}
+	if msgs[0].Role != llm.RoleSystem {
+		t.Fatalf("expected system prompt first")
+	}
+}
+
+// failingClearMemory is an in-memory Memory whose Clear fails
+type failingClearMemory struct {
+	memoryx.Memory
+}
+
+func (failingClearMemory) Clear() error { return errors.New("store unavailable") }
+
+func TestTokenWindowMemory_ReturnsTrimErrors(t *testing.T) {
+	base := failingClearMemory{memoryx.NewInMemoryMemory("system")}
+	mem := memoryx.NewTokenWindowMemory(base, 2, oneTokenPerMessage)
+
+	var evicted []llm.Message
+	mem.OnEvict = func(msgs []llm.Message) { evicted = msgs }
+
+	mem.Add(llm.NewUserMessage("one"))
+	mem.Add(llm.NewUserMessage("two"))
+
+	msgs, err := mem.Messages()
+	if err == nil {
+		t.Fatalf("expected the Clear error, got %d messages", len(msgs))
+	}
+	if evicted != nil {
+		t.Errorf("OnEvict called although the trim failed: %+v", evicted)
+	}
+}
@@ -124,6 +124,24 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// ReasoningTokens is the part of CompletionTokens spent on hidden
+	// reasoning/thinking (o-series, Gemini thinking models)
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+
+	// CachedTokens is the part of PromptTokens served from the prompt cache
+	CachedTokens int `json:"cached_tokens,omitempty"`
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+		ReasoningTokens:  u.ReasoningTokens + other.ReasoningTokens,
+		CachedTokens:     u.CachedTokens + other.CachedTokens,
+	}
 }
 
 // FunctionCall represents a function call in a message
@@ -227,6 +245,8 @@ const (
 type ResponseFormat struct {
 	Type       ResponseFormatType `json:"type"`
 	JSONSchema any                `json:"schema,omitempty"` // Optional JSON schema for JSONSchema type
+	Name       string             `json:"name,omitempty"`   // Schema name for JSONSchema type (defaults to "schema")
+	Strict     bool               `json:"strict,omitempty"` // Require exact schema adherence (provider support varies)
 }
 
 // WithResponseFormat specifies the output format
@@ -245,6 +265,21 @@ func WithJSONResponseFormat() Option {
 	}
 }
 
+// WithJSONSchema sets the response format to a named JSON schema. With strict,
+// providers that support it (OpenAI) guarantee the output matches the schema;
+// the schema must then be an object schema that lists every property as
+// required and sets additionalProperties to false.
+func WithJSONSchema(name string, schema any, strict bool) Option {
+	return func(o *ChatOptions) {
+		o.ResponseFormat = &ResponseFormat{
+			Type:       JSONSchema,
+			JSONSchema: schema,
+			Name:       name,
+			Strict:     strict,
+		}
+	}
+}
+
 // WithJSONSchemaResponseFormat sets the response format to conform to a specific JSON schema
 func WithJSONSchemaResponseFormat(schema any) Option {
 	return func(o *ChatOptions) {
@@ -0,0 +1,140 @@
+// Package modelx resolves logical model names ("chat-default",
+// "embed-default", or any alias ops define) to concrete models, globally and
+// per tenant, so callers never hard-code a model and plans can get different
+// ones.
+//
+// [ResolvingLLM] and [ResolvingEmbedder] decorate any provider: the model a
+// call asks for with llm.WithModel / embedding.WithModel is looked up as an
+// alias, calls without one use the default alias, and the provider receives
+// the concrete model as usual. Names that are not aliases are passed through
+// unchanged, so existing callers naming a model keep working.
+//
+//	resolver := modelx.NewResolver(map[string]string{
+//		modelx.AliasChatDefault:  "gpt-4.1",
+//		modelx.AliasEmbedDefault: "text-embedding-3-small",
+//	}, modelx.WithTenantAliases(func(ctx context.Context, tenantID string) (map[string]string, error) {
+//		return tenantSvc.ModelAliases(ctx, kernel.TenantID(tenantID))
+//	}))
+//	client := llm.NewClient(modelx.NewResolvingLLM(provider, resolver))
+//	client.Chat(ctx, messages, llm.WithModel("chat-fast"))
+//
+// Tenant aliases take precedence over the global ones; the tenant is the one
+// of kernel.AuthContextFrom(ctx).
+package modelx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// Default aliases, used by calls that do not name a model
+const (
+	AliasChatDefault  = "chat-default"
+	AliasEmbedDefault = "embed-default"
+)
+
+// defaultCacheTTL is how long the aliases of a tenant are reused when
+// WithCacheTTL is not set
+const defaultCacheTTL = time.Minute
+
+// TenantAliasesFunc returns the aliases of a tenant, e.g. those of its plan
+// with its own overrides. A nil map means the tenant has none.
+type TenantAliasesFunc func(ctx context.Context, tenantID string) (map[string]string, error)
+
+// Resolver maps aliases to models
+type Resolver struct {
+	aliases       map[string]string
+	tenantAliases TenantAliasesFunc
+	cacheTTL      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedAliases
+}
+
+type cachedAliases struct {
+	aliases   map[string]string
+	expiresAt time.Time
+}
+
+// Option configures a Resolver
+type Option func(*Resolver)
+
+// WithTenantAliases looks up tenant aliases with fn
+func WithTenantAliases(fn TenantAliasesFunc) Option {
+	return func(r *Resolver) {
+		r.tenantAliases = fn
+	}
+}
+
+// WithCacheTTL sets how long the aliases of a tenant are reused before fn is
+// called again (default 1 minute); 0 disables the cache
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *Resolver) {
+		r.cacheTTL = ttl
+	}
+}
+
+// NewResolver creates a resolver with the global aliases
+func NewResolver(aliases map[string]string, opts ...Option) *Resolver {
+	r := &Resolver{
+		aliases:  make(map[string]string, len(aliases)),
+		cacheTTL: defaultCacheTTL,
+		cache:    make(map[string]cachedAliases),
+	}
+	for alias, model := range aliases {
+		r.aliases[alias] = model
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolve returns the model for name: the alias of the tenant in ctx, else
+// the global alias, else name itself. It fails only when the tenant aliases
+// cannot be loaded, rather than silently picking a model the tenant may not
+// be entitled to.
+func (r *Resolver) Resolve(ctx context.Context, name string) (string, error) {
+	if authContext, ok := kernel.AuthContextFrom(ctx); ok && r.tenantAliases != nil {
+		aliases, err := r.forTenant(ctx, authContext.TenantID.String())
+		if err != nil {
+			return "", fmt.Errorf("failed to load model aliases of tenant %s: %w", authContext.TenantID, err)
+		}
+		if model, ok := aliases[name]; ok && model != "" {
+			return model, nil
+		}
+	}
+
+	if model, ok := r.aliases[name]; ok && model != "" {
+		return model, nil
+	}
+	return name, nil
+}
+
+func (r *Resolver) forTenant(ctx context.Context, tenantID string) (map[string]string, error) {
+	if r.cacheTTL <= 0 {
+		return r.tenantAliases(ctx, tenantID)
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	cached, ok := r.cache[tenantID]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.aliases, nil
+	}
+
+	aliases, err := r.tenantAliases(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[tenantID] = cachedAliases{aliases: aliases, expiresAt: now.Add(r.cacheTTL)}
+	r.mu.Unlock()
+	return aliases, nil
+}
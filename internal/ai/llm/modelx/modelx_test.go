@@ -0,0 +1,92 @@
+package modelx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// modelLLM remembers the model of the last call
+type modelLLM struct {
+	model string
+}
+
+func (l *modelLLM) Chat(_ context.Context, _ []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	var options llm.ChatOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	l.model = options.Model
+	return llm.Response{}, nil
+}
+
+func (l *modelLLM) ChatStream(context.Context, []llm.Message, ...llm.Option) (llm.Stream, error) {
+	return nil, nil
+}
+
+func tenantContext(tenantID string) context.Context {
+	userID := kernel.UserID("u1")
+	return kernel.WithAuthContext(context.Background(), &kernel.AuthContext{
+		UserID:   &userID,
+		TenantID: kernel.TenantID(tenantID),
+	})
+}
+
+func TestResolvingLLM(t *testing.T) {
+	calls := 0
+	resolver := NewResolver(map[string]string{
+		AliasChatDefault: "gpt-4.1-mini",
+		"chat-smart":     "gpt-4.1",
+	}, WithTenantAliases(func(_ context.Context, tenantID string) (map[string]string, error) {
+		calls++
+		if tenantID == "enterprise" {
+			return map[string]string{AliasChatDefault: "gpt-4o"}, nil
+		}
+		return nil, nil
+	}))
+	inner := &modelLLM{}
+	client := llm.NewClient(NewResolvingLLM(inner, resolver))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		opts []llm.Option
+		want string
+	}{
+		{"global default", context.Background(), nil, "gpt-4.1-mini"},
+		{"global alias", context.Background(), []llm.Option{llm.WithModel("chat-smart")}, "gpt-4.1"},
+		{"concrete model", context.Background(), []llm.Option{llm.WithModel("o3")}, "o3"},
+		{"tenant default", tenantContext("enterprise"), nil, "gpt-4o"},
+		{"tenant falls back to global", tenantContext("enterprise"), []llm.Option{llm.WithModel("chat-smart")}, "gpt-4.1"},
+		{"tenant without aliases", tenantContext("basic"), nil, "gpt-4.1-mini"},
+	}
+	for _, tt := range tests {
+		if _, err := client.Chat(tt.ctx, nil, tt.opts...); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if inner.model != tt.want {
+			t.Errorf("%s: model = %q, want %q", tt.name, inner.model, tt.want)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("tenant aliases loaded %d times, want 2 (one per tenant, then cached)", calls)
+	}
+}
+
+func TestResolverFailsWhenTenantAliasesFail(t *testing.T) {
+	resolver := NewResolver(map[string]string{AliasChatDefault: "gpt-4.1"},
+		WithTenantAliases(func(context.Context, string) (map[string]string, error) {
+			return nil, errors.New("db down")
+		}))
+
+	if _, err := resolver.Resolve(tenantContext("t1"), AliasChatDefault); err == nil {
+		t.Error("expected error when tenant aliases cannot be loaded")
+	}
+	if model, err := resolver.Resolve(context.Background(), AliasChatDefault); err != nil || model != "gpt-4.1" {
+		t.Errorf("Resolve without tenant = %q, %v", model, err)
+	}
+}
@@ -0,0 +1,111 @@
+package modelx
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// ResolvingLLM resolves the model of every call before passing it to the
+// wrapped llm.LLM. Wrap it around usagex.MeteredLLM, not inside, so usage is
+// priced with the concrete model.
+type ResolvingLLM struct {
+	llm      llm.LLM
+	resolver *Resolver
+}
+
+var _ llm.LLM = (*ResolvingLLM)(nil)
+
+// NewResolvingLLM wraps next
+func NewResolvingLLM(next llm.LLM, resolver *Resolver) *ResolvingLLM {
+	return &ResolvingLLM{llm: next, resolver: resolver}
+}
+
+func (l *ResolvingLLM) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	opts, err := l.resolve(ctx, opts)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return l.llm.Chat(ctx, messages, opts...)
+}
+
+func (l *ResolvingLLM) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	opts, err := l.resolve(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return l.llm.ChatStream(ctx, messages, opts...)
+}
+
+// resolve appends the resolved model to opts, overriding the requested one
+func (l *ResolvingLLM) resolve(ctx context.Context, opts []llm.Option) ([]llm.Option, error) {
+	var options llm.ChatOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	name := options.Model
+	if name == "" {
+		name = AliasChatDefault
+	}
+
+	model, err := l.resolver.Resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if model == AliasChatDefault {
+		// No default configured: leave the choice to the provider
+		return opts, nil
+	}
+	return append(opts[:len(opts):len(opts)], llm.WithModel(model)), nil
+}
+
+// ResolvingEmbedder resolves the model of every call before passing it to
+// the wrapped embedding.Embedder
+type ResolvingEmbedder struct {
+	embedder embedding.Embedder
+	resolver *Resolver
+}
+
+var _ embedding.Embedder = (*ResolvingEmbedder)(nil)
+
+// NewResolvingEmbedder wraps next
+func NewResolvingEmbedder(next embedding.Embedder, resolver *Resolver) *ResolvingEmbedder {
+	return &ResolvingEmbedder{embedder: next, resolver: resolver}
+}
+
+func (e *ResolvingEmbedder) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	opts, err := e.resolve(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.embedder.EmbedDocuments(ctx, documents, opts...)
+}
+
+func (e *ResolvingEmbedder) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	opts, err := e.resolve(ctx, opts)
+	if err != nil {
+		return embedding.Embedding{}, err
+	}
+	return e.embedder.EmbedQuery(ctx, text, opts...)
+}
+
+func (e *ResolvingEmbedder) resolve(ctx context.Context, opts []embedding.Option) ([]embedding.Option, error) {
+	var options embedding.EmbeddingOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	name := options.Model
+	if name == "" {
+		name = AliasEmbedDefault
+	}
+
+	model, err := e.resolver.Resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if model == AliasEmbedDefault {
+		return opts, nil
+	}
+	return append(opts[:len(opts):len(opts)], embedding.WithModel(model)), nil
+}
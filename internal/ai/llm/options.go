@@ -23,6 +23,8 @@ type ChatOptions struct {
 
 	ReasoningEffort string // Reasoning effort level: "low", "medium", "high"
 
+	PromptCaching  bool   // Mark stable prefixes (tools, system prompt, history) as cacheable
+	PromptCacheKey string // Groups requests sharing a prefix for providers with automatic caching (OpenAI)
 }
 
 // Option is a function type to modify ChatOptions
@@ -151,6 +153,25 @@ func WithReasoningEffort(effort string) Option {
 	}
 }
 
+// WithPromptCaching enables prompt caching of the stable request prefix (tool
+// definitions, system prompt and conversation history). Anthropic gets
+// cache_control breakpoints; OpenAI caches automatically, see WithPromptCacheKey.
+// Cache hits are reported in Usage.CachedTokens.
+func WithPromptCaching() Option {
+	return func(o *ChatOptions) {
+		o.PromptCaching = true
+	}
+}
+
+// WithPromptCacheKey sets a key that routes requests sharing a long prefix
+// (e.g. the same agent) to the same cache, improving OpenAI cache hit rates
+func WithPromptCacheKey(key string) Option {
+	return func(o *ChatOptions) {
+		o.PromptCaching = true
+		o.PromptCacheKey = key
+	}
+}
+
 // DefaultOptions returns the default options
 func DefaultOptions() *ChatOptions {
 	return &ChatOptions{
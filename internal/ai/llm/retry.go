@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how provider calls are retried on transient failures
+// (429 and 5xx). MaxAttempts counts the first call, so 1 disables retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration // Delay before the first retry, doubled on each attempt
+	MaxDelay    time.Duration // Upper bound of a single wait, Retry-After included
+	Jitter      float64       // Random +/- fraction applied to each delay (0..1)
+}
+
+// DefaultRetryPolicy returns the policy used by providers unless overridden
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// NoRetry is a policy that makes a single attempt
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// RetryClass is how a failed attempt must be handled
+type RetryClass int
+
+const (
+	// RetryNever returns the error as is
+	RetryNever RetryClass = iota
+	// RetryTransient retries a temporary failure (5xx, connection error)
+	RetryTransient
+	// RetryRateLimited retries a 429
+	RetryRateLimited
+)
+
+// ClassifyFunc decides whether an error is retryable and, when the provider
+// sent a Retry-After header, how long to wait before the next attempt
+type ClassifyFunc func(err error) (class RetryClass, retryAfter time.Duration)
+
+// Retry calls fn until it succeeds, fails with a non-retryable error, or the
+// policy's attempts run out. Waits grow exponentially from BaseDelay (with
+// jitter) unless the provider asked for a specific Retry-After; a Retry-After
+// above MaxDelay ends the retries early. When attempts are exhausted the
+// returned error is ErrRateLimitExhausted (last failure was a 429) or
+// ErrRetriesExhausted, wrapping the last provider error.
+func Retry(ctx context.Context, policy RetryPolicy, classify ClassifyFunc, fn func() error) error {
+	attempts := max(policy.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		class, retryAfter := classify(err)
+		if class == RetryNever {
+			return err
+		}
+		if attempt >= attempts {
+			return newExhaustedError(class, attempt, retryAfter, err)
+		}
+
+		delay := retryAfter
+		if delay <= 0 {
+			delay = policy.backoff(attempt)
+		} else if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			return newExhaustedError(class, attempt, retryAfter, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before retry number attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delta := float64(delay) * p.Jitter * (2*rand.Float64() - 1)
+		delay += time.Duration(delta)
+	}
+	return max(delay, 0)
+}
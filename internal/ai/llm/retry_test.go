@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("503")
+var errRateLimited = errors.New("429")
+var errBadRequest = errors.New("400")
+
+func classifyTest(err error) (RetryClass, time.Duration) {
+	switch err {
+	case errTransient:
+		return RetryTransient, 0
+	case errRateLimited:
+		return RetryRateLimited, time.Millisecond
+	default:
+		return RetryNever, 0
+	}
+}
+
+func fastPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+}
+
+func TestRetrySucceedsAfterTransientFailures(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), fastPolicy(3), classifyTest, func() error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err = %v, calls = %d; want nil after 3 calls", err, calls)
+	}
+}
+
+func TestRetryExhaustion(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		isType func(error) bool
+	}{
+		{"rate limited", errRateLimited, IsRateLimitExhausted},
+		{"transient", errTransient, IsRetriesExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(context.Background(), fastPolicy(2), classifyTest, func() error {
+				calls++
+				return tt.err
+			})
+			if calls != 2 {
+				t.Errorf("calls = %d, want 2", calls)
+			}
+			if !tt.isType(err) {
+				t.Errorf("err = %v, wrong exhaustion type", err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("exhaustion error does not wrap the last failure")
+			}
+		})
+	}
+}
+
+func TestRetryDoesNotRetryPermanentErrors(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), fastPolicy(3), classifyTest, func() error {
+		calls++
+		return errBadRequest
+	})
+	if err != errBadRequest || calls != 1 {
+		t.Fatalf("err = %v, calls = %d; want the original error after 1 call", err, calls)
+	}
+}
+
+func TestRetryAfterAboveMaxDelayStops(t *testing.T) {
+	classify := func(error) (RetryClass, time.Duration) { return RetryRateLimited, time.Hour }
+
+	calls := 0
+	err := Retry(context.Background(), fastPolicy(5), classify, func() error {
+		calls++
+		return errRateLimited
+	})
+	if calls != 1 || !IsRateLimitExhausted(err) {
+		t.Fatalf("err = %v, calls = %d; want rate limit exhaustion after 1 call", err, calls)
+	}
+}
@@ -0,0 +1,317 @@
+package toolx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// HTTP tool defaults
+const (
+	defaultHTTPToolTimeout  = 30 * time.Second
+	defaultMaxResponseBytes = 64 << 10
+	maxErrorBodyBytes       = 512
+)
+
+// HTTPRequest is the request an HTTP tool sends for one call
+type HTTPRequest struct {
+	Method  string            // Defaults to GET
+	URL     string            // Absolute http(s) URL on an allowed origin
+	Body    any               // Sent as JSON; []byte and string are sent as-is
+	Headers map[string]string // Override the defaults, including Authorization
+}
+
+// HTTPRequestFunc builds the request from the arguments the model sent,
+// decoded from JSON
+type HTTPRequestFunc func(args map[string]any) (HTTPRequest, error)
+
+// HTTPTool is a Toolx that performs an HTTP request and returns the response
+// body as the tool result. It is meant to expose existing REST endpoints to an
+// agent without writing a tool per endpoint:
+//
+//	listUsers := toolx.NewHTTPTool("list_users", "Lists the users of the tenant",
+//		map[string]any{"type": "object", "properties": map[string]any{}},
+//		func(args map[string]any) (toolx.HTTPRequest, error) {
+//			return toolx.HTTPRequest{URL: baseURL + "/api/v1/users"}, nil
+//		},
+//		toolx.WithAllowedOrigins(baseURL))
+//
+// The caller's credentials attached with WithAuthorization are sent as the
+// Authorization header, so the endpoint authorizes the call as the user that
+// started the run. Requests only go to the origins given with
+// WithAllowedOrigins, redirects included: the URL may be built from model
+// output, and a prompt-injected model must not be able to send the caller's
+// token, or any request, to another host. A tool without allowed origins
+// rejects every call.
+type HTTPTool struct {
+	name             string
+	description      string
+	schema           any
+	build            HTTPRequestFunc
+	client           *http.Client
+	maxResponseBytes int64
+	allowedOrigins   map[string]bool // scheme://host:port
+}
+
+// HTTPToolOption configures an HTTPTool
+type HTTPToolOption func(*HTTPTool)
+
+// WithHTTPClient sets the client used for the requests. Its Timeout applies
+// instead of the default 30s.
+func WithHTTPClient(client *http.Client) HTTPToolOption {
+	return func(t *HTTPTool) {
+		t.client = client
+	}
+}
+
+// WithHTTPTimeout bounds each request, including reading the response
+func WithHTTPTimeout(timeout time.Duration) HTTPToolOption {
+	return func(t *HTTPTool) {
+		t.client = &http.Client{Timeout: timeout}
+	}
+}
+
+// WithAllowedOrigins sets the base URLs (e.g. "https://api.example.com") the
+// tool may call; only their scheme, host and port are used. It panics on a
+// URL without scheme or host, which is a programming error.
+func WithAllowedOrigins(baseURLs ...string) HTTPToolOption {
+	return func(t *HTTPTool) {
+		for _, baseURL := range baseURLs {
+			u, err := url.Parse(baseURL)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				panic(fmt.Sprintf("toolx: invalid allowed origin %q", baseURL))
+			}
+			t.allowedOrigins[origin(u)] = true
+		}
+	}
+}
+
+// WithMaxResponseBytes caps the response body returned to the model; longer
+// bodies are truncated and marked as such
+func WithMaxResponseBytes(n int64) HTTPToolOption {
+	return func(t *HTTPTool) {
+		t.maxResponseBytes = n
+	}
+}
+
+// NewHTTPTool creates an HTTP tool. schema is the JSON schema of the
+// arguments, as in llm.Function.Parameters.
+func NewHTTPTool(name, description string, schema any, build HTTPRequestFunc, opts ...HTTPToolOption) *HTTPTool {
+	t := &HTTPTool{
+		name:             name,
+		description:      description,
+		schema:           schema,
+		build:            build,
+		client:           &http.Client{Timeout: defaultHTTPToolTimeout},
+		maxResponseBytes: defaultMaxResponseBytes,
+		allowedOrigins:   make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	// Redirects must stay on the allowed origins too; the client may be
+	// shared, so the tool uses a copy
+	client := *t.client
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !t.allowed(req.URL) {
+			return fmt.Errorf("redirect to %s is not allowed", req.URL.Redacted())
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	t.client = &client
+	return t
+}
+
+func (t *HTTPTool) Name() string { return t.name }
+
+func (t *HTTPTool) GetTool() llm.Tool {
+	return llm.Tool{
+		Type: "function",
+		Function: llm.Function{
+			Name:        t.name,
+			Description: t.description,
+			Parameters:  t.schema,
+		},
+	}
+}
+
+// Call performs the request. Responses with a status of 400 or more are
+// returned as an *HTTPStatusError.
+func (t *HTTPTool) Call(ctx context.Context, inputs string) (any, error) {
+	args := map[string]any{}
+	if strings.TrimSpace(inputs) != "" {
+		if err := json.Unmarshal([]byte(inputs), &args); err != nil {
+			return nil, fmt.Errorf("arguments must be a JSON object: %w", err)
+		}
+	}
+
+	spec, err := t.build(args)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := t.newRequest(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", req.Method, req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: truncate(body, maxErrorBodyBytes)}
+	}
+
+	if int64(len(body)) > t.maxResponseBytes {
+		return truncate(body, int(t.maxResponseBytes)) + fmt.Sprintf("\n[response truncated to %d bytes]", t.maxResponseBytes), nil
+	}
+	return string(body), nil
+}
+
+// newRequest builds the http.Request for spec
+func (t *HTTPTool) newRequest(ctx context.Context, spec HTTPRequest) (*http.Request, error) {
+	method := strings.ToUpper(spec.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	isJSON := false
+	switch b := spec.Body.(type) {
+	case nil:
+	case []byte:
+		body = bytes.NewReader(b)
+	case string:
+		body = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body = bytes.NewReader(data)
+		isJSON = true
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, spec.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid request URL %q: only http and https are supported", req.URL.Redacted())
+	}
+	if !t.allowed(req.URL) {
+		return nil, fmt.Errorf("request URL %q is not allowed: host is not one of the tool's allowed origins", req.URL.Redacted())
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authorization, ok := AuthorizationFromContext(ctx); ok {
+		req.Header.Set("Authorization", authorization)
+	}
+	for k, v := range spec.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// allowed reports whether u is on one of the allowed origins
+func (t *HTTPTool) allowed(u *url.URL) bool {
+	return t.allowedOrigins[origin(u)]
+}
+
+// origin returns the normalized scheme://host:port of u, filling in the
+// default port of the scheme
+func origin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		switch scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	return scheme + "://" + strings.ToLower(u.Hostname()) + ":" + port
+}
+
+// HTTPStatusError is returned by HTTPTool.Call for responses with a status of
+// 400 or more. Its message tells the model what kind of failure it was.
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string // Start of the response body
+}
+
+func (e *HTTPStatusError) Error() string {
+	var reason string
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		reason = "not authorized to perform this request"
+	case e.StatusCode == http.StatusNotFound:
+		reason = "resource not found"
+	case e.StatusCode == http.StatusConflict:
+		reason = "conflicts with the current state of the resource"
+	case e.StatusCode == http.StatusTooManyRequests:
+		reason = "rate limited, try again later"
+	case e.StatusCode >= http.StatusInternalServerError:
+		reason = "the service failed, try again later"
+	default:
+		reason = "the request was rejected, check the arguments"
+	}
+
+	msg := fmt.Sprintf("HTTP %d: %s", e.StatusCode, reason)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// authorizationKey is the context key of the Authorization header value
+type authorizationKey struct{}
+
+// WithAuthorization returns a copy of ctx whose HTTP tools send authorization
+// (e.g. "Bearer <token>") as the Authorization header
+func WithAuthorization(ctx context.Context, authorization string) context.Context {
+	return context.WithValue(ctx, authorizationKey{}, authorization)
+}
+
+// AuthorizationFromContext returns the value attached with WithAuthorization
+func AuthorizationFromContext(ctx context.Context) (string, bool) {
+	authorization, ok := ctx.Value(authorizationKey{}).(string)
+	return authorization, ok && authorization != ""
+}
+
+// truncate returns the first n bytes of b, dropping a rune cut in half
+func truncate(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	return strings.ToValidUTF8(string(b[:n]), "")
+}
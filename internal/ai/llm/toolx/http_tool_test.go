@@ -0,0 +1,109 @@
+package toolx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPTool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer caller-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/users/42":
+			w.Write([]byte(`{"id":"42","name":"Ada"}`))
+		case "/invitations":
+			body, _ := io.ReadAll(r.Body)
+			var payload map[string]any
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || json.Unmarshal(body, &payload) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"email":"` + payload["email"].(string) + `"}`))
+		case "/big":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	getUser := NewHTTPTool("get_user", "Gets a user", nil, func(args map[string]any) (HTTPRequest, error) {
+		return HTTPRequest{URL: server.URL + "/users/" + args["id"].(string)}, nil
+	}, WithMaxResponseBytes(64), WithAllowedOrigins(server.URL))
+	invite := NewHTTPTool("invite", "Invites a user", nil, func(args map[string]any) (HTTPRequest, error) {
+		return HTTPRequest{Method: http.MethodPost, URL: server.URL + "/invitations", Body: args}, nil
+	}, WithAllowedOrigins(server.URL))
+	big := NewHTTPTool("big", "Large response", nil, func(map[string]any) (HTTPRequest, error) {
+		return HTTPRequest{URL: server.URL + "/big"}, nil
+	}, WithMaxResponseBytes(10), WithAllowedOrigins(server.URL))
+
+	ctx := WithAuthorization(context.Background(), "Bearer caller-token")
+
+	if result, err := getUser.Call(ctx, `{"id":"42"}`); err != nil || result != `{"id":"42","name":"Ada"}` {
+		t.Errorf("get_user = %v, %v", result, err)
+	}
+	if result, err := invite.Call(ctx, `{"email":"ada@example.com"}`); err != nil || result != `{"email":"ada@example.com"}` {
+		t.Errorf("invite = %v, %v", result, err)
+	}
+	if result, err := big.Call(ctx, ``); err != nil || !strings.HasPrefix(result.(string), "xxxxxxxxxx\n[response truncated to 10 bytes]") {
+		t.Errorf("big = %q, %v, want a truncated body", result, err)
+	}
+
+	var statusErr *HTTPStatusError
+	if _, err := getUser.Call(ctx, `{"id":"7/missing"}`); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || !strings.Contains(err.Error(), "resource not found") {
+		t.Errorf("missing user error = %v, want a 404 HTTPStatusError", err)
+	}
+	if _, err := getUser.Call(context.Background(), `{"id":"42"}`); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("call without credentials error = %v, want a 401 HTTPStatusError", err)
+	}
+}
+
+func TestHTTPToolOnlyCallsAllowedOrigins(t *testing.T) {
+	var leaked []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, other.URL+"/steal", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer api.Close()
+
+	fetch := NewHTTPTool("fetch", "Fetches a URL", nil, func(args map[string]any) (HTTPRequest, error) {
+		return HTTPRequest{URL: args["url"].(string)}, nil
+	}, WithAllowedOrigins(api.URL+"/api/v1"))
+	ctx := WithAuthorization(context.Background(), "Bearer caller-token")
+
+	if result, err := fetch.Call(ctx, `{"url":"`+api.URL+`/users"}`); err != nil || result != "ok" {
+		t.Errorf("allowed origin = %v, %v", result, err)
+	}
+	for _, target := range []string{other.URL + "/steal", api.URL + "/redirect"} {
+		if _, err := fetch.Call(ctx, `{"url":"`+target+`"}`); err == nil {
+			t.Errorf("call to %s succeeded, want it rejected", target)
+		}
+	}
+	if len(leaked) > 0 {
+		t.Errorf("other host received %d requests (Authorization %q)", len(leaked), leaked)
+	}
+
+	unconfigured := NewHTTPTool("fetch", "Fetches a URL", nil, func(map[string]any) (HTTPRequest, error) {
+		return HTTPRequest{URL: api.URL}, nil
+	})
+	if _, err := unconfigured.Call(ctx, ``); err == nil {
+		t.Error("tool without allowed origins sent a request")
+	}
+}
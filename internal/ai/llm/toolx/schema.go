@@ -0,0 +1,223 @@
+package toolx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema used in tool parameter
+// declarations: type, properties, required, items, enum and
+// additionalProperties: false. Other keywords are ignored.
+type jsonSchema struct {
+	Type                 any                    `json:"type"` // string or []string
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+	AdditionalProperties any                    `json:"additionalProperties"` // bool or schema
+}
+
+// parseSchema converts a llm.Function.Parameters value (a map, a struct or raw
+// JSON) into a jsonSchema. A nil schema means there is nothing to validate.
+func parseSchema(parameters any) (*jsonSchema, error) {
+	if parameters == nil {
+		return nil, nil
+	}
+
+	var raw []byte
+	switch v := parameters.(type) {
+	case json.RawMessage:
+		raw = v
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		b, err := json.Marshal(parameters)
+		if err != nil {
+			return nil, err
+		}
+		raw = b
+	}
+
+	var schema jsonSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// ValidateArguments checks the raw JSON arguments sent by the model against
+// the tool's parameter schema and returns one message per violation, or nil
+// when the arguments are valid. An error is returned only when the schema
+// itself cannot be parsed.
+func ValidateArguments(parameters any, arguments string) ([]string, error) {
+	schema, err := parseSchema(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameter schema: %w", err)
+	}
+
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(arguments))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []string{"arguments are not valid JSON: " + err.Error()}, nil
+	}
+	if decoder.More() {
+		return []string{"arguments must be a single JSON value"}, nil
+	}
+
+	if schema == nil {
+		return nil, nil
+	}
+
+	var problems []string
+	schema.validate("$", value, &problems)
+	return problems, nil
+}
+
+func (s *jsonSchema) validate(path string, value any, problems *[]string) {
+	if s == nil {
+		return
+	}
+
+	if types := s.types(); len(types) > 0 {
+		actual := jsonType(value)
+		if !typeAllowed(types, actual, value) {
+			*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual))
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		allowed := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			b, _ := json.Marshal(v)
+			allowed[i] = string(b)
+		}
+		*problems = append(*problems, fmt.Sprintf("%s: must be one of %s", path, strings.Join(allowed, ", ")))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required field %q", path, name))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(path+"."+name, v[name], problems)
+				continue
+			}
+			switch extra := s.AdditionalProperties.(type) {
+			case bool:
+				if !extra {
+					*problems = append(*problems, fmt.Sprintf("%s: unknown field %q", path, name))
+				}
+			case map[string]any:
+				if sub, err := parseSchema(extra); err == nil {
+					sub.validate(path+"."+name, v[name], problems)
+				}
+			}
+		}
+	case []any:
+		for i, item := range v {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+		}
+	}
+}
+
+func (s *jsonSchema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// jsonType names the JSON type of a value decoded with UseNumber
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func typeAllowed(types []string, actual string, value any) bool {
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "integer" && actual == "number" && isInteger(value.(json.Number)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isInteger(n json.Number) bool {
+	if _, err := n.Int64(); err == nil {
+		return true
+	}
+	f, err := n.Float64()
+	return err == nil && f == float64(int64(f))
+}
+
+func inEnum(enum []any, value any) bool {
+	actual, err := json.Marshal(normalizeNumber(value))
+	if err != nil {
+		return false
+	}
+	for _, allowed := range enum {
+		b, err := json.Marshal(allowed)
+		if err == nil && bytes.Equal(b, actual) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeNumber turns json.Number into float64 so 1 and 1.0 compare equal
+// with enum values decoded without UseNumber
+func normalizeNumber(value any) any {
+	if n, ok := value.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
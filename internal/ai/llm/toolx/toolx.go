@@ -3,10 +3,16 @@ package toolx
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"runtime/debug"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/asyncx"
+	"github.com/Abraxas-365/manifesto/internal/logx"
 )
 
 type Toolx interface {
@@ -15,8 +21,20 @@ type Toolx interface {
 	Name() string
 }
 
+// MetadataToolError is the llm.Message metadata key under which Call records
+// why a tool call failed. Failures are returned to the model as regular tool
+// messages, so this is how callers tell them apart from results.
+const MetadataToolError = "tool_error"
+
+// errToolTimeout is returned for a tool call cut short by the per-call timeout
+var errToolTimeout = errors.New("tool call timed out")
+
+// errToolPanicked is returned for a tool call that panicked
+var errToolPanicked = errors.New("panic")
+
 type ToolxClient struct {
-	tools map[string]Toolx
+	tools   map[string]Toolx
+	timeout time.Duration // Per-call deadline; 0 means none
 }
 
 func FromToolx(tools ...Toolx) *ToolxClient {
@@ -27,6 +45,14 @@ func FromToolx(tools ...Toolx) *ToolxClient {
 	return &ToolxClient{tools: toolMap}
 }
 
+// WithTimeout bounds every tool call to timeout. The tool receives a context
+// with that deadline; a call still running when it expires is abandoned and
+// the model gets a tool message saying the tool timed out.
+func (t *ToolxClient) WithTimeout(timeout time.Duration) *ToolxClient {
+	t.timeout = timeout
+	return t
+}
+
 func (t *ToolxClient) GetTools() []llm.Tool {
 	tools := make([]llm.Tool, 0, len(t.tools))
 	for _, tool := range t.tools {
@@ -35,15 +61,34 @@ func (t *ToolxClient) GetTools() []llm.Tool {
 	return tools
 }
 
+// Call runs the tool requested by tc. Arguments that do not match the tool's
+// parameter schema are not passed to the tool: the returned tool message
+// lists the violations so the model can correct the call on its next turn.
+// The only error returned is ctx's, when the run itself is cancelled.
 func (t *ToolxClient) Call(ctx context.Context, tc llm.ToolCall) (llm.Message, error) {
 	tool, ok := t.tools[tc.Function.Name]
 	if !ok {
-		return llm.NewToolMessage(tc.ID, "This tool dont exists"), nil // create custom errors for this
+		return errorResult(tc.ID, "This tool dont exists", "unknown tool"), nil // create custom errors for this
+	}
+
+	// A schema that cannot be parsed is the tool's problem, not the model's;
+	// the tool then receives the arguments unchecked
+	if problems, err := ValidateArguments(tool.GetTool().Function.Parameters, tc.Function.Arguments); err == nil && len(problems) > 0 {
+		return errorResult(tc.ID, invalidArgumentsMessage(tc.Function.Name, problems), "invalid arguments: "+strings.Join(problems, "; ")), nil
 	}
 
-	result, err := tool.Call(ctx, tc.Function.Arguments)
+	result, err := t.invoke(ctx, tool, tc.Function.Arguments)
 	if err != nil {
-		return llm.NewToolMessage(tc.ID, "Error calling tool: "+err.Error()), nil //create a custom error for this
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return llm.Message{}, ctxErr
+		}
+		if errors.Is(err, errToolTimeout) {
+			return errorResult(tc.ID, fmt.Sprintf("Tool %q timed out after %s. Try a narrower request or continue without it.", tc.Function.Name, t.timeout), err.Error()), nil
+		}
+		if errors.Is(err, errToolPanicked) {
+			return errorResult(tc.ID, "Error calling tool: the tool failed unexpectedly", err.Error()), nil
+		}
+		return errorResult(tc.ID, "Error calling tool: "+err.Error(), err.Error()), nil //create a custom error for this
 	}
 
 	var resultStr string
@@ -64,9 +109,65 @@ func (t *ToolxClient) Call(ctx context.Context, tc llm.ToolCall) (llm.Message, e
 		// Use JSON marshaling for complex types
 		jsonBytes, jsonErr := json.Marshal(result)
 		if jsonErr != nil {
-			return llm.NewToolMessage(tc.ID, "Error converting result to string: "+jsonErr.Error()), nil //create a custom error for this
+			return errorResult(tc.ID, "Error converting result to string: "+jsonErr.Error(), jsonErr.Error()), nil //create a custom error for this
 		}
 		resultStr = string(jsonBytes)
 	}
 	return llm.NewToolMessage(tc.ID, resultStr), nil
 }
+
+// ToolError returns the failure Call recorded on a tool message, empty when the
+// call succeeded
+func ToolError(msg llm.Message) string {
+	reason, _ := msg.Metadata[MetadataToolError].(string)
+	return reason
+}
+
+// errorResult is the tool message sent to the model when a call fails
+func errorResult(toolCallID, content, reason string) llm.Message {
+	msg := llm.NewToolMessage(toolCallID, content)
+	msg.Metadata = map[string]any{MetadataToolError: reason}
+	return msg
+}
+
+// invoke runs the tool under the per-call timeout. asyncx.WithTimeout runs the
+// tool in its own goroutine so a tool that ignores its context cannot block
+// the agent past the deadline.
+func (t *ToolxClient) invoke(ctx context.Context, tool Toolx, arguments string) (any, error) {
+	if t.timeout <= 0 {
+		return callTool(ctx, tool, arguments)
+	}
+
+	result, err := asyncx.WithTimeout(ctx, t.timeout, func(callCtx context.Context) (any, error) {
+		return callTool(callCtx, tool, arguments)
+	})
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, errToolTimeout
+	}
+	return result, err
+}
+
+// callTool calls the tool, turning a panic into an errToolPanicked error: the
+// tool may run in a goroutine of its own, where a panic would bring the
+// process down
+func callTool(ctx context.Context, tool Toolx, arguments string) (result any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			logx.Errorf("tool %q panicked: %v\n%s", tool.Name(), p, debug.Stack())
+			result, err = nil, fmt.Errorf("%w: %v", errToolPanicked, p)
+		}
+	}()
+	return tool.Call(ctx, arguments)
+}
+
+// invalidArgumentsMessage is the tool result sent back to the model when its
+// arguments fail validation
+func invalidArgumentsMessage(name string, problems []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Invalid arguments for tool %q:\n", name)
+	for _, problem := range problems {
+		b.WriteString("- " + problem + "\n")
+	}
+	b.WriteString("Fix the arguments to match the tool's parameter schema and call it again.")
+	return b.String()
+}
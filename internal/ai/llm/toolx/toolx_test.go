@@ -0,0 +1,179 @@
+package toolx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+)
+
+// weatherTool declares a schema and counts how often it actually runs
+type weatherTool struct {
+	calls int
+}
+
+func (t *weatherTool) Name() string { return "weather" }
+
+func (t *weatherTool) GetTool() llm.Tool {
+	return llm.Tool{
+		Type: "function",
+		Function: llm.Function{
+			Name: "weather",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city":  map[string]any{"type": "string"},
+					"days":  map[string]any{"type": "integer"},
+					"units": map[string]any{"type": "string", "enum": []string{"metric", "imperial"}},
+				},
+				"required":             []string{"city"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+func (t *weatherTool) Call(context.Context, string) (any, error) {
+	t.calls++
+	return "sunny", nil
+}
+
+func TestCallValidatesArguments(t *testing.T) {
+	tool := &weatherTool{}
+	client := FromToolx(tool)
+
+	call := func(arguments string) string {
+		msg, err := client.Call(context.Background(), llm.ToolCall{
+			ID:       "call-1",
+			Function: llm.FunctionCall{Name: "weather", Arguments: arguments},
+		})
+		if err != nil {
+			t.Fatalf("Call(%s): %v", arguments, err)
+		}
+		return msg.Content
+	}
+
+	tests := []struct {
+		arguments string
+		want      []string
+	}{
+		{`{"days": 2}`, []string{`missing required field "city"`}},
+		{`{"city": 7, "days": 1.5}`, []string{"$.city: expected string, got number", "$.days: expected integer, got number"}},
+		{`{"city": "Lima", "units": "kelvin"}`, []string{`$.units: must be one of "metric", "imperial"`}},
+		{`{"city": "Lima", "country": "PE"}`, []string{`unknown field "country"`}},
+		{`{"city": `, []string{"not valid JSON"}},
+	}
+	for _, tt := range tests {
+		content := call(tt.arguments)
+		for _, want := range tt.want {
+			if !strings.Contains(content, want) {
+				t.Errorf("Call(%s) = %q, want it to contain %q", tt.arguments, content, want)
+			}
+		}
+	}
+	if tool.calls != 0 {
+		t.Fatalf("tool ran %d times with invalid arguments", tool.calls)
+	}
+
+	if content := call(`{"city": "Lima", "days": 3, "units": "metric"}`); content != "sunny" || tool.calls != 1 {
+		t.Errorf("valid call = %q after %d runs, want the tool result", content, tool.calls)
+	}
+}
+
+// slowTool blocks for delay, or until its context ends when it honors it
+type slowTool struct {
+	delay        time.Duration
+	honorContext bool
+	cancelled    chan error
+}
+
+func (t *slowTool) Name() string { return "slow" }
+
+func (t *slowTool) GetTool() llm.Tool {
+	return llm.Tool{Type: "function", Function: llm.Function{Name: "slow"}}
+}
+
+func (t *slowTool) Call(ctx context.Context, _ string) (any, error) {
+	if !t.honorContext {
+		time.Sleep(t.delay)
+		return "done", nil
+	}
+	select {
+	case <-time.After(t.delay):
+		return "done", nil
+	case <-ctx.Done():
+		t.cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	slowCall := llm.ToolCall{ID: "call-1", Function: llm.FunctionCall{Name: "slow"}}
+
+	for _, honorContext := range []bool{true, false} {
+		tool := &slowTool{delay: time.Second, honorContext: honorContext, cancelled: make(chan error, 1)}
+		client := FromToolx(tool).WithTimeout(20 * time.Millisecond)
+
+		start := time.Now()
+		msg, err := client.Call(context.Background(), slowCall)
+		if err != nil {
+			t.Fatalf("honorContext=%v: Call error = %v, want a timeout tool message", honorContext, err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("honorContext=%v: Call took %s, want it to return at the deadline", honorContext, elapsed)
+		}
+		if !strings.Contains(msg.Content, "timed out") || msg.ToolCallID != "call-1" {
+			t.Errorf("honorContext=%v: message = %+v, want a timeout result", honorContext, msg)
+		}
+
+		if honorContext {
+			select {
+			case cause := <-tool.cancelled:
+				if !errors.Is(cause, context.DeadlineExceeded) {
+					t.Errorf("tool context ended with %v, want DeadlineExceeded", cause)
+				}
+			case <-time.After(time.Second):
+				t.Error("tool context was not cancelled")
+			}
+		}
+	}
+
+	// A cancelled run is reported as an error, not as a tool result
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tool := &slowTool{delay: time.Second, honorContext: true, cancelled: make(chan error, 1)}
+	if _, err := FromToolx(tool).WithTimeout(time.Minute).Call(ctx, slowCall); !errors.Is(err, context.Canceled) {
+		t.Errorf("Call with cancelled context error = %v, want context.Canceled", err)
+	}
+}
+
+type panickingTool struct{}
+
+func (panickingTool) Name() string { return "panics" }
+
+func (panickingTool) GetTool() llm.Tool {
+	return llm.Tool{Type: "function", Function: llm.Function{Name: "panics"}}
+}
+
+func (panickingTool) Call(context.Context, string) (any, error) {
+	panic("boom")
+}
+
+func TestCallRecoversPanics(t *testing.T) {
+	panicCall := llm.ToolCall{ID: "call-1", Function: llm.FunctionCall{Name: "panics"}}
+
+	// With a timeout the tool runs in its own goroutine, where an unrecovered
+	// panic would crash the test binary
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		msg, err := FromToolx(panickingTool{}).WithTimeout(timeout).Call(context.Background(), panicCall)
+		if err != nil {
+			t.Fatalf("timeout=%s: Call error = %v, want a tool error message", timeout, err)
+		}
+		if reason := ToolError(msg); !strings.Contains(reason, "boom") || strings.Contains(msg.Content, "boom") {
+			t.Errorf("timeout=%s: message = %+v, want the panic recorded but not shown to the model", timeout, msg)
+		}
+	}
+}
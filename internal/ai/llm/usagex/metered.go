@@ -0,0 +1,172 @@
+package usagex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/memoryx"
+)
+
+// MeteredLLM records the usage of every successful Chat call and of every
+// stream with a Meter.
+// It wraps any llm.LLM, an llm.Client included, so it works with every
+// provider.
+type MeteredLLM struct {
+	llm          llm.LLM
+	meter        *Meter
+	defaultModel string
+}
+
+var _ llm.LLM = (*MeteredLLM)(nil)
+
+// NewMeteredLLM wraps next. defaultModel prices calls that do not set
+// llm.WithModel; pass the model the provider falls back to.
+func NewMeteredLLM(next llm.LLM, meter *Meter, defaultModel string) *MeteredLLM {
+	return &MeteredLLM{llm: next, meter: meter, defaultModel: defaultModel}
+}
+
+func (m *MeteredLLM) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	response, err := m.llm.Chat(ctx, messages, opts...)
+	if err != nil {
+		return response, err
+	}
+	m.meter.RecordChat(ctx, m.model(opts), response.Usage)
+	return response, nil
+}
+
+// ChatStream meters the stream once it ends or is closed. Streams that learn
+// their usage from the provider (llm.UsageStream) are recorded with it;
+// others, and streams closed before the provider reported it, are recorded
+// with an estimate (see memoryx.CharBasedEstimator), since the provider bills
+// them all the same.
+func (m *MeteredLLM) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	stream, err := m.llm.ChatStream(ctx, messages, opts...)
+	if err != nil {
+		return stream, err
+	}
+	return &meteredStream{
+		Stream:   stream,
+		ctx:      ctx,
+		meter:    m.meter,
+		model:    m.model(opts),
+		messages: messages,
+	}, nil
+}
+
+func (m *MeteredLLM) model(opts []llm.Option) string {
+	var options llm.ChatOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Model != "" {
+		return options.Model
+	}
+	return m.defaultModel
+}
+
+// MeteredEmbedder records the usage of every successful embedding call with
+// a Meter
+type MeteredEmbedder struct {
+	embedder     embedding.Embedder
+	meter        *Meter
+	defaultModel string
+}
+
+var _ embedding.Embedder = (*MeteredEmbedder)(nil)
+
+// NewMeteredEmbedder wraps next. defaultModel prices calls that do not set
+// embedding.WithModel.
+func NewMeteredEmbedder(next embedding.Embedder, meter *Meter, defaultModel string) *MeteredEmbedder {
+	return &MeteredEmbedder{embedder: next, meter: meter, defaultModel: defaultModel}
+}
+
+func (m *MeteredEmbedder) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	embeddings, err := m.embedder.EmbedDocuments(ctx, documents, opts...)
+	if err != nil {
+		return embeddings, err
+	}
+	// Every embedding carries the usage of the whole call (see EmbedInBatches)
+	if len(embeddings) > 0 {
+		m.meter.RecordEmbedding(ctx, m.model(opts), embeddings[0].Usage)
+	}
+	return embeddings, nil
+}
+
+func (m *MeteredEmbedder) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	result, err := m.embedder.EmbedQuery(ctx, text, opts...)
+	if err != nil {
+		return result, err
+	}
+	m.meter.RecordEmbedding(ctx, m.model(opts), result.Usage)
+	return result, nil
+}
+
+func (m *MeteredEmbedder) model(opts []embedding.Option) string {
+	var options embedding.EmbeddingOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Model != "" {
+		return options.Model
+	}
+	return m.defaultModel
+}
+
+// meteredStream records the usage of a stream once, when Next reports its end
+// or the caller closes it
+type meteredStream struct {
+	llm.Stream
+	ctx      context.Context
+	meter    *Meter
+	model    string
+	messages []llm.Message
+
+	output    llm.Message // Text and tool calls streamed so far
+	ended     bool
+	recording sync.Once
+}
+
+func (s *meteredStream) Next() (llm.Message, error) {
+	chunk, err := s.Stream.Next()
+	if err != nil {
+		s.ended = errors.Is(err, io.EOF)
+		s.record()
+		return chunk, err
+	}
+	s.output.Content += chunk.Content
+	if len(chunk.ToolCalls) > 0 {
+		// Chunks carry every tool call accumulated so far
+		s.output.ToolCalls = chunk.ToolCalls
+	}
+	return chunk, nil
+}
+
+func (s *meteredStream) Close() error {
+	s.record()
+	return s.Stream.Close()
+}
+
+// record meters the reported usage or, when there is none, an estimate for
+// streams that completed or produced output. A stream that failed before its
+// first chunk is not billed, like a failed Chat.
+func (s *meteredStream) record() {
+	s.recording.Do(func() {
+		var usage llm.Usage
+		if reporter, ok := s.Stream.(llm.UsageStream); ok {
+			usage = reporter.Usage()
+		}
+		if usage.PromptTokens == 0 && usage.TotalTokens == 0 {
+			if !s.ended && s.output.Content == "" && len(s.output.ToolCalls) == 0 {
+				return
+			}
+			estimator := &memoryx.CharBasedEstimator{}
+			usage.PromptTokens = estimator.EstimateTokens(s.messages)
+			usage.CompletionTokens = estimator.EstimateTokens([]llm.Message{s.output})
+		}
+		s.meter.RecordChat(s.ctx, s.model, usage)
+	})
+}
@@ -0,0 +1,111 @@
+package usagex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+)
+
+// Price is the price of a model in USD per 1M tokens
+type Price struct {
+	Input  float64 // Prompt tokens
+	Output float64 // Completion tokens, reasoning included
+
+	// CachedInput is charged for prompt tokens served from the prompt cache;
+	// zero charges them as Input
+	CachedInput float64
+}
+
+// Pricing maps model names to their price
+type Pricing map[string]Price
+
+// DefaultPricing holds list prices at the time of writing. Override or extend
+// it with LLM_PRICING (see ParsePricing) rather than editing it when prices
+// change. Embedding models come from embedding.PricePerMillionTokens, the
+// table embedding.Estimate budgets with, so both agree.
+var DefaultPricing = withEmbeddingPrices(Pricing{
+	// OpenAI chat
+	"gpt-4.1":      {Input: 2.00, CachedInput: 0.50, Output: 8.00},
+	"gpt-4.1-mini": {Input: 0.40, CachedInput: 0.10, Output: 1.60},
+	"gpt-4.1-nano": {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+	"gpt-4o":       {Input: 2.50, CachedInput: 1.25, Output: 10.00},
+	"gpt-4o-mini":  {Input: 0.15, CachedInput: 0.075, Output: 0.60},
+	"o3":           {Input: 2.00, CachedInput: 0.50, Output: 8.00},
+	"o4-mini":      {Input: 1.10, CachedInput: 0.275, Output: 4.40},
+
+	// Anthropic
+	"claude-sonnet-4-5": {Input: 3.00, CachedInput: 0.30, Output: 15.00},
+	"claude-haiku-4-5":  {Input: 1.00, CachedInput: 0.10, Output: 5.00},
+
+	// Gemini
+	"gemini-2.5-pro":   {Input: 1.25, CachedInput: 0.31, Output: 10.00},
+	"gemini-2.5-flash": {Input: 0.30, CachedInput: 0.075, Output: 2.50},
+})
+
+// withEmbeddingPrices adds the embedding models, which are charged for input
+// only
+func withEmbeddingPrices(pricing Pricing) Pricing {
+	for model, price := range embedding.PricePerMillionTokens {
+		pricing[model] = Price{Input: price}
+	}
+	return pricing
+}
+
+// ParsePricing parses overrides in the LLM_PRICING format, one
+// "input/output" or "input/output/cached_input" price per model, and returns
+// base with them applied:
+//
+//	gpt-4.1=2.00/8.00/0.50,my-finetune=3.00/12.00
+//
+// The result is a new map; base is not modified.
+func ParsePricing(base Pricing, overrides map[string]string) (Pricing, error) {
+	pricing := make(Pricing, len(base)+len(overrides))
+	for model, price := range base {
+		pricing[model] = price
+	}
+
+	for model, value := range overrides {
+		parts := strings.Split(value, "/")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid price %q for model %q: want input/output[/cached_input]", value, model)
+		}
+
+		prices := make([]float64, len(parts))
+		for i, part := range parts {
+			p, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil || p < 0 {
+				return nil, fmt.Errorf("invalid price %q for model %q: want non-negative numbers", value, model)
+			}
+			prices[i] = p
+		}
+
+		price := Price{Input: prices[0], Output: prices[1]}
+		if len(prices) == 3 {
+			price.CachedInput = prices[2]
+		}
+		pricing[model] = price
+	}
+	return pricing, nil
+}
+
+// Cost returns the cost in USD of a call to model, and false when the model
+// has no price. Cached prompt tokens are charged at CachedInput when set.
+func (p Pricing) Cost(model string, promptTokens, cachedTokens, completionTokens int) (float64, bool) {
+	price, ok := p[model]
+	if !ok {
+		return 0, false
+	}
+
+	cachedTokens = min(cachedTokens, promptTokens)
+	cachedPrice := price.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+
+	cost := float64(promptTokens-cachedTokens)*price.Input +
+		float64(cachedTokens)*cachedPrice +
+		float64(completionTokens)*price.Output
+	return cost / 1_000_000, true
+}
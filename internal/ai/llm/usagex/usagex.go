@@ -0,0 +1,217 @@
+// Package usagex meters LLM and embedding usage per tenant, for tenants on
+// metered plans.
+//
+// [MeteredLLM] and [MeteredEmbedder] decorate any provider (or an llm.Client)
+// and hand the token usage of every successful call to a [Meter], which
+// prices it with a [Pricing] table and stores it through a [Store]. Calls are
+// attributed to the tenant and user of kernel.AuthContextFrom(ctx); calls
+// without one (background jobs, health checks) are not metered.
+//
+//	meter := usagex.NewMeter(usagexpg.NewStore(db), usagex.DefaultPricing)
+//	client := llm.NewClient(usagex.NewMeteredLLM(provider, meter, "gpt-4.1"))
+//	embedder := usagex.NewMeteredEmbedder(provider, meter, "text-embedding-3-small")
+//
+// Streams are recorded when they end or are closed, with the usage the
+// provider reported (llm.UsageStream) or, failing that, an estimate.
+//
+// With [WithQuota] the tokens of every metered call also count against the
+// tenant's monthly quota, which auth.EntitlementMiddleware.RequireQuota
+// enforces:
+//
+//	meter := usagex.NewMeter(store, pricing, usagex.WithQuota(
+//		func(ctx context.Context, tenantID kernel.TenantID, tokens int64) error {
+//			_, err := quotaService.Consume(ctx, tenantID, tenant.QuotaLLMTokensMonthly, tokens)
+//			return err
+//		}))
+//
+// usagexpg stores usage in Postgres and usagexapi serves GET /usage/llm.
+package usagex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// recordTimeout bounds a Store.Record call. Usage is recorded after the
+// provider answered, so it must not fail or stall the call it meters.
+const recordTimeout = 5 * time.Second
+
+// Operation is the kind of call a Record meters
+type Operation string
+
+const (
+	OperationChat      Operation = "chat"
+	OperationEmbedding Operation = "embedding"
+)
+
+// Record is the usage of a single call
+type Record struct {
+	TenantID  kernel.TenantID
+	UserID    *kernel.UserID // nil for API keys not bound to a user
+	Operation Operation
+	Model     string
+
+	PromptTokens     int
+	CompletionTokens int
+	CachedTokens     int
+	TotalTokens      int
+
+	// CostUSD is zero for models without a price
+	CostUSD   float64
+	CreatedAt time.Time
+}
+
+// Period is the granularity of aggregated usage
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodMonth Period = "month"
+)
+
+// IsValid reports whether p is a known period
+func (p Period) IsValid() bool {
+	return p == PeriodDay || p == PeriodMonth
+}
+
+// Query selects the usage of a tenant in [From, To)
+type Query struct {
+	TenantID kernel.TenantID
+	Period   Period
+	From     time.Time
+	To       time.Time
+}
+
+// Bucket is the usage of one model in one day or month
+type Bucket struct {
+	// Start is the first instant of the day or month, in UTC
+	Start     time.Time `json:"start"`
+	Operation Operation `json:"operation"`
+	Model     string    `json:"model"`
+
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// Store persists usage records and aggregates them
+type Store interface {
+	Record(ctx context.Context, record Record) error
+
+	// Aggregate returns the buckets of q ordered by Start, then operation and
+	// model
+	Aggregate(ctx context.Context, q Query) ([]Bucket, error)
+}
+
+// QuotaFunc adds the tokens of a metered call to the tenant's monthly quota,
+// e.g. with tenantsrv.QuotaService.Consume and tenant.QuotaLLMTokensMonthly
+type QuotaFunc func(ctx context.Context, tenantID kernel.TenantID, tokens int64) error
+
+// Meter prices calls and records them for the tenant in the context
+type Meter struct {
+	store   Store
+	pricing Pricing
+	quota   QuotaFunc
+
+	unpriced sync.Map // Models already warned about, see record
+}
+
+// MeterOption configures a Meter
+type MeterOption func(*Meter)
+
+// WithQuota counts the tokens of every metered call against the tenant's
+// quota, so auth.EntitlementMiddleware.RequireQuota rejects once it is used up
+func WithQuota(quota QuotaFunc) MeterOption {
+	return func(m *Meter) {
+		m.quota = quota
+	}
+}
+
+// NewMeter creates a meter. Models missing from pricing are recorded with
+// their tokens and a zero cost. A nil store only counts quotas (see
+// WithQuota).
+func NewMeter(store Store, pricing Pricing, opts ...MeterOption) *Meter {
+	m := &Meter{store: store, pricing: pricing}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// RecordChat records the usage of a chat completion
+func (m *Meter) RecordChat(ctx context.Context, model string, usage llm.Usage) {
+	m.record(ctx, Record{
+		Operation:        OperationChat,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		CachedTokens:     usage.CachedTokens,
+		TotalTokens:      usage.TotalTokens,
+	})
+}
+
+// RecordEmbedding records the usage of an embedding call
+func (m *Meter) RecordEmbedding(ctx context.Context, model string, usage embedding.Usage) {
+	m.record(ctx, Record{
+		Operation:    OperationEmbedding,
+		Model:        model,
+		PromptTokens: usage.PromptTokens,
+		TotalTokens:  usage.TotalTokens,
+	})
+}
+
+// record attributes the call to the caller in ctx and stores it. Failures
+// are logged, never returned: the call already succeeded and was billed by
+// the provider.
+func (m *Meter) record(ctx context.Context, record Record) {
+	authContext, ok := kernel.AuthContextFrom(ctx)
+	if !ok {
+		return
+	}
+	if record.TotalTokens == 0 {
+		record.TotalTokens = record.PromptTokens + record.CompletionTokens
+	}
+	if record.TotalTokens == 0 {
+		// The provider reported no usage
+		return
+	}
+
+	record.TenantID = authContext.TenantID
+	record.UserID = authContext.UserID
+	record.CreatedAt = time.Now().UTC()
+
+	cost, priced := m.pricing.Cost(record.Model, record.PromptTokens, record.CachedTokens, record.CompletionTokens)
+	if !priced && m.store != nil {
+		if _, warned := m.unpriced.LoadOrStore(record.Model, true); !warned {
+			logx.Warnf("no LLM price for model %q, usage recorded without cost", record.Model)
+		}
+	}
+	record.CostUSD = cost
+
+	// The call's context may be cancelled as soon as it returns
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	log := logx.WithFields(logx.Fields{
+		"tenant_id": record.TenantID.String(),
+		"model":     record.Model,
+	})
+	if m.store != nil {
+		if err := m.store.Record(ctx, record); err != nil {
+			log.Warnf("failed to record LLM usage: %v", err)
+		}
+	}
+	if m.quota != nil {
+		if err := m.quota(ctx, record.TenantID, int64(record.TotalTokens)); err != nil {
+			log.Warnf("failed to count LLM usage against the quota: %v", err)
+		}
+	}
+}
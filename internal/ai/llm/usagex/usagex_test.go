@@ -0,0 +1,313 @@
+package usagex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+type recordingStore struct {
+	records []Record
+}
+
+func (s *recordingStore) Record(_ context.Context, record Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *recordingStore) Aggregate(context.Context, Query) ([]Bucket, error) {
+	return nil, nil
+}
+
+type fixedLLM struct {
+	usage llm.Usage
+}
+
+func (l *fixedLLM) Chat(context.Context, []llm.Message, ...llm.Option) (llm.Response, error) {
+	return llm.Response{Usage: l.usage}, nil
+}
+
+func (l *fixedLLM) ChatStream(context.Context, []llm.Message, ...llm.Option) (llm.Stream, error) {
+	return nil, nil
+}
+
+type fixedEmbedder struct {
+	usage embedding.Usage
+}
+
+func (e *fixedEmbedder) EmbedDocuments(_ context.Context, documents []string, _ ...embedding.Option) ([]embedding.Embedding, error) {
+	embeddings := make([]embedding.Embedding, len(documents))
+	for i := range embeddings {
+		embeddings[i].Usage = e.usage
+	}
+	return embeddings, nil
+}
+
+func (e *fixedEmbedder) EmbedQuery(context.Context, string, ...embedding.Option) (embedding.Embedding, error) {
+	return embedding.Embedding{Usage: e.usage}, nil
+}
+
+func tenantContext() context.Context {
+	userID := kernel.UserID("u1")
+	return kernel.WithAuthContext(context.Background(), &kernel.AuthContext{
+		UserID:   &userID,
+		TenantID: kernel.TenantID("t1"),
+	})
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
+
+func TestPricingCost(t *testing.T) {
+	pricing := Pricing{
+		"chat":  {Input: 2, CachedInput: 0.5, Output: 8},
+		"embed": {Input: 0.02},
+	}
+
+	cost, ok := pricing.Cost("chat", 1_000_000, 400_000, 500_000)
+	if want := 0.6*2 + 0.4*0.5 + 0.5*8; !ok || !almostEqual(cost, want) {
+		t.Errorf("chat cost = %v, %v, want %v", cost, ok, want)
+	}
+
+	// Cached tokens fall back to the input price
+	cost, _ = pricing.Cost("embed", 1_000_000, 1_000_000, 0)
+	if !almostEqual(cost, 0.02) {
+		t.Errorf("embed cost = %v, want 0.02", cost)
+	}
+
+	if _, ok := pricing.Cost("unknown", 10, 0, 10); ok {
+		t.Error("unknown model priced")
+	}
+}
+
+func TestParsePricing(t *testing.T) {
+	base := Pricing{"gpt-4.1": {Input: 2, Output: 8}}
+
+	pricing, err := ParsePricing(base, map[string]string{
+		"gpt-4.1": "1.5/6/0.25",
+		"custom":  "3/12",
+	})
+	if err != nil {
+		t.Fatalf("ParsePricing: %v", err)
+	}
+	if got := pricing["gpt-4.1"]; got != (Price{Input: 1.5, Output: 6, CachedInput: 0.25}) {
+		t.Errorf("gpt-4.1 = %+v", got)
+	}
+	if got := pricing["custom"]; got != (Price{Input: 3, Output: 12}) {
+		t.Errorf("custom = %+v", got)
+	}
+	if base["gpt-4.1"].Input != 2 {
+		t.Error("base pricing was modified")
+	}
+
+	for _, value := range []string{"3", "3/x", "1/2/3/4", "-1/2"} {
+		if _, err := ParsePricing(nil, map[string]string{"m": value}); err == nil {
+			t.Errorf("ParsePricing(%q) succeeded", value)
+		}
+	}
+}
+
+func TestMeteredLLMRecordsTenantUsage(t *testing.T) {
+	store := &recordingStore{}
+	meter := NewMeter(store, Pricing{"gpt-4.1": {Input: 2, Output: 8}, "gpt-4.1-mini": {Input: 0.4, Output: 1.6}})
+	client := llm.NewClient(NewMeteredLLM(&fixedLLM{usage: llm.Usage{
+		PromptTokens:     1000,
+		CompletionTokens: 500,
+		TotalTokens:      1500,
+	}}, meter, "gpt-4.1"))
+
+	if _, err := client.Chat(tenantContext(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Chat(tenantContext(), nil, llm.WithModel("gpt-4.1-mini")); err != nil {
+		t.Fatal(err)
+	}
+	// No tenant: not metered
+	if _, err := client.Chat(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.records) != 2 {
+		t.Fatalf("recorded %d calls, want 2", len(store.records))
+	}
+	first, second := store.records[0], store.records[1]
+	if first.TenantID != "t1" || first.UserID == nil || *first.UserID != "u1" || first.Operation != OperationChat {
+		t.Errorf("unexpected record %+v", first)
+	}
+	if first.Model != "gpt-4.1" || !almostEqual(first.CostUSD, (1000*2+500*8)/1e6) {
+		t.Errorf("default model record = %s %v", first.Model, first.CostUSD)
+	}
+	if second.Model != "gpt-4.1-mini" || !almostEqual(second.CostUSD, (1000*0.4+500*1.6)/1e6) {
+		t.Errorf("WithModel record = %s %v", second.Model, second.CostUSD)
+	}
+}
+
+func TestMeteredEmbedderRecordsCallOnce(t *testing.T) {
+	store := &recordingStore{}
+	meter := NewMeter(store, Pricing{"text-embedding-3-small": {Input: 0.02}})
+	embedder := NewMeteredEmbedder(&fixedEmbedder{usage: embedding.Usage{PromptTokens: 300, TotalTokens: 300}}, meter, "text-embedding-3-small")
+
+	if _, err := embedder.EmbedDocuments(tenantContext(), []string{"a", "b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.records) != 1 {
+		t.Fatalf("recorded %d calls, want 1", len(store.records))
+	}
+	if r := store.records[0]; r.Operation != OperationEmbedding || r.TotalTokens != 300 || !almostEqual(r.CostUSD, 300*0.02/1e6) {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
+
+func TestMeterCountsQuota(t *testing.T) {
+	consumed := map[kernel.TenantID]int64{}
+	meter := NewMeter(nil, nil, WithQuota(func(_ context.Context, tenantID kernel.TenantID, tokens int64) error {
+		consumed[tenantID] += tokens
+		return nil
+	}))
+	client := llm.NewClient(NewMeteredLLM(&fixedLLM{usage: llm.Usage{PromptTokens: 1000, CompletionTokens: 500}}, meter, "gpt-4.1"))
+	embedder := NewMeteredEmbedder(&fixedEmbedder{usage: embedding.Usage{PromptTokens: 300}}, meter, "text-embedding-3-small")
+
+	if _, err := client.Chat(tenantContext(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := embedder.EmbedQuery(tenantContext(), "q"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Chat(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if consumed["t1"] != 1800 || len(consumed) != 1 {
+		t.Errorf("consumed = %v, want 1800 tokens for t1", consumed)
+	}
+}
+
+// sliceStream yields chunks, then err (io.EOF when nil)
+type sliceStream struct {
+	chunks []llm.Message
+	err    error
+	usage  llm.Usage
+}
+
+func (s *sliceStream) Next() (llm.Message, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return llm.Message{}, s.err
+		}
+		return llm.Message{}, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *sliceStream) Close() error { return nil }
+
+// reportingStream is a sliceStream that knows its usage, like OpenAI's
+type reportingStream struct{ *sliceStream }
+
+func (s reportingStream) Usage() llm.Usage { return s.usage }
+
+type streamLLM struct{ stream llm.Stream }
+
+func (l streamLLM) Chat(context.Context, []llm.Message, ...llm.Option) (llm.Response, error) {
+	return llm.Response{}, nil
+}
+
+func (l streamLLM) ChatStream(context.Context, []llm.Message, ...llm.Option) (llm.Stream, error) {
+	return l.stream, nil
+}
+
+func TestMeteredLLMRecordsStreams(t *testing.T) {
+	chunks := func() []llm.Message {
+		return []llm.Message{{Content: strings.Repeat("a", 40)}, {Content: strings.Repeat("b", 40)}}
+	}
+	prompt := []llm.Message{{Role: llm.RoleUser, Content: strings.Repeat("q", 400)}}
+	drain := func(stream llm.Stream) {
+		for {
+			if _, err := stream.Next(); err != nil {
+				break
+			}
+		}
+		stream.Close()
+	}
+
+	tests := []struct {
+		name   string
+		stream llm.Stream
+		read   func(llm.Stream)
+		want   *Record
+	}{
+		{
+			name:   "reported usage",
+			stream: reportingStream{&sliceStream{chunks: chunks(), usage: llm.Usage{PromptTokens: 90, CompletionTokens: 25, TotalTokens: 115}}},
+			read:   drain,
+			want:   &Record{PromptTokens: 90, CompletionTokens: 25, TotalTokens: 115},
+		},
+		{
+			name:   "estimated without usage",
+			stream: &sliceStream{chunks: chunks()},
+			read:   drain,
+			want:   &Record{PromptTokens: 104, CompletionTokens: 24, TotalTokens: 128},
+		},
+		{
+			name:   "closed early",
+			stream: &sliceStream{chunks: chunks()},
+			read: func(stream llm.Stream) {
+				stream.Next()
+				stream.Close()
+				stream.Close()
+			},
+			want: &Record{PromptTokens: 104, CompletionTokens: 14, TotalTokens: 118},
+		},
+		{
+			name:   "failed before output",
+			stream: &sliceStream{err: errors.New("rate limited")},
+			read:   drain,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &recordingStore{}
+			metered := NewMeteredLLM(streamLLM{stream: tt.stream}, NewMeter(store, nil), "gpt-4.1")
+
+			stream, err := metered.ChatStream(tenantContext(), prompt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.read(stream)
+
+			if tt.want == nil {
+				if len(store.records) != 0 {
+					t.Errorf("recorded %+v, want nothing", store.records)
+				}
+				return
+			}
+			if len(store.records) != 1 {
+				t.Fatalf("recorded %d times, want once", len(store.records))
+			}
+			got := store.records[0]
+			if got.Model != "gpt-4.1" || got.PromptTokens != tt.want.PromptTokens ||
+				got.CompletionTokens != tt.want.CompletionTokens || got.TotalTokens != tt.want.TotalTokens {
+				t.Errorf("record = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultPricingIncludesEmbeddingModels(t *testing.T) {
+	for model, price := range embedding.PricePerMillionTokens {
+		if got := DefaultPricing[model]; got != (Price{Input: price}) {
+			t.Errorf("DefaultPricing[%s] = %+v, want input %v", model, got, price)
+		}
+	}
+}
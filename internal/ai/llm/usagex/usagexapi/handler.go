@@ -0,0 +1,167 @@
+// Package usagexapi exposes the LLM usage of the caller's tenant:
+//
+//	GET /usage/llm?period=day&from=2026-01-01&to=2026-01-31
+//
+// period is "day" (default) or "month". from and to are inclusive dates
+// (YYYY-MM-DD, UTC); they default to the last 30 days, or to the last 12
+// months for period=month. Buckets hold one row per period, operation and
+// model, and total sums them all.
+package usagexapi
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/usagex"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/gofiber/fiber/v2"
+)
+
+const dateLayout = "2006-01-02"
+
+// Range limits keep a single response small
+const (
+	maxDays   = 366
+	maxMonths = 36
+)
+
+// UsageResponse is the body of GET /usage/llm
+type UsageResponse struct {
+	Period   usagex.Period   `json:"period"`
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Currency string          `json:"currency"`
+	Total    Total           `json:"total"`
+	Buckets  []usagex.Bucket `json:"buckets"`
+}
+
+// Total is the usage of the whole range
+type Total struct {
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// UsageHandlers serves the usage of the authenticated tenant; the tenant
+// never comes from the request
+type UsageHandlers struct {
+	store usagex.Store
+}
+
+func NewUsageHandlers(store usagex.Store) *UsageHandlers {
+	return &UsageHandlers{store: store}
+}
+
+// RegisterRoutes mounts the usage routes. They require "usage:read" or admin.
+func (h *UsageHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	usage := router.Group("/usage", authMiddleware.Authenticate())
+
+	usage.Get("/llm", authMiddleware.RequireAdminOrScope(scopes.ScopeUsageRead), h.GetLLMUsage)
+}
+
+// GetLLMUsage returns the LLM usage of the caller's tenant aggregated by day
+// or month
+func (h *UsageHandlers) GetLLMUsage(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	q, err := parseQuery(c, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	q.TenantID = authContext.TenantID
+
+	buckets, err := h.store.Aggregate(c.UserContext(), q)
+	if err != nil {
+		return errx.Wrap(err, "failed to load LLM usage", errx.TypeInternal)
+	}
+
+	var total Total
+	for _, b := range buckets {
+		total.Calls += b.Calls
+		total.PromptTokens += b.PromptTokens
+		total.CompletionTokens += b.CompletionTokens
+		total.CachedTokens += b.CachedTokens
+		total.TotalTokens += b.TotalTokens
+		total.CostUSD += b.CostUSD
+	}
+	if buckets == nil {
+		buckets = []usagex.Bucket{}
+	}
+
+	return c.JSON(UsageResponse{
+		Period:   q.Period,
+		From:     q.From.Format(dateLayout),
+		To:       q.To.AddDate(0, 0, -1).Format(dateLayout),
+		Currency: "USD",
+		Total:    total,
+		Buckets:  buckets,
+	})
+}
+
+// parseQuery reads period, from and to. Months start on the first day of
+// the month of from and end with the month of to, so buckets are never
+// partial at the edges.
+func parseQuery(c *fiber.Ctx, now time.Time) (usagex.Query, error) {
+	q := usagex.Query{Period: usagex.Period(c.Query("period", string(usagex.PeriodDay)))}
+	if !q.Period.IsValid() {
+		return q, errx.Validation("period must be day or month").WithDetail("period", string(q.Period))
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to, err := parseDate(c.Query("to"), today, "to")
+	if err != nil {
+		return q, err
+	}
+
+	defaultFrom := to.AddDate(0, 0, -29)
+	if q.Period == usagex.PeriodMonth {
+		defaultFrom = to.AddDate(0, -11, 1-to.Day())
+	}
+	from, err := parseDate(c.Query("from"), defaultFrom, "from")
+	if err != nil {
+		return q, err
+	}
+
+	// to is inclusive; the query end is exclusive
+	end := to.AddDate(0, 0, 1)
+	if q.Period == usagex.PeriodMonth {
+		from = from.AddDate(0, 0, 1-from.Day())
+		end = to.AddDate(0, 1, 1-to.Day())
+	}
+	if !from.Before(end) {
+		return q, errx.Validation("from must not be after to")
+	}
+
+	switch q.Period {
+	case usagex.PeriodDay:
+		if end.Sub(from) > maxDays*24*time.Hour {
+			return q, errx.Validation("range is too long").WithDetail("max_days", maxDays)
+		}
+	case usagex.PeriodMonth:
+		if from.AddDate(0, maxMonths, 0).Before(end) {
+			return q, errx.Validation("range is too long").WithDetail("max_months", maxMonths)
+		}
+	}
+
+	q.From, q.To = from, end
+	return q, nil
+}
+
+func parseDate(value string, fallback time.Time, param string) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, errx.Validation(param+" must be a date in YYYY-MM-DD format").WithDetail(param, value)
+	}
+	return t, nil
+}
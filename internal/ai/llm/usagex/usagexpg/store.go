@@ -0,0 +1,105 @@
+// Package usagexpg provides a Postgres-backed usagex.Store. The table is
+// created by migrations/019_llm_usage.up.sql.
+package usagexpg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm/usagex"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store keeps one row per metered call in llm_usage and aggregates them with
+// date_trunc, so days and months are UTC calendar days and months
+type Store struct {
+	db *sqlx.DB
+}
+
+var _ usagex.Store = (*Store)(nil)
+
+// NewStore creates a store
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Record(ctx context.Context, record usagex.Record) error {
+	var userID *string
+	if record.UserID != nil {
+		id := record.UserID.String()
+		userID = &id
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO llm_usage (
+			tenant_id, user_id, operation, model,
+			prompt_tokens, completion_tokens, cached_tokens, total_tokens,
+			cost_usd, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		record.TenantID.String(), userID, string(record.Operation), record.Model,
+		record.PromptTokens, record.CompletionTokens, record.CachedTokens, record.TotalTokens,
+		record.CostUSD, record.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record LLM usage: %w", err)
+	}
+	return nil
+}
+
+// bucketRow is a usagex.Bucket as selected by Aggregate
+type bucketRow struct {
+	Start            time.Time `db:"start"`
+	Operation        string    `db:"operation"`
+	Model            string    `db:"model"`
+	Calls            int64     `db:"calls"`
+	PromptTokens     int64     `db:"prompt_tokens"`
+	CompletionTokens int64     `db:"completion_tokens"`
+	CachedTokens     int64     `db:"cached_tokens"`
+	TotalTokens      int64     `db:"total_tokens"`
+	CostUSD          float64   `db:"cost_usd"`
+}
+
+func (s *Store) Aggregate(ctx context.Context, q usagex.Query) ([]usagex.Bucket, error) {
+	if !q.Period.IsValid() {
+		return nil, fmt.Errorf("invalid usage period %q", q.Period)
+	}
+
+	var rows []bucketRow
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT
+			date_trunc($2::text, created_at) AS start,
+			operation,
+			model,
+			COUNT(*) AS calls,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(cached_tokens), 0) AS cached_tokens,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens,
+			COALESCE(SUM(cost_usd), 0)::float8 AS cost_usd
+		FROM llm_usage
+		WHERE tenant_id = $1 AND created_at >= $3 AND created_at < $4
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`,
+		q.TenantID.String(), string(q.Period), q.From.UTC(), q.To.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate LLM usage: %w", err)
+	}
+
+	buckets := make([]usagex.Bucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = usagex.Bucket{
+			Start:            row.Start.UTC(),
+			Operation:        usagex.Operation(row.Operation),
+			Model:            row.Model,
+			Calls:            row.Calls,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			CachedTokens:     row.CachedTokens,
+			TotalTokens:      row.TotalTokens,
+			CostUSD:          row.CostUSD,
+		}
+	}
+	return buckets, nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
 	"github.com/Abraxas-365/manifesto/internal/ai/llm"
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -107,6 +108,10 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []llm.Message, op
 		params.ToolChoice = convertToolChoice(options.ToolChoice)
 	}
 
+	if options.PromptCaching {
+		applyPromptCaching(&params)
+	}
+
 	// Make the API call
 	message, err := p.client.Messages.New(ctx, params)
 	if err != nil {
@@ -184,6 +189,10 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []llm.Messa
 		params.ToolChoice = convertToolChoice(options.ToolChoice)
 	}
 
+	if options.PromptCaching {
+		applyPromptCaching(&params)
+	}
+
 	stream := p.client.Messages.NewStreaming(ctx, params)
 
 	return &anthropicStream{
@@ -191,6 +200,21 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []llm.Messa
 	}, nil
 }
 
+// ============================================================================
+// Embedding Implementation
+// ============================================================================
+
+// EmbedDocuments is not supported: Anthropic does not offer an embeddings API.
+// Use another provider (e.g. aiopenai or aimistral) for embeddings.
+func (p *AnthropicProvider) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	return nil, errorRegistry.New(ErrEmbeddingsNotSupported)
+}
+
+// EmbedQuery is not supported: Anthropic does not offer an embeddings API.
+func (p *AnthropicProvider) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	return embedding.Embedding{}, errorRegistry.New(ErrEmbeddingsNotSupported)
+}
+
 // ============================================================================
 // Stream Implementation
 // ============================================================================
@@ -247,6 +271,13 @@ func (s *anthropicStream) Next() (llm.Message, error) {
 
 		case "message_stop":
 			s.lastError = io.EOF
+			if len(s.toolCalls) > 0 {
+				// Flush tool calls that were not followed by a text delta
+				return llm.Message{
+					Role:      llm.RoleAssistant,
+					ToolCalls: s.toolCalls,
+				}, nil
+			}
 			return llm.Message{}, io.EOF
 		}
 	}
@@ -444,6 +475,28 @@ func convertToolSchema(params any) anthropic.ToolInputSchemaParam {
 	return schema
 }
 
+// applyPromptCaching sets cache_control breakpoints at the end of the tool
+// definitions, the system prompt and the conversation, so each stable prefix
+// is read from the cache on the next turn instead of billed again
+func applyPromptCaching(params *anthropic.MessageNewParams) {
+	if n := len(params.Tools); n > 0 && params.Tools[n-1].OfTool != nil {
+		params.Tools[n-1].OfTool.CacheControl = anthropic.NewCacheControlEphemeralParam()
+	}
+
+	if n := len(params.System); n > 0 {
+		params.System[n-1].CacheControl = anthropic.NewCacheControlEphemeralParam()
+	}
+
+	if n := len(params.Messages); n > 0 {
+		content := params.Messages[n-1].Content
+		if m := len(content); m > 0 {
+			if cacheControl := content[m-1].GetCacheControl(); cacheControl != nil {
+				*cacheControl = anthropic.NewCacheControlEphemeralParam()
+			}
+		}
+	}
+}
+
 func convertToolChoice(toolChoice any) anthropic.ToolChoiceUnionParam {
 	if strChoice, ok := toolChoice.(string); ok {
 		switch strChoice {
@@ -459,6 +512,12 @@ func convertToolChoice(toolChoice any) anthropic.ToolChoiceUnionParam {
 			return anthropic.ToolChoiceUnionParam{
 				OfNone: &anthropic.ToolChoiceNoneParam{},
 			}
+		case "":
+		default:
+			// Any other value forces a call to the tool with that name
+			return anthropic.ToolChoiceUnionParam{
+				OfTool: &anthropic.ToolChoiceToolParam{Name: strChoice},
+			}
 		}
 	}
 
@@ -492,6 +551,9 @@ func convertFromAnthropicResponse(msg *anthropic.Message) llm.Response {
 		}
 	}
 
+	// input_tokens excludes cache reads and writes; PromptTokens covers the whole prompt
+	promptTokens := int(msg.Usage.InputTokens + msg.Usage.CacheReadInputTokens + msg.Usage.CacheCreationInputTokens)
+
 	return llm.Response{
 		Message: llm.Message{
 			Role:      llm.RoleAssistant,
@@ -499,9 +561,10 @@ func convertFromAnthropicResponse(msg *anthropic.Message) llm.Response {
 			ToolCalls: toolCalls,
 		},
 		Usage: llm.Usage{
-			PromptTokens:     int(msg.Usage.InputTokens),
+			PromptTokens:     promptTokens,
 			CompletionTokens: int(msg.Usage.OutputTokens),
-			TotalTokens:      int(msg.Usage.InputTokens) + int(msg.Usage.OutputTokens),
+			TotalTokens:      promptTokens + int(msg.Usage.OutputTokens),
+			CachedTokens:     int(msg.Usage.CacheReadInputTokens),
 		},
 	}
 }
@@ -94,6 +94,13 @@ var (
 		"Anthropic API key not provided",
 	)
 
+	ErrEmbeddingsNotSupported = errorRegistry.Register(
+		"EMBEDDINGS_NOT_SUPPORTED",
+		errx.TypeValidation,
+		http.StatusNotImplemented,
+		"Anthropic does not provide an embeddings API",
+	)
+
 	ErrJSONParsing = errorRegistry.Register(
 		"JSON_PARSING_FAILED",
 		errx.TypeInternal,
@@ -181,40 +181,45 @@ func (p *AzureOpenAIProvider) EmbedDocuments(ctx context.Context, documents []st
 			WithDetail("error", "model/deployment name is required for Azure OpenAI embeddings")
 	}
 
-	params := openai.EmbeddingNewParams{
-		Input: openai.EmbeddingNewParamsInputUnion{
-			OfArrayOfStrings: documents,
-		},
-		Model: options.Model,
-	}
+	return embedding.EmbedInBatches(ctx, documents, options, func(ctx context.Context, batch []string) ([]embedding.Embedding, error) {
+		params := openai.EmbeddingNewParams{
+			Input: openai.EmbeddingNewParamsInputUnion{
+				OfArrayOfStrings: batch,
+			},
+			Model: options.Model,
+		}
 
-	if options.Dimensions > 0 {
-		params.Dimensions = openai.Int(int64(options.Dimensions))
-	}
+		if options.Dimensions > 0 {
+			params.Dimensions = openai.Int(int64(options.Dimensions))
+		}
 
-	resp, err := p.client.Embeddings.New(ctx, params)
-	if err != nil {
-		return nil, ParseAzureError(err).
-			WithDetail("model", options.Model).
-			WithDetail("num_documents", len(documents))
-	}
+		resp, err := p.client.Embeddings.New(ctx, params)
+		if err != nil {
+			return nil, ParseAzureError(err).
+				WithDetail("model", options.Model).
+				WithDetail("num_documents", len(batch))
+		}
 
-	if len(resp.Data) == 0 {
-		return nil, errorRegistry.New(ErrNoEmbeddingReturned)
-	}
+		if len(resp.Data) != len(batch) {
+			return nil, errorRegistry.New(ErrNoEmbeddingReturned)
+		}
 
-	embeddings := make([]embedding.Embedding, len(resp.Data))
-	for i, data := range resp.Data {
-		embeddings[i] = embedding.Embedding{
-			Vector: convertToFloat32Slice(data.Embedding),
-			Usage: embedding.Usage{
-				PromptTokens: int(resp.Usage.PromptTokens),
-				TotalTokens:  int(resp.Usage.TotalTokens),
-			},
+		embeddings := make([]embedding.Embedding, len(batch))
+		for _, data := range resp.Data {
+			if data.Index < 0 || int(data.Index) >= len(batch) {
+				return nil, errorRegistry.New(ErrNoEmbeddingReturned)
+			}
+			embeddings[data.Index] = embedding.Embedding{
+				Vector: convertToFloat32Slice(data.Embedding),
+				Usage: embedding.Usage{
+					PromptTokens: int(resp.Usage.PromptTokens),
+					TotalTokens:  int(resp.Usage.TotalTokens),
+				},
+			}
 		}
-	}
 
-	return embeddings, nil
+		return embeddings, nil
+	})
 }
 
 // EmbedQuery converts a single query to an embedding
@@ -540,6 +545,8 @@ func convertFromResponse(completion *openai.ChatCompletion) (llm.Response, error
 		PromptTokens:     int(completion.Usage.PromptTokens),
 		CompletionTokens: int(completion.Usage.CompletionTokens),
 		TotalTokens:      int(completion.Usage.TotalTokens),
+		ReasoningTokens:  int(completion.Usage.CompletionTokensDetails.ReasoningTokens),
+		CachedTokens:     int(completion.Usage.PromptTokensDetails.CachedTokens),
 	}
 
 	return llm.Response{
@@ -224,6 +224,13 @@ func (s *bedrockStream) Next() (llm.Message, error) {
 
 		case *types.ConverseStreamOutputMemberMessageStop:
 			s.lastError = io.EOF
+			if len(s.toolCalls) > 0 {
+				// Flush tool calls that were not followed by a text delta
+				return llm.Message{
+					Role:      llm.RoleAssistant,
+					ToolCalls: s.toolCalls,
+				}, nil
+			}
 			return llm.Message{}, io.EOF
 		}
 	}
@@ -539,6 +546,9 @@ func convertFromBedrockResponse(output *bedrockruntime.ConverseOutput) (llm.Resp
 		if output.Usage.TotalTokens != nil {
 			usage.TotalTokens = int(*output.Usage.TotalTokens)
 		}
+		if output.Usage.CacheReadInputTokens != nil {
+			usage.CachedTokens = int(*output.Usage.CacheReadInputTokens)
+		}
 	}
 
 	return llm.Response{
@@ -180,39 +180,41 @@ func (p *GeminiProvider) EmbedDocuments(ctx context.Context, documents []string,
 		model = options.Model
 	}
 
-	// Embed each document
-	var contents []*genai.Content
-	for _, doc := range documents {
-		contents = append(contents, &genai.Content{
-			Parts: []*genai.Part{genai.NewPartFromText(doc)},
-		})
-	}
-
 	config := &genai.EmbedContentConfig{}
 	if options.Dimensions > 0 {
 		dim := int32(options.Dimensions)
 		config.OutputDimensionality = &dim
 	}
 
-	resp, err := p.client.Models.EmbedContent(ctx, model, contents, config)
-	if err != nil {
-		return nil, ParseGeminiError(err).
-			WithDetail("model", model).
-			WithDetail("num_documents", len(documents))
-	}
+	return embedding.EmbedInBatches(ctx, documents, options, func(ctx context.Context, batch []string) ([]embedding.Embedding, error) {
+		// Embed each document
+		var contents []*genai.Content
+		for _, doc := range batch {
+			contents = append(contents, &genai.Content{
+				Parts: []*genai.Part{genai.NewPartFromText(doc)},
+			})
+		}
 
-	if resp == nil || len(resp.Embeddings) == 0 {
-		return nil, errorRegistry.New(ErrNoEmbeddingReturned)
-	}
+		resp, err := p.client.Models.EmbedContent(ctx, model, contents, config)
+		if err != nil {
+			return nil, ParseGeminiError(err).
+				WithDetail("model", model).
+				WithDetail("num_documents", len(batch))
+		}
 
-	embeddings := make([]embedding.Embedding, len(resp.Embeddings))
-	for i, emb := range resp.Embeddings {
-		embeddings[i] = embedding.Embedding{
-			Vector: emb.Values,
+		if resp == nil || len(resp.Embeddings) != len(batch) {
+			return nil, errorRegistry.New(ErrNoEmbeddingReturned)
+		}
+
+		embeddings := make([]embedding.Embedding, len(resp.Embeddings))
+		for i, emb := range resp.Embeddings {
+			embeddings[i] = embedding.Embedding{
+				Vector: emb.Values,
+			}
 		}
-	}
 
-	return embeddings, nil
+		return embeddings, nil
+	})
 }
 
 // EmbedQuery converts a single query to an embedding
@@ -647,6 +649,8 @@ func convertFromGeminiResponse(result *genai.GenerateContentResponse) (llm.Respo
 		usage.PromptTokens = int(result.UsageMetadata.PromptTokenCount)
 		usage.CompletionTokens = int(result.UsageMetadata.CandidatesTokenCount)
 		usage.TotalTokens = int(result.UsageMetadata.TotalTokenCount)
+		usage.ReasoningTokens = int(result.UsageMetadata.ThoughtsTokenCount)
+		usage.CachedTokens = int(result.UsageMetadata.CachedContentTokenCount)
 	}
 
 	return llm.Response{
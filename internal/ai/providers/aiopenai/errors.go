@@ -2,11 +2,14 @@ package aiopenai
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
 	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/openai/openai-go/v3"
 )
 
 var (
@@ -99,6 +102,13 @@ var (
 		"Embedding input cannot be empty",
 	)
 
+	ErrInvalidJSONSchema = errorRegistry.Register(
+		"INVALID_JSON_SCHEMA",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Response JSON schema must be an object schema",
+	)
+
 	ErrEmptySpeechInput = errorRegistry.Register(
 		"EMPTY_SPEECH_INPUT",
 		errx.TypeValidation,
@@ -177,13 +187,76 @@ func (e *OpenAIAPIError) Error() string {
 	return fmt.Sprintf("OpenAI API error: %s", e.Message)
 }
 
-// ParseOpenAIError parses an OpenAI API error
+// translateOpenAIError maps an OpenAI SDK error to a typed llm error (see
+// llm.ErrLLMRateLimited and friends), keeping the SDK error as the cause.
+// Other errors are returned unchanged.
+func translateOpenAIError(err error) error {
+	var apiErr *openai.Error
+	if err == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+
+	llmErr := llm.NewError(llmErrorCode(apiErr), err).
+		WithDetail("provider", "openai").
+		WithDetail("status_code", apiErr.StatusCode)
+	if apiErr.Code != "" {
+		llmErr.WithDetail("error_code", apiErr.Code)
+	}
+	if apiErr.Message != "" {
+		llmErr.WithDetail("provider_message", apiErr.Message)
+	}
+	return llmErr
+}
+
+// llmErrorCode picks the llm error for an SDK error, by OpenAI error code
+// first and HTTP status otherwise
+func llmErrorCode(apiErr *openai.Error) *errx.ErrorCode {
+	msg := strings.ToLower(apiErr.Message)
+
+	switch apiErr.Code {
+	case "context_length_exceeded", "string_above_max_length":
+		return llm.ErrLLMContextLengthExceeded
+	case "content_policy_violation", "content_filter":
+		return llm.ErrLLMContentFiltered
+	case "invalid_api_key":
+		return llm.ErrLLMInvalidAPIKey
+	case "insufficient_quota", "billing_hard_limit_reached":
+		return llm.ErrLLMQuotaExceeded
+	case "rate_limit_exceeded":
+		return llm.ErrLLMRateLimited
+	case "model_not_found":
+		return llm.ErrLLMModelNotFound
+	}
+
+	switch {
+	case apiErr.StatusCode == http.StatusUnauthorized:
+		return llm.ErrLLMInvalidAPIKey
+	case apiErr.StatusCode == http.StatusForbidden:
+		return llm.ErrLLMPermissionDenied
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		return llm.ErrLLMRateLimited
+	case apiErr.StatusCode == http.StatusNotFound && strings.Contains(msg, "model"):
+		return llm.ErrLLMModelNotFound
+	case strings.Contains(msg, "context length") || strings.Contains(msg, "maximum context"):
+		return llm.ErrLLMContextLengthExceeded
+	case strings.Contains(msg, "safety system") || strings.Contains(msg, "content policy"):
+		return llm.ErrLLMContentFiltered
+	case apiErr.StatusCode >= 500:
+		return llm.ErrLLMProviderUnavailable
+	default:
+		return llm.ErrLLMInvalidRequest
+	}
+}
+
+// ParseOpenAIError parses an OpenAI API error. SDK errors become typed llm
+// errors; anything else falls back to the OPENAI_* codes below.
 func ParseOpenAIError(err error) *errx.Error {
 	if err == nil {
 		return nil
 	}
 
-	// Check if it's already a custom error
+	// Check if it's already a custom error (including translated llm errors)
+	err = translateOpenAIError(err)
 	var customErr *errx.Error
 	if errx.As(err, &customErr) {
 		return customErr
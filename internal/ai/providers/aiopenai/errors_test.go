@@ -0,0 +1,100 @@
+package aiopenai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/openai/openai-go/v3"
+)
+
+// apiError builds the error the SDK returns for a failed request
+func apiError(status int, code, message string) *openai.Error {
+	return &openai.Error{
+		Code:       code,
+		Message:    message,
+		StatusCode: status,
+		Request:    httptest.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil),
+		Response:   &http.Response{StatusCode: status},
+	}
+}
+
+func TestLLMErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *openai.Error
+		want *errx.ErrorCode
+	}{
+		{"context length code", apiError(400, "context_length_exceeded", "too long"), llm.ErrLLMContextLengthExceeded},
+		{"string above max length", apiError(400, "string_above_max_length", "too long"), llm.ErrLLMContextLengthExceeded},
+		{"content policy code", apiError(400, "content_policy_violation", ""), llm.ErrLLMContentFiltered},
+		{"content filter code", apiError(400, "content_filter", ""), llm.ErrLLMContentFiltered},
+		{"invalid key code", apiError(401, "invalid_api_key", ""), llm.ErrLLMInvalidAPIKey},
+		// insufficient_quota comes with a 429 but is not a transient rate limit
+		{"insufficient quota", apiError(429, "insufficient_quota", "You exceeded your current quota"), llm.ErrLLMQuotaExceeded},
+		{"billing limit", apiError(400, "billing_hard_limit_reached", ""), llm.ErrLLMQuotaExceeded},
+		{"rate limit code", apiError(429, "rate_limit_exceeded", ""), llm.ErrLLMRateLimited},
+		{"model not found code", apiError(404, "model_not_found", ""), llm.ErrLLMModelNotFound},
+		{"401", apiError(401, "", "Unauthorized"), llm.ErrLLMInvalidAPIKey},
+		{"403", apiError(403, "", "Project does not have access"), llm.ErrLLMPermissionDenied},
+		{"429", apiError(429, "", "Slow down"), llm.ErrLLMRateLimited},
+		{"404 model", apiError(404, "", "The model `gpt-9` does not exist"), llm.ErrLLMModelNotFound},
+		{"404 other", apiError(404, "", "Not found"), llm.ErrLLMInvalidRequest},
+		{"context length message", apiError(400, "", "This model's maximum context length is 8192 tokens"), llm.ErrLLMContextLengthExceeded},
+		{"safety message", apiError(400, "", "Your request was rejected by our safety system"), llm.ErrLLMContentFiltered},
+		{"500", apiError(500, "", "The server had an error"), llm.ErrLLMProviderUnavailable},
+		{"503", apiError(503, "", ""), llm.ErrLLMProviderUnavailable},
+		{"400", apiError(400, "invalid_value", "Invalid 'temperature'"), llm.ErrLLMInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		if got := llmErrorCode(tt.err); got != tt.want {
+			t.Errorf("%s: llmErrorCode = %s, want %s", tt.name, got.Code, tt.want.Code)
+		}
+	}
+}
+
+func TestTranslateOpenAIError(t *testing.T) {
+	if translateOpenAIError(nil) != nil {
+		t.Error("nil error was translated")
+	}
+
+	plain := errors.New("connection reset")
+	if got := translateOpenAIError(plain); got != plain {
+		t.Errorf("non-SDK error = %v, want it unchanged", got)
+	}
+
+	sdkErr := apiError(429, "rate_limit_exceeded", "Rate limit reached for requests")
+	got := translateOpenAIError(fmt.Errorf("chat completion: %w", sdkErr))
+
+	var e *errx.Error
+	if !errx.As(got, &e) || !errx.Is(got, llm.ErrLLMRateLimited) {
+		t.Fatalf("translated error = %v, want LLM rate limited", got)
+	}
+	if e.Details["provider"] != "openai" || e.Details["status_code"] != 429 ||
+		e.Details["error_code"] != "rate_limit_exceeded" || e.Details["provider_message"] != "Rate limit reached for requests" {
+		t.Errorf("details = %v", e.Details)
+	}
+	var cause *openai.Error
+	if !errors.As(got, &cause) || cause != sdkErr {
+		t.Error("SDK error is not kept as the cause")
+	}
+
+	// Empty code and message are left out of the details
+	errx.As(translateOpenAIError(apiError(500, "", "")), &e)
+	if _, ok := e.Details["error_code"]; ok {
+		t.Errorf("details = %v, want no error_code", e.Details)
+	}
+	if _, ok := e.Details["provider_message"]; ok {
+		t.Errorf("details = %v, want no provider_message", e.Details)
+	}
+
+	// ParseOpenAIError returns the translated error rather than an OPENAI_* code
+	if parsed := ParseOpenAIError(sdkErr); !errx.Is(parsed, llm.ErrLLMRateLimited) {
+		t.Errorf("ParseOpenAIError = %v, want LLM rate limited", parsed)
+	}
+}
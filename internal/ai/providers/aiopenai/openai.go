@@ -1,42 +1,78 @@
 package aiopenai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
 	"github.com/Abraxas-365/manifesto/internal/ai/llm"
 	"github.com/Abraxas-365/manifesto/internal/ai/speech"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/openai/openai-go/v3/packages/param"
 	"github.com/openai/openai-go/v3/responses"
 	"github.com/openai/openai-go/v3/shared"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ProviderOption configures the OpenAI provider
+type ProviderOption func(*OpenAIProvider)
+
+// WithRequestOptions passes options to the underlying OpenAI client (base URL,
+// HTTP client, headers, ...)
+func WithRequestOptions(opts ...option.RequestOption) ProviderOption {
+	return func(p *OpenAIProvider) {
+		p.requestOptions = append(p.requestOptions, opts...)
+	}
+}
+
+// WithRetryPolicy sets how Chat, EmbedDocuments, Synthesize and Transcribe
+// retry 429 and 5xx responses. llm.NoRetry disables retries.
+func WithRetryPolicy(policy llm.RetryPolicy) ProviderOption {
+	return func(p *OpenAIProvider) {
+		p.retryPolicy = policy
+	}
+}
+
 // OpenAIProvider implements the LLM interface for OpenAI
 type OpenAIProvider struct {
-	client openai.Client
-	apiKey string
+	client         openai.Client
+	apiKey         string
+	requestOptions []option.RequestOption
+	retryPolicy    llm.RetryPolicy
 }
 
-// NewOpenAIProvider creates a new OpenAI provider
-func NewOpenAIProvider(apiKey string, opts ...option.RequestOption) *OpenAIProvider {
+// NewOpenAIProvider creates a new OpenAI provider. Calls are retried with
+// llm.DefaultRetryPolicy unless WithRetryPolicy says otherwise.
+func NewOpenAIProvider(apiKey string, opts ...ProviderOption) *OpenAIProvider {
 	if apiKey == "" {
 		apiKey = os.Getenv("OPENAI_API_KEY")
 	}
 
-	options := append([]option.RequestOption{option.WithAPIKey(apiKey)}, opts...)
-	client := openai.NewClient(options...)
-
-	return &OpenAIProvider{
-		client: client,
-		apiKey: apiKey,
+	p := &OpenAIProvider{
+		apiKey:      apiKey,
+		retryPolicy: llm.DefaultRetryPolicy(),
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// Retries are handled by withRetry; the client's own retries would
+	// multiply the attempts
+	options := append([]option.RequestOption{option.WithAPIKey(apiKey), option.WithMaxRetries(0)}, p.requestOptions...)
+	p.client = openai.NewClient(options...)
+
+	return p
 }
 
 func defaultChatOptions() *llm.ChatOptions {
@@ -62,7 +98,7 @@ func modelSupportsTemperature(model string) bool {
 // ============================================================================
 
 // Chat implements the LLM interface
-func (p *OpenAIProvider) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+func (p *OpenAIProvider) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (response llm.Response, err error) {
 	if p.apiKey == "" {
 		return llm.Response{}, errorRegistry.New(ErrMissingAPIKey)
 	}
@@ -75,6 +111,9 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []llm.Message, opts
 		opt(options)
 	}
 
+	ctx, span := startSpan(ctx, "openai.chat", options.Model, attribute.Int("gen_ai.request.messages", len(messages)))
+	defer func() { endChatSpan(span, response.Usage, err) }()
+
 	instructions, inputItems, err := convertMessagesToResponsesInput(messages)
 	if err != nil {
 		return llm.Response{}, WrapError(err, ErrInvalidMessage).WithDetail("error", "failed to convert messages")
@@ -107,6 +146,9 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []llm.Message, opts
 	if options.User != "" {
 		params.User = openai.String(options.User)
 	}
+	if options.PromptCacheKey != "" {
+		params.PromptCacheKey = openai.String(options.PromptCacheKey)
+	}
 	if options.ReasoningEffort != "" {
 		params.Reasoning = shared.ReasoningParam{
 			Effort: convertToReasoningEffort(options.ReasoningEffort),
@@ -138,13 +180,26 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []llm.Message, opts
 		params.Text = responses.ResponseTextConfigParam{Format: textFormat}
 	}
 
-	resp, err := p.client.Responses.New(ctx, params)
+	var resp *responses.Response
+	err = p.withRetry(ctx, func() error {
+		var err error
+		resp, err = p.client.Responses.New(ctx, params)
+		return err
+	})
 	if err != nil {
 		return llm.Response{}, ParseOpenAIError(err).
 			WithDetail("model", options.Model).
 			WithDetail("num_messages", len(messages))
 	}
 
+	// A response cut by the content filter comes back as a successful
+	// "incomplete" response rather than an API error
+	if resp.IncompleteDetails.Reason == "content_filter" {
+		return llm.Response{}, llm.NewError(llm.ErrLLMContentFiltered, nil).
+			WithDetail("provider", "openai").
+			WithDetail("model", options.Model)
+	}
+
 	result, err := convertFromResponsesResponse(resp)
 	if err != nil {
 		return llm.Response{}, WrapError(err, ErrAPIResponse).WithDetail("error", "failed to parse response")
@@ -157,7 +212,7 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []llm.Message, opts
 // ============================================================================
 
 // ChatStream implements streaming for Responses API
-func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (stream llm.Stream, err error) {
 	if p.apiKey == "" {
 		return nil, errorRegistry.New(ErrMissingAPIKey)
 	}
@@ -170,6 +225,14 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []llm.Message,
 		opt(options)
 	}
 
+	// The span stays open until the stream is drained or closed
+	ctx, span := startSpan(ctx, "openai.chat_stream", options.Model, attribute.Int("gen_ai.request.messages", len(messages)))
+	defer func() {
+		if err != nil {
+			tracex.End(span, err)
+		}
+	}()
+
 	instructions, inputItems, err := convertMessagesToResponsesInput(messages)
 	if err != nil {
 		return nil, WrapError(err, ErrInvalidMessage).WithDetail("error", "failed to convert messages")
@@ -202,6 +265,9 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []llm.Message,
 	if options.User != "" {
 		params.User = openai.String(options.User)
 	}
+	if options.PromptCacheKey != "" {
+		params.PromptCacheKey = openai.String(options.PromptCacheKey)
+	}
 	if options.ReasoningEffort != "" {
 		params.Reasoning = shared.ReasoningParam{
 			Effort: convertToReasoningEffort(options.ReasoningEffort),
@@ -234,14 +300,14 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []llm.Message,
 	}
 
 	sseStream := p.client.Responses.NewStreaming(ctx, params)
-	return &openAIStream{stream: sseStream}, nil
+	return &openAIStream{stream: sseStream, span: span}, nil
 }
 
 // ============================================================================
 // Embedding Implementation
 // ============================================================================
 
-func (p *OpenAIProvider) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+func (p *OpenAIProvider) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) (result []embedding.Embedding, err error) {
 	// Validate input
 	if len(documents) == 0 {
 		return nil, errorRegistry.New(ErrEmptyEmbeddingInput)
@@ -252,50 +318,77 @@ func (p *OpenAIProvider) EmbedDocuments(ctx context.Context, documents []string,
 		opt(options)
 	}
 
-	params := openai.EmbeddingNewParams{
-		Input: openai.EmbeddingNewParamsInputUnion{
-			OfArrayOfStrings: documents,
-		},
+	model := options.Model
+	if model == "" {
+		model = "text-embedding-3-small"
 	}
 
-	if options.Model != "" {
-		params.Model = options.Model
-	} else {
-		params.Model = "text-embedding-3-small"
-	}
+	ctx, span := startSpan(ctx, "openai.embeddings", model, attribute.Int("gen_ai.request.documents", len(documents)))
+	promptTokens := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("gen_ai.usage.input_tokens", promptTokens))
+		tracex.End(span, err)
+	}()
 
-	if options.Dimensions > 0 {
-		params.Dimensions = openai.Int(int64(options.Dimensions))
-	}
+	// Each request is retried by withRetry, which honors Retry-After and gives
+	// up on an exhausted quota, so EmbedInBatches must not retry the batch
+	// again: the provider's RetryPolicy replaces embedding.WithMaxRetries
+	batchOptions := *options
+	batchOptions.MaxRetries = 0
 
-	if options.User != "" {
-		params.User = openai.String(options.User)
-	}
+	return embedding.EmbedInBatches(ctx, documents, &batchOptions, func(ctx context.Context, batch []string) ([]embedding.Embedding, error) {
+		params := openai.EmbeddingNewParams{
+			Input: openai.EmbeddingNewParamsInputUnion{
+				OfArrayOfStrings: batch,
+			},
+			Model: model,
+		}
 
-	resp, err := p.client.Embeddings.New(ctx, params)
-	if err != nil {
-		return nil, ParseOpenAIError(err).
-			WithDetail("model", params.Model).
-			WithDetail("num_documents", len(documents))
-	}
+		if options.Dimensions > 0 {
+			params.Dimensions = openai.Int(int64(options.Dimensions))
+		}
 
-	if len(resp.Data) == 0 {
-		return nil, errorRegistry.New(ErrNoEmbeddingReturned).
-			WithDetail("num_documents", len(documents))
-	}
+		if options.User != "" {
+			params.User = openai.String(options.User)
+		}
 
-	embeddings := make([]embedding.Embedding, len(resp.Data))
-	for i, data := range resp.Data {
-		embeddings[i] = embedding.Embedding{
-			Vector: convertToFloat32Slice(data.Embedding),
-			Usage: embedding.Usage{
-				PromptTokens: int(resp.Usage.PromptTokens),
-				TotalTokens:  int(resp.Usage.TotalTokens),
-			},
+		var resp *openai.CreateEmbeddingResponse
+		err := p.withRetry(ctx, func() error {
+			var err error
+			resp, err = p.client.Embeddings.New(ctx, params)
+			return err
+		})
+		if err != nil {
+			return nil, ParseOpenAIError(err).
+				WithDetail("model", params.Model).
+				WithDetail("num_documents", len(batch))
 		}
-	}
 
-	return embeddings, nil
+		promptTokens += int(resp.Usage.PromptTokens)
+
+		if len(resp.Data) != len(batch) {
+			return nil, errorRegistry.New(ErrNoEmbeddingReturned).
+				WithDetail("num_documents", len(batch)).
+				WithDetail("num_embeddings", len(resp.Data))
+		}
+
+		embeddings := make([]embedding.Embedding, len(batch))
+		for _, data := range resp.Data {
+			if data.Index < 0 || int(data.Index) >= len(batch) {
+				return nil, errorRegistry.New(ErrNoEmbeddingReturned).
+					WithDetail("index", data.Index)
+			}
+			embeddings[data.Index] = embedding.Embedding{
+				Vector: convertToFloat32Slice(data.Embedding),
+				Usage: embedding.Usage{
+					PromptTokens: int(resp.Usage.PromptTokens),
+					TotalTokens:  int(resp.Usage.TotalTokens),
+				},
+			}
+		}
+
+		return embeddings, nil
+	})
 }
 
 func (p *OpenAIProvider) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
@@ -319,7 +412,59 @@ func (p *OpenAIProvider) EmbedQuery(ctx context.Context, text string, opts ...em
 // Speech Synthesis Implementation
 // ============================================================================
 
+// openAIVoices maps speech.WithVoice names to OpenAI voices. Some voices
+// (ballad, verse, marin, cedar) are only available on gpt-4o-mini-tts.
+var openAIVoices = map[string]openai.AudioSpeechNewParamsVoice{
+	"alloy":   openai.AudioSpeechNewParamsVoiceAlloy,
+	"ash":     openai.AudioSpeechNewParamsVoiceAsh,
+	"ballad":  openai.AudioSpeechNewParamsVoiceBallad,
+	"coral":   openai.AudioSpeechNewParamsVoiceCoral,
+	"echo":    openai.AudioSpeechNewParamsVoiceEcho,
+	"fable":   "fable",
+	"onyx":    "onyx",
+	"nova":    "nova",
+	"sage":    openai.AudioSpeechNewParamsVoiceSage,
+	"shimmer": openai.AudioSpeechNewParamsVoiceShimmer,
+	"verse":   openai.AudioSpeechNewParamsVoiceVerse,
+	"marin":   openai.AudioSpeechNewParamsVoiceMarin,
+	"cedar":   openai.AudioSpeechNewParamsVoiceCedar,
+}
+
+// openAIAudioFormats maps speech formats to OpenAI response formats. OGG is
+// returned as Opus in an Ogg container.
+var openAIAudioFormats = map[speech.AudioFormat]openai.AudioSpeechNewParamsResponseFormat{
+	speech.AudioFormatMP3: openai.AudioSpeechNewParamsResponseFormatMP3,
+	speech.AudioFormatWAV: openai.AudioSpeechNewParamsResponseFormatWAV,
+	speech.AudioFormatPCM: openai.AudioSpeechNewParamsResponseFormatPCM,
+	speech.AudioFormatOGG: openai.AudioSpeechNewParamsResponseFormatOpus,
+}
+
+// Synthesize converts text to speech and reads the whole audio before
+// returning, so a failed download is reported here. Use SynthesizeStream to
+// start playback while the audio is generated.
 func (p *OpenAIProvider) Synthesize(ctx context.Context, text string, opts ...speech.SynthesisOption) (speech.Audio, error) {
+	start := time.Now()
+	audio, err := p.SynthesizeStream(ctx, text, opts...)
+	if err != nil {
+		return speech.Audio{}, err
+	}
+	defer audio.Content.Close()
+
+	data, err := io.ReadAll(audio.Content)
+	if err != nil {
+		return speech.Audio{}, WrapError(err, ErrAPIResponse).
+			WithDetail("error", "failed to read audio")
+	}
+
+	audio.Content = io.NopCloser(bytes.NewReader(data))
+	audio.Usage.ProcessingTime = int(time.Since(start).Milliseconds())
+	return audio, nil
+}
+
+// SynthesizeStream converts text to speech and returns the audio as the API
+// sends it, in chunks, so long texts can start playing before generation
+// completes. The caller must close Content.
+func (p *OpenAIProvider) SynthesizeStream(ctx context.Context, text string, opts ...speech.SynthesisOption) (speech.Audio, error) {
 	if text == "" {
 		return speech.Audio{}, errorRegistry.New(ErrEmptySpeechInput)
 	}
@@ -335,43 +480,17 @@ func (p *OpenAIProvider) Synthesize(ctx context.Context, text string, opts ...sp
 		opt(&options)
 	}
 
-	responseFormat := openai.AudioSpeechNewParamsResponseFormatMP3
-	switch options.AudioFormat {
-	case speech.AudioFormatMP3:
-		responseFormat = openai.AudioSpeechNewParamsResponseFormatMP3
-	case speech.AudioFormatPCM:
-		responseFormat = openai.AudioSpeechNewParamsResponseFormatPCM
-	case speech.AudioFormatOGG:
-		responseFormat = openai.AudioSpeechNewParamsResponseFormatOpus
-	case speech.AudioFormatWAV:
-		responseFormat = openai.AudioSpeechNewParamsResponseFormatPCM
-	}
-
-	voice := openai.AudioSpeechNewParamsVoiceAlloy
-	switch strings.ToLower(options.Voice) {
-	case "alloy":
-		voice = openai.AudioSpeechNewParamsVoiceAlloy
-	case "echo":
-		voice = openai.AudioSpeechNewParamsVoiceEcho
-	case "fable":
-	case "shimmer":
-		voice = openai.AudioSpeechNewParamsVoiceShimmer
-	default:
-		voice = openai.AudioSpeechNewParamsVoiceAlloy
-	}
-
-	params := openai.AudioSpeechNewParams{
-		Model:          options.Model,
-		Input:          text,
-		Voice:          voice,
-		ResponseFormat: responseFormat,
-	}
-
-	if options.SpeechRate != 1.0 {
-		params.Speed = param.NewOpt(float64(options.SpeechRate))
+	params, err := speechParams(text, options)
+	if err != nil {
+		return speech.Audio{}, err
 	}
 
-	res, err := p.client.Audio.Speech.New(ctx, params)
+	var res *http.Response
+	err = p.withRetry(ctx, func() error {
+		var err error
+		res, err = p.client.Audio.Speech.New(ctx, params)
+		return err
+	})
 	if err != nil {
 		return speech.Audio{}, ParseOpenAIError(err).
 			WithDetail("model", options.Model).
@@ -393,6 +512,47 @@ func (p *OpenAIProvider) Synthesize(ctx context.Context, text string, opts ...sp
 	}, nil
 }
 
+// speechParams builds the request, rejecting voices, formats and options the
+// API does not support
+func speechParams(text string, options speech.SynthesisOptions) (openai.AudioSpeechNewParams, error) {
+	voice, ok := openAIVoices[strings.ToLower(options.Voice)]
+	if !ok {
+		return openai.AudioSpeechNewParams{}, errorRegistry.New(ErrInvalidRequest).
+			WithDetail("error", "unsupported voice").
+			WithDetail("voice", options.Voice)
+	}
+
+	responseFormat, ok := openAIAudioFormats[options.AudioFormat]
+	if !ok {
+		return openai.AudioSpeechNewParams{}, errorRegistry.New(ErrInvalidRequest).
+			WithDetail("error", "unsupported audio format").
+			WithDetail("format", string(options.AudioFormat))
+	}
+
+	params := openai.AudioSpeechNewParams{
+		Model:          options.Model,
+		Input:          text,
+		Voice:          voice,
+		ResponseFormat: responseFormat,
+	}
+
+	if options.Instructions != "" {
+		// tts-1 and tts-1-hd reject instructions
+		if options.Model == openai.SpeechModelTTS1 || options.Model == openai.SpeechModelTTS1HD {
+			return openai.AudioSpeechNewParams{}, errorRegistry.New(ErrInvalidRequest).
+				WithDetail("error", "instructions are not supported by this model").
+				WithDetail("model", options.Model)
+		}
+		params.Instructions = param.NewOpt(options.Instructions)
+	}
+
+	if options.SpeechRate != 1.0 {
+		params.Speed = param.NewOpt(float64(options.SpeechRate))
+	}
+
+	return params, nil
+}
+
 // ============================================================================
 // Speech Transcription Implementation
 // ============================================================================
@@ -413,32 +573,102 @@ func (p *OpenAIProvider) Transcribe(ctx context.Context, audio io.Reader, opts .
 		opt(&options)
 	}
 
+	// A retry must upload the whole file again
+	audioBody, err := replayableReader(audio)
+	if err != nil {
+		return speech.Transcript{}, WrapError(err, ErrInvalidRequest).
+			WithDetail("error", "failed to read audio")
+	}
+
 	params := openai.AudioTranscriptionNewParams{
 		Model: options.Model,
-		File:  audio,
 	}
 
 	if options.Language != "" {
 		params.Language = param.NewOpt(options.Language)
 	}
 
-	response, err := p.client.Audio.Transcriptions.New(ctx, params)
+	// Timestamps need verbose_json (whisper-1 only). Without them the default
+	// json response carries just the text and is much smaller.
+	if options.Timestamps {
+		params.ResponseFormat = openai.AudioResponseFormatVerboseJSON
+		params.TimestampGranularities = []string{"word", "segment"}
+	}
+
+	start := time.Now()
+	var response *openai.AudioTranscriptionNewResponseUnion
+	err = p.withRetry(ctx, func() error {
+		file, err := audioBody()
+		if err != nil {
+			return err
+		}
+		params.File = file
+
+		response, err = p.client.Audio.Transcriptions.New(ctx, params)
+		return err
+	})
 	if err != nil {
 		return speech.Transcript{}, ParseOpenAIError(err).
 			WithDetail("model", options.Model)
 	}
 
+	result := toTranscript(response)
+	result.Usage.ProcessingTime = int(time.Since(start).Milliseconds())
+
+	return result, nil
+}
+
+// toTranscript converts a transcription response. Segments, words, language
+// and confidence are only present in verbose_json responses.
+func toTranscript(response *openai.AudioTranscriptionNewResponseUnion) speech.Transcript {
 	result := speech.Transcript{
-		Text: response.Text,
+		Text:         response.Text,
+		LanguageCode: response.Language,
+		Usage: speech.STTUsage{
+			AudioDuration: float32(response.Duration),
+		},
+	}
+	// Whisper also reports the duration as usage in plain json responses
+	if result.Usage.AudioDuration == 0 && response.Usage.Type == "duration" {
+		result.Usage.AudioDuration = float32(response.Usage.Seconds)
 	}
 
-	return result, nil
+	var weightedConfidence, totalDuration float64
+	for _, s := range response.Segments {
+		// avg_logprob is the mean token log-probability of the segment
+		confidence := min(math.Exp(s.AvgLogprob), 1)
+		result.Segments = append(result.Segments, speech.TranscriptSegment{
+			Text:       strings.TrimSpace(s.Text),
+			StartTime:  float32(s.Start),
+			EndTime:    float32(s.End),
+			Confidence: float32(confidence),
+		})
+
+		duration := s.End - s.Start
+		weightedConfidence += confidence * duration
+		totalDuration += duration
+	}
+	if totalDuration > 0 {
+		result.Confidence = float32(weightedConfidence / totalDuration)
+	}
+
+	for _, w := range response.Words {
+		result.Words = append(result.Words, speech.TranscriptWord{
+			Text:      w.Word,
+			StartTime: float32(w.Start),
+			EndTime:   float32(w.End),
+		})
+	}
+
+	return result
 }
 
 // ============================================================================
 // Stream Implementation
 // ============================================================================
 
+var _ llm.UsageStream = (*openAIStream)(nil)
+
 type openAIStream struct {
 	stream interface {
 		Next() bool
@@ -446,7 +676,12 @@ type openAIStream struct {
 		Err() error
 		Close() error
 	}
-	done bool
+	toolCalls []llm.ToolCall
+	done      bool
+
+	span      trace.Span
+	usage     llm.Usage
+	spanEnded bool
 }
 
 func (s *openAIStream) Next() (llm.Message, error) {
@@ -456,37 +691,67 @@ func (s *openAIStream) Next() (llm.Message, error) {
 	for s.stream.Next() {
 		event := s.stream.Current()
 		switch event.Type {
+		case "response.completed":
+			s.usage = llm.Usage{
+				PromptTokens:     int(event.Response.Usage.InputTokens),
+				CompletionTokens: int(event.Response.Usage.OutputTokens),
+				TotalTokens:      int(event.Response.Usage.InputTokens + event.Response.Usage.OutputTokens),
+				ReasoningTokens:  int(event.Response.Usage.OutputTokensDetails.ReasoningTokens),
+				CachedTokens:     int(event.Response.Usage.InputTokensDetails.CachedTokens),
+			}
 		case "response.output_text.delta":
 			return llm.Message{Role: llm.RoleAssistant, Content: event.Delta}, nil
 		case "response.output_item.done":
 			item := event.Item
 			if item.Type == "function_call" {
 				fc := item.AsFunctionCall()
+				s.toolCalls = append(s.toolCalls, llm.ToolCall{
+					ID:       fc.CallID,
+					Type:     "function",
+					Function: llm.FunctionCall{Name: fc.Name, Arguments: fc.Arguments},
+				})
+				// Report every tool call accumulated so far, by index
 				return llm.Message{
-					Role: llm.RoleAssistant,
-					ToolCalls: []llm.ToolCall{{
-						ID:   fc.CallID,
-						Type: "function",
-						Function: llm.FunctionCall{Name: fc.Name, Arguments: fc.Arguments},
-					}},
+					Role:      llm.RoleAssistant,
+					ToolCalls: s.toolCalls,
 				}, nil
 			}
 		case "response.failed":
 			s.done = true
-			return llm.Message{}, errorRegistry.New(ErrAPIResponse).WithDetail("error", "response failed")
+			err := errorRegistry.New(ErrAPIResponse).WithDetail("error", "response failed")
+			s.endSpan(err)
+			return llm.Message{}, err
 		}
 	}
 	s.done = true
 	if err := s.stream.Err(); err != nil {
-		return llm.Message{}, ParseOpenAIError(err)
+		parsed := ParseOpenAIError(err)
+		s.endSpan(parsed)
+		return llm.Message{}, parsed
 	}
+	s.endSpan(nil)
 	return llm.Message{}, io.EOF
 }
 
+// Usage returns the usage of the completed response, for usage metering
+func (s *openAIStream) Usage() llm.Usage {
+	return s.usage
+}
+
 func (s *openAIStream) Close() error {
+	s.endSpan(nil)
 	return s.stream.Close()
 }
 
+// endSpan ends the stream span once, with the usage of the completed response
+func (s *openAIStream) endSpan(err error) {
+	if s.span == nil || s.spanEnded {
+		return
+	}
+	s.spanEnded = true
+	endChatSpan(s.span, s.usage, err)
+}
+
 // ============================================================================
 // Helper Functions
 // ============================================================================
@@ -682,15 +947,28 @@ func convertToResponsesFormatParam(format *llm.ResponseFormat) (responses.Respon
 			}
 			var schemaMap map[string]any
 			if err := json.Unmarshal(schemaBytes, &schemaMap); err != nil {
-				return responses.ResponseFormatTextConfigUnionParam{}, WrapError(err, ErrJSONParsing)
+				return responses.ResponseFormatTextConfigUnionParam{}, errorRegistry.NewWithCause(ErrInvalidJSONSchema, err)
 			}
 			schema = schemaMap
 		}
+		if schemaType, _ := schema["type"].(string); schemaType != "object" {
+			return responses.ResponseFormatTextConfigUnionParam{}, errorRegistry.New(ErrInvalidJSONSchema).
+				WithDetail("type", schema["type"])
+		}
+
+		name := format.Name
+		if name == "" {
+			name = "schema"
+		}
+		jsonSchema := &responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:   name,
+			Schema: schema,
+		}
+		if format.Strict {
+			jsonSchema.Strict = openai.Bool(true)
+		}
 		return responses.ResponseFormatTextConfigUnionParam{
-			OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
-				Name:   "schema",
-				Schema: schema,
-			},
+			OfJSONSchema: jsonSchema,
 		}, nil
 	default:
 		return responses.ResponseFormatTextConfigUnionParam{
@@ -708,8 +986,8 @@ func convertFromResponsesResponse(resp *responses.Response) (llm.Response, error
 		if item.Type == "function_call" {
 			fc := item.AsFunctionCall()
 			message.ToolCalls = append(message.ToolCalls, llm.ToolCall{
-				ID:   fc.CallID,
-				Type: "function",
+				ID:       fc.CallID,
+				Type:     "function",
 				Function: llm.FunctionCall{Name: fc.Name, Arguments: fc.Arguments},
 			})
 		}
@@ -718,6 +996,8 @@ func convertFromResponsesResponse(resp *responses.Response) (llm.Response, error
 		PromptTokens:     int(resp.Usage.InputTokens),
 		CompletionTokens: int(resp.Usage.OutputTokens),
 		TotalTokens:      int(resp.Usage.InputTokens + resp.Usage.OutputTokens),
+		ReasoningTokens:  int(resp.Usage.OutputTokensDetails.ReasoningTokens),
+		CachedTokens:     int(resp.Usage.InputTokensDetails.CachedTokens),
 	}
 	return llm.Response{Message: message, Usage: usage}, nil
 }
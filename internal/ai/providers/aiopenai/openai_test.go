@@ -0,0 +1,129 @@
+package aiopenai
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/embedding"
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/ai/speech"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+func TestToTranscriptVerbose(t *testing.T) {
+	raw := `{
+		"text": "Hello there. General Kenobi.",
+		"language": "english",
+		"duration": 4.0,
+		"segments": [
+			{"id": 0, "start": 0.0, "end": 1.0, "text": " Hello there.", "avg_logprob": 0.0},
+			{"id": 1, "start": 1.0, "end": 4.0, "text": " General Kenobi.", "avg_logprob": -0.6931471805599453}
+		],
+		"words": [
+			{"word": "Hello", "start": 0.0, "end": 0.4},
+			{"word": "there", "start": 0.5, "end": 0.9}
+		]
+	}`
+
+	var response openai.AudioTranscriptionNewResponseUnion
+	if err := json.Unmarshal([]byte(raw), &response); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	transcript := toTranscript(&response)
+
+	if transcript.LanguageCode != "english" || transcript.Usage.AudioDuration != 4 {
+		t.Errorf("language %q, duration %v", transcript.LanguageCode, transcript.Usage.AudioDuration)
+	}
+	if len(transcript.Segments) != 2 || transcript.Segments[1].Text != "General Kenobi." ||
+		transcript.Segments[1].StartTime != 1 || transcript.Segments[1].EndTime != 4 {
+		t.Fatalf("segments = %+v", transcript.Segments)
+	}
+	if math.Abs(float64(transcript.Segments[1].Confidence)-0.5) > 1e-6 {
+		t.Errorf("segment confidence = %v, want 0.5", transcript.Segments[1].Confidence)
+	}
+	// Duration-weighted: (1*1 + 0.5*3) / 4
+	if math.Abs(float64(transcript.Confidence)-0.625) > 1e-6 {
+		t.Errorf("confidence = %v, want 0.625", transcript.Confidence)
+	}
+	if len(transcript.Words) != 2 || transcript.Words[1].Text != "there" || transcript.Words[1].EndTime != 0.9 {
+		t.Errorf("words = %+v", transcript.Words)
+	}
+}
+
+func TestToTranscriptPlain(t *testing.T) {
+	var response openai.AudioTranscriptionNewResponseUnion
+	if err := json.Unmarshal([]byte(`{"text":"hi","usage":{"type":"duration","seconds":2}}`), &response); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	transcript := toTranscript(&response)
+
+	if transcript.Text != "hi" || transcript.Segments != nil || transcript.Confidence != 0 {
+		t.Errorf("transcript = %+v", transcript)
+	}
+	if transcript.Usage.AudioDuration != 2 {
+		t.Errorf("duration = %v, want 2", transcript.Usage.AudioDuration)
+	}
+}
+
+func TestSpeechParams(t *testing.T) {
+	options := speech.SynthesisOptions{
+		Model:       openai.SpeechModelGPT4oMiniTTS,
+		Voice:       "Nova",
+		AudioFormat: speech.AudioFormatWAV,
+		SpeechRate:  1.0,
+	}
+
+	params, err := speechParams("hello", options)
+	if err != nil {
+		t.Fatalf("speechParams: %v", err)
+	}
+	if params.Voice != "nova" || params.ResponseFormat != openai.AudioSpeechNewParamsResponseFormatWAV {
+		t.Errorf("voice %q, format %q", params.Voice, params.ResponseFormat)
+	}
+
+	options.Voice = "robot"
+	if _, err := speechParams("hello", options); err == nil {
+		t.Error("unknown voice accepted")
+	}
+
+	options.Voice = "alloy"
+	options.Model = openai.SpeechModelTTS1
+	options.Instructions = "Speak calmly"
+	if _, err := speechParams("hello", options); err == nil {
+		t.Error("instructions accepted for tts-1")
+	}
+}
+
+func TestEmbedDocumentsRetriesOnce(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("test-key",
+		WithRequestOptions(option.WithBaseURL(server.URL)),
+		WithRetryPolicy(llm.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+	)
+
+	_, err := p.EmbedDocuments(context.Background(), []string{"a", "b"}, embedding.WithMaxRetries(3))
+	if err == nil {
+		t.Fatal("EmbedDocuments succeeded on 429")
+	}
+	// Only the provider's policy retries: 3 attempts, not 3 x 4
+	if n := calls.Load(); n != 3 {
+		t.Errorf("provider called %d times, want 3", n)
+	}
+}
@@ -0,0 +1,103 @@
+package aiopenai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/openai/openai-go/v3"
+)
+
+// withRetry runs fn under the provider's retry policy. SDK errors are
+// translated to typed llm errors on every attempt, so an exhaustion error
+// wraps e.g. LLM_RATE_LIMITED rather than the raw SDK error.
+func (p *OpenAIProvider) withRetry(ctx context.Context, fn func() error) error {
+	return llm.Retry(ctx, p.retryPolicy, classifyOpenAIError, func() error {
+		return translateOpenAIError(fn())
+	})
+}
+
+// classifyOpenAIError retries 429 (except exhausted quota, which waiting does
+// not fix) and 5xx responses, reading Retry-After / retry-after-ms when sent
+func classifyOpenAIError(err error) (llm.RetryClass, time.Duration) {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return llm.RetryNever, 0
+	}
+
+	switch {
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		if apiErr.Code == "insufficient_quota" {
+			return llm.RetryNever, 0
+		}
+		return llm.RetryRateLimited, retryAfter(apiErr.Response)
+	case apiErr.StatusCode >= 500:
+		return llm.RetryTransient, retryAfter(apiErr.Response)
+	default:
+		return llm.RetryNever, 0
+	}
+}
+
+// retryAfter parses the wait requested by the API, 0 when absent
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	if ms, err := strconv.ParseFloat(resp.Header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// replayableReader returns a function yielding the audio from the start on
+// every call, so a retried upload sends the whole file. Seekable readers are
+// rewound; others are buffered once. A Name() (e.g. *os.File) is preserved
+// because the API infers the audio format from the file name.
+func replayableReader(r io.Reader) (func() (io.Reader, error), error) {
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		return func() (io.Reader, error) {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return r, nil
+		}, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	named, hasName := r.(interface{ Name() string })
+	return func() (io.Reader, error) {
+		if hasName {
+			return namedReader{Reader: bytes.NewReader(data), name: named.Name()}, nil
+		}
+		return bytes.NewReader(data), nil
+	}, nil
+}
+
+// namedReader is a buffered reader that keeps the original file name
+type namedReader struct {
+	io.Reader
+	name string
+}
+
+func (n namedReader) Name() string {
+	return n.name
+}
@@ -0,0 +1,37 @@
+package aiopenai
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/llm"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a client span for an OpenAI call, following the OpenTelemetry
+// gen_ai semantic conventions
+func startSpan(ctx context.Context, name, model string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !tracex.Enabled() {
+		return tracex.Start(ctx, name)
+	}
+	return tracex.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append([]attribute.KeyValue{
+			attribute.String("gen_ai.system", "openai"),
+			attribute.String("gen_ai.request.model", model),
+		}, attrs...)...),
+	)
+}
+
+// endChatSpan records the token usage of a chat call and ends the span
+func endChatSpan(span trace.Span, usage llm.Usage, err error) {
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", usage.CompletionTokens),
+			attribute.Int("gen_ai.usage.total_tokens", usage.TotalTokens),
+		)
+	}
+	tracex.End(span, err)
+}
@@ -7,11 +7,12 @@ type SynthesisOption func(*SynthesisOptions)
 
 // SynthesisOptions contains all configurable parameters for text-to-speech operations
 type SynthesisOptions struct {
-	Voice       string
-	Model       string
-	SpeechRate  float32 // 1.0 is normal speed
-	AudioFormat AudioFormat
-	SampleRate  int
+	Voice        string
+	Model        string
+	Instructions string  // Tone and style guidance, for models that support it
+	SpeechRate   float32 // 1.0 is normal speed
+	AudioFormat  AudioFormat
+	SampleRate   int
 }
 
 // WithVoice sets the voice to use
@@ -28,6 +29,14 @@ func WithTTSModel(model string) SynthesisOption {
 	}
 }
 
+// WithInstructions guides the tone and style of the voice (e.g. "Speak
+// calmly"). Only some models support it, such as OpenAI's gpt-4o-mini-tts.
+func WithInstructions(instructions string) SynthesisOption {
+	return func(o *SynthesisOptions) {
+		o.Instructions = instructions
+	}
+}
+
 // WithSpeechRate sets the speech rate multiplier
 func WithSpeechRate(rate float32) SynthesisOption {
 	return func(o *SynthesisOptions) {
@@ -77,7 +86,8 @@ func WithLanguage(language string) TranscriptionOption {
 	}
 }
 
-// WithTimestamps enables word/phrase timestamps in the output
+// WithTimestamps enables segment and word timestamps in the output
+// (Transcript.Segments and Transcript.Words)
 func WithTimestamps(enable bool) TranscriptionOption {
 	return func(o *TranscriptionOptions) {
 		o.Timestamps = enable
@@ -17,6 +17,16 @@ type Speaker interface {
 	Synthesize(ctx context.Context, text string, opts ...SynthesisOption) (Audio, error)
 }
 
+// StreamingSpeaker is a Speaker that can return audio while it is still being
+// generated, so playback of long texts starts before synthesis completes
+type StreamingSpeaker interface {
+	Speaker
+
+	// SynthesizeStream returns Audio whose Content yields chunks as the
+	// provider produces them. The caller must close Content.
+	SynthesizeStream(ctx context.Context, text string, opts ...SynthesisOption) (Audio, error)
+}
+
 // Audio represents the generated speech audio
 type Audio struct {
 	// Content is the audio data
@@ -54,6 +64,9 @@ type Transcript struct {
 	// Segments contains detailed information about segments (if supported)
 	Segments []TranscriptSegment
 
+	// Words contains word-level timestamps (if supported)
+	Words []TranscriptWord
+
 	// LanguageCode is the detected language (if available)
 	LanguageCode string
 
@@ -79,6 +92,18 @@ type TranscriptSegment struct {
 	Confidence float32
 }
 
+// TranscriptWord represents a single transcribed word
+type TranscriptWord struct {
+	// Text is the word
+	Text string
+
+	// StartTime is the start time in seconds
+	StartTime float32
+
+	// EndTime is the end time in seconds
+	EndTime float32
+}
+
 // STTUsage represents resource usage statistics for speech-to-text
 type STTUsage struct {
 	AudioDuration  float32 // in seconds
@@ -114,6 +139,15 @@ func (c *TTSClient) Synthesize(ctx context.Context, text string, opts ...Synthes
 	return c.speaker.Synthesize(ctx, text, opts...)
 }
 
+// SynthesizeStream converts text to speech audio, streaming it when the
+// speaker supports it and falling back to Synthesize otherwise
+func (c *TTSClient) SynthesizeStream(ctx context.Context, text string, opts ...SynthesisOption) (Audio, error) {
+	if streaming, ok := c.speaker.(StreamingSpeaker); ok {
+		return streaming.SynthesizeStream(ctx, text, opts...)
+	}
+	return c.speaker.Synthesize(ctx, text, opts...)
+}
+
 // STTClient represents a configured speech-to-text client
 type STTClient struct {
 	transcriber Transcriber
@@ -5,6 +5,10 @@ type Options struct {
 	// Namespace/partition
 	Namespace string
 
+	// TenantID scopes the operation to a single tenant on providers that
+	// support tenant isolation
+	TenantID string
+
 	// TopK results to return
 	TopK int
 
@@ -42,6 +46,13 @@ func WithNamespace(namespace string) Option {
 	}
 }
 
+// WithTenant scopes the operation to tenantID
+func WithTenant(tenantID string) Option {
+	return func(o *Options) {
+		o.TenantID = tenantID
+	}
+}
+
 // Query options
 func WithTopK(k int) Option {
 	return func(o *Options) {
@@ -3,10 +3,11 @@ package vstpgvector
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq"
 )
 
 // DBClient handles all database operations
@@ -16,10 +17,11 @@ type DBClient struct {
 	tableName          string
 	dimension          int
 	useNamespaceColumn bool
+	useTenantColumn    bool
 }
 
 // NewDBClient creates a new database client
-func NewDBClient(db *sqlx.DB, schema, tableName string, dimension int, useNamespaceColumn bool) *DBClient {
+func NewDBClient(db *sqlx.DB, schema, tableName string, dimension int, useNamespaceColumn, useTenantColumn bool) *DBClient {
 	if schema == "" {
 		schema = "public"
 	}
@@ -30,6 +32,7 @@ func NewDBClient(db *sqlx.DB, schema, tableName string, dimension int, useNamesp
 		tableName:          tableName,
 		dimension:          dimension,
 		useNamespaceColumn: useNamespaceColumn,
+		useTenantColumn:    useTenantColumn,
 	}
 }
 
@@ -69,38 +72,48 @@ func (c *DBClient) EnsureExtension(ctx context.Context) error {
 	return nil
 }
 
-// CreateTable creates the vectors table
+// CreateTable creates the vectors table. With the tenant column the primary
+// key is (tenant_id, id), so two tenants may use the same vector ID; a table
+// created before the tenant column is migrated to that key (see
+// migrateTenantKey).
 func (c *DBClient) CreateTable(ctx context.Context) error {
-	var query string
-
+	columns := []string{}
+	primaryKey := "id"
+	if c.useTenantColumn {
+		columns = append(columns, "tenant_id TEXT NOT NULL")
+		primaryKey = "tenant_id, id"
+	}
+	columns = append(columns,
+		"id TEXT NOT NULL",
+		fmt.Sprintf("vector vector(%d) NOT NULL", c.dimension),
+		"metadata JSONB",
+	)
 	if c.useNamespaceColumn {
-		query = fmt.Sprintf(`
-			CREATE TABLE IF NOT EXISTS %s.%s (
-				id TEXT PRIMARY KEY,
-				vector vector(%d) NOT NULL,
-				metadata JSONB,
-				namespace TEXT DEFAULT '',
-				created_at TIMESTAMP DEFAULT NOW(),
-				updated_at TIMESTAMP DEFAULT NOW()
-			)`,
-			c.schema, c.tableName, c.dimension)
-	} else {
-		query = fmt.Sprintf(`
-			CREATE TABLE IF NOT EXISTS %s.%s (
-				id TEXT PRIMARY KEY,
-				vector vector(%d) NOT NULL,
-				metadata JSONB,
-				created_at TIMESTAMP DEFAULT NOW(),
-				updated_at TIMESTAMP DEFAULT NOW()
-			)`,
-			c.schema, c.tableName, c.dimension)
+		columns = append(columns, "namespace TEXT DEFAULT ''")
 	}
+	columns = append(columns,
+		"created_at TIMESTAMP DEFAULT NOW()",
+		"updated_at TIMESTAMP DEFAULT NOW()",
+		fmt.Sprintf("PRIMARY KEY (%s)", primaryKey),
+	)
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s.%s (
+			%s
+		)`,
+		c.schema, c.tableName, strings.Join(columns, ",\n\t\t\t"))
 
 	_, err := c.db.ExecContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to create table: %w", err)
 	}
 
+	if c.useTenantColumn {
+		if err := c.migrateTenantKey(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Create indexes
 	if c.useNamespaceColumn {
 		indexQuery := fmt.Sprintf(`
@@ -126,6 +139,56 @@ func (c *DBClient) CreateTable(ctx context.Context) error {
 	return nil
 }
 
+// migrateTenantKey upgrades a table created without the tenant column, which
+// CREATE TABLE IF NOT EXISTS leaves untouched: it adds tenant_id and moves the
+// primary key to (tenant_id, id). Rows stored before the upgrade get an empty
+// tenant, which no scoped operation reads, so they have to be backfilled with
+// their owner before they are visible again.
+func (c *DBClient) migrateTenantKey(ctx context.Context) error {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin tenant migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	addColumn := fmt.Sprintf(`
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT ''`,
+		c.FullTableName())
+	if _, err := tx.ExecContext(ctx, addColumn); err != nil {
+		return fmt.Errorf("failed to add tenant column: %w", err)
+	}
+	dropDefault := fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN tenant_id DROP DEFAULT`, c.FullTableName())
+	if _, err := tx.ExecContext(ctx, dropDefault); err != nil {
+		return fmt.Errorf("failed to add tenant column: %w", err)
+	}
+
+	var primaryKey struct {
+		Name       string `db:"conname"`
+		Definition string `db:"definition"`
+	}
+	err = tx.GetContext(ctx, &primaryKey, `
+		SELECT conname, pg_get_constraintdef(oid) AS definition
+		FROM pg_constraint
+		WHERE conrelid = $1::regclass AND contype = 'p'`,
+		c.FullTableName())
+	if err != nil {
+		return fmt.Errorf("failed to read primary key: %w", err)
+	}
+	if primaryKey.Definition != "PRIMARY KEY (tenant_id, id)" {
+		alter := fmt.Sprintf(`
+			ALTER TABLE %s DROP CONSTRAINT %s, ADD PRIMARY KEY (tenant_id, id)`,
+			c.FullTableName(), pq.QuoteIdentifier(primaryKey.Name))
+		if _, err := tx.ExecContext(ctx, alter); err != nil {
+			return fmt.Errorf("failed to migrate primary key: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tenant migration: %w", err)
+	}
+	return nil
+}
+
 // CreateVectorIndex creates a vector similarity index
 func (c *DBClient) CreateVectorIndex(ctx context.Context, config IndexConfig) error {
 	var query string
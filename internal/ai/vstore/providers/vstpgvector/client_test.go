@@ -0,0 +1,63 @@
+package vstpgvector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/testx"
+)
+
+func TestCreateTableMigratesTenantKey(t *testing.T) {
+	ctx := context.Background()
+	db := testx.Postgres(t)
+
+	legacy := NewDBClient(db, "", "vectors", 3, false, false)
+	if err := legacy.EnsureExtension(ctx); err != nil {
+		t.Skipf("pgvector unavailable: %v", err)
+	}
+	if err := db.GetContext(ctx, &legacy.schema, "SELECT current_schema()"); err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO "+legacy.FullTableName()+" (id, vector) VALUES ('doc-1', '[1,2,3]')"); err != nil {
+		t.Fatal(err)
+	}
+
+	tenant := NewDBClient(db, legacy.schema, "vectors", 3, false, true)
+	for i := 0; i < 2; i++ {
+		if err := tenant.CreateTable(ctx); err != nil {
+			t.Fatalf("CreateTable run %d: %v", i+1, err)
+		}
+	}
+
+	var primaryKey string
+	err := db.GetContext(ctx, &primaryKey, `
+		SELECT pg_get_constraintdef(oid) FROM pg_constraint
+		WHERE conrelid = $1::regclass AND contype = 'p'`, tenant.FullTableName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primaryKey != "PRIMARY KEY (tenant_id, id)" {
+		t.Errorf("primary key = %s, want (tenant_id, id)", primaryKey)
+	}
+
+	// Legacy rows are kept with an empty tenant, and the same ID is free for
+	// every tenant
+	var legacyTenant string
+	if err := db.GetContext(ctx, &legacyTenant, "SELECT tenant_id FROM "+tenant.FullTableName()+" WHERE id = 'doc-1'"); err != nil {
+		t.Fatal(err)
+	}
+	if legacyTenant != "" {
+		t.Errorf("legacy row tenant = %q, want empty", legacyTenant)
+	}
+	for _, tenantID := range []string{"t1", "t2"} {
+		if _, err := db.ExecContext(ctx, "INSERT INTO "+tenant.FullTableName()+" (tenant_id, id, vector) VALUES ($1, 'doc-1', '[1,2,3]')", tenantID); err != nil {
+			t.Fatalf("insert doc-1 for %s: %v", tenantID, err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO "+tenant.FullTableName()+" (id, vector) VALUES ('doc-2', '[1,2,3]')"); err == nil {
+		t.Error("insert without a tenant succeeded, want tenant_id to be required")
+	}
+}
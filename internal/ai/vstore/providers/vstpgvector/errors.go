@@ -70,6 +70,13 @@ var (
 		"Invalid namespace name",
 	)
 
+	ErrMissingTenant = errorRegistry.Register(
+		"MISSING_TENANT",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Tenant ID is required when tenant isolation is enabled",
+	)
+
 	// Table/Index Errors
 	ErrTableNotFound = errorRegistry.Register(
 		"TABLE_NOT_FOUND",
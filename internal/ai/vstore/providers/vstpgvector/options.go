@@ -70,6 +70,15 @@ func WithNamespaceColumn(enabled bool) ProviderOption {
 	}
 }
 
+// WithTenantColumn enables tenant isolation via a tenant_id column. Every
+// operation must then carry vstore.WithTenant, and vectors of one tenant are
+// never read, overwritten or deleted by another.
+func WithTenantColumn(enabled bool) ProviderOption {
+	return func(p *PgVectorProvider) {
+		p.useTenantColumn = enabled
+	}
+}
+
 // WithBatchSize sets the default batch size for operations
 func WithBatchSize(size int) ProviderOption {
 	return func(p *PgVectorProvider) {
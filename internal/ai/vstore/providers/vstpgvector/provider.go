@@ -35,6 +35,7 @@ type PgVectorProvider struct {
 	defaultMetric      DistanceMetric
 	autoCreateTable    bool
 	useNamespaceColumn bool
+	useTenantColumn    bool
 	batchSize          int
 
 	// Track if we own the connection (should close it)
@@ -82,7 +83,7 @@ func NewPgVectorProvider(connStr string, dimension int, opts ...ProviderOption)
 	}
 
 	provider.db = dbx
-	provider.client = NewDBClient(dbx, provider.schema, provider.tableName, provider.dimension, provider.useNamespaceColumn)
+	provider.client = NewDBClient(dbx, provider.schema, provider.tableName, provider.dimension, provider.useNamespaceColumn, provider.useTenantColumn)
 
 	// Ensure extension and table
 	if err := provider.initialize(ctx); err != nil {
@@ -123,7 +124,7 @@ func NewPgVectorProviderFromDB(dbx *sqlx.DB, dimension int, opts ...ProviderOpti
 	}
 
 	provider.db = dbx
-	provider.client = NewDBClient(dbx, provider.schema, provider.tableName, provider.dimension, provider.useNamespaceColumn)
+	provider.client = NewDBClient(dbx, provider.schema, provider.tableName, provider.dimension, provider.useNamespaceColumn, provider.useTenantColumn)
 
 	// Ensure extension and table
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -189,31 +190,44 @@ func (p *PgVectorProvider) Upsert(ctx context.Context, vectors []vstore.Vector,
 	}
 
 	options := vstore.ApplyOptions(opts...)
-	namespace := options.Namespace
+	if p.useTenantColumn && options.TenantID == "" {
+		return errorRegistry.New(ErrMissingTenant)
+	}
 
-	// Build upsert query
-	var query string
+	// Build upsert query. With the tenant column the conflict target includes
+	// tenant_id, so an upsert can never overwrite another tenant's vector.
+	columns := []string{"id", "vector", "metadata"}
+	conflict := "id"
+	if p.useTenantColumn {
+		columns = append(columns, "tenant_id")
+		conflict = "tenant_id, id"
+	}
 	if p.useNamespaceColumn {
-		query = fmt.Sprintf(`
-			INSERT INTO %s (id, vector, metadata, namespace, updated_at)
-			VALUES ($1, $2, $3, $4, NOW())
-			ON CONFLICT (id) DO UPDATE SET
-				vector = EXCLUDED.vector,
-				metadata = EXCLUDED.metadata,
-				namespace = EXCLUDED.namespace,
-				updated_at = NOW()`,
-			p.client.FullTableName())
-	} else {
-		query = fmt.Sprintf(`
-			INSERT INTO %s (id, vector, metadata, updated_at)
-			VALUES ($1, $2, $3, NOW())
-			ON CONFLICT (id) DO UPDATE SET
-				vector = EXCLUDED.vector,
-				metadata = EXCLUDED.metadata,
-				updated_at = NOW()`,
-			p.client.FullTableName())
+		columns = append(columns, "namespace")
 	}
 
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	updates := []string{"vector = EXCLUDED.vector", "metadata = EXCLUDED.metadata"}
+	if p.useNamespaceColumn {
+		updates = append(updates, "namespace = EXCLUDED.namespace")
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (%s, updated_at)
+		VALUES (%s, NOW())
+		ON CONFLICT (%s) DO UPDATE SET
+			%s,
+			updated_at = NOW()`,
+		p.client.FullTableName(),
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+		conflict,
+		strings.Join(updates, ",\n\t\t\t"))
+
 	// Execute in transaction for batch consistency
 	tx, err := p.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -231,14 +245,15 @@ func (p *PgVectorProvider) Upsert(ctx context.Context, vectors []vstore.Vector,
 		pgVector := Vector(v.Values)
 		metadata := Metadata(v.Metadata)
 
-		var execErr error
+		args := []any{v.ID, pgVector, metadata}
+		if p.useTenantColumn {
+			args = append(args, options.TenantID)
+		}
 		if p.useNamespaceColumn {
-			_, execErr = stmt.ExecContext(ctx, v.ID, pgVector, metadata, namespace)
-		} else {
-			_, execErr = stmt.ExecContext(ctx, v.ID, pgVector, metadata)
+			args = append(args, options.Namespace)
 		}
 
-		if execErr != nil {
+		if _, execErr := stmt.ExecContext(ctx, args...); execErr != nil {
 			return ParseDatabaseError(execErr, query, v.ID)
 		}
 	}
@@ -260,6 +275,15 @@ func (p *PgVectorProvider) Query(ctx context.Context, vector []float32, opts ...
 
 	options := vstore.ApplyOptions(opts...)
 
+	// Tenant and namespace scope come first so a metadata filter can only
+	// narrow the search, never widen it
+	args := []any{Vector(vector)}
+	conditions, scopeArgs, scopeErr := p.scopeConditions(options, len(args)+1)
+	if scopeErr != nil {
+		return nil, scopeErr
+	}
+	args = append(args, scopeArgs...)
+
 	// Build query
 	selectFields := "id"
 	if options.IncludeValues {
@@ -277,28 +301,19 @@ func (p *PgVectorProvider) Query(ctx context.Context, vector []float32, opts ...
 		FROM %s`,
 		selectFields, distanceOp, p.client.FullTableName())
 
-	// Add namespace filter
-	args := []any{Vector(vector)}
-	argNum := 2
-	if p.useNamespaceColumn && options.Namespace != "" {
-		query += fmt.Sprintf(" WHERE namespace = $%d", argNum)
-		args = append(args, options.Namespace)
-		argNum++
-	}
-
 	// Add metadata filter if provided
 	if options.Filter != nil {
-		filterClause, filterArgs := p.buildFilterClause(options.Filter, argNum)
+		filterClause, filterArgs := p.buildFilterClause(options.Filter, len(args)+1)
 		if filterClause != "" {
-			if strings.Contains(query, "WHERE") {
-				query += " AND " + filterClause
-			} else {
-				query += " WHERE " + filterClause
-			}
+			conditions = append(conditions, "("+filterClause+")")
 			args = append(args, filterArgs...)
 		}
 	}
 
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
 	// Order by distance and limit
 	query += fmt.Sprintf(" ORDER BY distance LIMIT %d", options.TopK)
 
@@ -344,10 +359,14 @@ func (p *PgVectorProvider) Delete(ctx context.Context, ids []string, opts ...vst
 	query := fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1)`, p.client.FullTableName())
 	args := []any{ids}
 
-	if p.useNamespaceColumn && options.Namespace != "" {
-		query += " AND namespace = $2"
-		args = append(args, options.Namespace)
+	conditions, scopeArgs, scopeErr := p.scopeConditions(options, len(args)+1)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	for _, cond := range conditions {
+		query += " AND " + cond
 	}
+	args = append(args, scopeArgs...)
 
 	_, err := p.db.ExecContext(ctx, query, args...)
 	if err != nil {
@@ -373,10 +392,14 @@ func (p *PgVectorProvider) Fetch(ctx context.Context, ids []string, opts ...vsto
 
 	args := []any{ids}
 
-	if p.useNamespaceColumn && options.Namespace != "" {
-		query += " AND namespace = $2"
-		args = append(args, options.Namespace)
+	conditions, scopeArgs, scopeErr := p.scopeConditions(options, len(args)+1)
+	if scopeErr != nil {
+		return nil, scopeErr
 	}
+	for _, cond := range conditions {
+		query += " AND " + cond
+	}
+	args = append(args, scopeArgs...)
 
 	rows, err := p.db.QueryxContext(ctx, query, args...)
 	if err != nil {
@@ -456,12 +479,17 @@ func (p *PgVectorProvider) DeleteBatch(ctx context.Context, ids []string, opts .
 // NamespaceManager Implementation
 // ============================================================================
 
-// ListNamespaces returns all namespaces
+// ListNamespaces returns all namespaces. It takes no tenant, so it is not
+// available when tenant isolation is enabled.
 func (p *PgVectorProvider) ListNamespaces(ctx context.Context) ([]string, *errx.Error) {
 	if !p.useNamespaceColumn {
 		return nil, errorRegistry.New(ErrFeatureNotSupported).
 			WithDetail("error", "namespace column not enabled")
 	}
+	if p.useTenantColumn {
+		return nil, errorRegistry.New(ErrFeatureNotSupported).
+			WithDetail("error", "namespaces span tenants when tenant isolation is enabled")
+	}
 
 	query := fmt.Sprintf(`
 		SELECT DISTINCT namespace
@@ -484,12 +512,17 @@ func (p *PgVectorProvider) CreateNamespace(ctx context.Context, namespace string
 	return nil
 }
 
-// DeleteNamespace deletes a namespace and all its vectors
+// DeleteNamespace deletes a namespace and all its vectors. It takes no
+// tenant, so it is not available when tenant isolation is enabled.
 func (p *PgVectorProvider) DeleteNamespace(ctx context.Context, namespace string) *errx.Error {
 	if !p.useNamespaceColumn {
 		return errorRegistry.New(ErrFeatureNotSupported).
 			WithDetail("error", "namespace column not enabled")
 	}
+	if p.useTenantColumn {
+		return errorRegistry.New(ErrFeatureNotSupported).
+			WithDetail("error", "namespaces span tenants when tenant isolation is enabled")
+	}
 
 	query := fmt.Sprintf(`DELETE FROM %s WHERE namespace = $1`, p.client.FullTableName())
 
@@ -585,14 +618,13 @@ func (p *PgVectorProvider) GetStatistics(ctx context.Context, opts ...vstore.Opt
 	var totalCount int64
 
 	// Get total vector count
-	var countQuery string
-	var args []any
-
-	if p.useNamespaceColumn && options.Namespace != "" {
-		countQuery = fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE namespace = $1`, p.client.FullTableName())
-		args = []any{options.Namespace}
-	} else {
-		countQuery = fmt.Sprintf(`SELECT COUNT(*) FROM %s`, p.client.FullTableName())
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, p.client.FullTableName())
+	conditions, args, scopeErr := p.scopeConditions(options, 1)
+	if scopeErr != nil {
+		return nil, scopeErr
+	}
+	if len(conditions) > 0 {
+		countQuery += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	if err := p.db.GetContext(ctx, &totalCount, countQuery, args...); err != nil {
@@ -604,12 +636,17 @@ func (p *PgVectorProvider) GetStatistics(ctx context.Context, opts ...vstore.Opt
 	if p.useNamespaceColumn {
 		nsQuery := fmt.Sprintf(`
 			SELECT namespace as name, COUNT(*) as vector_count
-			FROM %s
-			GROUP BY namespace`,
+			FROM %s`,
 			p.client.FullTableName())
+		var nsArgs []any
+		if p.useTenantColumn {
+			nsQuery += " WHERE tenant_id = $1"
+			nsArgs = append(nsArgs, options.TenantID)
+		}
+		nsQuery += " GROUP BY namespace"
 
-		if err := p.db.SelectContext(ctx, &namespaceStats, nsQuery); err != nil {
-			return nil, ParseDatabaseError(err, nsQuery)
+		if err := p.db.SelectContext(ctx, &namespaceStats, nsQuery, nsArgs...); err != nil {
+			return nil, ParseDatabaseError(err, nsQuery, nsArgs...)
 		}
 	}
 
@@ -620,6 +657,29 @@ func (p *PgVectorProvider) GetStatistics(ctx context.Context, opts ...vstore.Opt
 // Helper Methods
 // ============================================================================
 
+// scopeConditions returns the tenant and namespace conditions for an
+// operation, numbering placeholders from startArgNum. With the tenant column
+// enabled a missing tenant is an error rather than an unscoped query.
+func (p *PgVectorProvider) scopeConditions(options *vstore.Options, startArgNum int) ([]string, []any, *errx.Error) {
+	var conditions []string
+	var args []any
+
+	if p.useTenantColumn {
+		if options.TenantID == "" {
+			return nil, nil, errorRegistry.New(ErrMissingTenant)
+		}
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", startArgNum+len(args)))
+		args = append(args, options.TenantID)
+	}
+
+	if p.useNamespaceColumn && options.Namespace != "" {
+		conditions = append(conditions, fmt.Sprintf("namespace = $%d", startArgNum+len(args)))
+		args = append(args, options.Namespace)
+	}
+
+	return conditions, args, nil
+}
+
 // buildFilterClause builds a WHERE clause from filter
 func (p *PgVectorProvider) buildFilterClause(filter *vstore.Filter, startArgNum int) (string, []any) {
 	if filter == nil {
@@ -0,0 +1,120 @@
+package vstpgvector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/ai/vstore"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+func TestScopeConditions(t *testing.T) {
+	tests := []struct {
+		name           string
+		tenantColumn   bool
+		namespaceCol   bool
+		opts           []vstore.Option
+		wantConditions []string
+		wantArgs       []any
+		wantErr        bool
+	}{
+		{
+			name: "no scope columns",
+			opts: []vstore.Option{vstore.WithTenant("t1"), vstore.WithNamespace("docs")},
+		},
+		{
+			name:           "tenant",
+			tenantColumn:   true,
+			opts:           []vstore.Option{vstore.WithTenant("t1")},
+			wantConditions: []string{"tenant_id = $2"},
+			wantArgs:       []any{"t1"},
+		},
+		{
+			name:         "tenant is required",
+			tenantColumn: true,
+			opts:         []vstore.Option{vstore.WithNamespace("docs")},
+			wantErr:      true,
+		},
+		{
+			name:           "tenant and namespace",
+			tenantColumn:   true,
+			namespaceCol:   true,
+			opts:           []vstore.Option{vstore.WithTenant("t1"), vstore.WithNamespace("docs")},
+			wantConditions: []string{"tenant_id = $2", "namespace = $3"},
+			wantArgs:       []any{"t1", "docs"},
+		},
+		{
+			name:           "empty namespace is not a condition",
+			tenantColumn:   true,
+			namespaceCol:   true,
+			opts:           []vstore.Option{vstore.WithTenant("t1")},
+			wantConditions: []string{"tenant_id = $2"},
+			wantArgs:       []any{"t1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PgVectorProvider{useTenantColumn: tt.tenantColumn, useNamespaceColumn: tt.namespaceCol}
+			conditions, args, err := p.scopeConditions(vstore.ApplyOptions(tt.opts...), 2)
+			if tt.wantErr {
+				if !errx.Is(err, ErrMissingTenant) {
+					t.Fatalf("error = %v, want %s", err, ErrMissingTenant.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(conditions, tt.wantConditions) || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("scopeConditions = %q %v, want %q %v", conditions, args, tt.wantConditions, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildFilterClause(t *testing.T) {
+	p := &PgVectorProvider{}
+
+	tests := []struct {
+		name       string
+		filter     *vstore.Filter
+		wantClause string
+		wantArgs   []any
+	}{
+		{name: "nil filter"},
+		{name: "empty filter", filter: vstore.NewFilter()},
+		{
+			name:       "must",
+			filter:     vstore.NewFilter().AddMust("source", vstore.OpEqual, "wiki").AddMust("year", vstore.OpGreaterThanOrEqual, 2020),
+			wantClause: "metadata->>'source' = $3 AND metadata->>'year' >= $4",
+			wantArgs:   []any{"wiki", 2020},
+		},
+		{
+			name:       "should is grouped",
+			filter:     vstore.NewFilter().AddMust("source", vstore.OpEqual, "wiki").AddShould("lang", vstore.OpEqual, "en").AddShould("lang", vstore.OpEqual, "es"),
+			wantClause: "metadata->>'source' = $3 AND (metadata->>'lang' = $4 OR metadata->>'lang' = $5)",
+			wantArgs:   []any{"wiki", "en", "es"},
+		},
+		{
+			name:       "must not",
+			filter:     vstore.NewFilter().AddMustNot("draft", vstore.OpExists, nil).AddMustNot("title", vstore.OpContains, "old"),
+			wantClause: "NOT (metadata ? 'draft') AND NOT (metadata->>'title' LIKE $3)",
+			wantArgs:   []any{"%old%"},
+		},
+		{
+			name:     "unsupported operators are skipped",
+			filter:   vstore.NewFilter().AddMust("tag", vstore.OpIn, []string{"a"}),
+			wantArgs: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args := p.buildFilterClause(tt.filter, 3)
+			if clause != tt.wantClause || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("buildFilterClause = %q %v, want %q %v", clause, args, tt.wantClause, tt.wantArgs)
+			}
+		})
+	}
+}
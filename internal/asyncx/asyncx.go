@@ -2,6 +2,7 @@ package asyncx
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 )
@@ -398,3 +399,65 @@ func Once[T any](fn func() (T, error)) func() (T, error) {
 		return val, err
 	}
 }
+
+// ─── Workers ──────────────────────────────────────────────────────────────────
+
+// Workers tracks named long-running goroutines so shutdown can wait for them.
+// The zero value is ready to use.
+type Workers struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// Go runs fn in a goroutine registered under name. fn must return once ctx
+// is cancelled.
+func (w *Workers) Go(ctx context.Context, name string, fn func(context.Context)) {
+	w.mu.Lock()
+	if w.running == nil {
+		w.running = map[string]int{}
+	}
+	w.running[name]++
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			w.mu.Lock()
+			if w.running[name]--; w.running[name] == 0 {
+				delete(w.running, name)
+			}
+			w.mu.Unlock()
+		}()
+		fn(ctx)
+	}()
+}
+
+// Wait blocks until every worker returns or timeout elapses, and returns the
+// sorted names of the workers still running (nil if all finished).
+func (w *Workers) Wait(timeout time.Duration) []string {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	pending := make([]string, 0, len(w.running))
+	for name := range w.running {
+		pending = append(pending, name)
+	}
+	slices.Sort(pending)
+	return pending
+}
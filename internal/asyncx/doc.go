@@ -127,6 +127,20 @@
 //
 //	cfg, err := loadConfig() // always returns the same value
 //
+// # Background Workers
+//
+// [Workers] tracks long-running goroutines by name so graceful shutdown can
+// cancel their context and wait for them. [Workers.Wait] returns the names
+// of the workers that did not finish before the timeout.
+//
+//	var workers asyncx.Workers
+//	workers.Go(ctx, "cleanup", cleanupService.Start)
+//
+//	cancel()
+//	if pending := workers.Wait(10 * time.Second); len(pending) > 0 {
+//	    logx.Warnf("workers did not finish: %v", pending)
+//	}
+//
 // # Design Notes
 //
 // All functions that accept a [context.Context] propagate cancellation and
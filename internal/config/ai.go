@@ -0,0 +1,28 @@
+package config
+
+// AIConfig configures model resolution and LLM usage metering
+type AIConfig struct {
+	// UsageMetering records the tokens and cost of LLM and embedding calls
+	// made on behalf of a tenant (GET /usage/llm)
+	UsageMetering bool
+	// ChatModel and EmbeddingModel are the global targets of the
+	// "chat-default" and "embed-default" aliases
+	ChatModel      string
+	EmbeddingModel string
+	// ModelAliases adds or overrides global aliases ("chat-fast=gpt-4.1-mini");
+	// tenant plans and settings override them in turn
+	ModelAliases map[string]string
+	// Pricing overrides the built-in price table, as
+	// "model=input/output[/cached_input]" in USD per 1M tokens
+	Pricing map[string]string
+}
+
+func loadAIConfig() AIConfig {
+	return AIConfig{
+		UsageMetering:  getEnvBool("LLM_USAGE_METERING", true),
+		ChatModel:      getEnv("LLM_DEFAULT_MODEL", "gpt-4.1"),
+		EmbeddingModel: getEnv("LLM_DEFAULT_EMBEDDING_MODEL", "text-embedding-3-small"),
+		ModelAliases:   getEnvStringMap("LLM_MODEL_ALIASES", nil),
+		Pricing:        getEnvStringMap("LLM_PRICING", nil),
+	}
+}
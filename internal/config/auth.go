@@ -11,47 +11,116 @@ type AuthConfig struct {
 	PasswordReset PasswordResetConfig
 	Cookie        CookieConfig
 	Password      PasswordConfig
+	RateLimit     RateLimitConfig
+	Idempotency   IdempotencyConfig
+	Outbox        OutboxConfig
+	Cache         CacheConfig
+
+	// NormalizeScopes drops scopes implied by a wildcard in the same list
+	// (e.g. "jobs:read" next to "jobs:*") before user and invitation scopes
+	// are saved. The requested list is kept in the audit event.
+	NormalizeScopes bool
 }
 
 type JWTConfig struct {
-	SecretKey       string
+	SecretKey       string // HS256 signing secret
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 	Issuer          string
 	Audience        []string
+	// StrictAudience rejects access tokens without an aud claim. When false,
+	// tokens without aud are accepted, but an aud outside Audience still fails.
+	StrictAudience bool
+
+	// Key rotation: new tokens are signed with the current key and carry its
+	// kid; PreviousKeys keep validating the tokens of retired keys until they
+	// expire (kid → HMAC secret, or kid → "file:<path>" to a PEM key)
+	SigningAlgorithm string // HS256 (SecretKey), RS256 or ES256 (PrivateKeyFile)
+	PrivateKeyFile   string // PEM private key for RS256 / ES256
+	KeyID            string // kid of the current key; derived from the key when empty
+	PreviousKeys     map[string]string
 }
 
 type APIKeyConfig struct {
 	LivePrefix  string
 	TestPrefix  string
 	TokenLength int
+	// LastUsedInterval is the minimum time between two last_used_at writes of
+	// the same key
+	LastUsedInterval time.Duration
 }
 
 type SessionConfig struct {
 	ExpirationTime  time.Duration
 	CleanupInterval time.Duration
 	MaxSessions     int
+
+	// MaxSessionsPolicy decides what happens when a user at MaxSessions logs
+	// in again: "revoke_oldest" signs out the oldest session, "reject" refuses
+	// the login. MaxSessions <= 0 means unlimited.
+	MaxSessionsPolicy string
+
+	// ActivityInterval is the minimum time between two last_activity writes of
+	// the same session. With SlidingExpiration every write also moves the
+	// session's expiry to ExpirationTime from now, never past AbsoluteTimeout
+	// after login, and sessions idle longer than ExpirationTime are cleaned up.
+	ActivityInterval  time.Duration
+	SlidingExpiration bool
+	AbsoluteTimeout   time.Duration
+
+	// CleanupMode is "all" (every instance cleans) or "leader" (a Redis lock
+	// makes a single instance run each pass). A zero CleanupLockTTL holds the
+	// lock for half a CleanupInterval.
+	CleanupMode    string
+	CleanupJitter  time.Duration
+	CleanupLockTTL time.Duration
 }
 
 type OTPConfig struct {
 	CodeLength      int
+	Charset         string // "numeric" (default) or "alphanumeric"
 	ExpirationTime  time.Duration
 	MaxAttempts     int
 	RateLimitWindow time.Duration
 	TokenByteLength int
+	Store           string // "postgres" (default) or "redis"
 }
 
 type InvitationConfig struct {
-	DefaultExpirationDays int
-	TokenByteLength       int
-	MaxPendingPerTenant   int
+	DefaultExpirationDays   int
+	TokenByteLength         int
+	MaxPendingPerTenant     int
+	ResendCooldown          time.Duration // Minimum time between two sends of the same invitation
+	RegenerateTokenOnResend bool          // Invalidate the previous link when resending
+	AcceptURL               string        // Frontend page that accepts invitations; the token is appended as ?token=
+}
+
+// OutboxConfig configures the transactional outbox that delivers invitation
+// emails and OTP codes outside the request path
+type OutboxConfig struct {
+	Enabled        bool          // When false, emails and codes are sent inline as before
+	PollInterval   time.Duration // How often the dispatcher looks for due messages
+	BatchSize      int           // Messages claimed per query
+	MaxAttempts    int           // Deliveries tried before a message is marked FAILED
+	RetryBaseDelay time.Duration // Backoff after the first failure, doubled on each retry
+	RetryMaxDelay  time.Duration
+	Lease          time.Duration // How long a claimed message stays hidden from other instances
+	Retention      time.Duration // How long SENT messages are kept
+}
+
+// CacheConfig controls the Redis read-through cache in front of the tenant and
+// user lookups by ID
+type CacheConfig struct {
+	Enabled bool          // Requires Redis; when false every lookup hits Postgres
+	TTL     time.Duration // Upper bound on staleness when an invalidation is missed
 }
 
 type PasswordResetConfig struct {
 	TokenByteLength      int
 	ExpirationTime       time.Duration
 	RateLimitWindow      time.Duration
-	MaxAttemptsPerWindow int
+	MaxAttemptsPerWindow int    // Reset emails per user within RateLimitWindow
+	ResetURL             string // Frontend page that sets the new password; the token is appended as ?token=
 }
 
 type CookieConfig struct {
@@ -64,46 +133,109 @@ type CookieConfig struct {
 	SameSite         string
 }
 
+// PasswordConfig configures password hashing and the policy enforced when a
+// password is set. Only the minimum length is required by default.
 type PasswordConfig struct {
-	BcryptCost int
+	BcryptCost       int
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// RateLimitConfig configures the HTTP rate limits of the auth endpoints.
+// Counters live in Redis so limits hold across instances.
+type RateLimitConfig struct {
+	Enabled  bool
+	FailOpen bool          // Let requests through when Redis is unreachable
+	Login    RateLimitRule // Login, signup, tenant lookup and password reset initiation, per IP
+	OTP      RateLimitRule // OTP verification, resend, password login and reset, per IP
+	Default  RateLimitRule // Every other /auth route, per IP; Requests 0 disables it
+}
+
+type RateLimitRule struct {
+	Requests int
+	Window   time.Duration
+}
+
+// IdempotencyConfig configures Idempotency-Key handling on creation endpoints.
+// Responses are kept in Redis for TTL and replayed on retries with the same key.
+type IdempotencyConfig struct {
+	Enabled bool
+	TTL     time.Duration
 }
 
 func loadAuthConfig() AuthConfig {
 	return AuthConfig{
 		JWT: JWTConfig{
-			SecretKey:       getEnv("JWT_SECRET_KEY", ""),
-			AccessTokenTTL:  getEnvDuration("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
-			RefreshTokenTTL: getEnvDuration("JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour),
-			Issuer:          getEnv("JWT_ISSUER", "manifesto"),
-			Audience:        getEnvStringSlice("JWT_AUDIENCE", []string{"manifesto-api"}),
+			SecretKey:        getEnv("JWT_SECRET_KEY", ""),
+			SigningAlgorithm: getEnv("JWT_SIGNING_ALGORITHM", "HS256"),
+			PrivateKeyFile:   getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			KeyID:            getEnv("JWT_KEY_ID", ""),
+			PreviousKeys:     getEnvStringMap("JWT_PREVIOUS_KEYS", nil),
+			AccessTokenTTL:   getEnvDuration("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:  getEnvDuration("JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			Issuer:           getEnv("JWT_ISSUER", "manifesto"),
+			Audience:         getEnvStringSlice("JWT_AUDIENCE", []string{"manifesto-api"}),
+			StrictAudience:   getEnvBool("JWT_STRICT_AUDIENCE", true),
 		},
 		APIKey: APIKeyConfig{
-			LivePrefix:  getEnv("API_KEY_LIVE_PREFIX", "manifesto_live"),
-			TestPrefix:  getEnv("API_KEY_TEST_PREFIX", "manifesto_test"),
-			TokenLength: getEnvInt("API_KEY_TOKEN_LENGTH", 32),
+			LivePrefix:       getEnv("API_KEY_LIVE_PREFIX", "manifesto_live"),
+			TestPrefix:       getEnv("API_KEY_TEST_PREFIX", "manifesto_test"),
+			TokenLength:      getEnvInt("API_KEY_TOKEN_LENGTH", 32),
+			LastUsedInterval: getEnvDuration("API_KEY_LAST_USED_INTERVAL", 1*time.Minute),
 		},
 		Session: SessionConfig{
-			ExpirationTime:  getEnvDuration("SESSION_EXPIRATION_TIME", 24*time.Hour),
-			CleanupInterval: getEnvDuration("SESSION_CLEANUP_INTERVAL", 1*time.Hour),
-			MaxSessions:     getEnvInt("SESSION_MAX_PER_USER", 10),
+			ExpirationTime:    getEnvDuration("SESSION_EXPIRATION_TIME", 24*time.Hour),
+			CleanupInterval:   getEnvDuration("SESSION_CLEANUP_INTERVAL", 1*time.Hour),
+			MaxSessions:       getEnvInt("SESSION_MAX_PER_USER", 10),
+			MaxSessionsPolicy: getEnv("SESSION_MAX_POLICY", "revoke_oldest"),
+			ActivityInterval:  getEnvDuration("SESSION_ACTIVITY_INTERVAL", 1*time.Minute),
+			SlidingExpiration: getEnvBool("SESSION_SLIDING_EXPIRATION", false),
+			AbsoluteTimeout:   getEnvDuration("SESSION_ABSOLUTE_TIMEOUT", 7*24*time.Hour),
+			CleanupMode:       getEnv("SESSION_CLEANUP_MODE", "all"),
+			CleanupJitter:     getEnvDuration("SESSION_CLEANUP_JITTER", 0),
+			CleanupLockTTL:    getEnvDuration("SESSION_CLEANUP_LOCK_TTL", 0),
 		},
 		OTP: OTPConfig{
 			CodeLength:      getEnvInt("OTP_CODE_LENGTH", 6),
+			Charset:         getEnv("OTP_CHARSET", "numeric"),
 			ExpirationTime:  getEnvDuration("OTP_EXPIRATION_TIME", 10*time.Minute),
 			MaxAttempts:     getEnvInt("OTP_MAX_ATTEMPTS", 5),
 			RateLimitWindow: getEnvDuration("OTP_RATE_LIMIT_WINDOW", 1*time.Minute),
 			TokenByteLength: getEnvInt("OTP_TOKEN_BYTE_LENGTH", 3),
+			Store:           getEnv("OTP_STORE", "postgres"),
 		},
 		Invitation: InvitationConfig{
-			DefaultExpirationDays: getEnvInt("INVITATION_DEFAULT_EXPIRATION_DAYS", 7),
-			TokenByteLength:       getEnvInt("INVITATION_TOKEN_BYTE_LENGTH", 32),
-			MaxPendingPerTenant:   getEnvInt("INVITATION_MAX_PENDING_PER_TENANT", 100),
+			DefaultExpirationDays:   getEnvInt("INVITATION_DEFAULT_EXPIRATION_DAYS", 7),
+			TokenByteLength:         getEnvInt("INVITATION_TOKEN_BYTE_LENGTH", 32),
+			MaxPendingPerTenant:     getEnvInt("INVITATION_MAX_PENDING_PER_TENANT", 100),
+			ResendCooldown:          getEnvDuration("INVITATION_RESEND_COOLDOWN", 5*time.Minute),
+			RegenerateTokenOnResend: getEnvBool("INVITATION_RESEND_REGENERATE_TOKEN", true),
+			AcceptURL:               getEnv("INVITATION_ACCEPT_URL", "http://localhost:5173/invitations/accept"),
+		},
+		Outbox: OutboxConfig{
+			Enabled:        getEnvBool("OUTBOX_ENABLED", true),
+			PollInterval:   getEnvDuration("OUTBOX_POLL_INTERVAL", 2*time.Second),
+			BatchSize:      getEnvInt("OUTBOX_BATCH_SIZE", 50),
+			MaxAttempts:    getEnvInt("OUTBOX_MAX_ATTEMPTS", 8),
+			RetryBaseDelay: getEnvDuration("OUTBOX_RETRY_BASE_DELAY", 5*time.Second),
+			RetryMaxDelay:  getEnvDuration("OUTBOX_RETRY_MAX_DELAY", 10*time.Minute),
+			Lease:          getEnvDuration("OUTBOX_LEASE", 1*time.Minute),
+			Retention:      getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
 		},
+		Cache: CacheConfig{
+			Enabled: getEnvBool("IAM_CACHE_ENABLED", true),
+			TTL:     getEnvDuration("IAM_CACHE_TTL", 30*time.Second),
+		},
+		NormalizeScopes: getEnvBool("IAM_NORMALIZE_SCOPES", false),
 		PasswordReset: PasswordResetConfig{
 			TokenByteLength:      getEnvInt("PASSWORD_RESET_TOKEN_BYTE_LENGTH", 32),
 			ExpirationTime:       getEnvDuration("PASSWORD_RESET_EXPIRATION_TIME", 1*time.Hour),
 			RateLimitWindow:      getEnvDuration("PASSWORD_RESET_RATE_LIMIT_WINDOW", 15*time.Minute),
 			MaxAttemptsPerWindow: getEnvInt("PASSWORD_RESET_MAX_ATTEMPTS", 3),
+			ResetURL:             getEnv("PASSWORD_RESET_URL", "http://localhost:5173/reset-password"),
 		},
 		Cookie: CookieConfig{
 			AccessTokenName:  getEnv("COOKIE_ACCESS_TOKEN_NAME", "access_token"),
@@ -115,7 +247,32 @@ func loadAuthConfig() AuthConfig {
 			SameSite:         getEnv("COOKIE_SAME_SITE", "Lax"),
 		},
 		Password: PasswordConfig{
-			BcryptCost: getEnvInt("BCRYPT_COST", 10),
+			BcryptCost:       getEnvInt("BCRYPT_COST", 10),
+			MinLength:        getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			RequireUppercase: getEnvBool("PASSWORD_REQUIRE_UPPERCASE", false),
+			RequireLowercase: getEnvBool("PASSWORD_REQUIRE_LOWERCASE", false),
+			RequireDigit:     getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:    getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:  getEnvBool("RATE_LIMIT_ENABLED", true),
+			FailOpen: getEnvBool("RATE_LIMIT_FAIL_OPEN", true),
+			Login: RateLimitRule{
+				Requests: getEnvInt("RATE_LIMIT_LOGIN_REQUESTS", 10),
+				Window:   getEnvDuration("RATE_LIMIT_LOGIN_WINDOW", 1*time.Minute),
+			},
+			OTP: RateLimitRule{
+				Requests: getEnvInt("RATE_LIMIT_OTP_REQUESTS", 5),
+				Window:   getEnvDuration("RATE_LIMIT_OTP_WINDOW", 1*time.Minute),
+			},
+			Default: RateLimitRule{
+				Requests: getEnvInt("RATE_LIMIT_DEFAULT_REQUESTS", 60),
+				Window:   getEnvDuration("RATE_LIMIT_DEFAULT_WINDOW", 1*time.Minute),
+			},
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled: getEnvBool("IDEMPOTENCY_ENABLED", true),
+			TTL:     getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
 		},
 	}
 }
@@ -126,6 +283,41 @@ type TenantConfig struct {
 	MaxUsersBasic        int
 	MaxUsersProfessional int
 	MaxUsersEnterprise   int
+	MaxCustomRoles       int
+
+	// Concurrent agent runs per tenant, by plan (0 = unlimited)
+	MaxAgentRunsTrial        int
+	MaxAgentRunsBasic        int
+	MaxAgentRunsProfessional int
+	MaxAgentRunsEnterprise   int
+	AgentRunLeaseTTL         time.Duration // Redis lease lifetime of a run slot
+	AgentRunWaitTimeout      time.Duration // Queue time before rejecting (0 = reject immediately)
+
+	// Monthly LLM token quota per tenant, by plan (0 = unlimited). Tenants can
+	// override it with the "quota.llm_tokens_monthly" setting.
+	LLMTokensMonthlyTrial        int64
+	LLMTokensMonthlyBasic        int64
+	LLMTokensMonthlyProfessional int64
+	LLMTokensMonthlyEnterprise   int64
+
+	// Model aliases per tenant, by plan ("chat-default=gpt-4.1-mini,...").
+	// Aliases a plan leaves out use LLM_MODEL_ALIASES; tenants can override
+	// them with "model.<alias>" settings.
+	ModelAliasesTrial        map[string]string
+	ModelAliasesBasic        map[string]string
+	ModelAliasesProfessional map[string]string
+	ModelAliasesEnterprise   map[string]string
+
+	// Subscription enforcement: lapsed tenants are rejected after authentication
+	// except under the SubscriptionAllowedPaths prefixes (billing / renewal
+	// routes), and the cleanup job moves them to EXPIRED when ExpireLapsed is set
+	SubscriptionCacheTTL     time.Duration
+	SubscriptionAllowedPaths []string
+	ExpireLapsed             bool
+
+	// DomainDiscovery lets tenants claim verified email domains so that
+	// passwordless tenant lookup suggests them to new addresses of the domain
+	DomainDiscovery bool
 }
 
 func loadTenantConfig() TenantConfig {
@@ -135,5 +327,30 @@ func loadTenantConfig() TenantConfig {
 		MaxUsersBasic:        getEnvInt("TENANT_MAX_USERS_BASIC", 5),
 		MaxUsersProfessional: getEnvInt("TENANT_MAX_USERS_PROFESSIONAL", 50),
 		MaxUsersEnterprise:   getEnvInt("TENANT_MAX_USERS_ENTERPRISE", 500),
+		MaxCustomRoles:       getEnvInt("TENANT_MAX_CUSTOM_ROLES", 50),
+
+		MaxAgentRunsTrial:        getEnvInt("TENANT_MAX_AGENT_RUNS_TRIAL", 1),
+		MaxAgentRunsBasic:        getEnvInt("TENANT_MAX_AGENT_RUNS_BASIC", 2),
+		MaxAgentRunsProfessional: getEnvInt("TENANT_MAX_AGENT_RUNS_PROFESSIONAL", 10),
+		MaxAgentRunsEnterprise:   getEnvInt("TENANT_MAX_AGENT_RUNS_ENTERPRISE", 50),
+		AgentRunLeaseTTL:         getEnvDuration("TENANT_AGENT_RUN_LEASE_TTL", time.Minute),
+		AgentRunWaitTimeout:      getEnvDuration("TENANT_AGENT_RUN_WAIT_TIMEOUT", 0),
+		DomainDiscovery:          getEnvBool("TENANT_DOMAIN_DISCOVERY", false),
+
+		LLMTokensMonthlyTrial:        int64(getEnvInt("TENANT_LLM_TOKENS_MONTHLY_TRIAL", 100_000)),
+		LLMTokensMonthlyBasic:        int64(getEnvInt("TENANT_LLM_TOKENS_MONTHLY_BASIC", 1_000_000)),
+		LLMTokensMonthlyProfessional: int64(getEnvInt("TENANT_LLM_TOKENS_MONTHLY_PROFESSIONAL", 10_000_000)),
+		LLMTokensMonthlyEnterprise:   int64(getEnvInt("TENANT_LLM_TOKENS_MONTHLY_ENTERPRISE", 0)),
+
+		ModelAliasesTrial:        getEnvStringMap("TENANT_MODEL_ALIASES_TRIAL", nil),
+		ModelAliasesBasic:        getEnvStringMap("TENANT_MODEL_ALIASES_BASIC", nil),
+		ModelAliasesProfessional: getEnvStringMap("TENANT_MODEL_ALIASES_PROFESSIONAL", nil),
+		ModelAliasesEnterprise:   getEnvStringMap("TENANT_MODEL_ALIASES_ENTERPRISE", nil),
+
+		SubscriptionCacheTTL: getEnvDuration("TENANT_SUBSCRIPTION_CACHE_TTL", time.Minute),
+		SubscriptionAllowedPaths: getEnvStringSlice("TENANT_SUBSCRIPTION_ALLOWED_PATHS", []string{
+			"/billing", "/auth/me", "/auth/logout", "/auth/refresh",
+		}),
+		ExpireLapsed: getEnvBool("TENANT_EXPIRE_LAPSED", true),
 	}
 }
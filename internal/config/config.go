@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
 )
 
 type Config struct {
@@ -16,6 +18,12 @@ type Config struct {
 	Auth         AuthConfig
 	OAuth        OAuthConfig
 	TenantConfig TenantConfig
+	Email        EmailConfig
+	SMS          SMSConfig
+	Storage      StorageConfig
+	Tracing      TracingConfig
+	Webhook      WebhookConfig
+	AI           AIConfig
 }
 
 type Environment string
@@ -57,6 +65,12 @@ func Load() (*Config, error) {
 		Auth:         loadAuthConfig(),
 		OAuth:        loadOAuthConfig(),
 		TenantConfig: loadTenantConfig(),
+		Email:        loadEmailConfig(),
+		SMS:          loadSMSConfig(),
+		Storage:      loadStorageConfig(),
+		Tracing:      loadTracingConfig(),
+		Webhook:      loadWebhookConfig(),
+		AI:           loadAIConfig(),
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -67,6 +81,24 @@ func Load() (*Config, error) {
 }
 
 func (c *Config) Validate() error {
+	if err := c.Server.validateCORS(); err != nil {
+		return err
+	}
+	if charset := otp.CodeCharset(c.Auth.OTP.Charset); charset != "" && !charset.IsValid() {
+		return fmt.Errorf("OTP_CHARSET must be %q or %q, got %q", otp.CodeCharsetNumeric, otp.CodeCharsetAlphanumeric, charset)
+	}
+	if c.Auth.OTP.CodeLength <= 0 || c.Auth.OTP.CodeLength > otp.MaxCodeLength {
+		return fmt.Errorf("OTP_CODE_LENGTH must be between 1 and %d, got %d", otp.MaxCodeLength, c.Auth.OTP.CodeLength)
+	}
+	if c.Server.AuthBodyLimit <= 0 || c.Server.JSONBodyLimit <= 0 || c.Server.UploadBodyLimit <= 0 {
+		return fmt.Errorf("SERVER_AUTH_BODY_LIMIT, SERVER_JSON_BODY_LIMIT and SERVER_UPLOAD_BODY_LIMIT must be positive")
+	}
+	if c.Server.ProxyHeader != "" && len(c.Server.TrustedProxies) == 0 {
+		return fmt.Errorf("SERVER_PROXY_HEADER requires SERVER_TRUSTED_PROXIES, otherwise clients can spoof their IP")
+	}
+	if c.Storage.UploadMaxSize <= 0 || c.Storage.UploadMaxSize >= int64(c.Server.UploadBodyLimit) {
+		return fmt.Errorf("STORAGE_UPLOAD_MAX_SIZE must be positive and below SERVER_UPLOAD_BODY_LIMIT")
+	}
 	return nil
 }
 
@@ -100,6 +132,15 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -115,3 +156,24 @@ func getEnvStringSlice(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// getEnvStringMap parses "key1=value1,key2=value2"
+func getEnvStringMap(key string, defaultValue map[string]string) map[string]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OriginPattern is an allowed CORS origin: either an exact origin
+// ("https://app.example.com") or a wildcard subdomain origin
+// ("https://*.example.com"), which matches any subdomain at any depth but not
+// the bare domain. Scheme and port must match exactly.
+type OriginPattern struct {
+	scheme   string
+	host     string // without the "*." prefix for wildcard patterns
+	port     string
+	wildcard bool
+}
+
+// ParseOriginPattern parses a CORS_ORIGINS entry
+func ParseOriginPattern(pattern string) (OriginPattern, error) {
+	raw := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), "/")
+
+	wildcard := false
+	if scheme, rest, ok := strings.Cut(raw, "://*."); ok {
+		wildcard = true
+		raw = scheme + "://" + rest
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Hostname() == "" {
+		return OriginPattern{}, fmt.Errorf("invalid CORS origin %q: expected scheme://host[:port]", pattern)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return OriginPattern{}, fmt.Errorf("invalid CORS origin %q: an origin has no path, query or credentials", pattern)
+	}
+	if strings.Contains(u.Host, "*") {
+		return OriginPattern{}, fmt.Errorf("invalid CORS origin %q: only a leading \"*.\" wildcard is supported", pattern)
+	}
+
+	return OriginPattern{
+		scheme:   u.Scheme,
+		host:     u.Hostname(),
+		port:     u.Port(),
+		wildcard: wildcard,
+	}, nil
+}
+
+// Match reports whether the request Origin header matches the pattern
+func (p OriginPattern) Match(origin string) bool {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme != p.scheme || u.Port() != p.port {
+		return false
+	}
+
+	host := u.Hostname()
+	if !p.wildcard {
+		return host == p.host
+	}
+	sub, ok := strings.CutSuffix(host, "."+p.host)
+	return ok && sub != ""
+}
+
+// CORSOriginMatcher returns a matcher for the configured origins, used to
+// echo the request Origin only when it is allowed
+func (s ServerConfig) CORSOriginMatcher() func(origin string) bool {
+	patterns := make([]OriginPattern, 0, len(s.CORSOrigins))
+	for _, origin := range s.CORSOrigins {
+		if pattern, err := ParseOriginPattern(origin); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return func(origin string) bool {
+		for _, pattern := range patterns {
+			if pattern.Match(origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// CORSAllowsAnyOrigin reports whether CORS_ORIGINS is the "*" wildcard
+func (s ServerConfig) CORSAllowsAnyOrigin() bool {
+	for _, origin := range s.CORSOrigins {
+		if strings.TrimSpace(origin) == "*" {
+			return true
+		}
+	}
+	return len(s.CORSOrigins) == 0
+}
+
+// validateCORS rejects "*" together with credentials (browsers refuse
+// credentialed responses with Access-Control-Allow-Origin: *) and malformed
+// origins
+func (s ServerConfig) validateCORS() error {
+	if s.CORSAllowsAnyOrigin() {
+		if s.CORSAllowCredentials {
+			return fmt.Errorf("CORS_ORIGINS must list explicit origins when CORS_ALLOW_CREDENTIALS is true")
+		}
+		return nil
+	}
+
+	for _, origin := range s.CORSOrigins {
+		if _, err := ParseOriginPattern(origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import "testing"
+
+func TestOriginPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com/", "https://APP.example.com", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"https://*.example.com", "https://a.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"https://*.example.com", "https://a.example.com.evil.io", false},
+		{"http://localhost:3000", "http://localhost:3000", true},
+		{"http://localhost:3000", "http://localhost:5173", false},
+	}
+
+	for _, tt := range tests {
+		pattern, err := ParseOriginPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("ParseOriginPattern(%q): %v", tt.pattern, err)
+		}
+		if got := pattern.Match(tt.origin); got != tt.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  ServerConfig
+		wantErr bool
+	}{
+		{"wildcard with credentials", ServerConfig{CORSOrigins: []string{"*"}, CORSAllowCredentials: true}, true},
+		{"wildcard without credentials", ServerConfig{CORSOrigins: []string{"*"}}, false},
+		{"explicit origins", ServerConfig{CORSOrigins: []string{"https://*.example.com", "http://localhost:3000"}, CORSAllowCredentials: true}, false},
+		{"missing scheme", ServerConfig{CORSOrigins: []string{"*.example.com"}, CORSAllowCredentials: true}, true},
+		{"inner wildcard", ServerConfig{CORSOrigins: []string{"https://app.*.example.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.server.validateCORS(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateCORS() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
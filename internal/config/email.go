@@ -5,6 +5,7 @@ type EmailConfig struct {
 	Provider       string
 	FromAddress    string
 	FromName       string
+	ReplyTo        string
 	SMTPHost       string
 	SMTPPort       int
 	SMTPUsername   string
@@ -14,11 +15,12 @@ type EmailConfig struct {
 }
 
 type SMSConfig struct {
-	Provider         string
-	TwilioAccountSID string
-	TwilioAuthToken  string
-	TwilioFromNumber string
-	AWSRegion        string
+	Provider                  string
+	TwilioAccountSID          string
+	TwilioAuthToken           string
+	TwilioFromNumber          string
+	TwilioMessagingServiceSID string // Takes precedence over TwilioFromNumber when set
+	AWSRegion                 string
 }
 
 func loadEmailConfig() EmailConfig {
@@ -26,6 +28,7 @@ func loadEmailConfig() EmailConfig {
 		Provider:       getEnv("EMAIL_PROVIDER", "smtp"),
 		FromAddress:    getEnv("EMAIL_FROM_ADDRESS", "noreply@manifesto.com"),
 		FromName:       getEnv("EMAIL_FROM_NAME", "Manifesto"),
+		ReplyTo:        getEnv("EMAIL_REPLY_TO", ""),
 		SMTPHost:       getEnv("SMTP_HOST", ""),
 		SMTPPort:       getEnvInt("SMTP_PORT", 587),
 		SMTPUsername:   getEnv("SMTP_USERNAME", ""),
@@ -37,10 +40,11 @@ func loadEmailConfig() EmailConfig {
 
 func loadSMSConfig() SMSConfig {
 	return SMSConfig{
-		Provider:         getEnv("SMS_PROVIDER", "twilio"),
-		TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
-		TwilioAuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
-		TwilioFromNumber: getEnv("TWILIO_FROM_NUMBER", ""),
-		AWSRegion:        getEnv("AWS_REGION", "us-east-1"),
+		Provider:                  getEnv("SMS_PROVIDER", "twilio"),
+		TwilioAccountSID:          getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:           getEnv("TWILIO_AUTH_TOKEN", ""),
+		TwilioFromNumber:          getEnv("TWILIO_FROM_NUMBER", ""),
+		TwilioMessagingServiceSID: getEnv("TWILIO_MESSAGING_SERVICE_SID", ""),
+		AWSRegion:                 getEnv("AWS_REGION", "us-east-1"),
 	}
 }
@@ -1,11 +1,24 @@
 package config
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type OAuthConfig struct {
 	Google       OAuthProviderConfig
 	Microsoft    OAuthProviderConfig
 	StateManager StateManagerConfig
+	GroupSync    GroupSyncConfig
+	OIDC         []OIDCProviderConfig // Generic OpenID Connect providers (Okta, Keycloak, ...)
+
+	// SSOSecretKey encrypts the client secrets of tenant SSO connections at
+	// rest. Changing it makes the stored secrets unreadable.
+	SSOSecretKey string
+
+	// ProfilePhotoBaseURL is the public URL of the storage where IdP profile
+	// photos are saved; without it photos are inlined as data URLs
+	ProfilePhotoBaseURL string
 }
 
 type OAuthProviderConfig struct {
@@ -17,7 +30,29 @@ type OAuthProviderConfig struct {
 	AuthURL      string
 	TokenURL     string
 	UserInfoURL  string
-	Timeout      time.Duration
+	FetchGroups  bool
+	FetchPhoto   bool // Microsoft: fetch the Graph profile photo on login (adds a request)
+
+	// Timeout bounds connecting to the IdP (dial and TLS handshake).
+	// OperationTimeout bounds each token exchange or user info lookup end to
+	// end; an earlier deadline on the request context still wins.
+	Timeout          time.Duration
+	OperationTimeout time.Duration
+}
+
+// OIDCProviderConfig configures a generic OpenID Connect provider. Endpoints
+// are discovered from {IssuerURL}/.well-known/openid-configuration.
+type OIDCProviderConfig struct {
+	Key          string // Provider key, e.g. "OKTA" (POST /auth/login provider, /auth/callback/okta)
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string // id_token claim holding the user's groups; empty disables group sync
+
+	Timeout          time.Duration // Connection timeout (dial and TLS handshake)
+	OperationTimeout time.Duration // Timeout of each call to the issuer
 }
 
 type StateManagerConfig struct {
@@ -25,33 +60,78 @@ type StateManagerConfig struct {
 	TTL  time.Duration
 }
 
+// GroupSyncConfig maps IdP group claims to scope templates. When enabled the
+// IdP is authoritative for every scope covered by a mapped template: scopes
+// are re-synced on each OAuth login. Enabled is the default for tenants without
+// an "oauth_group_sync" config setting.
+type GroupSyncConfig struct {
+	Enabled  bool
+	Mappings map[string]string // IdP group -> scope template
+}
+
 func loadOAuthConfig() OAuthConfig {
 	return OAuthConfig{
 		Google: OAuthProviderConfig{
-			Enabled:      getEnvBool("OAUTH_GOOGLE_ENABLED", false),
-			ClientID:     getEnv("OAUTH_GOOGLE_CLIENT_ID", ""),
-			ClientSecret: getEnv("OAUTH_GOOGLE_CLIENT_SECRET", ""),
-			RedirectURL:  getEnv("OAUTH_GOOGLE_REDIRECT_URL", ""),
-			Scopes:       getEnvStringSlice("OAUTH_GOOGLE_SCOPES", []string{"openid", "email", "profile"}),
-			AuthURL:      getEnv("OAUTH_GOOGLE_AUTH_URL", "https://accounts.google.com/o/oauth2/auth"),
-			TokenURL:     getEnv("OAUTH_GOOGLE_TOKEN_URL", "https://oauth2.googleapis.com/token"),
-			UserInfoURL:  getEnv("OAUTH_GOOGLE_USER_INFO_URL", "https://www.googleapis.com/oauth2/v2/userinfo"),
-			Timeout:      getEnvDuration("OAUTH_GOOGLE_TIMEOUT", 30*time.Second),
+			Enabled:          getEnvBool("OAUTH_GOOGLE_ENABLED", false),
+			ClientID:         getEnv("OAUTH_GOOGLE_CLIENT_ID", ""),
+			ClientSecret:     getEnv("OAUTH_GOOGLE_CLIENT_SECRET", ""),
+			RedirectURL:      getEnv("OAUTH_GOOGLE_REDIRECT_URL", ""),
+			Scopes:           getEnvStringSlice("OAUTH_GOOGLE_SCOPES", []string{"openid", "email", "profile"}),
+			AuthURL:          getEnv("OAUTH_GOOGLE_AUTH_URL", "https://accounts.google.com/o/oauth2/auth"),
+			TokenURL:         getEnv("OAUTH_GOOGLE_TOKEN_URL", "https://oauth2.googleapis.com/token"),
+			UserInfoURL:      getEnv("OAUTH_GOOGLE_USER_INFO_URL", "https://www.googleapis.com/oauth2/v2/userinfo"),
+			Timeout:          getEnvDuration("OAUTH_GOOGLE_TIMEOUT", 10*time.Second),
+			OperationTimeout: getEnvDuration("OAUTH_GOOGLE_OPERATION_TIMEOUT", 15*time.Second),
 		},
 		Microsoft: OAuthProviderConfig{
-			Enabled:      getEnvBool("OAUTH_MICROSOFT_ENABLED", false),
-			ClientID:     getEnv("OAUTH_MICROSOFT_CLIENT_ID", ""),
-			ClientSecret: getEnv("OAUTH_MICROSOFT_CLIENT_SECRET", ""),
-			RedirectURL:  getEnv("OAUTH_MICROSOFT_REDIRECT_URL", ""),
-			Scopes:       getEnvStringSlice("OAUTH_MICROSOFT_SCOPES", []string{"openid", "email", "profile", "User.Read"}),
-			AuthURL:      getEnv("OAUTH_MICROSOFT_AUTH_URL", "https://login.microsoftonline.com/common/oauth2/v2.0/authorize"),
-			TokenURL:     getEnv("OAUTH_MICROSOFT_TOKEN_URL", "https://login.microsoftonline.com/common/oauth2/v2.0/token"),
-			UserInfoURL:  getEnv("OAUTH_MICROSOFT_USER_INFO_URL", "https://graph.microsoft.com/v1.0/me"),
-			Timeout:      getEnvDuration("OAUTH_MICROSOFT_TIMEOUT", 30*time.Second),
+			Enabled:          getEnvBool("OAUTH_MICROSOFT_ENABLED", false),
+			ClientID:         getEnv("OAUTH_MICROSOFT_CLIENT_ID", ""),
+			ClientSecret:     getEnv("OAUTH_MICROSOFT_CLIENT_SECRET", ""),
+			RedirectURL:      getEnv("OAUTH_MICROSOFT_REDIRECT_URL", ""),
+			Scopes:           getEnvStringSlice("OAUTH_MICROSOFT_SCOPES", []string{"openid", "email", "profile", "User.Read"}),
+			AuthURL:          getEnv("OAUTH_MICROSOFT_AUTH_URL", "https://login.microsoftonline.com/common/oauth2/v2.0/authorize"),
+			TokenURL:         getEnv("OAUTH_MICROSOFT_TOKEN_URL", "https://login.microsoftonline.com/common/oauth2/v2.0/token"),
+			UserInfoURL:      getEnv("OAUTH_MICROSOFT_USER_INFO_URL", "https://graph.microsoft.com/v1.0/me"),
+			Timeout:          getEnvDuration("OAUTH_MICROSOFT_TIMEOUT", 10*time.Second),
+			OperationTimeout: getEnvDuration("OAUTH_MICROSOFT_OPERATION_TIMEOUT", 15*time.Second),
+			FetchGroups:      getEnvBool("OAUTH_MICROSOFT_FETCH_GROUPS", false),
+			FetchPhoto:       getEnvBool("OAUTH_MICROSOFT_FETCH_PHOTO", false),
 		},
 		StateManager: StateManagerConfig{
 			Type: getEnv("OAUTH_STATE_MANAGER_TYPE", "redis"),
 			TTL:  getEnvDuration("OAUTH_STATE_TTL", 10*time.Minute),
 		},
+		GroupSync: GroupSyncConfig{
+			Enabled:  getEnvBool("OAUTH_GROUP_SYNC_ENABLED", false),
+			Mappings: getEnvStringMap("OAUTH_GROUP_SCOPE_MAPPINGS", map[string]string{}),
+		},
+		OIDC:                loadOIDCProviderConfigs(),
+		SSOSecretKey:        getEnv("OAUTH_SSO_SECRET_KEY", ""),
+		ProfilePhotoBaseURL: getEnv("OAUTH_PROFILE_PHOTO_BASE_URL", ""),
+	}
+}
+
+// loadOIDCProviderConfigs reads OAUTH_OIDC_PROVIDERS (e.g. "okta,keycloak")
+// and, for each key, the OAUTH_OIDC_<KEY>_* variables
+func loadOIDCProviderConfigs() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, key := range getEnvStringSlice("OAUTH_OIDC_PROVIDERS", nil) {
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		prefix := "OAUTH_OIDC_" + key + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Key:              key,
+			IssuerURL:        getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:         getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret:     getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:      getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:           getEnvStringSlice(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+			GroupsClaim:      getEnv(prefix+"GROUPS_CLAIM", ""),
+			Timeout:          getEnvDuration(prefix+"TIMEOUT", 10*time.Second),
+			OperationTimeout: getEnvDuration(prefix+"OPERATION_TIMEOUT", 15*time.Second),
+		})
 	}
+	return providers
 }
@@ -1,11 +1,54 @@
 package config
 
+import "time"
+
 type ServerConfig struct {
 	Port        int
 	Environment string
 	LogLevel    string
 	BaseURL     string
 	CORSOrigins []string
+
+	// CORSAllowCredentials lets browsers send cookies and Authorization on
+	// cross-origin requests. Requires explicit CORS_ORIGINS (no "*"); entries
+	// may use wildcard subdomains, e.g. https://*.example.com.
+	CORSAllowCredentials bool
+
+	// LogHTTPBodies adds the (redacted) request and response bodies to the
+	// request log. Meant for debugging; bodies can be large.
+	LogHTTPBodies bool
+
+	// ShutdownTimeout bounds graceful shutdown: draining HTTP requests and
+	// then waiting for background workers, each up to this long
+	ShutdownTimeout time.Duration
+
+	// ShutdownDrainDelay is how long the server keeps serving after a
+	// shutdown signal with /readyz failing, so load balancers stop routing
+	// to it before listeners close. Set it above the readiness probe period.
+	ShutdownDrainDelay time.Duration
+
+	// Request body limits in bytes per route group: AuthBodyLimit for login,
+	// OTP and password endpoints, JSONBodyLimit for the JSON API and
+	// UploadBodyLimit for multipart uploads. Fiber's own limit is the largest
+	// of the three, see MaxBodyLimit.
+	AuthBodyLimit   int
+	JSONBodyLimit   int
+	UploadBodyLimit int
+
+	// ProxyHeader is the header carrying the client IP when the server runs
+	// behind a load balancer, e.g. X-Real-IP. It is only honoured for
+	// requests coming from TrustedProxies, so clients cannot spoof it; empty
+	// uses the connection's remote address. For X-Forwarded-For the first
+	// address is used, so the load balancer must overwrite the header rather
+	// than append to it.
+	ProxyHeader    string
+	TrustedProxies []string // IPs or CIDR ranges of the load balancers
+}
+
+// MaxBodyLimit is the largest per-group body limit, used as Fiber's own
+// limit so no group is cut short by it
+func (s ServerConfig) MaxBodyLimit() int {
+	return max(s.AuthBodyLimit, s.JSONBodyLimit, s.UploadBodyLimit)
 }
 
 func loadServerConfig() ServerConfig {
@@ -15,5 +58,18 @@ func loadServerConfig() ServerConfig {
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
 		BaseURL:     getEnv("BASE_URL", "http://localhost:8080"),
 		CORSOrigins: getEnvStringSlice("CORS_ORIGINS", []string{"http://localhost:3000"}),
+
+		CORSAllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
+
+		LogHTTPBodies:      getEnvBool("LOG_HTTP_BODIES", false),
+		ShutdownTimeout:    getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		ShutdownDrainDelay: getEnvDuration("SERVER_SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+
+		AuthBodyLimit:   getEnvInt("SERVER_AUTH_BODY_LIMIT", 64*1024),
+		JSONBodyLimit:   getEnvInt("SERVER_JSON_BODY_LIMIT", 1024*1024),
+		UploadBodyLimit: getEnvInt("SERVER_UPLOAD_BODY_LIMIT", 10*1024*1024),
+
+		ProxyHeader:    getEnv("SERVER_PROXY_HEADER", ""),
+		TrustedProxies: getEnvStringSlice("SERVER_TRUSTED_PROXIES", nil),
 	}
 }
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+// StorageConfig configures presigned URLs and direct uploads for file storage
+type StorageConfig struct {
+	PresignExpiration    time.Duration // Used when the caller does not ask for one
+	PresignMaxExpiration time.Duration // Upper bound for caller-requested expirations
+
+	// UploadMaxSize is the largest file accepted by the upload endpoint, in
+	// bytes. It must stay below SERVER_UPLOAD_BODY_LIMIT, which also has to
+	// fit the multipart framing.
+	UploadMaxSize int64
+	// UploadAllowedTypes lists the media types the upload endpoint accepts
+	UploadAllowedTypes []string
+}
+
+func loadStorageConfig() StorageConfig {
+	return StorageConfig{
+		PresignExpiration:    getEnvDuration("STORAGE_PRESIGN_EXPIRATION", 15*time.Minute),
+		PresignMaxExpiration: getEnvDuration("STORAGE_PRESIGN_MAX_EXPIRATION", time.Hour),
+
+		UploadMaxSize: int64(getEnvInt("STORAGE_UPLOAD_MAX_SIZE", 8*1024*1024)),
+		UploadAllowedTypes: getEnvStringSlice("STORAGE_UPLOAD_ALLOWED_TYPES", []string{
+			"application/pdf",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"image/jpeg",
+			"image/png",
+			"text/plain",
+		}),
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+// TracingConfig configures OpenTelemetry tracing. When disabled a no-op tracer
+// is used, so instrumented code has no measurable overhead.
+type TracingConfig struct {
+	Enabled     bool
+	ServiceName string
+	// Endpoint is the OTLP/HTTP collector endpoint (host:port)
+	Endpoint string
+	Insecure bool
+	// SampleRatio is the fraction of new traces sampled (0..1); sampled
+	// parents are always followed
+	SampleRatio float64
+}
+
+func loadTracingConfig() TracingConfig {
+	return TracingConfig{
+		Enabled:     getEnvBool("TRACING_ENABLED", false),
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "manifesto-api"),
+		Endpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318"),
+		Insecure:    getEnvBool("OTEL_EXPORTER_OTLP_INSECURE", true),
+		SampleRatio: getEnvFloat("TRACING_SAMPLE_RATIO", 1.0),
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+// WebhookConfig configures the delivery of IAM lifecycle events to tenant
+// webhook endpoints. Deliveries go through the outbox, so retries follow
+// OUTBOX_MAX_ATTEMPTS and its backoff; webhooks stay off while the outbox is
+// disabled.
+type WebhookConfig struct {
+	Enabled bool
+	// Timeout bounds each delivery request
+	Timeout time.Duration
+	// MaxEndpointsPerTenant caps the endpoints a tenant can register
+	MaxEndpointsPerTenant int
+	// AllowPrivateNetworks lets endpoints resolve to loopback and private
+	// addresses, and use plain http. Development only: otherwise a tenant
+	// could make the server call internal services.
+	AllowPrivateNetworks bool
+	// SecretKey encrypts the endpoints' signing secrets at rest. Webhooks
+	// stay off without it.
+	SecretKey string
+}
+
+func loadWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Enabled:               getEnvBool("WEBHOOKS_ENABLED", true),
+		Timeout:               getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		MaxEndpointsPerTenant: getEnvInt("WEBHOOK_MAX_ENDPOINTS_PER_TENANT", 10),
+		AllowPrivateNetworks:  getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		SecretKey:             getEnv("WEBHOOK_SECRET_KEY", ""),
+	}
+}
@@ -0,0 +1,107 @@
+// Package dbx provides a unit of work over sqlx: WithTx runs a function in a
+// database transaction carried by the context, and Postgres repositories pick
+// it up through Executor (or their own getExecutor) so several repository
+// calls commit or roll back together. AfterCommit defers side effects, like
+// cache invalidation, until the transaction commits.
+package dbx
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/jmoiron/sqlx"
+)
+
+// txContextKey is the context key repositories look up for the active
+// transaction
+const txContextKey = "db_tx"
+
+// afterCommitContextKey holds the hooks registered with AfterCommit
+const afterCommitContextKey = "db_tx_after_commit"
+
+// afterCommitHooks are run, in order, once the transaction commits
+type afterCommitHooks struct {
+	fns []func(ctx context.Context)
+}
+
+// Transactor runs functions inside a database transaction
+type Transactor interface {
+	// WithinTx runs fn in a transaction: it commits when fn returns nil and
+	// rolls back otherwise. Repositories called with the ctx passed to fn
+	// take part in it. If ctx already carries a transaction, fn joins it.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// TxFromContext returns the transaction carried by ctx, if any
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey).(*sqlx.Tx)
+	return tx, ok
+}
+
+// Executor returns the transaction carried by ctx, or db when there is none
+func Executor(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// AfterCommit runs fn once the transaction carried by ctx commits, or right
+// away when ctx carries none. Hooks are dropped if the transaction rolls back,
+// so they fit side effects that must only see committed data, like dropping
+// a cache entry.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	hooks, ok := ctx.Value(afterCommitContextKey).(*afterCommitHooks)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	hooks.fns = append(hooks.fns, fn)
+}
+
+// WithTx runs fn in a new transaction on db, or in the one ctx already
+// carries. A nested call never commits: the outermost WithTx does.
+func WithTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errx.Wrap(err, "failed to begin transaction", errx.TypeInternal)
+	}
+	defer tx.Rollback()
+
+	hooks := &afterCommitHooks{}
+	txCtx := context.WithValue(context.WithValue(ctx, txContextKey, tx), afterCommitContextKey, hooks)
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errx.Wrap(err, "failed to commit transaction", errx.TypeInternal)
+	}
+
+	for _, hook := range hooks.fns {
+		hook(ctx)
+	}
+	return nil
+}
+
+// SQLTransactor implements Transactor with WithTx on a sqlx database
+type SQLTransactor struct {
+	db *sqlx.DB
+}
+
+// NewTransactor creates a Transactor backed by db
+func NewTransactor(db *sqlx.DB) *SQLTransactor {
+	return &SQLTransactor{db: db}
+}
+
+// WithinTx runs fn in a transaction, or in the one ctx already carries
+func (t *SQLTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithTx(ctx, t.db, fn)
+}
+
+// Compile-time check that SQLTransactor implements Transactor
+var _ Transactor = (*SQLTransactor)(nil)
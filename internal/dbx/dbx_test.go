@@ -0,0 +1,103 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/testx"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestExecutorPrefersContextTransaction(t *testing.T) {
+	db := &sqlx.DB{}
+	tx := &sqlx.Tx{}
+
+	if got := Executor(context.Background(), db); got != db {
+		t.Errorf("Executor without tx = %v, want db", got)
+	}
+
+	ctx := context.WithValue(context.Background(), txContextKey, tx)
+	if got := Executor(ctx, db); got != tx {
+		t.Errorf("Executor with tx = %v, want tx", got)
+	}
+}
+
+func TestWithTxJoinsExistingTransaction(t *testing.T) {
+	tx := &sqlx.Tx{}
+	ctx := context.WithValue(context.Background(), txContextKey, tx)
+	wantErr := errors.New("boom")
+
+	// A nil db proves the nested call never begins a transaction of its own
+	err := WithTx(ctx, nil, func(ctx context.Context) error {
+		if got, ok := TxFromContext(ctx); !ok || got != tx {
+			t.Error("nested fn did not receive the outer transaction")
+		}
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("WithTx error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestAfterCommitWithoutTransactionRunsNow(t *testing.T) {
+	ran := false
+	AfterCommit(context.Background(), func(context.Context) { ran = true })
+	if !ran {
+		t.Error("hook without transaction did not run")
+	}
+}
+
+func TestAfterCommitRunsOnlyOnCommit(t *testing.T) {
+	db := testx.Postgres(t)
+	ctx := context.Background()
+
+	var ran []string
+	err := WithTx(ctx, db, func(ctx context.Context) error {
+		AfterCommit(ctx, func(context.Context) { ran = append(ran, "outer") })
+		// A nested WithTx joins the transaction and its hooks wait for it too
+		return WithTx(ctx, db, func(ctx context.Context) error {
+			AfterCommit(ctx, func(context.Context) { ran = append(ran, "nested") })
+			if len(ran) != 0 {
+				t.Error("hook ran before the commit")
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 2 || ran[0] != "outer" || ran[1] != "nested" {
+		t.Errorf("hooks ran = %v, want [outer nested]", ran)
+	}
+
+	ran = nil
+	wantErr := errors.New("boom")
+	err = WithTx(ctx, db, func(ctx context.Context) error {
+		AfterCommit(ctx, func(context.Context) { ran = append(ran, "rolled back") })
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) || len(ran) != 0 {
+		t.Errorf("WithTx = %v, hooks ran = %v; want the error and no hooks", err, ran)
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	keyset := Keyset{CreatedAtColumn: "created_at", IDColumn: "id"}
+	args := []any{"tenant-1"}
+
+	if clause, got := keyset.After(nil, args); clause != "" || len(got) != 1 {
+		t.Errorf("After(nil) = %q, %v; want no condition", clause, got)
+	}
+
+	cursor := &kernel.Cursor{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), ID: "u1"}
+	clause, got := keyset.After(cursor, args)
+	if clause != "(created_at, id) < ($2, $3)" {
+		t.Errorf("clause = %q", clause)
+	}
+	if len(got) != 3 || got[1] != cursor.CreatedAt || got[2] != "u1" {
+		t.Errorf("args = %v", got)
+	}
+}
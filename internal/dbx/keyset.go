@@ -0,0 +1,38 @@
+package dbx
+
+import (
+	"fmt"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// Keyset builds the SQL of keyset (cursor) pagination over a creation time
+// and an ID column, newest first. Unlike OFFSET, the database seeks straight
+// to the cursor through an index on (tenant_id, created_at, id), so deep
+// pages cost the same as the first one.
+//
+//	keyset := dbx.Keyset{CreatedAtColumn: "created_at", IDColumn: "id"}
+//	if clause, next := keyset.After(filter.Cursor, args); clause != "" {
+//		conditions, args = append(conditions, clause), next
+//	}
+//	query += " ORDER BY " + keyset.OrderBy() + fmt.Sprintf(" LIMIT $%d", len(args)+1)
+type Keyset struct {
+	CreatedAtColumn string
+	IDColumn        string
+}
+
+// After returns the condition selecting the rows after cursor, with its two
+// values appended to args as the next positional parameters. A nil cursor
+// (first page) returns an empty condition and args unchanged.
+func (k Keyset) After(cursor *kernel.Cursor, args []any) (string, []any) {
+	if cursor == nil {
+		return "", args
+	}
+	args = append(args, cursor.CreatedAt, cursor.ID)
+	return fmt.Sprintf("(%s, %s) < ($%d, $%d)", k.CreatedAtColumn, k.IDColumn, len(args)-1, len(args)), args
+}
+
+// OrderBy returns the ORDER BY expression matching After
+func (k Keyset) OrderBy() string {
+	return k.CreatedAtColumn + " DESC, " + k.IDColumn + " DESC"
+}
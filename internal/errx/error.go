@@ -40,6 +40,22 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether e has the same code as target, an *Error or a registered
+// *ErrorCode, so errors.Is matches by code instead of by pointer:
+//
+//	errors.Is(err, otp.CodeOTPExpired)
+//
+// Details, message and cause are not compared.
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case *Error:
+		return t.Code != "" && t.Code == e.Code
+	case *ErrorCode:
+		return t.Code != "" && t.Code == e.Code
+	}
+	return false
+}
+
 // WithDetail adds a detail to the error and returns the error for chaining
 func (e *Error) WithDetail(key string, value interface{}) *Error {
 	if e.Details == nil {
@@ -121,6 +137,22 @@ func As(err error, target interface{}) bool {
 	return errors.As(err, target)
 }
 
+// AsError returns the first *Error in err's chain
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// HasCode reports whether err, or any error it wraps, carries code, e.g.
+// "OTP_OTP_EXPIRED". Prefer errors.Is with the registered *ErrorCode when the
+// registry is importable.
+func HasCode(err error, code string) bool {
+	return code != "" && errors.Is(err, &ErrorCode{Code: code})
+}
+
 // typeToHTTPStatus maps error types to HTTP status codes
 func typeToHTTPStatus(t Type) int {
 	switch t {
@@ -0,0 +1,37 @@
+package errx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorsIsMatchesByCode(t *testing.T) {
+	registry := NewRegistry("TEST")
+	expired := registry.Register("EXPIRED", TypeValidation, http.StatusBadRequest, "Expired")
+	invalid := registry.Register("INVALID", TypeValidation, http.StatusBadRequest, "Invalid")
+
+	err := fmt.Errorf("verify: %w", Wrap(registry.New(expired).WithDetail("id", 1), "failed to verify", TypeInternal))
+
+	if !errors.Is(err, expired) {
+		t.Error("errors.Is(err, expired) = false, want true")
+	}
+	if !errors.Is(err, registry.New(expired)) {
+		t.Error("errors.Is(err, New(expired)) = false, want true")
+	}
+	if errors.Is(err, invalid) {
+		t.Error("errors.Is(err, invalid) = true, want false")
+	}
+	if !HasCode(err, "TEST_EXPIRED") || HasCode(err, "TEST_INVALID") || HasCode(err, "") {
+		t.Error("HasCode does not match by the full registered code")
+	}
+
+	e, ok := AsError(err)
+	if !ok || e.Code != expired.Code {
+		t.Errorf("AsError = %v, %v; want the %s error", e, ok, expired.Code)
+	}
+	if _, ok := AsError(errors.New("plain")); ok {
+		t.Error("AsError matched an error without errx.Error")
+	}
+}
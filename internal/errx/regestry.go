@@ -13,6 +13,12 @@ type ErrorCode struct {
 	Message    string
 }
 
+// Error implements the error interface so a registered code can be the
+// target of errors.Is; see (*Error).Is
+func (c *ErrorCode) Error() string {
+	return fmt.Sprintf("[%s] %s", c.Code, c.Message)
+}
+
 // Registry manages error codes for a module
 type Registry struct {
 	prefix string
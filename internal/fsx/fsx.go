@@ -81,3 +81,14 @@ type FileSystemWithPresign interface {
 	FileSystem
 	PresignedURLGenerator
 }
+
+// Presigner returns the presigned URL capability of fs. Backends without it,
+// such as local disk, return ErrPresignNotSupported; callers then serve the
+// bytes through the API instead.
+func Presigner(fs FileSystem) (PresignedURLGenerator, error) {
+	generator, ok := fs.(PresignedURLGenerator)
+	if !ok {
+		return nil, fsxErrors.New(ErrPresignNotSupported)
+	}
+	return generator, nil
+}
@@ -0,0 +1,97 @@
+package fsxapi
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/fsx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PresignHandlers exposes tenant-scoped presigned URLs. The tenant always
+// comes from the authenticated context, never from the request.
+type PresignHandlers struct {
+	presigner *fsx.TenantPresigner
+}
+
+func NewPresignHandlers(presigner *fsx.TenantPresigner) *PresignHandlers {
+	return &PresignHandlers{presigner: presigner}
+}
+
+func (h *PresignHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	files := router.Group("/files/presign", authMiddleware.Authenticate())
+
+	files.Post("/download", h.PresignDownload)
+	files.Post("/upload", h.PresignUpload)
+}
+
+// PresignRequest is the body of the presign endpoints
+type PresignRequest struct {
+	Key              string            `json:"key"`
+	ExpiresInSeconds int               `json:"expires_in_seconds,omitempty"`
+	ContentType      string            `json:"content_type,omitempty"` // Uploads only
+	Metadata         map[string]string `json:"metadata,omitempty"`     // Uploads only
+}
+
+// PresignResponse contains the signed URL and the tenant-namespaced key
+type PresignResponse struct {
+	URL       string    `json:"url"`
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PresignDownload signs a download URL for an object of the caller's tenant
+func (h *PresignHandlers) PresignDownload(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req PresignRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("invalid request body")
+	}
+
+	expiration := time.Duration(req.ExpiresInSeconds) * time.Second
+	url, key, err := h.presigner.DownloadURL(c.Context(), authContext.TenantID, req.Key, expiration)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(PresignResponse{
+		URL:       url,
+		Key:       key,
+		ExpiresAt: time.Now().Add(h.presigner.Expiration(expiration)),
+	})
+}
+
+// PresignUpload signs an upload URL for a key in the caller's tenant
+func (h *PresignHandlers) PresignUpload(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req PresignRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("invalid request body")
+	}
+
+	expiration := time.Duration(req.ExpiresInSeconds) * time.Second
+	url, key, err := h.presigner.UploadURL(c.Context(), authContext.TenantID, req.Key, fsx.PresignedURLOptions{
+		Expiration:  expiration,
+		ContentType: req.ContentType,
+		Metadata:    req.Metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(PresignResponse{
+		URL:       url,
+		Key:       key,
+		ExpiresAt: time.Now().Add(h.presigner.Expiration(expiration)),
+	})
+}
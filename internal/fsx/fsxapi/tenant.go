@@ -0,0 +1,19 @@
+package fsxapi
+
+import (
+	"github.com/Abraxas-365/manifesto/internal/fsx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TenantFileSystem returns fs bound to the authenticated caller's tenant.
+// Handlers that take file paths from the request must use it instead of the
+// shared FileSystem, so one tenant can never reach another tenant's keys.
+func TenantFileSystem(c *fiber.Ctx, fs fsx.FileSystem) (*fsx.TenantScopedFileSystem, error) {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return nil, iam.ErrUnauthorized()
+	}
+	return fsx.NewTenantScopedFileSystem(fs, authContext.TenantID), nil
+}
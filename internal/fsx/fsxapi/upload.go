@@ -0,0 +1,94 @@
+package fsxapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/fsx"
+	"github.com/Abraxas-365/manifesto/internal/httpx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxKeyFieldLength bounds the optional "key" form field
+const maxKeyFieldLength = 1024
+
+// UploadHandlers receives multipart uploads into the caller's tenant. The
+// tenant always comes from the authenticated context, never from the request.
+type UploadHandlers struct {
+	uploader *fsx.Uploader
+}
+
+func NewUploadHandlers(uploader *fsx.Uploader) *UploadHandlers {
+	return &UploadHandlers{uploader: uploader}
+}
+
+func (h *UploadHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	router.Post("/files/upload",
+		authMiddleware.Authenticate(),
+		httpx.RequireContentType(fiber.MIMEMultipartForm),
+		h.Upload,
+	)
+}
+
+// Upload stores the "file" part of a multipart/form-data request and returns
+// its key, size, content type and SHA-256. An optional "key" field names the
+// object inside the tenant and replaces whatever is stored there, so it
+// requires "files:write" or admin; it must come before the file part, as
+// browsers send fields in form order. The body is read from the request
+// stream (the server runs with StreamRequestBody), never into memory as a
+// whole.
+func (h *UploadHandlers) Upload(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	_, params, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || params["boundary"] == "" {
+		return errx.Validation("invalid multipart body")
+	}
+	var body io.Reader
+	if c.Request().IsBodyStream() {
+		body = c.Context().RequestBodyStream()
+	} else {
+		body = bytes.NewReader(c.Request().Body())
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+
+	var key string
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return fsx.FileRequired()
+		}
+		if err != nil {
+			return errx.Validation("invalid multipart body")
+		}
+
+		switch part.FormName() {
+		case "key":
+			value, err := io.ReadAll(io.LimitReader(part, maxKeyFieldLength+1))
+			if err != nil || len(value) > maxKeyFieldLength {
+				return errx.Validation("invalid key field").WithDetail("max_length", maxKeyFieldLength)
+			}
+			key = string(value)
+			if key != "" && !authContext.IsAdmin() && !authContext.HasScope(scopes.ScopeFilesWrite) {
+				return iam.ErrAccessDenied().WithDetail("required_scopes", []string{scopes.ScopeFilesWrite})
+			}
+
+		case "file":
+			result, err := h.uploader.Upload(c.Context(), authContext.TenantID, key, part.FileName(), part.Header.Get(fiber.HeaderContentType), part)
+			if err != nil {
+				return err
+			}
+			return c.Status(fiber.StatusCreated).JSON(result)
+		}
+	}
+}
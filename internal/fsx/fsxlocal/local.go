@@ -10,11 +10,14 @@ import (
 	"github.com/Abraxas-365/manifesto/internal/fsx"
 )
 
-// LocalFileSystem implements fsx.FileSystem using local disk
+// LocalFileSystem implements fsx.FileSystem using local disk. It cannot
+// presign URLs: fsx.Presigner reports fsx.ErrPresignNotSupported for it.
 type LocalFileSystem struct {
 	basePath string // Root directory for all files
 }
 
+var _ fsx.FileSystem = (*LocalFileSystem)(nil)
+
 // NewLocalFileSystem creates a new local file system
 // basePath: root directory (e.g., "./uploads" or "/tmp/manifesto-files")
 func NewLocalFileSystem(basePath string) (*LocalFileSystem, error) {
@@ -38,7 +38,8 @@ var (
 	ErrFailedPresign    = s3Errors.Register("FAILED_PRESIGN", errx.TypeExternal, 500, "Failed to generate presigned URL")
 )
 
-// S3FileSystem implements the FileSystem interface for AWS S3
+// S3FileSystem implements the FileSystem interface for AWS S3, including
+// presigned URLs so clients can download and upload directly
 type S3FileSystem struct {
 	client        *s3.Client
 	presignClient *s3.PresignClient
@@ -46,6 +47,8 @@ type S3FileSystem struct {
 	rootPath      string
 }
 
+var _ fsx.FileSystemWithPresign = (*S3FileSystem)(nil)
+
 // NewS3FileSystem creates a new S3FileSystem
 func NewS3FileSystem(client *s3.Client, bucket string, rootPath string) *S3FileSystem {
 	if rootPath != "" {
@@ -0,0 +1,174 @@
+package fsx
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// TenantScopedFileSystem confines a FileSystem to one tenant's namespace.
+// Paths are relative to the tenant root and resolved with TenantKey, so
+// traversal ("../") and keys of other tenants are rejected before reaching
+// the wrapped FileSystem. An empty path, "." or "/" is the tenant root.
+//
+// Handlers should never touch the shared FileSystem with caller-supplied
+// paths; they get one of these bound to the authenticated tenant instead
+// (see fsxapi.TenantFileSystem). Presigned URLs are scoped separately by
+// TenantPresigner.
+type TenantScopedFileSystem struct {
+	fs       FileSystem
+	tenantID kernel.TenantID
+}
+
+var _ FileSystem = (*TenantScopedFileSystem)(nil)
+
+// NewTenantScopedFileSystem wraps fs for tenantID
+func NewTenantScopedFileSystem(fs FileSystem, tenantID kernel.TenantID) *TenantScopedFileSystem {
+	return &TenantScopedFileSystem{fs: fs, tenantID: tenantID}
+}
+
+// TenantID returns the tenant the file system is bound to
+func (s *TenantScopedFileSystem) TenantID() kernel.TenantID {
+	return s.tenantID
+}
+
+// ============================================================================
+// FileReader Implementation
+// ============================================================================
+
+func (s *TenantScopedFileSystem) ReadFile(ctx context.Context, p string) ([]byte, error) {
+	key, err := s.key(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.fs.ReadFile(ctx, key)
+}
+
+func (s *TenantScopedFileSystem) ReadFileStream(ctx context.Context, p string) (io.ReadCloser, error) {
+	key, err := s.key(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.fs.ReadFileStream(ctx, key)
+}
+
+func (s *TenantScopedFileSystem) Stat(ctx context.Context, p string) (FileInfo, error) {
+	key, err := s.dir(p)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	info, err := s.fs.Stat(ctx, key)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	info.Name = s.strip(info.Name)
+	return info, nil
+}
+
+// List lists a directory of the tenant. Names are relative to it: backends
+// return base names, and any tenant prefix is stripped.
+func (s *TenantScopedFileSystem) List(ctx context.Context, p string) ([]FileInfo, error) {
+	key, err := s.dir(p)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := s.fs.List(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	for i := range infos {
+		infos[i].Name = s.strip(infos[i].Name)
+	}
+	return infos, nil
+}
+
+func (s *TenantScopedFileSystem) Exists(ctx context.Context, p string) (bool, error) {
+	key, err := s.dir(p)
+	if err != nil {
+		return false, err
+	}
+	return s.fs.Exists(ctx, key)
+}
+
+// ============================================================================
+// FileWriter Implementation
+// ============================================================================
+
+func (s *TenantScopedFileSystem) WriteFile(ctx context.Context, p string, data []byte) error {
+	key, err := s.key(p)
+	if err != nil {
+		return err
+	}
+	return s.fs.WriteFile(ctx, key, data)
+}
+
+func (s *TenantScopedFileSystem) WriteFileStream(ctx context.Context, p string, r io.Reader) error {
+	key, err := s.key(p)
+	if err != nil {
+		return err
+	}
+	return s.fs.WriteFileStream(ctx, key, r)
+}
+
+func (s *TenantScopedFileSystem) CreateDir(ctx context.Context, p string) error {
+	key, err := s.dir(p)
+	if err != nil {
+		return err
+	}
+	return s.fs.CreateDir(ctx, key)
+}
+
+// ============================================================================
+// FileDeleter Implementation
+// ============================================================================
+
+func (s *TenantScopedFileSystem) DeleteFile(ctx context.Context, p string) error {
+	key, err := s.key(p)
+	if err != nil {
+		return err
+	}
+	return s.fs.DeleteFile(ctx, key)
+}
+
+// DeleteDir deletes a directory of the tenant; the tenant root deletes all
+// of its files
+func (s *TenantScopedFileSystem) DeleteDir(ctx context.Context, p string, recursive bool) error {
+	key, err := s.dir(p)
+	if err != nil {
+		return err
+	}
+	return s.fs.DeleteDir(ctx, key, recursive)
+}
+
+// ============================================================================
+// PathOperations Implementation
+// ============================================================================
+
+// Join joins tenant-relative path elements
+func (s *TenantScopedFileSystem) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// key resolves a file path; the tenant root is not a file
+func (s *TenantScopedFileSystem) key(p string) (string, error) {
+	return TenantKey(s.tenantID, p)
+}
+
+// dir resolves a path that may be the tenant root
+func (s *TenantScopedFileSystem) dir(p string) (string, error) {
+	switch strings.TrimSpace(p) {
+	case "", ".", "/":
+		if s.tenantID.IsEmpty() {
+			return "", fsxErrors.New(ErrInvalidKey).WithDetail("reason", "missing tenant")
+		}
+		return strings.TrimSuffix(TenantRoot(s.tenantID), "/"), nil
+	}
+	return TenantKey(s.tenantID, p)
+}
+
+func (s *TenantScopedFileSystem) strip(name string) string {
+	return strings.TrimPrefix(name, TenantRoot(s.tenantID))
+}
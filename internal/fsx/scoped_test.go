@@ -0,0 +1,34 @@
+package fsx
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTenantScopedFileSystem(t *testing.T) {
+	ctx := context.Background()
+	shared := &memoryFS{files: map[string][]byte{
+		"tenants/t2/secret.txt": []byte("t2"),
+	}}
+	fs := NewTenantScopedFileSystem(shared, "t1")
+
+	if err := fs.WriteFileStream(ctx, "/docs/a.txt", strings.NewReader("a")); err != nil {
+		t.Fatalf("WriteFileStream: %v", err)
+	}
+	if _, ok := shared.files["tenants/t1/docs/a.txt"]; !ok {
+		t.Errorf("file not stored under the tenant prefix: %v", shared.files)
+	}
+	if exists, err := fs.Exists(ctx, "docs/a.txt"); err != nil || !exists {
+		t.Errorf("Exists = %v, %v, want true", exists, err)
+	}
+
+	for _, p := range []string{"../t2/secret.txt", "docs/../../t2/secret.txt", "tenants/t2/secret.txt", ""} {
+		if err := fs.DeleteFile(ctx, p); err == nil {
+			t.Errorf("DeleteFile(%q) succeeded, want rejection", p)
+		}
+	}
+	if _, ok := shared.files["tenants/t2/secret.txt"]; !ok {
+		t.Error("another tenant's file was deleted")
+	}
+}
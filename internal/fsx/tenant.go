@@ -0,0 +1,152 @@
+package fsx
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// TenantPrefix is the root under which every tenant's objects are stored:
+// tenants/{tenantID}/{key}
+const TenantPrefix = "tenants"
+
+var (
+	fsxErrors = errx.NewRegistry("FSX")
+
+	ErrInvalidKey          = fsxErrors.Register("INVALID_KEY", errx.TypeValidation, 400, "Invalid object key")
+	ErrCrossTenantKey      = fsxErrors.Register("CROSS_TENANT_KEY", errx.TypeAuthorization, 403, "Object key belongs to another tenant")
+	ErrInvalidExpiration   = fsxErrors.Register("INVALID_EXPIRATION", errx.TypeValidation, 400, "Invalid presigned URL expiration")
+	ErrPresignNotSupported = fsxErrors.Register("PRESIGN_NOT_SUPPORTED", errx.TypeBusiness, 501, "Storage backend does not support presigned URLs")
+)
+
+// TenantRoot returns the storage prefix of a tenant, with a trailing slash
+func TenantRoot(tenantID kernel.TenantID) string {
+	return TenantPrefix + "/" + tenantID.String() + "/"
+}
+
+// TenantKey resolves a caller-supplied key into the tenant's namespace.
+//
+// Keys may be given relative to the tenant ("invoices/1.pdf") or already
+// namespaced ("tenants/{tenantID}/invoices/1.pdf"). Keys that escape the
+// namespace or point at another tenant are rejected.
+func TenantKey(tenantID kernel.TenantID, key string) (string, error) {
+	if tenantID.IsEmpty() {
+		return "", fsxErrors.New(ErrInvalidKey).WithDetail("reason", "missing tenant")
+	}
+
+	raw := strings.TrimPrefix(strings.TrimSpace(key), "/")
+	if raw == "" || strings.Contains(raw, "\\") {
+		return "", fsxErrors.New(ErrInvalidKey).WithDetail("key", key)
+	}
+	for _, segment := range strings.Split(raw, "/") {
+		if segment == ".." {
+			return "", fsxErrors.New(ErrInvalidKey).
+				WithDetail("key", key).
+				WithDetail("reason", "path traversal")
+		}
+	}
+
+	cleaned := path.Clean(raw)
+	if cleaned == "." {
+		return "", fsxErrors.New(ErrInvalidKey).WithDetail("key", key)
+	}
+
+	root := TenantRoot(tenantID)
+	if strings.HasPrefix(cleaned, root) {
+		return cleaned, nil
+	}
+	if cleaned == TenantPrefix || strings.HasPrefix(cleaned, TenantPrefix+"/") {
+		return "", fsxErrors.New(ErrCrossTenantKey).
+			WithDetail("key", key).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	return root + cleaned, nil
+}
+
+// TenantPresigner generates presigned URLs restricted to a tenant's namespace.
+// Every key is resolved with TenantKey before signing.
+//
+// A nil *TenantPresigner is valid and fails with ErrPresignNotSupported, so
+// the presign endpoints can be mounted whatever the storage backend.
+type TenantPresigner struct {
+	generator         PresignedURLGenerator
+	defaultExpiration time.Duration
+	maxExpiration     time.Duration
+}
+
+// NewTenantPresigner wraps generator. defaultExpiration is used when callers
+// pass zero; longer expirations than maxExpiration are rejected.
+func NewTenantPresigner(generator PresignedURLGenerator, defaultExpiration, maxExpiration time.Duration) *TenantPresigner {
+	return &TenantPresigner{
+		generator:         generator,
+		defaultExpiration: defaultExpiration,
+		maxExpiration:     maxExpiration,
+	}
+}
+
+// DownloadURL returns a presigned download URL for a key of the tenant.
+// The resolved object key is returned alongside the URL.
+func (p *TenantPresigner) DownloadURL(ctx context.Context, tenantID kernel.TenantID, key string, expiration time.Duration) (string, string, error) {
+	objectKey, expiration, err := p.resolve(tenantID, key, expiration)
+	if err != nil {
+		return "", "", err
+	}
+
+	url, err := p.generator.GetPresignedDownloadURL(ctx, objectKey, expiration)
+	if err != nil {
+		return "", "", err
+	}
+
+	return url, objectKey, nil
+}
+
+// UploadURL returns a presigned upload URL for a key of the tenant.
+// The resolved object key is returned alongside the URL.
+func (p *TenantPresigner) UploadURL(ctx context.Context, tenantID kernel.TenantID, key string, opts PresignedURLOptions) (string, string, error) {
+	objectKey, expiration, err := p.resolve(tenantID, key, opts.Expiration)
+	if err != nil {
+		return "", "", err
+	}
+	opts.Expiration = expiration
+
+	url, err := p.generator.GetPresignedUploadURLWithOptions(ctx, objectKey, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	return url, objectKey, nil
+}
+
+// Expiration returns the expiration applied to a request, using the default
+// when none was given
+func (p *TenantPresigner) Expiration(requested time.Duration) time.Duration {
+	if requested == 0 {
+		return p.defaultExpiration
+	}
+	return requested
+}
+
+func (p *TenantPresigner) resolve(tenantID kernel.TenantID, key string, expiration time.Duration) (string, time.Duration, error) {
+	if p == nil {
+		return "", 0, fsxErrors.New(ErrPresignNotSupported)
+	}
+
+	objectKey, err := TenantKey(tenantID, key)
+	if err != nil {
+		return "", 0, err
+	}
+
+	expiration = p.Expiration(expiration)
+	if expiration < 0 || (p.maxExpiration > 0 && expiration > p.maxExpiration) {
+		return "", 0, fsxErrors.New(ErrInvalidExpiration).
+			WithDetail("expiration", expiration.String()).
+			WithDetail("max_expiration", p.maxExpiration.String())
+	}
+
+	return objectKey, expiration, nil
+}
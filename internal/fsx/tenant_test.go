@@ -0,0 +1,107 @@
+package fsx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+func TestTenantKey(t *testing.T) {
+	tests := []struct {
+		tenantID string
+		key      string
+		want     string
+		wantCode *errx.ErrorCode
+	}{
+		{"t1", "invoices/1.pdf", "tenants/t1/invoices/1.pdf", nil},
+		{"t1", "/invoices/1.pdf", "tenants/t1/invoices/1.pdf", nil},
+		{"t1", " invoices//2024/./1.pdf ", "tenants/t1/invoices/2024/1.pdf", nil},
+		{"t1", "tenants/t1/invoices/1.pdf", "tenants/t1/invoices/1.pdf", nil},
+		{"t1", "/tenants/t1/invoices/1.pdf", "tenants/t1/invoices/1.pdf", nil},
+		// A tenant whose ID prefixes another's is not mistaken for it
+		{"t1", "tenants/t10/invoices/1.pdf", "", ErrCrossTenantKey},
+		{"t1", "tenants/t2/invoices/1.pdf", "", ErrCrossTenantKey},
+		{"t1", "tenants", "", ErrCrossTenantKey},
+		{"t1", "tenants/t1", "", ErrCrossTenantKey},
+		{"t1", "../t2/invoices/1.pdf", "", ErrInvalidKey},
+		{"t1", "invoices/../../t2/1.pdf", "", ErrInvalidKey},
+		{"t1", "invoices/..", "", ErrInvalidKey},
+		{"t1", `invoices\..\1.pdf`, "", ErrInvalidKey},
+		{"t1", "", "", ErrInvalidKey},
+		{"t1", "/", "", ErrInvalidKey},
+		{"t1", "./.", "", ErrInvalidKey},
+		{"", "invoices/1.pdf", "", ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		got, err := TenantKey(kernel.TenantID(tt.tenantID), tt.key)
+		if tt.wantCode == nil {
+			if err != nil || got != tt.want {
+				t.Errorf("TenantKey(%q, %q) = %q, %v; want %q", tt.tenantID, tt.key, got, err, tt.want)
+			}
+			continue
+		}
+		if !errx.Is(err, tt.wantCode) {
+			t.Errorf("TenantKey(%q, %q) = %q, %v; want %s", tt.tenantID, tt.key, got, err, tt.wantCode.Code)
+		}
+	}
+}
+
+// recordingPresigner returns the key it was asked to sign as the URL
+type recordingPresigner struct {
+	expiration time.Duration
+}
+
+func (p *recordingPresigner) GetPresignedDownloadURL(_ context.Context, path string, expiration time.Duration) (string, error) {
+	p.expiration = expiration
+	return "https://storage/" + path, nil
+}
+
+func (p *recordingPresigner) GetPresignedUploadURL(ctx context.Context, path string, expiration time.Duration) (string, error) {
+	return p.GetPresignedDownloadURL(ctx, path, expiration)
+}
+
+func (p *recordingPresigner) GetPresignedUploadURLWithOptions(ctx context.Context, path string, opts PresignedURLOptions) (string, error) {
+	return p.GetPresignedDownloadURL(ctx, path, opts.Expiration)
+}
+
+func TestTenantPresigner(t *testing.T) {
+	ctx := context.Background()
+	generator := &recordingPresigner{}
+	presigner := NewTenantPresigner(generator, 15*time.Minute, time.Hour)
+
+	url, key, err := presigner.DownloadURL(ctx, "t1", "docs/a.pdf", 0)
+	if err != nil || key != "tenants/t1/docs/a.pdf" || url != "https://storage/tenants/t1/docs/a.pdf" {
+		t.Fatalf("DownloadURL = %q, %q, %v; want the tenant's key signed", url, key, err)
+	}
+	if generator.expiration != 15*time.Minute {
+		t.Errorf("expiration = %s, want the default", generator.expiration)
+	}
+
+	if _, key, err := presigner.UploadURL(ctx, "t1", "docs/b.pdf", PresignedURLOptions{Expiration: time.Hour}); err != nil || key != "tenants/t1/docs/b.pdf" {
+		t.Fatalf("UploadURL = %q, %v; want the tenant's key", key, err)
+	}
+
+	if _, _, err := presigner.DownloadURL(ctx, "t1", "tenants/t2/docs/a.pdf", 0); !errx.Is(err, ErrCrossTenantKey) {
+		t.Errorf("DownloadURL of another tenant's key = %v, want CROSS_TENANT_KEY", err)
+	}
+	if _, _, err := presigner.UploadURL(ctx, "t1", "../t2/docs/a.pdf", PresignedURLOptions{}); !errx.Is(err, ErrInvalidKey) {
+		t.Errorf("UploadURL with traversal = %v, want INVALID_KEY", err)
+	}
+	if _, _, err := presigner.DownloadURL(ctx, "", "docs/a.pdf", 0); !errx.Is(err, ErrInvalidKey) {
+		t.Errorf("DownloadURL without tenant = %v, want INVALID_KEY", err)
+	}
+	for _, expiration := range []time.Duration{-time.Minute, 2 * time.Hour} {
+		if _, _, err := presigner.DownloadURL(ctx, "t1", "docs/a.pdf", expiration); !errx.Is(err, ErrInvalidExpiration) {
+			t.Errorf("DownloadURL with expiration %s = %v, want INVALID_EXPIRATION", expiration, err)
+		}
+	}
+
+	var unsupported *TenantPresigner
+	if _, _, err := unsupported.DownloadURL(ctx, "t1", "docs/a.pdf", 0); !errx.Is(err, ErrPresignNotSupported) {
+		t.Errorf("nil presigner = %v, want PRESIGN_NOT_SUPPORTED", err)
+	}
+}
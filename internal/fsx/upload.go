@@ -0,0 +1,286 @@
+package fsx
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// UploadDir is where uploads without an explicit key are stored, relative to
+// the tenant root
+const UploadDir = "uploads"
+
+// sniffLen is how much of a file content sniffing looks at
+const sniffLen = 512
+
+var (
+	ErrFileRequired        = fsxErrors.Register("FILE_REQUIRED", errx.TypeValidation, 400, "A file is required")
+	ErrFileTooLarge        = fsxErrors.Register("FILE_TOO_LARGE", errx.TypeValidation, 413, "File exceeds the maximum upload size")
+	ErrUnsupportedFileType = fsxErrors.Register("UNSUPPORTED_FILE_TYPE", errx.TypeValidation, 415, "File type is not allowed")
+	ErrFileInfected        = fsxErrors.Register("FILE_INFECTED", errx.TypeValidation, 422, "File was rejected by the malware scanner")
+	ErrScanFailed          = fsxErrors.Register("SCAN_FAILED", errx.TypeExternal, 503, "File could not be scanned")
+	ErrUploadFailed        = fsxErrors.Register("UPLOAD_FAILED", errx.TypeInternal, 500, "File could not be stored")
+)
+
+// Scanner inspects an upload before it is stored, e.g. with ClamAV's INSTREAM
+// command. It reads the whole file from r and returns Infected to reject it;
+// any other error fails the upload as well, so a scanner outage never lets
+// files through unscanned.
+type Scanner interface {
+	Scan(ctx context.Context, filename string, r io.Reader) error
+}
+
+// Infected builds the error a Scanner returns for a malicious file
+func Infected(signature string) *errx.Error {
+	return fsxErrors.New(ErrFileInfected).WithDetail("signature", signature)
+}
+
+// FileRequired builds the error for an upload request without a file
+func FileRequired() *errx.Error {
+	return fsxErrors.New(ErrFileRequired)
+}
+
+// UploadResult describes a stored upload
+type UploadResult struct {
+	Key         string    `json:"key"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// sniffedAs maps declared types to the more generic type content sniffing
+// reports for them: Office Open XML documents are zip archives and CSV is
+// plain text
+var sniffedAs = map[string]string{
+	"text/csv": "text/plain",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   "application/zip",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         "application/zip",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": "application/zip",
+}
+
+// Uploader stores files in a tenant's namespace, enforcing a size limit and a
+// list of allowed media types and hashing the content on the way.
+//
+// Uploads to a generated key stream straight into WriteFileStream when there
+// is no Scanner. Uploads to an explicit key, and every upload when there is a
+// Scanner, are first spooled to a temporary file so the size limit and the
+// scanner see the whole file before anything reaches storage: a rejected
+// upload never touches the object already stored under its key.
+type Uploader struct {
+	fs           FileSystem
+	scanner      Scanner
+	maxSize      int64
+	allowedTypes map[string]bool
+}
+
+// NewUploader creates an Uploader. A nil scanner disables scanning.
+func NewUploader(fs FileSystem, scanner Scanner, maxSize int64, allowedTypes []string) *Uploader {
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, t := range allowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &Uploader{
+		fs:           fs,
+		scanner:      scanner,
+		maxSize:      maxSize,
+		allowedTypes: allowed,
+	}
+}
+
+// Upload stores r under key in the tenant's namespace, or under
+// uploads/{random}{ext} when key is empty. declaredType is the Content-Type
+// sent by the client; it must be allowed and agree with the sniffed content.
+func (u *Uploader) Upload(ctx context.Context, tenantID kernel.TenantID, key, filename, declaredType string, r io.Reader) (*UploadResult, error) {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if filename == "." || filename == "/" {
+		filename = ""
+	}
+	explicitKey := key != ""
+	if !explicitKey {
+		key = UploadDir + "/" + randomName() + strings.ToLower(path.Ext(filename))
+	}
+	objectKey, err := TenantKey(tenantID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fsxErrors.NewWithCause(ErrUploadFailed, err)
+	}
+	if len(head) == 0 {
+		return nil, FileRequired()
+	}
+	contentType, err := u.contentType(declaredType, head)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	body := &limitedReader{r: io.TeeReader(br, hash), remaining: u.maxSize}
+
+	if u.scanner == nil && !explicitKey {
+		// A generated key is new, so whatever a failed write leaves is ours
+		if err := u.fs.WriteFileStream(ctx, objectKey, body); err != nil {
+			u.discard(objectKey)
+			return nil, u.writeError(body, err)
+		}
+	} else if err := u.spoolAndWrite(ctx, objectKey, filename, body); err != nil {
+		return nil, err
+	}
+
+	return &UploadResult{
+		Key:         objectKey,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        body.read,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		UploadedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// spoolAndWrite spools body to a temporary file, scans it when there is a
+// Scanner and only then copies it to storage. A failed copy only removes the
+// object when it did not exist before, so an overwrite never loses the
+// stored file.
+func (u *Uploader) spoolAndWrite(ctx context.Context, objectKey, filename string, body *limitedReader) error {
+	spool, err := os.CreateTemp("", "fsx-upload-*")
+	if err != nil {
+		return fsxErrors.NewWithCause(ErrUploadFailed, err)
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+
+	if _, err := io.Copy(spool, body); err != nil {
+		return u.writeError(body, err)
+	}
+
+	if u.scanner != nil {
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return fsxErrors.NewWithCause(ErrUploadFailed, err)
+		}
+		if err := u.scanner.Scan(ctx, filename, spool); err != nil {
+			var e *errx.Error
+			if errx.As(err, &e) && e.Code == ErrFileInfected.Code {
+				return e
+			}
+			return fsxErrors.NewWithCause(ErrScanFailed, err)
+		}
+	}
+
+	existed, err := u.fs.Exists(ctx, objectKey)
+	if err != nil {
+		return fsxErrors.NewWithCause(ErrUploadFailed, err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fsxErrors.NewWithCause(ErrUploadFailed, err)
+	}
+	if err := u.fs.WriteFileStream(ctx, objectKey, spool); err != nil {
+		if !existed {
+			u.discard(objectKey)
+		}
+		return fsxErrors.NewWithCause(ErrUploadFailed, err).WithDetail("key", objectKey)
+	}
+	return nil
+}
+
+// contentType checks the declared type against the allowed list and the
+// sniffed content, and returns the type to record
+func (u *Uploader) contentType(declaredType string, head []byte) (string, error) {
+	declared, _, err := mime.ParseMediaType(declaredType)
+	if err != nil || declared == "application/octet-stream" {
+		// Clients that do not know the type get the sniffed one
+		declared = ""
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+
+	contentType := sniffed
+	if declared != "" && declared != sniffed {
+		if sniffedAs[declared] != sniffed {
+			return "", fsxErrors.New(ErrUnsupportedFileType).
+				WithDetail("content_type", declared).
+				WithDetail("detected", sniffed)
+		}
+		contentType = declared
+	}
+
+	if !u.allowedTypes[contentType] {
+		return "", fsxErrors.New(ErrUnsupportedFileType).WithDetail("content_type", contentType)
+	}
+	return contentType, nil
+}
+
+func (u *Uploader) writeError(body *limitedReader, err error) error {
+	if body.exceeded {
+		return fsxErrors.New(ErrFileTooLarge).WithDetail("max_bytes", u.maxSize)
+	}
+	return fsxErrors.NewWithCause(ErrUploadFailed, err)
+}
+
+// discard removes what a failed write left under a key this upload created.
+// It runs on its own context because the request's may already be cancelled.
+func (u *Uploader) discard(objectKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if exists, err := u.fs.Exists(ctx, objectKey); err != nil || !exists {
+		return
+	}
+	if err := u.fs.DeleteFile(ctx, objectKey); err != nil {
+		logx.Warnf("failed to remove partial upload %s: %v", objectKey, err)
+	}
+}
+
+// limitedReader fails once more than remaining bytes are read, unlike
+// io.LimitReader which silently truncates
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	read      int64
+	exceeded  bool
+}
+
+var errLimitExceeded = errors.New("upload size limit exceeded")
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, errLimitExceeded
+	}
+	// Read one byte past the limit so an exact-size file still ends in EOF
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return n, errLimitExceeded
+	}
+	return n, err
+}
+
+func randomName() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
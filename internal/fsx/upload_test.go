@@ -0,0 +1,137 @@
+package fsx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// memoryFS implements the FileSystem methods the Uploader uses
+type memoryFS struct {
+	FileSystem
+	files map[string][]byte
+}
+
+func (m *memoryFS) WriteFileStream(_ context.Context, path string, r io.Reader) error {
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, r)
+	m.files[path] = buf.Bytes() // partial content stays, like a local file
+	return err
+}
+
+func (m *memoryFS) Exists(_ context.Context, path string) (bool, error) {
+	_, ok := m.files[path]
+	return ok, nil
+}
+
+func (m *memoryFS) DeleteFile(_ context.Context, path string) error {
+	delete(m.files, path)
+	return nil
+}
+
+type scannerFunc func(r io.Reader) error
+
+func (f scannerFunc) Scan(_ context.Context, _ string, r io.Reader) error { return f(r) }
+
+func errorCode(err error) string {
+	var e *errx.Error
+	if errx.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+func TestUploaderUpload(t *testing.T) {
+	pdf := "%PDF-1.7\n" + strings.Repeat("x", 100)
+	infected := scannerFunc(func(r io.Reader) error {
+		data, _ := io.ReadAll(r)
+		if bytes.Contains(data, []byte("EICAR")) {
+			return Infected("Eicar-Test-Signature")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name         string
+		scanner      Scanner
+		content      string
+		declaredType string
+		wantCode     string
+	}{
+		{"stored without scanner", nil, pdf, "application/pdf", ""},
+		{"stored after clean scan", infected, pdf, "application/pdf", ""},
+		{"sniffed type when undeclared", nil, pdf, "application/octet-stream", ""},
+		{"exactly max size", nil, "%PDF-" + strings.Repeat("x", 123), "application/pdf", ""},
+		{"over max size", nil, pdf + strings.Repeat("x", 100), "application/pdf", ErrFileTooLarge.Code},
+		{"over max size with scanner", infected, pdf + strings.Repeat("x", 100), "application/pdf", ErrFileTooLarge.Code},
+		{"type not allowed", nil, "\x89PNG\r\n\x1a\n", "image/png", ErrUnsupportedFileType.Code},
+		{"declared type disagrees with content", nil, "plain text", "application/pdf", ErrUnsupportedFileType.Code},
+		{"empty file", nil, "", "application/pdf", ErrFileRequired.Code},
+		{"infected", infected, pdf + "EICAR", "application/pdf", ErrFileInfected.Code},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &memoryFS{files: map[string][]byte{}}
+			uploader := NewUploader(fs, tt.scanner, 128, []string{"application/pdf", "text/plain"})
+
+			result, err := uploader.Upload(context.Background(), kernel.TenantID("t1"), "", "../cv.PDF", tt.declaredType, strings.NewReader(tt.content))
+			if tt.wantCode != "" {
+				if code := errorCode(err); code != tt.wantCode {
+					t.Fatalf("error = %v, want %s", err, tt.wantCode)
+				}
+				if len(fs.files) != 0 {
+					t.Errorf("files left in storage: %d", len(fs.files))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+
+			sum := sha256.Sum256([]byte(tt.content))
+			if !strings.HasPrefix(result.Key, "tenants/t1/uploads/") || !strings.HasSuffix(result.Key, ".pdf") {
+				t.Errorf("key = %q", result.Key)
+			}
+			if result.Filename != "cv.PDF" || result.ContentType != "application/pdf" ||
+				result.Size != int64(len(tt.content)) || result.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("unexpected result: %+v", result)
+			}
+			if string(fs.files[result.Key]) != tt.content {
+				t.Errorf("stored content differs")
+			}
+		})
+	}
+}
+
+func TestUploaderExplicitKey(t *testing.T) {
+	ctx := context.Background()
+	stored := "%PDF-1.7\nstored"
+	fs := &memoryFS{files: map[string][]byte{"tenants/t1/docs/cv.pdf": []byte(stored)}}
+	uploader := NewUploader(fs, nil, 128, []string{"application/pdf"})
+
+	// A rejected overwrite leaves the stored object alone
+	tooLarge := "%PDF-1.7\n" + strings.Repeat("x", 200)
+	if _, err := uploader.Upload(ctx, "t1", "docs/cv.pdf", "cv.pdf", "application/pdf", strings.NewReader(tooLarge)); errorCode(err) != ErrFileTooLarge.Code {
+		t.Fatalf("error = %v, want %s", err, ErrFileTooLarge.Code)
+	}
+	if string(fs.files["tenants/t1/docs/cv.pdf"]) != stored {
+		t.Fatal("stored object changed by a rejected upload")
+	}
+
+	replacement := "%PDF-1.7\nreplacement"
+	result, err := uploader.Upload(ctx, "t1", "docs/cv.pdf", "cv.pdf", "application/pdf", strings.NewReader(replacement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Key != "tenants/t1/docs/cv.pdf" || string(fs.files[result.Key]) != replacement {
+		t.Errorf("overwrite stored %q under %s", fs.files[result.Key], result.Key)
+	}
+}
@@ -0,0 +1,103 @@
+package httpx
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/gofiber/fiber/v2"
+)
+
+func errorHandler(c *fiber.Ctx, err error) error {
+	var e *errx.Error
+	if errx.As(err, &e) {
+		return c.Status(e.HTTPStatus).SendString(e.Code)
+	}
+	return fiber.DefaultErrorHandler(c, err)
+}
+
+func newTestApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errorHandler})
+	app.Post("/login",
+		BodyLimit(16),
+		RequireContentType(fiber.MIMEApplicationJSON),
+		func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) },
+	)
+	return app
+}
+
+func TestBodyLimitAndContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        int
+	}{
+		{"json within limit", `{"a":"b"}`, "application/json; charset=utf-8", fiber.StatusNoContent},
+		{"media type is case-insensitive", `{"a":"b"}`, "Application/JSON", fiber.StatusNoContent},
+		{"empty body skips content type", "", "", fiber.StatusNoContent},
+		{"body over limit", `{"a":"` + strings.Repeat("x", 32) + `"}`, "application/json", fiber.StatusRequestEntityTooLarge},
+		{"unexpected content type", "a=b", "application/x-www-form-urlencoded", fiber.StatusUnsupportedMediaType},
+		{"missing content type", `{"a":"b"}`, "", fiber.StatusUnsupportedMediaType},
+	}
+
+	app := newTestApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/login", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyLimitStreamedBodies(t *testing.T) {
+	app := fiber.New(fiber.Config{StreamRequestBody: true, ErrorHandler: errorHandler})
+	app.Post("/echo", BodyLimit(16), func(c *fiber.Ctx) error {
+		return c.Send(c.Body())
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"known length within limit", `{"a":"b"}`, false, fiber.StatusOK},
+		{"known length over limit", strings.Repeat("x", 32), false, fiber.StatusRequestEntityTooLarge},
+		{"chunked within limit", `{"a":"b"}`, true, fiber.StatusOK},
+		{"chunked exactly at limit", strings.Repeat("x", 16), true, fiber.StatusOK},
+		{"chunked over limit", strings.Repeat("x", 32), true, fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/echo", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == fiber.StatusOK {
+				if got, _ := io.ReadAll(resp.Body); string(got) != tt.body {
+					t.Errorf("handler read %q, want %q", got, tt.body)
+				}
+			}
+		})
+	}
+}
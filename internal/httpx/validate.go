@@ -0,0 +1,139 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	CodeInvalidBody      = ErrRegistry.Register("INVALID_BODY", errx.TypeValidation, http.StatusBadRequest, "Invalid request body")
+	CodeValidationFailed = ErrRegistry.Register("VALIDATION_FAILED", errx.TypeValidation, http.StatusUnprocessableEntity, "Request validation failed")
+)
+
+func ErrInvalidBody() *errx.Error {
+	return ErrRegistry.New(CodeInvalidBody)
+}
+
+// ErrValidationFailed carries one message per invalid field in
+// details.fields, keyed by the field's JSON name
+func ErrValidationFailed(fields map[string]string) *errx.Error {
+	return ErrRegistry.New(CodeValidationFailed).WithDetail("fields", fields)
+}
+
+// validate is safe for concurrent use and caches struct metadata, so one
+// instance serves every request
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by the name clients send, not the Go field name
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// Bind parses the request body into req, a pointer to a struct, and runs its
+// validate tags. It returns ErrInvalidBody when the body cannot be parsed and
+// ErrValidationFailed (422) listing every invalid field otherwise:
+//
+//	var req CreateAPIKeyRequest
+//	if err := httpx.Bind(c, &req); err != nil {
+//		return err
+//	}
+func Bind(c *fiber.Ctx, req any) error {
+	if err := c.BodyParser(req); err != nil {
+		return ErrInvalidBody()
+	}
+	return Validate(req)
+}
+
+// Validate runs the validate tags of req, a struct or a pointer to one. See
+// Bind for the errors.
+func Validate(req any) error {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		// Not a struct: a programming error, not bad input
+		return errx.Wrap(err, "failed to validate request", errx.TypeInternal)
+	}
+
+	fields := make(map[string]string, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		// Keep the first failure per field, the one earliest in its tag
+		if name := fieldName(fe); fields[name] == "" {
+			fields[name] = fieldMessage(fe)
+		}
+	}
+	return ErrValidationFailed(fields)
+}
+
+// fieldName is the JSON path of the field without the struct name, e.g.
+// "email" or "address.city"
+func fieldName(fe validator.FieldError) string {
+	_, name, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return name
+}
+
+// fieldMessage describes a failed tag in words clients can show next to the
+// field
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "e164":
+		return "must be a phone number in E.164 format, e.g. +14155552671"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		return sizeMessage(fe, "at least")
+	case "max":
+		return sizeMessage(fe, "at most")
+	case "len":
+		return sizeMessage(fe, "exactly")
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed the %s=%s check", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed the %s check", fe.Tag())
+}
+
+// sizeMessage words min, max and len by the kind of field they apply to
+func sizeMessage(fe validator.FieldError, bound string) string {
+	plural := "s"
+	if fe.Param() == "1" {
+		plural = ""
+	}
+	switch fe.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s character%s long", bound, fe.Param(), plural)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must contain %s %s item%s", bound, fe.Param(), plural)
+	default:
+		return fmt.Sprintf("must be %s %s", bound, fe.Param())
+	}
+}
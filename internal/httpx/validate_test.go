@@ -0,0 +1,41 @@
+package httpx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+type signupRequest struct {
+	Email       string   `json:"email" validate:"required,email"`
+	Name        string   `json:"name" validate:"required,min=2"`
+	Scopes      []string `json:"scopes" validate:"required,min=1"`
+	Environment string   `json:"environment,omitempty" validate:"required,oneof=live test"`
+}
+
+func TestValidateReportsEveryInvalidField(t *testing.T) {
+	err := Validate(&signupRequest{Email: "not-an-email", Name: "A", Scopes: []string{}})
+
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != CodeValidationFailed.Code || e.HTTPStatus != 422 {
+		t.Fatalf("err = %v, want %s with status 422", err, CodeValidationFailed.Code)
+	}
+
+	want := map[string]string{
+		"email":       "must be a valid email address",
+		"name":        "must be at least 2 characters long",
+		"scopes":      "must contain at least 1 item",
+		"environment": "is required",
+	}
+	if got := e.Details["fields"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestValidatePassesValidRequest(t *testing.T) {
+	req := signupRequest{Email: "ana@acme.com", Name: "Ana", Scopes: []string{"users:read"}, Environment: "test"}
+	if err := Validate(req); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
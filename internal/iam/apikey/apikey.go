@@ -3,6 +3,7 @@ package apikey
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
 )
 
@@ -43,13 +45,13 @@ func (k *APIKey) IsExpired() bool {
 	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
 }
 
+// MatchesHash compares keyHash with the stored hash in constant time
+func (k *APIKey) MatchesHash(keyHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.KeyHash), []byte(keyHash)) == 1
+}
+
 func (k *APIKey) HasScope(scope string) bool {
-	for _, s := range k.Scopes {
-		if s == scope || s == "*" {
-			return true
-		}
-	}
-	return false
+	return scopes.HasScopeIn(k.Scopes, scope)
 }
 
 func (k *APIKey) Revoke() {
@@ -121,6 +123,7 @@ type APIKeyDTO struct {
 	IsActive    bool            `json:"is_active"`
 	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
 	LastUsedAt  *time.Time      `json:"last_used_at,omitempty"`
+	IsExpired   bool            `json:"is_expired"`
 	CreatedAt   time.Time       `json:"created_at"`
 }
 
@@ -136,6 +139,7 @@ func (k *APIKey) ToDTO() APIKeyDTO {
 		IsActive:    k.IsActive,
 		ExpiresAt:   k.ExpiresAt,
 		LastUsedAt:  k.LastUsedAt,
+		IsExpired:   k.IsExpired(),
 		CreatedAt:   k.CreatedAt,
 	}
 }
@@ -167,6 +171,36 @@ type APIKeyListResponse struct {
 	Total   int         `json:"total"`
 }
 
+// APIKeyOrderBy is the sort column of an API key search, newest first
+type APIKeyOrderBy string
+
+const (
+	APIKeyOrderByCreatedAt  APIKeyOrderBy = "created_at"
+	APIKeyOrderByLastUsedAt APIKeyOrderBy = "last_used_at" // Never-used keys last
+)
+
+func (o APIKeyOrderBy) IsValid() bool {
+	return o == APIKeyOrderByCreatedAt || o == APIKeyOrderByLastUsedAt
+}
+
+// APIKeySearchFilter narrows a paginated search of a tenant's API keys
+type APIKeySearchFilter struct {
+	IsActive       *bool          `json:"is_active,omitempty"`
+	ExpiredOnly    bool           `json:"expired_only,omitempty"`
+	UserScopedOnly bool           `json:"user_scoped_only,omitempty"` // Keys bound to a user, not tenant-wide
+	UserID         *kernel.UserID `json:"user_id,omitempty"`
+	OrderBy        APIKeyOrderBy  `json:"order_by,omitempty"`
+	Limit          int            `json:"limit"`
+	Offset         int            `json:"offset"`
+}
+
+type APIKeySearchResponse struct {
+	APIKeys []APIKeyDTO `json:"api_keys"`
+	Total   int         `json:"total"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+}
+
 type RevokeAPIKeyRequest struct {
 	Reason string `json:"reason"`
 }
@@ -1,26 +1,35 @@
 package apikeyapi
 
 import (
+	"strconv"
+	"strings"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/httpx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey/apikeysrv"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
 	"github.com/gofiber/fiber/v2"
 )
 
 type APIKeyHandlers struct {
-	service *apikeysrv.APIKeyService
+	service     *apikeysrv.APIKeyService
+	idempotency *auth.IdempotencyMiddleware // nil disables Idempotency-Key
 }
 
-func NewAPIKeyHandlers(service *apikeysrv.APIKeyService) *APIKeyHandlers {
-	return &APIKeyHandlers{service: service}
+func NewAPIKeyHandlers(service *apikeysrv.APIKeyService, idempotency *auth.IdempotencyMiddleware) *APIKeyHandlers {
+	return &APIKeyHandlers{service: service, idempotency: idempotency}
 }
 
 func (h *APIKeyHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
 	keys := router.Group("/api-keys", authMiddleware.Authenticate())
 
-	keys.Post("/", h.CreateAPIKey)
+	keys.Post("/", h.idempotency.Handler(), h.CreateAPIKey)
 	keys.Get("/", h.GetTenantAPIKeys)
+	keys.Get("/search", authMiddleware.RequireAdminOrScope(scopes.ScopeAPIKeysRead), h.SearchAPIKeys)
 	keys.Get("/:id", h.GetAPIKey)
 	keys.Put("/:id", h.UpdateAPIKey)
 	keys.Post("/:id/revoke", h.RevokeAPIKey)
@@ -29,16 +38,16 @@ func (h *APIKeyHandlers) RegisterRoutes(router fiber.Router, authMiddleware *aut
 
 func (h *APIKeyHandlers) CreateAPIKey(c *fiber.Ctx) error {
 	authContext, ok := auth.GetAuthContext(c)
-	if !ok {
+	if !ok || authContext.UserID == nil {
 		return iam.ErrUnauthorized()
 	}
 
 	var req apikey.CreateAPIKeyRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
-	response, err := h.service.CreateAPIKey(c.Context(), authContext.TenantID, *authContext.UserID, req)
+	response, err := h.service.CreateAPIKey(auth.AuditContext(c), authContext.TenantID, *authContext.UserID, req)
 	if err != nil {
 		return err
 	}
@@ -60,6 +69,30 @@ func (h *APIKeyHandlers) GetTenantAPIKeys(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
+// SearchAPIKeys lists the API keys of the caller's tenant with filters and
+// pagination. Each key carries is_expired; the hash is never returned.
+//
+// Query params: is_active, expired, user_scoped, user_id,
+// order_by (created_at | last_used_at), limit, offset.
+func (h *APIKeyHandlers) SearchAPIKeys(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	filter, err := parseSearchFilter(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.service.SearchAPIKeys(c.Context(), authContext.TenantID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
 func (h *APIKeyHandlers) GetAPIKey(c *fiber.Ctx) error {
 	authContext, ok := auth.GetAuthContext(c)
 	if !ok {
@@ -77,17 +110,17 @@ func (h *APIKeyHandlers) GetAPIKey(c *fiber.Ctx) error {
 
 func (h *APIKeyHandlers) UpdateAPIKey(c *fiber.Ctx) error {
 	authContext, ok := auth.GetAuthContext(c)
-	if !ok {
+	if !ok || authContext.UserID == nil {
 		return iam.ErrUnauthorized()
 	}
 
 	keyID := c.Params("id")
 	var req apikey.UpdateAPIKeyRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
-	key, err := h.service.UpdateAPIKey(c.Context(), keyID, authContext.TenantID, req)
+	key, err := h.service.UpdateAPIKey(auth.AuditContext(c), keyID, authContext.TenantID, *authContext.UserID, req)
 	if err != nil {
 		return err
 	}
@@ -102,7 +135,7 @@ func (h *APIKeyHandlers) RevokeAPIKey(c *fiber.Ctx) error {
 	}
 
 	keyID := c.Params("id")
-	if err := h.service.RevokeAPIKey(c.Context(), keyID, authContext.TenantID); err != nil {
+	if err := h.service.RevokeAPIKey(auth.AuditContext(c), keyID, authContext.TenantID); err != nil {
 		return err
 	}
 
@@ -122,3 +155,46 @@ func (h *APIKeyHandlers) DeleteAPIKey(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"message": "API key deleted successfully"})
 }
+
+func parseSearchFilter(c *fiber.Ctx) (apikey.APIKeySearchFilter, error) {
+	filter := apikey.APIKeySearchFilter{
+		OrderBy: apikey.APIKeyOrderBy(strings.TrimSpace(c.Query("order_by"))),
+		Limit:   c.QueryInt("limit", 0),
+		Offset:  c.QueryInt("offset", 0),
+	}
+
+	var err error
+	if filter.IsActive, err = parseOptionalBool(c, "is_active"); err != nil {
+		return filter, err
+	}
+	expired, err := parseOptionalBool(c, "expired")
+	if err != nil {
+		return filter, err
+	}
+	filter.ExpiredOnly = expired != nil && *expired
+	userScoped, err := parseOptionalBool(c, "user_scoped")
+	if err != nil {
+		return filter, err
+	}
+	filter.UserScopedOnly = userScoped != nil && *userScoped
+
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		userID := kernel.UserID(raw)
+		filter.UserID = &userID
+	}
+
+	return filter, nil
+}
+
+func parseOptionalBool(c *fiber.Ctx, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, errx.Validation("invalid boolean query param").WithDetail(key, raw)
+	}
+	return &value, nil
+}
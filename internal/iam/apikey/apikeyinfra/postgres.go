@@ -3,8 +3,11 @@ package apikeyinfra
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
@@ -146,21 +149,73 @@ func (r *PostgresAPIKeyRepository) FindActiveByTenant(ctx context.Context, tenan
 	return toDomainSlice(keys), nil
 }
 
-// FindByUser busca todas las API keys para un usuario específico.
+// FindByUser busca todas las API keys para un usuario específico. Participa
+// de la transacción del contexto, si la hay.
 func (r *PostgresAPIKeyRepository) FindByUser(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID) ([]*apikey.APIKey, error) {
 	var keys []apiKeyPersistence
 	query := `SELECT * FROM api_keys WHERE user_id = $1 AND tenant_id = $2 ORDER BY created_at DESC`
-	err := r.db.SelectContext(ctx, &keys, query, userID.String(), tenantID.String())
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &keys, query, userID.String(), tenantID.String())
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to find API keys by user", errx.TypeInternal)
 	}
 	return toDomainSlice(keys), nil
 }
 
-// Delete elimina una API key de la base de datos.
+// Search busca una página de API keys de un tenant según el filtro y retorna
+// el total de coincidencias.
+func (r *PostgresAPIKeyRepository) Search(ctx context.Context, tenantID kernel.TenantID, filter apikey.APIKeySearchFilter) ([]*apikey.APIKey, int, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []any{tenantID.String()}
+
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.IsActive != nil {
+		addCondition("is_active = $%d", *filter.IsActive)
+	}
+	if filter.ExpiredOnly {
+		addCondition("(expires_at IS NOT NULL AND expires_at <= $%d)", time.Now())
+	}
+	if filter.UserScopedOnly {
+		conditions = append(conditions, "user_id IS NOT NULL")
+	}
+	if filter.UserID != nil {
+		addCondition("user_id = $%d", filter.UserID.String())
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM api_keys WHERE ` + where
+	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, errx.Wrap(err, "failed to count API keys", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	orderBy := "created_at DESC, id ASC"
+	if filter.OrderBy == apikey.APIKeyOrderByLastUsedAt {
+		orderBy = "last_used_at DESC NULLS LAST, created_at DESC, id ASC"
+	}
+
+	query := `SELECT * FROM api_keys WHERE ` + where + ` ORDER BY ` + orderBy +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	var keys []apiKeyPersistence
+	if err := r.db.SelectContext(ctx, &keys, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, errx.Wrap(err, "failed to search API keys", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	return toDomainSlice(keys), total, nil
+}
+
+// Delete elimina una API key de la base de datos. Participa de la
+// transacción del contexto, si la hay.
 func (r *PostgresAPIKeyRepository) Delete(ctx context.Context, id string, tenantID kernel.TenantID) error {
 	query := `DELETE FROM api_keys WHERE id = $1 AND tenant_id = $2`
-	result, err := r.db.ExecContext(ctx, query, id, tenantID.String())
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, id, tenantID.String())
 	if err != nil {
 		return errx.Wrap(err, "failed to delete API key", errx.TypeInternal)
 	}
@@ -185,6 +240,18 @@ func (r *PostgresAPIKeyRepository) UpdateLastUsed(ctx context.Context, id string
 	return nil
 }
 
+// DeactivateExpired desactiva las API keys activas cuya fecha de expiración ya pasó.
+func (r *PostgresAPIKeyRepository) DeactivateExpired(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE api_keys SET is_active = false, updated_at = NOW()
+		WHERE is_active = true AND expires_at IS NOT NULL AND expires_at <= NOW()`
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, errx.Wrap(err, "failed to deactivate expired API keys", errx.TypeInternal)
+	}
+	return result.RowsAffected()
+}
+
 func (r *PostgresAPIKeyRepository) keyExists(ctx context.Context, id string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM api_keys WHERE id = $1)`
@@ -0,0 +1,39 @@
+package apikeyinfra
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/redis/go-redis/v9"
+)
+
+const lastUsedKeyPrefix = "apikey:last_used:"
+
+// RedisLastUsedThrottle limita las escrituras de last_used_at con una llave
+// por API key que expira tras el intervalo; compartida entre instancias.
+type RedisLastUsedThrottle struct {
+	client   *redis.Client
+	interval time.Duration
+}
+
+// NewRedisLastUsedThrottle crea un throttle que permite una escritura por key cada interval.
+func NewRedisLastUsedThrottle(client *redis.Client, interval time.Duration) apikey.LastUsedThrottle {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &RedisLastUsedThrottle{
+		client:   client,
+		interval: interval,
+	}
+}
+
+// Allow retorna true solo para la primera llamada de cada intervalo.
+func (t *RedisLastUsedThrottle) Allow(ctx context.Context, keyID string) (bool, error) {
+	acquired, err := t.client.SetNX(ctx, lastUsedKeyPrefix+keyID, 1, t.interval).Result()
+	if err != nil {
+		return false, errx.Wrap(err, "failed to check API key last-used throttle", errx.TypeInternal)
+	}
+	return acquired, nil
+}
@@ -2,32 +2,52 @@ package apikeysrv
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
 	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
 	"github.com/Abraxas-365/manifesto/internal/iam/user"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
 	"github.com/google/uuid"
 )
 
+// Pagination limits for API key searches
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// lastUsedWriteTimeout bounds the asynchronous last_used_at write
+const lastUsedWriteTimeout = 5 * time.Second
+
 type APIKeyService struct {
-	apiKeyRepo apikey.APIKeyRepository
-	tenantRepo tenant.TenantRepository
-	userRepo   user.UserRepository
+	apiKeyRepo    apikey.APIKeyRepository
+	tenantRepo    tenant.TenantRepository
+	userRepo      user.UserRepository
+	auditRecorder audit.Recorder
+
+	// lastUsedThrottle debounces last_used_at writes; nil writes on every use
+	lastUsedThrottle apikey.LastUsedThrottle
 }
 
 func NewAPIKeyService(
 	apiKeyRepo apikey.APIKeyRepository,
 	tenantRepo tenant.TenantRepository,
 	userRepo user.UserRepository,
+	auditRecorder audit.Recorder,
+	lastUsedThrottle apikey.LastUsedThrottle,
 ) *APIKeyService {
 	return &APIKeyService{
-		apiKeyRepo: apiKeyRepo,
-		tenantRepo: tenantRepo,
-		userRepo:   userRepo,
+		apiKeyRepo:       apiKeyRepo,
+		tenantRepo:       tenantRepo,
+		userRepo:         userRepo,
+		auditRecorder:    auditRecorder,
+		lastUsedThrottle: lastUsedThrottle,
 	}
 }
 
@@ -45,7 +65,7 @@ func (s *APIKeyService) CreateAPIKey(
 		return nil, tenant.ErrTenantSuspended()
 	}
 
-	_, err = s.userRepo.FindByID(ctx, creatorID, tenantID)
+	creator, err := s.userRepo.FindByID(ctx, creatorID, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +81,13 @@ func (s *APIKeyService) CreateAPIKey(
 		return nil, err
 	}
 
+	// A key can never carry more than its creator holds
+	if missing := scopesNotHeldBy(creator, req.Scopes); len(missing) > 0 {
+		return nil, apikey.ErrAPIKeyInsufficientScope().
+			WithDetail("scopes", missing).
+			WithDetail("reason", "cannot grant scopes the creator does not have")
+	}
+
 	var prefix string
 	if req.Environment == "live" {
 		prefix = apikey.KeyPrefixLive
@@ -98,6 +125,13 @@ func (s *APIKeyService) CreateAPIKey(
 		return nil, errx.Wrap(err, "failed to save API key", errx.TypeInternal)
 	}
 
+	event := audit.NewEvent(tenantID, audit.ActionAPIKeyCreated, audit.ResourceAPIKey, newKey.ID).
+		WithMetadata("name", newKey.Name).
+		WithMetadata("scopes", newKey.Scopes).
+		WithMetadata("environment", req.Environment)
+	event.ActorUserID = &creatorID
+	s.auditRecorder.Record(ctx, event)
+
 	return &apikey.CreateAPIKeyResponse{
 		APIKey:    newKey.ToDTO(),
 		SecretKey: generated.Key,
@@ -139,10 +173,54 @@ func (s *APIKeyService) GetTenantAPIKeys(
 	}, nil
 }
 
+// SearchAPIKeys returns one page of the tenant's API keys. Keys are ordered by
+// creation date unless filter.OrderBy asks for last use.
+func (s *APIKeyService) SearchAPIKeys(
+	ctx context.Context,
+	tenantID kernel.TenantID,
+	filter apikey.APIKeySearchFilter,
+) (*apikey.APIKeySearchResponse, error) {
+	if filter.OrderBy == "" {
+		filter.OrderBy = apikey.APIKeyOrderByCreatedAt
+	}
+	if !filter.OrderBy.IsValid() {
+		return nil, errx.Validation("invalid order_by").
+			WithDetail("order_by", filter.OrderBy).
+			WithDetail("allowed", []apikey.APIKeyOrderBy{apikey.APIKeyOrderByCreatedAt, apikey.APIKeyOrderByLastUsedAt})
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	keys, total, err := s.apiKeyRepo.Search(ctx, tenantID, filter)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to search API keys", errx.TypeInternal)
+	}
+
+	dtos := make([]apikey.APIKeyDTO, 0, len(keys))
+	for _, key := range keys {
+		dtos = append(dtos, key.ToDTO())
+	}
+
+	return &apikey.APIKeySearchResponse{
+		APIKeys: dtos,
+		Total:   total,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}, nil
+}
+
 func (s *APIKeyService) UpdateAPIKey(
 	ctx context.Context,
 	keyID string,
 	tenantID kernel.TenantID,
+	actorID kernel.UserID,
 	req apikey.UpdateAPIKeyRequest,
 ) (*apikey.APIKeyDTO, error) {
 	key, err := s.apiKeyRepo.FindByID(ctx, keyID, tenantID)
@@ -156,10 +234,25 @@ func (s *APIKeyService) UpdateAPIKey(
 	if req.Description != nil {
 		key.Description = *req.Description
 	}
+	previousScopes := key.Scopes
+	wasActive := key.IsActive
+
 	if req.Scopes != nil {
 		if err := s.validateScopes(req.Scopes); err != nil {
 			return nil, err
 		}
+
+		// Same rule as on creation: whoever edits the key cannot grant it
+		// scopes they do not hold
+		actor, err := s.userRepo.FindByID(ctx, actorID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if missing := scopesNotHeldBy(actor, req.Scopes); len(missing) > 0 {
+			return nil, apikey.ErrAPIKeyInsufficientScope().
+				WithDetail("scopes", missing).
+				WithDetail("reason", "cannot grant scopes the editor does not have")
+		}
 		key.Scopes = req.Scopes
 	}
 	if req.IsActive != nil {
@@ -172,6 +265,15 @@ func (s *APIKeyService) UpdateAPIKey(
 		return nil, errx.Wrap(err, "failed to update API key", errx.TypeInternal)
 	}
 
+	if req.Scopes != nil && !slices.Equal(previousScopes, key.Scopes) {
+		s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionAPIKeyScopesChanged, audit.ResourceAPIKey, key.ID).
+			WithMetadata("previous_scopes", previousScopes).
+			WithMetadata("scopes", key.Scopes))
+	}
+	if wasActive && !key.IsActive {
+		s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionAPIKeyRevoked, audit.ResourceAPIKey, key.ID))
+	}
+
 	dto := key.ToDTO()
 	return &dto, nil
 }
@@ -186,7 +288,13 @@ func (s *APIKeyService) RevokeAPIKey(
 	}
 
 	key.Revoke()
-	return s.apiKeyRepo.Save(ctx, *key)
+	if err := s.apiKeyRepo.Save(ctx, *key); err != nil {
+		return err
+	}
+
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionAPIKeyRevoked, audit.ResourceAPIKey, key.ID).
+		WithMetadata("name", key.Name))
+	return nil
 }
 
 func (s *APIKeyService) DeleteAPIKey(
@@ -202,6 +310,20 @@ func (s *APIKeyService) DeleteAPIKey(
 	return s.apiKeyRepo.Delete(ctx, keyID, tenantID)
 }
 
+// scopesNotHeldBy returns the requested scopes the creator cannot delegate.
+// A scope is delegable when the creator holds it directly or through a
+// wildcard, the same way scopes.HasScopeIn matches: "jobs:*" covers "jobs:read"
+// and "*" covers everything, but "jobs:read" does not cover "jobs:*".
+func scopesNotHeldBy(creator *user.User, requested []string) []string {
+	var missing []string
+	for _, scope := range requested {
+		if !creator.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
 func (s *APIKeyService) validateScopes(scopesList []string) error {
 	if len(scopesList) == 0 {
 		return errx.New("at least one scope is required", errx.TypeValidation)
@@ -231,9 +353,12 @@ func (s *APIKeyService) ValidateAPIKey(
 		return nil, apikey.ErrAPIKeyInvalid()
 	}
 
+	// The lookup is by hash, so the secret itself is never compared; the
+	// hash is re-checked in constant time in case the store (or a cache in
+	// front of it) matched loosely
 	keyHash := apikey.HashAPIKey(keyString)
 	key, err := s.apiKeyRepo.FindByHash(ctx, keyHash)
-	if err != nil {
+	if err != nil || !key.MatchesHash(keyHash) {
 		return nil, apikey.ErrAPIKeyNotFound()
 	}
 
@@ -244,7 +369,32 @@ func (s *APIKeyService) ValidateAPIKey(
 		return nil, apikey.ErrAPIKeyRevoked()
 	}
 
-	go s.apiKeyRepo.UpdateLastUsed(context.Background(), key.ID)
-
 	return key, nil
 }
+
+// RecordUsage persists the last use of a key in the background so it adds no
+// latency to the request. With a throttle, at most one write per key is made
+// per interval; failures are logged and never affect the caller.
+func (s *APIKeyService) RecordUsage(keyID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), lastUsedWriteTimeout)
+		defer cancel()
+
+		if s.lastUsedThrottle != nil {
+			allowed, err := s.lastUsedThrottle.Allow(ctx, keyID)
+			if err != nil {
+				logx.WithFields(logx.Fields{"api_key_id": keyID}).
+					Warnf("last-used throttle unavailable, skipping write: %v", err)
+				return
+			}
+			if !allowed {
+				return
+			}
+		}
+
+		if err := s.apiKeyRepo.UpdateLastUsed(ctx, keyID); err != nil {
+			logx.WithFields(logx.Fields{"api_key_id": keyID}).
+				Errorf("failed to update API key last use: %v", err)
+		}
+	}()
+}
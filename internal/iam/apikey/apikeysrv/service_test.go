@@ -0,0 +1,135 @@
+package apikeysrv
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+func TestScopesNotHeldBy(t *testing.T) {
+	tests := []struct {
+		name          string
+		creatorScopes []string
+		requested     []string
+		want          []string
+	}{
+		{
+			name:          "exact scopes",
+			creatorScopes: []string{"jobs:read", "users:read"},
+			requested:     []string{"jobs:read"},
+		},
+		{
+			name:          "wildcard covers specific scope",
+			creatorScopes: []string{"jobs:*"},
+			requested:     []string{"jobs:read", "jobs:write"},
+		},
+		{
+			name:          "wildcard covers itself",
+			creatorScopes: []string{"jobs:*"},
+			requested:     []string{"jobs:*"},
+		},
+		{
+			name:          "wildcard covers nested scope",
+			creatorScopes: []string{"jobs:*"},
+			requested:     []string{"jobs:runs:cancel"},
+		},
+		{
+			name:          "global wildcard grants anything",
+			creatorScopes: []string{"*"},
+			requested:     []string{"*", "admin:*", "jobs:read"},
+		},
+		{
+			name:          "specific scope does not cover wildcard",
+			creatorScopes: []string{"jobs:read"},
+			requested:     []string{"jobs:*"},
+			want:          []string{"jobs:*"},
+		},
+		{
+			name:          "wildcard does not cover other resource",
+			creatorScopes: []string{"jobs:*"},
+			requested:     []string{"jobs:read", "jobsx:read", "users:read"},
+			want:          []string{"jobsx:read", "users:read"},
+		},
+		{
+			name:          "admin wildcard does not grant global wildcard",
+			creatorScopes: []string{"admin:*"},
+			requested:     []string{"*"},
+			want:          []string{"*"},
+		},
+		{
+			name:          "creator without scopes",
+			creatorScopes: nil,
+			requested:     []string{"jobs:read"},
+			want:          []string{"jobs:read"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creator := &user.User{Scopes: tt.creatorScopes}
+
+			got := scopesNotHeldBy(creator, tt.requested)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("scopesNotHeldBy(%v, %v) = %v, want %v", tt.creatorScopes, tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+// memoryAPIKeyRepo implements the parts of apikey.APIKeyRepository used by UpdateAPIKey
+type memoryAPIKeyRepo struct {
+	apikey.APIKeyRepository
+	keys map[string]apikey.APIKey
+}
+
+func (r *memoryAPIKeyRepo) FindByID(_ context.Context, id string, tenantID kernel.TenantID) (*apikey.APIKey, error) {
+	key, ok := r.keys[id]
+	if !ok || key.TenantID != tenantID {
+		return nil, apikey.ErrAPIKeyNotFound()
+	}
+	return &key, nil
+}
+
+func (r *memoryAPIKeyRepo) Save(_ context.Context, key apikey.APIKey) error {
+	r.keys[key.ID] = key
+	return nil
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) Record(context.Context, audit.AuditEvent) {}
+
+func TestUpdateAPIKeyRejectsScopesTheEditorLacks(t *testing.T) {
+	ctx := context.Background()
+
+	users := userinfra.NewInMemoryUserRepository()
+	editor := user.User{ID: "u1", TenantID: "t1", Email: "dev@example.com", Scopes: []string{"users:read"}}
+	if err := users.Save(ctx, editor); err != nil {
+		t.Fatalf("Save user: %v", err)
+	}
+
+	keys := &memoryAPIKeyRepo{keys: map[string]apikey.APIKey{
+		"k1": {ID: "k1", TenantID: "t1", Scopes: []string{"users:read"}, IsActive: true},
+	}}
+	svc := NewAPIKeyService(keys, nil, users, noopRecorder{}, nil)
+
+	_, err := svc.UpdateAPIKey(ctx, "k1", "t1", editor.ID, apikey.UpdateAPIKeyRequest{Scopes: []string{"*"}})
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != apikey.CodeAPIKeyInsufficientScope.Code {
+		t.Fatalf("UpdateAPIKey(*) error = %v, want INSUFFICIENT_SCOPE", err)
+	}
+	if got := keys.keys["k1"].Scopes; !slices.Equal(got, []string{"users:read"}) {
+		t.Errorf("key scopes after rejected update = %v, want unchanged", got)
+	}
+
+	if _, err := svc.UpdateAPIKey(ctx, "k1", "t1", editor.ID, apikey.UpdateAPIKeyRequest{Scopes: []string{"users:read"}}); err != nil {
+		t.Errorf("UpdateAPIKey with held scopes: %v", err)
+	}
+}
@@ -12,6 +12,18 @@ type APIKeyRepository interface {
 	FindByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*APIKey, error)
 	FindActiveByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*APIKey, error)
 	FindByUser(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID) ([]*APIKey, error)
+	// Search returns one page of the tenant's keys matching filter and the total count
+	Search(ctx context.Context, tenantID kernel.TenantID, filter APIKeySearchFilter) ([]*APIKey, int, error)
 	Delete(ctx context.Context, id string, tenantID kernel.TenantID) error
 	UpdateLastUsed(ctx context.Context, id string) error
+	// DeactivateExpired deactivates every active key past its ExpiresAt and
+	// returns how many were deactivated
+	DeactivateExpired(ctx context.Context) (int64, error)
+}
+
+// LastUsedThrottle limits how often the last use of a key is persisted
+type LastUsedThrottle interface {
+	// Allow reports whether the last use of keyID may be written now. It
+	// returns true at most once per interval per key.
+	Allow(ctx context.Context, keyID string) (bool, error)
 }
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// ============================================================================
+// Entity
+// ============================================================================
+
+// Action identifica la transición auditada, con forma "<recurso>.<verbo>"
+type Action string
+
+const (
+	ActionLoginSucceeded    Action = "auth.login_succeeded"
+	ActionLoginFailed       Action = "auth.login_failed"
+	ActionLogout            Action = "auth.logout"
+	ActionTokenRefreshed    Action = "auth.token_refreshed"
+	ActionSessionRevoked    Action = "auth.session_revoked"
+	ActionAccountCreated    Action = "user.created"
+	ActionAccountLinked     Action = "user.linked"
+	ActionAccountUnlinked   Action = "user.unlinked"
+	ActionUserActivated     Action = "user.activated"
+	ActionUserSuspended     Action = "user.suspended"
+	ActionUserScopesChanged Action = "user.scopes_changed"
+	ActionPasswordReset     Action = "user.password_reset"
+	ActionUserErased        Action = "user.erased"
+
+	ActionInvitationCreated  Action = "invitation.created"
+	ActionInvitationResent   Action = "invitation.resent"
+	ActionInvitationRevoked  Action = "invitation.revoked"
+	ActionInvitationAccepted Action = "invitation.accepted"
+
+	ActionAPIKeyCreated       Action = "api_key.created"
+	ActionAPIKeyRevoked       Action = "api_key.revoked"
+	ActionAPIKeyScopesChanged Action = "api_key.scopes_changed"
+
+	ActionTenantExported Action = "tenant.exported"
+	ActionUserExported   Action = "user.exported"
+)
+
+// Actions lista la taxonomía completa. Los webhooks validan sus suscripciones
+// contra ella, así que toda acción nueva debe agregarse aquí.
+var Actions = []Action{
+	ActionLoginSucceeded,
+	ActionLoginFailed,
+	ActionLogout,
+	ActionTokenRefreshed,
+	ActionSessionRevoked,
+	ActionAccountCreated,
+	ActionAccountLinked,
+	ActionAccountUnlinked,
+	ActionUserActivated,
+	ActionUserSuspended,
+	ActionUserScopesChanged,
+	ActionPasswordReset,
+	ActionUserErased,
+	ActionInvitationCreated,
+	ActionInvitationResent,
+	ActionInvitationRevoked,
+	ActionInvitationAccepted,
+	ActionAPIKeyCreated,
+	ActionAPIKeyRevoked,
+	ActionAPIKeyScopesChanged,
+	ActionTenantExported,
+	ActionUserExported,
+}
+
+// IsKnown indica si la acción pertenece a la taxonomía
+func (a Action) IsKnown() bool {
+	return slices.Contains(Actions, a)
+}
+
+// Tipos de recurso sobre los que actúan los eventos
+const (
+	ResourceUser       = "user"
+	ResourceInvitation = "invitation"
+	ResourceAPIKey     = "api_key"
+	ResourceSession    = "session"
+	ResourceTenant     = "tenant"
+)
+
+// AuditEvent registra quién hizo qué sobre qué recurso dentro de un tenant.
+// Los eventos son inmutables: solo se insertan y se consultan.
+type AuditEvent struct {
+	ID            string          `json:"id"`
+	TenantID      kernel.TenantID `json:"tenant_id"`
+	ActorUserID   *kernel.UserID  `json:"actor_user_id,omitempty"`
+	ActorAPIKeyID *string         `json:"actor_api_key_id,omitempty"`
+	Action        Action          `json:"action"`
+	ResourceType  string          `json:"resource_type"`
+	ResourceID    string          `json:"resource_id"`
+	Metadata      map[string]any  `json:"metadata,omitempty"`
+	IP            string          `json:"ip,omitempty"`
+	UserAgent     string          `json:"user_agent,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// NewEvent crea un evento para un recurso del tenant. El ID, la fecha y el
+// actor los completa el Recorder.
+func NewEvent(tenantID kernel.TenantID, action Action, resourceType, resourceID string) AuditEvent {
+	return AuditEvent{
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Metadata:     make(map[string]any),
+	}
+}
+
+// WithMetadata agrega un dato al evento
+func (e AuditEvent) WithMetadata(key string, value any) AuditEvent {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]any)
+	}
+	e.Metadata[key] = value
+	return e
+}
+
+// ============================================================================
+// Actor
+// ============================================================================
+
+// Actor es quien origina un evento: un usuario, una API key o ambos (una API
+// key asociada a un usuario), junto con los datos de la petición
+type Actor struct {
+	UserID    *kernel.UserID
+	APIKeyID  *string
+	IP        string
+	UserAgent string
+}
+
+type actorContextKey struct{}
+
+// WithActor adjunta el actor al contexto para que los servicios lo registren
+// en los eventos que emitan
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext obtiene el actor adjuntado con WithActor
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(Actor)
+	return actor, ok
+}
+
+// ============================================================================
+// Query DTOs
+// ============================================================================
+
+// EventFilter define los criterios de búsqueda paginada de eventos
+type EventFilter struct {
+	Action        Action         `json:"action,omitempty"`
+	ResourceType  string         `json:"resource_type,omitempty"`
+	ResourceID    string         `json:"resource_id,omitempty"`
+	ActorUserID   *kernel.UserID `json:"actor_user_id,omitempty"`
+	CreatedAfter  *time.Time     `json:"created_after,omitempty"`
+	CreatedBefore *time.Time     `json:"created_before,omitempty"`
+	Limit         int            `json:"limit"`
+	Offset        int            `json:"offset"`
+}
+
+// EventListResponse es el resultado paginado de una búsqueda de eventos,
+// del más reciente al más antiguo
+type EventListResponse struct {
+	Events []AuditEvent `json:"events"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// ============================================================================
+// Error Registry
+// ============================================================================
+
+var ErrRegistry = errx.NewRegistry("AUDIT")
+
+var (
+	CodeInvalidFilter = ErrRegistry.Register("INVALID_FILTER", errx.TypeValidation, http.StatusBadRequest, "Invalid audit event filter")
+)
+
+func ErrInvalidFilter() *errx.Error {
+	return ErrRegistry.New(CodeInvalidFilter)
+}
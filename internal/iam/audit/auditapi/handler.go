@@ -0,0 +1,91 @@
+package auditapi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit/auditsrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandlers struct {
+	service *auditsrv.AuditService
+}
+
+func NewAuditHandlers(service *auditsrv.AuditService) *AuditHandlers {
+	return &AuditHandlers{service: service}
+}
+
+func (h *AuditHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	events := router.Group("/audit-events", authMiddleware.Authenticate())
+
+	events.Get("/", authMiddleware.RequireAdminOrScope(scopes.ScopeAuditRead), h.ListEvents)
+}
+
+// ListEvents lists the audit events of the caller's tenant, newest first.
+//
+// Query params: action, resource_type, resource_id, actor_user_id,
+// created_after, created_before (RFC3339), limit, offset.
+func (h *AuditHandlers) ListEvents(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.service.ListEvents(c.Context(), authContext.TenantID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+func parseEventFilter(c *fiber.Ctx) (audit.EventFilter, error) {
+	filter := audit.EventFilter{
+		Action:       audit.Action(strings.TrimSpace(c.Query("action"))),
+		ResourceType: strings.TrimSpace(c.Query("resource_type")),
+		ResourceID:   strings.TrimSpace(c.Query("resource_id")),
+		Limit:        c.QueryInt("limit", 0),
+		Offset:       c.QueryInt("offset", 0),
+	}
+
+	if raw := strings.TrimSpace(c.Query("actor_user_id")); raw != "" {
+		actorID := kernel.UserID(raw)
+		filter.ActorUserID = &actorID
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseOptionalTime(c, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseOptionalTime(c, "created_before"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func parseOptionalTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, audit.ErrInvalidFilter().
+			WithDetail(key, raw).
+			WithDetail("reason", "invalid date, expected RFC3339")
+	}
+	return &value, nil
+}
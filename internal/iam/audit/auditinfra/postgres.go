@@ -0,0 +1,172 @@
+package auditinfra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresAuditRepository implementación de PostgreSQL para AuditRepository
+type PostgresAuditRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresAuditRepository crea una nueva instancia del repositorio de auditoría
+func NewPostgresAuditRepository(db *sqlx.DB) audit.AuditRepository {
+	return &PostgresAuditRepository{
+		db: db,
+	}
+}
+
+// auditEventDB es la representación de un evento en la tabla audit_events
+type auditEventDB struct {
+	ID            string          `db:"id"`
+	TenantID      kernel.TenantID `db:"tenant_id"`
+	ActorUserID   *kernel.UserID  `db:"actor_user_id"`
+	ActorAPIKeyID *string         `db:"actor_api_key_id"`
+	Action        string          `db:"action"`
+	ResourceType  string          `db:"resource_type"`
+	ResourceID    string          `db:"resource_id"`
+	Metadata      []byte          `db:"metadata"`
+	IP            string          `db:"ip"`
+	UserAgent     string          `db:"user_agent"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
+
+// Save inserta un evento de auditoría
+func (r *PostgresAuditRepository) Save(ctx context.Context, event audit.AuditEvent) error {
+	metadata := []byte("{}")
+	if len(event.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(event.Metadata); err != nil {
+			return errx.Wrap(err, "failed to encode audit metadata", errx.TypeInternal).
+				WithDetail("action", string(event.Action))
+		}
+	}
+
+	query := `
+		INSERT INTO audit_events (
+			id, tenant_id, actor_user_id, actor_api_key_id, action,
+			resource_type, resource_id, metadata, ip, user_agent, created_at
+		) VALUES (
+			:id, :tenant_id, :actor_user_id, :actor_api_key_id, :action,
+			:resource_type, :resource_id, :metadata, :ip, :user_agent, :created_at
+		)`
+
+	_, err := r.db.NamedExecContext(ctx, query, auditEventDB{
+		ID:            event.ID,
+		TenantID:      event.TenantID,
+		ActorUserID:   event.ActorUserID,
+		ActorAPIKeyID: event.ActorAPIKeyID,
+		Action:        string(event.Action),
+		ResourceType:  event.ResourceType,
+		ResourceID:    event.ResourceID,
+		Metadata:      metadata,
+		IP:            event.IP,
+		UserAgent:     event.UserAgent,
+		CreatedAt:     event.CreatedAt,
+	})
+	if err != nil {
+		return errx.Wrap(err, "failed to save audit event", errx.TypeInternal).
+			WithDetail("action", string(event.Action))
+	}
+
+	return nil
+}
+
+// Search busca eventos de un tenant con filtros, del más reciente al más antiguo
+func (r *PostgresAuditRepository) Search(ctx context.Context, tenantID kernel.TenantID, filter audit.EventFilter) ([]*audit.AuditEvent, int, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []any{tenantID.String()}
+
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.Action != "" {
+		addCondition("action = $%d", string(filter.Action))
+	}
+	if filter.ResourceType != "" {
+		addCondition("resource_type = $%d", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		addCondition("resource_id = $%d", filter.ResourceID)
+	}
+	if filter.ActorUserID != nil {
+		addCondition("actor_user_id = $%d", filter.ActorUserID.String())
+	}
+	if filter.CreatedAfter != nil {
+		addCondition("created_at >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		addCondition("created_at <= $%d", *filter.CreatedBefore)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM audit_events WHERE ` + where
+	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, errx.Wrap(err, "failed to count audit events", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	query := `
+		SELECT
+			id, tenant_id, actor_user_id, actor_api_key_id, action,
+			resource_type, resource_id, metadata, ip, user_agent, created_at
+		FROM audit_events
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id DESC` +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	var rows []auditEventDB
+	err := r.db.SelectContext(ctx, &rows, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, errx.Wrap(err, "failed to search audit events", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	result := make([]*audit.AuditEvent, len(rows))
+	for i := range rows {
+		event, err := rows[i].toDomain()
+		if err != nil {
+			return nil, 0, err
+		}
+		result[i] = event
+	}
+
+	return result, total, nil
+}
+
+func (e auditEventDB) toDomain() (*audit.AuditEvent, error) {
+	var metadata map[string]any
+	if len(e.Metadata) > 0 {
+		if err := json.Unmarshal(e.Metadata, &metadata); err != nil {
+			return nil, errx.Wrap(err, "failed to decode audit metadata", errx.TypeInternal).
+				WithDetail("event_id", e.ID)
+		}
+	}
+
+	return &audit.AuditEvent{
+		ID:            e.ID,
+		TenantID:      e.TenantID,
+		ActorUserID:   e.ActorUserID,
+		ActorAPIKeyID: e.ActorAPIKeyID,
+		Action:        audit.Action(e.Action),
+		ResourceType:  e.ResourceType,
+		ResourceID:    e.ResourceID,
+		Metadata:      metadata,
+		IP:            e.IP,
+		UserAgent:     e.UserAgent,
+		CreatedAt:     e.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package auditsrv
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// Los siguientes métodos implementan auth.AuditService, de modo que los
+// handlers de autenticación persisten sus eventos en el log de auditoría.
+
+func (s *AuditService) LogLoginAttempt(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, success bool, ip string, userAgent string) {
+	action := audit.ActionLoginSucceeded
+	if !success {
+		action = audit.ActionLoginFailed
+	}
+
+	event := audit.NewEvent(tenantID, action, audit.ResourceUser, userID.String()).
+		WithMetadata("method", method)
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	event.UserAgent = userAgent
+	s.Record(ctx, event)
+}
+
+func (s *AuditService) LogLogout(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, ip string) {
+	event := audit.NewEvent(tenantID, audit.ActionLogout, audit.ResourceUser, userID.String())
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	s.Record(ctx, event)
+}
+
+func (s *AuditService) LogTokenRefresh(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, ip string) {
+	event := audit.NewEvent(tenantID, audit.ActionTokenRefreshed, audit.ResourceUser, userID.String())
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	s.Record(ctx, event)
+}
+
+// LogOTPVerification no recibe tenant, por lo que el evento no puede
+// asociarse a un log de auditoría y solo se registra en el log de la
+// aplicación, con el contacto enmascarado
+func (s *AuditService) LogOTPVerification(_ context.Context, contact string, success bool, ip string) {
+	logx.WithFields(logx.Fields{
+		"audit_event": "otp_verification",
+		"contact":     otp.MaskContact(contact),
+		"success":     success,
+		"ip":          ip,
+	}).Info("Audit: OTP verification")
+}
+
+func (s *AuditService) LogAccountCreated(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, ip string) {
+	event := audit.NewEvent(tenantID, audit.ActionAccountCreated, audit.ResourceUser, userID.String()).
+		WithMetadata("method", method)
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	s.Record(ctx, event)
+}
+
+func (s *AuditService) LogAccountLinked(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, ip string) {
+	event := audit.NewEvent(tenantID, audit.ActionAccountLinked, audit.ResourceUser, userID.String()).
+		WithMetadata("method", method)
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	s.Record(ctx, event)
+}
+
+func (s *AuditService) LogAccountUnlinked(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, ip string) {
+	event := audit.NewEvent(tenantID, audit.ActionAccountUnlinked, audit.ResourceUser, userID.String()).
+		WithMetadata("method", method)
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	s.Record(ctx, event)
+}
+
+func (s *AuditService) LogInvitationAccepted(ctx context.Context, invitationID string, userID kernel.UserID, tenantID kernel.TenantID, ip string) {
+	event := audit.NewEvent(tenantID, audit.ActionInvitationAccepted, audit.ResourceInvitation, invitationID)
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	s.Record(ctx, event)
+}
+
+func (s *AuditService) LogPasswordReset(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, ip string) {
+	event := audit.NewEvent(tenantID, audit.ActionPasswordReset, audit.ResourceUser, userID.String())
+	event.ActorUserID = userActor(userID)
+	event.IP = ip
+	s.Record(ctx, event)
+}
+
+// userActor devuelve nil para intentos fallidos donde el usuario no se conoce
+func userActor(userID kernel.UserID) *kernel.UserID {
+	if userID.IsEmpty() {
+		return nil
+	}
+	return &userID
+}
@@ -0,0 +1,110 @@
+package auditsrv
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/google/uuid"
+)
+
+// Límites de paginación para la consulta de eventos
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// AuditService registra y consulta eventos de auditoría
+type AuditService struct {
+	repo        audit.AuditRepository
+	subscribers []audit.Subscriber
+}
+
+// NewAuditService crea una nueva instancia del servicio de auditoría
+func NewAuditService(repo audit.AuditRepository) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+// Subscribe agrega un suscriptor que recibe cada evento registrado. Se llama
+// al construir el contenedor, antes de registrar eventos.
+func (s *AuditService) Subscribe(subscriber audit.Subscriber) {
+	s.subscribers = append(s.subscribers, subscriber)
+}
+
+// Record persiste un evento completando ID, fecha y actor (si el contexto lo
+// trae y el evento no lo define) y lo entrega a los suscriptores. Los errores
+// se registran en el log y no se propagan, para no fallar la operación auditada.
+func (s *AuditService) Record(ctx context.Context, event audit.AuditEvent) {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	if actor, ok := audit.ActorFromContext(ctx); ok {
+		if event.ActorUserID == nil {
+			event.ActorUserID = actor.UserID
+		}
+		if event.ActorAPIKeyID == nil {
+			event.ActorAPIKeyID = actor.APIKeyID
+		}
+		if event.IP == "" {
+			event.IP = actor.IP
+		}
+		if event.UserAgent == "" {
+			event.UserAgent = actor.UserAgent
+		}
+	}
+
+	if err := s.repo.Save(ctx, event); err != nil {
+		logx.WithFields(logx.Fields{
+			"audit_event":   event.Action,
+			"tenant_id":     event.TenantID,
+			"resource_type": event.ResourceType,
+			"resource_id":   event.ResourceID,
+		}).Errorf("failed to record audit event: %v", err)
+	}
+
+	for _, subscriber := range s.subscribers {
+		subscriber.Notify(ctx, event)
+	}
+}
+
+// ListEvents busca eventos de un tenant con filtros y paginación
+func (s *AuditService) ListEvents(ctx context.Context, tenantID kernel.TenantID, filter audit.EventFilter) (*audit.EventListResponse, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && filter.CreatedAfter.After(*filter.CreatedBefore) {
+		return nil, audit.ErrInvalidFilter().WithDetail("reason", "created_after must be before created_before")
+	}
+
+	events, total, err := s.repo.Search(ctx, tenantID, filter)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to list audit events", errx.TypeInternal)
+	}
+
+	result := make([]audit.AuditEvent, 0, len(events))
+	for _, e := range events {
+		result = append(result, *e)
+	}
+
+	return &audit.EventListResponse{
+		Events: result,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
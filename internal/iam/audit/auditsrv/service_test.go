@@ -0,0 +1,170 @@
+package auditsrv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// memoryAudit keeps saved events and the last search filter
+type memoryAudit struct {
+	events    []audit.AuditEvent
+	filter    audit.EventFilter
+	saveErr   error
+	searchErr error
+}
+
+func (r *memoryAudit) Save(_ context.Context, event audit.AuditEvent) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryAudit) Search(_ context.Context, _ kernel.TenantID, filter audit.EventFilter) ([]*audit.AuditEvent, int, error) {
+	r.filter = filter
+	if r.searchErr != nil {
+		return nil, 0, r.searchErr
+	}
+	events := make([]*audit.AuditEvent, len(r.events))
+	for i := range r.events {
+		events[i] = &r.events[i]
+	}
+	return events, len(events), nil
+}
+
+type recordingSubscriber struct {
+	events []audit.AuditEvent
+}
+
+func (s *recordingSubscriber) Notify(_ context.Context, event audit.AuditEvent) {
+	s.events = append(s.events, event)
+}
+
+func TestRecord(t *testing.T) {
+	repo := &memoryAudit{}
+	subscriber := &recordingSubscriber{}
+	s := NewAuditService(repo)
+	s.Subscribe(subscriber)
+
+	actorID, keyID := kernel.UserID("u1"), "key-1"
+	ctx := audit.WithActor(context.Background(), audit.Actor{UserID: &actorID, APIKeyID: &keyID, IP: "203.0.113.7", UserAgent: "curl"})
+
+	s.Record(ctx, audit.NewEvent("t1", audit.ActionUserSuspended, audit.ResourceUser, "u2"))
+	if len(repo.events) != 1 {
+		t.Fatalf("saved %d events, want 1", len(repo.events))
+	}
+	event := repo.events[0]
+	if event.ID == "" || event.CreatedAt.IsZero() {
+		t.Errorf("event without ID or date: %+v", event)
+	}
+	if event.ActorUserID == nil || *event.ActorUserID != "u1" || event.ActorAPIKeyID == nil || *event.ActorAPIKeyID != "key-1" {
+		t.Errorf("event actor = %v / %v, want the context actor", event.ActorUserID, event.ActorAPIKeyID)
+	}
+	if event.IP != "203.0.113.7" || event.UserAgent != "curl" {
+		t.Errorf("event request data = %q %q, want the context's", event.IP, event.UserAgent)
+	}
+
+	// Fields set on the event win over the context
+	other := kernel.UserID("u9")
+	explicit := audit.NewEvent("t1", audit.ActionLogout, audit.ResourceUser, "u9")
+	explicit.ID = "event-1"
+	explicit.ActorUserID = &other
+	explicit.IP = "198.51.100.1"
+	s.Record(ctx, explicit)
+	if got := repo.events[1]; got.ID != "event-1" || *got.ActorUserID != "u9" || got.IP != "198.51.100.1" {
+		t.Errorf("explicit event = %+v, want its own ID, actor and IP", got)
+	}
+
+	// A failed save does not reach the caller and subscribers still see the event
+	repo.saveErr = errors.New("connection reset")
+	s.Record(context.Background(), audit.NewEvent("t1", audit.ActionLogout, audit.ResourceUser, "u3"))
+	if len(subscriber.events) != 3 {
+		t.Errorf("subscriber got %d events, want 3", len(subscriber.events))
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryAudit{}
+	s := NewAuditService(repo)
+
+	tests := []struct {
+		filter               audit.EventFilter
+		wantLimit, wantStart int
+	}{
+		{audit.EventFilter{}, defaultListLimit, 0},
+		{audit.EventFilter{Limit: 10, Offset: 20}, 10, 20},
+		{audit.EventFilter{Limit: 1000, Offset: -5}, maxListLimit, 0},
+	}
+	for _, tt := range tests {
+		result, err := s.ListEvents(ctx, "t1", tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repo.filter.Limit != tt.wantLimit || repo.filter.Offset != tt.wantStart || result.Limit != tt.wantLimit || result.Offset != tt.wantStart {
+			t.Errorf("ListEvents(%+v) searched %+v, want limit %d offset %d", tt.filter, repo.filter, tt.wantLimit, tt.wantStart)
+		}
+	}
+
+	after, before := time.Now(), time.Now().Add(-time.Hour)
+	if _, err := s.ListEvents(ctx, "t1", audit.EventFilter{CreatedAfter: &after, CreatedBefore: &before}); !errx.Is(err, audit.CodeInvalidFilter) {
+		t.Errorf("inverted date range error = %v, want INVALID_FILTER", err)
+	}
+
+	repo.searchErr = errors.New("connection reset")
+	_, err := s.ListEvents(ctx, "t1", audit.EventFilter{})
+	if e, ok := errx.AsError(err); !ok || e.Type != errx.TypeInternal {
+		t.Errorf("failing search error = %v, want an internal error", err)
+	}
+}
+
+func TestLogLoginAttempt(t *testing.T) {
+	repo := &memoryAudit{}
+	s := NewAuditService(repo)
+
+	s.LogLoginAttempt(context.Background(), "u1", "t1", "password", true, "203.0.113.7", "curl")
+	s.LogLoginAttempt(context.Background(), "", "t1", "password", false, "203.0.113.7", "curl")
+
+	ok, failed := repo.events[0], repo.events[1]
+	if ok.Action != audit.ActionLoginSucceeded || ok.ActorUserID == nil || *ok.ActorUserID != "u1" || ok.Metadata["method"] != "password" {
+		t.Errorf("successful login event = %+v", ok)
+	}
+	if failed.Action != audit.ActionLoginFailed || failed.ActorUserID != nil {
+		t.Errorf("failed login event = %+v, want no actor for an unknown user", failed)
+	}
+}
+
+func TestLogOTPVerificationMasksContact(t *testing.T) {
+	var out bytes.Buffer
+	config := logx.DefaultConfig()
+	config.Format = logx.FormatJSON
+	logger := logx.NewLogger(config)
+	logger.SetOutput(&out)
+	saved := logx.GetDefaultLogger()
+	logx.SetDefaultLogger(logger)
+	t.Cleanup(func() { logx.SetDefaultLogger(saved) })
+
+	s := NewAuditService(&memoryAudit{})
+	s.LogOTPVerification(context.Background(), "ana@acme.com", false, "203.0.113.7")
+	s.LogOTPVerification(context.Background(), "+15550100", true, "203.0.113.7")
+
+	logged := out.String()
+	for _, contact := range []string{"ana@acme.com", "+15550100"} {
+		if strings.Contains(logged, contact) {
+			t.Errorf("log contains the full contact %q: %s", contact, logged)
+		}
+	}
+	if !strings.Contains(logged, "a***@acme.com") || !strings.Contains(logged, "***00") {
+		t.Errorf("log does not contain the masked contacts: %s", logged)
+	}
+}
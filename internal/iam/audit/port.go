@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// AuditRepository persiste y consulta eventos de auditoría
+type AuditRepository interface {
+	Save(ctx context.Context, event AuditEvent) error
+	Search(ctx context.Context, tenantID kernel.TenantID, filter EventFilter) ([]*AuditEvent, int, error)
+}
+
+// Recorder registra eventos de auditoría. Registrar nunca falla la operación
+// auditada: los errores de persistencia solo se registran en el log.
+type Recorder interface {
+	Record(ctx context.Context, event AuditEvent)
+}
+
+// Subscriber recibe cada evento que pasa por el Recorder (p. ej. los webhooks
+// de los tenants). Igual que Record, Notify nunca falla la operación auditada.
+type Subscriber interface {
+	Notify(ctx context.Context, event AuditEvent)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemoryActivityThrottle implementación en memoria del ActivityThrottle, para
+// una sola instancia. Las llaves vencidas se purgan al registrar nuevas.
+type InMemoryActivityThrottle struct {
+	interval  time.Duration
+	mu        sync.Mutex
+	lastSeen  map[string]time.Time
+	lastPurge time.Time
+}
+
+// NewInMemoryActivityThrottle crea un throttle que permite una escritura por llave cada interval
+func NewInMemoryActivityThrottle(interval time.Duration) *InMemoryActivityThrottle {
+	return &InMemoryActivityThrottle{
+		interval:  interval,
+		lastSeen:  make(map[string]time.Time),
+		lastPurge: time.Now(),
+	}
+}
+
+// Allow retorna true solo para la primera llamada de cada intervalo
+func (t *InMemoryActivityThrottle) Allow(_ context.Context, key string) (bool, error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastPurge) > t.interval {
+		for k, seen := range t.lastSeen {
+			if now.Sub(seen) >= t.interval {
+				delete(t.lastSeen, k)
+			}
+		}
+		t.lastPurge = now
+	}
+
+	if seen, ok := t.lastSeen[key]; ok && now.Sub(seen) < t.interval {
+		return false, nil
+	}
+
+	t.lastSeen[key] = now
+	return true, nil
+}
@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/errx"
@@ -21,14 +25,33 @@ type RefreshToken struct {
 	ExpiresAt time.Time       `db:"expires_at" json:"expires_at"`
 	CreatedAt time.Time       `db:"created_at" json:"created_at"`
 	IsRevoked bool            `db:"is_revoked" json:"is_revoked"`
+	// SessionID links the token to the login session it was issued for;
+	// empty for tokens issued before sessions were tracked per token
+	SessionID string `db:"session_id" json:"session_id,omitempty"`
+	// RevokedReason tells why the token was revoked; empty while it is active
+	// and for tokens revoked before the reason was recorded
+	RevokedReason RevokedReason `db:"revoked_reason" json:"revoked_reason,omitempty"`
 }
 
+// RevokedReason tells why a refresh token was revoked
+type RevokedReason string
+
+const (
+	// RevokedReasonRotated: the token was exchanged for a new one by a refresh.
+	// Presenting it again means it was copied, so it triggers reuse detection.
+	RevokedReasonRotated RevokedReason = "ROTATED"
+	// RevokedReasonSignedOut: the token was revoked on purpose (logout,
+	// session sign-out or eviction, reuse response). Presenting it again is
+	// just an invalid token.
+	RevokedReasonSignedOut RevokedReason = "SIGNED_OUT"
+)
+
 // UserSession represents a user session
 type UserSession struct {
 	ID           string          `db:"id" json:"id"`
 	UserID       kernel.UserID   `db:"user_id" json:"user_id"`
 	TenantID     kernel.TenantID `db:"tenant_id" json:"tenant_id"`
-	SessionToken string          `db:"session_token" json:"session_token"`
+	SessionToken string          `db:"session_token" json:"-"`
 	IPAddress    string          `db:"ip_address" json:"ip_address"`
 	UserAgent    string          `db:"user_agent" json:"user_agent"`
 	ExpiresAt    time.Time       `db:"expires_at" json:"expires_at"`
@@ -36,14 +59,28 @@ type UserSession struct {
 	LastActivity time.Time       `db:"last_activity" json:"last_activity"`
 }
 
-// PasswordResetToken represents a password reset token
+// SessionDTO is a session as shown to its owner; the session token is never exposed
+type SessionDTO struct {
+	ID           string    `json:"id"`
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActivity time.Time `json:"last_activity"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	// Current marks the session of the access token making the request
+	Current bool `json:"current"`
+}
+
+// PasswordResetToken represents a password reset token. Token holds the
+// SHA-256 of the value sent to the user (see HashResetToken), never the value itself.
 type PasswordResetToken struct {
-	ID        string        `db:"id" json:"id"`
-	Token     string        `db:"token" json:"token"`
-	UserID    kernel.UserID `db:"user_id" json:"user_id"`
-	ExpiresAt time.Time     `db:"expires_at" json:"expires_at"`
-	CreatedAt time.Time     `db:"created_at" json:"created_at"`
-	IsUsed    bool          `db:"is_used" json:"is_used"`
+	ID        string          `db:"id" json:"id"`
+	Token     string          `db:"token" json:"-"`
+	UserID    kernel.UserID   `db:"user_id" json:"user_id"`
+	TenantID  kernel.TenantID `db:"tenant_id" json:"tenant_id"`
+	ExpiresAt time.Time       `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	IsUsed    bool            `db:"is_used" json:"is_used"`
 }
 
 // TokenClaims represents JWT claims
@@ -55,6 +92,75 @@ type TokenClaims struct {
 	Scopes    []string        `json:"scopes"`
 	IssuedAt  time.Time       `json:"iat"`
 	ExpiresAt time.Time       `json:"exp"`
+	NotBefore time.Time       `json:"nbf"`
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  []string        `json:"aud"`
+	TokenID   string          `json:"jti,omitempty"`
+	SessionID string          `json:"sid,omitempty"`
+}
+
+// TokenIntrospection is the result of introspecting an access token, after
+// RFC 7662. Inactive tokens (invalid signature, expired, not yet valid) only
+// carry Active=false.
+type TokenIntrospection struct {
+	Active    bool
+	Claims    *TokenClaims
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+}
+
+// ExpiresIn returns the remaining lifetime of an active token
+func (t *TokenIntrospection) ExpiresIn() time.Duration {
+	if !t.Active {
+		return 0
+	}
+	return max(time.Until(t.ExpiresAt), 0)
+}
+
+// IntrospectionResponse is the RFC 7662 response body. Times are Unix seconds.
+type IntrospectionResponse struct {
+	Active    bool            `json:"active"`
+	Scope     string          `json:"scope,omitempty"`
+	TokenType string          `json:"token_type,omitempty"`
+	Subject   string          `json:"sub,omitempty"`
+	Issuer    string          `json:"iss,omitempty"`
+	Audience  []string        `json:"aud,omitempty"`
+	ExpiresAt int64           `json:"exp,omitempty"`
+	IssuedAt  int64           `json:"iat,omitempty"`
+	NotBefore int64           `json:"nbf,omitempty"`
+	TokenID   string          `json:"jti,omitempty"`
+	UserID    kernel.UserID   `json:"user_id,omitempty"`
+	TenantID  kernel.TenantID `json:"tenant_id,omitempty"`
+	Email     string          `json:"email,omitempty"`
+	Name      string          `json:"name,omitempty"`
+}
+
+// ToResponse converts the introspection to its RFC 7662 representation
+func (t *TokenIntrospection) ToResponse() IntrospectionResponse {
+	if !t.Active || t.Claims == nil {
+		return IntrospectionResponse{Active: false}
+	}
+
+	resp := IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(t.Claims.Scopes, " "),
+		TokenType: "Bearer",
+		Subject:   t.Claims.Subject,
+		Issuer:    t.Claims.Issuer,
+		Audience:  t.Claims.Audience,
+		ExpiresAt: t.ExpiresAt.Unix(),
+		IssuedAt:  t.IssuedAt.Unix(),
+		TokenID:   t.Claims.TokenID,
+		UserID:    t.Claims.UserID,
+		TenantID:  t.Claims.TenantID,
+		Email:     t.Claims.Email,
+		Name:      t.Claims.Name,
+	}
+	if !t.Claims.NotBefore.IsZero() {
+		resp.NotBefore = t.Claims.NotBefore.Unix()
+	}
+	return resp
 }
 
 // ============================================================================
@@ -71,16 +177,39 @@ func (r *RefreshToken) IsValid() bool {
 	return !r.IsRevoked && !r.IsExpired()
 }
 
+// WasRotated checks if the token was revoked because a refresh replaced it
+func (r *RefreshToken) WasRotated() bool {
+	return r.IsRevoked && r.RevokedReason == RevokedReasonRotated
+}
+
 // IsExpired checks if the session has expired
 func (s *UserSession) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
 }
 
+// IsIdle checks if the session has had no activity for longer than timeout
+func (s *UserSession) IsIdle(timeout time.Duration) bool {
+	return timeout > 0 && time.Since(s.LastActivity) > timeout
+}
+
 // UpdateActivity updates the session's last activity
 func (s *UserSession) UpdateActivity() {
 	s.LastActivity = time.Now()
 }
 
+// ToDTO converts the session for its owner, flagging it when it is currentSessionID
+func (s *UserSession) ToDTO(currentSessionID string) SessionDTO {
+	return SessionDTO{
+		ID:           s.ID,
+		IPAddress:    s.IPAddress,
+		UserAgent:    s.UserAgent,
+		CreatedAt:    s.CreatedAt,
+		LastActivity: s.LastActivity,
+		ExpiresAt:    s.ExpiresAt,
+		Current:      currentSessionID != "" && s.ID == currentSessionID,
+	}
+}
+
 // IsExpired checks if the reset token has expired
 func (p *PasswordResetToken) IsExpired() bool {
 	return time.Now().After(p.ExpiresAt)
@@ -96,6 +225,13 @@ func (p *PasswordResetToken) MarkAsUsed() {
 	p.IsUsed = true
 }
 
+// HashResetToken returns the value stored for a password reset token, so a
+// leaked table does not hand out working reset links
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // ============================================================================
 // Error Registry
 // ============================================================================
@@ -108,9 +244,22 @@ var (
 	CodeInvalidOAuthProvider     = ErrRegistry.Register("INVALID_OAUTH_PROVIDER", errx.TypeValidation, http.StatusBadRequest, "Invalid OAuth provider")
 	CodeOAuthAuthorizationFailed = ErrRegistry.Register("OAUTH_AUTHORIZATION_FAILED", errx.TypeExternal, http.StatusBadRequest, "OAuth authorization failed")
 	CodeInvalidState             = ErrRegistry.Register("INVALID_STATE", errx.TypeValidation, http.StatusBadRequest, "Invalid OAuth state")
+	CodeStateAlreadyUsed         = ErrRegistry.Register("STATE_ALREADY_USED", errx.TypeValidation, http.StatusBadRequest, "OAuth state was already used")
 	CodeTokenGenerationFailed    = ErrRegistry.Register("TOKEN_GENERATION_FAILED", errx.TypeInternal, http.StatusInternalServerError, "Token generation failed")
 	CodeTokenValidationFailed    = ErrRegistry.Register("TOKEN_VALIDATION_FAILED", errx.TypeAuthorization, http.StatusUnauthorized, "Token validation failed")
 	CodeOAuthCallbackError       = ErrRegistry.Register("OAUTH_CALLBACK_ERROR", errx.TypeExternal, http.StatusBadRequest, "OAuth callback error")
+	CodeInvalidIDToken           = ErrRegistry.Register("INVALID_ID_TOKEN", errx.TypeAuthorization, http.StatusUnauthorized, "Invalid OIDC id_token")
+	CodeRefreshTokenReused       = ErrRegistry.Register("REFRESH_TOKEN_REUSED", errx.TypeAuthorization, http.StatusUnauthorized, "Refresh token reuse detected, all sessions revoked")
+	CodeRateLimited              = ErrRegistry.Register("RATE_LIMITED", errx.TypeBusiness, http.StatusTooManyRequests, "Too many requests, please try again later")
+	CodeRateLimiterUnavailable   = ErrRegistry.Register("RATE_LIMITER_UNAVAILABLE", errx.TypeExternal, http.StatusServiceUnavailable, "Rate limiter unavailable")
+	CodeInvalidIssuer            = ErrRegistry.Register("INVALID_ISSUER", errx.TypeAuthorization, http.StatusUnauthorized, "Token was not issued by this service")
+	CodeSessionNotFound          = ErrRegistry.Register("SESSION_NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Session not found")
+	CodeInvalidAudience          = ErrRegistry.Register("INVALID_AUDIENCE", errx.TypeAuthorization, http.StatusUnauthorized, "Token is not intended for this service")
+	CodeTooManySessions          = ErrRegistry.Register("TOO_MANY_SESSIONS", errx.TypeBusiness, http.StatusConflict, "Maximum number of active sessions reached")
+	CodeOAuthTimeout             = ErrRegistry.Register("OAUTH_TIMEOUT", errx.TypeExternal, http.StatusGatewayTimeout, "OAuth provider did not respond in time")
+	CodeInvalidIdempotencyKey    = ErrRegistry.Register("INVALID_IDEMPOTENCY_KEY", errx.TypeValidation, http.StatusBadRequest, "Invalid Idempotency-Key header")
+	CodeIdempotencyKeyReused     = ErrRegistry.Register("IDEMPOTENCY_KEY_REUSED", errx.TypeConflict, http.StatusConflict, "Idempotency-Key was already used with a different request")
+	CodeIdempotencyInProgress    = ErrRegistry.Register("IDEMPOTENCY_IN_PROGRESS", errx.TypeConflict, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
 )
 
 // Helper functions
@@ -134,6 +283,12 @@ func ErrInvalidState() *errx.Error {
 	return ErrRegistry.New(CodeInvalidState)
 }
 
+// ErrStateAlreadyUsed reports a callback replaying a state that was already
+// redeemed
+func ErrStateAlreadyUsed() *errx.Error {
+	return ErrRegistry.New(CodeStateAlreadyUsed)
+}
+
 func ErrTokenGenerationFailed() *errx.Error {
 	return ErrRegistry.New(CodeTokenGenerationFailed)
 }
@@ -142,6 +297,89 @@ func ErrTokenValidationFailed() *errx.Error {
 	return ErrRegistry.New(CodeTokenValidationFailed)
 }
 
+// ErrOAuthTimeout reports that the IdP did not answer within the operation or
+// connection timeout; unlike ErrOAuthAuthorizationFailed it is safe to retry
+func ErrOAuthTimeout() *errx.Error {
+	return ErrRegistry.New(CodeOAuthTimeout)
+}
+
 func ErrOAuthCallbackError() *errx.Error {
 	return ErrRegistry.New(CodeOAuthCallbackError)
 }
+
+func ErrInvalidIDToken() *errx.Error {
+	return ErrRegistry.New(CodeInvalidIDToken)
+}
+
+func ErrRefreshTokenReused() *errx.Error {
+	return ErrRegistry.New(CodeRefreshTokenReused)
+}
+
+func ErrSessionNotFound() *errx.Error {
+	return ErrRegistry.New(CodeSessionNotFound)
+}
+
+func ErrTooManySessions() *errx.Error {
+	return ErrRegistry.New(CodeTooManySessions)
+}
+
+func ErrInvalidIssuer() *errx.Error {
+	return ErrRegistry.New(CodeInvalidIssuer)
+}
+
+func ErrInvalidAudience() *errx.Error {
+	return ErrRegistry.New(CodeInvalidAudience)
+}
+
+// ErrRateLimited reports that a rate limit rule was exceeded; retry_after_seconds
+// tells the client how long to wait
+func ErrRateLimited(retryAfter time.Duration) *errx.Error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return ErrRegistry.New(CodeRateLimited).WithDetail("retry_after_seconds", seconds)
+}
+
+func ErrInvalidIdempotencyKey() *errx.Error {
+	return ErrRegistry.New(CodeInvalidIdempotencyKey)
+}
+
+func ErrIdempotencyKeyReused() *errx.Error {
+	return ErrRegistry.New(CodeIdempotencyKeyReused)
+}
+
+func ErrIdempotencyInProgress() *errx.Error {
+	return ErrRegistry.New(CodeIdempotencyInProgress)
+}
+
+func ErrRateLimiterUnavailable(cause error) *errx.Error {
+	return ErrRegistry.NewWithCause(CodeRateLimiterUnavailable, cause)
+}
+
+// IsRefreshTokenReused reports whether err means a revoked refresh token was presented again
+func IsRefreshTokenReused(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeRefreshTokenReused.Code
+}
+
+// IsInvalidIssuer reports whether err means a token carried a foreign iss
+func IsInvalidIssuer(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeInvalidIssuer.Code
+}
+
+// IsInvalidAudience reports whether err means a token's aud does not include this service
+func IsInvalidAudience(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeInvalidAudience.Code
+}
+
+// IsTooManySessions reports whether err means a login was refused by the session limit
+func IsTooManySessions(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeTooManySessions.Code
+}
+
+// IsOAuthTimeout reports whether err means the IdP timed out
+func IsOAuthTimeout(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeOAuthTimeout.Code
+}
@@ -3,17 +3,86 @@ package authinfra
 import (
 	"context"
 	"log"
+	"math/rand/v2"
 	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 )
 
+// cleanupLockKey es la llave del lock distribuido compartida por todas las instancias
+const cleanupLockKey = "iam:cleanup:lock"
+
 // CleanupService servicio de limpieza en background
 type CleanupService struct {
 	tokenRepo         auth.TokenRepository
 	sessionRepo       auth.SessionRepository
 	passwordResetRepo auth.PasswordResetRepository
 	interval          time.Duration
+
+	// Desactivación de API keys expiradas (opcional)
+	apiKeyRepo apikey.APIKeyRepository
+
+	// Expiración de tenants con trial o suscripción vencida (opcional)
+	tenantRepo tenant.TenantRepository
+
+	// Expiración de sesiones inactivas (opcional)
+	sessionIdleTimeout time.Duration
+
+	// Coordinación entre instancias (opcional)
+	redis   *redis.Client
+	lockTTL time.Duration
+	jitter  time.Duration
+	owner   string
+}
+
+// CleanupOption configura el CleanupService
+type CleanupOption func(*CleanupService)
+
+// WithLeaderLock hace que solo la instancia que obtiene el lock en Redis ejecute
+// cada pasada. El lock no se libera al terminar: expira tras ttl (medio
+// intervalo si ttl es 0), así las demás instancias omiten esa misma pasada.
+// ttl debe ser mayor que el jitter y menor que intervalo - jitter.
+func WithLeaderLock(client *redis.Client, ttl time.Duration) CleanupOption {
+	return func(s *CleanupService) {
+		s.redis = client
+		s.lockTTL = ttl
+	}
+}
+
+// WithJitter retrasa cada pasada un tiempo aleatorio en [0, max) para evitar
+// que todas las instancias golpeen la base de datos al mismo tiempo
+func WithJitter(max time.Duration) CleanupOption {
+	return func(s *CleanupService) {
+		s.jitter = max
+	}
+}
+
+// WithExpiredAPIKeyDeactivation desactiva en cada pasada las API keys cuya
+// fecha de expiración ya pasó
+func WithExpiredAPIKeyDeactivation(repo apikey.APIKeyRepository) CleanupOption {
+	return func(s *CleanupService) {
+		s.apiKeyRepo = repo
+	}
+}
+
+// WithTenantExpiry pasa en cada pasada a EXPIRED los tenants cuyo trial o
+// suscripción vencieron, así Tenant.IsActive refleja el vencimiento
+func WithTenantExpiry(repo tenant.TenantRepository) CleanupOption {
+	return func(s *CleanupService) {
+		s.tenantRepo = repo
+	}
+}
+
+// WithIdleSessionExpiry elimina en cada pasada las sesiones sin actividad
+// durante más de idleTimeout (expiración deslizante)
+func WithIdleSessionExpiry(idleTimeout time.Duration) CleanupOption {
+	return func(s *CleanupService) {
+		s.sessionIdleTimeout = idleTimeout
+	}
 }
 
 // NewCleanupService crea un nuevo servicio de limpieza
@@ -22,22 +91,35 @@ func NewCleanupService(
 	sessionRepo auth.SessionRepository,
 	passwordResetRepo auth.PasswordResetRepository,
 	interval time.Duration,
+	opts ...CleanupOption,
 ) *CleanupService {
-	return &CleanupService{
+	s := &CleanupService{
 		tokenRepo:         tokenRepo,
 		sessionRepo:       sessionRepo,
 		passwordResetRepo: passwordResetRepo,
 		interval:          interval,
+		owner:             uuid.NewString(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.lockTTL <= 0 {
+		s.lockTTL = interval / 2
 	}
+
+	return s
 }
 
-// Start inicia el servicio de limpieza
+// Start inicia el servicio de limpieza. Bloquea hasta que ctx se cancela; una
+// pasada ya iniciada termina antes de retornar.
 func (s *CleanupService) Start(ctx context.Context) {
 	ticker := time.NewTicker(s.interval)
 	defer ticker.Stop()
 
 	// Ejecutar limpieza inicial
-	s.runCleanup(ctx)
+	s.scheduleCleanup(ctx)
 
 	for {
 		select {
@@ -45,9 +127,35 @@ func (s *CleanupService) Start(ctx context.Context) {
 			log.Println("Cleanup service stopped")
 			return
 		case <-ticker.C:
-			s.runCleanup(ctx)
+			s.scheduleCleanup(ctx)
+		}
+	}
+}
+
+// scheduleCleanup aplica el jitter y el lock de líder antes de limpiar
+func (s *CleanupService) scheduleCleanup(ctx context.Context) {
+	if s.jitter > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rand.N(s.jitter)):
+		}
+	}
+
+	if s.redis != nil {
+		acquired, err := s.redis.SetNX(ctx, cleanupLockKey, s.owner, s.lockTTL).Result()
+		if err != nil {
+			log.Printf("Error acquiring cleanup lock, skipping pass: %v", err)
+			return
+		}
+		if !acquired {
+			log.Println("Cleanup pass already handled by another instance")
+			return
 		}
 	}
+
+	// La pasada no se interrumpe a mitad si ctx se cancela durante el shutdown
+	s.runCleanup(context.WithoutCancel(ctx))
 }
 
 // runCleanup ejecuta las tareas de limpieza
@@ -64,10 +172,40 @@ func (s *CleanupService) runCleanup(ctx context.Context) {
 		log.Printf("Error cleaning expired sessions: %v", err)
 	}
 
+	// Limpiar sesiones inactivas
+	if s.sessionIdleTimeout > 0 {
+		removed, err := s.sessionRepo.CleanIdleSessions(ctx, s.sessionIdleTimeout)
+		if err != nil {
+			log.Printf("Error cleaning idle sessions: %v", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d idle sessions", removed)
+		}
+	}
+
 	// Limpiar tokens de reset expirados
 	if err := s.passwordResetRepo.CleanExpiredResetTokens(ctx); err != nil {
 		log.Printf("Error cleaning expired reset tokens: %v", err)
 	}
 
+	// Desactivar API keys expiradas
+	if s.apiKeyRepo != nil {
+		deactivated, err := s.apiKeyRepo.DeactivateExpired(ctx)
+		if err != nil {
+			log.Printf("Error deactivating expired API keys: %v", err)
+		} else if deactivated > 0 {
+			log.Printf("Deactivated %d expired API keys", deactivated)
+		}
+	}
+
+	// Expirar tenants vencidos
+	if s.tenantRepo != nil {
+		expired, err := s.tenantRepo.ExpireLapsed(ctx)
+		if err != nil {
+			log.Printf("Error expiring lapsed tenants: %v", err)
+		} else if len(expired) > 0 {
+			log.Printf("Expired %d tenants with a lapsed trial or subscription", len(expired))
+		}
+	}
+
 	log.Println("Cleanup tasks completed")
 }
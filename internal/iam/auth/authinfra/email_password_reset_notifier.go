@@ -0,0 +1,105 @@
+package authinfra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+)
+
+var passwordResetEmailHTML = htmltemplate.Must(htmltemplate.New("password_reset_html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <p>We received a request to reset your {{.AppName}} password.</p>
+  <p><a href="{{.ResetURL}}" style="display: inline-block; padding: 10px 18px; background: #2563eb; color: #fff; text-decoration: none; border-radius: 4px;">Reset password</a></p>
+  <p>If the button doesn't work, copy this link into your browser:<br>{{.ResetURL}}</p>
+  <p>The link expires in {{.ExpiresIn}}. If you didn't ask for a new password, you can ignore this email.</p>
+</body>
+</html>`))
+
+var passwordResetEmailText = texttemplate.Must(texttemplate.New("password_reset_text").Parse(`We received a request to reset your {{.AppName}} password.
+
+Reset it here:
+{{.ResetURL}}
+
+The link expires in {{.ExpiresIn}}. If you didn't ask for a new password, you can ignore this email.
+`))
+
+type passwordResetEmailData struct {
+	AppName   string
+	ResetURL  string
+	ExpiresIn string
+}
+
+// EmailPasswordResetNotifier sends password reset links through any
+// notifx.EmailSender (SMTP, SES or console)
+type EmailPasswordResetNotifier struct {
+	sender notifx.EmailSender
+	cfg    *config.EmailConfig
+}
+
+// NewEmailPasswordResetNotifier creates the notifier. cfg provides the sender
+// address and the application name shown in the email.
+func NewEmailPasswordResetNotifier(sender notifx.EmailSender, cfg *config.EmailConfig) *EmailPasswordResetNotifier {
+	return &EmailPasswordResetNotifier{
+		sender: sender,
+		cfg:    cfg,
+	}
+}
+
+// SendPasswordReset renders the reset email and sends it to email
+func (n *EmailPasswordResetNotifier) SendPasswordReset(ctx context.Context, email, resetURL string, expiresAt time.Time) error {
+	data := passwordResetEmailData{
+		AppName:   n.cfg.FromName,
+		ResetURL:  resetURL,
+		ExpiresIn: formatExpiresIn(time.Until(expiresAt)),
+	}
+
+	var html, text bytes.Buffer
+	if err := passwordResetEmailHTML.Execute(&html, data); err != nil {
+		return errx.Wrap(err, "failed to render password reset email", errx.TypeInternal)
+	}
+	if err := passwordResetEmailText.Execute(&text, data); err != nil {
+		return errx.Wrap(err, "failed to render password reset email", errx.TypeInternal)
+	}
+
+	msg := notifx.EmailMessage{
+		From:     notifx.FormatAddress(n.cfg.FromName, n.cfg.FromAddress),
+		To:       []string{email},
+		ReplyTo:  n.cfg.ReplyTo,
+		Subject:  "Reset your password",
+		HTMLBody: html.String(),
+		TextBody: text.String(),
+	}
+
+	if err := n.sender.SendEmail(ctx, msg); err != nil {
+		return errx.Wrap(err, "failed to send password reset email", errx.TypeExternal)
+	}
+
+	return nil
+}
+
+var _ auth.PasswordResetNotifier = (*EmailPasswordResetNotifier)(nil)
+
+// formatExpiresIn renders the link lifetime as "1 hour" or "15 minutes"
+func formatExpiresIn(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	switch {
+	case minutes >= 60 && minutes%60 == 0:
+		if minutes == 60 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", minutes/60)
+	case minutes == 1:
+		return "1 minute"
+	default:
+		return fmt.Sprintf("%d minutes", max(minutes, 1))
+	}
+}
@@ -0,0 +1,60 @@
+package authinfra
+
+import (
+	"context"
+	"mime"
+	"strings"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/fsx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+)
+
+// profilePhotoDir es la carpeta de las fotos de perfil dentro del FileSystem
+const profilePhotoDir = "avatars"
+
+// photoExtensions son las extensiones de los tipos de imagen que envían los IdP
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// FSXProfilePhotoStore guarda las fotos de perfil de los IdP en un
+// fsx.FileSystem como avatars/{provider}/{id}{ext}. publicBaseURL es la URL
+// desde la que se sirve el FileSystem (un bucket o CDN público).
+type FSXProfilePhotoStore struct {
+	fs            fsx.FileSystem
+	publicBaseURL string
+}
+
+// NewFSXProfilePhotoStore crea el store de fotos de perfil
+func NewFSXProfilePhotoStore(fs fsx.FileSystem, publicBaseURL string) *FSXProfilePhotoStore {
+	return &FSXProfilePhotoStore{
+		fs:            fs,
+		publicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+	}
+}
+
+// SaveProfilePhoto sobrescribe la foto del usuario y retorna su URL pública
+func (s *FSXProfilePhotoStore) SaveProfilePhoto(ctx context.Context, provider iam.OAuthProvider, providerUserID, contentType string, data []byte) (string, error) {
+	if providerUserID == "" || strings.ContainsAny(providerUserID, "/\\") || strings.Contains(providerUserID, "..") {
+		return "", errx.Validation("invalid provider user ID").WithDetail("provider", string(provider))
+	}
+
+	ext := ".jpg"
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if known, ok := photoExtensions[mediaType]; ok {
+			ext = known
+		}
+	}
+
+	path := s.fs.Join(profilePhotoDir, strings.ToLower(string(provider)), providerUserID+ext)
+	if err := s.fs.WriteFile(ctx, path, data); err != nil {
+		return "", errx.Wrap(err, "failed to store profile photo", errx.TypeExternal).
+			WithDetail("provider", string(provider))
+	}
+
+	return s.publicBaseURL + "/" + strings.TrimPrefix(path, "/"), nil
+}
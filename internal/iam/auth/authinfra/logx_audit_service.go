@@ -79,3 +79,35 @@ func (s *LogxAuditService) LogAccountLinked(_ context.Context, userID kernel.Use
 		"timestamp":   time.Now(),
 	}).Info("Audit: account linked")
 }
+
+func (s *LogxAuditService) LogAccountUnlinked(_ context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, ip string) {
+	logx.WithFields(logx.Fields{
+		"audit_event": "account_unlinked",
+		"user_id":     userID,
+		"tenant_id":   tenantID,
+		"method":      method,
+		"ip":          ip,
+		"timestamp":   time.Now(),
+	}).Info("Audit: account unlinked")
+}
+
+func (s *LogxAuditService) LogInvitationAccepted(_ context.Context, invitationID string, userID kernel.UserID, tenantID kernel.TenantID, ip string) {
+	logx.WithFields(logx.Fields{
+		"audit_event":   "invitation_accepted",
+		"invitation_id": invitationID,
+		"user_id":       userID,
+		"tenant_id":     tenantID,
+		"ip":            ip,
+		"timestamp":     time.Now(),
+	}).Info("Audit: invitation accepted")
+}
+
+func (s *LogxAuditService) LogPasswordReset(_ context.Context, userID kernel.UserID, tenantID kernel.TenantID, ip string) {
+	logx.WithFields(logx.Fields{
+		"audit_event": "password_reset",
+		"user_id":     userID,
+		"tenant_id":   tenantID,
+		"ip":          ip,
+		"timestamp":   time.Now(),
+	}).Info("Audit: password reset")
+}
@@ -3,9 +3,12 @@ package authinfra
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -25,9 +28,9 @@ func NewPostgresPasswordResetRepository(db *sqlx.DB) auth.PasswordResetRepositor
 func (r *PostgresPasswordResetRepository) SaveResetToken(ctx context.Context, token auth.PasswordResetToken) error {
 	query := `
 		INSERT INTO password_reset_tokens (
-			id, token, user_id, expires_at, created_at, is_used
+			id, token, user_id, tenant_id, expires_at, created_at, is_used
 		) VALUES (
-			:id, :token, :user_id, :expires_at, :created_at, :is_used
+			:id, :token, :user_id, :tenant_id, :expires_at, :created_at, :is_used
 		)`
 
 	_, err := r.db.NamedExecContext(ctx, query, token)
@@ -43,7 +46,7 @@ func (r *PostgresPasswordResetRepository) SaveResetToken(ctx context.Context, to
 func (r *PostgresPasswordResetRepository) FindResetToken(ctx context.Context, tokenValue string) (*auth.PasswordResetToken, error) {
 	query := `
 		SELECT 
-			id, token, user_id, expires_at, created_at, is_used
+			id, token, user_id, tenant_id, expires_at, created_at, is_used
 		FROM password_reset_tokens 
 		WHERE token = $1 AND is_used = false AND expires_at > NOW()`
 
@@ -59,14 +62,15 @@ func (r *PostgresPasswordResetRepository) FindResetToken(ctx context.Context, to
 	return &token, nil
 }
 
-// ConsumeResetToken marca un token como usado
+// ConsumeResetToken marca un token como usado.
+// Participa de la transacción del contexto, si la hay.
 func (r *PostgresPasswordResetRepository) ConsumeResetToken(ctx context.Context, tokenValue string) error {
 	query := `
 		UPDATE password_reset_tokens 
 		SET is_used = true 
 		WHERE token = $1 AND is_used = false AND expires_at > NOW()`
 
-	result, err := r.db.ExecContext(ctx, query, tokenValue)
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, tokenValue)
 	if err != nil {
 		return errx.Wrap(err, "failed to consume reset token", errx.TypeInternal)
 	}
@@ -97,17 +101,18 @@ func (r *PostgresPasswordResetRepository) CleanExpiredResetTokens(ctx context.Co
 	return nil
 }
 
-// RevokeAllUserResetTokens revoca todos los tokens de reset de un usuario
-func (r *PostgresPasswordResetRepository) RevokeAllUserResetTokens(ctx context.Context, userID string) error {
+// RevokeAllUserResetTokens revoca todos los tokens de reset de un usuario.
+// Participa de la transacción del contexto, si la hay.
+func (r *PostgresPasswordResetRepository) RevokeAllUserResetTokens(ctx context.Context, userID kernel.UserID) error {
 	query := `
 		UPDATE password_reset_tokens 
 		SET is_used = true 
 		WHERE user_id = $1 AND is_used = false`
 
-	_, err := r.db.ExecContext(ctx, query, userID)
+	_, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, userID.String())
 	if err != nil {
 		return errx.Wrap(err, "failed to revoke all user reset tokens", errx.TypeInternal).
-			WithDetail("user_id", userID)
+			WithDetail("user_id", userID.String())
 	}
 
 	return nil
@@ -130,23 +135,22 @@ func (r *PostgresPasswordResetRepository) CountActiveResetTokens(ctx context.Con
 	return count, nil
 }
 
-// HasRecentResetToken verifica si un usuario tiene un token reciente (anti-spam)
-func (r *PostgresPasswordResetRepository) HasRecentResetToken(ctx context.Context, userID string, withinMinutes int) (bool, error) {
+// HasRecentResetToken verifica si un usuario ya pidió maxTokens tokens dentro
+// de la ventana (anti-spam). Cuenta también los usados, para que consumir un
+// token no reinicie el límite.
+func (r *PostgresPasswordResetRepository) HasRecentResetToken(ctx context.Context, userID kernel.UserID, window time.Duration, maxTokens int) (bool, error) {
 	query := `
-		SELECT EXISTS(
-			SELECT 1 
-			FROM password_reset_tokens 
-			WHERE user_id = $1 
-			AND created_at > NOW() - make_interval(mins => $2)
-			AND is_used = false
-		)`
+		SELECT COUNT(*) 
+		FROM password_reset_tokens 
+		WHERE user_id = $1 
+		AND created_at > $2`
 
-	var exists bool
-	err := r.db.GetContext(ctx, &exists, query, userID, withinMinutes)
+	var count int
+	err := r.db.GetContext(ctx, &count, query, userID.String(), time.Now().Add(-window))
 	if err != nil {
 		return false, errx.Wrap(err, "failed to check recent reset token", errx.TypeInternal).
-			WithDetail("user_id", userID)
+			WithDetail("user_id", userID.String())
 	}
 
-	return exists, nil
+	return count >= maxTokens, nil
 }
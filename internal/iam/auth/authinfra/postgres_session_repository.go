@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
@@ -87,8 +88,8 @@ func (r *PostgresSessionRepository) FindSessionByToken(ctx context.Context, sess
 	return &session, nil
 }
 
-// FindUserSessions busca todas las sesiones activas de un usuario
-func (r *PostgresSessionRepository) FindUserSessions(ctx context.Context, userID kernel.UserID) ([]*auth.UserSession, error) {
+// FindActiveByUser busca todas las sesiones activas de un usuario
+func (r *PostgresSessionRepository) FindActiveByUser(ctx context.Context, userID kernel.UserID) ([]*auth.UserSession, error) {
 	query := `
 		SELECT 
 			id, user_id, tenant_id, session_token, ip_address,
@@ -113,6 +114,31 @@ func (r *PostgresSessionRepository) FindUserSessions(ctx context.Context, userID
 	return result, nil
 }
 
+// FindOldestActiveByUser busca la sesión activa más antigua de un usuario
+func (r *PostgresSessionRepository) FindOldestActiveByUser(ctx context.Context, userID kernel.UserID) (*auth.UserSession, error) {
+	query := `
+		SELECT 
+			id, user_id, tenant_id, session_token, ip_address,
+			user_agent, expires_at, created_at, last_activity
+		FROM user_sessions 
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY created_at ASC
+		LIMIT 1`
+
+	var session auth.UserSession
+	err := r.db.GetContext(ctx, &session, query, userID.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errx.New("session not found", errx.TypeNotFound).
+				WithDetail("user_id", userID.String())
+		}
+		return nil, errx.Wrap(err, "failed to find oldest session", errx.TypeInternal).
+			WithDetail("user_id", userID.String())
+	}
+
+	return &session, nil
+}
+
 // UpdateSessionActivity actualiza la última actividad de una sesión
 func (r *PostgresSessionRepository) UpdateSessionActivity(ctx context.Context, sessionID string) error {
 	query := `
@@ -139,6 +165,30 @@ func (r *PostgresSessionRepository) UpdateSessionActivity(ctx context.Context, s
 	return nil
 }
 
+// Touch registra actividad en una sesión y, con slideBy > 0, desliza su
+// expiración sin superar created_at + maxLifetime
+func (r *PostgresSessionRepository) Touch(ctx context.Context, sessionID string, slideBy, maxLifetime time.Duration) error {
+	query := `
+		UPDATE user_sessions 
+		SET last_activity = NOW(),
+		    expires_at = CASE
+		        WHEN $2 > 0 THEN LEAST(
+		            NOW() + make_interval(secs => $2),
+		            created_at + make_interval(secs => $3)
+		        )
+		        ELSE expires_at
+		    END
+		WHERE id = $1 AND expires_at > NOW()`
+
+	_, err := r.db.ExecContext(ctx, query, sessionID, slideBy.Seconds(), maxLifetime.Seconds())
+	if err != nil {
+		return errx.Wrap(err, "failed to touch session", errx.TypeInternal).
+			WithDetail("session_id", sessionID)
+	}
+
+	return nil
+}
+
 // RevokeSession revoca una sesión específica
 func (r *PostgresSessionRepository) RevokeSession(ctx context.Context, sessionID string) error {
 	query := `DELETE FROM user_sessions WHERE id = $1`
@@ -162,11 +212,12 @@ func (r *PostgresSessionRepository) RevokeSession(ctx context.Context, sessionID
 	return nil
 }
 
-// RevokeAllUserSessions revoca todas las sesiones de un usuario
+// RevokeAllUserSessions revoca todas las sesiones de un usuario. Participa de
+// la transacción del contexto, si la hay.
 func (r *PostgresSessionRepository) RevokeAllUserSessions(ctx context.Context, userID kernel.UserID) error {
 	query := `DELETE FROM user_sessions WHERE user_id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, userID.String())
+	_, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, userID.String())
 	if err != nil {
 		return errx.Wrap(err, "failed to revoke all user sessions", errx.TypeInternal).
 			WithDetail("user_id", userID.String())
@@ -187,6 +238,18 @@ func (r *PostgresSessionRepository) CleanExpiredSessions(ctx context.Context) er
 	return nil
 }
 
+// CleanIdleSessions elimina sesiones sin actividad durante más de idleTimeout
+func (r *PostgresSessionRepository) CleanIdleSessions(ctx context.Context, idleTimeout time.Duration) (int64, error) {
+	query := `DELETE FROM user_sessions WHERE last_activity < NOW() - make_interval(secs => $1)`
+
+	result, err := r.db.ExecContext(ctx, query, idleTimeout.Seconds())
+	if err != nil {
+		return 0, errx.Wrap(err, "failed to clean idle sessions", errx.TypeInternal)
+	}
+
+	return result.RowsAffected()
+}
+
 // ExtendSession extiende la expiración de una sesión
 func (r *PostgresSessionRepository) ExtendSession(ctx context.Context, sessionID string, duration time.Duration) error {
 	query := `
@@ -215,8 +278,8 @@ func (r *PostgresSessionRepository) ExtendSession(ctx context.Context, sessionID
 	return nil
 }
 
-// CountActiveSessions cuenta las sesiones activas de un usuario
-func (r *PostgresSessionRepository) CountActiveSessions(ctx context.Context, userID kernel.UserID) (int, error) {
+// CountActiveByUser cuenta las sesiones activas de un usuario
+func (r *PostgresSessionRepository) CountActiveByUser(ctx context.Context, userID kernel.UserID) (int, error) {
 	query := `
 		SELECT COUNT(*) 
 		FROM user_sessions 
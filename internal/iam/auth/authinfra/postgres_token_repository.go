@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
@@ -26,9 +27,9 @@ func NewPostgresTokenRepository(db *sqlx.DB) auth.TokenRepository {
 func (r *PostgresTokenRepository) SaveRefreshToken(ctx context.Context, token auth.RefreshToken) error {
 	query := `
 		INSERT INTO refresh_tokens (
-			id, token, user_id, tenant_id, expires_at, created_at, is_revoked
+			id, token, user_id, tenant_id, expires_at, created_at, is_revoked, session_id
 		) VALUES (
-			:id, :token, :user_id, :tenant_id, :expires_at, :created_at, :is_revoked
+			:id, :token, :user_id, :tenant_id, :expires_at, :created_at, :is_revoked, NULLIF(:session_id, '')
 		)`
 
 	_, err := r.db.NamedExecContext(ctx, query, token)
@@ -40,13 +41,16 @@ func (r *PostgresTokenRepository) SaveRefreshToken(ctx context.Context, token au
 	return nil
 }
 
-// FindRefreshToken busca un refresh token por su valor
+// FindRefreshToken busca un refresh token por su valor.
+// Devuelve también tokens revocados para poder detectar su reutilización.
 func (r *PostgresTokenRepository) FindRefreshToken(ctx context.Context, tokenValue string) (*auth.RefreshToken, error) {
 	query := `
 		SELECT 
-			id, token, user_id, tenant_id, expires_at, created_at, is_revoked
+			id, token, user_id, tenant_id, expires_at, created_at, is_revoked,
+			COALESCE(session_id, '') AS session_id,
+			COALESCE(revoked_reason, '') AS revoked_reason
 		FROM refresh_tokens 
-		WHERE token = $1 AND is_revoked = false`
+		WHERE token = $1`
 
 	var token auth.RefreshToken
 	err := r.db.GetContext(ctx, &token, query, tokenValue)
@@ -64,10 +68,10 @@ func (r *PostgresTokenRepository) FindRefreshToken(ctx context.Context, tokenVal
 func (r *PostgresTokenRepository) RevokeRefreshToken(ctx context.Context, tokenValue string) error {
 	query := `
 		UPDATE refresh_tokens 
-		SET is_revoked = true 
+		SET is_revoked = true, revoked_reason = COALESCE(revoked_reason, $2)
 		WHERE token = $1`
 
-	result, err := r.db.ExecContext(ctx, query, tokenValue)
+	result, err := r.db.ExecContext(ctx, query, tokenValue, auth.RevokedReasonSignedOut)
 	if err != nil {
 		return errx.Wrap(err, "failed to revoke refresh token", errx.TypeInternal)
 	}
@@ -84,14 +88,72 @@ func (r *PostgresTokenRepository) RevokeRefreshToken(ctx context.Context, tokenV
 	return nil
 }
 
-// RevokeAllUserTokens revoca todos los tokens de un usuario
+// RotateRefreshToken revoca el token usado y guarda su reemplazo en una sola transacción.
+// Si el token ya estaba rotado (otro refresh lo usó primero) devuelve
+// ErrRefreshTokenReused; si fue revocado a propósito, ErrInvalidRefreshToken.
+func (r *PostgresTokenRepository) RotateRefreshToken(ctx context.Context, oldTokenValue string, newToken auth.RefreshToken) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errx.Wrap(err, "failed to begin transaction", errx.TypeInternal)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens 
+		SET is_revoked = true, revoked_reason = $2
+		WHERE token = $1 AND is_revoked = false`, oldTokenValue, auth.RevokedReasonRotated)
+	if err != nil {
+		return errx.Wrap(err, "failed to revoke refresh token", errx.TypeInternal)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errx.Wrap(err, "failed to get rows affected", errx.TypeInternal)
+	}
+
+	if rowsAffected == 0 {
+		// Solo un token ya rotado indica reutilización; uno revocado a
+		// propósito (logout, cierre de sesión) es simplemente inválido
+		var reason string
+		err := tx.GetContext(ctx, &reason, `
+			SELECT COALESCE(revoked_reason, '') FROM refresh_tokens WHERE token = $1`, oldTokenValue)
+		if err != nil && err != sql.ErrNoRows {
+			return errx.Wrap(err, "failed to check revoked refresh token", errx.TypeInternal)
+		}
+		if auth.RevokedReason(reason) != auth.RevokedReasonRotated {
+			return auth.ErrInvalidRefreshToken()
+		}
+		return auth.ErrRefreshTokenReused().
+			WithDetail("user_id", newToken.UserID.String())
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+		INSERT INTO refresh_tokens (
+			id, token, user_id, tenant_id, expires_at, created_at, is_revoked, session_id
+		) VALUES (
+			:id, :token, :user_id, :tenant_id, :expires_at, :created_at, :is_revoked, NULLIF(:session_id, '')
+		)`, newToken)
+	if err != nil {
+		return errx.Wrap(err, "failed to save refresh token", errx.TypeInternal).
+			WithDetail("user_id", newToken.UserID.String())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errx.Wrap(err, "failed to commit refresh token rotation", errx.TypeInternal)
+	}
+
+	return nil
+}
+
+// RevokeAllUserTokens revoca todos los tokens de un usuario. Participa de la
+// transacción del contexto, si la hay.
 func (r *PostgresTokenRepository) RevokeAllUserTokens(ctx context.Context, userID kernel.UserID) error {
 	query := `
 		UPDATE refresh_tokens 
-		SET is_revoked = true 
+		SET is_revoked = true, revoked_reason = $2
 		WHERE user_id = $1 AND is_revoked = false`
 
-	_, err := r.db.ExecContext(ctx, query, userID.String())
+	_, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, userID.String(), auth.RevokedReasonSignedOut)
 	if err != nil {
 		return errx.Wrap(err, "failed to revoke all user tokens", errx.TypeInternal).
 			WithDetail("user_id", userID.String())
@@ -100,11 +162,28 @@ func (r *PostgresTokenRepository) RevokeAllUserTokens(ctx context.Context, userI
 	return nil
 }
 
-// CleanExpiredTokens elimina tokens expirados (para mantenimiento)
+// RevokeSessionTokens revoca los refresh tokens emitidos para una sesión
+func (r *PostgresTokenRepository) RevokeSessionTokens(ctx context.Context, sessionID string) error {
+	query := `
+		UPDATE refresh_tokens 
+		SET is_revoked = true, revoked_reason = $2
+		WHERE session_id = $1 AND is_revoked = false`
+
+	_, err := r.db.ExecContext(ctx, query, sessionID, auth.RevokedReasonSignedOut)
+	if err != nil {
+		return errx.Wrap(err, "failed to revoke session tokens", errx.TypeInternal).
+			WithDetail("session_id", sessionID)
+	}
+
+	return nil
+}
+
+// CleanExpiredTokens elimina tokens expirados (para mantenimiento).
+// Los tokens revocados se conservan hasta expirar para detectar su reutilización.
 func (r *PostgresTokenRepository) CleanExpiredTokens(ctx context.Context) error {
 	query := `
 		DELETE FROM refresh_tokens 
-		WHERE expires_at < NOW() OR is_revoked = true`
+		WHERE expires_at < NOW()`
 
 	_, err := r.db.ExecContext(ctx, query)
 	if err != nil {
@@ -135,7 +214,9 @@ func (r *PostgresTokenRepository) CountActiveTokens(ctx context.Context, userID
 func (r *PostgresTokenRepository) GetActiveTokensByUser(ctx context.Context, userID kernel.UserID) ([]*auth.RefreshToken, error) {
 	query := `
 		SELECT 
-			id, token, user_id, tenant_id, expires_at, created_at, is_revoked
+			id, token, user_id, tenant_id, expires_at, created_at, is_revoked,
+			COALESCE(session_id, '') AS session_id,
+			COALESCE(revoked_reason, '') AS revoked_reason
 		FROM refresh_tokens 
 		WHERE user_id = $1 AND is_revoked = false AND expires_at > NOW()
 		ORDER BY created_at DESC`
@@ -0,0 +1,36 @@
+package authinfra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisActivityThrottle implementación en Redis del ActivityThrottle,
+// compartida entre instancias: una llave por sesión que expira tras el intervalo
+type RedisActivityThrottle struct {
+	client   *redis.Client
+	prefix   string
+	interval time.Duration
+}
+
+// NewRedisActivityThrottle crea un throttle que permite una escritura por llave cada interval
+func NewRedisActivityThrottle(client *redis.Client, prefix string, interval time.Duration) auth.ActivityThrottle {
+	return &RedisActivityThrottle{
+		client:   client,
+		prefix:   prefix,
+		interval: interval,
+	}
+}
+
+// Allow retorna true solo para la primera llamada de cada intervalo
+func (t *RedisActivityThrottle) Allow(ctx context.Context, key string) (bool, error) {
+	acquired, err := t.client.SetNX(ctx, t.prefix+key, 1, t.interval).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check activity throttle in Redis: %w", err)
+	}
+	return acquired, nil
+}
@@ -0,0 +1,80 @@
+package authinfra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisIdempotencyStore implementación en Redis del IdempotencyStore: cada
+// key guarda el registro serializado en JSON con su TTL
+type RedisIdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewRedisIdempotencyStore crea un nuevo store de idempotencia con Redis
+func NewRedisIdempotencyStore(client *redis.Client) auth.IdempotencyStore {
+	return &RedisIdempotencyStore{
+		client: client,
+	}
+}
+
+// Reserve guarda record con SETNX; si la key ya existe devuelve su registro
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, record auth.IdempotencyRecord, ttl time.Duration) (*auth.IdempotencyRecord, bool, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	// Si la key expira entre SETNX y GET se reintenta una vez
+	for range 2 {
+		reserved, err := s.client.SetNX(ctx, key, data, ttl).Result()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to reserve idempotency key in Redis: %w", err)
+		}
+		if reserved {
+			return nil, true, nil
+		}
+
+		existing, err := s.client.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read idempotency key from Redis: %w", err)
+		}
+
+		var stored auth.IdempotencyRecord
+		if err := json.Unmarshal(existing, &stored); err != nil {
+			return nil, false, fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		return &stored, false, nil
+	}
+
+	return nil, false, fmt.Errorf("idempotency key %q expired while reserving it", key)
+}
+
+// Complete reemplaza la reserva por la respuesta final
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, record auth.IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotent response in Redis: %w", err)
+	}
+	return nil
+}
+
+// Release elimina la reserva para que la petición pueda reintentarse
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key in Redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package authinfra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/testx"
+)
+
+func TestRedisIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	client := testx.Redis(t)
+	store := NewRedisIdempotencyStore(client)
+	key := "idempotency:t1:user:u1:abc"
+
+	existing, reserved, err := store.Reserve(ctx, key, auth.IdempotencyRecord{Fingerprint: "f1"}, time.Minute)
+	if err != nil || !reserved || existing != nil {
+		t.Fatalf("first Reserve = %v, %v, %v; want reserved", existing, reserved, err)
+	}
+
+	existing, reserved, err = store.Reserve(ctx, key, auth.IdempotencyRecord{Fingerprint: "f2"}, time.Minute)
+	if err != nil || reserved || existing == nil || existing.Fingerprint != "f1" || existing.Completed() {
+		t.Fatalf("second Reserve = %+v, %v, %v; want the in-progress reservation", existing, reserved, err)
+	}
+
+	record := auth.IdempotencyRecord{Fingerprint: "f1", StatusCode: 201, ContentType: "application/json", Body: []byte{0, 1, 2}}
+	if err := store.Complete(ctx, key, record, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	existing, _, err = store.Reserve(ctx, key, auth.IdempotencyRecord{Fingerprint: "f1"}, time.Minute)
+	if err != nil || existing == nil || existing.StatusCode != 201 || string(existing.Body) != string(record.Body) {
+		t.Fatalf("Reserve after Complete = %+v, %v; want the stored response", existing, err)
+	}
+	if ttl := client.TTL(ctx, key).Val(); ttl <= time.Minute {
+		t.Errorf("TTL after Complete = %s, want the response TTL", ttl)
+	}
+
+	if err := store.Release(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, reserved, err := store.Reserve(ctx, key, auth.IdempotencyRecord{Fingerprint: "f3"}, time.Minute); err != nil || !reserved {
+		t.Fatalf("Reserve after Release = %v, %v; want reserved", reserved, err)
+	}
+}
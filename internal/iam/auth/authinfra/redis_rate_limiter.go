@@ -0,0 +1,78 @@
+package authinfra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript drops the requests that left the window and records the
+// new one if the key is below its limit.
+// Returns {allowed, count, oldest request score in ms}.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+if count < tonumber(ARGV[3]) then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	redis.call("PEXPIRE", KEYS[1], window)
+	count = count + 1
+	allowed = 1
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+local oldestScore = now
+if oldest[2] then
+	oldestScore = tonumber(oldest[2])
+end
+return {allowed, count, oldestScore}
+`)
+
+// RedisRateLimiter implementación en Redis del RateLimiter con ventana
+// deslizante: cada petición es un miembro de un sorted set con su timestamp
+type RedisRateLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisRateLimiter crea un nuevo rate limiter con Redis
+func NewRedisRateLimiter(client *redis.Client) auth.RateLimiter {
+	return &RedisRateLimiter{
+		client: client,
+	}
+}
+
+// Allow registra una petición para key si cabe en el límite de la ventana
+func (rl *RedisRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (auth.RateLimitResult, error) {
+	now := time.Now().UnixMilli()
+
+	values, err := slidingWindowScript.Run(ctx, rl.client, []string{key},
+		now,
+		window.Milliseconds(),
+		limit,
+		uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return auth.RateLimitResult{}, fmt.Errorf("failed to evaluate rate limit in Redis: %w", err)
+	}
+	if len(values) != 3 {
+		return auth.RateLimitResult{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	count := int(values[1])
+	resetAfter := time.Duration(values[2]+window.Milliseconds()-now) * time.Millisecond
+	if resetAfter < 0 {
+		resetAfter = 0
+	}
+
+	return auth.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  max(limit-count, 0),
+		ResetAfter: resetAfter,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package authinfra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/testx"
+)
+
+func TestRedisRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewRedisRateLimiter(testx.Redis(t))
+	key := "ratelimit:login:ip:203.0.113.7"
+	window := 500 * time.Millisecond
+
+	for i := 1; i <= 3; i++ {
+		result, err := limiter.Allow(ctx, key, 3, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Limit != 3 || result.Remaining != 3-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, 3-i)
+		}
+		if result.ResetAfter <= 0 || result.ResetAfter > window {
+			t.Errorf("request %d ResetAfter = %s, want within the window", i, result.ResetAfter)
+		}
+	}
+
+	result, err := limiter.Allow(ctx, key, 3, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.Remaining != 0 || result.ResetAfter <= 0 {
+		t.Fatalf("request over the limit = %+v, want rejected with a reset time", result)
+	}
+
+	// Other keys have their own window
+	if result, err := limiter.Allow(ctx, "ratelimit:login:ip:203.0.113.8", 3, window); err != nil || !result.Allowed {
+		t.Fatalf("other key = %+v, %v; want allowed", result, err)
+	}
+
+	// Rejected requests are not counted, so the window frees up once the
+	// accepted ones leave it
+	time.Sleep(window + 50*time.Millisecond)
+	result, err = limiter.Allow(ctx, key, 3, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("request after the window = %+v, want allowed with 2 remaining", result)
+	}
+}
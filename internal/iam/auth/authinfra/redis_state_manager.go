@@ -11,6 +11,25 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// consumeStateScript lee y elimina el estado y deja en su lugar una marca de
+// uso con el TTL restante. Devuelve los datos, 0 si el estado ya fue usado o
+// nil si no existe.
+var consumeStateScript = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+local data = redis.call("GET", KEYS[1])
+if data then
+	redis.call("DEL", KEYS[1])
+	if ttl > 0 then
+		redis.call("SET", KEYS[2], "1", "PX", ttl)
+	end
+	return data
+end
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	return 0
+end
+return false
+`)
+
 // RedisStateManager implementación en Redis del StateManager
 type RedisStateManager struct {
 	client *redis.Client
@@ -59,12 +78,17 @@ func (sm *RedisStateManager) ValidateState(state string) bool {
 	return exists == 1
 }
 
-// GetStateData obtiene los datos asociados a un estado
+// GetStateData consume un estado y devuelve sus datos. La lectura, el borrado
+// y la marca de uso son atómicos, así que dos callbacks concurrentes con el
+// mismo estado no pueden obtener los datos ambos.
 func (sm *RedisStateManager) GetStateData(ctx context.Context, state string) (map[string]any, error) {
-	key := fmt.Sprintf("oauth_state:%s", state)
+	keys := []string{
+		fmt.Sprintf("oauth_state:%s", state),
+		fmt.Sprintf("oauth_state_used:%s", state),
+	}
 
 	// Obtener y eliminar el estado (one-time use)
-	jsonData, err := sm.client.GetDel(ctx, key).Result()
+	result, err := consumeStateScript.Run(ctx, sm.client, keys).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, auth.ErrInvalidState()
@@ -72,6 +96,11 @@ func (sm *RedisStateManager) GetStateData(ctx context.Context, state string) (ma
 		return nil, fmt.Errorf("failed to get state from Redis: %w", err)
 	}
 
+	jsonData, ok := result.(string)
+	if !ok {
+		return nil, auth.ErrStateAlreadyUsed()
+	}
+
 	var data map[string]any
 	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal state data: %w", err)
@@ -0,0 +1,202 @@
+package authtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+)
+
+func refreshToken(value, sessionID string, ttl time.Duration) auth.RefreshToken {
+	now := time.Now()
+	return auth.RefreshToken{
+		ID:        "id-" + value,
+		Token:     value,
+		UserID:    "u1",
+		TenantID:  "t1",
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		SessionID: sessionID,
+	}
+}
+
+func TestTokenRepositoryRotateDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryTokenRepository()
+
+	if err := repo.SaveRefreshToken(ctx, refreshToken("r1", "s1", time.Hour)); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+	if err := repo.RotateRefreshToken(ctx, "r1", refreshToken("r2", "s1", time.Hour)); err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+
+	err := repo.RotateRefreshToken(ctx, "r1", refreshToken("r3", "s1", time.Hour))
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != auth.ErrRefreshTokenReused().Code {
+		t.Fatalf("second rotation: got %v, want ErrRefreshTokenReused", err)
+	}
+
+	// El token revocado sigue visible para detectar la reutilización
+	old, err := repo.FindRefreshToken(ctx, "r1")
+	if err != nil || !old.IsRevoked {
+		t.Fatalf("FindRefreshToken(r1) = %+v, %v; want revoked token", old, err)
+	}
+
+	if err := repo.RevokeSessionTokens(ctx, "s1"); err != nil {
+		t.Fatalf("RevokeSessionTokens: %v", err)
+	}
+	if count, _ := repo.CountActiveTokens(ctx, "u1"); count != 0 {
+		t.Errorf("active tokens after session revocation = %d, want 0", count)
+	}
+}
+
+func TestTokenRepositoryCleanExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryTokenRepository()
+
+	_ = repo.SaveRefreshToken(ctx, refreshToken("expired", "", -time.Minute))
+	_ = repo.SaveRefreshToken(ctx, refreshToken("live", "", time.Hour))
+
+	if err := repo.CleanExpiredTokens(ctx); err != nil {
+		t.Fatalf("CleanExpiredTokens: %v", err)
+	}
+	if _, err := repo.FindRefreshToken(ctx, "expired"); err == nil {
+		t.Error("expired token was not cleaned")
+	}
+	if _, err := repo.FindRefreshToken(ctx, "live"); err != nil {
+		t.Errorf("live token was cleaned: %v", err)
+	}
+}
+
+func TestSessionRepositoryTouchCapsLifetime(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemorySessionRepository()
+
+	created := time.Now().Add(-50 * time.Minute)
+	_ = repo.SaveSession(ctx, auth.UserSession{
+		ID:           "s1",
+		UserID:       "u1",
+		TenantID:     "t1",
+		ExpiresAt:    time.Now().Add(time.Minute),
+		CreatedAt:    created,
+		LastActivity: created,
+	})
+
+	if err := repo.Touch(ctx, "s1", 30*time.Minute, time.Hour); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+
+	session, err := repo.FindSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("FindSession: %v", err)
+	}
+	if !session.ExpiresAt.Equal(created.Add(time.Hour)) {
+		t.Errorf("expires_at = %v, want capped at %v", session.ExpiresAt, created.Add(time.Hour))
+	}
+	if count, _ := repo.CountActiveByUser(ctx, "u1"); count != 1 {
+		t.Errorf("active sessions = %d, want 1", count)
+	}
+
+	if err := repo.RevokeSession(ctx, "s1"); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	var e *errx.Error
+	if _, err := repo.FindSession(ctx, "s1"); !errx.As(err, &e) || e.Type != errx.TypeNotFound {
+		t.Errorf("FindSession after revoke: got %v, want not found", err)
+	}
+}
+
+func TestPasswordResetRepositoryConsumeOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryPasswordResetRepository()
+
+	_ = repo.SaveResetToken(ctx, auth.PasswordResetToken{
+		ID:        "p1",
+		Token:     "reset",
+		UserID:    "u1",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	})
+
+	if err := repo.ConsumeResetToken(ctx, "reset"); err != nil {
+		t.Fatalf("ConsumeResetToken: %v", err)
+	}
+	if err := repo.ConsumeResetToken(ctx, "reset"); err == nil {
+		t.Error("token consumed twice")
+	}
+	if _, err := repo.FindResetToken(ctx, "reset"); err == nil {
+		t.Error("used token is still found")
+	}
+}
+
+func TestPasswordResetRepositoryHasRecentResetToken(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryPasswordResetRepository()
+
+	for i, created := range []time.Duration{-time.Hour, -5 * time.Minute, -time.Minute} {
+		_ = repo.SaveResetToken(ctx, auth.PasswordResetToken{
+			ID:        string(rune('a' + i)),
+			Token:     string(rune('a' + i)),
+			UserID:    "u1",
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now().Add(created),
+		})
+	}
+	// Consumed tokens still count against the limit
+	_ = repo.ConsumeResetToken(ctx, "c")
+
+	if limited, _ := repo.HasRecentResetToken(ctx, "u1", 15*time.Minute, 2); !limited {
+		t.Error("2 tokens within the window: want limited")
+	}
+	if limited, _ := repo.HasRecentResetToken(ctx, "u1", 15*time.Minute, 3); limited {
+		t.Error("token outside the window was counted")
+	}
+	if limited, _ := repo.HasRecentResetToken(ctx, "u2", 15*time.Minute, 1); limited {
+		t.Error("another user's tokens were counted")
+	}
+}
+
+func TestTokenRepositoryRotateSignedOutTokenIsNotReuse(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryTokenRepository()
+
+	_ = repo.SaveRefreshToken(ctx, refreshToken("r1", "s1", time.Hour))
+	if err := repo.RevokeSessionTokens(ctx, "s1"); err != nil {
+		t.Fatalf("RevokeSessionTokens: %v", err)
+	}
+
+	old, err := repo.FindRefreshToken(ctx, "r1")
+	if err != nil || old.WasRotated() {
+		t.Fatalf("FindRefreshToken(r1) = %+v, %v; want signed-out token", old, err)
+	}
+
+	err = repo.RotateRefreshToken(ctx, "r1", refreshToken("r2", "s1", time.Hour))
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != auth.ErrInvalidRefreshToken().Code {
+		t.Fatalf("rotation of signed-out token: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestTokenRepositoryRejectsDuplicateTokens(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryTokenRepository()
+
+	_ = repo.SaveRefreshToken(ctx, refreshToken("r1", "s1", time.Hour))
+	_ = repo.SaveRefreshToken(ctx, refreshToken("r2", "s1", time.Hour))
+
+	if err := repo.SaveRefreshToken(ctx, refreshToken("r1", "s2", time.Hour)); err == nil {
+		t.Fatal("SaveRefreshToken with a duplicate token succeeded")
+	}
+
+	// Como la transacción en PostgreSQL, la rotación fallida no revoca el token usado
+	if err := repo.RotateRefreshToken(ctx, "r1", refreshToken("r2", "s1", time.Hour)); err == nil {
+		t.Fatal("RotateRefreshToken to a duplicate token succeeded")
+	}
+	old, err := repo.FindRefreshToken(ctx, "r1")
+	if err != nil || old.IsRevoked || old.SessionID != "s1" {
+		t.Fatalf("FindRefreshToken(r1) = %+v, %v; want the original, unrevoked token", old, err)
+	}
+}
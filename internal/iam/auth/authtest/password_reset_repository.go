@@ -0,0 +1,105 @@
+package authtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// InMemoryPasswordResetRepository implementación en memoria de PasswordResetRepository
+type InMemoryPasswordResetRepository struct {
+	tokens map[string]*auth.PasswordResetToken // por valor del token
+	mu     sync.RWMutex
+}
+
+// NewInMemoryPasswordResetRepository crea un nuevo repositorio de reset de contraseña en memoria
+func NewInMemoryPasswordResetRepository() *InMemoryPasswordResetRepository {
+	return &InMemoryPasswordResetRepository{
+		tokens: make(map[string]*auth.PasswordResetToken),
+	}
+}
+
+// SaveResetToken guarda un token de reset de contraseña
+func (r *InMemoryPasswordResetRepository) SaveResetToken(ctx context.Context, token auth.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.Token] = &token
+	return nil
+}
+
+// FindResetToken busca un token de reset válido (no usado ni expirado) por su valor
+func (r *InMemoryPasswordResetRepository) FindResetToken(ctx context.Context, tokenValue string) (*auth.PasswordResetToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[tokenValue]
+	if !ok || !token.IsValid() {
+		return nil, errx.New("reset token not found or invalid", errx.TypeNotFound)
+	}
+
+	found := *token
+	return &found, nil
+}
+
+// ConsumeResetToken marca un token como usado
+func (r *InMemoryPasswordResetRepository) ConsumeResetToken(ctx context.Context, tokenValue string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenValue]
+	if !ok || !token.IsValid() {
+		return errx.New("reset token not found or already used", errx.TypeNotFound)
+	}
+
+	token.MarkAsUsed()
+	return nil
+}
+
+// CleanExpiredResetTokens limpia tokens expirados o usados
+func (r *InMemoryPasswordResetRepository) CleanExpiredResetTokens(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for value, token := range r.tokens {
+		if token.IsUsed || token.IsExpired() {
+			delete(r.tokens, value)
+		}
+	}
+	return nil
+}
+
+// HasRecentResetToken verifica si el usuario ya pidió maxTokens tokens dentro de la ventana
+func (r *InMemoryPasswordResetRepository) HasRecentResetToken(ctx context.Context, userID kernel.UserID, window time.Duration, maxTokens int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	since := time.Now().Add(-window)
+	count := 0
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count >= maxTokens, nil
+}
+
+// RevokeAllUserResetTokens marca como usados los tokens pendientes del usuario
+func (r *InMemoryPasswordResetRepository) RevokeAllUserResetTokens(ctx context.Context, userID kernel.UserID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			token.MarkAsUsed()
+		}
+	}
+	return nil
+}
+
+// Verificación en compilación de que implementa la interfaz
+var _ auth.PasswordResetRepository = (*InMemoryPasswordResetRepository)(nil)
@@ -0,0 +1,192 @@
+package authtest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// InMemorySessionRepository implementación en memoria de SessionRepository
+type InMemorySessionRepository struct {
+	sessions map[string]*auth.UserSession // por ID de sesión
+	mu       sync.RWMutex
+}
+
+// NewInMemorySessionRepository crea un nuevo repositorio de sesiones en memoria
+func NewInMemorySessionRepository() *InMemorySessionRepository {
+	return &InMemorySessionRepository{
+		sessions: make(map[string]*auth.UserSession),
+	}
+}
+
+// SaveSession guarda una nueva sesión de usuario
+func (r *InMemorySessionRepository) SaveSession(ctx context.Context, session auth.UserSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[session.ID] = &session
+	return nil
+}
+
+// FindSession busca una sesión por ID, aunque esté expirada
+func (r *InMemorySessionRepository) FindSession(ctx context.Context, sessionID string) (*auth.UserSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, errx.New("session not found", errx.TypeNotFound).
+			WithDetail("session_id", sessionID)
+	}
+
+	found := *session
+	return &found, nil
+}
+
+// FindActiveByUser busca las sesiones activas de un usuario, la de actividad más reciente primero
+func (r *InMemorySessionRepository) FindActiveByUser(ctx context.Context, userID kernel.UserID) ([]*auth.UserSession, error) {
+	result := r.activeByUser(userID)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastActivity.After(result[j].LastActivity)
+	})
+	return result, nil
+}
+
+// CountActiveByUser cuenta las sesiones activas de un usuario
+func (r *InMemorySessionRepository) CountActiveByUser(ctx context.Context, userID kernel.UserID) (int, error) {
+	return len(r.activeByUser(userID)), nil
+}
+
+// FindOldestActiveByUser busca la sesión activa más antigua de un usuario
+func (r *InMemorySessionRepository) FindOldestActiveByUser(ctx context.Context, userID kernel.UserID) (*auth.UserSession, error) {
+	var oldest *auth.UserSession
+	for _, session := range r.activeByUser(userID) {
+		if oldest == nil || session.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = session
+		}
+	}
+
+	if oldest == nil {
+		return nil, errx.New("session not found", errx.TypeNotFound).
+			WithDetail("user_id", userID.String())
+	}
+	return oldest, nil
+}
+
+// UpdateSessionActivity actualiza la última actividad de una sesión
+func (r *InMemorySessionRepository) UpdateSessionActivity(ctx context.Context, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok || session.IsExpired() {
+		return errx.New("session not found or expired", errx.TypeNotFound).
+			WithDetail("session_id", sessionID)
+	}
+
+	session.UpdateActivity()
+	return nil
+}
+
+// Touch registra actividad en una sesión y, con slideBy > 0, desliza su
+// expiración sin superar created_at + maxLifetime. Las sesiones inexistentes
+// o expiradas se ignoran, igual que en PostgreSQL.
+func (r *InMemorySessionRepository) Touch(ctx context.Context, sessionID string, slideBy, maxLifetime time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok || session.IsExpired() {
+		return nil
+	}
+
+	now := time.Now()
+	session.LastActivity = now
+	if slideBy > 0 {
+		expiresAt := now.Add(slideBy)
+		if limit := session.CreatedAt.Add(maxLifetime); limit.Before(expiresAt) {
+			expiresAt = limit
+		}
+		session.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// RevokeSession revoca (elimina) una sesión específica
+func (r *InMemorySessionRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[sessionID]; !ok {
+		return errx.New("session not found", errx.TypeNotFound).
+			WithDetail("session_id", sessionID)
+	}
+
+	delete(r.sessions, sessionID)
+	return nil
+}
+
+// RevokeAllUserSessions revoca todas las sesiones de un usuario
+func (r *InMemorySessionRepository) RevokeAllUserSessions(ctx context.Context, userID kernel.UserID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+// CleanExpiredSessions elimina sesiones expiradas
+func (r *InMemorySessionRepository) CleanExpiredSessions(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.IsExpired() {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+// CleanIdleSessions elimina sesiones sin actividad durante más de idleTimeout
+func (r *InMemorySessionRepository) CleanIdleSessions(ctx context.Context, idleTimeout time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().Add(-idleTimeout)
+	var deleted int64
+	for id, session := range r.sessions {
+		if session.LastActivity.Before(cutoff) {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// activeByUser retorna copias de las sesiones no expiradas de un usuario
+func (r *InMemorySessionRepository) activeByUser(userID kernel.UserID) []*auth.UserSession {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*auth.UserSession, 0)
+	for _, session := range r.sessions {
+		if session.UserID == userID && !session.IsExpired() {
+			found := *session
+			result = append(result, &found)
+		}
+	}
+	return result
+}
+
+// Verificación en compilación de que implementa la interfaz
+var _ auth.SessionRepository = (*InMemorySessionRepository)(nil)
@@ -0,0 +1,173 @@
+// Package authtest provee implementaciones en memoria de los repositorios de
+// auth para tests unitarios. Replican la semántica de las implementaciones de
+// PostgreSQL en authinfra (revocación, expiración y errores) y son seguras
+// para uso concurrente.
+package authtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// InMemoryTokenRepository implementación en memoria de TokenRepository
+type InMemoryTokenRepository struct {
+	tokens map[string]*auth.RefreshToken // por valor del token
+	mu     sync.RWMutex
+}
+
+// NewInMemoryTokenRepository crea un nuevo repositorio de tokens en memoria
+func NewInMemoryTokenRepository() *InMemoryTokenRepository {
+	return &InMemoryTokenRepository{
+		tokens: make(map[string]*auth.RefreshToken),
+	}
+}
+
+// SaveRefreshToken guarda un nuevo refresh token. Como la restricción UNIQUE
+// de refresh_tokens.token, falla si el valor ya existe.
+func (r *InMemoryTokenRepository) SaveRefreshToken(ctx context.Context, token auth.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(token); err != nil {
+		return err
+	}
+	r.tokens[token.Token] = &token
+	return nil
+}
+
+// FindRefreshToken busca un refresh token por su valor.
+// Devuelve también tokens revocados para poder detectar su reutilización.
+func (r *InMemoryTokenRepository) FindRefreshToken(ctx context.Context, tokenValue string) (*auth.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[tokenValue]
+	if !ok {
+		return nil, auth.ErrInvalidRefreshToken()
+	}
+
+	found := *token
+	return &found, nil
+}
+
+// RevokeRefreshToken revoca un refresh token
+func (r *InMemoryTokenRepository) RevokeRefreshToken(ctx context.Context, tokenValue string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenValue]
+	if !ok {
+		return auth.ErrInvalidRefreshToken()
+	}
+
+	revoke(token, auth.RevokedReasonSignedOut)
+	return nil
+}
+
+// RotateRefreshToken revoca el token usado y guarda su reemplazo de forma atómica.
+// Si el token ya estaba rotado (otro refresh lo usó primero) devuelve
+// ErrRefreshTokenReused; si fue revocado a propósito, ErrInvalidRefreshToken.
+func (r *InMemoryTokenRepository) RotateRefreshToken(ctx context.Context, oldTokenValue string, newToken auth.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.tokens[oldTokenValue]
+	if !ok {
+		return auth.ErrInvalidRefreshToken()
+	}
+	if old.IsRevoked {
+		if old.WasRotated() {
+			return auth.ErrRefreshTokenReused().
+				WithDetail("user_id", newToken.UserID.String())
+		}
+		return auth.ErrInvalidRefreshToken()
+	}
+	// En PostgreSQL el INSERT duplicado deshace también la revocación
+	if err := r.checkUnique(newToken); err != nil {
+		return err
+	}
+
+	revoke(old, auth.RevokedReasonRotated)
+	r.tokens[newToken.Token] = &newToken
+	return nil
+}
+
+// RevokeAllUserTokens revoca todos los tokens de un usuario
+func (r *InMemoryTokenRepository) RevokeAllUserTokens(ctx context.Context, userID kernel.UserID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && !token.IsRevoked {
+			revoke(token, auth.RevokedReasonSignedOut)
+		}
+	}
+	return nil
+}
+
+// RevokeSessionTokens revoca los refresh tokens emitidos para una sesión
+func (r *InMemoryTokenRepository) RevokeSessionTokens(ctx context.Context, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.SessionID != "" && token.SessionID == sessionID && !token.IsRevoked {
+			revoke(token, auth.RevokedReasonSignedOut)
+		}
+	}
+	return nil
+}
+
+// CleanExpiredTokens elimina tokens expirados.
+// Los tokens revocados se conservan hasta expirar para detectar su reutilización.
+func (r *InMemoryTokenRepository) CleanExpiredTokens(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for value, token := range r.tokens {
+		if token.ExpiresAt.Before(now) {
+			delete(r.tokens, value)
+		}
+	}
+	return nil
+}
+
+// CountActiveTokens cuenta tokens activos (no revocados ni expirados) de un usuario
+func (r *InMemoryTokenRepository) CountActiveTokens(ctx context.Context, userID kernel.UserID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.IsValid() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Verificación en compilación de que implementa la interfaz
+var _ auth.TokenRepository = (*InMemoryTokenRepository)(nil)
+
+// checkUnique falla si ya existe un token con el mismo valor
+func (r *InMemoryTokenRepository) checkUnique(token auth.RefreshToken) error {
+	if _, exists := r.tokens[token.Token]; exists {
+		return errx.New("failed to save refresh token: duplicate token", errx.TypeInternal).
+			WithDetail("user_id", token.UserID.String())
+	}
+	return nil
+}
+
+// revoke marca el token como revocado conservando el primer motivo registrado
+func revoke(token *auth.RefreshToken, reason auth.RevokedReason) {
+	token.IsRevoked = true
+	if token.RevokedReason == "" {
+		token.RevokedReason = reason
+	}
+}
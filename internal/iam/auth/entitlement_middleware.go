@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Quota response headers
+const (
+	HeaderQuotaLimit     = "X-Quota-Limit"
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	HeaderQuotaReset     = "X-Quota-Reset" // Unix seconds when the period ends
+)
+
+// EntitlementReader loads the features and quotas of a tenant
+// (tenantsrv.TenantService)
+type EntitlementReader interface {
+	GetEntitlements(ctx context.Context, tenantID kernel.TenantID) (*tenant.Entitlements, error)
+}
+
+// QuotaChecker reads the usage of a tenant quota (tenantsrv.QuotaService)
+type QuotaChecker interface {
+	CheckQuota(ctx context.Context, tenantID kernel.TenantID, quota tenant.Quota) (tenant.QuotaStatus, error)
+}
+
+// EntitlementMiddleware gates routes on the features and quotas of the
+// caller's tenant. Mount it after Authenticate.
+type EntitlementMiddleware struct {
+	entitlements EntitlementReader
+	quotas       QuotaChecker
+}
+
+// NewEntitlementMiddleware creates the middleware. quotas may be nil (no
+// Redis): RequireQuota then lets every request through.
+func NewEntitlementMiddleware(entitlements EntitlementReader, quotas QuotaChecker) *EntitlementMiddleware {
+	return &EntitlementMiddleware{
+		entitlements: entitlements,
+		quotas:       quotas,
+	}
+}
+
+// RequireFeature rejects with 403 FEATURE_NOT_AVAILABLE when the tenant's
+// plan or settings do not enable feature
+func (m *EntitlementMiddleware) RequireFeature(feature tenant.Feature) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authContext, ok := GetAuthContext(c)
+		if !ok {
+			return iam.ErrUnauthorized()
+		}
+
+		entitlements, err := m.entitlements.GetEntitlements(c.Context(), authContext.TenantID)
+		if err != nil {
+			return err
+		}
+		if !entitlements.HasFeature(feature) {
+			return tenant.ErrFeatureNotAvailable().
+				WithDetail("feature", string(feature)).
+				WithDetail("plan", string(entitlements.Plan))
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireQuota rejects with 429 QUOTA_EXCEEDED when the tenant used up quota
+// in the current period, and sets the X-Quota-* headers. Usage is recorded by
+// the handler (QuotaService.Consume), which can refresh the headers with
+// SetQuotaHeaders.
+func (m *EntitlementMiddleware) RequireQuota(quota tenant.Quota) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if m.quotas == nil {
+			return c.Next()
+		}
+
+		authContext, ok := GetAuthContext(c)
+		if !ok {
+			return iam.ErrUnauthorized()
+		}
+
+		status, err := m.quotas.CheckQuota(c.Context(), authContext.TenantID, quota)
+		SetQuotaHeaders(c, status)
+		if err != nil {
+			if status.Exceeded() {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(time.Until(status.ResetAt).Seconds())+1))
+			}
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// SetQuotaHeaders sets the X-Quota-* headers from status. Unlimited quotas
+// have no headers.
+func SetQuotaHeaders(c *fiber.Ctx, status tenant.QuotaStatus) {
+	if status.IsUnlimited() {
+		return
+	}
+	c.Set(HeaderQuotaLimit, strconv.FormatInt(status.Limit, 10))
+	c.Set(HeaderQuotaRemaining, strconv.FormatInt(status.Remaining, 10))
+	c.Set(HeaderQuotaReset, strconv.FormatInt(status.ResetAt.Unix(), 10))
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+type planEntitlements map[kernel.TenantID]tenant.SubscriptionPlan
+
+func (p planEntitlements) GetEntitlements(_ context.Context, tenantID kernel.TenantID) (*tenant.Entitlements, error) {
+	plan, ok := p[tenantID]
+	if !ok {
+		return nil, tenant.ErrTenantNotFound()
+	}
+	return tenant.NewEntitlements(plan, nil, nil, nil), nil
+}
+
+// usedQuotas reports a fixed usage against a limit of 100
+type usedQuotas map[kernel.TenantID]int64
+
+func (u usedQuotas) CheckQuota(_ context.Context, tenantID kernel.TenantID, quota tenant.Quota) (tenant.QuotaStatus, error) {
+	status := tenant.NewQuotaStatus(quota, 100, u[tenantID], time.Now().Add(time.Hour))
+	if status.Exceeded() {
+		return status, tenant.ErrQuotaExceeded()
+	}
+	return status, nil
+}
+
+func TestEntitlementMiddleware(t *testing.T) {
+	newApp := func(quotas QuotaChecker) *fiber.App {
+		app := fiber.New(fiber.Config{
+			ErrorHandler: func(c *fiber.Ctx, err error) error {
+				var e *errx.Error
+				if errx.As(err, &e) {
+					return c.Status(e.HTTPStatus).SendString(e.Code)
+				}
+				return fiber.DefaultErrorHandler(c, err)
+			},
+		})
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("auth", &kernel.AuthContext{TenantID: kernel.TenantID(c.Get("X-Tenant")), IsAPIKey: true})
+			return c.Next()
+		})
+		ent := NewEntitlementMiddleware(planEntitlements{"trial": tenant.PlanTrial, "pro": tenant.PlanProfessional}, quotas)
+		app.Get("/sso", ent.RequireFeature(tenant.FeatureSSO), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+		app.Get("/llm", ent.RequireQuota(tenant.QuotaLLMTokensMonthly), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+		return app
+	}
+	get := func(app *fiber.App, path string, tenantID kernel.TenantID) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		req.Header.Set("X-Tenant", tenantID.String())
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	app := newApp(usedQuotas{"trial": 40, "pro": 100})
+	tests := []struct {
+		name     string
+		path     string
+		tenantID kernel.TenantID
+		want     int
+	}{
+		{"feature of the plan", "/sso", "pro", fiber.StatusNoContent},
+		{"feature outside the plan", "/sso", "trial", fiber.StatusForbidden},
+		{"unknown tenant", "/sso", "gone", fiber.StatusNotFound},
+		{"quota left", "/llm", "trial", fiber.StatusNoContent},
+		{"quota used up", "/llm", "pro", fiber.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := get(app, tt.path, tt.tenantID); resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	left := get(app, "/llm", "trial")
+	if left.Header.Get(HeaderQuotaLimit) != "100" || left.Header.Get(HeaderQuotaRemaining) != "60" {
+		t.Errorf("quota headers = %q / %q, want 100 / 60", left.Header.Get(HeaderQuotaLimit), left.Header.Get(HeaderQuotaRemaining))
+	}
+	if get(app, "/llm", "pro").Header.Get(fiber.HeaderRetryAfter) == "" {
+		t.Error("rejection without Retry-After")
+	}
+
+	// Without Redis quotas are not enforced
+	if resp := get(newApp(nil), "/llm", "pro"); resp.StatusCode != fiber.StatusNoContent {
+		t.Errorf("status without quota checker = %d, want 204", resp.StatusCode)
+	}
+}
@@ -0,0 +1,461 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksMinRefreshInterval evita que un kid desconocido dispare una descarga del
+// JWKS en cada callback
+const jwksMinRefreshInterval = time.Minute
+
+// oidcDiscovery contiene los campos usados de /.well-known/openid-configuration
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// GenericOIDCOAuthService implementa OAuthService para cualquier proveedor
+// OpenID Connect (Okta, Keycloak, Auth0, ...). Los endpoints se descubren desde
+// el issuer y la identidad se toma del id_token, cuya firma se valida contra el
+// JWKS del issuer.
+type GenericOIDCOAuthService struct {
+	provider     iam.OAuthProvider
+	config       OAuthConfig
+	httpClient   *http.Client
+	stateManager StateManager
+	discovery    oidcDiscovery
+	groupsClaim  string
+	opTimeout    time.Duration // Límite de cada llamada al issuer
+
+	jwksMu        sync.RWMutex
+	jwks          map[string]any // kid -> *rsa.PublicKey | *ecdsa.PublicKey
+	jwksFetchedAt time.Time
+}
+
+// NewGenericOIDCOAuthServiceFromConfig crea el servicio y ejecuta el discovery
+// del issuer. cfg.Key es la clave del proveedor (POST /auth/login y /auth/callback/:provider).
+func NewGenericOIDCOAuthServiceFromConfig(ctx context.Context, cfg *config.OIDCProviderConfig, stateManager StateManager) (*GenericOIDCOAuthService, error) {
+	if cfg.Key == "" || cfg.IssuerURL == "" || cfg.ClientID == "" {
+		return nil, errx.New("OIDC provider requires key, issuer URL and client ID", errx.TypeValidation).
+			WithDetail("provider", cfg.Key)
+	}
+
+	s := &GenericOIDCOAuthService{
+		provider: iam.OAuthProvider(strings.ToUpper(cfg.Key)),
+		config: OAuthConfig{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		},
+		httpClient:   newOAuthHTTPClient(cfg.Timeout),
+		stateManager: stateManager,
+		groupsClaim:  cfg.GroupsClaim,
+		opTimeout:    cfg.OperationTimeout,
+	}
+
+	if err := s.discover(ctx, cfg.IssuerURL); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// discover carga la configuración OpenID del issuer
+func (s *GenericOIDCOAuthService) discover(ctx context.Context, issuerURL string) error {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+
+	var doc oidcDiscovery
+	if err := s.getJSON(ctx, issuerURL+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return err
+	}
+
+	// El issuer publicado debe coincidir con el configurado (OIDC Discovery §4.3)
+	if strings.TrimSuffix(doc.Issuer, "/") != issuerURL {
+		return ErrOAuthAuthorizationFailed().
+			WithDetail("provider", string(s.provider)).
+			WithDetail("reason", "issuer mismatch").
+			WithDetail("issuer", doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return ErrOAuthAuthorizationFailed().
+			WithDetail("provider", string(s.provider)).
+			WithDetail("reason", "incomplete discovery document")
+	}
+
+	s.discovery = doc
+	return nil
+}
+
+// GetProvider retorna la clave configurada del proveedor
+func (s *GenericOIDCOAuthService) GetProvider() iam.OAuthProvider {
+	return s.provider
+}
+
+// GetAuthURL genera la URL de autorización del issuer, sin nonce. El login
+// usa GetAuthURLWithNonce.
+func (s *GenericOIDCOAuthService) GetAuthURL(state string) string {
+	return s.GetAuthURLWithNonce(state, "")
+}
+
+// GetAuthURLWithNonce genera la URL de autorización con el nonce que el issuer
+// debe devolver en el id_token
+func (s *GenericOIDCOAuthService) GetAuthURLWithNonce(state, nonce string) string {
+	params := url.Values{
+		"client_id":     {s.config.ClientID},
+		"redirect_uri":  {s.config.RedirectURL},
+		"scope":         {strings.Join(s.config.Scopes, " ")},
+		"response_type": {"code"},
+		"state":         {state},
+	}
+	if nonce != "" {
+		params.Set("nonce", nonce)
+	}
+
+	separator := "?"
+	if strings.Contains(s.discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return s.discovery.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// ValidateState valida el estado OAuth
+func (s *GenericOIDCOAuthService) ValidateState(state string) bool {
+	return s.stateManager.ValidateState(state)
+}
+
+// ExchangeToken intercambia el código de autorización por tokens (incluido el id_token)
+func (s *GenericOIDCOAuthService) ExchangeToken(ctx context.Context, code string) (_ *OAuthTokenResponse, err error) {
+	ctx, span := startOAuthSpan(ctx, "oauth.exchange_token", s.GetProvider())
+	defer func() { tracex.End(span, err) }()
+
+	ctx, cancel := withOperationTimeout(ctx, s.opTimeout)
+	defer cancel()
+
+	data := url.Values{
+		"client_id":     {s.config.ClientID},
+		"client_secret": {s.config.ClientSecret},
+		"code":          {code},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {s.config.RedirectURL},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.discovery.TokenEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to create token request", errx.TypeInternal)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, oauthCallError(ctx, err, s.provider, "failed to exchange token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, oauthStatusError(resp, s.provider, "")
+	}
+
+	var tokenResp OAuthTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, oauthCallError(ctx, err, s.provider, "failed to decode token response")
+	}
+
+	return &tokenResp, nil
+}
+
+// GetUserInfo obtiene la información del usuario desde el userinfo endpoint.
+// HandleCallback usa UserInfoFromTokens, que valida el id_token.
+func (s *GenericOIDCOAuthService) GetUserInfo(ctx context.Context, accessToken string) (_ *OAuthUserInfo, err error) {
+	ctx, span := startOAuthSpan(ctx, "oauth.user_info", s.GetProvider())
+	defer func() { tracex.End(span, err) }()
+
+	if s.discovery.UserInfoEndpoint == "" {
+		return nil, ErrOAuthAuthorizationFailed().
+			WithDetail("provider", string(s.provider)).
+			WithDetail("reason", "issuer has no userinfo endpoint")
+	}
+
+	var claims map[string]any
+	if err := s.getJSON(ctx, s.discovery.UserInfoEndpoint, accessToken, &claims); err != nil {
+		return nil, err
+	}
+
+	return s.userInfoFromClaims(claims), nil
+}
+
+// UserInfoFromTokens valida el id_token (firma, issuer, audiencia, expiración
+// y el nonce enviado en la URL de autorización) y extrae los claims estándar.
+// Si el id_token no trae el email se completa con el userinfo endpoint.
+func (s *GenericOIDCOAuthService) UserInfoFromTokens(ctx context.Context, tokens *OAuthTokenResponse, nonce string) (*OAuthUserInfo, error) {
+	if tokens.IDToken == "" {
+		return nil, ErrInvalidIDToken().
+			WithDetail("provider", string(s.provider)).
+			WithDetail("reason", "missing id_token")
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokens.IDToken, claims,
+		func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			return s.signingKey(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(s.discovery.Issuer),
+		jwt.WithAudience(s.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, ErrInvalidIDToken().
+			WithDetail("provider", string(s.provider)).
+			WithDetail("reason", err.Error())
+	}
+
+	// Sin nonce un id_token emitido para otro login podría reutilizarse
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claimString(claims, "nonce")), []byte(nonce)) != 1 {
+		return nil, ErrInvalidIDToken().
+			WithDetail("provider", string(s.provider)).
+			WithDetail("reason", "nonce mismatch")
+	}
+
+	info := s.userInfoFromClaims(claims)
+	if info.ID == "" {
+		return nil, ErrInvalidIDToken().
+			WithDetail("provider", string(s.provider)).
+			WithDetail("reason", "missing sub claim")
+	}
+
+	if info.Email == "" && tokens.AccessToken != "" && s.discovery.UserInfoEndpoint != "" {
+		extra, err := s.GetUserInfo(ctx, tokens.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		// El sub del userinfo debe ser el del id_token (OIDC Core §5.3.2)
+		if extra.ID == info.ID {
+			info.Email = extra.Email
+			info.EmailVerified = extra.EmailVerified
+			if info.Name == "" {
+				info.Name = extra.Name
+			}
+			if info.Picture == "" {
+				info.Picture = extra.Picture
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// userInfoFromClaims mapea los claims estándar de OIDC a OAuthUserInfo
+func (s *GenericOIDCOAuthService) userInfoFromClaims(claims map[string]any) *OAuthUserInfo {
+	info := &OAuthUserInfo{
+		ID:            claimString(claims, "sub"),
+		Email:         claimString(claims, "email"),
+		Name:          claimString(claims, "name"),
+		Picture:       claimString(claims, "picture"),
+		EmailVerified: claimBool(claims, "email_verified"),
+	}
+
+	if info.Name == "" {
+		info.Name = strings.TrimSpace(claimString(claims, "given_name") + " " + claimString(claims, "family_name"))
+	}
+
+	// Groups nil = el proveedor no informa grupos; solo se leen si hay claim configurado
+	if s.groupsClaim != "" {
+		info.Groups = []string{}
+		if raw, ok := claims[s.groupsClaim].([]any); ok {
+			for _, g := range raw {
+				if name, ok := g.(string); ok {
+					info.Groups = append(info.Groups, name)
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// signingKey retorna la clave pública del JWKS para un kid, recargando el JWKS
+// si el kid es desconocido (rotación de claves del IdP)
+func (s *GenericOIDCOAuthService) signingKey(ctx context.Context, kid string) (any, error) {
+	if key, ok := s.cachedKey(kid); ok {
+		return key, nil
+	}
+
+	s.jwksMu.Lock()
+	defer s.jwksMu.Unlock()
+
+	if key, ok := s.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(s.jwksFetchedAt) < jwksMinRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := s.fetchJWKS(ctx)
+	s.jwksFetchedAt = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	s.jwks = keys
+
+	if key, ok := s.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (s *GenericOIDCOAuthService) cachedKey(kid string) (any, bool) {
+	s.jwksMu.RLock()
+	defer s.jwksMu.RUnlock()
+	return s.lookupKey(kid)
+}
+
+// lookupKey busca por kid; sin kid solo es válido si el JWKS tiene una clave.
+// Se llama con jwksMu tomado.
+func (s *GenericOIDCOAuthService) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(s.jwks) == 1 {
+		for _, key := range s.jwks {
+			return key, true
+		}
+	}
+	key, ok := s.jwks[kid]
+	return key, ok
+}
+
+// fetchJWKS descarga y parsea las claves de firma del issuer
+func (s *GenericOIDCOAuthService) fetchJWKS(ctx context.Context) (map[string]any, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := s.getJSON(ctx, s.discovery.JWKSURI, "", &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// getJSON hace un GET (con bearer token opcional) y decodifica la respuesta
+func (s *GenericOIDCOAuthService) getJSON(ctx context.Context, endpoint, accessToken string, out any) error {
+	ctx, cancel := withOperationTimeout(ctx, s.opTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return errx.Wrap(err, "failed to create OIDC request", errx.TypeInternal)
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return oauthCallError(ctx, err, s.provider, "failed to call OIDC provider")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return oauthStatusError(resp, s.provider, endpoint)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return oauthCallError(ctx, err, s.provider, "failed to decode OIDC response").
+			WithDetail("endpoint", endpoint)
+	}
+
+	return nil
+}
+
+func claimString(claims map[string]any, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimBool acepta booleanos y "true"/"false" (algunos IdPs envían strings)
+func claimBool(claims map[string]any, name string) bool {
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
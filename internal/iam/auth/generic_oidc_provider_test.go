@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIssuer serves discovery and a JWKS with the public keys in keys
+type testIssuer struct {
+	server      *httptest.Server
+	mu          sync.Mutex
+	keys        map[string]*rsa.PrivateKey
+	discoveries atomic.Int32
+	jwksFetches atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	issuer := &testIssuer{keys: map[string]*rsa.PrivateKey{}}
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			issuer.discoveries.Add(1)
+			json.NewEncoder(w).Encode(oidcDiscovery{
+				Issuer:                issuer.server.URL,
+				AuthorizationEndpoint: issuer.server.URL + "/authorize",
+				TokenEndpoint:         issuer.server.URL + "/token",
+				JWKSURI:               issuer.server.URL + "/jwks",
+			})
+		case "/jwks":
+			issuer.jwksFetches.Add(1)
+			issuer.mu.Lock()
+			defer issuer.mu.Unlock()
+			var keys []map[string]string
+			for kid, key := range issuer.keys {
+				keys = append(keys, map[string]string{
+					"kty": "RSA",
+					"kid": kid,
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys[kid] = key
+}
+
+func (i *testIssuer) key(kid string) *rsa.PrivateKey {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.keys[kid]
+}
+
+// sign issues an id_token of the issuer for client "app" with the given
+// claim overrides
+func (i *testIssuer) sign(t *testing.T, kid string, overrides jwt.MapClaims) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"iss":            i.server.URL,
+		"aud":            "app",
+		"sub":            "user-1",
+		"email":          "ana@acme.com",
+		"email_verified": true,
+		"nonce":          "nonce-1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(i.key(kid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func newDiscoveredOIDCService(t *testing.T, issuer *testIssuer) *GenericOIDCOAuthService {
+	t.Helper()
+	s := &GenericOIDCOAuthService{
+		provider:   "TEST",
+		config:     OAuthConfig{ClientID: "app", RedirectURL: "https://app.example.com/auth/callback/test"},
+		httpClient: newOAuthHTTPClient(time.Second),
+	}
+	if err := s.discover(context.Background(), issuer.server.URL); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestGetAuthURLWithNonce(t *testing.T) {
+	s := newDiscoveredOIDCService(t, newTestIssuer(t))
+
+	authURL, err := url.Parse(s.GetAuthURLWithNonce("state-1", "nonce-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query := authURL.Query(); query.Get("state") != "state-1" || query.Get("nonce") != "nonce-1" {
+		t.Errorf("auth URL query = %v, want state and nonce", query)
+	}
+}
+
+func TestUserInfoFromTokensValidatesIDToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	issuer.addKey(t, "k1")
+	s := newDiscoveredOIDCService(t, issuer)
+
+	info, err := s.UserInfoFromTokens(ctx, &OAuthTokenResponse{IDToken: issuer.sign(t, "k1", nil)}, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "user-1" || info.Email != "ana@acme.com" || !info.EmailVerified {
+		t.Errorf("user info = %+v", info)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": issuer.server.URL, "aud": "app", "sub": "user-1", "nonce": "nonce-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = "k1"
+	forgedToken, err := forged.SignedString(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		idToken string
+		nonce   string
+	}{
+		{"missing id_token", "", "nonce-1"},
+		{"signed by another key", forgedToken, "nonce-1"},
+		{"other issuer", issuer.sign(t, "k1", jwt.MapClaims{"iss": "https://evil.example.com"}), "nonce-1"},
+		{"other audience", issuer.sign(t, "k1", jwt.MapClaims{"aud": "other-app"}), "nonce-1"},
+		{"expired", issuer.sign(t, "k1", jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}), "nonce-1"},
+		{"without exp", issuer.sign(t, "k1", jwt.MapClaims{"exp": nil}), "nonce-1"},
+		{"without sub", issuer.sign(t, "k1", jwt.MapClaims{"sub": ""}), "nonce-1"},
+		{"nonce of another login", issuer.sign(t, "k1", nil), "nonce-2"},
+		{"without nonce claim", issuer.sign(t, "k1", jwt.MapClaims{"nonce": nil}), "nonce-1"},
+		{"login without nonce", issuer.sign(t, "k1", nil), ""},
+		{"alg none", "eyJhbGciOiJub25lIiwia2lkIjoiazEifQ." + strings.Split(issuer.sign(t, "k1", nil), ".")[1] + ".", "nonce-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.UserInfoFromTokens(ctx, &OAuthTokenResponse{IDToken: tt.idToken}, tt.nonce)
+			if !errx.Is(err, CodeInvalidIDToken) {
+				t.Errorf("error = %v, want INVALID_ID_TOKEN", err)
+			}
+		})
+	}
+}
+
+func TestUserInfoFromTokensRefetchesJWKSOnRotation(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	issuer.addKey(t, "k1")
+	s := newDiscoveredOIDCService(t, issuer)
+
+	if _, err := s.UserInfoFromTokens(ctx, &OAuthTokenResponse{IDToken: issuer.sign(t, "k1", nil)}, "nonce-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new kid reloads the JWKS, but at most once per jwksMinRefreshInterval
+	issuer.addKey(t, "k2")
+	if _, err := s.UserInfoFromTokens(ctx, &OAuthTokenResponse{IDToken: issuer.sign(t, "k2", nil)}, "nonce-1"); !errx.Is(err, CodeInvalidIDToken) {
+		t.Fatalf("error = %v, want INVALID_ID_TOKEN within the refresh interval", err)
+	}
+	s.jwksFetchedAt = time.Now().Add(-jwksMinRefreshInterval)
+	if _, err := s.UserInfoFromTokens(ctx, &OAuthTokenResponse{IDToken: issuer.sign(t, "k2", nil)}, "nonce-1"); err != nil {
+		t.Fatalf("token of the rotated key rejected: %v", err)
+	}
+	if fetches := issuer.jwksFetches.Load(); fetches != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", fetches)
+	}
+
+	// With several keys a token without kid matches none
+	noKid := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": issuer.server.URL, "aud": "app", "sub": "user-1", "nonce": "nonce-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	withoutKid, err := noKid.SignedString(issuer.key("k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UserInfoFromTokens(ctx, &OAuthTokenResponse{IDToken: withoutKid}, "nonce-1"); !errx.Is(err, CodeInvalidIDToken) {
+		t.Errorf("error = %v, want INVALID_ID_TOKEN for a token without kid", err)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/config"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
 )
 
 const (
@@ -24,6 +26,7 @@ type GoogleOAuthService struct {
 	config       OAuthConfig
 	httpClient   *http.Client
 	stateManager StateManager
+	opTimeout    time.Duration // Límite de cada operación (intercambio, userinfo)
 	authURL      string
 	tokenURL     string
 	userInfoURL  string
@@ -38,8 +41,9 @@ func NewGoogleOAuthServiceFromConfig(cfg *config.OAuthProviderConfig, stateManag
 			RedirectURL:  cfg.RedirectURL,
 			Scopes:       cfg.Scopes,
 		},
-		httpClient:   &http.Client{Timeout: cfg.Timeout},
+		httpClient:   newOAuthHTTPClient(cfg.Timeout),
 		stateManager: stateManager,
+		opTimeout:    cfg.OperationTimeout,
 		authURL:      cfg.AuthURL,
 		tokenURL:     cfg.TokenURL,
 		userInfoURL:  cfg.UserInfoURL,
@@ -72,7 +76,13 @@ func (g *GoogleOAuthService) ValidateState(state string) bool {
 }
 
 // ExchangeToken intercambia el código de autorización por tokens
-func (g *GoogleOAuthService) ExchangeToken(ctx context.Context, code string) (*OAuthTokenResponse, error) {
+func (g *GoogleOAuthService) ExchangeToken(ctx context.Context, code string) (_ *OAuthTokenResponse, err error) {
+	ctx, span := startOAuthSpan(ctx, "oauth.exchange_token", g.GetProvider())
+	defer func() { tracex.End(span, err) }()
+
+	ctx, cancel := withOperationTimeout(ctx, g.opTimeout)
+	defer cancel()
+
 	data := url.Values{
 		"client_id":     {g.config.ClientID},
 		"client_secret": {g.config.ClientSecret},
@@ -90,26 +100,30 @@ func (g *GoogleOAuthService) ExchangeToken(ctx context.Context, code string) (*O
 
 	resp, err := g.httpClient.Do(req)
 	if err != nil {
-		return nil, errx.Wrap(err, "failed to exchange token", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, g.GetProvider(), "failed to exchange token")
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, ErrOAuthAuthorizationFailed().
-			WithDetail("status_code", resp.StatusCode).
-			WithDetail("provider", "google")
+		return nil, oauthStatusError(resp, g.GetProvider(), "")
 	}
 
 	var tokenResp OAuthTokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return nil, errx.Wrap(err, "failed to decode token response", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, g.GetProvider(), "failed to decode token response")
 	}
 
 	return &tokenResp, nil
 }
 
 // GetUserInfo obtiene la información del usuario desde Google
-func (g *GoogleOAuthService) GetUserInfo(ctx context.Context, accessToken string) (*OAuthUserInfo, error) {
+func (g *GoogleOAuthService) GetUserInfo(ctx context.Context, accessToken string) (_ *OAuthUserInfo, err error) {
+	ctx, span := startOAuthSpan(ctx, "oauth.user_info", g.GetProvider())
+	defer func() { tracex.End(span, err) }()
+
+	ctx, cancel := withOperationTimeout(ctx, g.opTimeout)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", GoogleUserInfoURL, nil)
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to create user info request", errx.TypeInternal)
@@ -119,15 +133,12 @@ func (g *GoogleOAuthService) GetUserInfo(ctx context.Context, accessToken string
 
 	resp, err := g.httpClient.Do(req)
 	if err != nil {
-		return nil, errx.Wrap(err, "failed to get user info", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, g.GetProvider(), "failed to get user info")
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, ErrOAuthAuthorizationFailed().
-			WithDetail("status_code", resp.StatusCode).
-			WithDetail("provider", "google").
-			WithDetail("endpoint", "userinfo")
+		return nil, oauthStatusError(resp, g.GetProvider(), "userinfo")
 	}
 
 	var googleUser struct {
@@ -139,7 +150,7 @@ func (g *GoogleOAuthService) GetUserInfo(ctx context.Context, accessToken string
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&googleUser); err != nil {
-		return nil, errx.Wrap(err, "failed to decode user info", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, g.GetProvider(), "failed to decode user info")
 	}
 
 	return &OAuthUserInfo{
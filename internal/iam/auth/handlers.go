@@ -2,10 +2,13 @@ package auth
 
 import (
 	"context"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
@@ -13,6 +16,7 @@ import (
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
 	"github.com/Abraxas-365/manifesto/internal/iam/user"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
 	"github.com/Abraxas-365/manifesto/internal/ptrx"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -20,49 +24,61 @@ import (
 
 // AuthHandlers handles authentication routes with Fiber
 type AuthHandlers struct {
-	oauthServices  map[iam.OAuthProvider]OAuthService
-	tokenService   TokenService
-	userRepo       user.UserRepository
-	tenantRepo     tenant.TenantRepository
-	tokenRepo      TokenRepository
-	sessionRepo    SessionRepository
-	stateManager   StateManager
-	invitationRepo invitation.InvitationRepository
-	auditService   AuditService
-	config         *config.Config
+	oauthResolver    *OAuthProviderResolver
+	tokenService     TokenService
+	userRepo         user.UserRepository
+	tenantRepo       tenant.TenantRepository
+	tenantConfigRepo tenant.TenantConfigRepository
+	tokenRepo        TokenRepository
+	sessionRepo      SessionRepository
+	stateManager     StateManager
+	invitationRepo   invitation.InvitationRepository
+	auditService     AuditService
+	transactor       dbx.Transactor
+	config           *config.Config
 }
 
-// NewAuthHandlers creates a new authentication handler
+// NewAuthHandlers creates a new authentication handler. transactor may be nil,
+// in which case new OAuth accounts are not created in a transaction.
+// tenantConfigRepo may be nil, in which case OAUTH_GROUP_SYNC_ENABLED applies
+// to every tenant.
 func NewAuthHandlers(
-	oauthServices map[iam.OAuthProvider]OAuthService,
+	oauthResolver *OAuthProviderResolver,
 	tokenService TokenService,
 	userRepo user.UserRepository,
 	tenantRepo tenant.TenantRepository,
+	tenantConfigRepo tenant.TenantConfigRepository,
 	tokenRepo TokenRepository,
 	sessionRepo SessionRepository,
 	stateManager StateManager,
 	invitationRepo invitation.InvitationRepository,
 	auditService AuditService,
+	transactor dbx.Transactor,
 	config *config.Config,
 ) *AuthHandlers {
 	return &AuthHandlers{
-		oauthServices:  oauthServices,
-		tokenService:   tokenService,
-		userRepo:       userRepo,
-		tenantRepo:     tenantRepo,
-		tokenRepo:      tokenRepo,
-		sessionRepo:    sessionRepo,
-		stateManager:   stateManager,
-		invitationRepo: invitationRepo,
-		auditService:   auditService,
-		config:         config,
+		oauthResolver:    oauthResolver,
+		tokenService:     tokenService,
+		userRepo:         userRepo,
+		tenantRepo:       tenantRepo,
+		tenantConfigRepo: tenantConfigRepo,
+		tokenRepo:        tokenRepo,
+		sessionRepo:      sessionRepo,
+		stateManager:     stateManager,
+		invitationRepo:   invitationRepo,
+		auditService:     auditService,
+		transactor:       transactor,
+		config:           config,
 	}
 }
 
-// LoginRequest estructura para iniciar login OAuth
+// LoginRequest estructura para iniciar login OAuth. Con provider "sso" se usa
+// la conexión SSO del tenant, indicado por tenant_id o por el dominio de email.
 type LoginRequest struct {
 	Provider        iam.OAuthProvider `json:"provider"`
 	InvitationToken string            `json:"invitation_token,omitempty"`
+	TenantID        kernel.TenantID   `json:"tenant_id,omitempty"`
+	Email           string            `json:"email,omitempty"`
 }
 
 // LoginResponse respuesta del endpoint de login
@@ -86,6 +102,13 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// IntrospectRequest estructura para introspección de tokens (RFC 7662).
+// Acepta form-urlencoded o JSON.
+type IntrospectRequest struct {
+	Token         string `json:"token" form:"token"`
+	TokenTypeHint string `json:"token_type_hint,omitempty" form:"token_type_hint"`
+}
+
 // RegisterRoutes registers the auth routes on Fiber
 func (ah *AuthHandlers) RegisterRoutes(router fiber.Router) {
 	auth := router.Group("/auth")
@@ -97,6 +120,44 @@ func (ah *AuthHandlers) RegisterRoutes(router fiber.Router) {
 	auth.Get("/me", ah.GetCurrentUser)
 }
 
+// RegisterIntrospectionRoutes registers POST /auth/introspect. Callers must be
+// admins or hold tokens:introspect, typically a resource server's API key.
+func (ah *AuthHandlers) RegisterIntrospectionRoutes(router fiber.Router, authMiddleware *UnifiedAuthMiddleware) {
+	router.Post("/auth/introspect",
+		authMiddleware.Authenticate(),
+		authMiddleware.RequireAdminOrScope(scopes.ScopeTokensIntrospect),
+		ah.IntrospectToken,
+	)
+}
+
+// IntrospectToken indica si un access token está activo y retorna sus claims
+// (RFC 7662). Los tokens de otro tenant se reportan como inactivos.
+func (ah *AuthHandlers) IntrospectToken(c *fiber.Ctx) error {
+	authContext, ok := GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req IntrospectRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "token is required",
+		})
+	}
+
+	introspection, err := ah.tokenService.IntrospectToken(req.Token)
+	if err != nil {
+		return err
+	}
+
+	if introspection.Active && introspection.Claims.TenantID != authContext.TenantID {
+		introspection = &TokenIntrospection{Active: false}
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(introspection.ToResponse())
+}
+
 // InitiateLogin inicia el proceso de login OAuth
 func (ah *AuthHandlers) InitiateLogin(c *fiber.Ctx) error {
 	var req LoginRequest
@@ -106,12 +167,25 @@ func (ah *AuthHandlers) InitiateLogin(c *fiber.Ctx) error {
 		})
 	}
 
-	// Normalizar el proveedor a mayúsculas y verificar que esté soportado
+	// Normalizar el proveedor a mayúsculas y resolver su servicio; el SSO del
+	// tenant se busca por tenant_id o, si no viene, por el dominio del email
 	normalizedProvider := iam.OAuthProvider(strings.ToUpper(string(req.Provider)))
-	oauthService, exists := ah.oauthServices[normalizedProvider]
-	if !exists {
+	tenantID := req.TenantID
+
+	var oauthService OAuthService
+	var err error
+	if normalizedProvider == iam.OAuthProviderSSO && tenantID.IsEmpty() && req.Email != "" {
+		var conn *tenant.SSOConnection
+		oauthService, conn, err = ah.oauthResolver.ForEmail(c.Context(), req.Email)
+		if err == nil {
+			tenantID = conn.TenantID
+		}
+	} else {
+		oauthService, err = ah.oauthResolver.Resolve(c.Context(), normalizedProvider, tenantID)
+	}
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": ErrInvalidOAuthProvider().Error(),
+			"error": err.Error(),
 		})
 	}
 
@@ -125,6 +199,17 @@ func (ah *AuthHandlers) InitiateLogin(c *fiber.Ctx) error {
 	if req.InvitationToken != "" {
 		stateData["invitation_token"] = req.InvitationToken
 	}
+	if normalizedProvider == iam.OAuthProviderSSO {
+		stateData["tenant_id"] = tenantID.String()
+	}
+
+	// Los proveedores OIDC reciben un nonce que el id_token debe devolver
+	oidcService, isOIDC := oauthService.(OIDCUserInfoProvider)
+	var nonce string
+	if isOIDC {
+		nonce = ah.stateManager.GenerateState()
+		stateData["nonce"] = nonce
+	}
 
 	if err := ah.stateManager.StoreState(c.Context(), state, stateData); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -134,6 +219,9 @@ func (ah *AuthHandlers) InitiateLogin(c *fiber.Ctx) error {
 
 	// Generar URL de autorización
 	authURL := oauthService.GetAuthURL(state)
+	if isOIDC {
+		authURL = oidcService.GetAuthURLWithNonce(state, nonce)
+	}
 
 	return c.JSON(LoginResponse{
 		AuthURL: authURL,
@@ -143,28 +231,9 @@ func (ah *AuthHandlers) InitiateLogin(c *fiber.Ctx) error {
 
 // HandleCallback maneja el callback OAuth
 func (ah *AuthHandlers) HandleCallback(c *fiber.Ctx) error {
-	providerStr := c.Params("provider")
-
-	// Convertir string a OAuthProvider
-	var provider iam.OAuthProvider
-	switch providerStr {
-	case "google":
-		provider = iam.OAuthProviderGoogle
-	case "microsoft":
-		provider = iam.OAuthProviderMicrosoft
-	default:
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": ErrInvalidOAuthProvider().Error(),
-		})
-	}
-
-	// Verificar que el servicio OAuth exista
-	oauthService, exists := ah.oauthServices[provider]
-	if !exists {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": ErrInvalidOAuthProvider().Error(),
-		})
-	}
+	// La clave del proveedor en la URL es la del mapa en minúsculas
+	// (google, microsoft o la clave de un proveedor OIDC genérico)
+	provider := iam.OAuthProvider(strings.ToUpper(c.Params("provider")))
 
 	// Obtener parámetros del callback
 	code := c.Query("code")
@@ -184,43 +253,92 @@ func (ah *AuthHandlers) HandleCallback(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validar estado
+	// Validar y consumir el estado (un solo uso)
 	stateData, err := ah.stateManager.GetStateData(c.Context(), state)
 	if err != nil {
+		stateErr := ErrInvalidState()
+		if errx.Is(err, CodeStateAlreadyUsed) {
+			// Un callback repetido puede ser un intento de inyectar el código
+			logx.WithFields(logx.Fields{"provider": provider, "ip": c.IP()}).
+				Warn("OAuth callback replayed an already used state")
+			stateErr = ErrStateAlreadyUsed()
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": ErrInvalidState().Error(),
+			"error": stateErr.Error(),
 		})
 	}
 
-	// Intercambiar código por token
-	tokenResp, err := oauthService.ExchangeToken(c.Context(), code)
+	// Resolver el servicio OAuth; el SSO usa la conexión del tenant guardado
+	// en el estado al iniciar el login
+	var oauthService OAuthService
+	var ssoConn *tenant.SSOConnection
+	if provider == iam.OAuthProviderSSO {
+		tenantID, _ := stateData["tenant_id"].(string)
+		oauthService, ssoConn, err = ah.oauthResolver.ForTenant(c.Context(), kernel.TenantID(tenantID))
+	} else {
+		oauthService, err = ah.oauthResolver.Resolve(c.Context(), provider, "")
+	}
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
+			"error": ErrInvalidOAuthProvider().Error(),
 		})
 	}
 
-	// Obtener información del usuario
-	userInfo, err := oauthService.GetUserInfo(c.Context(), tokenResp.AccessToken)
+	// Intercambiar código por token
+	tokenResp, err := oauthService.ExchangeToken(c.Context(), code)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
+		return respondOAuthProviderError(c, err)
+	}
+
+	// Obtener información del usuario (del id_token validado en proveedores OIDC)
+	var userInfo *OAuthUserInfo
+	if oidcService, ok := oauthService.(OIDCUserInfoProvider); ok {
+		nonce, _ := stateData["nonce"].(string)
+		userInfo, err = oidcService.UserInfoFromTokens(c.Context(), tokenResp, nonce)
+	} else {
+		userInfo, err = oauthService.GetUserInfo(c.Context(), tokenResp.AccessToken)
+	}
+	if err != nil {
+		return respondOAuthProviderError(c, err)
+	}
+
+	// El IdP de un tenant solo autentica emails de sus dominios
+	if ssoConn != nil && !ssoConn.AllowsEmail(userInfo.Email) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Email domain is not allowed for this SSO connection",
 		})
 	}
 
 	// Find or create user
-	userEntity, tenantEntity, err := ah.findOrCreateUser(c.Context(), userInfo, provider, stateData, c.IP())
+	userEntity, tenantEntity, err := ah.findOrCreateUser(c.Context(), userInfo, provider, stateData, ssoConn, c.IP())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	// Respetar el límite de sesiones activas por usuario
+	if err := enforceSessionLimit(c.Context(), ah.sessionRepo, ah.tokenRepo, ah.config.Auth.Session, userEntity.ID); err != nil {
+		if IsTooManySessions(err) {
+			ah.auditService.LogLoginAttempt(c.Context(), userEntity.ID, tenantEntity.ID, "oauth_"+strings.ToLower(string(provider)), false, c.IP(), c.Get("User-Agent"))
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		// Si no se puede aplicar el límite no se bloquea el login
+		logx.WithFields(logx.Fields{"user_id": userEntity.ID}).
+			Warnf("failed to enforce session limit: %v", err)
+	}
+
+	// La sesión se identifica antes de emitir los tokens para vincularlos a ella
+	sessionID := generateID()
+
 	// Generar tokens de nuestra aplicación
 	accessToken, err := ah.tokenService.GenerateAccessToken(userEntity.ID, tenantEntity.ID, map[string]any{
-		"email":  userEntity.Email,
-		"name":   userEntity.Name,
-		"scopes": userEntity.Scopes,
+		"email":      userEntity.Email,
+		"name":       userEntity.Name,
+		"scopes":     userEntity.Scopes,
+		"session_id": sessionID,
 	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -244,6 +362,7 @@ func (ah *AuthHandlers) HandleCallback(c *fiber.Ctx) error {
 		ExpiresAt: time.Now().UTC().Add(ah.config.Auth.JWT.RefreshTokenTTL),
 		CreatedAt: time.Now(),
 		IsRevoked: false,
+		SessionID: sessionID,
 	}
 
 	if err := ah.tokenRepo.SaveRefreshToken(c.Context(), refreshToken); err != nil {
@@ -254,7 +373,7 @@ func (ah *AuthHandlers) HandleCallback(c *fiber.Ctx) error {
 
 	// Crear sesión de usuario
 	session := UserSession{
-		ID:           generateID(),
+		ID:           sessionID,
 		UserID:       userEntity.ID,
 		TenantID:     tenantEntity.ID,
 		SessionToken: generateID(),
@@ -342,8 +461,20 @@ func (ah *AuthHandlers) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
+	// Un token ya rotado presentado de nuevo indica robo: revocar toda la
+	// familia. Uno revocado a propósito (logout, cierre o desalojo de sesión)
+	// solo es inválido; escalar ahí cerraría las demás sesiones del usuario
+	if refreshToken.IsRevoked {
+		if refreshToken.WasRotated() {
+			return ah.handleRefreshTokenReuse(c, refreshToken)
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrInvalidRefreshToken().Error(),
+		})
+	}
+
 	// Verificar validez del refresh token
-	if !refreshToken.IsValid() {
+	if refreshToken.IsExpired() {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": ErrExpiredRefreshToken().Error(),
 		})
@@ -378,11 +509,23 @@ func (ah *AuthHandlers) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
+	// Una sesión cerrada (DELETE /auth/sessions/:id) invalida sus refresh tokens
+	// y una sesión vencida (o inactiva, con expiración deslizante) no se renueva
+	if refreshToken.SessionID != "" {
+		session, err := ah.sessionRepo.FindSession(c.Context(), refreshToken.SessionID)
+		if err != nil || session.IsExpired() || ah.sessionIdle(session) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": ErrInvalidRefreshToken().Error(),
+			})
+		}
+	}
+
 	// Generar nuevo access token
 	accessToken, err := ah.tokenService.GenerateAccessToken(userEntity.ID, tenantEntity.ID, map[string]any{
-		"email":  userEntity.Email,
-		"name":   userEntity.Name,
-		"scopes": userEntity.Scopes,
+		"email":      userEntity.Email,
+		"name":       userEntity.Name,
+		"scopes":     userEntity.Scopes,
+		"session_id": refreshToken.SessionID,
 	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -390,6 +533,42 @@ func (ah *AuthHandlers) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
+	// Rotar refresh token: revocar el usado y emitir uno nuevo
+	newRefreshTokenStr, err := ah.tokenService.GenerateRefreshToken(userEntity.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	newRefreshToken := RefreshToken{
+		ID:        generateID(),
+		Token:     newRefreshTokenStr,
+		UserID:    userEntity.ID,
+		TenantID:  tenantEntity.ID,
+		ExpiresAt: time.Now().UTC().Add(ah.config.Auth.JWT.RefreshTokenTTL),
+		CreatedAt: time.Now(),
+		IsRevoked: false,
+		SessionID: refreshToken.SessionID,
+	}
+
+	if err := ah.tokenRepo.RotateRefreshToken(c.Context(), refreshToken.Token, newRefreshToken); err != nil {
+		// Otra petición rotó el token primero: también es reutilización
+		if IsRefreshTokenReused(err) {
+			return ah.handleRefreshTokenReuse(c, refreshToken)
+		}
+		// O lo revocaron a propósito mientras tanto (logout, cierre de sesión)
+		var e *errx.Error
+		if errx.As(err, &e) && e.Code == CodeInvalidRefreshToken.Code {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": ErrInvalidRefreshToken().Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to rotate refresh token",
+		})
+	}
+
 	// Audit: token refresh
 	ah.auditService.LogTokenRefresh(c.Context(), userEntity.ID, tenantEntity.ID, c.IP())
 
@@ -403,10 +582,51 @@ func (ah *AuthHandlers) RefreshToken(c *fiber.Ctx) error {
 		SameSite: "Lax",
 	})
 
+	c.Cookie(&fiber.Cookie{
+		Name:     ah.config.Auth.Cookie.RefreshTokenName,
+		Value:    newRefreshTokenStr,
+		Expires:  time.Now().Add(ah.config.Auth.JWT.RefreshTokenTTL),
+		HTTPOnly: ah.config.Auth.Cookie.HTTPOnly,
+		Secure:   ah.config.Auth.Cookie.Secure,
+		SameSite: ah.config.Auth.Cookie.SameSite,
+		Domain:   ah.config.Auth.Cookie.Domain,
+		Path:     ah.config.Auth.Cookie.Path,
+	})
+
 	return c.JSON(fiber.Map{
-		"access_token": accessToken,
-		"token_type":   "Bearer",
-		"expires_in":   int(15 * time.Minute / time.Second),
+		"access_token":  accessToken,
+		"refresh_token": newRefreshTokenStr,
+		"token_type":    "Bearer",
+		"expires_in":    int(15 * time.Minute / time.Second),
+	})
+}
+
+// sessionIdle indica si la sesión superó el tiempo de inactividad permitido;
+// solo aplica con expiración deslizante
+func (ah *AuthHandlers) sessionIdle(session *UserSession) bool {
+	cfg := ah.config.Auth.Session
+	return cfg.SlidingExpiration && session.IsIdle(cfg.ExpirationTime)
+}
+
+// handleRefreshTokenReuse revoca todos los refresh tokens del usuario cuando
+// se presenta un token ya rotado, ya que el token pudo haber sido robado
+func (ah *AuthHandlers) handleRefreshTokenReuse(c *fiber.Ctx, refreshToken *RefreshToken) error {
+	if err := ah.tokenRepo.RevokeAllUserTokens(c.Context(), refreshToken.UserID); err != nil {
+		logx.WithFields(logx.Fields{
+			"user_id":   refreshToken.UserID,
+			"tenant_id": refreshToken.TenantID,
+		}).Errorf("failed to revoke tokens after refresh token reuse: %v", err)
+	}
+
+	logx.WithFields(logx.Fields{
+		"user_id":   refreshToken.UserID,
+		"tenant_id": refreshToken.TenantID,
+		"token_id":  refreshToken.ID,
+		"ip":        c.IP(),
+	}).Warn("Refresh token reuse detected, all user tokens revoked")
+
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": ErrRefreshTokenReused().Error(),
 	})
 }
 
@@ -439,12 +659,13 @@ func (ah *AuthHandlers) Logout(c *fiber.Ctx) error {
 			})
 		}
 		authContext = &kernel.AuthContext{
-			UserID:   &claims.UserID,
-			TenantID: claims.TenantID,
-			Email:    claims.Email,
-			Name:     claims.Name,
-			Scopes:   claims.Scopes,
-			IsAPIKey: false,
+			UserID:    &claims.UserID,
+			TenantID:  claims.TenantID,
+			Email:     claims.Email,
+			Name:      claims.Name,
+			Scopes:    claims.Scopes,
+			IsAPIKey:  false,
+			SessionID: claims.SessionID,
 		}
 	}
 
@@ -513,12 +734,13 @@ func (ah *AuthHandlers) GetCurrentUser(c *fiber.Ctx) error {
 			})
 		}
 		authContext = &kernel.AuthContext{
-			UserID:   &claims.UserID,
-			TenantID: claims.TenantID,
-			Email:    claims.Email,
-			Name:     claims.Name,
-			Scopes:   claims.Scopes,
-			IsAPIKey: false,
+			UserID:    &claims.UserID,
+			TenantID:  claims.TenantID,
+			Email:     claims.Email,
+			Name:      claims.Name,
+			Scopes:    claims.Scopes,
+			IsAPIKey:  false,
+			SessionID: claims.SessionID,
 		}
 	}
 
@@ -549,10 +771,12 @@ func (ah *AuthHandlers) GetCurrentUser(c *fiber.Ctx) error {
 }
 
 // findOrCreateUser handles user lookup, creation, and account linking for OAuth
-func (ah *AuthHandlers) findOrCreateUser(ctx context.Context, userInfo *OAuthUserInfo, provider iam.OAuthProvider, stateData map[string]interface{}, ip string) (*user.User, *tenant.Tenant, error) {
+// findOrCreateUser resuelve el usuario del login. Sin invitación solo pueden
+// entrar usuarios existentes del tenant de la conexión SSO (ssoConn).
+func (ah *AuthHandlers) findOrCreateUser(ctx context.Context, userInfo *OAuthUserInfo, provider iam.OAuthProvider, stateData map[string]interface{}, ssoConn *tenant.SSOConnection, ip string) (*user.User, *tenant.Tenant, error) {
 	var tenantEntity *tenant.Tenant
 	var invitationToken string
-	var invitationScopes []string
+	var invitationScopes, invitationRequestedScopes []string
 	var err error
 
 	// Verificar si hay un token de invitación
@@ -578,12 +802,25 @@ func (ah *AuthHandlers) findOrCreateUser(ctx context.Context, userInfo *OAuthUse
 			return nil, nil, errx.New("email does not match invitation", errx.TypeBusiness)
 		}
 
+		if ssoConn != nil && inv.GetTenantID() != ssoConn.TenantID {
+			return nil, nil, errx.New("invitation belongs to another tenant", errx.TypeBusiness)
+		}
+
 		invitationScopes = inv.GetScopes()
+		invitationRequestedScopes = inv.GetRequestedScopes()
 
 		tenantEntity, err = ah.tenantRepo.FindByID(ctx, inv.GetTenantID())
 		if err != nil {
 			return nil, nil, tenant.ErrTenantNotFound()
 		}
+	} else if ssoConn != nil {
+		tenantEntity, err = ah.tenantRepo.FindByID(ctx, ssoConn.TenantID)
+		if err != nil {
+			return nil, nil, tenant.ErrTenantNotFound()
+		}
+		if !tenantEntity.IsActive() {
+			return nil, nil, tenant.ErrTenantSuspended()
+		}
 	} else {
 		return nil, nil, errx.New("invitation required for registration", errx.TypeAuthorization)
 	}
@@ -591,27 +828,52 @@ func (ah *AuthHandlers) findOrCreateUser(ctx context.Context, userInfo *OAuthUse
 	// Account linking: look up existing user
 	existingUser, err := ah.userRepo.FindByEmail(ctx, userInfo.Email, tenantEntity.ID)
 	if err == nil {
+		linked := false
 		if existingUser.OAuthProvider != provider || existingUser.OAuthProviderID != userInfo.ID {
+			// Solo se vincula por email si el proveedor lo verificó: si no,
+			// quien registre ese email en el IdP tomaría la cuenta
+			if !userInfo.EmailVerified {
+				return nil, nil, user.ErrEmailNotVerified().WithDetail("provider", string(provider))
+			}
 			existingUser.LinkOAuth(provider, userInfo.ID)
 			existingUser.UpdateProfile(userInfo.Name, userInfo.Picture)
+			linked = true
+		}
+
+		// Re-sync scopes from IdP groups on every login
+		scopesSynced := ah.syncGroupScopes(ctx, tenantEntity.ID, existingUser, userInfo.Groups)
 
+		if linked || scopesSynced {
 			if err := ah.userRepo.Save(ctx, *existingUser); err != nil {
 				return nil, nil, err
 			}
+		}
+		if linked {
 			ah.auditService.LogAccountLinked(ctx, existingUser.ID, tenantEntity.ID, "oauth_"+strings.ToLower(string(provider)), ip)
 		}
 		return existingUser, tenantEntity, nil
 	}
 
+	// Las cuentas nuevas siempre requieren invitación
+	if invitationToken == "" {
+		return nil, nil, errx.New("invitation required for registration", errx.TypeAuthorization)
+	}
+
+	// La invitación va a un email; la cuenta nueva debe probar que es suyo
+	if !userInfo.EmailVerified {
+		return nil, nil, user.ErrEmailNotVerified().WithDetail("provider", string(provider))
+	}
+
 	// Verificar si el tenant puede agregar más usuarios
 	if !tenantEntity.CanAddUser() {
 		return nil, nil, tenant.ErrMaxUsersReached()
 	}
 
 	// Determine scopes
-	var userScopes []string
+	var userScopes, requestedScopes []string
 	if len(invitationScopes) > 0 {
 		userScopes = invitationScopes
+		requestedScopes = invitationRequestedScopes
 	} else {
 		userScopes = scopes.GetScopesByGroup("viewer")
 	}
@@ -625,6 +887,7 @@ func (ah *AuthHandlers) findOrCreateUser(ctx context.Context, userInfo *OAuthUse
 		Picture:         ptrx.String(userInfo.Picture),
 		Status:          user.UserStatusActive,
 		Scopes:          userScopes,
+		RequestedScopes: requestedScopes,
 		OAuthProvider:   provider,
 		OAuthProviderID: userInfo.ID,
 		OTPEnabled:      false, // 🔥 OAuth users don't have OTP by default
@@ -633,39 +896,174 @@ func (ah *AuthHandlers) findOrCreateUser(ctx context.Context, userInfo *OAuthUse
 		UpdatedAt:       time.Now(),
 	}
 
-	// Save user
-	if err := ah.userRepo.Save(ctx, *newUser); err != nil {
+	// Just-in-time provisioning: apply scopes mapped from IdP groups
+	ah.syncGroupScopes(ctx, tenantEntity.ID, newUser, userInfo.Groups)
+
+	// Guardar el usuario, incrementar el contador del tenant y aceptar la
+	// invitación en una sola transacción: si un paso falla no queda nada
+	// escrito a medias
+	var acceptedInvitation *invitation.Invitation
+	err = ah.withinTx(ctx, func(ctx context.Context) error {
+		if err := ah.userRepo.Save(ctx, *newUser); err != nil {
+			return err
+		}
+
+		if err := tenantEntity.AddUser(); err != nil {
+			return err
+		}
+		if err := ah.tenantRepo.Save(ctx, *tenantEntity); err != nil {
+			return err
+		}
+
+		inv, err := ah.invitationRepo.FindByToken(ctx, invitationToken)
+		if err != nil {
+			return err
+		}
+		if err := inv.Accept(newUser.ID); err != nil {
+			return err
+		}
+		if err := ah.invitationRepo.Save(ctx, *inv); err != nil {
+			return err
+		}
+		acceptedInvitation = inv
+		return nil
+	})
+	if err != nil {
 		return nil, nil, err
 	}
 
-	// Increment tenant user count
-	if err := tenantEntity.AddUser(); err != nil {
-		ah.userRepo.Delete(ctx, newUser.ID, tenantEntity.ID)
-		return nil, nil, err
+	// Audit: account created
+	ah.auditService.LogAccountCreated(ctx, newUser.ID, tenantEntity.ID, "oauth_"+strings.ToLower(string(provider)), ip)
+	ah.auditService.LogInvitationAccepted(ctx, acceptedInvitation.GetID(), newUser.ID, tenantEntity.ID, ip)
+
+	return newUser, tenantEntity, nil
+}
+
+// syncGroupScopes makes the IdP authoritative for every scope covered by a
+// mapped template: scopes from groups the user no longer belongs to are
+// removed and scopes from current groups are added. Scopes not covered by any
+// mapping are left untouched. Returns true if the user's scopes changed.
+//
+// A nil groups slice means the provider does not report groups, in which case
+// nothing is synced. Neither is it for tenants that opted out (see
+// groupSyncEnabled).
+func (ah *AuthHandlers) syncGroupScopes(ctx context.Context, tenantID kernel.TenantID, u *user.User, groups []string) bool {
+	groupSync := ah.config.OAuth.GroupSync
+	if len(groupSync.Mappings) == 0 || groups == nil || !ah.groupSyncEnabled(ctx, tenantID) {
+		return false
 	}
 
-	// Save updated tenant
-	if err := ah.tenantRepo.Save(ctx, *tenantEntity); err != nil {
-		// Log error but don't fail
+	managed := make(map[string]bool)
+	granted := []string{}
+	for group, template := range groupSync.Mappings {
+		templateScopes := scopes.GetScopesByGroup(template)
+		for _, scope := range templateScopes {
+			managed[scope] = true
+		}
+		if slices.ContainsFunc(groups, func(g string) bool { return strings.EqualFold(g, group) }) {
+			granted = append(granted, templateScopes...)
+		}
 	}
 
-	// Audit: account created
-	ah.auditService.LogAccountCreated(ctx, newUser.ID, tenantEntity.ID, "oauth_"+strings.ToLower(string(provider)), ip)
+	synced := []string{}
+	seen := make(map[string]bool)
+	for _, scope := range u.Scopes {
+		if !managed[scope] && !seen[scope] {
+			synced = append(synced, scope)
+			seen[scope] = true
+		}
+	}
+	for _, scope := range granted {
+		if !seen[scope] {
+			synced = append(synced, scope)
+			seen[scope] = true
+		}
+	}
 
-	// Accept the invitation
-	if invitationToken != "" {
-		inv, err := ah.invitationRepo.FindByToken(ctx, invitationToken)
-		if err == nil {
-			if err := inv.Accept(newUser.ID); err == nil {
-				ah.invitationRepo.Save(ctx, *inv)
-			}
+	if sameScopes(u.Scopes, synced) {
+		return false
+	}
+
+	u.SetScopes(synced)
+	return true
+}
+
+// groupSyncEnabled reports whether group sync applies to the tenant: its
+// tenant.ConfigOAuthGroupSync setting wins over OAUTH_GROUP_SYNC_ENABLED. If
+// the setting cannot be read nothing is synced, since syncing removes scopes.
+func (ah *AuthHandlers) groupSyncEnabled(ctx context.Context, tenantID kernel.TenantID) bool {
+	enabled := ah.config.OAuth.GroupSync.Enabled
+	if ah.tenantConfigRepo == nil {
+		return enabled
+	}
+
+	settings, err := ah.tenantConfigRepo.FindByTenant(ctx, tenantID)
+	if err != nil {
+		logx.WithFields(logx.Fields{"tenant_id": tenantID}).
+			Errorf("failed to read tenant config, skipping group sync: %v", err)
+		return false
+	}
+
+	if value, ok := settings[tenant.ConfigOAuthGroupSync]; ok {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
 		}
 	}
+	return enabled
+}
 
-	return newUser, tenantEntity, nil
+// sameScopes compares two scope lists ignoring order and duplicates
+func sameScopes(a, b []string) bool {
+	setA := make(map[string]bool, len(a))
+	for _, s := range a {
+		setA[s] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, s := range b {
+		setB[s] = true
+	}
+	if len(setA) != len(setB) {
+		return false
+	}
+	for s := range setA {
+		if !setB[s] {
+			return false
+		}
+	}
+	return true
 }
 
 // Helper functions
 func generateID() string {
 	return uuid.NewString()
 }
+
+// withinTx ejecuta fn en una transacción si hay transactor configurado, o
+// directamente si no
+func (ah *AuthHandlers) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ah.transactor == nil {
+		return fn(ctx)
+	}
+	return ah.transactor.WithinTx(ctx, fn)
+}
+
+// respondOAuthProviderError responde un fallo del IdP en el callback. Un
+// timeout es 504 y se puede reintentar; el resto es 400 con el
+// error_description del IdP si lo envió.
+func respondOAuthProviderError(c *fiber.Ctx, err error) error {
+	if IsOAuthTimeout(err) {
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{
+			"error":     err.Error(),
+			"retryable": true,
+		})
+	}
+
+	body := fiber.Map{"error": err.Error()}
+	var e *errx.Error
+	if errx.As(err, &e) {
+		if description, ok := e.Details["error_description"].(string); ok {
+			body["error_description"] = description
+		}
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(body)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+type oauthTenantRepo struct{ tenant.TenantRepository }
+
+func (oauthTenantRepo) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	return &tenant.Tenant{ID: id, Status: tenant.TenantStatusActive, MaxUsers: 10}, nil
+}
+
+type oauthInvitationRepo struct {
+	invitation.InvitationRepository
+}
+
+func (oauthInvitationRepo) FindByToken(_ context.Context, token string) (*invitation.Invitation, error) {
+	return &invitation.Invitation{
+		ID:        "inv-1",
+		TenantID:  "t1",
+		Email:     "new@acme.com",
+		Token:     token,
+		Status:    invitation.InvitationStatusPending,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil
+}
+
+type oauthAudit struct{ AuditService }
+
+func (oauthAudit) LogAccountLinked(context.Context, kernel.UserID, kernel.TenantID, string, string) {}
+
+func TestFindOrCreateUserRequiresVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	users := userinfra.NewInMemoryUserRepository()
+	if err := users.Save(ctx, user.User{ID: "u1", TenantID: "t1", Email: "ana@acme.com", Status: user.UserStatusActive}); err != nil {
+		t.Fatal(err)
+	}
+	ah := &AuthHandlers{
+		userRepo:       users,
+		tenantRepo:     oauthTenantRepo{},
+		invitationRepo: oauthInvitationRepo{},
+		auditService:   oauthAudit{},
+		config:         &config.Config{},
+	}
+	sso := &tenant.SSOConnection{TenantID: "t1"}
+
+	// An IdP account claiming someone's email without verifying it
+	unverified := &OAuthUserInfo{ID: "idp-1", Email: "ana@acme.com"}
+	if _, _, err := ah.findOrCreateUser(ctx, unverified, iam.OAuthProviderSSO, nil, sso, ""); !errx.Is(err, user.CodeEmailNotVerified) {
+		t.Fatalf("linking unverified email error = %v, want EMAIL_NOT_VERIFIED", err)
+	}
+	if existing, _ := users.FindByID(ctx, "u1", "t1"); existing.OAuthProviderID != "" {
+		t.Fatalf("unverified identity linked: %+v", existing)
+	}
+
+	invited := &OAuthUserInfo{ID: "idp-2", Email: "new@acme.com"}
+	stateData := map[string]any{"invitation_token": "token-1"}
+	if _, _, err := ah.findOrCreateUser(ctx, invited, iam.OAuthProviderSSO, stateData, nil, ""); !errx.Is(err, user.CodeEmailNotVerified) {
+		t.Fatalf("creating unverified user error = %v, want EMAIL_NOT_VERIFIED", err)
+	}
+
+	verified := &OAuthUserInfo{ID: "idp-1", Email: "ana@acme.com", EmailVerified: true}
+	linked, _, err := ah.findOrCreateUser(ctx, verified, iam.OAuthProviderSSO, nil, sso, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.OAuthProviderID != "idp-1" {
+		t.Errorf("verified identity not linked: %+v", linked)
+	}
+
+	// Once linked the identity logs in even if the IdP stops reporting the
+	// email as verified
+	if _, _, err := ah.findOrCreateUser(ctx, unverified, iam.OAuthProviderSSO, nil, sso, ""); err != nil {
+		t.Errorf("linked identity rejected: %v", err)
+	}
+}
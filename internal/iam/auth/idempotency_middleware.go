@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Idempotency headers
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyReservationTTL = 1 * time.Minute
+)
+
+// IdempotencyMiddleware replays the response of a POST or PUT retried with the
+// same Idempotency-Key. Keys are scoped per tenant and caller (user or API
+// key), so it must be mounted after Authenticate: another member of the tenant
+// sending the same key gets its own request, never the stored response.
+// Requests without the header pass through untouched.
+//
+// Stored responses may carry secrets (POST /api-keys returns the raw key), so
+// the store never sees the Idempotency-Key or the body in clear: records are
+// kept under a hash of the key and bodies are sealed with AES-GCM under a key
+// derived from it, which only the client knows.
+//
+// A nil *IdempotencyMiddleware is valid and disables the behavior, so handlers
+// can mount it unconditionally.
+type IdempotencyMiddleware struct {
+	store IdempotencyStore
+	ttl   time.Duration
+}
+
+// NewIdempotencyMiddleware creates the middleware. Stored responses are
+// replayed for ttl.
+func NewIdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Handler returns the middleware. The first request with a key reserves it;
+// a concurrent retry gets ErrIdempotencyInProgress, a later one the stored
+// response, and the same key with a different body ErrIdempotencyKeyReused.
+// 5xx responses are not stored so the client can retry them.
+func (m *IdempotencyMiddleware) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if m == nil || (c.Method() != fiber.MethodPost && c.Method() != fiber.MethodPut) {
+			return c.Next()
+		}
+
+		idempotencyKey := c.Get(HeaderIdempotencyKey)
+		if idempotencyKey == "" {
+			return c.Next()
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return ErrInvalidIdempotencyKey().
+				WithDetail("max_length", maxIdempotencyKeyLength)
+		}
+
+		authContext, ok := GetAuthContext(c)
+		if !ok {
+			return c.Next()
+		}
+
+		scope := authContext.TenantID.String() + ":" + idempotencyPrincipal(c, authContext)
+		key := "idempotency:" + scope + ":" + deriveIdempotencyKey("lookup", scope, idempotencyKey)
+		fingerprint := requestFingerprint(c)
+
+		existing, reserved, err := m.store.Reserve(c.Context(), key, IdempotencyRecord{Fingerprint: fingerprint}, idempotencyReservationTTL)
+		if err != nil {
+			logx.WithField("path", c.Path()).
+				Warnf("idempotency store unavailable, processing request without it: %v", err)
+			return c.Next()
+		}
+
+		if !reserved {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				return ErrIdempotencyKeyReused()
+			case !existing.Completed():
+				return ErrIdempotencyInProgress()
+			}
+			body, err := openIdempotentBody(scope, idempotencyKey, existing.Body)
+			if err != nil {
+				return errx.Wrap(err, "failed to open stored idempotent response", errx.TypeInternal)
+			}
+			c.Set(HeaderIdempotentReplayed, "true")
+			if existing.ContentType != "" {
+				c.Set(fiber.HeaderContentType, existing.ContentType)
+			}
+			return c.Status(existing.StatusCode).Send(body)
+		}
+
+		// Errors are formatted by the global error handler, which runs after
+		// this middleware; run it here so the final response is stored
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = m.store.Release(c.Context(), key)
+				return handlerErr
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			if err := m.store.Release(c.Context(), key); err != nil {
+				logx.Warnf("failed to release idempotency key: %v", err)
+			}
+			return nil
+		}
+
+		body, err := sealIdempotentBody(scope, idempotencyKey, c.Response().Body())
+		if err != nil {
+			logx.Warnf("failed to seal idempotent response: %v", err)
+			_ = m.store.Release(c.Context(), key)
+			return nil
+		}
+		record := IdempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        body,
+		}
+		if err := m.store.Complete(c.Context(), key, record, m.ttl); err != nil {
+			logx.Warnf("failed to store idempotent response: %v", err)
+		}
+		return nil
+	}
+}
+
+// requestFingerprint identifies the request a key was first used with
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Path()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotencyPrincipal identifies the caller within the tenant: the API key
+// for API key requests, the user otherwise
+func idempotencyPrincipal(c *fiber.Ctx, authContext *kernel.AuthContext) string {
+	if keyID, ok := c.Locals("api_key_id").(string); ok && keyID != "" {
+		return "apikey:" + keyID
+	}
+	if authContext.UserID != nil {
+		return "user:" + authContext.UserID.String()
+	}
+	return "anonymous"
+}
+
+// deriveIdempotencyKey derives from the client's Idempotency-Key the value
+// used for purpose ("lookup" for the store key, "seal" for the AES key), hex
+// encoded. Different purposes give unrelated values, so the stored lookup
+// hash does not reveal the sealing key.
+func deriveIdempotencyKey(purpose, scope, idempotencyKey string) string {
+	hash := sha256.New()
+	hash.Write([]byte(purpose))
+	hash.Write([]byte{0})
+	hash.Write([]byte(scope))
+	hash.Write([]byte{0})
+	hash.Write([]byte(idempotencyKey))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotencyAEAD returns the AES-256-GCM cipher of a key in scope
+func idempotencyAEAD(scope, idempotencyKey string) (cipher.AEAD, error) {
+	key, err := hex.DecodeString(deriveIdempotencyKey("seal", scope, idempotencyKey))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealIdempotentBody encrypts a response body as nonce || ciphertext
+func sealIdempotentBody(scope, idempotencyKey string, body []byte) ([]byte, error) {
+	aead, err := idempotencyAEAD(scope, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, body, nil), nil
+}
+
+// openIdempotentBody decrypts a body sealed by sealIdempotentBody
+func openIdempotentBody(scope, idempotencyKey string, sealed []byte) ([]byte, error) {
+	aead, err := idempotencyAEAD(scope, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("stored response is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, key string, record IdempotencyRecord, _ time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok {
+		return &existing, false, nil
+	}
+	s.records[key] = record
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, key string, record IdempotencyRecord, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	created := 0
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusConflict).SendString(err.Error())
+		},
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("auth", &kernel.AuthContext{TenantID: "tenant-1", IsAPIKey: true})
+		return c.Next()
+	})
+	idempotency := NewIdempotencyMiddleware(&memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}, time.Hour)
+	app.Post("/things", idempotency.Handler(), func(c *fiber.Ctx) error {
+		created++
+		return c.Status(fiber.StatusCreated).SendString("thing-" + strconv.Itoa(created))
+	})
+
+	send := func(key, body string) (int, string, string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/things", strings.NewReader(body))
+		req.Header.Set(HeaderIdempotencyKey, key)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data), resp.Header.Get(HeaderIdempotentReplayed)
+	}
+
+	status, body, replayed := send("key-1", `{"name":"a"}`)
+	if status != fiber.StatusCreated || body != "thing-1" || replayed != "" {
+		t.Fatalf("first request = %d %q replayed=%q", status, body, replayed)
+	}
+
+	status, body, replayed = send("key-1", `{"name":"a"}`)
+	if status != fiber.StatusCreated || body != "thing-1" || replayed != "true" {
+		t.Fatalf("retry = %d %q replayed=%q, want stored response", status, body, replayed)
+	}
+	if created != 1 {
+		t.Fatalf("handler ran %d times, want 1", created)
+	}
+
+	status, body, _ = send("key-1", `{"name":"b"}`)
+	if status != fiber.StatusConflict || !strings.Contains(body, "different request") {
+		t.Fatalf("reused key = %d %q, want conflict", status, body)
+	}
+
+	if status, body, _ = send("key-2", `{"name":"b"}`); status != fiber.StatusCreated || body != "thing-2" {
+		t.Fatalf("new key = %d %q", status, body)
+	}
+}
+
+func TestIdempotencyKeysAreScopedPerCaller(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+	idempotency := NewIdempotencyMiddleware(store, time.Hour)
+
+	created := 0
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		userID := kernel.UserID(c.Get("X-User"))
+		c.Locals("auth", &kernel.AuthContext{UserID: &userID, TenantID: "tenant-1"})
+		return c.Next()
+	})
+	app.Post("/api-keys", idempotency.Handler(), func(c *fiber.Ctx) error {
+		created++
+		return c.Status(fiber.StatusCreated).SendString("secret-" + strconv.Itoa(created))
+	})
+
+	send := func(user string) (string, string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/api-keys", strings.NewReader(`{"name":"ci"}`))
+		req.Header.Set(HeaderIdempotencyKey, "shared-key")
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return string(data), resp.Header.Get(HeaderIdempotentReplayed)
+	}
+
+	if body, _ := send("alice"); body != "secret-1" {
+		t.Fatalf("alice = %q", body)
+	}
+	if body, replayed := send("alice"); body != "secret-1" || replayed != "true" {
+		t.Fatalf("alice retry = %q replayed=%q, want her stored response", body, replayed)
+	}
+	if body, replayed := send("mallory"); body != "secret-2" || replayed != "" {
+		t.Fatalf("mallory with alice's key = %q replayed=%q, want a request of her own", body, replayed)
+	}
+
+	for key, record := range store.records {
+		if strings.Contains(key, "shared-key") {
+			t.Errorf("store key %q contains the Idempotency-Key", key)
+		}
+		if strings.Contains(string(record.Body), "secret-") {
+			t.Errorf("stored body %q is not sealed", record.Body)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKSProvider exposes the public verification keys (JWTService)
+type JWKSProvider interface {
+	JWKS() JWKSet
+}
+
+// JWKSHandlers publishes the public keys of RS256 / ES256 tokens so other
+// services can verify them without sharing a secret
+type JWKSHandlers struct {
+	keys JWKSProvider
+}
+
+// NewJWKSHandlers creates the handlers
+func NewJWKSHandlers(keys JWKSProvider) *JWKSHandlers {
+	return &JWKSHandlers{keys: keys}
+}
+
+// RegisterRoutes mounts GET /.well-known/jwks.json. Mount it on the app root:
+// verifiers look for it next to the issuer.
+func (h *JWKSHandlers) RegisterRoutes(router fiber.Router) {
+	router.Get("/.well-known/jwks.json", h.GetJWKS)
+}
+
+// GetJWKS returns the current and retired public keys. Verifiers may cache
+// the set for a few minutes; a token with an unknown kid should trigger a
+// refetch.
+func (h *JWKSHandlers) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.keys.JWKS())
+}
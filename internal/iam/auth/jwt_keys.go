@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritmos de firma soportados
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// SigningKey es una clave de JWT identificada por su kid. Las claves HMAC
+// firman y verifican con el mismo secreto; las RSA y EC firman con la privada
+// y publican la pública en el JWKS. Una clave sin privada solo verifica.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	signKey   any // []byte, *rsa.PrivateKey o *ecdsa.PrivateKey; nil = solo verificación
+	verifyKey any // []byte, *rsa.PublicKey o *ecdsa.PublicKey
+
+	// thumbprint es el kid derivado de la clave. Los tokens firmados con un kid
+	// derivado se siguen validando cuando la clave pasa a retirada con otro kid.
+	thumbprint string
+}
+
+// NewHMACKey crea una clave HS256. Con id vacío el kid se deriva del secreto.
+func NewHMACKey(id string, secret []byte) SigningKey {
+	thumbprint := keyThumbprint(secret)
+	if id == "" {
+		id = thumbprint
+	}
+	return SigningKey{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret, thumbprint: thumbprint}
+}
+
+// NewRSAKey crea una clave RS256. Con id vacío el kid se deriva de la pública.
+func NewRSAKey(id string, key *rsa.PrivateKey) (SigningKey, error) {
+	k, err := NewVerificationKey(id, &key.PublicKey)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	k.signKey = key
+	return k, nil
+}
+
+// NewECDSAKey crea una clave ES256 (P-256). Con id vacío el kid se deriva de
+// la pública.
+func NewECDSAKey(id string, key *ecdsa.PrivateKey) (SigningKey, error) {
+	k, err := NewVerificationKey(id, &key.PublicKey)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	k.signKey = key
+	return k, nil
+}
+
+// NewVerificationKey crea una clave que solo verifica, p. ej. la pública de
+// una clave retirada. El algoritmo se deduce del tipo: RSA → RS256, P-256 → ES256.
+func NewVerificationKey(id string, pub crypto.PublicKey) (SigningKey, error) {
+	var method jwt.SigningMethod
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < 2048 {
+			return SigningKey{}, fmt.Errorf("RSA key must be at least 2048 bits, got %d", key.N.BitLen())
+		}
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return SigningKey{}, fmt.Errorf("ES256 requires a P-256 key, got %s", key.Curve.Params().Name)
+		}
+		method = jwt.SigningMethodES256
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	thumbprint := keyThumbprint(der)
+	if id == "" {
+		id = thumbprint
+	}
+
+	return SigningKey{ID: id, Method: method, verifyKey: pub, thumbprint: thumbprint}, nil
+}
+
+// ParsePEMKey lee una clave privada (PKCS#1, PKCS#8 o SEC 1) o pública (PKIX)
+// en PEM. Las privadas firman; las públicas solo verifican.
+func ParsePEMKey(id string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+		return NewRSAKey(id, key)
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+		return NewECDSAKey(id, key)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			return NewRSAKey(id, key)
+		case *ecdsa.PrivateKey:
+			return NewECDSAKey(id, key)
+		default:
+			return SigningKey{}, fmt.Errorf("unsupported private key type %T", key)
+		}
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return NewVerificationKey(id, pub)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// CanSign indica si la clave tiene la parte privada
+func (k SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// IsAsymmetric indica si la clave es RSA o EC, es decir, publicable en el JWKS
+func (k SigningKey) IsAsymmetric() bool {
+	_, isHMAC := k.verifyKey.([]byte)
+	return !isHMAC
+}
+
+// LoadSigningKeys construye la clave actual y las retiradas desde la
+// configuración:
+//
+//   - JWT_SIGNING_ALGORITHM=HS256 firma con JWT_SECRET_KEY; RS256 / ES256 con
+//     la clave privada PEM de JWT_PRIVATE_KEY_FILE
+//   - JWT_KEY_ID es el kid de la clave actual (por defecto derivado de la clave)
+//   - JWT_PREVIOUS_KEYS son las claves retiradas que se siguen aceptando:
+//     "kid=secreto" para HMAC y "kid=file:/ruta/clave.pem" para RSA / EC
+func LoadSigningKeys(cfg *config.JWTConfig) (SigningKey, []SigningKey, error) {
+	var current SigningKey
+	switch strings.ToUpper(cfg.SigningAlgorithm) {
+	case "", AlgHS256:
+		if cfg.SecretKey == "" {
+			return SigningKey{}, nil, fmt.Errorf("JWT_SECRET_KEY is required for HS256")
+		}
+		current = NewHMACKey(cfg.KeyID, []byte(cfg.SecretKey))
+	case AlgRS256, AlgES256:
+		key, err := loadPEMKeyFile(cfg.KeyID, cfg.PrivateKeyFile)
+		if err != nil {
+			return SigningKey{}, nil, fmt.Errorf("failed to load JWT_PRIVATE_KEY_FILE: %w", err)
+		}
+		if !key.CanSign() {
+			return SigningKey{}, nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE must hold a private key")
+		}
+		if key.Method.Alg() != strings.ToUpper(cfg.SigningAlgorithm) {
+			return SigningKey{}, nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE holds a %s key, JWT_SIGNING_ALGORITHM is %s", key.Method.Alg(), cfg.SigningAlgorithm)
+		}
+		current = key
+	default:
+		return SigningKey{}, nil, fmt.Errorf("unsupported JWT_SIGNING_ALGORITHM %q", cfg.SigningAlgorithm)
+	}
+
+	previous := make([]SigningKey, 0, len(cfg.PreviousKeys))
+	for id, value := range cfg.PreviousKeys {
+		if path, ok := strings.CutPrefix(value, "file:"); ok {
+			key, err := loadPEMKeyFile(id, path)
+			if err != nil {
+				return SigningKey{}, nil, fmt.Errorf("failed to load previous JWT key %q: %w", id, err)
+			}
+			previous = append(previous, key)
+			continue
+		}
+		previous = append(previous, NewHMACKey(id, []byte(value)))
+	}
+
+	return current, previous, nil
+}
+
+func loadPEMKeyFile(id, path string) (SigningKey, error) {
+	if path == "" {
+		return SigningKey{}, fmt.Errorf("key file path is empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return ParsePEMKey(id, data)
+}
+
+// keyThumbprint deriva un kid estable del material de la clave sin exponerlo
+func keyThumbprint(material []byte) string {
+	sum := sha256.Sum256(material)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ============================================================================
+// JWKS
+// ============================================================================
+
+// JWK es una clave pública en formato JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet es el documento de /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// toJWK retorna la pública de la clave; false para claves HMAC
+func (k SigningKey) toJWK() (JWK, bool) {
+	jwk := JWK{Use: "sig", Alg: k.Method.Alg(), Kid: k.ID}
+
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdh, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, false
+		}
+		// Punto sin comprimir: 0x04 || X || Y, 32 bytes cada coordenada en P-256
+		point := ecdh.Bytes()[1:]
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[:32])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[32:])
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
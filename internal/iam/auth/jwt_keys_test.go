@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testJWTConfig() *config.JWTConfig {
+	return &config.JWTConfig{
+		SecretKey:       "old-secret-at-least-32-characters-long",
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+		Issuer:          "manifesto",
+		Audience:        []string{"manifesto-api"},
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	cfg := testJWTConfig()
+	oldKey := NewHMACKey("old", []byte(cfg.SecretKey))
+	oldService := NewJWTServiceFromConfig(cfg, WithSigningKeys(oldKey))
+
+	oldToken, err := oldService.GenerateAccessToken("u1", "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := NewRSAKey("new", rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := NewJWTServiceFromConfig(cfg, WithSigningKeys(newKey, oldKey))
+
+	if _, err := rotated.ValidateAccessToken(oldToken); err != nil {
+		t.Fatalf("token of the retired key rejected: %v", err)
+	}
+
+	newToken, err := rotated.GenerateAccessToken("u1", "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &JWTClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != AlgRS256 {
+		t.Fatalf("header = %v, want kid new and RS256", parsed.Header)
+	}
+	if _, err := rotated.ValidateAccessToken(newToken); err != nil {
+		t.Fatalf("token of the current key rejected: %v", err)
+	}
+
+	// Once the old key is dropped its tokens stop validating
+	if _, err := NewJWTServiceFromConfig(cfg, WithSigningKeys(newKey)).ValidateAccessToken(oldToken); err == nil {
+		t.Fatal("token of a removed key accepted")
+	}
+}
+
+func TestJWTRejectsAlgorithmMismatch(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewECDSAKey("ec", ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewJWTServiceFromConfig(testJWTConfig(), WithSigningKeys(key))
+
+	// An HS256 token claiming the EC kid must not be accepted
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Issuer: "manifesto"})
+	forged.Header["kid"] = "ec"
+	forgedString, err := forged.SignedString([]byte("anything"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ValidateAccessToken(forgedString); err == nil {
+		t.Fatal("token with a mismatched algorithm accepted")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewECDSAKey("", ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewJWTServiceFromConfig(testJWTConfig(), WithSigningKeys(key, NewHMACKey("hmac", []byte("secret"))))
+
+	set := service.JWKS()
+	if len(set.Keys) != 1 {
+		t.Fatalf("JWKS has %d keys, want only the EC key", len(set.Keys))
+	}
+	jwk := set.Keys[0]
+	if jwk.Kty != "EC" || jwk.Crv != "P-256" || jwk.Alg != AlgES256 || jwk.Kid != key.ID || jwk.X == "" || jwk.Y == "" {
+		t.Fatalf("unexpected JWK %+v", jwk)
+	}
+}
+
+func TestJWTRetiredKeyMatchedByDerivedKid(t *testing.T) {
+	cfg := testJWTConfig()
+	oldKey := NewHMACKey("", []byte(cfg.SecretKey)) // derived kid, as with JWT_KEY_ID unset
+	oldToken, err := NewJWTServiceFromConfig(cfg, WithSigningKeys(oldKey)).GenerateAccessToken("u1", "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := NewJWTServiceFromConfig(cfg, WithSigningKeys(
+		NewHMACKey("", []byte("new-secret-at-least-32-characters-long")),
+		NewHMACKey("old", []byte(cfg.SecretKey)), // retired under a new name
+	))
+	if _, err := rotated.ValidateAccessToken(oldToken); err != nil {
+		t.Fatalf("token of the renamed retired key rejected: %v", err)
+	}
+}
+
+func TestJWTKidlessTokenAfterRotation(t *testing.T) {
+	cfg := testJWTConfig()
+	oldKey := NewHMACKey("old", []byte(cfg.SecretKey))
+	claims := JWTClaims{
+		UserID:   "u1",
+		TenantID: "t1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    cfg.Issuer,
+			Audience:  cfg.Audience,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	// Tokens issued before kids were added carry no kid header
+	kidless, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.SecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRSA, err := NewRSAKey("new-rsa", rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newHMAC := NewHMACKey("new-hmac", []byte("new-secret-at-least-32-characters-long"))
+
+	for name, current := range map[string]SigningKey{"RS256": newRSA, "HS256": newHMAC} {
+		rotated := NewJWTServiceFromConfig(cfg, WithSigningKeys(current, oldKey))
+		if _, err := rotated.ValidateAccessToken(kidless); err != nil {
+			t.Errorf("kid-less token rejected after rotating to %s: %v", name, err)
+		}
+	}
+
+	// Without the key that signed it, trying the other keys does not help
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("unknown-secret-at-least-32-characters"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewJWTServiceFromConfig(cfg, WithSigningKeys(newHMAC, oldKey)).ValidateAccessToken(forged); err == nil {
+		t.Error("kid-less token of an unknown key accepted")
+	}
+	if _, err := NewJWTServiceFromConfig(cfg, WithSigningKeys(newHMAC)).ValidateAccessToken(kidless); err == nil {
+		t.Error("kid-less token accepted after its key was removed")
+	}
+}
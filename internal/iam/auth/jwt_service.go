@@ -2,31 +2,149 @@ package auth
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/config"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// JWTService implementación del TokenService usando JWT
+// JWTService implementación del TokenService usando JWT. Firma con la clave
+// actual e incluye su kid en el header; valida contra todas las claves
+// configuradas, así los tokens firmados con una clave retirada siguen siendo
+// válidos hasta que expiran.
 type JWTService struct {
-	secretKey       []byte
+	signingKey      SigningKey
+	keys            []SigningKey
+	keysByID        map[string]SigningKey // por kid y por kid derivado
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
 	issuer          string
 	audience        []string
+	strictAudience  bool
 }
 
-// NewJWTService crea una nueva instancia del servicio JWT
-func NewJWTServiceFromConfig(cfg *config.JWTConfig) *JWTService {
-	return &JWTService{
-		secretKey:       []byte(cfg.SecretKey),
+// JWTOption configura el JWTService
+type JWTOption func(*JWTService)
+
+// WithStrictAudience exige que los tokens de acceso traigan un aud que
+// incluya alguna de las audiencias configuradas (activo por defecto). Con
+// false se aceptan tokens sin aud, emitidos antes de configurar la audiencia;
+// un aud de otro servicio se rechaza igualmente.
+func WithStrictAudience(strict bool) JWTOption {
+	return func(j *JWTService) {
+		j.strictAudience = strict
+	}
+}
+
+// WithSigningKeys reemplaza las claves: current firma los tokens nuevos y
+// previous (claves retiradas o solo públicas) siguen validando los emitidos
+// antes de la rotación. Ver LoadSigningKeys.
+func WithSigningKeys(current SigningKey, previous ...SigningKey) JWTOption {
+	return func(j *JWTService) {
+		j.signingKey = current
+		j.keys = append([]SigningKey{current}, previous...)
+		j.keysByID = make(map[string]SigningKey, 2*len(j.keys))
+		// Los kid explícitos ganan sobre los derivados, y la clave actual sobre todas
+		for _, key := range slices.Backward(j.keys) {
+			if key.thumbprint != "" {
+				if _, taken := j.keysByID[key.thumbprint]; !taken {
+					j.keysByID[key.thumbprint] = key
+				}
+			}
+		}
+		for _, key := range slices.Backward(j.keys) {
+			j.keysByID[key.ID] = key
+		}
+	}
+}
+
+// NewJWTService crea una nueva instancia del servicio JWT. Sin
+// WithSigningKeys firma con HS256 y cfg.SecretKey.
+func NewJWTServiceFromConfig(cfg *config.JWTConfig, opts ...JWTOption) *JWTService {
+	j := &JWTService{
 		accessTokenTTL:  cfg.AccessTokenTTL,
 		refreshTokenTTL: cfg.RefreshTokenTTL,
 		issuer:          cfg.Issuer,
 		audience:        cfg.Audience,
+		strictAudience:  true,
+	}
+	WithSigningKeys(NewHMACKey(cfg.KeyID, []byte(cfg.SecretKey)))(j)
+
+	for _, opt := range opts {
+		opt(j)
 	}
+
+	return j
+}
+
+// JWKS retorna las claves públicas de verificación (RSA y EC); las HMAC no se
+// publican
+func (j *JWTService) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range j.keys {
+		if jwk, ok := key.toJWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set
+}
+
+// HasPublicKeys indica si alguna clave es asimétrica, es decir, si el JWKS
+// tiene contenido
+func (j *JWTService) HasPublicKeys() bool {
+	for _, key := range j.keys {
+		if key.IsAsymmetric() {
+			return true
+		}
+	}
+	return false
+}
+
+// sign firma los claims con la clave actual
+func (j *JWTService) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(j.signingKey.Method, claims)
+	token.Header["kid"] = j.signingKey.ID
+
+	tokenString, err := token.SignedString(j.signingKey.signKey)
+	if err != nil {
+		return "", ErrTokenGenerationFailed().WithDetail("error", err.Error())
+	}
+	return tokenString, nil
+}
+
+// verificationKey elige la clave por el kid del header. Los tokens sin kid,
+// emitidos antes de la rotación de claves, se prueban contra todas las claves
+// de su algoritmo, así la primera rotación no invalida las sesiones abiertas.
+// El algoritmo del token debe ser el de la clave, así un token HS256 no puede
+// firmarse con la clave pública de una RS256.
+func (j *JWTService) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		var set jwt.VerificationKeySet
+		for _, key := range j.keys {
+			if token.Method.Alg() == key.Method.Alg() {
+				set.Keys = append(set.Keys, key.verifyKey)
+			}
+		}
+		if len(set.Keys) == 0 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return set, nil
+	}
+
+	key, found := j.keysByID[kid]
+	if !found {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
 }
 
 // Claims personalizados para JWT
@@ -36,6 +154,8 @@ type JWTClaims struct {
 	Email    string          `json:"email"`
 	Name     string          `json:"name"`
 	Scopes   []string        `json:"scopes"`
+	// SessionID vincula el token a la sesión de login (UserSession.ID)
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -47,6 +167,7 @@ func (j *JWTService) GenerateAccessToken(userID kernel.UserID, tenantID kernel.T
 	email, _ := claims["email"].(string)
 	name, _ := claims["name"].(string)
 	scopes, _ := claims["scopes"].([]string)
+	sessionID, _ := claims["session_id"].(string)
 
 	// Default to empty scopes if not provided
 	if scopes == nil {
@@ -54,11 +175,12 @@ func (j *JWTService) GenerateAccessToken(userID kernel.UserID, tenantID kernel.T
 	}
 
 	jwtClaims := JWTClaims{
-		UserID:   userID,
-		TenantID: tenantID,
-		Email:    email,
-		Name:     name,
-		Scopes:   scopes,
+		UserID:    userID,
+		TenantID:  tenantID,
+		Email:     email,
+		Name:      name,
+		Scopes:    scopes,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    j.issuer,
 			Subject:   userID.String(),
@@ -69,25 +191,42 @@ func (j *JWTService) GenerateAccessToken(userID kernel.UserID, tenantID kernel.T
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims)
+	return j.sign(jwtClaims)
+}
 
-	tokenString, err := token.SignedString(j.secretKey)
+// ValidateAccessToken valida y decodifica un token de acceso
+func (j *JWTService) ValidateAccessToken(tokenString string) (*TokenClaims, error) {
+	jwtClaims, err := j.parseAccessToken(tokenString)
 	if err != nil {
-		return "", ErrTokenGenerationFailed().WithDetail("error", err.Error())
+		return nil, err
+	}
+	return jwtClaims.toTokenClaims(), nil
+}
+
+// IntrospectToken valida un token de acceso y retorna sus claims junto con
+// su vigencia. Un token inválido o expirado retorna Active=false sin error.
+func (j *JWTService) IntrospectToken(tokenString string) (*TokenIntrospection, error) {
+	if tokenString == "" {
+		return nil, ErrTokenValidationFailed().WithDetail("error", "token is required")
 	}
 
-	return tokenString, nil
+	jwtClaims, err := j.parseAccessToken(tokenString)
+	if err != nil {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	claims := jwtClaims.toTokenClaims()
+	return &TokenIntrospection{
+		Active:    true,
+		Claims:    claims,
+		ExpiresAt: claims.ExpiresAt,
+		IssuedAt:  claims.IssuedAt,
+	}, nil
 }
 
-// ValidateAccessToken valida y decodifica un token de acceso
-func (j *JWTService) ValidateAccessToken(tokenString string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (any, error) {
-		// Verificar el método de firma
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secretKey, nil
-	})
+// parseAccessToken verifica firma, vigencia (exp, nbf), emisor y audiencia del token
+func (j *JWTService) parseAccessToken(tokenString string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, j.verificationKey)
 
 	if err != nil {
 		return nil, ErrTokenValidationFailed().WithDetail("error", err.Error())
@@ -102,22 +241,90 @@ func (j *JWTService) ValidateAccessToken(tokenString string) (*TokenClaims, erro
 		return nil, ErrTokenValidationFailed().WithDetail("error", "invalid claims type")
 	}
 
-	return &TokenClaims{
-		UserID:    jwtClaims.UserID,
-		TenantID:  jwtClaims.TenantID,
-		Email:     jwtClaims.Email,
-		Name:      jwtClaims.Name,
-		Scopes:    jwtClaims.Scopes,
-		IssuedAt:  jwtClaims.IssuedAt.Time,
-		ExpiresAt: jwtClaims.ExpiresAt.Time,
-	}, nil
+	if err := j.verifyIssuer(jwtClaims); err != nil {
+		return nil, err
+	}
+	if err := j.verifyAudience(jwtClaims); err != nil {
+		return nil, err
+	}
+
+	return jwtClaims, nil
+}
+
+// verifyIssuer exige que iss coincida exactamente con el emisor configurado
+func (j *JWTService) verifyIssuer(c *JWTClaims) error {
+	if j.issuer == "" {
+		return nil
+	}
+	if c.RegisteredClaims.Issuer != j.issuer {
+		return ErrInvalidIssuer().
+			WithDetail("expected", j.issuer).
+			WithDetail("got", c.RegisteredClaims.Issuer)
+	}
+	return nil
 }
 
-// GenerateRefreshToken genera un token de refresh simple
+// verifyAudience exige que aud incluya al menos una audiencia configurada
+func (j *JWTService) verifyAudience(c *JWTClaims) error {
+	if len(j.audience) == 0 {
+		return nil
+	}
+
+	tokenAudience := c.RegisteredClaims.Audience
+	if len(tokenAudience) == 0 {
+		if !j.strictAudience {
+			return nil
+		}
+		return ErrInvalidAudience().
+			WithDetail("expected", j.audience).
+			WithDetail("error", "token has no audience")
+	}
+
+	for _, aud := range tokenAudience {
+		if slices.Contains(j.audience, aud) {
+			return nil
+		}
+	}
+
+	return ErrInvalidAudience().
+		WithDetail("expected", j.audience).
+		WithDetail("got", []string(tokenAudience))
+}
+
+// toTokenClaims expone los claims propios y los registrados (RFC 7519)
+func (c *JWTClaims) toTokenClaims() *TokenClaims {
+	claims := &TokenClaims{
+		UserID:    c.UserID,
+		TenantID:  c.TenantID,
+		Email:     c.Email,
+		Name:      c.Name,
+		Scopes:    c.Scopes,
+		Issuer:    c.RegisteredClaims.Issuer,
+		Subject:   c.RegisteredClaims.Subject,
+		Audience:  c.RegisteredClaims.Audience,
+		TokenID:   c.RegisteredClaims.ID,
+		SessionID: c.SessionID,
+	}
+	if c.RegisteredClaims.IssuedAt != nil {
+		claims.IssuedAt = c.RegisteredClaims.IssuedAt.Time
+	}
+	if c.RegisteredClaims.ExpiresAt != nil {
+		claims.ExpiresAt = c.RegisteredClaims.ExpiresAt.Time
+	}
+	if c.RegisteredClaims.NotBefore != nil {
+		claims.NotBefore = c.RegisteredClaims.NotBefore.Time
+	}
+	return claims
+}
+
+// GenerateRefreshToken genera un token de refresh simple. El jti aleatorio
+// hace único cada token, aunque se emitan dos para el mismo usuario en el
+// mismo segundo (p. ej. al rotarlo).
 func (j *JWTService) GenerateRefreshToken(userID kernel.UserID) (string, error) {
 	now := time.Now()
 
 	claims := jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
 		Issuer:    j.issuer,
 		Subject:   userID.String(),
 		Audience:  j.audience,
@@ -126,12 +333,5 @@ func (j *JWTService) GenerateRefreshToken(userID kernel.UserID) (string, error)
 		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString(j.secretKey)
-	if err != nil {
-		return "", ErrTokenGenerationFailed().WithDetail("error", err.Error())
-	}
-
-	return tokenString, nil
+	return j.sign(claims)
 }
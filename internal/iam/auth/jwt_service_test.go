@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRefreshTokensAreUniqueWithinASecond(t *testing.T) {
+	service := NewJWTServiceFromConfig(testJWTConfig())
+
+	// The refresh endpoint rotates a token right after issuing it; iat, nbf
+	// and exp have second precision, so only the jti tells them apart
+	first, err := service.GenerateRefreshToken("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{first: true}
+	for range 100 {
+		rotated, err := service.GenerateRefreshToken("u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[rotated] {
+			t.Fatalf("refresh token issued twice: %s", rotated)
+		}
+		seen[rotated] = true
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(first, claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID == "" {
+		t.Error("refresh token without jti")
+	}
+}
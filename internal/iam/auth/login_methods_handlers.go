@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/httpx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp/otpsrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LoginMethodHandlers lets the authenticated user manage the methods linked
+// to their account: OAuth provider, email OTP and password
+type LoginMethodHandlers struct {
+	userRepo     user.UserRepository
+	otpService   *otpsrv.OTPService
+	auditService AuditService
+}
+
+// NewLoginMethodHandlers creates the login method handlers
+func NewLoginMethodHandlers(userRepo user.UserRepository, otpService *otpsrv.OTPService, auditService AuditService) *LoginMethodHandlers {
+	return &LoginMethodHandlers{
+		userRepo:     userRepo,
+		otpService:   otpService,
+		auditService: auditService,
+	}
+}
+
+// RegisterRoutes registers the /auth/me/methods routes. All of them require a
+// user access token; API keys have no login methods.
+func (h *LoginMethodHandlers) RegisterRoutes(router fiber.Router, authMiddleware *UnifiedAuthMiddleware) {
+	methods := router.Group("/auth/me/methods", authMiddleware.Authenticate())
+
+	methods.Get("/", h.ListMethods)
+	methods.Post("/otp", h.EnableOTP)
+	methods.Post("/otp/verify", h.VerifyOTP)
+	methods.Delete("/oauth", h.UnlinkOAuth)
+}
+
+// ListMethods lista los métodos de login habilitados del usuario
+func (h *LoginMethodHandlers) ListMethods(c *fiber.Ctx) error {
+	userEntity, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(userEntity.LoginMethods())
+}
+
+// EnableOTP envía un código al email del usuario. OTP queda habilitado al
+// confirmarlo en POST /auth/me/methods/otp/verify.
+func (h *LoginMethodHandlers) EnableOTP(c *fiber.Ctx) error {
+	userEntity, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	if userEntity.HasOTP() {
+		return c.JSON(userEntity.LoginMethods())
+	}
+
+	otpEntity, err := h.otpService.GenerateOTP(c.Context(), userEntity.Email, otp.OTPPurposeVerification)
+	if err != nil {
+		return respondOTPError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":            "Verification code sent to your email",
+		"email":              userEntity.Email,
+		"expires_in_seconds": int(time.Until(otpEntity.ExpiresAt).Seconds()),
+	})
+}
+
+// VerifyOTPMethodRequest confirms the code sent by EnableOTP
+type VerifyOTPMethodRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// VerifyOTP verifica el código y habilita el login por OTP
+func (h *LoginMethodHandlers) VerifyOTP(c *fiber.Ctx) error {
+	var req VerifyOTPMethodRequest
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
+	}
+
+	userEntity, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.otpService.VerifyOTP(c.Context(), userEntity.Email, req.Code, otp.OTPPurposeVerification); err != nil {
+		h.auditService.LogOTPVerification(c.Context(), userEntity.Email, false, c.IP())
+		return err
+	}
+
+	userEntity.EnableOTP()
+	userEntity.EmailVerified = true
+	if err := h.userRepo.Save(c.Context(), *userEntity); err != nil {
+		return err
+	}
+
+	h.auditService.LogAccountLinked(c.Context(), userEntity.ID, userEntity.TenantID, "otp", c.IP())
+
+	return c.JSON(userEntity.LoginMethods())
+}
+
+// UnlinkOAuth desvincula el proveedor OAuth. Se rechaza si es el último método
+// de login del usuario.
+func (h *LoginMethodHandlers) UnlinkOAuth(c *fiber.Ctx) error {
+	userEntity, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	provider := userEntity.OAuthProvider
+	if err := userEntity.UnlinkOAuth(); err != nil {
+		return err
+	}
+	if err := h.userRepo.Save(c.Context(), *userEntity); err != nil {
+		return err
+	}
+
+	h.auditService.LogAccountUnlinked(c.Context(), userEntity.ID, userEntity.TenantID, "oauth_"+strings.ToLower(string(provider)), c.IP())
+
+	return c.JSON(userEntity.LoginMethods())
+}
+
+func (h *LoginMethodHandlers) currentUser(c *fiber.Ctx) (*user.User, error) {
+	authContext, ok := GetAuthContext(c)
+	if !ok || authContext.IsAPIKey || authContext.UserID == nil {
+		return nil, iam.ErrUnauthorized()
+	}
+
+	return h.userRepo.FindByID(c.Context(), *authContext.UserID, authContext.TenantID)
+}
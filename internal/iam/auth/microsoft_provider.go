@@ -2,21 +2,40 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/config"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
 )
 
 const (
 	MicrosoftAuthURL     = "https://login.microsoftonline.com/common/oauth2/v2.0/authorize"
 	MicrosoftTokenURL    = "https://login.microsoftonline.com/common/oauth2/v2.0/token"
 	MicrosoftUserInfoURL = "https://graph.microsoft.com/v1.0/me"
+	MicrosoftMemberOfURL = "https://graph.microsoft.com/v1.0/me/memberOf?$select=displayName"
+
+	// Foto original para guardarla en un ProfilePhotoStore; sin store se usa
+	// la de 96x96 para que la data URL quede pequeña
+	MicrosoftPhotoURL      = "https://graph.microsoft.com/v1.0/me/photo/$value"
+	MicrosoftSmallPhotoURL = "https://graph.microsoft.com/v1.0/me/photos/96x96/$value"
+)
+
+// Límites de la foto de perfil de Microsoft. La foto es opcional: no debe
+// consumir el presupuesto de la operación ni hacer fallar el login.
+const (
+	microsoftPhotoTimeout      = 5 * time.Second
+	maxProfilePhotoBytes       = 4 << 20  // Foto que se guarda en el ProfilePhotoStore
+	maxInlineProfilePhotoBytes = 64 << 10 // Foto que se incrusta como data URL
 )
 
 // MicrosoftOAuthService implementación del servicio OAuth para Microsoft
@@ -24,13 +43,18 @@ type MicrosoftOAuthService struct {
 	config       OAuthConfig
 	httpClient   *http.Client
 	stateManager StateManager
+	opTimeout    time.Duration // Límite de cada operación (intercambio, userinfo)
 	authURL      string
 	tokenURL     string
 	userInfoURL  string
+	fetchGroups  bool
+	fetchPhoto   bool
+	photoStore   ProfilePhotoStore // nil: la foto se incrusta como data URL
 }
 
-// NewMicrosoftOAuthService crea una nueva instancia del servicio Microsoft OAuth
-func NewMicrosoftOAuthServiceFromConfig(cfg *config.OAuthProviderConfig, stateManager StateManager) *MicrosoftOAuthService {
+// NewMicrosoftOAuthService crea una nueva instancia del servicio Microsoft OAuth.
+// photoStore puede ser nil; solo se usa con cfg.FetchPhoto.
+func NewMicrosoftOAuthServiceFromConfig(cfg *config.OAuthProviderConfig, stateManager StateManager, photoStore ProfilePhotoStore) *MicrosoftOAuthService {
 	return &MicrosoftOAuthService{
 		config: OAuthConfig{
 			ClientID:     cfg.ClientID,
@@ -38,11 +62,15 @@ func NewMicrosoftOAuthServiceFromConfig(cfg *config.OAuthProviderConfig, stateMa
 			RedirectURL:  cfg.RedirectURL,
 			Scopes:       cfg.Scopes,
 		},
-		httpClient:   &http.Client{Timeout: cfg.Timeout},
+		httpClient:   newOAuthHTTPClient(cfg.Timeout),
 		stateManager: stateManager,
+		opTimeout:    cfg.OperationTimeout,
 		authURL:      cfg.AuthURL,
 		tokenURL:     cfg.TokenURL,
 		userInfoURL:  cfg.UserInfoURL,
+		fetchGroups:  cfg.FetchGroups,
+		fetchPhoto:   cfg.FetchPhoto,
+		photoStore:   photoStore,
 	}
 }
 
@@ -71,7 +99,13 @@ func (m *MicrosoftOAuthService) ValidateState(state string) bool {
 }
 
 // ExchangeToken intercambia el código de autorización por tokens
-func (m *MicrosoftOAuthService) ExchangeToken(ctx context.Context, code string) (*OAuthTokenResponse, error) {
+func (m *MicrosoftOAuthService) ExchangeToken(ctx context.Context, code string) (_ *OAuthTokenResponse, err error) {
+	ctx, span := startOAuthSpan(ctx, "oauth.exchange_token", m.GetProvider())
+	defer func() { tracex.End(span, err) }()
+
+	ctx, cancel := withOperationTimeout(ctx, m.opTimeout)
+	defer cancel()
+
 	data := url.Values{
 		"client_id":     {m.config.ClientID},
 		"client_secret": {m.config.ClientSecret},
@@ -89,26 +123,30 @@ func (m *MicrosoftOAuthService) ExchangeToken(ctx context.Context, code string)
 
 	resp, err := m.httpClient.Do(req)
 	if err != nil {
-		return nil, errx.Wrap(err, "failed to exchange token", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, m.GetProvider(), "failed to exchange token")
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, ErrOAuthAuthorizationFailed().
-			WithDetail("status_code", resp.StatusCode).
-			WithDetail("provider", "microsoft")
+		return nil, oauthStatusError(resp, m.GetProvider(), "")
 	}
 
 	var tokenResp OAuthTokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return nil, errx.Wrap(err, "failed to decode token response", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, m.GetProvider(), "failed to decode token response")
 	}
 
 	return &tokenResp, nil
 }
 
 // GetUserInfo obtiene la información del usuario desde Microsoft
-func (m *MicrosoftOAuthService) GetUserInfo(ctx context.Context, accessToken string) (*OAuthUserInfo, error) {
+func (m *MicrosoftOAuthService) GetUserInfo(ctx context.Context, accessToken string) (_ *OAuthUserInfo, err error) {
+	ctx, span := startOAuthSpan(ctx, "oauth.user_info", m.GetProvider())
+	defer func() { tracex.End(span, err) }()
+
+	ctx, cancel := withOperationTimeout(ctx, m.opTimeout)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", MicrosoftUserInfoURL, nil)
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to create user info request", errx.TypeInternal)
@@ -118,15 +156,12 @@ func (m *MicrosoftOAuthService) GetUserInfo(ctx context.Context, accessToken str
 
 	resp, err := m.httpClient.Do(req)
 	if err != nil {
-		return nil, errx.Wrap(err, "failed to get user info", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, m.GetProvider(), "failed to get user info")
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, ErrOAuthAuthorizationFailed().
-			WithDetail("status_code", resp.StatusCode).
-			WithDetail("provider", "microsoft").
-			WithDetail("endpoint", "userinfo")
+		return nil, oauthStatusError(resp, m.GetProvider(), "userinfo")
 	}
 
 	var msUser struct {
@@ -137,7 +172,7 @@ func (m *MicrosoftOAuthService) GetUserInfo(ctx context.Context, accessToken str
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&msUser); err != nil {
-		return nil, errx.Wrap(err, "failed to decode user info", errx.TypeExternal)
+		return nil, oauthCallError(ctx, err, m.GetProvider(), "failed to decode user info")
 	}
 
 	// Microsoft puede usar mail o userPrincipalName como email
@@ -146,11 +181,143 @@ func (m *MicrosoftOAuthService) GetUserInfo(ctx context.Context, accessToken str
 		email = msUser.UserPrincipalName
 	}
 
-	return &OAuthUserInfo{
+	userInfo := &OAuthUserInfo{
 		ID:            msUser.ID,
 		Email:         email,
 		Name:          msUser.DisplayName,
-		Picture:       "",   // Microsoft Graph requiere endpoint separado para foto
 		EmailVerified: true, // Asumimos verificado si viene de Microsoft
-	}, nil
+	}
+
+	if m.fetchGroups {
+		groups, err := m.getGroups(ctx, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		userInfo.Groups = groups
+	}
+
+	// Microsoft Graph expone la foto en un endpoint aparte
+	if m.fetchPhoto {
+		userInfo.Picture = m.getPhoto(ctx, accessToken, msUser.ID)
+	}
+
+	return userInfo, nil
+}
+
+// getPhoto obtiene la foto de perfil y retorna su URL: la del ProfilePhotoStore
+// o una data URL. Es best-effort: sin foto o ante cualquier error retorna ""
+// y el login sigue sin foto.
+func (m *MicrosoftOAuthService) getPhoto(ctx context.Context, accessToken, userID string) string {
+	ctx, cancel := context.WithTimeout(ctx, microsoftPhotoTimeout)
+	defer cancel()
+
+	photoURL, maxBytes := MicrosoftSmallPhotoURL, maxInlineProfilePhotoBytes
+	if m.photoStore != nil {
+		photoURL, maxBytes = MicrosoftPhotoURL, maxProfilePhotoBytes
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", photoURL, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		logx.Warnf("Microsoft profile photo unavailable: %v", err)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	// 404: el usuario no tiene foto
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode != http.StatusNotFound {
+			logx.Warnf("Microsoft profile photo unavailable: status %d", resp.StatusCode)
+		}
+		return ""
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return ""
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	if len(data) > maxBytes {
+		logx.Warnf("Microsoft profile photo skipped: larger than %d bytes", maxBytes)
+		return ""
+	}
+
+	if m.photoStore == nil {
+		return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+
+	pictureURL, err := m.photoStore.SaveProfilePhoto(ctx, m.GetProvider(), userID, contentType, data)
+	if err != nil {
+		logx.Warnf("Microsoft profile photo not stored: %v", err)
+		return ""
+	}
+	return pictureURL
+}
+
+// getGroups obtiene los nombres de los grupos del usuario desde Microsoft Graph.
+// Requiere el permiso GroupMember.Read.All en los scopes configurados. Graph
+// pagina la respuesta, así que sigue @odata.nextLink hasta agotarla: un grupo
+// omitido haría que syncGroupScopes quitara scopes que el usuario conserva.
+func (m *MicrosoftOAuthService) getGroups(ctx context.Context, accessToken string) ([]string, error) {
+	groups := []string{}
+	for pageURL := MicrosoftMemberOfURL; pageURL != ""; {
+		page, nextLink, err := m.getGroupsPage(ctx, accessToken, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, page...)
+		pageURL = nextLink
+	}
+
+	return groups, nil
+}
+
+// getGroupsPage obtiene una página de memberOf y el enlace a la siguiente,
+// vacío en la última
+func (m *MicrosoftOAuthService) getGroupsPage(ctx context.Context, accessToken, pageURL string) ([]string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, "", errx.Wrap(err, "failed to create member of request", errx.TypeInternal)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, "", oauthCallError(ctx, err, m.GetProvider(), "failed to get user groups")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", oauthStatusError(resp, m.GetProvider(), "memberOf")
+	}
+
+	var memberOf struct {
+		Value []struct {
+			DisplayName string `json:"displayName"`
+		} `json:"value"`
+		NextLink string `json:"@odata.nextLink"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&memberOf); err != nil {
+		return nil, "", oauthCallError(ctx, err, m.GetProvider(), "failed to decode user groups")
+	}
+
+	groups := make([]string, 0, len(memberOf.Value))
+	for _, g := range memberOf.Value {
+		if g.DisplayName != "" {
+			groups = append(groups, g.DisplayName)
+		}
+	}
+
+	return groups, memberOf.NextLink, nil
 }
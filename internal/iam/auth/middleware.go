@@ -61,16 +61,17 @@ func (am *TokenMiddleware) Authenticate() fiber.Handler {
 
 		// Crear contexto de autenticación
 		authContext := &kernel.AuthContext{
-			UserID:   &claims.UserID,
-			TenantID: claims.TenantID,
-			Email:    claims.Email,
-			Name:     claims.Name,
-			Scopes:   claims.Scopes,
-			IsAPIKey: false,
+			UserID:    &claims.UserID,
+			TenantID:  claims.TenantID,
+			Email:     claims.Email,
+			Name:      claims.Name,
+			Scopes:    claims.Scopes,
+			IsAPIKey:  false,
+			SessionID: claims.SessionID,
 		}
 
 		// Agregar al contexto de Fiber
-		c.Locals("auth", authContext)
+		setAuthContext(c, authContext)
 
 		return c.Next()
 	}
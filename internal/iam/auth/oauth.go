@@ -18,6 +18,10 @@ type OAuthUserInfo struct {
 	Name          string `json:"name"`
 	Picture       string `json:"picture"`
 	EmailVerified bool   `json:"email_verified"`
+
+	// Groups contiene los grupos del IdP. nil significa que el proveedor no
+	// informa grupos (y por lo tanto no se sincronizan scopes).
+	Groups []string `json:"groups,omitempty"`
 }
 
 // OAuthService define el contrato para servicios OAuth
@@ -29,12 +33,21 @@ type OAuthService interface {
 	GetProvider() iam.OAuthProvider
 }
 
+// OIDCUserInfoProvider lo implementan los servicios que obtienen la identidad
+// del id_token en lugar del userinfo endpoint. InitiateLogin envía un nonce en
+// la URL de autorización y HandleCallback exige el mismo nonce en el id_token.
+type OIDCUserInfoProvider interface {
+	GetAuthURLWithNonce(state, nonce string) string
+	UserInfoFromTokens(ctx context.Context, tokens *OAuthTokenResponse, nonce string) (*OAuthUserInfo, error)
+}
+
 // OAuthTokenResponse respuesta del intercambio de código por token
 type OAuthTokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	TokenType    string `json:"token_type"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 	ExpiresIn    int    `json:"expires_in"`
+	IDToken      string `json:"id_token,omitempty"` // Solo proveedores OpenID Connect
 }
 
 // StateManager maneja la validación de estados OAuth
@@ -42,5 +55,10 @@ type StateManager interface {
 	GenerateState() string
 	ValidateState(state string) bool
 	StoreState(ctx context.Context, state string, data map[string]any) error
+
+	// GetStateData consume el estado de forma atómica: solo la primera
+	// llamada obtiene los datos. Las siguientes devuelven ErrStateAlreadyUsed
+	// hasta que el estado expira; un estado desconocido o expirado devuelve
+	// ErrInvalidState.
 	GetStateData(ctx context.Context, state string) (map[string]any, error)
 }
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+)
+
+// maxOAuthErrorBody acota lo que se lee del cuerpo de una respuesta de error del IdP
+const maxOAuthErrorBody = 4 << 10
+
+// newOAuthHTTPClient crea el cliente HTTP de un IdP. connectTimeout acota la
+// conexión (dial y handshake TLS); la duración total de cada operación la
+// acota el contexto (ver withOperationTimeout), no el cliente.
+func newOAuthHTTPClient(connectTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if connectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = connectTimeout
+	}
+	return &http.Client{Transport: transport}
+}
+
+// withOperationTimeout limita una operación contra el IdP. El deadline del
+// contexto de la request se respeta si es anterior.
+func withOperationTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// oauthCallError clasifica el error de una llamada al IdP: ErrOAuthTimeout si
+// venció el deadline de la operación o de la conexión, error externo si no
+func oauthCallError(ctx context.Context, err error, provider iam.OAuthProvider, message string) *errx.Error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrRegistry.NewWithCause(CodeOAuthTimeout, err).
+			WithDetail("provider", string(provider))
+	}
+	return errx.Wrap(err, message, errx.TypeExternal).
+		WithDetail("provider", string(provider))
+}
+
+// oauthStatusError convierte una respuesta no-200 del IdP en
+// ErrOAuthAuthorizationFailed, con el error y error_description del cuerpo
+// (RFC 6749 §5.2, o el formato {"error": {"code", "message"}} de Graph)
+func oauthStatusError(resp *http.Response, provider iam.OAuthProvider, endpoint string) *errx.Error {
+	e := ErrOAuthAuthorizationFailed().
+		WithDetail("status_code", resp.StatusCode).
+		WithDetail("provider", string(provider))
+	if endpoint != "" {
+		e.WithDetail("endpoint", endpoint)
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxOAuthErrorBody))
+	var payload struct {
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return e
+	}
+
+	var code string
+	if json.Unmarshal(payload.Error, &code) != nil {
+		var graphErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(payload.Error, &graphErr) == nil {
+			code = graphErr.Code
+			if payload.ErrorDescription == "" {
+				payload.ErrorDescription = graphErr.Message
+			}
+		}
+	}
+
+	if code != "" {
+		e.WithDetail("error", code)
+	}
+	if payload.ErrorDescription != "" {
+		e.WithDetail("error_description", payload.ErrorDescription)
+	}
+	return e
+}
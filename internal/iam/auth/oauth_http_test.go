@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+func newTestOIDCService(tokenEndpoint string, opTimeout time.Duration) *GenericOIDCOAuthService {
+	return &GenericOIDCOAuthService{
+		provider:   "TEST",
+		httpClient: newOAuthHTTPClient(time.Second),
+		discovery:  oidcDiscovery{TokenEndpoint: tokenEndpoint},
+		opTimeout:  opTimeout,
+	}
+}
+
+func TestExchangeTokenTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	_, err := newTestOIDCService(server.URL, 50*time.Millisecond).ExchangeToken(context.Background(), "code")
+	if !IsOAuthTimeout(err) {
+		t.Fatalf("ExchangeToken error = %v, want OAUTH_TIMEOUT", err)
+	}
+}
+
+func TestExchangeTokenErrorDescription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"Code already redeemed"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestOIDCService(server.URL, time.Second).ExchangeToken(context.Background(), "code")
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != CodeOAuthAuthorizationFailed.Code {
+		t.Fatalf("ExchangeToken error = %v, want OAUTH_AUTHORIZATION_FAILED", err)
+	}
+	if e.Details["error"] != "invalid_grant" || e.Details["error_description"] != "Code already redeemed" {
+		t.Errorf("details = %v, want the provider's error and error_description", e.Details)
+	}
+}
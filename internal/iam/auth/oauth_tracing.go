@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startOAuthSpan abre el span de una llamada HTTP al proveedor OAuth. Sin
+// tracing habilitado no tiene costo (ver tracex.Start).
+func startOAuthSpan(ctx context.Context, name string, provider iam.OAuthProvider) (context.Context, trace.Span) {
+	if !tracex.Enabled() {
+		return tracex.Start(ctx, name)
+	}
+	return tracex.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("oauth.provider", string(provider))),
+	)
+}
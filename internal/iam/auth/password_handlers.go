@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/httpx"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// resetEmailTimeout bounds the reset email, which is sent after the response
+const resetEmailTimeout = 30 * time.Second
+
+// PasswordAuthHandlers handles email + password login for users that set a
+// password, and the forgot/reset flow. Password login is opt-in per user:
+// OAuth/OTP-only accounts have no password hash, so they can neither log in
+// with a password nor get one through a reset link. Users of tenants with
+// their own SSO connection are refused too, so deprovisioning them in the IdP
+// is enough to lock them out.
+type PasswordAuthHandlers struct {
+	userRepo       user.UserRepository
+	passwordSvc    user.PasswordService
+	passwordPolicy user.PasswordPolicy
+	auditService   AuditService
+	tokenRepo      TokenRepository
+	sessionRepo    SessionRepository
+	resetRepo      PasswordResetRepository
+	resetNotifier  PasswordResetNotifier
+	resetConfig    *config.PasswordResetConfig
+
+	// login issues tokens and the session exactly like the OTP login
+	login *PasswordlessAuthHandlers
+
+	// dummyHash is compared when the user has no password, so unknown emails
+	// take as long as wrong passwords
+	dummyHash string
+}
+
+// NewPasswordAuthHandlers creates the handlers. Tokens and sessions are issued
+// through passwordless, so both login flows behave the same. resetNotifier may
+// be nil, in which case reset tokens are created but no email is sent.
+func NewPasswordAuthHandlers(
+	userRepo user.UserRepository,
+	passwordSvc user.PasswordService,
+	passwordPolicy user.PasswordPolicy,
+	auditService AuditService,
+	tokenRepo TokenRepository,
+	sessionRepo SessionRepository,
+	resetRepo PasswordResetRepository,
+	resetNotifier PasswordResetNotifier,
+	resetConfig *config.PasswordResetConfig,
+	passwordless *PasswordlessAuthHandlers,
+) *PasswordAuthHandlers {
+	dummyHash, _ := passwordSvc.HashPassword(uuid.NewString())
+
+	return &PasswordAuthHandlers{
+		userRepo:       userRepo,
+		passwordSvc:    passwordSvc,
+		passwordPolicy: passwordPolicy,
+		auditService:   auditService,
+		tokenRepo:      tokenRepo,
+		sessionRepo:    sessionRepo,
+		resetRepo:      resetRepo,
+		resetNotifier:  resetNotifier,
+		resetConfig:    resetConfig,
+		login:          passwordless,
+		dummyHash:      dummyHash,
+	}
+}
+
+// RegisterRoutes registers password auth routes
+func (h *PasswordAuthHandlers) RegisterRoutes(router fiber.Router) {
+	auth := router.Group("/auth/password")
+
+	auth.Post("/login", h.Login)
+	auth.Post("/forgot", h.ForgotPassword)
+	auth.Post("/reset", h.ResetPassword)
+}
+
+// PasswordLoginRequest logs in with email and password
+type PasswordLoginRequest struct {
+	Email    string          `json:"email" validate:"required,email"`
+	Password string          `json:"password" validate:"required"`
+	TenantID kernel.TenantID `json:"tenant_id" validate:"required"`
+}
+
+// Login verifies the user's password and returns JWT tokens
+func (h *PasswordAuthHandlers) Login(c *fiber.Ctx) error {
+	var req PasswordLoginRequest
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
+	}
+
+	// 1. Find user and check the password. Unknown users, users without a
+	// password and wrong passwords get the same response
+	userEntity, err := h.userRepo.FindByEmail(c.Context(), req.Email, req.TenantID)
+	if err != nil || !h.passwordEnabled(c.Context(), userEntity) {
+		h.passwordSvc.VerifyPassword(h.dummyHash, req.Password)
+		h.auditService.LogLoginAttempt(c.Context(), "", req.TenantID, "password", false, c.IP(), c.Get("User-Agent"))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid email or password",
+		})
+	}
+
+	if !userEntity.CheckPassword(req.Password, h.passwordSvc) {
+		h.auditService.LogLoginAttempt(c.Context(), userEntity.ID, req.TenantID, "password", false, c.IP(), c.Get("User-Agent"))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid email or password",
+		})
+	}
+
+	// 2. Check CanLogin, the tenant and the session limit, then issue tokens
+	return h.login.completeLogin(c, userEntity, "password")
+}
+
+// ForgotPasswordRequest asks for a password reset link
+type ForgotPasswordRequest struct {
+	Email    string          `json:"email" validate:"required,email"`
+	TenantID kernel.TenantID `json:"tenant_id" validate:"required"`
+}
+
+// ForgotPassword creates a reset token and emails the reset link. The response
+// is the same whether or not the account exists, is rate limited or the email
+// fails, so it cannot be used to discover accounts.
+func (h *PasswordAuthHandlers) ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.createResetToken(c.Context(), req.Email, req.TenantID); err != nil {
+		logx.WithFields(logx.Fields{
+			"tenant_id": req.TenantID,
+			"error":     err.Error(),
+		}).Error("Failed to create password reset token")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "If the account exists, a password reset link has been sent",
+	})
+}
+
+// createResetToken creates and sends a reset token for an active, verified
+// user that already has a password. Unknown users, users without password
+// login and users over MaxAttemptsPerWindow are skipped silently.
+func (h *PasswordAuthHandlers) createResetToken(ctx context.Context, email string, tenantID kernel.TenantID) error {
+	userEntity, err := h.userRepo.FindByEmail(ctx, email, tenantID)
+	if err != nil || !userEntity.CanLogin() || !h.passwordEnabled(ctx, userEntity) {
+		return nil
+	}
+
+	limited, err := h.resetRepo.HasRecentResetToken(ctx, userEntity.ID, h.resetConfig.RateLimitWindow, h.resetConfig.MaxAttemptsPerWindow)
+	if err != nil {
+		return err
+	}
+	if limited {
+		logx.WithFields(logx.Fields{
+			"user_id":   userEntity.ID,
+			"tenant_id": tenantID,
+		}).Warn("Password reset rate limit reached")
+		return nil
+	}
+
+	token, err := generateResetToken(h.resetConfig.TokenByteLength)
+	if err != nil {
+		return errx.Wrap(err, "failed to generate reset token", errx.TypeInternal)
+	}
+
+	resetURL, err := buildResetURL(h.resetConfig.ResetURL, token)
+	if err != nil {
+		return errx.Wrap(err, "invalid password reset URL", errx.TypeInternal)
+	}
+
+	now := time.Now()
+	resetToken := PasswordResetToken{
+		ID:        uuid.NewString(),
+		Token:     HashResetToken(token),
+		UserID:    userEntity.ID,
+		TenantID:  tenantID,
+		ExpiresAt: now.Add(h.resetConfig.ExpirationTime),
+		CreatedAt: now,
+	}
+	if err := h.resetRepo.SaveResetToken(ctx, resetToken); err != nil {
+		return err
+	}
+
+	if h.resetNotifier == nil {
+		logx.Warnf("No password reset notifier configured, reset link for user %s not sent", userEntity.ID)
+		return nil
+	}
+
+	// Sent after the response so known and unknown emails take the same time
+	go h.sendResetLink(userEntity.ID, userEntity.Email, resetURL, resetToken.ExpiresAt)
+
+	return nil
+}
+
+// sendResetLink emails the reset link outside the request context
+func (h *PasswordAuthHandlers) sendResetLink(userID kernel.UserID, email, resetURL string, expiresAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), resetEmailTimeout)
+	defer cancel()
+
+	if err := h.resetNotifier.SendPasswordReset(ctx, email, resetURL, expiresAt); err != nil {
+		logx.WithFields(logx.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		}).Error("Failed to send password reset email")
+	}
+}
+
+// ResetPasswordRequest sets a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ResetPassword consumes the reset token, sets the new password and signs the
+// user out everywhere: every session, refresh token and pending reset token
+// is revoked.
+func (h *PasswordAuthHandlers) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Context()
+	tokenHash := HashResetToken(req.Token)
+
+	// 1. Find the token and its user
+	resetToken, err := h.resetRepo.FindResetToken(ctx, tokenHash)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or expired reset token",
+		})
+	}
+
+	// The token may predate the tenant's SSO connection
+	userEntity, err := h.userRepo.FindByID(ctx, resetToken.UserID, resetToken.TenantID)
+	if err != nil || !userEntity.IsActive() || !h.passwordEnabled(ctx, userEntity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or expired reset token",
+		})
+	}
+
+	// 2. Validate the new password before spending the token
+	if err := userEntity.SetPassword(req.Password, h.passwordPolicy, h.passwordSvc); err != nil {
+		var e *errx.Error
+		if errx.As(err, &e) && e.Code == user.CodeInvalidPassword.Code {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":        "Password does not meet the requirements",
+				"failed_rules": e.Details["failed_rules"],
+				"policy":       h.passwordPolicy,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reset password",
+		})
+	}
+
+	// 3. Consume the token and save the password together; only one
+	// concurrent request can win, and a failed save leaves the token usable
+	tokenConsumed := false
+	err = h.login.withinTx(ctx, func(ctx context.Context) error {
+		if err := h.resetRepo.ConsumeResetToken(ctx, tokenHash); err != nil {
+			return err
+		}
+		tokenConsumed = true
+		return h.userRepo.Save(ctx, *userEntity)
+	})
+	if err != nil {
+		if !tokenConsumed {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid or expired reset token",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reset password",
+		})
+	}
+
+	// 4. Sign out everywhere: whoever knew the old password loses access
+	if err := h.revokeUserCredentials(ctx, userEntity.ID); err != nil {
+		logx.WithFields(logx.Fields{
+			"user_id": userEntity.ID,
+			"error":   err.Error(),
+		}).Error("Password reset but credentials not revoked")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Password was reset but existing sessions could not be revoked",
+		})
+	}
+
+	h.auditService.LogPasswordReset(ctx, userEntity.ID, userEntity.TenantID, c.IP())
+
+	return c.JSON(fiber.Map{
+		"message": "Password has been reset",
+	})
+}
+
+// passwordEnabled reports whether the user may log in with or reset a
+// password: the user must have one and the tenant must not use its own SSO
+func (h *PasswordAuthHandlers) passwordEnabled(ctx context.Context, u *user.User) bool {
+	return u.HasPassword() && !h.login.oauthResolver.HasSSO(ctx, u.TenantID)
+}
+
+// revokeUserCredentials revokes the user's sessions, refresh tokens and
+// remaining reset tokens
+func (h *PasswordAuthHandlers) revokeUserCredentials(ctx context.Context, userID kernel.UserID) error {
+	if err := h.sessionRepo.RevokeAllUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	if err := h.tokenRepo.RevokeAllUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	return h.resetRepo.RevokeAllUserResetTokens(ctx, userID)
+}
+
+// generateResetToken returns byteLength random bytes hex encoded
+func generateResetToken(byteLength int) (string, error) {
+	b := make([]byte, byteLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// buildResetURL agrega el token como query param a config.PasswordResetConfig.ResetURL
+func buildResetURL(resetURL, token string) (string, error) {
+	u, err := url.Parse(resetURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
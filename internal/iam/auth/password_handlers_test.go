@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+// plainPasswords "hashes" by prefixing, enough to tell passwords apart
+type plainPasswords struct{}
+
+func (plainPasswords) HashPassword(password string) (string, error) { return "hash:" + password, nil }
+func (plainPasswords) VerifyPassword(hash, password string) bool    { return hash == "hash:"+password }
+
+type memoryResetRepo struct {
+	PasswordResetRepository
+	tokens map[string]PasswordResetToken
+}
+
+func (r *memoryResetRepo) SaveResetToken(_ context.Context, token PasswordResetToken) error {
+	r.tokens[token.Token] = token
+	return nil
+}
+
+func (r *memoryResetRepo) FindResetToken(_ context.Context, tokenValue string) (*PasswordResetToken, error) {
+	token, ok := r.tokens[tokenValue]
+	if !ok || token.IsUsed {
+		return nil, errx.New("reset token not found or invalid", errx.TypeNotFound)
+	}
+	return &token, nil
+}
+
+func (r *memoryResetRepo) ConsumeResetToken(_ context.Context, tokenValue string) error {
+	token, ok := r.tokens[tokenValue]
+	if !ok || token.IsUsed {
+		return errx.New("reset token not found or already used", errx.TypeNotFound)
+	}
+	token.IsUsed = true
+	r.tokens[tokenValue] = token
+	return nil
+}
+
+func (r *memoryResetRepo) HasRecentResetToken(context.Context, kernel.UserID, time.Duration, int) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryResetRepo) RevokeAllUserResetTokens(context.Context, kernel.UserID) error { return nil }
+
+// snapshotTx restores the reset tokens when fn fails, like a rollback
+type snapshotTx struct{ resets *memoryResetRepo }
+
+func (t snapshotTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := maps.Clone(t.resets.tokens)
+	if err := fn(ctx); err != nil {
+		t.resets.tokens = saved
+		return err
+	}
+	return nil
+}
+
+type failingSaveUsers struct {
+	*userinfra.InMemoryUserRepository
+	fail bool
+}
+
+func (r *failingSaveUsers) Save(ctx context.Context, u user.User) error {
+	if r.fail {
+		return errors.New("connection reset")
+	}
+	return r.InMemoryUserRepository.Save(ctx, u)
+}
+
+type revokedCredentials struct {
+	TokenRepository
+	SessionRepository
+	users []kernel.UserID
+}
+
+func (r *revokedCredentials) RevokeAllUserTokens(_ context.Context, userID kernel.UserID) error {
+	r.users = append(r.users, userID)
+	return nil
+}
+
+func (r *revokedCredentials) RevokeAllUserSessions(context.Context, kernel.UserID) error { return nil }
+
+type passwordAudit struct{ AuditService }
+
+func (passwordAudit) LogPasswordReset(context.Context, kernel.UserID, kernel.TenantID, string) {}
+
+func newPasswordTestHandlers(t *testing.T) (*PasswordAuthHandlers, *failingSaveUsers, *memoryResetRepo, *revokedCredentials) {
+	t.Helper()
+	ctx := context.Background()
+	hash := "hash:old-password"
+	users := &failingSaveUsers{InMemoryUserRepository: userinfra.NewInMemoryUserRepository()}
+	for _, u := range []user.User{
+		{ID: "with-password", TenantID: "t1", Email: "ana@acme.com", Status: user.UserStatusActive, EmailVerified: true, PasswordHash: &hash},
+		{ID: "oauth-only", TenantID: "t1", Email: "luis@acme.com", Status: user.UserStatusActive, EmailVerified: true},
+		{ID: "sso-tenant", TenantID: "t2", Email: "eva@corp.com", Status: user.UserStatusActive, EmailVerified: true, PasswordHash: &hash},
+	} {
+		if err := users.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sso := &ssoRepo{conns: map[kernel.TenantID]*tenant.SSOConnection{"t2": {TenantID: "t2", IsActive: true}}}
+	resets := &memoryResetRepo{tokens: map[string]PasswordResetToken{}}
+	revoked := &revokedCredentials{}
+
+	h := &PasswordAuthHandlers{
+		userRepo:       users,
+		passwordSvc:    plainPasswords{},
+		passwordPolicy: user.PasswordPolicy{MinLength: 8},
+		auditService:   passwordAudit{},
+		tokenRepo:      revoked,
+		sessionRepo:    revoked,
+		resetRepo:      resets,
+		resetConfig: &config.PasswordResetConfig{
+			TokenByteLength:      32,
+			ExpirationTime:       time.Hour,
+			RateLimitWindow:      15 * time.Minute,
+			MaxAttemptsPerWindow: 3,
+			ResetURL:             "https://app.example.com/reset",
+		},
+		login: &PasswordlessAuthHandlers{
+			oauthResolver: NewOAuthProviderResolver(nil, sso, nil),
+			transactor:    snapshotTx{resets: resets},
+		},
+	}
+	return h, users, resets, revoked
+}
+
+func TestForgotPasswordOnlyForPasswordUsers(t *testing.T) {
+	ctx := context.Background()
+	h, _, resets, _ := newPasswordTestHandlers(t)
+
+	for _, req := range []struct {
+		email    string
+		tenantID kernel.TenantID
+	}{
+		{"luis@acme.com", "t1"},   // no password: OAuth only
+		{"eva@corp.com", "t2"},    // tenant logs in through its IdP
+		{"nadie@acme.com", "t1"},  // unknown
+		{"ana@acme.com", "other"}, // wrong tenant
+	} {
+		if err := h.createResetToken(ctx, req.email, req.tenantID); err != nil {
+			t.Fatalf("createResetToken(%s) error = %v", req.email, err)
+		}
+	}
+	if len(resets.tokens) != 0 {
+		t.Fatalf("reset tokens created for users without password login: %+v", resets.tokens)
+	}
+
+	if err := h.createResetToken(ctx, "ana@acme.com", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(resets.tokens) != 1 {
+		t.Fatalf("got %d reset tokens, want 1", len(resets.tokens))
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	h, users, resets, revoked := newPasswordTestHandlers(t)
+	app := fiber.New()
+	app.Post("/auth/password/reset", h.ResetPassword)
+
+	issue := func(userID kernel.UserID, tenantID kernel.TenantID) string {
+		token := string(userID) + "-token"
+		resets.tokens[HashResetToken(token)] = PasswordResetToken{
+			Token: HashResetToken(token), UserID: userID, TenantID: tenantID, ExpiresAt: time.Now().Add(time.Hour),
+		}
+		return token
+	}
+	reset := func(token, password string) int {
+		body := `{"token":"` + token + `","password":"` + password + `"}`
+		req := httptest.NewRequest(fiber.MethodPost, "/auth/password/reset", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	// Tokens issued before the user lost password login are refused
+	for _, token := range []string{issue("oauth-only", "t1"), issue("sso-tenant", "t2")} {
+		if status := reset(token, "new-password"); status != fiber.StatusBadRequest {
+			t.Errorf("reset with %s = %d, want 400", token, status)
+		}
+	}
+
+	token := issue("with-password", "t1")
+	if status := reset(token, "short"); status != fiber.StatusBadRequest {
+		t.Errorf("reset with a weak password = %d, want 400", status)
+	}
+
+	// A failed save rolls back the consumed token
+	users.fail = true
+	if status := reset(token, "new-password"); status != fiber.StatusInternalServerError {
+		t.Errorf("reset with failing save = %d, want 500", status)
+	}
+	if resets.tokens[HashResetToken(token)].IsUsed {
+		t.Fatal("token spent by a failed reset")
+	}
+	users.fail = false
+
+	if status := reset(token, "new-password"); status != fiber.StatusOK {
+		t.Fatalf("reset = %d, want 200", status)
+	}
+	u, err := users.FindByID(context.Background(), "with-password", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.CheckPassword("new-password", plainPasswords{}) {
+		t.Error("password not changed")
+	}
+	if len(revoked.users) != 1 || revoked.users[0] != "with-password" {
+		t.Errorf("revoked tokens of %v, want the reset user", revoked.users)
+	}
+
+	if status := reset(token, "other-password"); status != fiber.StatusBadRequest {
+		t.Errorf("reusing the token = %d, want 400", status)
+	}
+}
@@ -1,10 +1,16 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/httpx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
 	"github.com/Abraxas-365/manifesto/internal/iam/otp"
@@ -12,6 +18,7 @@ import (
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
 	"github.com/Abraxas-365/manifesto/internal/iam/user"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -26,9 +33,14 @@ type PasswordlessAuthHandlers struct {
 	invitationRepo invitation.InvitationRepository
 	otpService     *otpsrv.OTPService
 	auditService   AuditService
+	oauthResolver  *OAuthProviderResolver
+	transactor     dbx.Transactor
 	config         *config.Config
 }
 
+// NewPasswordlessAuthHandlers creates the handlers. oauthResolver may be nil,
+// in which case tenant SSO connections are not reported. transactor may be
+// nil, in which case signup writes are not wrapped in a transaction.
 func NewPasswordlessAuthHandlers(
 	tokenService TokenService,
 	userRepo user.UserRepository,
@@ -38,6 +50,8 @@ func NewPasswordlessAuthHandlers(
 	invitationRepo invitation.InvitationRepository,
 	otpService *otpsrv.OTPService,
 	auditService AuditService,
+	oauthResolver *OAuthProviderResolver,
+	transactor dbx.Transactor,
 	config *config.Config,
 ) *PasswordlessAuthHandlers {
 	return &PasswordlessAuthHandlers{
@@ -49,6 +63,8 @@ func NewPasswordlessAuthHandlers(
 		invitationRepo: invitationRepo,
 		otpService:     otpService,
 		auditService:   auditService,
+		oauthResolver:  oauthResolver,
+		transactor:     transactor,
 		config:         config,
 	}
 }
@@ -68,6 +84,10 @@ func (h *PasswordlessAuthHandlers) RegisterRoutes(router fiber.Router) {
 	auth.Post("/login/initiate", h.InitiateLogin)
 	auth.Post("/login/verify", h.VerifyLogin)
 
+	// Phone login flow (SMS OTP)
+	auth.Post("/login/phone/initiate", h.InitiatePhoneLogin)
+	auth.Post("/login/phone/verify", h.VerifyPhoneLogin)
+
 	// Utility
 	auth.Post("/resend-otp", h.ResendOTP)
 }
@@ -81,15 +101,24 @@ type GetUserTenantsRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
-// TenantOption represents a tenant the user belongs to
+// Tenant match kinds reported by GetUserTenants
+const (
+	TenantMatchExistingAccount = "existing_account"
+	TenantMatchDomain          = "domain_match"
+)
+
+// TenantOption represents a tenant the user belongs to, or one that claims the
+// email's domain (Match == TenantMatchDomain, no account details)
 type TenantOption struct {
 	TenantID    kernel.TenantID `json:"tenant_id"`
 	CompanyName string          `json:"company_name"`
-	UserStatus  user.UserStatus `json:"user_status"`
+	Match       string          `json:"match"`
+	UserStatus  user.UserStatus `json:"user_status,omitempty"`
 	AuthMethods struct {
 		OTP      bool              `json:"otp"`
 		OAuth    bool              `json:"oauth"`
 		Provider iam.OAuthProvider `json:"oauth_provider,omitempty"`
+		SSO      bool              `json:"sso"` // Tenant has its own IdP (login with provider "sso")
 	} `json:"auth_methods"`
 }
 
@@ -102,25 +131,20 @@ type GetUserTenantsResponse struct {
 // GetUserTenants returns all tenants where this email has an account
 func (h *PasswordlessAuthHandlers) GetUserTenants(c *fiber.Ctx) error {
 	var req GetUserTenantsRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Find all users with this email across tenants
+	// Find all users with this email across tenants. Lookup errors are
+	// treated as no accounts so they don't reveal whether the email exists.
 	users, err := h.userRepo.FindByEmailAcrossTenants(c.Context(), req.Email)
-	if err != nil || len(users) == 0 {
-		// Don't reveal if email exists - return empty list
-		return c.JSON(GetUserTenantsResponse{
-			Email:   req.Email,
-			Tenants: []TenantOption{},
-			Count:   0,
-		})
+	if err != nil {
+		users = nil
 	}
 
 	// Build tenant options
 	tenantOptions := make([]TenantOption, 0, len(users))
+	listed := make(map[kernel.TenantID]bool, len(users))
 	for _, u := range users {
 		tenantEntity, err := h.tenantRepo.FindByID(c.Context(), u.TenantID)
 		if err != nil || !tenantEntity.IsActive() {
@@ -130,6 +154,7 @@ func (h *PasswordlessAuthHandlers) GetUserTenants(c *fiber.Ctx) error {
 		option := TenantOption{
 			TenantID:    u.TenantID,
 			CompanyName: tenantEntity.CompanyName,
+			Match:       TenantMatchExistingAccount,
 			UserStatus:  u.Status,
 		}
 
@@ -137,8 +162,16 @@ func (h *PasswordlessAuthHandlers) GetUserTenants(c *fiber.Ctx) error {
 		option.AuthMethods.OTP = u.HasOTP()
 		option.AuthMethods.OAuth = u.HasOAuth()
 		option.AuthMethods.Provider = u.OAuthProvider
+		option.AuthMethods.SSO = h.oauthResolver.HasSSO(c.Context(), u.TenantID)
 
 		tenantOptions = append(tenantOptions, option)
+		listed[u.TenantID] = true
+	}
+
+	// Suggest tenants that verified the email's domain. These depend only on
+	// the domain, never on whether this address has an account.
+	if h.config.TenantConfig.DomainDiscovery {
+		tenantOptions = append(tenantOptions, h.domainTenantOptions(c.Context(), req.Email, listed)...)
 	}
 
 	return c.JSON(GetUserTenantsResponse{
@@ -148,6 +181,38 @@ func (h *PasswordlessAuthHandlers) GetUserTenants(c *fiber.Ctx) error {
 	})
 }
 
+// domainTenantOptions returns the active tenants that verified the email's
+// domain, skipping those already listed
+func (h *PasswordlessAuthHandlers) domainTenantOptions(ctx context.Context, email string, listed map[kernel.TenantID]bool) []TenantOption {
+	domain := tenant.EmailDomain(email)
+	if domain == "" {
+		return nil
+	}
+
+	tenants, err := h.tenantRepo.FindByEmailDomain(ctx, domain)
+	if err != nil {
+		return nil
+	}
+
+	options := make([]TenantOption, 0, len(tenants))
+	for _, t := range tenants {
+		if listed[t.ID] {
+			continue
+		}
+
+		option := TenantOption{
+			TenantID:    t.ID,
+			CompanyName: t.CompanyName,
+			Match:       TenantMatchDomain,
+		}
+		option.AuthMethods.SSO = h.oauthResolver.HasSSO(ctx, t.ID)
+
+		options = append(options, option)
+	}
+
+	return options
+}
+
 // ============================================================================
 // SIGNUP FLOW
 // ============================================================================
@@ -176,10 +241,8 @@ type InitiateSignupResponse struct {
 // InitiateSignup creates user account and sends OTP (with account linking support)
 func (h *PasswordlessAuthHandlers) InitiateSignup(c *fiber.Ctx) error {
 	var req InitiateSignupRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
 	// 1. Validate invitation token
@@ -255,7 +318,7 @@ func (h *PasswordlessAuthHandlers) InitiateSignup(c *fiber.Ctx) error {
 			h.auditService.LogAccountLinked(c.Context(), existingUser.ID, tenantID, "otp", c.IP())
 
 			// Generate and send OTP
-			otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Email, otp.OTPPurposeVerification)
+			otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Email, otp.OTPPurposeSignup)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": "Failed to send verification code",
@@ -293,40 +356,76 @@ func (h *PasswordlessAuthHandlers) InitiateSignup(c *fiber.Ctx) error {
 
 	// 7. Create NEW user account
 	newUser := &user.User{
-		ID:            kernel.NewUserID(uuid.NewString()),
-		TenantID:      tenantID,
-		Email:         req.Email,
-		Name:          req.Name,
-		Status:        user.UserStatusPending,
-		Scopes:        inv.GetScopes(),
-		OTPEnabled:    true, // 🔥 Enable OTP for this user
-		EmailVerified: false,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
-
-	// 8. Save user
-	if err := h.userRepo.Save(c.Context(), *newUser); err != nil {
+		ID:              kernel.NewUserID(uuid.NewString()),
+		TenantID:        tenantID,
+		Email:           req.Email,
+		Name:            req.Name,
+		Status:          user.UserStatusPending,
+		Scopes:          inv.GetScopes(),
+		RequestedScopes: inv.GetRequestedScopes(),
+		OTPEnabled:      true, // 🔥 Enable OTP for this user
+		EmailVerified:   false,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	// 8-11. Save the user, update the tenant user count and accept the
+	// invitation. With a transactor they commit or roll back together, so a
+	// failure midway never leaves the user count or the invitation out of step.
+	// With the OTP outbox the code is enqueued in the same transaction;
+	// otherwise it is sent after commit, so no email goes out while the
+	// transaction is open or for an account that is then rolled back
+	otpInTx := h.otpService.DeliversAsync()
+	var otpEntity *otp.OTP
+	accountSaved, invitationAccepted := false, false
+	err = h.withinTx(c.Context(), func(ctx context.Context) error {
+		if err := h.userRepo.Save(ctx, *newUser); err != nil {
+			return err
+		}
+		if err := tenantEntity.AddUser(); err != nil {
+			return err
+		}
+		if err := h.tenantRepo.Save(ctx, *tenantEntity); err != nil {
+			return err
+		}
+		if err := inv.Accept(newUser.ID); err == nil {
+			if err := h.invitationRepo.Save(ctx, *inv); err != nil {
+				return err
+			}
+			invitationAccepted = true
+		}
+		accountSaved = true
+
+		if !otpInTx {
+			return nil
+		}
+		var err error
+		otpEntity, err = h.otpService.GenerateOTP(ctx, req.Email, otp.OTPPurposeSignup)
+		return err
+	})
+
+	// Without a transactor the account is kept even if the OTP failed
+	if err != nil && (!accountSaved || h.transactor != nil) {
+		if !accountSaved {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create user account",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create user account",
+			"error": "Failed to send verification code",
 		})
 	}
 
-	// 9. Update tenant user count
-	if err := tenantEntity.AddUser(); err == nil {
-		h.tenantRepo.Save(c.Context(), *tenantEntity)
+	if err == nil && !otpInTx {
+		otpEntity, err = h.otpService.GenerateOTP(c.Context(), req.Email, otp.OTPPurposeSignup)
 	}
 
 	// Audit: account created via OTP
 	h.auditService.LogAccountCreated(c.Context(), newUser.ID, tenantID, "otp", c.IP())
-
-	// 10. Mark invitation as accepted
-	if err := inv.Accept(newUser.ID); err == nil {
-		h.invitationRepo.Save(c.Context(), *inv)
+	if invitationAccepted {
+		h.auditService.LogInvitationAccepted(c.Context(), inv.GetID(), newUser.ID, tenantID, c.IP())
 	}
 
-	// 11. Generate and send OTP
-	otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Email, otp.OTPPurposeVerification)
 	if err != nil {
 		return c.Status(fiber.StatusPartialContent).JSON(fiber.Map{
 			"error":     "Account created but failed to send verification code",
@@ -355,18 +454,21 @@ type VerifySignupRequest struct {
 // VerifySignup verifies OTP and activates account
 func (h *PasswordlessAuthHandlers) VerifySignup(c *fiber.Ctx) error {
 	var req VerifySignupRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
 	// 1. Verify OTP
-	_, err := h.otpService.VerifyOTP(c.Context(), req.Email, req.Code, otp.OTPPurposeVerification)
+	_, err := h.otpService.VerifyOTP(c.Context(), req.Email, req.Code, otp.OTPPurposeSignup)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		// The OTP codes tell the client whether to retype the code or request
+		// a new one; anything else is a server failure
+		switch {
+		case otp.IsInvalid(err), otp.IsExpired(err), errors.Is(err, otp.CodeOTPAlreadyUsed),
+			otp.IsTooManyAttempts(err):
+			return err
+		}
+		return errx.Wrap(err, "failed to verify signup code", errx.TypeInternal)
 	}
 
 	// 2. Find user
@@ -427,10 +529,8 @@ type InitiateLoginResponse struct {
 // InitiateLogin sends OTP for login
 func (h *PasswordlessAuthHandlers) InitiateLogin(c *fiber.Ctx) error {
 	var req InitiateLoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
 	// 1. Find user by email and tenant
@@ -486,11 +586,9 @@ func (h *PasswordlessAuthHandlers) InitiateLogin(c *fiber.Ctx) error {
 	}
 
 	// 6. Generate and send OTP
-	otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Email, otp.OTPPurposeVerification)
+	otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Email, otp.OTPPurposeLogin)
 	if err != nil {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return respondOTPError(c, err)
 	}
 
 	// 7. Return success response with available auth methods
@@ -522,15 +620,17 @@ type VerifyLoginRequest struct {
 // VerifyLogin verifies OTP and returns JWT tokens
 func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 	var req VerifyLoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
 	// 1. Verify OTP
-	_, err := h.otpService.VerifyOTP(c.Context(), req.Email, req.Code, otp.OTPPurposeVerification)
+	_, err := h.otpService.VerifyOTP(c.Context(), req.Email, req.Code, otp.OTPPurposeLogin)
 	if err != nil {
+		h.auditService.LogLoginAttempt(c.Context(), "", req.TenantID, "otp", false, c.IP(), c.Get("User-Agent"))
+		if otp.IsTooManyAttempts(err) {
+			return err
+		}
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid or expired code",
 		})
@@ -544,32 +644,52 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 		})
 	}
 
-	// 3. Check user can login
+	// 3. The code reached the inbox: the email is verified (persisted with the last login)
+	userEntity.EmailVerified = true
+
+	return h.completeLogin(c, userEntity, "otp")
+}
+
+// completeLogin issues tokens and a session for a user whose OTP code or
+// password was verified. method is recorded in the audit log.
+func (h *PasswordlessAuthHandlers) completeLogin(c *fiber.Ctx, userEntity *user.User, method string) error {
+	// 1. Check user can login
 	if !userEntity.CanLogin() {
+		h.auditService.LogLoginAttempt(c.Context(), userEntity.ID, userEntity.TenantID, method, false, c.IP(), c.Get("User-Agent"))
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Account cannot login. Status: " + string(userEntity.Status),
 		})
 	}
 
-	// 4. Get tenant
+	// 2. Get tenant
 	tenantEntity, err := h.tenantRepo.FindByID(c.Context(), userEntity.TenantID)
 	if err != nil || !tenantEntity.IsActive() {
+		h.auditService.LogLoginAttempt(c.Context(), userEntity.ID, userEntity.TenantID, method, false, c.IP(), c.Get("User-Agent"))
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Organization is not active",
 		})
 	}
 
-	// 5. Ensure email is verified
-	if !userEntity.EmailVerified {
-		userEntity.EmailVerified = true
-		h.userRepo.Save(c.Context(), *userEntity)
+	// 3. Enforce the per-user session limit
+	if err := enforceSessionLimit(c.Context(), h.sessionRepo, h.tokenRepo, h.config.Auth.Session, userEntity.ID); err != nil {
+		if IsTooManySessions(err) {
+			h.auditService.LogLoginAttempt(c.Context(), userEntity.ID, userEntity.TenantID, method, false, c.IP(), c.Get("User-Agent"))
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		logx.WithFields(logx.Fields{"user_id": userEntity.ID}).
+			Warnf("failed to enforce session limit: %v", err)
 	}
 
-	// 6. Generate JWT tokens
+	// 4. Generate JWT tokens, bound to the session created below
+	sessionID := uuid.NewString()
+
 	accessToken, err := h.tokenService.GenerateAccessToken(userEntity.ID, tenantEntity.ID, map[string]any{
-		"email":  userEntity.Email,
-		"name":   userEntity.Name,
-		"scopes": userEntity.Scopes,
+		"email":      userEntity.Email,
+		"name":       userEntity.Name,
+		"scopes":     userEntity.Scopes,
+		"session_id": sessionID,
 	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -584,7 +704,7 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 		})
 	}
 
-	// 7. Save refresh token
+	// 5. Save refresh token
 	refreshToken := RefreshToken{
 		ID:       uuid.NewString(),
 		Token:    refreshTokenStr,
@@ -595,12 +715,13 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 
 		CreatedAt: time.Now(),
 		IsRevoked: false,
+		SessionID: sessionID,
 	}
 	h.tokenRepo.SaveRefreshToken(c.Context(), refreshToken)
 
-	// 8. Create session
+	// 6. Create session
 	session := UserSession{
-		ID:           uuid.NewString(),
+		ID:           sessionID,
 		UserID:       userEntity.ID,
 		TenantID:     tenantEntity.ID,
 		SessionToken: uuid.NewString(),
@@ -612,11 +733,11 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 	}
 	h.sessionRepo.SaveSession(c.Context(), session)
 
-	// 9. Update last login
+	// 7. Update last login
 	userEntity.UpdateLastLogin()
 	h.userRepo.Save(c.Context(), *userEntity)
 
-	// 10. Set cookies
+	// 8. Set cookies
 	c.Cookie(&fiber.Cookie{
 		Name:     h.config.Auth.Cookie.AccessTokenName,
 		Value:    accessToken,
@@ -639,10 +760,10 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 		Path:     h.config.Auth.Cookie.Path,
 	})
 
-	// 11. Audit: successful OTP login
-	h.auditService.LogLoginAttempt(c.Context(), userEntity.ID, tenantEntity.ID, "otp", true, c.IP(), c.Get("User-Agent"))
+	// 9. Audit: successful login
+	h.auditService.LogLoginAttempt(c.Context(), userEntity.ID, tenantEntity.ID, method, true, c.IP(), c.Get("User-Agent"))
 
-	// 12. Return tokens and user info
+	// 10. Return tokens and user info
 	return c.JSON(TokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshTokenStr,
@@ -653,6 +774,125 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 	})
 }
 
+// ============================================================================
+// PHONE LOGIN (SMS OTP)
+// ============================================================================
+
+// InitiatePhoneLoginRequest starts login with an SMS code
+type InitiatePhoneLoginRequest struct {
+	Phone    string          `json:"phone" validate:"required,e164"`
+	TenantID kernel.TenantID `json:"tenant_id" validate:"required"`
+}
+
+type InitiatePhoneLoginResponse struct {
+	Message   string `json:"message"`
+	Phone     string `json:"phone"`
+	ExpiresIn int    `json:"expires_in_seconds"`
+}
+
+// InitiatePhoneLogin sends a login OTP by SMS to a user's registered phone
+func (h *PasswordlessAuthHandlers) InitiatePhoneLogin(c *fiber.Ctx) error {
+	var req InitiatePhoneLoginRequest
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
+	}
+
+	// 1. Validate E.164 format
+	if !kernel.Phone(req.Phone).IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Phone must be in E.164 format (e.g. +14155552671)",
+		})
+	}
+
+	// 2. Find user by phone and tenant
+	userEntity, err := h.userRepo.FindByPhone(c.Context(), req.Phone, req.TenantID)
+	if err != nil {
+		// Don't reveal if user exists
+		return c.JSON(InitiatePhoneLoginResponse{
+			Message:   "If this phone is registered, you'll receive a login code.",
+			Phone:     req.Phone,
+			ExpiresIn: 300,
+		})
+	}
+
+	// 3. Check user can use OTP login
+	if !userEntity.IsActive() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account is not active. Please complete signup verification or contact support.",
+		})
+	}
+
+	if !userEntity.HasOTP() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":                "This account doesn't use passwordless login. Please sign in with OAuth instead.",
+			"can_login_with_oauth": userEntity.HasOAuth(),
+			"oauth_provider":       userEntity.OAuthProvider,
+		})
+	}
+
+	// 4. Check tenant status
+	tenantEntity, err := h.tenantRepo.FindByID(c.Context(), userEntity.TenantID)
+	if err != nil || !tenantEntity.IsActive() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account access is currently unavailable",
+		})
+	}
+
+	// 5. Generate and send OTP by SMS
+	otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Phone, otp.OTPPurposeLogin)
+	if err != nil {
+		return respondOTPError(c, err)
+	}
+
+	return c.JSON(InitiatePhoneLoginResponse{
+		Message:   "Login code sent by SMS!",
+		Phone:     req.Phone,
+		ExpiresIn: int(time.Until(otpEntity.ExpiresAt).Seconds()),
+	})
+}
+
+// VerifyPhoneLoginRequest completes login by verifying the SMS code
+type VerifyPhoneLoginRequest struct {
+	Phone    string          `json:"phone" validate:"required,e164"`
+	Code     string          `json:"code" validate:"required"`
+	TenantID kernel.TenantID `json:"tenant_id" validate:"required"`
+}
+
+// VerifyPhoneLogin verifies the SMS OTP and returns JWT tokens
+func (h *PasswordlessAuthHandlers) VerifyPhoneLogin(c *fiber.Ctx) error {
+	var req VerifyPhoneLoginRequest
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
+	}
+
+	if !kernel.Phone(req.Phone).IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Phone must be in E.164 format (e.g. +14155552671)",
+		})
+	}
+
+	// 1. Verify OTP
+	if _, err := h.otpService.VerifyOTP(c.Context(), req.Phone, req.Code, otp.OTPPurposeLogin); err != nil {
+		h.auditService.LogLoginAttempt(c.Context(), "", req.TenantID, "otp_sms", false, c.IP(), c.Get("User-Agent"))
+		if otp.IsTooManyAttempts(err) {
+			return err
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired code",
+		})
+	}
+
+	// 2. Find user
+	userEntity, err := h.userRepo.FindByPhone(c.Context(), req.Phone, req.TenantID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication failed",
+		})
+	}
+
+	return h.completeLogin(c, userEntity, "otp_sms")
+}
+
 // ============================================================================
 // UTILITY
 // ============================================================================
@@ -667,10 +907,8 @@ type ResendOTPRequest struct {
 // ResendOTP resends OTP code
 func (h *PasswordlessAuthHandlers) ResendOTP(c *fiber.Ctx) error {
 	var req ResendOTPRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
 	// Verify user exists in the tenant
@@ -704,12 +942,14 @@ func (h *PasswordlessAuthHandlers) ResendOTP(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate new OTP
-	otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Email, otp.OTPPurposeVerification)
+	// Generate new OTP for the requested flow
+	purpose := otp.OTPPurposeLogin
+	if req.Purpose == "signup" {
+		purpose = otp.OTPPurposeSignup
+	}
+	otpEntity, err := h.otpService.GenerateOTP(c.Context(), req.Email, purpose)
 	if err != nil {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return respondOTPError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -717,3 +957,41 @@ func (h *PasswordlessAuthHandlers) ResendOTP(c *fiber.Ctx) error {
 		"expires_in": int(time.Until(otpEntity.ExpiresAt).Seconds()),
 	})
 }
+
+// respondOTPError maps a GenerateOTP error to its HTTP response. Rate-limited
+// requests get a Retry-After header and retry_after_seconds so clients can
+// show a countdown.
+func respondOTPError(c *fiber.Ctx, err error) error {
+	if retryAfter, ok := otp.RetryAfter(err); ok {
+		seconds := int(retryAfter / time.Second)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":               "Too many code requests. Please wait before requesting a new one.",
+			"retry_after_seconds": seconds,
+		})
+	}
+
+	switch {
+	case otp.IsSendFailed(err):
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to send verification code",
+		})
+	case otp.IsChannelDisabled(err):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "This delivery channel is not available",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to generate verification code",
+	})
+}
+
+// withinTx runs fn in a transaction when a transactor is configured, and
+// directly otherwise
+func (h *PasswordlessAuthHandlers) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if h.transactor == nil {
+		return fn(ctx)
+	}
+	return h.transactor.WithinTx(ctx, fn)
+}
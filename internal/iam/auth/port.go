@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
 )
 
@@ -11,7 +13,12 @@ type TokenRepository interface {
 	SaveRefreshToken(ctx context.Context, token RefreshToken) error
 	FindRefreshToken(ctx context.Context, tokenValue string) (*RefreshToken, error)
 	RevokeRefreshToken(ctx context.Context, tokenValue string) error
+	// RotateRefreshToken atomically revokes oldTokenValue and saves newToken.
+	// Returns ErrRefreshTokenReused if oldTokenValue was already revoked.
+	RotateRefreshToken(ctx context.Context, oldTokenValue string, newToken RefreshToken) error
 	RevokeAllUserTokens(ctx context.Context, userID kernel.UserID) error
+	// RevokeSessionTokens revokes the refresh tokens issued for a login session
+	RevokeSessionTokens(ctx context.Context, sessionID string) error
 	CleanExpiredTokens(ctx context.Context) error
 }
 
@@ -19,11 +26,29 @@ type TokenRepository interface {
 type SessionRepository interface {
 	SaveSession(ctx context.Context, session UserSession) error
 	FindSession(ctx context.Context, sessionID string) (*UserSession, error)
-	FindUserSessions(ctx context.Context, userID kernel.UserID) ([]*UserSession, error)
+	// FindActiveByUser returns the user's unexpired sessions, most recently active first
+	FindActiveByUser(ctx context.Context, userID kernel.UserID) ([]*UserSession, error)
+	// CountActiveByUser returns how many unexpired sessions the user has
+	CountActiveByUser(ctx context.Context, userID kernel.UserID) (int, error)
+	// FindOldestActiveByUser returns the user's earliest created unexpired session
+	FindOldestActiveByUser(ctx context.Context, userID kernel.UserID) (*UserSession, error)
 	UpdateSessionActivity(ctx context.Context, sessionID string) error
+	// Touch records activity on a session. When slideBy > 0 it also moves
+	// expires_at to slideBy from now, but never past created_at + maxLifetime.
+	Touch(ctx context.Context, sessionID string, slideBy, maxLifetime time.Duration) error
 	RevokeSession(ctx context.Context, sessionID string) error
 	RevokeAllUserSessions(ctx context.Context, userID kernel.UserID) error
 	CleanExpiredSessions(ctx context.Context) error
+	// CleanIdleSessions deletes sessions without activity for longer than
+	// idleTimeout and returns how many were deleted
+	CleanIdleSessions(ctx context.Context, idleTimeout time.Duration) (int64, error)
+}
+
+// ActivityThrottle limits how often activity on the same key is persisted
+type ActivityThrottle interface {
+	// Allow reports whether activity on key may be written now. It returns
+	// true at most once per interval per key.
+	Allow(ctx context.Context, key string) (bool, error)
 }
 
 // PasswordResetRepository defines the contract for password reset tokens
@@ -32,12 +57,33 @@ type PasswordResetRepository interface {
 	FindResetToken(ctx context.Context, tokenValue string) (*PasswordResetToken, error)
 	ConsumeResetToken(ctx context.Context, tokenValue string) error
 	CleanExpiredResetTokens(ctx context.Context) error
+	// HasRecentResetToken reports whether maxTokens or more tokens were
+	// created for the user within window, used or not
+	HasRecentResetToken(ctx context.Context, userID kernel.UserID, window time.Duration, maxTokens int) (bool, error)
+	// RevokeAllUserResetTokens marks every pending token of the user as used
+	RevokeAllUserResetTokens(ctx context.Context, userID kernel.UserID) error
+}
+
+// PasswordResetNotifier sends password reset links (e.g.
+// authinfra.EmailPasswordResetNotifier)
+type PasswordResetNotifier interface {
+	SendPasswordReset(ctx context.Context, email, resetURL string, expiresAt time.Time) error
+}
+
+// ProfilePhotoStore stores the profile photo fetched from an IdP and returns
+// the URL to use as the user's picture (e.g. authinfra.FSXProfilePhotoStore)
+type ProfilePhotoStore interface {
+	SaveProfilePhoto(ctx context.Context, provider iam.OAuthProvider, providerUserID, contentType string, data []byte) (string, error)
 }
 
 // TokenService defines the contract for JWT token management
 type TokenService interface {
 	GenerateAccessToken(userID kernel.UserID, tenantID kernel.TenantID, claims map[string]any) (string, error)
 	ValidateAccessToken(token string) (*TokenClaims, error)
+	// IntrospectToken reports whether token is an active access token and
+	// returns its claims. Invalid or expired tokens are not an error: they
+	// come back with Active=false.
+	IntrospectToken(token string) (*TokenIntrospection, error)
 	GenerateRefreshToken(userID kernel.UserID) (string, error)
 }
 
@@ -49,6 +95,41 @@ type AuditService interface {
 	LogOTPVerification(ctx context.Context, contact string, success bool, ip string)
 	LogAccountCreated(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, ip string)
 	LogAccountLinked(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, ip string)
+	LogAccountUnlinked(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, method string, ip string)
+	LogInvitationAccepted(ctx context.Context, invitationID string, userID kernel.UserID, tenantID kernel.TenantID, ip string)
+	LogPasswordReset(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, ip string)
+}
+
+// RateLimiter counts requests per key over a sliding window
+type RateLimiter interface {
+	// Allow records a request for key and reports whether it fits within
+	// limit requests per window. Rejected requests are not counted.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// IdempotencyRecord is what IdempotencyStore keeps per Idempotency-Key. A
+// record without StatusCode belongs to a request still in progress.
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"` // Hash of method, path and body
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"` // Sealed by the middleware, never in clear
+}
+
+// Completed reports whether the record holds a stored response
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// IdempotencyStore keeps the responses of requests sent with an Idempotency-Key
+type IdempotencyStore interface {
+	// Reserve saves record under key unless one already exists. It returns
+	// the existing record and false when the key was already taken.
+	Reserve(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Complete replaces the reservation with the final response
+	Complete(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+	// Release drops a reservation so the request can be retried
+	Release(ctx context.Context, key string) error
 }
 
 // Invitation represents an invitation (to avoid circular dependency)
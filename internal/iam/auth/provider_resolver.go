@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// Timeouts de los IdP de los tenants: conexión y cada operación
+const (
+	ssoProviderConnectTimeout   = 10 * time.Second
+	ssoProviderOperationTimeout = 15 * time.Second
+)
+
+// OAuthProviderResolver decide qué OAuthService atiende un login: los
+// proveedores globales (Google, Microsoft, OIDC de configuración) o la conexión
+// SSO propia de un tenant (iam.OAuthProviderSSO).
+type OAuthProviderResolver struct {
+	global       map[iam.OAuthProvider]OAuthService
+	ssoRepo      tenant.SSOConnectionRepository
+	stateManager StateManager
+
+	// Servicios SSO ya descubiertos, por tenant. Se reconstruyen cuando la
+	// conexión cambia (updated_at distinto).
+	mu    sync.Mutex
+	cache map[kernel.TenantID]cachedSSOService
+}
+
+type cachedSSOService struct {
+	service   OAuthService
+	updatedAt time.Time
+}
+
+// NewOAuthProviderResolver crea el resolver. ssoRepo puede ser nil, en cuyo
+// caso solo se usan los proveedores globales.
+func NewOAuthProviderResolver(
+	global map[iam.OAuthProvider]OAuthService,
+	ssoRepo tenant.SSOConnectionRepository,
+	stateManager StateManager,
+) *OAuthProviderResolver {
+	return &OAuthProviderResolver{
+		global:       global,
+		ssoRepo:      ssoRepo,
+		stateManager: stateManager,
+		cache:        make(map[kernel.TenantID]cachedSSOService),
+	}
+}
+
+// Resolve retorna el servicio de un proveedor. Para iam.OAuthProviderSSO se
+// usa la conexión del tenant indicado.
+func (r *OAuthProviderResolver) Resolve(ctx context.Context, provider iam.OAuthProvider, tenantID kernel.TenantID) (OAuthService, error) {
+	if provider == iam.OAuthProviderSSO {
+		if tenantID.IsEmpty() {
+			return nil, ErrInvalidOAuthProvider().WithDetail("reason", "tenant required for SSO")
+		}
+		service, _, err := r.ForTenant(ctx, tenantID)
+		return service, err
+	}
+
+	service, ok := r.global[provider]
+	if !ok {
+		return nil, ErrInvalidOAuthProvider().WithDetail("provider", string(provider))
+	}
+	return service, nil
+}
+
+// ForTenant retorna el servicio de la conexión SSO del tenant
+func (r *OAuthProviderResolver) ForTenant(ctx context.Context, tenantID kernel.TenantID) (OAuthService, *tenant.SSOConnection, error) {
+	if r.ssoRepo == nil {
+		return nil, nil, tenant.ErrSSONotConfigured().WithDetail("tenant_id", tenantID.String())
+	}
+
+	conn, err := r.ssoRepo.FindByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	service, err := r.serviceFor(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service, conn, nil
+}
+
+// ForEmail retorna el servicio de la conexión SSO que reclama el dominio del email
+func (r *OAuthProviderResolver) ForEmail(ctx context.Context, email string) (OAuthService, *tenant.SSOConnection, error) {
+	domain := tenant.EmailDomain(email)
+	if r.ssoRepo == nil || domain == "" {
+		return nil, nil, tenant.ErrSSONotConfigured().WithDetail("domain", domain)
+	}
+
+	conn, err := r.ssoRepo.FindByEmailDomain(ctx, domain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	service, err := r.serviceFor(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service, conn, nil
+}
+
+// HasSSO indica si el tenant tiene una conexión SSO activa
+func (r *OAuthProviderResolver) HasSSO(ctx context.Context, tenantID kernel.TenantID) bool {
+	if r == nil || r.ssoRepo == nil {
+		return false
+	}
+	_, err := r.ssoRepo.FindByTenant(ctx, tenantID)
+	return err == nil
+}
+
+// serviceFor retorna el servicio cacheado de la conexión o ejecuta el
+// discovery del issuer si la conexión es nueva o cambió
+func (r *OAuthProviderResolver) serviceFor(ctx context.Context, conn *tenant.SSOConnection) (OAuthService, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[conn.TenantID]
+	r.mu.Unlock()
+	if ok && cached.updatedAt.Equal(conn.UpdatedAt) {
+		return cached.service, nil
+	}
+
+	service, err := NewGenericOIDCOAuthServiceFromConfig(ctx, &config.OIDCProviderConfig{
+		Key:              strings.ToLower(string(iam.OAuthProviderSSO)),
+		IssuerURL:        conn.IssuerURL,
+		ClientID:         conn.ClientID,
+		ClientSecret:     conn.ClientSecret,
+		RedirectURL:      conn.RedirectURL,
+		Scopes:           conn.Scopes,
+		GroupsClaim:      conn.GroupsClaim,
+		Timeout:          ssoProviderConnectTimeout,
+		OperationTimeout: ssoProviderOperationTimeout,
+	}, r.stateManager)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[conn.TenantID] = cachedSSOService{service: service, updatedAt: conn.UpdatedAt}
+	r.mu.Unlock()
+
+	return service, nil
+}
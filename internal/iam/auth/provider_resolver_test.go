@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// ssoRepo implements the lookups of tenant.SSOConnectionRepository used by
+// the resolver. EmailDomains stand for the tenant's verified domains.
+type ssoRepo struct {
+	tenant.SSOConnectionRepository
+	conns map[kernel.TenantID]*tenant.SSOConnection
+}
+
+func (r *ssoRepo) FindByTenant(_ context.Context, tenantID kernel.TenantID) (*tenant.SSOConnection, error) {
+	conn, ok := r.conns[tenantID]
+	if !ok || !conn.IsActive {
+		return nil, tenant.ErrSSONotConfigured()
+	}
+	return conn, nil
+}
+
+func (r *ssoRepo) FindByEmailDomain(_ context.Context, domain string) (*tenant.SSOConnection, error) {
+	for _, conn := range r.conns {
+		if conn.IsActive && slices.Contains(conn.EmailDomains, domain) {
+			return conn, nil
+		}
+	}
+	return nil, tenant.ErrSSONotConfigured()
+}
+
+type staticOAuthService struct {
+	OAuthService
+	provider iam.OAuthProvider
+}
+
+func (s staticOAuthService) GetProvider() iam.OAuthProvider { return s.provider }
+
+func TestResolverGlobalProviders(t *testing.T) {
+	ctx := context.Background()
+	google := staticOAuthService{provider: iam.OAuthProviderGoogle}
+	r := NewOAuthProviderResolver(map[iam.OAuthProvider]OAuthService{iam.OAuthProviderGoogle: google}, nil, nil)
+
+	if service, err := r.Resolve(ctx, iam.OAuthProviderGoogle, ""); err != nil || service != google {
+		t.Errorf("Resolve(GOOGLE) = %v, %v", service, err)
+	}
+	if _, err := r.Resolve(ctx, "OKTA", ""); !errx.Is(err, CodeInvalidOAuthProvider) {
+		t.Errorf("unknown provider error = %v, want INVALID_OAUTH_PROVIDER", err)
+	}
+	if _, err := r.Resolve(ctx, iam.OAuthProviderSSO, ""); !errx.Is(err, CodeInvalidOAuthProvider) {
+		t.Errorf("SSO without tenant error = %v, want INVALID_OAUTH_PROVIDER", err)
+	}
+
+	// Without an SSO repository there are no tenant connections
+	if _, _, err := r.ForTenant(ctx, "t1"); !errx.Is(err, tenant.CodeSSONotConfigured) {
+		t.Errorf("ForTenant error = %v, want SSO_NOT_CONFIGURED", err)
+	}
+	if _, _, err := r.ForEmail(ctx, "ana@acme.com"); !errx.Is(err, tenant.CodeSSONotConfigured) {
+		t.Errorf("ForEmail error = %v, want SSO_NOT_CONFIGURED", err)
+	}
+	if r.HasSSO(ctx, "t1") {
+		t.Error("HasSSO without repository = true")
+	}
+}
+
+func TestResolverTenantConnections(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	updatedAt := time.Now()
+	conn := &tenant.SSOConnection{
+		TenantID:     "t1",
+		IssuerURL:    issuer.server.URL,
+		ClientID:     "acme-app",
+		RedirectURL:  "https://app.example.com/auth/callback/sso",
+		EmailDomains: []string{"acme.com"},
+		IsActive:     true,
+		UpdatedAt:    updatedAt,
+	}
+	repo := &ssoRepo{conns: map[kernel.TenantID]*tenant.SSOConnection{
+		"t1": conn,
+		"t2": {TenantID: "t2", IssuerURL: issuer.server.URL, ClientID: "old-app", IsActive: false},
+	}}
+	r := NewOAuthProviderResolver(nil, repo, nil)
+
+	service, found, err := r.ForTenant(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != conn || !strings.Contains(service.GetAuthURL("s"), "client_id=acme-app") {
+		t.Errorf("ForTenant = %v, %+v; want the tenant's connection", service.GetAuthURL("s"), found)
+	}
+	if service, err := r.Resolve(ctx, iam.OAuthProviderSSO, "t1"); err != nil || service.GetProvider() != iam.OAuthProviderSSO {
+		t.Errorf("Resolve(SSO, t1) = %v, %v", service, err)
+	}
+
+	// The discovered service is reused until the connection changes
+	if _, _, err := r.ForTenant(ctx, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := issuer.discoveries.Load(); n != 1 {
+		t.Errorf("discovery ran %d times, want 1", n)
+	}
+	conn.UpdatedAt = updatedAt.Add(time.Second)
+	if _, _, err := r.ForTenant(ctx, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := issuer.discoveries.Load(); n != 2 {
+		t.Errorf("discovery ran %d times after the update, want 2", n)
+	}
+
+	if _, _, err := r.ForTenant(ctx, "t2"); !errx.Is(err, tenant.CodeSSONotConfigured) {
+		t.Errorf("inactive connection error = %v, want SSO_NOT_CONFIGURED", err)
+	}
+	if !r.HasSSO(ctx, "t1") || r.HasSSO(ctx, "t2") {
+		t.Error("HasSSO should only report the active connection")
+	}
+
+	// Emails are routed by the verified domain
+	if _, found, err := r.ForEmail(ctx, "ana@acme.com"); err != nil || found.TenantID != "t1" {
+		t.Errorf("ForEmail(acme.com) = %+v, %v; want t1", found, err)
+	}
+	for _, email := range []string{"ana@other.com", "not-an-email"} {
+		if _, _, err := r.ForEmail(ctx, email); !errx.Is(err, tenant.CodeSSONotConfigured) {
+			t.Errorf("ForEmail(%q) error = %v, want SSO_NOT_CONFIGURED", email, err)
+		}
+	}
+}
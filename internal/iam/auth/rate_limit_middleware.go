@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rate limit response headers
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// RateLimitResult is the outcome of RateLimiter.Allow
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the oldest counted request leaves the
+	// window; for a rejected request it is how long the client must wait
+	ResetAfter time.Duration
+}
+
+// RateLimitKeyBy selects what a rule counts requests against
+type RateLimitKeyBy string
+
+const (
+	RateLimitByIP       RateLimitKeyBy = "ip"
+	RateLimitByUserOrIP RateLimitKeyBy = "user" // authenticated user or API key, else IP
+)
+
+// RateLimitRule limits the requests to a set of routes
+type RateLimitRule struct {
+	Name   string   // Namespaces the counters, e.g. "login"
+	Paths  []string // Route suffixes it applies to, e.g. "/auth/login"; empty = any route
+	Limit  int      // Requests per Window; 0 disables the rule
+	Window time.Duration
+	KeyBy  RateLimitKeyBy
+}
+
+func (r RateLimitRule) matches(path string) bool {
+	if len(r.Paths) == 0 {
+		return true
+	}
+	path = strings.TrimSuffix(path, "/")
+	for _, p := range r.Paths {
+		if strings.HasSuffix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitMiddleware enforces RateLimitRules with a shared RateLimiter.
+// Rejections are returned as errx errors so the global error handler formats
+// them; the X-RateLimit-* and Retry-After headers are set on the response.
+type RateLimitMiddleware struct {
+	limiter  RateLimiter
+	rules    []RateLimitRule
+	failOpen bool
+}
+
+// NewRateLimitMiddleware creates the middleware. Rules are checked in order and
+// the first one matching the request path applies. With failOpen, requests go
+// through when the limiter errors instead of failing with 503.
+func NewRateLimitMiddleware(limiter RateLimiter, failOpen bool, rules ...RateLimitRule) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter:  limiter,
+		rules:    rules,
+		failOpen: failOpen,
+	}
+}
+
+// DefaultRateLimitRules builds the rules for the auth endpoints from config
+func DefaultRateLimitRules(cfg config.RateLimitConfig) []RateLimitRule {
+	return []RateLimitRule{
+		{
+			Name: "login",
+			Paths: []string{
+				"/auth/login",
+				"/auth/passwordless/tenants",
+				"/auth/passwordless/signup/initiate",
+				"/auth/passwordless/login/initiate",
+				"/auth/passwordless/login/phone/initiate",
+				"/auth/password/forgot",
+			},
+			Limit:  cfg.Login.Requests,
+			Window: cfg.Login.Window,
+			KeyBy:  RateLimitByIP,
+		},
+		{
+			Name: "otp",
+			Paths: []string{
+				"/auth/passwordless/signup/verify",
+				"/auth/passwordless/login/verify",
+				"/auth/passwordless/login/phone/verify",
+				"/auth/passwordless/resend-otp",
+				"/auth/password/login",
+				"/auth/password/reset",
+			},
+			Limit:  cfg.OTP.Requests,
+			Window: cfg.OTP.Window,
+			KeyBy:  RateLimitByIP,
+		},
+		// Handler() runs before Authenticate, so no AuthContext is available
+		// yet and these routes can only be counted per IP; mount a rule with
+		// Limit after Authenticate to count per user
+		{
+			Name:   "auth",
+			Paths:  []string{"/auth/refresh", "/auth/logout", "/auth/me"},
+			Limit:  cfg.Default.Requests,
+			Window: cfg.Default.Window,
+			KeyBy:  RateLimitByIP,
+		},
+	}
+}
+
+// Handler returns a global middleware applying the first rule that matches the
+// request path. Requests matching no rule pass through untouched.
+func (m *RateLimitMiddleware) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, rule := range m.rules {
+			if rule.matches(c.Path()) {
+				return m.check(c, rule)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// Limit returns a middleware applying rule to every request of the route it is
+// mounted on. Mounted after Authenticate, RateLimitByUserOrIP counts per user.
+func (m *RateLimitMiddleware) Limit(rule RateLimitRule) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return m.check(c, rule)
+	}
+}
+
+func (m *RateLimitMiddleware) check(c *fiber.Ctx, rule RateLimitRule) error {
+	if rule.Limit <= 0 || rule.Window <= 0 {
+		return c.Next()
+	}
+
+	key := "ratelimit:" + rule.Name + ":" + rateLimitSubject(c, rule.KeyBy)
+
+	result, err := m.limiter.Allow(c.Context(), key, rule.Limit, rule.Window)
+	if err != nil {
+		if m.failOpen {
+			logx.WithFields(logx.Fields{
+				"rule": rule.Name,
+				"path": c.Path(),
+			}).Warnf("rate limiter unavailable, allowing request: %v", err)
+			return c.Next()
+		}
+		return ErrRateLimiterUnavailable(err)
+	}
+
+	resetSeconds := int((result.ResetAfter + time.Second - 1) / time.Second)
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	c.Set(HeaderRateLimitReset, strconv.Itoa(resetSeconds))
+
+	if !result.Allowed {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(resetSeconds))
+		return ErrRateLimited(result.ResetAfter).
+			WithDetail("rule", rule.Name)
+	}
+
+	return c.Next()
+}
+
+// rateLimitSubject identifies who the request is counted against. Behind a
+// load balancer c.IP() is the client only when Fiber is configured with
+// SERVER_PROXY_HEADER and SERVER_TRUSTED_PROXIES; otherwise every client
+// shares the balancer's bucket.
+func rateLimitSubject(c *fiber.Ctx, keyBy RateLimitKeyBy) string {
+	if keyBy == RateLimitByUserOrIP {
+		if keyID, ok := c.Locals("api_key_id").(string); ok && keyID != "" {
+			return "key:" + keyID
+		}
+		if authContext, ok := GetAuthContext(c); ok && authContext.UserID != nil {
+			return "user:" + authContext.UserID.String()
+		}
+	}
+	return "ip:" + c.IP()
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+// memoryRateLimiter counts requests per key without expiring them
+type memoryRateLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int
+	keys   []string
+	err    error
+}
+
+func (l *memoryRateLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keys = append(l.keys, key)
+	if l.err != nil {
+		return RateLimitResult{}, l.err
+	}
+	if l.counts[key] >= limit {
+		return RateLimitResult{Limit: limit, ResetAfter: window}, nil
+	}
+	l.counts[key]++
+	return RateLimitResult{Allowed: true, Limit: limit, Remaining: limit - l.counts[key], ResetAfter: window}, nil
+}
+
+func (l *memoryRateLimiter) lastKey() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.keys) == 0 {
+		return ""
+	}
+	return l.keys[len(l.keys)-1]
+}
+
+func newRateLimitApp(cfg fiber.Config, middleware *RateLimitMiddleware) *fiber.App {
+	cfg.ErrorHandler = func(c *fiber.Ctx, err error) error {
+		var e *errx.Error
+		if errx.As(err, &e) {
+			return c.Status(e.HTTPStatus).SendString(e.Code)
+		}
+		return fiber.DefaultErrorHandler(c, err)
+	}
+	app := fiber.New(cfg)
+	app.Use(middleware.Handler())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	app.Post("/api/v1/auth/login", ok)
+	app.Post("/api/v1/auth/login/other", ok)
+	app.Get("/api/v1/things", ok)
+	return app
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := &memoryRateLimiter{counts: map[string]int{}}
+	middleware := NewRateLimitMiddleware(limiter, false,
+		RateLimitRule{Name: "login", Paths: []string{"/auth/login"}, Limit: 2, Window: 90 * time.Second, KeyBy: RateLimitByIP},
+		RateLimitRule{Name: "any", Limit: 100, Window: time.Minute, KeyBy: RateLimitByIP},
+	)
+	app := newRateLimitApp(fiber.Config{}, middleware)
+
+	send := func(method, path string) (int, string, string) {
+		resp, err := app.Test(httptest.NewRequest(method, path, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.StatusCode, resp.Header.Get(HeaderRateLimitRemaining), resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		status, remaining, _ := send(fiber.MethodPost, "/api/v1/auth/login/")
+		if status != fiber.StatusNoContent || remaining != wantRemaining {
+			t.Fatalf("login %d = %d remaining=%q, want 204 remaining=%s", i+1, status, remaining, wantRemaining)
+		}
+	}
+	status, remaining, retryAfter := send(fiber.MethodPost, "/api/v1/auth/login")
+	if status != fiber.StatusTooManyRequests || remaining != "0" || retryAfter != "90" {
+		t.Fatalf("third login = %d remaining=%q Retry-After=%q, want 429 with Retry-After 90", status, remaining, retryAfter)
+	}
+	if key := limiter.lastKey(); key != "ratelimit:login:ip:0.0.0.0" {
+		t.Errorf("login key = %q", key)
+	}
+
+	// Rules match on the route suffix, so /auth/login/other falls through to
+	// the catch-all rule with its own counter
+	if status, _, _ := send(fiber.MethodPost, "/api/v1/auth/login/other"); status != fiber.StatusNoContent {
+		t.Fatalf("other route = %d, want 204", status)
+	}
+	if key := limiter.lastKey(); key != "ratelimit:any:ip:0.0.0.0" {
+		t.Errorf("other route key = %q, want the catch-all rule", key)
+	}
+}
+
+func TestRateLimitMiddlewareLimiterDown(t *testing.T) {
+	down := errors.New("connection refused")
+
+	failClosed := newRateLimitApp(fiber.Config{}, NewRateLimitMiddleware(&memoryRateLimiter{err: down}, false,
+		RateLimitRule{Name: "any", Limit: 1, Window: time.Minute}))
+	resp, err := failClosed.Test(httptest.NewRequest(fiber.MethodGet, "/api/v1/things", nil))
+	if err != nil || resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("fail closed = %v, %v; want 503", resp.StatusCode, err)
+	}
+
+	failOpen := newRateLimitApp(fiber.Config{}, NewRateLimitMiddleware(&memoryRateLimiter{err: down}, true,
+		RateLimitRule{Name: "any", Limit: 1, Window: time.Minute}))
+	resp, err = failOpen.Test(httptest.NewRequest(fiber.MethodGet, "/api/v1/things", nil))
+	if err != nil || resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("fail open = %v, %v; want 204", resp.StatusCode, err)
+	}
+}
+
+func TestRateLimitSubject(t *testing.T) {
+	userID := kernel.UserID("user-1")
+	tests := []struct {
+		name    string
+		cfg     fiber.Config
+		keyBy   RateLimitKeyBy
+		locals  func(c *fiber.Ctx)
+		header  string
+		wantKey string
+	}{
+		{
+			name:    "ip",
+			keyBy:   RateLimitByIP,
+			locals:  func(c *fiber.Ctx) { c.Locals("auth", &kernel.AuthContext{UserID: &userID, TenantID: "tenant-1"}) },
+			wantKey: "ratelimit:r:ip:0.0.0.0",
+		},
+		{
+			name:    "user",
+			keyBy:   RateLimitByUserOrIP,
+			locals:  func(c *fiber.Ctx) { c.Locals("auth", &kernel.AuthContext{UserID: &userID, TenantID: "tenant-1"}) },
+			wantKey: "ratelimit:r:user:user-1",
+		},
+		{
+			name:    "api key",
+			keyBy:   RateLimitByUserOrIP,
+			locals:  func(c *fiber.Ctx) { c.Locals("api_key_id", "key-1") },
+			wantKey: "ratelimit:r:key:key-1",
+		},
+		{
+			name:    "anonymous falls back to ip",
+			keyBy:   RateLimitByUserOrIP,
+			wantKey: "ratelimit:r:ip:0.0.0.0",
+		},
+		{
+			name:    "proxy header without proxy config is ignored",
+			keyBy:   RateLimitByIP,
+			header:  "203.0.113.7",
+			wantKey: "ratelimit:r:ip:0.0.0.0",
+		},
+		{
+			name: "proxy header from a trusted proxy",
+			cfg: fiber.Config{
+				ProxyHeader:             fiber.HeaderXForwardedFor,
+				EnableTrustedProxyCheck: true,
+				TrustedProxies:          []string{"0.0.0.0"},
+				EnableIPValidation:      true,
+			},
+			keyBy:   RateLimitByIP,
+			header:  "203.0.113.7, 10.0.0.1",
+			wantKey: "ratelimit:r:ip:203.0.113.7",
+		},
+		{
+			name: "proxy header from an untrusted client",
+			cfg: fiber.Config{
+				ProxyHeader:             fiber.HeaderXForwardedFor,
+				EnableTrustedProxyCheck: true,
+				TrustedProxies:          []string{"10.0.0.0/8"},
+				EnableIPValidation:      true,
+			},
+			keyBy:   RateLimitByIP,
+			header:  "203.0.113.7",
+			wantKey: "ratelimit:r:ip:0.0.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &memoryRateLimiter{counts: map[string]int{}}
+			middleware := NewRateLimitMiddleware(limiter, false)
+			app := fiber.New(tt.cfg)
+			app.Get("/", func(c *fiber.Ctx) error {
+				if tt.locals != nil {
+					tt.locals(c)
+				}
+				return c.Next()
+			}, middleware.Limit(RateLimitRule{Name: "r", Limit: 10, Window: time.Minute, KeyBy: tt.keyBy}), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderXForwardedFor, tt.header)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if key := limiter.lastKey(); key != tt.wantKey {
+				t.Errorf("key = %q, want %q", key, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionHandlers exposes the login sessions of the authenticated user
+type SessionHandlers struct {
+	service *SessionService
+}
+
+// NewSessionHandlers creates the session handlers
+func NewSessionHandlers(service *SessionService) *SessionHandlers {
+	return &SessionHandlers{service: service}
+}
+
+// RegisterRoutes registers GET /auth/sessions and DELETE /auth/sessions/:id.
+// Both require a user access token; API keys have no sessions.
+func (h *SessionHandlers) RegisterRoutes(router fiber.Router, authMiddleware *UnifiedAuthMiddleware) {
+	sessions := router.Group("/auth/sessions", authMiddleware.Authenticate())
+
+	sessions.Get("/", h.ListSessions)
+	sessions.Delete("/:id", h.RevokeSession)
+}
+
+// ListSessions lista las sesiones activas del usuario, marcando la actual
+func (h *SessionHandlers) ListSessions(c *fiber.Ctx) error {
+	authContext, ok := GetAuthContext(c)
+	if !ok || authContext.IsAPIKey || authContext.UserID == nil {
+		return iam.ErrUnauthorized()
+	}
+
+	sessions, err := h.service.ListSessions(c.Context(), *authContext.UserID, authContext.TenantID, authContext.SessionID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// RevokeSession cierra una sesión del usuario y revoca sus refresh tokens
+func (h *SessionHandlers) RevokeSession(c *fiber.Ctx) error {
+	authContext, ok := GetAuthContext(c)
+	if !ok || authContext.IsAPIKey || authContext.UserID == nil {
+		return iam.ErrUnauthorized()
+	}
+
+	sessionID := c.Params("id")
+	if err := h.service.RevokeSession(AuditContext(c), *authContext.UserID, authContext.TenantID, sessionID); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"message": "Session revoked successfully"})
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// activityWriteTimeout bounds the asynchronous last_activity write
+const activityWriteTimeout = 5 * time.Second
+
+// SessionService lets users see their login sessions and sign out a single
+// one, and keeps track of session activity
+type SessionService struct {
+	sessionRepo   SessionRepository
+	tokenRepo     TokenRepository
+	auditRecorder audit.Recorder
+
+	// activityThrottle debounces activity writes; nil writes on every request
+	activityThrottle ActivityThrottle
+	config           *config.SessionConfig
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(
+	sessionRepo SessionRepository,
+	tokenRepo TokenRepository,
+	auditRecorder audit.Recorder,
+	activityThrottle ActivityThrottle,
+	cfg *config.SessionConfig,
+) *SessionService {
+	return &SessionService{
+		sessionRepo:      sessionRepo,
+		tokenRepo:        tokenRepo,
+		auditRecorder:    auditRecorder,
+		activityThrottle: activityThrottle,
+		config:           cfg,
+	}
+}
+
+// RecordActivity persists activity on a session in the background so it adds
+// no latency to the request. With SlidingExpiration the session's expiry moves
+// forward as well. Failures are logged and never affect the caller.
+func (s *SessionService) RecordActivity(sessionID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), activityWriteTimeout)
+		defer cancel()
+
+		if s.activityThrottle != nil {
+			allowed, err := s.activityThrottle.Allow(ctx, sessionID)
+			if err != nil {
+				logx.WithFields(logx.Fields{"session_id": sessionID}).
+					Warnf("session activity throttle unavailable, skipping write: %v", err)
+				return
+			}
+			if !allowed {
+				return
+			}
+		}
+
+		var slideBy time.Duration
+		if s.config.SlidingExpiration {
+			slideBy = s.config.ExpirationTime
+		}
+
+		if err := s.sessionRepo.Touch(ctx, sessionID, slideBy, s.config.AbsoluteTimeout); err != nil {
+			logx.WithFields(logx.Fields{"session_id": sessionID}).
+				Errorf("failed to record session activity: %v", err)
+		}
+	}()
+}
+
+// ListSessions returns the user's active sessions, most recently active first.
+// The session matching currentSessionID is flagged as current.
+func (s *SessionService) ListSessions(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, currentSessionID string) ([]SessionDTO, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]SessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		if session.TenantID != tenantID {
+			continue
+		}
+		dtos = append(dtos, session.ToDTO(currentSessionID))
+	}
+
+	return dtos, nil
+}
+
+// RevokeSession signs out one of the user's sessions: the session is deleted
+// and the refresh tokens issued for it are revoked, so the device cannot renew
+// its access token. Sessions of other users are reported as not found.
+func (s *SessionService) RevokeSession(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, sessionID string) error {
+	session, err := s.sessionRepo.FindSession(ctx, sessionID)
+	if err != nil {
+		if isNotFound(err) {
+			return ErrSessionNotFound().WithDetail("session_id", sessionID)
+		}
+		return err
+	}
+
+	if session.UserID != userID || session.TenantID != tenantID {
+		return ErrSessionNotFound().WithDetail("session_id", sessionID)
+	}
+
+	if err := s.tokenRepo.RevokeSessionTokens(ctx, sessionID); err != nil {
+		return err
+	}
+
+	if err := s.sessionRepo.RevokeSession(ctx, sessionID); err != nil {
+		if isNotFound(err) {
+			return ErrSessionNotFound().WithDetail("session_id", sessionID)
+		}
+		return err
+	}
+
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionSessionRevoked, audit.ResourceSession, sessionID).
+		WithMetadata("ip_address", session.IPAddress).
+		WithMetadata("user_agent", session.UserAgent))
+
+	logx.WithFields(logx.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Info("Session revoked")
+
+	return nil
+}
+
+// enforceSessionLimit makes room for a new session of userID according to
+// cfg.MaxSessions. With the "reject" policy a user at the limit gets
+// ErrTooManySessions; otherwise the oldest sessions are signed out until the
+// new one fits. MaxSessions <= 0 means unlimited.
+func enforceSessionLimit(ctx context.Context, sessionRepo SessionRepository, tokenRepo TokenRepository, cfg config.SessionConfig, userID kernel.UserID) error {
+	if cfg.MaxSessions <= 0 {
+		return nil
+	}
+
+	count, err := sessionRepo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if count < cfg.MaxSessions {
+		return nil
+	}
+
+	if cfg.MaxSessionsPolicy == "reject" {
+		return ErrTooManySessions().
+			WithDetail("max_sessions", cfg.MaxSessions)
+	}
+
+	for ; count >= cfg.MaxSessions; count-- {
+		oldest, err := sessionRepo.FindOldestActiveByUser(ctx, userID)
+		if err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		if err := tokenRepo.RevokeSessionTokens(ctx, oldest.ID); err != nil {
+			return err
+		}
+		if err := sessionRepo.RevokeSession(ctx, oldest.ID); err != nil && !isNotFound(err) {
+			return err
+		}
+
+		logx.WithFields(logx.Fields{
+			"user_id":    userID,
+			"session_id": oldest.ID,
+		}).Info("Oldest session revoked, session limit reached")
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Type == errx.TypeNotFound
+}
@@ -9,24 +9,44 @@ import (
 	"time"
 )
 
-// InMemoryStateManager implementación en memoria del StateManager
+// Límites del intervalo del janitor: nunca más seguido que cada 100ms ni
+// más espaciado que cada 5 minutos, sea cual sea el TTL
+const (
+	minStateCleanupInterval = 100 * time.Millisecond
+	maxStateCleanupInterval = 5 * time.Minute
+)
+
+// InMemoryStateManager implementación en memoria del StateManager.
+// Una goroutine janitor elimina los estados expirados cada TTL (acotado a
+// [100ms, 5m]) hasta que se llama a Close. Los estados consumidos se
+// conservan sin datos hasta su expiración para detectar callbacks repetidos.
 type InMemoryStateManager struct {
 	states map[string]*stateEntry
 	mu     sync.RWMutex
-	ttl    time.Duration // Add this field
+	ttl    time.Duration
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 type stateEntry struct {
 	data      map[string]any
 	expiresAt time.Time
+	used      bool
 }
 
-// NewInMemoryStateManager crea un nuevo state manager en memoria
+// NewInMemoryStateManager crea un nuevo state manager en memoria y arranca
+// su janitor. Llamar a Close para detenerlo.
 func NewInMemoryStateManager(ttl time.Duration) *InMemoryStateManager {
-	return &InMemoryStateManager{
+	sm := &InMemoryStateManager{
 		states: make(map[string]*stateEntry),
 		ttl:    ttl,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
 	}
+	go sm.janitor(min(max(ttl, minStateCleanupInterval), maxStateCleanupInterval))
+	return sm
 }
 
 // GenerateState genera un nuevo estado OAuth
@@ -39,14 +59,14 @@ func (sm *InMemoryStateManager) GenerateState() string {
 	return hex.EncodeToString(bytes)
 }
 
-// StoreState almacena un estado con sus datos asociados
+// StoreState almacena un estado con sus datos asociados, válido durante el TTL
 func (sm *InMemoryStateManager) StoreState(ctx context.Context, state string, data map[string]any) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
 	sm.states[state] = &stateEntry{
 		data:      data,
-		expiresAt: time.Now().Add(sm.ttl), // Estados válidos por 10 minutos
+		expiresAt: time.Now().Add(sm.ttl),
 	}
 
 	return nil
@@ -58,14 +78,16 @@ func (sm *InMemoryStateManager) ValidateState(state string) bool {
 	defer sm.mu.RUnlock()
 
 	entry, exists := sm.states[state]
-	if !exists {
+	if !exists || entry.used {
 		return false
 	}
 
 	return time.Now().Before(entry.expiresAt)
 }
 
-// GetStateData obtiene los datos asociados a un estado
+// GetStateData consume un estado y devuelve sus datos. Devuelve
+// ErrStateAlreadyUsed si ya fue consumido y ErrInvalidState si no existe o
+// ya expiró.
 func (sm *InMemoryStateManager) GetStateData(ctx context.Context, state string) (map[string]any, error) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -80,26 +102,54 @@ func (sm *InMemoryStateManager) GetStateData(ctx context.Context, state string)
 		return nil, ErrInvalidState()
 	}
 
-	// Eliminar el estado después de usarlo (one-time use)
+	if entry.used {
+		return nil, ErrStateAlreadyUsed()
+	}
+
+	// Marcar el estado como usado (one-time use); el janitor lo elimina al
+	// expirar
 	data := entry.data
-	delete(sm.states, state)
+	entry.data = nil
+	entry.used = true
 
 	return data, nil
 }
 
-// cleanup limpia estados expirados periodicamente
-func (sm *InMemoryStateManager) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute)
+// Close detiene el janitor y espera a que termine. Es seguro llamarlo más
+// de una vez; el state manager sigue funcionando, pero sin limpieza
+// periódica.
+func (sm *InMemoryStateManager) Close() error {
+	sm.closeOnce.Do(func() { close(sm.stop) })
+	<-sm.done
+	return nil
+}
+
+// janitor elimina los estados expirados cada interval hasta Close
+func (sm *InMemoryStateManager) janitor(interval time.Duration) {
+	defer close(sm.done)
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		sm.mu.Lock()
-		now := time.Now()
-		for state, entry := range sm.states {
-			if now.After(entry.expiresAt) {
-				delete(sm.states, state)
-			}
+	for {
+		select {
+		case <-sm.stop:
+			return
+		case <-ticker.C:
+			sm.removeExpired()
+		}
+	}
+}
+
+// removeExpired elimina los estados expirados
+func (sm *InMemoryStateManager) removeExpired() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	for state, entry := range sm.states {
+		if now.After(entry.expiresAt) {
+			delete(sm.states, state)
 		}
-		sm.mu.Unlock()
 	}
 }
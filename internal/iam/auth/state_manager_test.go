@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInMemoryStateManagerEvictsExpiredStates(t *testing.T) {
+	ctx := context.Background()
+	sm := NewInMemoryStateManager(50 * time.Millisecond)
+	defer sm.Close()
+
+	var wg sync.WaitGroup
+	for i := range 1000 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sm.StoreState(ctx, fmt.Sprintf("state-%d", i), map[string]any{"i": i}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if data, err := sm.GetStateData(ctx, "state-0"); err != nil || data["i"] != 0 {
+		t.Fatalf("GetStateData before TTL = %v, %v", data, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sm.mu.RLock()
+		remaining := len(sm.states)
+		sm.mu.RUnlock()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d expired states still stored", remaining)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	_, err := sm.GetStateData(ctx, "state-1")
+	if !errors.Is(err, CodeInvalidState) {
+		t.Errorf("GetStateData after TTL error = %v, want %s", err, CodeInvalidState.Code)
+	}
+}
+
+func TestInMemoryStateManagerCloseStopsJanitor(t *testing.T) {
+	sm := NewInMemoryStateManager(time.Minute)
+	if err := sm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Close is idempotent
+	if err := sm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-sm.done:
+	default:
+		t.Error("janitor still running after Close")
+	}
+}
+
+func TestInMemoryStateManagerStateIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	sm := NewInMemoryStateManager(time.Minute)
+	defer sm.Close()
+
+	if err := sm.StoreState(ctx, "state", map[string]any{"provider": "GOOGLE"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent callbacks with the same state: exactly one redeems it
+	var redeemed, replayed atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sm.GetStateData(ctx, "state")
+			switch {
+			case err == nil:
+				redeemed.Add(1)
+			case errors.Is(err, CodeStateAlreadyUsed):
+				replayed.Add(1)
+			default:
+				t.Errorf("GetStateData error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if redeemed.Load() != 1 || replayed.Load() != 19 {
+		t.Errorf("redeemed %d, replayed %d; want 1 and 19", redeemed.Load(), replayed.Load())
+	}
+	if sm.ValidateState("state") {
+		t.Error("used state still validates")
+	}
+	if _, err := sm.GetStateData(ctx, "unknown"); !errors.Is(err, CodeInvalidState) {
+		t.Errorf("unknown state error = %v, want %s", err, CodeInvalidState.Code)
+	}
+}
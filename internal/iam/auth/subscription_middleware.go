@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TenantLoader loads the tenant of a request (tenant.TenantRepository)
+type TenantLoader interface {
+	FindByID(ctx context.Context, id kernel.TenantID) (*tenant.Tenant, error)
+}
+
+// SubscriptionMiddleware rejects the requests of tenants that cannot operate:
+// a lapsed trial (402 TENANT.TRIAL_EXPIRED), a lapsed subscription (402
+// TENANT.SUBSCRIPTION_EXPIRED) or a suspended / canceled tenant (403
+// TENANT.SUSPENDED). Expiry is checked against the dates, so a tenant is
+// blocked as soon as it lapses, before the cleanup job marks it EXPIRED.
+//
+// Tenants are cached per instance for cacheTTL, so status changes such as a
+// renewal take up to cacheTTL to apply.
+type SubscriptionMiddleware struct {
+	tenants      TenantLoader
+	cacheTTL     time.Duration
+	allowedPaths []string
+
+	mu    sync.Mutex
+	cache map[kernel.TenantID]cachedTenant
+}
+
+type cachedTenant struct {
+	tenant    *tenant.Tenant
+	expiresAt time.Time
+}
+
+// NewSubscriptionMiddleware creates the middleware. allowedPaths are route
+// prefixes that stay reachable for lapsed tenants, matched from the start of
+// the path on whole segments: "/api/v1/billing" allows /api/v1/billing and
+// everything below it, but not /api/v1/billing-export or
+// /api/v1/users/billing. cacheTTL 0 disables the cache.
+func NewSubscriptionMiddleware(tenants TenantLoader, cacheTTL time.Duration, allowedPaths ...string) *SubscriptionMiddleware {
+	paths := make([]string, 0, len(allowedPaths))
+	for _, p := range allowedPaths {
+		if p = strings.Trim(strings.TrimSpace(p), "/"); p != "" {
+			paths = append(paths, "/"+p)
+		}
+	}
+
+	return &SubscriptionMiddleware{
+		tenants:      tenants,
+		cacheTTL:     cacheTTL,
+		allowedPaths: paths,
+		cache:        make(map[kernel.TenantID]cachedTenant),
+	}
+}
+
+// Handler returns the middleware. Mount it after Authenticate.
+func (m *SubscriptionMiddleware) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authContext, ok := GetAuthContext(c)
+		if !ok {
+			return iam.ErrUnauthorized()
+		}
+		if m.allowed(c.Path()) {
+			return c.Next()
+		}
+
+		tenantEntity, err := m.load(c.Context(), authContext.TenantID)
+		if err != nil {
+			return err
+		}
+		if err := tenantEntity.CheckAccess(); err != nil {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// Invalidate drops the cached tenant, e.g. right after a renewal
+func (m *SubscriptionMiddleware) Invalidate(tenantID kernel.TenantID) {
+	m.mu.Lock()
+	delete(m.cache, tenantID)
+	m.mu.Unlock()
+}
+
+// allowed reports whether requestPath is one of the allowed prefixes or below
+// one. The path is cleaned first, so "/billing/../users" is /users.
+func (m *SubscriptionMiddleware) allowed(requestPath string) bool {
+	requestPath = path.Clean("/" + requestPath)
+	for _, prefix := range m.allowedPaths {
+		if requestPath == prefix || strings.HasPrefix(requestPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SubscriptionMiddleware) load(ctx context.Context, tenantID kernel.TenantID) (*tenant.Tenant, error) {
+	if m.cacheTTL <= 0 {
+		return m.tenants.FindByID(ctx, tenantID)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	cached, ok := m.cache[tenantID]
+	m.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.tenant, nil
+	}
+
+	tenantEntity, err := m.tenants.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	// Drop expired entries so tenants that stopped calling do not pile up
+	for id, entry := range m.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(m.cache, id)
+		}
+	}
+	m.cache[tenantID] = cachedTenant{tenant: tenantEntity, expiresAt: now.Add(m.cacheTTL)}
+	m.mu.Unlock()
+
+	return tenantEntity, nil
+}
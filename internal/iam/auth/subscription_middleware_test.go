@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+// countingTenants serves tenants by ID and counts the lookups
+type countingTenants struct {
+	tenants map[kernel.TenantID]*tenant.Tenant
+	lookups int
+}
+
+func (r *countingTenants) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	r.lookups++
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, tenant.ErrTenantNotFound()
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func TestSubscriptionMiddleware(t *testing.T) {
+	tenants := &countingTenants{tenants: map[kernel.TenantID]*tenant.Tenant{
+		"active":    {ID: "active", Status: tenant.TenantStatusActive},
+		"suspended": {ID: "suspended", Status: tenant.TenantStatusSuspended},
+	}}
+	subscription := NewSubscriptionMiddleware(tenants, time.Minute, "/api/v1/billing/", " /auth/me")
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var e *errx.Error
+			if errx.As(err, &e) {
+				return c.Status(e.HTTPStatus).SendString(e.Code)
+			}
+			return fiber.DefaultErrorHandler(c, err)
+		},
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("auth", &kernel.AuthContext{TenantID: kernel.TenantID(c.Get("X-Tenant")), IsAPIKey: true})
+		return c.Next()
+	}, subscription.Handler())
+	app.Use(func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	get := func(tenantID, path string) int {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		req.Header.Set("X-Tenant", tenantID)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name     string
+		tenantID string
+		path     string
+		want     int
+	}{
+		{"active tenant", "active", "/api/v1/users", fiber.StatusNoContent},
+		{"suspended tenant", "suspended", "/api/v1/users", fiber.StatusForbidden},
+		{"allowed prefix", "suspended", "/api/v1/billing", fiber.StatusNoContent},
+		{"below allowed prefix", "suspended", "/api/v1/billing/invoices/", fiber.StatusNoContent},
+		{"allowed exact route", "suspended", "/auth/me", fiber.StatusNoContent},
+		{"prefix in the middle", "suspended", "/api/v1/users/api/v1/billing", fiber.StatusForbidden},
+		{"prefix of a segment", "suspended", "/api/v1/billing-export", fiber.StatusForbidden},
+		{"dot segments", "suspended", "/api/v1/billing/../users", fiber.StatusForbidden},
+		{"unknown tenant", "gone", "/api/v1/users", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := get(tt.tenantID, tt.path); status != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, status, tt.want)
+			}
+		})
+	}
+
+	// Tenants are cached until invalidated
+	tenants.tenants["active"].Status = tenant.TenantStatusSuspended
+	before := tenants.lookups
+	if status := get("active", "/api/v1/users"); status != fiber.StatusNoContent || tenants.lookups != before {
+		t.Errorf("cached tenant = %d after %d lookups, want 204 from the cache", status, tenants.lookups-before)
+	}
+	subscription.Invalidate("active")
+	if status := get("active", "/api/v1/users"); status != fiber.StatusForbidden {
+		t.Errorf("after Invalidate = %d, want 403", status)
+	}
+}
@@ -1,28 +1,37 @@
 package auth
 
 import (
+	"context"
 	"strings"
 
 	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey/apikeysrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
 	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/Abraxas-365/manifesto/internal/logx/logxfiber"
 	"github.com/gofiber/fiber/v2"
 )
 
 type UnifiedAuthMiddleware struct {
-	apiKeyService *apikeysrv.APIKeyService
-	tokenService  TokenService
+	apiKeyService  *apikeysrv.APIKeyService
+	tokenService   TokenService
+	sessionService *SessionService
 }
 
+// NewAPIKeyMiddleware creates the middleware. sessionService may be nil, in
+// which case session activity is not tracked.
 func NewAPIKeyMiddleware(
 	apiKeyService *apikeysrv.APIKeyService,
 	tokenService TokenService,
+	sessionService *SessionService,
 ) *UnifiedAuthMiddleware {
 	return &UnifiedAuthMiddleware{
-		apiKeyService: apiKeyService,
-		tokenService:  tokenService,
+		apiKeyService:  apiKeyService,
+		tokenService:   tokenService,
+		sessionService: sessionService,
 	}
 }
 
@@ -45,6 +54,8 @@ func (am *UnifiedAuthMiddleware) authenticateAPIKey(c *fiber.Ctx, keyString stri
 		})
 	}
 
+	am.apiKeyService.RecordUsage(key.ID)
+
 	authContext := &kernel.AuthContext{
 		UserID:   key.UserID,
 		TenantID: key.TenantID,
@@ -52,7 +63,7 @@ func (am *UnifiedAuthMiddleware) authenticateAPIKey(c *fiber.Ctx, keyString stri
 		IsAPIKey: true,
 	}
 
-	c.Locals("auth", authContext)
+	setAuthContext(c, authContext)
 	c.Locals("api_key_id", key.ID)
 
 	return c.Next()
@@ -87,15 +98,20 @@ func (am *UnifiedAuthMiddleware) authenticateJWT(c *fiber.Ctx) error {
 	}
 
 	authContext := &kernel.AuthContext{
-		UserID:   &claims.UserID,
-		TenantID: claims.TenantID,
-		Email:    claims.Email,
-		Name:     claims.Name,
-		Scopes:   claims.Scopes,
-		IsAPIKey: false,
+		UserID:    &claims.UserID,
+		TenantID:  claims.TenantID,
+		Email:     claims.Email,
+		Name:      claims.Name,
+		Scopes:    claims.Scopes,
+		IsAPIKey:  false,
+		SessionID: claims.SessionID,
 	}
 
-	c.Locals("auth", authContext)
+	if am.sessionService != nil && claims.SessionID != "" {
+		am.sessionService.RecordActivity(claims.SessionID)
+	}
+
+	setAuthContext(c, authContext)
 	return c.Next()
 }
 
@@ -202,3 +218,33 @@ func GetAuthContext(c *fiber.Ctx) (*kernel.AuthContext, bool) {
 	authContext, ok := c.Locals("auth").(*kernel.AuthContext)
 	return authContext, ok && authContext != nil && authContext.IsValid()
 }
+
+// setAuthContext stores the caller in the Fiber locals and in c.UserContext()
+// (kernel.AuthContextFrom), and adds its tenant and user to the request log
+// fields
+func setAuthContext(c *fiber.Ctx, authContext *kernel.AuthContext) {
+	c.Locals("auth", authContext)
+	c.SetUserContext(kernel.WithAuthContext(c.UserContext(), authContext))
+
+	fields := logx.Fields{"tenant_id": authContext.TenantID.String()}
+	if authContext.UserID != nil {
+		fields["user_id"] = authContext.UserID.String()
+	}
+	logxfiber.AddFields(c, fields)
+}
+
+// AuditContext returns the request context carrying the caller as audit actor,
+// so services record who performed the operation
+func AuditContext(c *fiber.Ctx) context.Context {
+	actor := audit.Actor{
+		IP:        c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}
+	if authContext, ok := GetAuthContext(c); ok {
+		actor.UserID = authContext.UserID
+	}
+	if keyID, ok := c.Locals("api_key_id").(string); ok && keyID != "" {
+		actor.APIKeyID = &keyID
+	}
+	return audit.WithActor(c.Context(), actor)
+}
@@ -0,0 +1,65 @@
+package dataexport
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+)
+
+// ============================================================================
+// Export documents
+// ============================================================================
+
+// WriteFunc escribe un documento de exportación en w. Las exportaciones se
+// validan antes de empezar a responder y se escriben después, de modo que un
+// tenant o usuario inexistente se reporta con su código HTTP y no como un
+// documento vacío.
+//
+// El documento de un tenant tiene la forma
+//
+//	{ "exported_at", "tenant", "users": [UserRecord], "invitations", "api_keys", "completed": true }
+//
+// y el de un usuario (solicitud de acceso del titular)
+//
+//	{ "exported_at", "tenant_id", "email", "user", "sessions", "api_keys",
+//	  "invitations", "audit_events", "completed": true }
+//
+// Los documentos se escriben sección por sección; "completed" va al final para
+// que quien los consuma distinga un documento completo de uno cortado.
+type WriteFunc func(ctx context.Context, w io.Writer) error
+
+// UserRecord es un usuario del tenant con sus sesiones activas. Nunca incluye
+// secretos: ni hash de contraseña, ni tokens, ni hashes de API keys.
+type UserRecord struct {
+	User     user.UserDetailsDTO `json:"user"`
+	Sessions []auth.SessionDTO   `json:"sessions"`
+}
+
+// UserExportRequest identifica al titular de una solicitud de acceso. El email
+// va en el cuerpo y no en la URL para que no quede en los logs de acceso.
+type UserExportRequest struct {
+	Email string `json:"email"`
+}
+
+// ============================================================================
+// Error Registry
+// ============================================================================
+
+var ErrRegistry = errx.NewRegistry("DATA_EXPORT")
+
+var (
+	CodeEmailRequired   = ErrRegistry.Register("EMAIL_REQUIRED", errx.TypeValidation, http.StatusBadRequest, "Email is required")
+	CodeSubjectNotFound = ErrRegistry.Register("SUBJECT_NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "No data found for this email")
+)
+
+func ErrEmailRequired() *errx.Error {
+	return ErrRegistry.New(CodeEmailRequired)
+}
+
+func ErrSubjectNotFound() *errx.Error {
+	return ErrRegistry.New(CodeSubjectNotFound)
+}
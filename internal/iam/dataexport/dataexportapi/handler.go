@@ -0,0 +1,105 @@
+package dataexportapi
+
+import (
+	"bufio"
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/dataexport"
+	"github.com/Abraxas-365/manifesto/internal/iam/dataexport/dataexportsrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DataExportHandlers struct {
+	service *dataexportsrv.DataExportService
+}
+
+func NewDataExportHandlers(service *dataexportsrv.DataExportService) *DataExportHandlers {
+	return &DataExportHandlers{service: service}
+}
+
+func (h *DataExportHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	tenants := router.Group("/tenants", authMiddleware.Authenticate())
+
+	tenants.Post("/:id/export", authMiddleware.RequireAdminOrScope(scopes.ScopeTenantsExport), h.ExportTenant)
+	tenants.Post("/:id/export/user", authMiddleware.RequireAdminOrScope(scopes.ScopeUsersExport), h.ExportUser)
+}
+
+// ExportTenant streams every record the tenant holds about its users as a
+// single JSON document: the tenant, users with their active sessions,
+// invitations and API key metadata. Callers can only export their own tenant.
+func (h *DataExportHandlers) ExportTenant(c *fiber.Ctx) error {
+	tenantID, err := tenantParam(c)
+	if err != nil {
+		return err
+	}
+
+	write, err := h.service.ExportTenant(auth.AuditContext(c), tenantID)
+	if err != nil {
+		return err
+	}
+
+	stream(c, "tenant-export.json", "tenant "+tenantID.String(), write)
+	return nil
+}
+
+// ExportUser streams the data held about one person of the tenant, identified
+// by the email in the body, for GDPR subject-access requests.
+func (h *DataExportHandlers) ExportUser(c *fiber.Ctx) error {
+	tenantID, err := tenantParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req dataexport.UserExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	write, err := h.service.ExportUser(auth.AuditContext(c), tenantID, req.Email)
+	if err != nil {
+		return err
+	}
+
+	stream(c, "user-export.json", "user of tenant "+tenantID.String(), write)
+	return nil
+}
+
+// tenantParam returns the :id tenant if it is the caller's own. Admin scopes
+// are granted per tenant, so being an admin never opens another tenant.
+func tenantParam(c *fiber.Ctx) (kernel.TenantID, error) {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return "", iam.ErrUnauthorized()
+	}
+
+	tenantID := kernel.NewTenantID(c.Params("id"))
+	if tenantID.IsEmpty() || authContext.TenantID != tenantID {
+		return "", iam.ErrAccessDenied()
+	}
+	return tenantID, nil
+}
+
+// stream sends the document as a JSON attachment, written as it is read so
+// large tenants are never buffered. Once streaming starts the status can no
+// longer change: a failure is logged and leaves the document without its
+// closing "completed": true.
+func stream(c *fiber.Ctx, filename, subject string, write dataexport.WriteFunc) {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The fiber context is released once the handler returns, so the
+		// export runs on its own context. A disconnected client shows up as a
+		// write error and stops the export.
+		if err := write(context.Background(), w); err != nil {
+			logx.Warnf("data export of %s stopped: %v", subject, err)
+			return
+		}
+		w.Flush()
+	})
+}
@@ -0,0 +1,99 @@
+package dataexportapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth/authtest"
+	"github.com/Abraxas-365/manifesto/internal/iam/dataexport/dataexportsrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+type tenantRepo struct{ tenant.TenantRepository }
+
+func (tenantRepo) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	return &tenant.Tenant{ID: id, CompanyName: "Acme"}, nil
+}
+
+type invitationRepo struct {
+	invitation.InvitationRepository
+}
+
+func (invitationRepo) FindByTenant(context.Context, kernel.TenantID) ([]*invitation.Invitation, error) {
+	return nil, nil
+}
+
+type apiKeyRepo struct{ apikey.APIKeyRepository }
+
+func (apiKeyRepo) FindByTenant(context.Context, kernel.TenantID) ([]*apikey.APIKey, error) {
+	return nil, nil
+}
+
+type recorder struct{}
+
+func (recorder) Record(context.Context, audit.AuditEvent) {}
+
+func TestExportTenantOnlyAllowsOwnTenant(t *testing.T) {
+	service := dataexportsrv.NewDataExportService(tenantRepo{}, userinfra.NewInMemoryUserRepository(),
+		invitationRepo{}, apiKeyRepo{}, authtest.NewInMemorySessionRepository(), nil, recorder{})
+	handlers := NewDataExportHandlers(service)
+
+	userID := kernel.UserID("u1")
+	caller := &kernel.AuthContext{UserID: &userID, TenantID: "t1", Scopes: []string{"*"}}
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var e *errx.Error
+			if errors.As(err, &e) {
+				return c.Status(e.HTTPStatus).SendString(e.Code)
+			}
+			return fiber.DefaultErrorHandler(c, err)
+		},
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		if caller != nil {
+			c.Locals("auth", caller)
+		}
+		return c.Next()
+	})
+	app.Post("/tenants/:id/export", handlers.ExportTenant)
+	app.Post("/tenants/:id/export/user", handlers.ExportUser)
+
+	send := func(path string) (int, string) {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(`{"email":"ana@acme.com"}`)))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := send("/tenants/t1/export")
+	var doc map[string]any
+	if status != fiber.StatusOK || json.Unmarshal([]byte(body), &doc) != nil || doc["completed"] != true {
+		t.Fatalf("own tenant export = %d %q", status, body)
+	}
+
+	// A global-wildcard admin of t1 is still only an admin of t1
+	for _, path := range []string{"/tenants/t2/export", "/tenants/t2/export/user"} {
+		if status, body := send(path); status != fiber.StatusForbidden {
+			t.Errorf("%s by admin of t1 = %d %q, want 403", path, status, body)
+		}
+	}
+
+	caller = nil
+	if status, _ := send("/tenants/t1/export"); status != fiber.StatusUnauthorized {
+		t.Errorf("unauthenticated export = %d, want 401", status)
+	}
+}
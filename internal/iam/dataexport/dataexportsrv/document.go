@@ -0,0 +1,82 @@
+package dataexportsrv
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// document escribe un objeto JSON campo por campo, y los arreglos elemento por
+// elemento, para no armar el documento completo en memoria. El primer error
+// de escritura se conserva y las escrituras siguientes se omiten.
+type document struct {
+	w      io.Writer
+	fields int
+	items  int
+	err    error
+}
+
+func newDocument(w io.Writer) *document {
+	return &document{w: w}
+}
+
+// field escribe un campo con su valor
+func (d *document) field(name string, value any) {
+	d.key(name)
+	d.value(value)
+}
+
+// beginArray abre un arreglo; sus elementos se escriben con item
+func (d *document) beginArray(name string) {
+	d.key(name)
+	d.raw("[")
+	d.items = 0
+}
+
+func (d *document) item(value any) {
+	if d.items > 0 {
+		d.raw(",")
+	}
+	d.items++
+	d.value(value)
+}
+
+func (d *document) endArray() {
+	d.raw("]")
+}
+
+// close marca el documento como completo, lo cierra y retorna el primer error
+func (d *document) close() error {
+	d.field("completed", true)
+	d.raw("}\n")
+	return d.err
+}
+
+func (d *document) key(name string) {
+	if d.fields == 0 {
+		d.raw("{")
+	} else {
+		d.raw(",")
+	}
+	d.fields++
+	d.value(name)
+	d.raw(":")
+}
+
+func (d *document) value(value any) {
+	if d.err != nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		d.err = err
+		return
+	}
+	_, d.err = d.w.Write(data)
+}
+
+func (d *document) raw(s string) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = io.WriteString(d.w, s)
+}
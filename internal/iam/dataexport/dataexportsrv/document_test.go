@@ -0,0 +1,64 @@
+package dataexportsrv
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDocumentWritesValidJSON(t *testing.T) {
+	var b strings.Builder
+	doc := newDocument(&b)
+	doc.field("tenant", map[string]string{"id": "t1"})
+	doc.beginArray("users")
+	doc.item(map[string]string{"email": "a@acme.com"})
+	doc.item(map[string]string{"email": "b@acme.com"})
+	doc.endArray()
+	doc.beginArray("invitations")
+	doc.endArray()
+	if err := doc.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	var got struct {
+		Tenant      map[string]string   `json:"tenant"`
+		Users       []map[string]string `json:"users"`
+		Invitations []any               `json:"invitations"`
+		Completed   bool                `json:"completed"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", b.String(), err)
+	}
+	if got.Tenant["id"] != "t1" || len(got.Users) != 2 || got.Users[1]["email"] != "b@acme.com" {
+		t.Errorf("unexpected document: %+v", got)
+	}
+	if got.Invitations == nil || len(got.Invitations) != 0 {
+		t.Errorf("invitations = %v, want empty array", got.Invitations)
+	}
+	if !got.Completed {
+		t.Error("completed = false, want true")
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("connection closed")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestDocumentKeepsFirstWriteError(t *testing.T) {
+	doc := newDocument(&failingWriter{n: 3})
+	doc.field("tenant", "t1")
+	doc.beginArray("users")
+	doc.item("u1")
+	doc.endArray()
+
+	if err := doc.close(); err == nil || err.Error() != "connection closed" {
+		t.Fatalf("close error = %v, want connection closed", err)
+	}
+}
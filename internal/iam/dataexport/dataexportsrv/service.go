@@ -0,0 +1,271 @@
+package dataexportsrv
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/dataexport"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// Tamaños de página al recorrer usuarios y eventos de auditoría
+const (
+	userPageSize  = 100
+	auditPageSize = 200
+)
+
+// DataExportService arma las exportaciones de datos personales (GDPR): la de
+// un tenant completo y la de un titular identificado por su email. Los
+// documentos se escriben a medida que se leen, sin cargarlos en memoria.
+type DataExportService struct {
+	tenantRepo     tenant.TenantRepository
+	userRepo       user.UserRepository
+	invitationRepo invitation.InvitationRepository
+	apiKeyRepo     apikey.APIKeyRepository
+	sessionRepo    auth.SessionRepository
+	auditRepo      audit.AuditRepository
+	auditRecorder  audit.Recorder
+}
+
+func NewDataExportService(
+	tenantRepo tenant.TenantRepository,
+	userRepo user.UserRepository,
+	invitationRepo invitation.InvitationRepository,
+	apiKeyRepo apikey.APIKeyRepository,
+	sessionRepo auth.SessionRepository,
+	auditRepo audit.AuditRepository,
+	auditRecorder audit.Recorder,
+) *DataExportService {
+	return &DataExportService{
+		tenantRepo:     tenantRepo,
+		userRepo:       userRepo,
+		invitationRepo: invitationRepo,
+		apiKeyRepo:     apiKeyRepo,
+		sessionRepo:    sessionRepo,
+		auditRepo:      auditRepo,
+		auditRecorder:  auditRecorder,
+	}
+}
+
+// ExportTenant valida que el tenant exista, registra la exportación en la
+// auditoría y retorna la función que escribe el documento: el tenant, sus
+// usuarios con sus sesiones activas, sus invitaciones y la metadata de sus API
+// keys.
+func (s *DataExportService) ExportTenant(ctx context.Context, tenantID kernel.TenantID) (dataexport.WriteFunc, error) {
+	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, tenant.ErrTenantNotFound()
+	}
+
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionTenantExported, audit.ResourceTenant, tenantID.String()))
+
+	return func(ctx context.Context, w io.Writer) error {
+		doc := newDocument(w)
+		doc.field("exported_at", time.Now().UTC())
+		doc.field("tenant", tenantEntity.ToDTO())
+
+		doc.beginArray("users")
+		if err := s.eachUser(ctx, tenantID, func(u *user.User) error {
+			sessions, err := s.activeSessions(ctx, u.ID)
+			if err != nil {
+				return err
+			}
+			doc.item(dataexport.UserRecord{User: u.ToDTO(), Sessions: sessions})
+			return doc.err
+		}); err != nil {
+			return err
+		}
+		doc.endArray()
+
+		invitations, err := s.invitationRepo.FindByTenant(ctx, tenantID)
+		if err != nil {
+			return errx.Wrap(err, "failed to export invitations", errx.TypeInternal)
+		}
+		doc.beginArray("invitations")
+		for _, inv := range invitations {
+			doc.item(inv.ToDTO())
+		}
+		doc.endArray()
+
+		keys, err := s.apiKeyRepo.FindByTenant(ctx, tenantID)
+		if err != nil {
+			return errx.Wrap(err, "failed to export API keys", errx.TypeInternal)
+		}
+		doc.beginArray("api_keys")
+		for _, key := range keys {
+			doc.item(key.ToDTO())
+		}
+		doc.endArray()
+
+		return doc.close()
+	}, nil
+}
+
+// ExportUser arma la exportación de un titular (solicitud de acceso GDPR)
+// dentro del tenant: su usuario, sesiones activas, API keys, invitaciones
+// recibidas y los eventos de auditoría que originó. Un email que solo tiene
+// invitaciones también se exporta; sin usuario ni invitaciones retorna
+// ErrSubjectNotFound.
+func (s *DataExportService) ExportUser(ctx context.Context, tenantID kernel.TenantID, email string) (dataexport.WriteFunc, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, dataexport.ErrEmailRequired()
+	}
+
+	userEntity, err := s.userRepo.FindByEmail(ctx, email, tenantID)
+	if err != nil {
+		var e *errx.Error
+		if !errx.As(err, &e) || e.Code != user.CodeUserNotFound.Code {
+			return nil, errx.Wrap(err, "failed to find user", errx.TypeInternal)
+		}
+		userEntity = nil
+	}
+
+	invitations, err := s.invitationRepo.FindByEmail(ctx, email, tenantID)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to find invitations", errx.TypeInternal)
+	}
+
+	if userEntity == nil && len(invitations) == 0 {
+		return nil, dataexport.ErrSubjectNotFound()
+	}
+
+	resourceType, resourceID := audit.ResourceUser, ""
+	if userEntity != nil {
+		resourceID = userEntity.ID.String()
+	} else {
+		resourceType, resourceID = audit.ResourceInvitation, invitations[0].ID
+	}
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionUserExported, resourceType, resourceID))
+
+	return func(ctx context.Context, w io.Writer) error {
+		doc := newDocument(w)
+		doc.field("exported_at", time.Now().UTC())
+		doc.field("tenant_id", tenantID)
+		doc.field("email", email)
+
+		if userEntity == nil {
+			doc.field("user", nil)
+			doc.field("sessions", []auth.SessionDTO{})
+			doc.field("api_keys", []apikey.APIKeyDTO{})
+		} else {
+			doc.field("user", userEntity.ToDTO())
+
+			sessions, err := s.activeSessions(ctx, userEntity.ID)
+			if err != nil {
+				return err
+			}
+			doc.field("sessions", sessions)
+
+			keys, err := s.apiKeyRepo.FindByUser(ctx, userEntity.ID, tenantID)
+			if err != nil {
+				return errx.Wrap(err, "failed to export API keys", errx.TypeInternal)
+			}
+			keyDTOs := make([]apikey.APIKeyDTO, len(keys))
+			for i, key := range keys {
+				keyDTOs[i] = key.ToDTO()
+			}
+			doc.field("api_keys", keyDTOs)
+		}
+
+		doc.beginArray("invitations")
+		for _, inv := range invitations {
+			doc.item(inv.ToDTO())
+		}
+		doc.endArray()
+
+		doc.beginArray("audit_events")
+		if userEntity != nil {
+			if err := s.eachAuditEvent(ctx, tenantID, userEntity.ID, func(event *audit.AuditEvent) error {
+				doc.item(event)
+				return doc.err
+			}); err != nil {
+				return err
+			}
+		}
+		doc.endArray()
+
+		return doc.close()
+	}, nil
+}
+
+// eachUser recorre los usuarios del tenant de a userPageSize. Se detiene si fn
+// retorna error o el contexto se cancela.
+func (s *DataExportService) eachUser(ctx context.Context, tenantID kernel.TenantID, fn func(*user.User) error) error {
+	filter := user.UserSearchFilter{Limit: userPageSize}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		users, _, err := s.userRepo.Search(ctx, tenantID, filter)
+		if err != nil {
+			return errx.Wrap(err, "failed to export users", errx.TypeInternal)
+		}
+
+		for _, u := range users {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+
+		if len(users) < filter.Limit {
+			return nil
+		}
+		cursor := users[len(users)-1].PageCursor()
+		filter.Cursor = &cursor
+	}
+}
+
+// eachAuditEvent recorre los eventos originados por el usuario, del más
+// reciente al más antiguo. Los eventos posteriores al inicio del recorrido se
+// excluyen para que no desplacen las páginas.
+func (s *DataExportService) eachAuditEvent(ctx context.Context, tenantID kernel.TenantID, userID kernel.UserID, fn func(*audit.AuditEvent) error) error {
+	before := time.Now().UTC()
+	filter := audit.EventFilter{ActorUserID: &userID, CreatedBefore: &before, Limit: auditPageSize}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		events, _, err := s.auditRepo.Search(ctx, tenantID, filter)
+		if err != nil {
+			return errx.Wrap(err, "failed to export audit events", errx.TypeInternal).
+				WithDetail("offset", filter.Offset)
+		}
+
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < filter.Limit {
+			return nil
+		}
+		filter.Offset += len(events)
+	}
+}
+
+func (s *DataExportService) activeSessions(ctx context.Context, userID kernel.UserID) ([]auth.SessionDTO, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to export sessions", errx.TypeInternal).
+			WithDetail("user_id", userID.String())
+	}
+
+	dtos := make([]auth.SessionDTO, len(sessions))
+	for i, session := range sessions {
+		dtos[i] = session.ToDTO("")
+	}
+	return dtos, nil
+}
@@ -0,0 +1,260 @@
+package dataexportsrv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth/authtest"
+	"github.com/Abraxas-365/manifesto/internal/iam/dataexport"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/ptrx"
+)
+
+// tenantRepo implements the parts of tenant.TenantRepository used by exports
+type tenantRepo struct {
+	tenant.TenantRepository
+	tenants map[kernel.TenantID]*tenant.Tenant
+}
+
+func (r *tenantRepo) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, tenant.ErrTenantNotFound()
+	}
+	return t, nil
+}
+
+// invitationRepo implements the parts of invitation.InvitationRepository used
+// by exports
+type invitationRepo struct {
+	invitation.InvitationRepository
+	invitations []*invitation.Invitation
+}
+
+func (r *invitationRepo) FindByTenant(_ context.Context, tenantID kernel.TenantID) ([]*invitation.Invitation, error) {
+	var found []*invitation.Invitation
+	for _, inv := range r.invitations {
+		if inv.TenantID == tenantID {
+			found = append(found, inv)
+		}
+	}
+	return found, nil
+}
+
+func (r *invitationRepo) FindByEmail(ctx context.Context, email string, tenantID kernel.TenantID) ([]*invitation.Invitation, error) {
+	all, _ := r.FindByTenant(ctx, tenantID)
+	var found []*invitation.Invitation
+	for _, inv := range all {
+		if inv.Email == email {
+			found = append(found, inv)
+		}
+	}
+	return found, nil
+}
+
+// apiKeyRepo implements the parts of apikey.APIKeyRepository used by exports
+type apiKeyRepo struct {
+	apikey.APIKeyRepository
+	keys []*apikey.APIKey
+}
+
+func (r *apiKeyRepo) FindByTenant(_ context.Context, tenantID kernel.TenantID) ([]*apikey.APIKey, error) {
+	var found []*apikey.APIKey
+	for _, key := range r.keys {
+		if key.TenantID == tenantID {
+			found = append(found, key)
+		}
+	}
+	return found, nil
+}
+
+func (r *apiKeyRepo) FindByUser(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID) ([]*apikey.APIKey, error) {
+	all, _ := r.FindByTenant(ctx, tenantID)
+	var found []*apikey.APIKey
+	for _, key := range all {
+		if key.UserID != nil && *key.UserID == userID {
+			found = append(found, key)
+		}
+	}
+	return found, nil
+}
+
+// auditRepo implements audit.AuditRepository.Search over fixed events
+type auditRepo struct {
+	audit.AuditRepository
+	events []*audit.AuditEvent
+}
+
+func (r *auditRepo) Search(_ context.Context, tenantID kernel.TenantID, filter audit.EventFilter) ([]*audit.AuditEvent, int, error) {
+	var found []*audit.AuditEvent
+	for _, event := range r.events {
+		if event.TenantID == tenantID && filter.ActorUserID != nil && event.ActorUserID != nil && *event.ActorUserID == *filter.ActorUserID {
+			found = append(found, event)
+		}
+	}
+	total := len(found)
+	found = found[min(filter.Offset, len(found)):]
+	return found[:min(filter.Limit, len(found))], total, nil
+}
+
+type recorder struct {
+	events []audit.AuditEvent
+}
+
+func (r *recorder) Record(_ context.Context, event audit.AuditEvent) {
+	r.events = append(r.events, event)
+}
+
+func newTestService(t *testing.T) (*DataExportService, *recorder) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+
+	users := userinfra.NewInMemoryUserRepository()
+	for _, u := range []user.User{
+		{ID: "u1", TenantID: "t1", Email: "ana@acme.com", Name: "Ana", Status: user.UserStatusActive, PasswordHash: ptrx.String("hash-ana"), CreatedAt: now, UpdatedAt: now},
+		{ID: "u2", TenantID: "t1", Email: "bob@acme.com", Name: "Bob", Status: user.UserStatusActive, CreatedAt: now.Add(time.Second), UpdatedAt: now},
+		{ID: "u3", TenantID: "t2", Email: "eve@other.com", Name: "Eve", Status: user.UserStatusActive, CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := users.Save(ctx, u); err != nil {
+			t.Fatalf("Save user: %v", err)
+		}
+	}
+
+	sessions := authtest.NewInMemorySessionRepository()
+	if err := sessions.SaveSession(ctx, auth.UserSession{ID: "s1", UserID: "u1", TenantID: "t1", SessionToken: "session-secret", ExpiresAt: now.Add(time.Hour), LastActivity: now}); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+
+	ana := kernel.UserID("u1")
+	rec := &recorder{}
+	svc := NewDataExportService(
+		&tenantRepo{tenants: map[kernel.TenantID]*tenant.Tenant{
+			"t1": {ID: "t1", CompanyName: "Acme"},
+			"t2": {ID: "t2", CompanyName: "Other"},
+		}},
+		users,
+		&invitationRepo{invitations: []*invitation.Invitation{
+			{ID: "i1", TenantID: "t1", Email: "new@acme.com", Token: "invite-secret"},
+			{ID: "i2", TenantID: "t2", Email: "new@acme.com", Token: "other-secret"},
+		}},
+		&apiKeyRepo{keys: []*apikey.APIKey{
+			{ID: "k1", TenantID: "t1", UserID: &ana, KeyHash: "key-hash", Name: "ci"},
+			{ID: "k2", TenantID: "t2", KeyHash: "other-hash", Name: "other"},
+		}},
+		sessions,
+		&auditRepo{events: []*audit.AuditEvent{
+			{ID: "e1", TenantID: "t1", ActorUserID: &ana, Action: audit.ActionUserExported},
+		}},
+		rec,
+	)
+	return svc, rec
+}
+
+func export(t *testing.T, write dataexport.WriteFunc) string {
+	t.Helper()
+	var b strings.Builder
+	if err := write(context.Background(), &b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return b.String()
+}
+
+func TestExportTenant(t *testing.T) {
+	svc, rec := newTestService(t)
+
+	write, err := svc.ExportTenant(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := export(t, write)
+
+	var doc struct {
+		Users []struct {
+			User     user.UserDetailsDTO `json:"user"`
+			Sessions []auth.SessionDTO   `json:"sessions"`
+		} `json:"users"`
+		Invitations []map[string]any `json:"invitations"`
+		APIKeys     []map[string]any `json:"api_keys"`
+		Completed   bool             `json:"completed"`
+	}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("invalid JSON %q: %v", body, err)
+	}
+	if len(doc.Users) != 2 || len(doc.Invitations) != 1 || len(doc.APIKeys) != 1 || !doc.Completed {
+		t.Fatalf("document = %+v, want the two users, one invitation and one key of t1", doc)
+	}
+	sessions := 0
+	for _, u := range doc.Users {
+		sessions += len(u.Sessions)
+	}
+	if sessions != 1 {
+		t.Errorf("exported %d sessions, want 1", sessions)
+	}
+	for _, secret := range []string{"hash-ana", "session-secret", "invite-secret", "key-hash", "eve@other.com", "other-hash"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("export contains %q", secret)
+		}
+	}
+	if len(rec.events) != 1 || rec.events[0].Action != audit.ActionTenantExported {
+		t.Errorf("audit events = %+v, want one tenant export", rec.events)
+	}
+
+	if _, err := svc.ExportTenant(context.Background(), "missing"); !errors.Is(err, tenant.CodeTenantNotFound) {
+		t.Errorf("missing tenant error = %v, want TENANT_NOT_FOUND", err)
+	}
+}
+
+func TestExportUser(t *testing.T) {
+	ctx := context.Background()
+	svc, rec := newTestService(t)
+
+	write, err := svc.ExportUser(ctx, "t1", " ana@acme.com ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		User        *user.UserDetailsDTO `json:"user"`
+		Sessions    []auth.SessionDTO    `json:"sessions"`
+		APIKeys     []map[string]any     `json:"api_keys"`
+		AuditEvents []map[string]any     `json:"audit_events"`
+	}
+	if err := json.Unmarshal([]byte(export(t, write)), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.User == nil || doc.User.Email != "ana@acme.com" || len(doc.Sessions) != 1 || len(doc.APIKeys) != 1 || len(doc.AuditEvents) != 1 {
+		t.Errorf("user export = %+v", doc)
+	}
+
+	// An email with only an invitation in the tenant is still a subject
+	write, err = svc.ExportUser(ctx, "t1", "new@acme.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := export(t, write)
+	if !strings.Contains(body, `"user":null`) || !strings.Contains(body, `"i1"`) || strings.Contains(body, `"i2"`) {
+		t.Errorf("invitation-only export = %s", body)
+	}
+
+	// Another tenant's user is not found
+	if _, err := svc.ExportUser(ctx, "t1", "eve@other.com"); !errors.Is(err, dataexport.CodeSubjectNotFound) {
+		t.Errorf("other tenant's user error = %v, want SUBJECT_NOT_FOUND", err)
+	}
+	if _, err := svc.ExportUser(ctx, "t1", "  "); !errors.Is(err, dataexport.CodeEmailRequired) {
+		t.Errorf("empty email error = %v, want EMAIL_REQUIRED", err)
+	}
+	if len(rec.events) != 2 {
+		t.Errorf("recorded %d audit events, want one per successful export", len(rec.events))
+	}
+}
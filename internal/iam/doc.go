@@ -12,6 +12,7 @@
 //   - iam/invitation   — Invitation flow for onboarding users
 //   - iam/apikey       — API key generation, validation, and management
 //   - iam/otp          — One-time password generation and verification
+//   - iam/role         — Tenant-scoped custom roles (named scope bundles)
 //   - iam/scopes       — Scope definitions, groups, and validation
 //
 // # Architecture
@@ -25,15 +26,112 @@
 //
 // # Authentication Methods
 //
-// Two authentication strategies are supported and can coexist on the same user:
+// Three authentication strategies are supported and can coexist on the same user:
+//
+//  1. OAuth2 — Sign in via Google, Microsoft or any OpenID Connect provider.
+//     Users are created automatically from invitation tokens on first login.
+//
+//  2. Passwordless (OTP) — Sign up and log in via a one-time code sent to the
+//     user's email: 6 digits by default, configurable with OTP_CODE_LENGTH (at
+//     most 6) and OTP_CHARSET ("numeric" or "alphanumeric"; alphanumeric codes
+//     are upper-case and accepted in any case). Requires an invitation token
+//     for registration.
+//
+//  3. Password — Log in with email and password. Opt-in per user: only users
+//     with a password hash (User.SetPassword) can use it, and they replace it
+//     through an emailed reset link. OAuth/OTP-only users can't get a password
+//     that way, and tenants with their own SSO connection don't allow password
+//     login at all.
+//
+// All methods produce the same JWT access/refresh token pair upon success.
+//
+// # SSO Group Sync
+//
+// Optionally, IdP group claims can be mapped to scope templates
+// (OAUTH_GROUP_SYNC_ENABLED, OAUTH_GROUP_SCOPE_MAPPINGS="Group=template,...").
+// On every OAuth login the scopes covered by mapped templates are re-synced
+// from the user's current groups: removed groups remove their scopes. Providers
+// that do not report groups (Google, or Microsoft without
+// OAUTH_MICROSOFT_FETCH_GROUPS) are never synced.
+//
+// OAUTH_GROUP_SYNC_ENABLED is the default; each tenant can opt in or out with
+// the tenant.ConfigOAuthGroupSync config key ("true"/"false"):
+//
+//	tenantService.SetTenantConfig(ctx, tenantID, tenant.ConfigOAuthGroupSync, "false")
+//
+// # Profile Photos
+//
+// Google and OIDC providers return a picture URL. Microsoft Graph serves the
+// photo from a separate endpoint, so it is only fetched with
+// OAUTH_MICROSOFT_FETCH_PHOTO=true (one extra request per login, capped at 5s).
+// With Deps.ProfilePhotoStorage and OAUTH_PROFILE_PHOTO_BASE_URL the photo is
+// saved as avatars/microsoft/{id}.{ext} and the user's picture is its public
+// URL; otherwise the 96x96 photo is inlined as a data URL (up to 64KB). The
+// fetch is best-effort: no photo or any error leaves the picture unchanged and
+// never fails the login.
+//
+// # Generic OIDC Providers
+//
+// Any OpenID Connect IdP (Okta, Keycloak, ...) can be added without code
+// changes. OAUTH_OIDC_PROVIDERS lists provider keys; each key reads
+// OAUTH_OIDC_<KEY>_ISSUER_URL, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL,
+// _SCOPES, _GROUPS_CLAIM, _TIMEOUT and _OPERATION_TIMEOUT:
+//
+//	OAUTH_OIDC_PROVIDERS=okta
+//	OAUTH_OIDC_OKTA_ISSUER_URL=https://acme.okta.com/oauth2/default
+//	OAUTH_OIDC_OKTA_REDIRECT_URL=https://app.example.com/auth/callback/okta
+//	OAUTH_OIDC_OKTA_GROUPS_CLAIM=groups
+//
+// GenericOIDCOAuthService discovers endpoints from
+// {issuer}/.well-known/openid-configuration at startup, validates the
+// id_token signature against the issuer JWKS (plus iss, aud and exp) and maps
+// sub, email, email_verified, name and picture into OAuthUserInfo. The login
+// sends a random nonce in the authorization URL, kept with the state, and the
+// id_token must carry the same nonce. The key is
+// the provider value for POST /auth/login ("OKTA") and the callback path
+// (/auth/callback/okta). Providers whose discovery fails are skipped with a
+// warning. An invalid id_token returns INVALID_ID_TOKEN.
+//
+// # Tenant SSO Connections
+//
+// Enterprise tenants can use their own OpenID Connect IdP instead of the shared
+// apps. Each tenant has at most one row in tenant_sso_connections (issuer,
+// client credentials, redirect URL, scopes and groups claim), managed by
+// tenantsrv.SSOConnectionService. The issuer must be https and the redirect URL
+// must point to /auth/callback/sso; otherwise TENANT.INVALID_SSO_CONNECTION. The
+// client secret is stored AES-GCM encrypted in client_secret_encrypted with a
+// key derived from OAUTH_SSO_SECRET_KEY; without that key connections can't be
+// saved or read.
+//
+// A connection authenticates only the tenant's verified domains (see
+// TenantService.VerifyDomain); a tenant without verified domains accepts no
+// SSO emails. Migration 020 moved the former per-connection email_domains to
+// tenant_domains as unverified claims, so they must be verified again.
+//
+// OAuthProviderResolver picks the OAuthService for each login: the global
+// providers above, or for provider "SSO" the tenant's connection, discovered on
+// first use and cached until the row's updated_at changes. The tenant comes
+// from tenant_id in POST /auth/login or from the email's verified domain, and
+// travels in the OAuth state to the callback. Existing users of the tenant log
+// in without an invitation, and new users still need one.
+//
+// ### GET /tenant/sso, PUT /tenant/sso, DELETE /tenant/sso
+//
+// Admin only (SSOHandlers.RegisterRoutes). GET returns the caller tenant's
+// connection, active or not, with its verified domains; 404
+// TENANT.SSO_NOT_CONFIGURED when there is none. PUT creates or replaces it:
 //
-//  1. OAuth2 — Sign in via Google or Microsoft. Users are created automatically
-//     from invitation tokens on first login.
-//
-//  2. Passwordless (OTP) — Sign up and log in via a 6-digit code sent to the
-//     user's email. Requires an invitation token for registration.
+//	{
+//	  "issuer_url": "https://acme.okta.com/oauth2/default",
+//	  "client_id": "...",
+//	  "client_secret": "...",          // required on create, omit to keep
+//	  "redirect_url": "https://app.example.com/auth/callback/sso",
+//	  "scopes": ["openid", "email", "profile"],   // default, must include openid
+//	  "groups_claim": "groups",
+//	  "is_active": true                // default true
+//	}
 //
-// Both methods produce the same JWT access/refresh token pair upon success.
+// The client secret is never returned. DELETE removes the connection.
 //
 // # Multi-Tenancy
 //
@@ -48,6 +146,92 @@
 //	PROFESSIONAL → 50 users
 //	ENTERPRISE   → 500 users
 //
+// Plans also bound concurrent agent runs (TENANT_MAX_AGENT_RUNS_<PLAN>;
+// defaults 1/2/10/50). TenantService.MaxConcurrentAgentRuns resolves the limit
+// for an agentx.RunLimiter; runs beyond it get 429 TOO_MANY_CONCURRENT_RUNS
+// with retry_after_seconds:
+//
+//	limiter := agentxredis.NewRunLimiter(redisClient,
+//		func(ctx context.Context, key string) (int, error) {
+//			return tenantSvc.MaxConcurrentAgentRuns(ctx, kernel.TenantID(key))
+//		},
+//		cfg.TenantConfig.AgentRunLeaseTTL,
+//		agentx.WithWaitTimeout(cfg.TenantConfig.AgentRunWaitTimeout),
+//	)
+//
+// # Features & Quotas
+//
+// The plan seeds the features of a tenant:
+//
+//	TRIAL        → ai_resume_search, ai_agents
+//	BASIC        → + webhooks
+//	PROFESSIONAL → + sso, audit_log
+//	ENTERPRISE   → all features
+//
+// and its monthly LLM token quota (TENANT_LLM_TOKENS_MONTHLY_<PLAN>; defaults
+// 100k/1M/10M/unlimited, 0 = unlimited). Tenant settings override both:
+// "feature.<name>" = "true"/"false" and "quota.<name>" = "<limit>", and
+// "model.<alias>" = "<model>" overrides model aliases (see below).
+// TenantService.GetEntitlements resolves the effective set.
+//
+// EntitlementMiddleware gates routes after Authenticate:
+//
+//	ent := container.EntitlementMiddleware
+//	search.Post("/", authMiddleware.Authenticate(),
+//		ent.RequireFeature(tenant.FeatureAIResumeSearch),  // 403 TENANT.FEATURE_NOT_AVAILABLE
+//		ent.RequireQuota(tenant.QuotaLLMTokensMonthly),    // 429 TENANT.QUOTA_EXCEEDED
+//		handler)
+//
+// Usage is kept per calendar month (UTC) in Redis under
+// quota:<tenant>:<quota>:<YYYY-MM>. LLM tokens are counted by the metered
+// LLM and embedder when their meter is built with usagex.WithQuota over
+// QuotaService.Consume; other handlers call QuotaService.Consume themselves
+// and may refresh the headers with auth.SetQuotaHeaders. Limited quotas set
+// X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset (Unix seconds); a
+// rejection also sets Retry-After. Without Redis QuotaService is nil and
+// RequireQuota lets every request through.
+//
+// Plans also pick the LLM models of their tenants. Callers name a model alias
+// ("chat-default", "embed-default" or any alias ops define) instead of a
+// model, and modelx.Resolver maps it: first the tenant's "model.<alias>"
+// settings, then its plan (TENANT_MODEL_ALIASES_<PLAN>, e.g.
+// "chat-default=gpt-4.1-mini"), then the global LLM_MODEL_ALIASES,
+// LLM_DEFAULT_MODEL and LLM_DEFAULT_EMBEDDING_MODEL. Names that are no alias
+// are used as the model. TenantService.ModelAliases feeds the resolver:
+//
+//	resolver := modelx.NewResolver(globalAliases,
+//		modelx.WithTenantAliases(func(ctx context.Context, tenantID string) (map[string]string, error) {
+//			return tenantSvc.ModelAliases(ctx, kernel.TenantID(tenantID))
+//		}))
+//
+// LLM spend is metered apart from the quota, in Postgres (usagex, table
+// llm_usage): the metered LLM and embedder of the container record the tokens
+// and USD cost of every call made with a tenant in the context, priced with
+// LLM_PRICING over the built-in table. GET /usage/llm?period=day|month
+// returns the tenant's usage by day or month and model; it requires
+// "usage:read" (analyst, tenant_admin) or admin.
+//
+// # Subscription Expiry
+//
+// A tenant whose trial or subscription lapsed keeps its credentials, so
+// SubscriptionMiddleware checks the tenant after authentication:
+//
+//	api := app.Group("/api/v1", authMiddleware.Authenticate(), container.SubscriptionMiddleware.Handler())
+//
+// Lapsed trials get 402 TENANT.TRIAL_EXPIRED, lapsed subscriptions 402
+// TENANT.SUBSCRIPTION_EXPIRED and suspended or canceled tenants 403
+// TENANT.SUSPENDED. The dates are checked on every request, so tenants are
+// blocked as soon as they lapse. Tenants are cached per instance for
+// TENANT_SUBSCRIPTION_CACHE_TTL (default 1m; Invalidate drops one after a
+// renewal). Routes under one of the prefixes in
+// TENANT_SUBSCRIPTION_ALLOWED_PATHS (default /billing, /auth/me,
+// /auth/logout, /auth/refresh) stay reachable so the tenant can renew. They
+// are matched from the start of the path on whole segments, so list the full
+// prefix, e.g. /api/v1/billing.
+//
+// With TENANT_EXPIRE_LAPSED=true (default) the cleanup job also moves lapsed
+// ACTIVE / TRIAL tenants to EXPIRED, so Tenant.IsActive reflects the lapse.
+//
 // # Scopes & Authorization
 //
 // Authorization is scope-based. Scopes follow the pattern "resource:action"
@@ -57,6 +241,31 @@
 //	super_admin, platform_admin, tenant_admin, user_manager,
 //	analyst, api_admin, settings_admin, auditor, viewer
 //
+// Tenants may additionally define their own roles (see iam/role). A tenant role
+// name is accepted anywhere a scope template is, and is resolved after the
+// global templates, so a role can never shadow a global template name.
+//
+// Scope checks on users, API keys, invitations and the request AuthContext all
+// go through scopes.HasScopeIn, so "*" and "<resource>:*" wildcards behave the
+// same everywhere. scopes.ResolveEffectiveScopes expands global template names
+// into their scopes without going through UserService:
+//
+//	effective := scopes.ResolveEffectiveScopes(direct, []string{"viewer"})
+//	scopes.HasScopeIn(effective, "users:read")
+//
+// scopes.Normalize dedupes a list and drops the scopes a wildcard of the same
+// list already implies ("*" absorbs everything, "jobs:*" absorbs "jobs:read").
+// With IAM_NORMALIZE_SCOPES=true (default false) UserService and
+// InvitationService normalize scopes before saving them. When normalization
+// changed the list, the list as granted is persisted in requested_scopes
+// (users and invitations, NULL otherwise) and returned as requested_scopes in
+// UserDetailsDTO; an accepted invitation passes it on to the new user.
+// Every scope change (add, remove, set, template, make and revoke admin)
+// applies to that list and normalizes the result, so removing "jobs:*" or
+// revoking admin gives back the narrower scopes it absorbed. The audit event
+// (user.scopes_changed / invitation.created) also keeps the requested list in
+// metadata.requested_scopes.
+//
 // # Middleware
 //
 // The UnifiedAuthMiddleware supports both JWT Bearer tokens and API keys
@@ -68,14 +277,37 @@
 //   - ?api_key=<key>  (query param)
 //   - access_token cookie
 //
+// The caller is stored in the Fiber locals (auth.GetAuthContext) and in
+// c.UserContext() (kernel.AuthContextFrom), so services and decorators that
+// only see a context.Context, like LLM usage metering, know the tenant and
+// user they act for.
+//
 // # Quick Start
 //
 // Register all IAM routes on a Fiber router:
 //
 //	authHandlers.RegisterRoutes(app)           // OAuth2 + JWT
+//	authHandlers.RegisterIntrospectionRoutes(app, mw) // Token introspection
+//	sessionHandlers.RegisterRoutes(app, mw)    // Active sessions
 //	passwordlessHandlers.RegisterRoutes(app)   // OTP login/signup
+//	passwordHandlers.RegisterRoutes(app)       // Password login and reset
 //	invitationHandlers.RegisterRoutes(app, mw) // Invitation management
 //	apiKeyHandlers.RegisterRoutes(app, mw)     // API key management
+//	webhookHandlers.RegisterRoutes(app, mw)    // Webhook endpoints
+//
+// The /auth endpoints take small JSON or form bodies; the server mounts
+// httpx.BodyLimit (SERVER_AUTH_BODY_LIMIT, 64KB) and httpx.RequireContentType
+// on that prefix, so oversized bodies get 413 HTTP.BODY_TOO_LARGE and other
+// media types 415 HTTP.UNSUPPORTED_MEDIA_TYPE before any handler runs.
+//
+// Request bodies of the auth, invitation and API key endpoints are parsed with
+// httpx.Bind, which runs the validate tags of the request struct. A body that
+// cannot be parsed gets 400 HTTP.INVALID_BODY; invalid fields get 422
+// HTTP.VALIDATION_FAILED with one message per field, keyed by JSON name:
+//
+//	{ "code": "HTTP_VALIDATION_FAILED", "error": "Request validation failed",
+//	  "details": { "fields": { "email": "must be a valid email address",
+//	                           "environment": "must be one of: live, test" } } }
 //
 // Protect a route group:
 //
@@ -105,8 +337,10 @@
 // Request body:
 //
 //	{
-//	  "provider": "GOOGLE" | "MICROSOFT",
-//	  "invitation_token": "<token>"   // required for first-time users
+//	  "provider": "GOOGLE" | "MICROSOFT" | "<OIDC provider key>" | "SSO",
+//	  "invitation_token": "<token>",  // required for first-time users
+//	  "tenant_id": "<tenant id>",     // SSO only
+//	  "email": "user@acme.com"        // SSO only, used when tenant_id is absent
 //	}
 //
 // Response 200:
@@ -120,6 +354,12 @@
 //   - The provider value is case-insensitive ("google" == "GOOGLE").
 //   - invitation_token is mandatory the first time a user signs up.
 //     Subsequent logins without a token will look up the user by email.
+//   - A provider identity is linked to an existing user by email, or creates
+//     the invited user, only if the provider reports the email as verified;
+//     otherwise the callback fails with USER.EMAIL_NOT_VERIFIED. Identities
+//     already linked keep logging in.
+//   - "SSO" uses the tenant's own IdP (see Tenant SSO Connections); 400 when
+//     the tenant has no active connection.
 //
 // ### GET /auth/callback/:provider
 //
@@ -128,7 +368,7 @@
 //
 // Path params:
 //
-//	provider — "google" | "microsoft"
+//	provider — "google" | "microsoft" | "<oidc provider key>" | "sso"
 //
 // Query params:
 //
@@ -153,25 +393,42 @@
 //	  }
 //	}
 //
-// Error responses: 400 (invalid state / provider), 500 (token generation)
+// Error responses: 400 (invalid state / provider, or the IdP rejected the code:
+// AUTH.OAUTH_AUTHORIZATION_FAILED with the IdP's "error_description"), 409
+// (AUTH.TOO_MANY_SESSIONS), 500 (token generation), 504 (AUTH.OAUTH_TIMEOUT,
+// "retryable": true)
+//
+// Calls to the IdP honor the request context and are bounded twice:
+// OAUTH_<PROVIDER>_TIMEOUT limits connecting (dial and TLS handshake, default
+// 10s) and OAUTH_<PROVIDER>_OPERATION_TIMEOUT each token exchange or user info
+// lookup end to end (default 15s). Generic OIDC providers read
+// OAUTH_OIDC_<KEY>_TIMEOUT / _OPERATION_TIMEOUT; tenant SSO connections use
+// 10s / 15s.
 //
 // ### POST /auth/refresh
 //
 // Refreshes an expired access token using a valid refresh token.
+// Refresh tokens are single-use: every refresh revokes the presented token
+// and issues a new one. Presenting an already-rotated token again is treated
+// as theft — all of the user's refresh tokens are revoked and
+// AUTH.REFRESH_TOKEN_REUSED is returned. Tokens revoked on purpose (logout,
+// session sign-out or eviction) only get AUTH.INVALID_REFRESH_TOKEN; the
+// refresh_tokens.revoked_reason column tells the two apart.
 //
 // Request body (or refresh_token cookie):
 //
 //	{ "refresh_token": "<jwt>" }
 //
-// Response 200 (updates access_token cookie):
+// Response 200 (updates access_token + refresh_token cookies):
 //
 //	{
-//	  "access_token": "<new-jwt>",
-//	  "token_type":   "Bearer",
-//	  "expires_in":   900
+//	  "access_token":  "<new-jwt>",
+//	  "refresh_token": "<new-jwt>",
+//	  "token_type":    "Bearer",
+//	  "expires_in":    900
 //	}
 //
-// Error responses: 400 (missing token), 401 (invalid / expired refresh token)
+// Error responses: 400 (missing token), 401 (invalid / expired / reused refresh token)
 //
 // ### POST /auth/logout
 //
@@ -197,8 +454,125 @@
 //	  "tenant": { ...TenantDetailsDTO }
 //	}
 //
+// ### POST /auth/introspect
+//
+// Token introspection after RFC 7662, so resource servers can check an access
+// token server-side. Registered by RegisterIntrospectionRoutes; the caller must
+// be an admin or hold the tokens:introspect scope (usually an API key).
+// Accepts form-urlencoded or JSON:
+//
+//	token=<access_token>&token_type_hint=access_token
+//
+// Response 200 (Cache-Control: no-store):
+//
+//	{
+//	  "active": true, "scope": "users:read reports:view", "token_type": "Bearer",
+//	  "sub": "<UserID>", "iss": "manifesto", "aud": ["manifesto-api"],
+//	  "exp": 1718000900, "iat": 1718000000, "nbf": 1718000000,
+//	  "user_id": "...", "tenant_id": "...", "email": "...", "name": "..."
+//	}
+//
+// Invalid, expired or other-tenant tokens return 200 with { "active": false }.
+//
+// Error responses: 400 (token missing), 401, 403 (missing tokens:introspect)
+//
+// ## Sessions  (registered by SessionHandlers — requires a user access token)
+//
+// Each login creates a session; its id travels in the access token as "sid"
+// and the refresh tokens issued for it are bound to it, so signing out one
+// session stops that device from refreshing. Tokens issued before sessions were
+// bound keep working until they expire but are never flagged as current.
+//
+// Every authenticated request updates the session's last_activity, at most
+// once per SESSION_ACTIVITY_INTERVAL. With SESSION_SLIDING_EXPIRATION=true the
+// session's expires_at also moves forward by SESSION_EXPIRATION_TIME on
+// activity, never past created_at + SESSION_ABSOLUTE_TIMEOUT; sessions idle for
+// longer than SESSION_EXPIRATION_TIME can no longer refresh and are removed by
+// the cleanup service.
+//
+// A user holds at most SESSION_MAX_PER_USER active sessions (<= 0: unlimited).
+// At the limit a new login signs out the oldest session and its refresh tokens
+// (SESSION_MAX_POLICY=revoke_oldest, default) or is refused with 409
+// AUTH.TOO_MANY_SESSIONS (SESSION_MAX_POLICY=reject).
+//
+// ### GET /auth/sessions
+//
+// Lists the caller's active sessions, most recently active first. The session
+// token is never returned.
+//
+// Response 200:
+//
+//	{
+//	  "sessions": [
+//	    { "id": "...", "ip_address": "203.0.113.7", "user_agent": "Mozilla/5.0 ...",
+//	      "created_at": "...", "last_activity": "...", "expires_at": "...",
+//	      "current": true }
+//	  ],
+//	  "total": 1
+//	}
+//
+// ### DELETE /auth/sessions/:id
+//
+// Signs out one session: deletes it and revokes its refresh tokens. The
+// session's current access token stays valid until it expires (15 min).
+//
+// Response 200:
+//
+//	{ "message": "Session revoked successfully" }
+//
+// Error responses: 401 (no user token), 404 (AUTH.SESSION_NOT_FOUND, also for
+// sessions of other users)
+//
+// ## Login Methods  (registered by LoginMethodHandlers — requires a user access token)
+//
+// ### GET /auth/me/methods
+//
+// Lists the methods the caller can sign in with. oauth_provider is omitted
+// when no provider is linked.
+//
+// Response 200:
+//
+//	{ "oauth_provider": "GOOGLE", "otp": false, "password": true }
+//
+// ### POST /auth/me/methods/otp
+//
+// Sends a verification code to the caller's email. OTP login is enabled once
+// the code is confirmed. If OTP is already enabled, returns 200 with the
+// methods and sends nothing.
+//
+// Response 202:
+//
+//	{ "message": "Verification code sent to your email", "email": "...", "expires_in_seconds": 300 }
+//
+// Error responses: 401, 429 (Retry-After), 502 (code could not be sent)
+//
+// ### POST /auth/me/methods/otp/verify
+//
+//	{ "code": "123456" }
+//
+// Enables OTP login, marks the email verified and returns the methods
+// (audited as user.linked with method "otp").
+//
+// Error responses: 400 (OTP.INVALID_OTP, OTP.OTP_EXPIRED), 401, 429
+//
+// ### DELETE /auth/me/methods/oauth
+//
+// Unlinks the OAuth provider and returns the remaining methods (audited as
+// user.unlinked). A user must keep at least one login method, so unlinking
+// is refused while OTP and password are both disabled.
+//
+// Error responses: 400 (USER.AUTH_METHOD_NOT_ENABLED, no provider linked),
+// 401, 409 (USER.LAST_LOGIN_METHOD)
+//
 // ## Passwordless (OTP) Authentication  (registered by PasswordlessAuthHandlers)
 //
+// Codes are scoped to the flow that issued them: signup endpoints issue and
+// accept SIGNUP codes, login endpoints (email and phone) LOGIN codes, and
+// /auth/me/methods/otp VERIFICATION codes. A code sent to another flow fails
+// like any wrong code (OTP.INVALID_OTP) and costs an attempt of that flow's
+// code, without revealing whether it is live elsewhere; rate limits and
+// attempts are counted per contact and purpose.
+//
 // ### POST /auth/passwordless/tenants
 //
 // Discovers which tenants an email address belongs to, and what authentication
@@ -215,19 +589,39 @@
 //	  "tenants": [
 //	    {
 //	      "tenant_id": "...", "company_name": "Acme Corp",
+//	      "match": "existing_account",
 //	      "user_status": "ACTIVE",
-//	      "auth_methods": { "otp": true, "oauth": true, "oauth_provider": "GOOGLE" }
+//	      "auth_methods": { "otp": true, "oauth": true, "oauth_provider": "GOOGLE", "sso": false }
+//	    },
+//	    {
+//	      "tenant_id": "...", "company_name": "Globex",
+//	      "match": "domain_match",
+//	      "auth_methods": { "otp": false, "oauth": false, "sso": true }
 //	    }
 //	  ],
-//	  "count": 1
+//	  "count": 2
 //	}
 //
+// "sso" is true when the tenant has its own IdP; log in with provider "SSO".
+//
+// With TENANT_DOMAIN_DISCOVERY=true, active tenants that verified the email's
+// domain are also listed as "domain_match" (unless already listed for an
+// existing account). Domain matches carry no account details and depend only on
+// the domain, so they never confirm whether the address has an account. Tenants
+// claim domains with TenantService.ClaimDomain and, once ownership has been
+// proven out of band, TenantService.VerifyDomain; a verified domain belongs to
+// a single tenant.
+//
 // Notes: Returns an empty list if the email is not found (does not reveal existence).
 //
 // ### POST /auth/passwordless/signup/initiate
 //
 // Creates a new user account and sends a verification OTP to the provided email.
-// Requires a valid invitation token.
+// Requires a valid invitation token. The user, the tenant user count, the
+// accepted invitation and the OTP (queued, with the outbox) are committed in
+// one transaction; if any step fails nothing is kept and the response is 500.
+// Without the outbox the OTP is sent after commit, and a failed send keeps the
+// account and returns 206.
 //
 // Account linking: if the email already exists with OAuth only, OTP is enabled
 // on the existing account instead of creating a new one.
@@ -282,7 +676,10 @@
 //	  "email":     "user@example.com"
 //	}
 //
-// Error responses: 400 (invalid / expired code), 404 (user not found)
+// Error responses: 400 with the OTP code, so clients can tell a mistyped code
+// (OTP.INVALID_OTP, details.attempts_remaining) from one that needs a new
+// request (OTP.OTP_EXPIRED, OTP.OTP_ALREADY_USED);
+// 429 (OTP.TOO_MANY_ATTEMPTS), 404 (user not found)
 //
 // ### POST /auth/passwordless/login/initiate
 //
@@ -334,11 +731,37 @@
 //	  "tenant": { ...TenantDetailsDTO }
 //	}
 //
-// Error responses: 401 (invalid / expired code, user not found), 403 (inactive tenant)
+// Error responses: 401 (invalid / expired code, user not found), 403 (inactive tenant),
+// 409 (AUTH.TOO_MANY_SESSIONS)
+//
+// ### POST /auth/passwordless/login/phone/initiate
+//
+// Sends a login OTP by SMS to the user's registered phone (User.Phone, set via
+// UpdateUserRequest.Phone). Requires Deps.OTPSMSNotifier.
+//
+// Request body:
+//
+//	{ "phone": "+14155552671", "tenant_id": "..." }
+//
+// Response 200:
+//
+//	{ "message": "Login code sent by SMS!", "phone": "+14155552671", "expires_in_seconds": 600 }
+//
+// Error responses: 400 (phone not E.164, phone login not configured), 403 (account
+// or tenant inactive), 429 (rate limited), 502 (SMS delivery failed)
+//
+// ### POST /auth/passwordless/login/phone/verify
+//
+// Verifies the SMS OTP and returns the same token response as /login/verify.
+//
+// Request body:
+//
+//	{ "phone": "+14155552671", "code": "123456", "tenant_id": "..." }
 //
 // ### POST /auth/passwordless/resend-otp
 //
-// Resends a verification or login OTP. Rate-limited per the OTP configuration.
+// Resends a signup or login OTP; the new code is only valid for that flow.
+// Rate-limited per the OTP configuration.
 //
 // Request body:
 //
@@ -355,12 +778,85 @@
 // Error responses: 400 (wrong purpose / account already verified),
 // 403 (account inactive), 429 (rate limit exceeded)
 //
+// Only one code per contact is issued per OTP_RATE_LIMIT_WINDOW. Every endpoint
+// that sends a code answers 429 with a Retry-After header and:
+//
+//	{ "error": "Too many code requests...", "retry_after_seconds": 42 }
+//
+// ## Password Authentication  (registered by PasswordAuthHandlers)
+//
+// ### POST /auth/password/login
+//
+// Verifies the user's password and returns the same token response as
+// /auth/passwordless/login/verify. The user must have a password, be ACTIVE
+// with a verified email, and belong to an active tenant without an active SSO
+// connection.
+//
+// Request body:
+//
+//	{
+//	  "email":     "user@example.com",
+//	  "password":  "correct horse battery staple",
+//	  "tenant_id": "..."
+//	}
+//
+// Error responses: 401 (unknown email, no password set, SSO tenant or wrong
+// password — not distinguished), 403 (account or tenant inactive), 409 (AUTH.TOO_MANY_SESSIONS)
+//
+// ### POST /auth/password/forgot
+//
+// Emails a reset link to an ACTIVE user with a verified email who already has
+// a password and whose tenant has no active SSO connection. The link is
+// PASSWORD_RESET_URL with the token as ?token= and expires after
+// PASSWORD_RESET_EXPIRATION_TIME (1h). At most PASSWORD_RESET_MAX_ATTEMPTS (3)
+// links are sent per user per PASSWORD_RESET_RATE_LIMIT_WINDOW (15m); further
+// requests are dropped silently. Only the SHA-256 of the token is stored.
+//
+// Request body:
+//
+//	{ "email": "user@example.com", "tenant_id": "..." }
+//
+// Response 200, whether or not the account exists:
+//
+//	{ "message": "If the account exists, a password reset link has been sent" }
+//
+// ### POST /auth/password/reset
+//
+// Consumes the token and sets the new password in one transaction, so a failed
+// save leaves the token usable. The new password must satisfy the
+// password policy: PASSWORD_MIN_LENGTH characters (default 8), at most 72
+// bytes (bcrypt's limit) and, when enabled, PASSWORD_REQUIRE_UPPERCASE /
+// _LOWERCASE / _DIGIT / _SYMBOL. All of
+// the user's sessions, refresh tokens and pending reset tokens are revoked; the
+// user logs in again with the new password.
+//
+// Request body:
+//
+//	{ "token": "...", "password": "new password" }
+//
+// Response 200:
+//
+//	{ "message": "Password has been reset" }
+//
+// Error responses: 400 (invalid, expired or used token, or the user no longer
+// has password login; password rejected by the policy — the token is not
+// spent), 500 (password not saved — the token is not spent). A rejected password lists the rules it
+// failed (min_length, max_length, uppercase, lowercase, digit, symbol) so the client can
+// show specific guidance:
+//
+//	{
+//	  "error": "Password does not meet the requirements",
+//	  "failed_rules": ["min_length", "digit"],
+//	  "policy": { "min_length": 10, "require_uppercase": false, "require_lowercase": false, "require_digit": true, "require_symbol": false }
+//	}
+//
 // ## Invitations  (registered by InvitationHandlers — requires authentication)
 //
 // ### POST /invitations
 //
 // Creates and sends an invitation to a new user. The inviting user must have the
-// "users:invite" scope or be an admin.
+// "users:invite" scope or be an admin. Accepts an Idempotency-Key header
+// (see Idempotency).
 //
 // Request body:
 //
@@ -382,24 +878,41 @@
 //	  "scope_templates": ["viewer", "tenant_admin", ...]
 //	}
 //
+// The invitation email links to INVITATION_ACCEPT_URL?token=<token>. With the
+// outbox (OUTBOX_ENABLED=true, default) the email is queued in the same
+// transaction as the invitation and sent in the background. With the outbox
+// disabled, if the email cannot be sent the invitation is kept and the
+// response is 206:
+//
+//	{
+//	  "error":      "Invitation created but failed to send the invitation email",
+//	  "message":    "Please try again using the resend option",
+//	  "invitation": { ...InvitationDetailsDTO }
+//	}
+//
 // Error responses: 400 (invalid scopes), 401, 403 (insufficient permissions),
-// 404 (tenant not found), 409 (pending invitation already exists / user exists)
+// 404 (tenant not found), 409 (pending invitation already exists / user exists /
+// INVITATION_MAX_PENDING_PER_TENANT pending invitations reached)
 //
 // ### GET /invitations
 //
-// Lists all invitations for the authenticated user's tenant.
+// Lists the invitations of the authenticated user's tenant, newest first,
+// with cursor pagination (see "Pagination" below).
 //
-// Response 200:
+// Query params (all optional):
 //
-//	{ "invitations": [ ...InvitationResponseDTO ], "total": 5 }
+//	status — PENDING | ACCEPTED | EXPIRED | REVOKED
+//	limit  — default 20, max 100
+//	cursor — next_cursor of the previous page
 //
-// ### GET /invitations/pending
+// Response 200:
 //
-// Lists only PENDING (non-expired) invitations for the tenant.
+//	{ "items": [ ...InvitationResponseDTO ], "total": 5, "next_cursor": "eyJ0Ijoi..." }
 //
-// Response 200:
+// ### GET /invitations/pending
 //
-//	{ "invitations": [ ...InvitationResponseDTO ], "total": 2 }
+// Lists only PENDING (non-expired) invitations for the tenant. Same limit,
+// cursor and response as GET /invitations.
 //
 // ### GET /invitations/:id
 //
@@ -422,6 +935,24 @@
 // Response 200: { "message": "Invitation revoked successfully" }
 // Error responses: 400 (already accepted or revoked), 401, 404
 //
+// ### POST /invitations/:id/resend
+//
+// Re-sends a pending, non-expired invitation. The expiration is extended by
+// INVITATION_DEFAULT_EXPIRATION_DAYS and, unless
+// INVITATION_RESEND_REGENERATE_TOKEN=false, a new token replaces the old one
+// (the previous link stops working). An invitation can be sent at most once
+// per INVITATION_RESEND_COOLDOWN (default 5m).
+//
+// Response 200: { ...InvitationDetailsDTO }
+// Response 429 (sets Retry-After):
+//
+//	{ "error": "...", "retry_after_seconds": 180 }
+//
+// Error responses: 400 (expired, accepted or revoked), 401, 404, 429,
+// 502 (outbox disabled and the email could not be sent; the invitation is
+// left unchanged, so the previous link still works and the resend can be
+// retried right away)
+//
 // ### GET /invitations/public/token/:token
 //
 // Public endpoint. Retrieves invitation details by token string (used by the
@@ -453,7 +984,8 @@
 //
 // ### POST /api-keys
 //
-// Creates a new API key for the authenticated tenant.
+// Creates a new API key for the authenticated tenant. Accepts an
+// Idempotency-Key header (see Idempotency); a replay returns the same raw secret.
 //
 // Request body:
 //
@@ -472,14 +1004,20 @@
 //	  "api_key": {
 //	    "id": "...", "key_prefix": "manifesto_live_a1b2c3d4...",
 //	    "tenant_id": "...", "name": "CI Pipeline Key",
-//	    "scopes": [...], "is_active": true,
+//	    "scopes": [...], "is_active": true, "is_expired": false,
 //	    "expires_at": "2026-05-19T...", "created_at": "..."
 //	  },
 //	  "secret_key": "manifesto_live_<64-char-hex>",
 //	  "message": "⚠️ Save this key securely. It will not be shown again!"
 //	}
 //
-// Error responses: 400 (validation), 401, 403 (tenant suspended)
+// Every requested scope must be held by the creator, directly or through a
+// wildcard ("jobs:*" allows granting "jobs:read"); only creators with "*" can
+// grant anything. Otherwise APIKEY.INSUFFICIENT_SCOPE lists the offending
+// scopes in details.scopes.
+//
+// Error responses: 400 (validation), 401, 403 (tenant suspended / scopes the
+// creator does not hold)
 //
 // ### GET /api-keys
 //
@@ -489,6 +1027,29 @@
 //
 //	{ "api_keys": [ ...APIKeyDTO ], "total": 3 }
 //
+// ### GET /api-keys/search
+//
+// Paginated, filtered listing of the tenant's API keys. Requires admin or
+// "api_keys:read". Every APIKeyDTO carries "is_expired" so stale keys can be
+// flagged; key hashes are never returned.
+//
+// Query params:
+//
+//	is_active=true|false   expired=true (only expired keys)
+//	user_scoped=true (only keys bound to a user)   user_id=<UserID>
+//	order_by=created_at|last_used_at (newest first; never-used keys last)
+//	limit (default 20, max 100), offset
+//
+// Response 200:
+//
+//	{ "api_keys": [ ...APIKeyDTO ], "total": 42, "limit": 20, "offset": 0 }
+//
+// Error responses: 400 (invalid order_by or boolean), 401, 403
+//
+// last_used_at is written asynchronously after each authenticated request, at
+// most once per API_KEY_LAST_USED_INTERVAL (default 1m) per key, so it can lag
+// behind the actual last use by up to that interval.
+//
 // ### GET /api-keys/:id
 //
 // Gets a single API key by its UUID.
@@ -526,9 +1087,328 @@
 // Response 200: { "message": "API key deleted successfully" }
 // Error responses: 401, 404
 //
+// ## Users  (registered by UserHandlers — requires authentication)
+//
+// ### GET /users/search
+//
+// Paginated user search within the caller's tenant, newest first, with cursor
+// pagination (see "Pagination" below). Requires "users:read" or admin.
+//
+// Query params (all optional):
+//
+//	status         — ACTIVE | INACTIVE | SUSPENDED | PENDING
+//	email          — case-insensitive substring
+//	scope          — users holding this exact scope
+//	has_oauth      — true | false
+//	has_otp        — true | false
+//	created_after  — RFC3339
+//	created_before — RFC3339
+//	include_deleted — true to include soft-deleted users
+//	limit          — default 20, max 100
+//	cursor         — next_cursor of the previous page
+//
+// Response 200:
+//
+//	{ "items": [ ...UserDetailsDTO ], "total": 1342, "next_cursor": "eyJ0Ijoi..." }
+//
+// ### GET /users/export
+//
+// Streams every user matching the GET /users/search filters (limit and cursor
+// are ignored). Requires "users:export" or admin. The format is negotiated via
+// the Accept header: text/csv (default) or application/json (a JSON array).
+// Rows are written incrementally; there is no total count. CSV cells starting
+// with =, +, -, @, tab or carriage return get a leading ' so spreadsheets do
+// not run them as formulas.
+//
+//	email,name,status,scopes,oauth_provider,otp_enabled,last_login_at
+//	a@acme.com,Ana,ACTIVE,users:read;reports:view,GOOGLE,false,2026-01-02T15:04:05Z
+//
+// Error responses: 400 (invalid filter), 406 (unsupported Accept)
+//
+// ### POST /users/:id/restore
+//
+// Restores a soft-deleted user. Requires "users:write" or admin. Deleting only
+// sets deleted_at and status DELETED, revokes the user's sessions and refresh
+// tokens and frees their seat; erased users cannot be restored. A restored
+// user signs in again and takes a seat back.
+//
+// Response 200: { ...UserDetailsDTO }
+// Error responses: 400 (user is not deleted or was erased), 403 (max users reached), 404, 409 (email reused by another user)
+//
+// ### POST /users/:id/erase
+//
+// Erases the personal data of a user (right to be forgotten), also for
+// soft-deleted users. Requires "users:delete" or admin. In one transaction it
+// deletes the user's sessions and API keys, revokes their refresh and reset
+// tokens, deletes every invitation addressed to their email and their OTP
+// codes (Redis OTP codes are deleted outside the transaction), then:
+//
+//	ANONYMIZE   (default) keeps the row with email "erased+<id>@erased.invalid",
+//	            name "Erased user" and no picture, phone, credentials or scopes,
+//	            so invitations they sent and audit events still resolve
+//	HARD_DELETE deletes the row; invitations they sent are deleted in cascade
+//
+// OTP codes are stored per contact, not per tenant, so codes of an email or
+// phone that another account (in any tenant) still uses are kept.
+//
+// Request (optional): { "mode": "HARD_DELETE" }
+//
+// Response 200: { "message": "User data erased successfully" }
+// Error responses: 400 (invalid mode, user already erased), 404
+//
+// ### POST /users/import
+//
+// Invites many users at once (up to 500). Requires "users:invite" or admin and
+// a user token. Each row goes through POST /invitations, so a failing row does
+// not stop the others.
+//
+// Request body (application/json):
+//
+//	{ "users": [ { "email": "a@acme.com", "scope_template": "viewer" }, ... ] }
+//
+// or text/csv with an "email" header and optional "scopes" (space separated)
+// and "scope_template" columns:
+//
+//	email,scopes,scope_template
+//	a@acme.com,,viewer
+//	b@acme.com,users:read reports:view,
+//
+// Response 200:
+//
+//	{
+//	  "successful":   ["a@acme.com"],
+//	  "email_failed": [],
+//	  "failed":       [ { "row": 2, "email": "b@acme.com", "error": "A pending invitation already exists for this email" } ],
+//	  "total":        2
+//	}
+//
+// "email_failed" lists invitations that were created but whose email could not
+// be sent (outbox disabled); resend them with POST /invitations/:id/resend.
+// "row" is the 1-based position in "users" or the CSV data row, so repeated or
+// empty emails are reported once per row.
+//
+// Error responses: 400 (empty / malformed body, USER_TOO_MANY_IMPORT_ROWS), 401
+//
+// ### POST /users/:id/scopes/preview
+//
+// Shows what a scope change would do, without saving it, so the UI can ask for
+// confirmation. Requires "users:write" or admin. operation is add, remove or
+// set (with "scopes") or template (with "template_name", a global template or
+// a tenant role).
+//
+//	{ "operation": "template", "template_name": "viewer" }
+//
+// Response 200:
+//
+//	{
+//	  "user_id": "...",
+//	  "current_scopes":   ["users:read", "users:write"],
+//	  "resulting_scopes": ["users:read", "reports:view"],
+//	  "added":   [ { "name": "reports:view", "description": "...", "category": "..." } ],
+//	  "removed": [ { "name": "users:write", "description": "...", "category": "..." } ],
+//	  "changed": true
+//	}
+//
+// Error responses: 400 (unknown operation, USER.INVALID_SCOPES,
+// USER.INVALID_SCOPE_TEMPLATE), 404
+//
+// ### POST /users/scopes/validate
+//
+// Checks a scope list and suggests near-matches for the invalid scopes. Any
+// authenticated caller may use it. Always 200; "invalid" is empty when every
+// scope is valid.
+//
+//	{ "scopes": ["users:read", "user:write"] }
+//
+// Response 200:
+//
+//	{
+//	  "valid":   ["users:read"],
+//	  "invalid": [ { "scope": "user:write", "suggestions": ["users:write"] } ]
+//	}
+//
+// USER.INVALID_SCOPES errors from the other scope endpoints carry the same
+// suggestions in details.suggestions.
+//
+// ## Roles  (registered by RoleHandlers — requires authentication)
+//
+// Tenant-defined named scope bundles. Requires "roles:read" / "roles:write" /
+// "roles:delete" or admin.
+//
+// ### POST /roles
+//
+// Request body:
+//
+//	{
+//	  "name":        "support_agent",
+//	  "description": "Front-line support",
+//	  "scopes":      ["users:read", "reports:view"]
+//	}
+//
+// Response 201: { ...Role }
+// Error responses: 400 (invalid scopes / reserved name), 403 (max roles reached),
+// 409 (name already exists)
+//
+// ### GET /roles
+//
+// Response 200: { "roles": [ ...Role ], "total": 3 }
+//
+// ### GET /roles/:id
+//
+// Response 200: { ...Role }
+//
+// ### PUT /roles/:id
+//
+// Request body (all fields optional): { "name": "...", "description": "...", "scopes": [...] }
+//
+// ### DELETE /roles/:id
+//
+// Deletes the role. Users that were assigned the role keep their expanded scopes.
+//
+// Response 200: { "message": "Role deleted successfully" }
+//
+// ## Audit Log  (registered by AuditHandlers — requires authentication)
+//
+// Authentication and IAM transitions are recorded in audit_events: logins
+// (succeeded / failed), logout, token refresh, session revoked, account created / linked,
+// invitations created / revoked / accepted, API keys created / revoked /
+// scope changes, users activated / suspended / scope changes / erased, and
+// data exports (tenant.exported / user.exported).
+//
+// Services take the actor (user, API key, IP, user agent) from the context;
+// handlers attach it with auth.AuditContext(c). Code calling services directly
+// can attach one with audit.WithActor. Recording never fails the operation.
+//
+// ### GET /audit-events
+//
+// Paginated events of the caller's tenant, newest first. Requires "audit:read" or admin.
+//
+// Query params (all optional):
+//
+//	action         — e.g. auth.login_failed, api_key.revoked
+//	resource_type  — user | invitation | api_key | session | tenant
+//	resource_id    — id of the resource
+//	actor_user_id  — user who performed the action
+//	created_after  — RFC3339
+//	created_before — RFC3339
+//	limit          — default 50, max 200
+//	offset         — default 0
+//
+// Response 200:
+//
+//	{ "events": [ ...AuditEvent ], "total": 87, "limit": 50, "offset": 0 }
+//
+// ## Data Export  (registered by DataExportHandlers — requires authentication)
+//
+// GDPR exports stream a single JSON document as an attachment, written as it
+// is read so large tenants are never buffered. Secrets are never included: no
+// password hashes, tokens, invitation tokens or API key hashes. Callers can
+// only export their own tenant, admins included (admin scopes are per
+// tenant), any other :id gets 403; every export is recorded in the audit log. The document ends with "completed": true, so a
+// document cut short by a failure while streaming can be told apart.
+//
+// ### POST /tenants/:id/export
+//
+// Requires "tenants:export" or admin.
+//
+// Response 200:
+//
+//	{ "exported_at": "...", "tenant": { ...TenantDetailsDTO },
+//	  "users": [ { "user": { ...UserDetailsDTO }, "sessions": [ ...SessionDTO ] } ],
+//	  "invitations": [ ...InvitationDetailsDTO ], "api_keys": [ ...APIKeyDTO ],
+//	  "completed": true }
+//
+// Error responses: 403 (another tenant), 404 (tenant not found)
+//
+// ### POST /tenants/:id/export/user
+//
+// Subject-access request: everything the tenant holds about one person,
+// found by email. Requires "users:export" or admin. An email with only
+// invitations (no account) is exported with "user": null.
+//
+// Request: { "email": "ana@acme.com" }
+//
+// Response 200:
+//
+//	{ "exported_at": "...", "tenant_id": "...", "email": "ana@acme.com",
+//	  "user": { ...UserDetailsDTO }, "sessions": [ ...SessionDTO ],
+//	  "api_keys": [ ...APIKeyDTO ], "invitations": [ ...InvitationDetailsDTO ],
+//	  "audit_events": [ ...AuditEvent performed by the user ], "completed": true }
+//
+// Error responses: 400 (email missing), 403 (another tenant), 404 (no user or invitation)
+//
+// ## Webhooks  (registered by WebhookHandlers — requires authentication)
+//
+// Tenants subscribe HTTPS endpoints to audit actions (e.g. "user.created",
+// "api_key.revoked") or to "*" for all of them. Each recorded audit event is
+// enqueued in the outbox in the same transaction, so webhooks need
+// OUTBOX_ENABLED=true; the outbox then looks up the subscribed endpoints off
+// the request path and creates one delivery per endpoint. Signing secrets are
+// stored AES-GCM encrypted with WEBHOOK_SECRET_KEY; webhooks stay off without
+// it. Requires "integrations:read" / "integrations:write" /
+// "integrations:delete" or admin.
+//
+// Every delivery is a POST with the JSON body below and these headers:
+//
+//	X-Signature:        sha256=<hex HMAC-SHA256 of the raw body with the endpoint secret>
+//	X-Webhook-Event:    user.created
+//	X-Webhook-Delivery: <delivery id, same on every retry>
+//
+//	{ "id": "<audit event id>", "type": "user.created", "tenant_id": "...",
+//	  "created_at": "...", "data": { "resource_type": "user", "resource_id": "...",
+//	  "actor_user_id": "...", "metadata": { ... } } }
+//
+// Non-2xx responses and network errors are retried with the outbox backoff;
+// after OUTBOX_MAX_ATTEMPTS the delivery is marked FAILED. Endpoints must use
+// https and resolve to a public address (checked when connecting, redirects
+// are not followed) unless WEBHOOK_ALLOW_PRIVATE_NETWORKS=true. Other settings:
+// WEBHOOKS_ENABLED, WEBHOOK_TIMEOUT (per attempt), WEBHOOK_MAX_ENDPOINTS_PER_TENANT.
+//
+// ### POST /webhooks
+//
+// Request:
+//
+//	{ "url": "https://example.com/hooks/iam", "event_types": ["user.created", "user.suspended"] }
+//
+// Response 201: { "endpoint": { ...Endpoint }, "secret": "whsec_...", "message": "..." }
+// The secret is only returned here.
+// Error responses: 400 (invalid url, unknown event type, private address), 409 (max endpoints)
+//
+// ### GET /webhooks
+//
+// Response 200: { "endpoints": [ ...Endpoint ] }
+//
+// ### PUT /webhooks/:id
+//
+// Request (all optional): { "url": "...", "event_types": [...], "is_active": false }
+//
+// Endpoints whose plaintext secret was cleared by migration 021 can't be
+// reactivated; delete and recreate them to get a new secret.
+//
+// Response 200: { ...Endpoint }
+// Error responses: 400, 404, 409 (SECRET_MISSING)
+//
+// ### DELETE /webhooks/:id
+//
+// Deletes the endpoint and its delivery history; pending deliveries are dropped.
+//
+// Response 200: { "message": "Webhook endpoint deleted successfully" }
+// Error responses: 404
+//
+// ### GET /webhooks/:id/deliveries
+//
+// Delivery history, newest first. Query params: limit (default 50, max 200), offset.
+//
+// Response 200:
+//
+//	{ "deliveries": [ { "id": "...", "event_type": "user.created", "status": "SUCCEEDED",
+//	  "attempts": 1, "response_status": 204, ... } ], "limit": 50, "offset": 0 }
+//
 // # JWT Token Structure
 //
-// Access tokens (HS256) contain the following custom claims:
+// Access tokens (HS256 by default, RS256 / ES256 with an asymmetric key)
+// carry the kid of their signing key in the header and contain the following
+// custom claims:
 //
 //	{
 //	  "user_id":   "<UserID>",
@@ -536,19 +1416,76 @@
 //	  "email":     "user@example.com",
 //	  "name":      "Jane Doe",
 //	  "scopes":    ["users:read", "reports:view"],
+//	  "sid":       "<session id>",
 //	  "iss": "manifesto",
 //	  "sub": "<UserID>",
+//	  "aud": ["manifesto-api"],
 //	  "iat": 1718000000,
+//	  "nbf": 1718000000,
 //	  "exp": 1718000900
 //	}
 //
+// ValidateAccessToken surfaces the registered claims (iss, sub, aud, iat, nbf,
+// exp, jti) in TokenClaims; IntrospectToken adds Active and the remaining
+// lifetime (TokenIntrospection.ExpiresIn).
+//
+// Validation requires iss to equal JWT_ISSUER (AUTH.INVALID_ISSUER) and aud to
+// include at least one JWT_AUDIENCE value (AUTH.INVALID_AUDIENCE). Tokens
+// without aud are rejected too unless JWT_STRICT_AUDIENCE=false
+// (auth.WithStrictAudience), which keeps tokens issued before the audience was
+// configured working.
+//
 // Default TTLs:
 //   - Access token:  15 minutes
 //   - Refresh token: 7 days
 //
+// # Signing Key Rotation
+//
+// Tokens are signed with the current key and validated against every
+// configured key, chosen by the kid header; tokens without kid (issued before
+// rotation support) are tried against every key of their algorithm, so they
+// survive the first rotation as long as their key stays listed. The token
+// algorithm must match the key's.
+//
+//	JWT_SIGNING_ALGORITHM   HS256 (JWT_SECRET_KEY) | RS256 | ES256 (JWT_PRIVATE_KEY_FILE)
+//	JWT_PRIVATE_KEY_FILE    PEM private key: PKCS#1, PKCS#8 or SEC 1 (P-256 for ES256)
+//	JWT_KEY_ID              kid of the current key; derived from the key when empty
+//	JWT_PREVIOUS_KEYS       retired keys still accepted:
+//	                        old=<hmac secret>,rsa-2024=file:/keys/rsa-2024.pub.pem
+//
+// To rotate, configure the new key as current, move the old one to
+// JWT_PREVIOUS_KEYS (its public key is enough for RS256 / ES256), and remove
+// it once JWT_ACCESS_TOKEN_TTL has passed. Keys are matched by their kid and
+// by the kid derived from the key material, so a retired key may be listed
+// under any name; keep the kid its tokens carry (logged at startup) when other
+// services verify them through the JWKS.
+//
+// With RS256 / ES256 keys the container builds JWKSHandlers, which publishes
+// the public keys (current and retired; HMAC keys never) for other services:
+//
+//	if container.JWKSHandlers != nil {
+//		container.JWKSHandlers.RegisterRoutes(app) // GET /.well-known/jwks.json
+//	}
+//
+// # Pagination
+//
+// GET /users/search, GET /invitations and GET /invitations/pending return a
+// kernel.Page, ordered newest first by (created_at, id):
+//
+//	{ "items": [ ... ], "total": 1342, "next_cursor": "eyJ0Ijoi..." }
+//
+// total counts every item matching the filters, not just the page. To get the
+// next page pass next_cursor back unchanged as ?cursor= with the same filters;
+// it is omitted on the last page. Cursors are opaque (base64 of created_at and
+// id) and seek through the (tenant_id, created_at, id) indexes with dbx.Keyset,
+// so deep pages cost the same as the first. Items created after the first
+// page was read do not shift later pages.
+//
 // # Error Response Format
 //
-// All errors follow the errx structured format:
+// All errors follow the errx structured format. In Go, match them by code with
+// errors.Is against the registered code (errors.Is(err, otp.CodeOTPExpired))
+// or errx.HasCode, never by comparing err.Error() strings:
 //
 //	{
 //	  "code":    "USER.NOT_FOUND",
@@ -565,46 +1502,235 @@
 //
 //	AUTH.INVALID_REFRESH_TOKEN  — 401
 //	AUTH.EXPIRED_REFRESH_TOKEN  — 401
+//	AUTH.REFRESH_TOKEN_REUSED   — 401  rotated token presented again; all tokens revoked
 //	AUTH.INVALID_OAUTH_PROVIDER — 400
-//	AUTH.INVALID_STATE          — 400
+//	AUTH.OAUTH_AUTHORIZATION_FAILED — 400  details.error / error_description from the IdP
+//	AUTH.OAUTH_TIMEOUT          — 504  IdP did not answer in time; safe to retry
+//	AUTH.INVALID_STATE          — 400  unknown or expired OAuth state
+//	AUTH.STATE_ALREADY_USED     — 400  OAuth callback replayed a redeemed state
 //	AUTH.TOKEN_GENERATION_FAILED— 500
+//	AUTH.TOKEN_VALIDATION_FAILED— 401  bad signature, expired or malformed token
+//	AUTH.INVALID_ISSUER         — 401  iss differs from JWT_ISSUER
+//	AUTH.INVALID_AUDIENCE       — 401  aud does not include any JWT_AUDIENCE value
+//	AUTH.SESSION_NOT_FOUND      — 404
+//	AUTH.TOO_MANY_SESSIONS      — 409  session limit reached (SESSION_MAX_POLICY=reject)
+//	AUTH.RATE_LIMITED           — 429  sets Retry-After and X-RateLimit-*
+//	AUTH.RATE_LIMITER_UNAVAILABLE — 503  Redis down and RATE_LIMIT_FAIL_OPEN=false
+//	AUTH.INVALID_IDEMPOTENCY_KEY — 400  Idempotency-Key longer than 255 characters
+//	AUTH.IDEMPOTENCY_KEY_REUSED — 409  same key sent with a different body
+//	AUTH.IDEMPOTENCY_IN_PROGRESS — 409  first request with the key still running
 //
 //	OTP.INVALID_OTP             — 400
 //	OTP.OTP_EXPIRED             — 400
 //	OTP.OTP_ALREADY_USED        — 400
-//	OTP.TOO_MANY_ATTEMPTS       — 429
+//	OTP.TOO_MANY_ATTEMPTS       — 429  code locked after OTP_MAX_ATTEMPTS wrong guesses; request a new one
 //	OTP.TOO_MANY_REQUESTS       — 429
+//	OTP.SEND_FAILED             — 502
+//	OTP.CHANNEL_DISABLED        — 400
 //
 //	USER.NOT_FOUND              — 404
 //	USER.ALREADY_EXISTS         — 409
 //	USER.SUSPENDED              — 403
 //	USER.INVALID_SCOPES         — 400
 //	USER.INVALID_SCOPE_TEMPLATE — 400
+//	USER.AUTH_METHOD_NOT_ENABLED — 400
+//	USER.LAST_LOGIN_METHOD      — 409  unlinking would leave no login method
 //
 //	TENANT.NOT_FOUND            — 404
 //	TENANT.SUSPENDED            — 403
 //	TENANT.MAX_USERS_REACHED    — 403
 //	TENANT.TRIAL_EXPIRED        — 402
 //	TENANT.SUBSCRIPTION_EXPIRED — 402
+//	TENANT.FEATURE_NOT_AVAILABLE — 403  plan or settings do not enable the feature
+//	TENANT.QUOTA_EXCEEDED       — 429  sets Retry-After and X-Quota-*
+//	TENANT.SSO_NOT_CONFIGURED   — 404
+//	TENANT.INVALID_SSO_CONNECTION — 400
 //
 //	INVITATION.NOT_FOUND        — 404
 //	INVITATION.EXPIRED          — 410
 //	INVITATION.ALREADY_EXISTS   — 409
 //	INVITATION.USER_ALREADY_EXISTS — 409
+//	INVITATION.RESEND_COOLDOWN  — 429
+//	INVITATION.SEND_FAILED      — 502
 //
 //	APIKEY.NOT_FOUND            — 404
 //	APIKEY.INVALID              — 401
 //	APIKEY.EXPIRED              — 401
 //	APIKEY.REVOKED              — 401
+//	APIKEY.INSUFFICIENT_SCOPE   — 403  also: key requested with scopes the creator lacks
+//
+//	ROLE.NOT_FOUND              — 404
+//	ROLE.ALREADY_EXISTS         — 409
+//	ROLE.RESERVED_NAME          — 400
+//	ROLE.INVALID_SCOPES         — 400
+//	ROLE.MAX_ROLES_REACHED      — 403
+//
+//	AUDIT.INVALID_FILTER        — 400
+//
+//	PAGINATION.INVALID_CURSOR   — 400  cursor not produced by a previous page
+//
+//	HTTP.INVALID_BODY           — 400  body is not valid JSON / form data
+//	HTTP.VALIDATION_FAILED      — 422  details.fields maps each invalid field to a message
+//	HTTP.BODY_TOO_LARGE         — 413
+//	HTTP.UNSUPPORTED_MEDIA_TYPE — 415
 //
 // # Infrastructure Dependencies
 //
 // Required:
 //   - PostgreSQL — tenants, users, invitations, refresh_tokens, user_sessions,
-//     password_reset_tokens, api_keys, otps, tenant_config
+//     password_reset_tokens, api_keys, otps, tenant_config, tenant_roles,
+//     audit_events, tenant_sso_connections, tenant_domains
 //
 // Optional:
 //   - Redis — RedisStateManager for OAuth state (replaces in-memory default)
+//   - Redis — RedisOTPRepository when OTP_STORE=redis; keys expire with the
+//     code, so the otps table and the OTP cleanup are not used
+//   - Redis — RedisRateLimiter for the HTTP rate limits (RATE_LIMIT_ENABLED)
+//   - Redis — RedisLastUsedThrottle to debounce API key last_used_at writes
+//     (API_KEY_LAST_USED_INTERVAL); without Redis every use is written
+//   - Redis — RedisActivityThrottle to debounce session last_activity writes;
+//     without Redis an in-memory throttle is used per instance
+//   - Redis — RedisQuotaCounter for the tenant quotas; without Redis quotas
+//     are not enforced
+//   - Redis — RedisIdempotencyStore for Idempotency-Key; without Redis the
+//     header is ignored
+//
+// # Lookup Cache
+//
+// With Redis and IAM_CACHE_ENABLED=true (default) the container wraps the
+// tenant and user repositories in read-through caches
+// (tenantinfra.CachedTenantRepository, userinfra.CachedUserRepository):
+// FindByID is served from Redis for IAM_CACHE_TTL (default 30s) and every
+// write through the repository (Save, Delete, HardDelete, ExpireLapsed)
+// drops the entry, which covers suspend / activate, plan and scope changes.
+// Both are decorators, so they can wrap any implementation:
+//
+//	tenantRepo = tenantinfra.NewCachedTenantRepository(tenantRepo, redisClient, 30*time.Second)
+//
+// Inside a dbx transaction the entry is dropped once the transaction commits
+// (dbx.AfterCommit), so a concurrent read can't cache the row being replaced.
+// Writes made outside the repositories are visible after the TTL at most.
+// Redis errors fall back to Postgres. Credentials are never cached: users with
+// a password are always read from Postgres. Set IAM_CACHE_ENABLED=false to
+// disable it.
+//
+// # OTP Delivery
+//
+// OTPService sends codes through an otp.NotificationService, injected via
+// Deps.OTPNotifier. otpinfra ships email implementations configured from
+// config.Email (EMAIL_FROM_ADDRESS, EMAIL_FROM_NAME, EMAIL_REPLY_TO):
+//
+//	// SMTP (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD)
+//	notifier := otpinfra.NewSMTPNotifier(&cfg.Email, cfg.Auth.OTP.ExpirationTime)
+//
+//	// AWS SES
+//	notifier := otpinfra.NewSESNotifier(ses.NewFromConfig(awsCfg), &cfg.Email, cfg.Auth.OTP.ExpirationTime)
+//
+// They render the email and hand it to the notifx providers (notifxsmtp,
+// notifxses); notifxsmtp strips line breaks from header values and RFC 2047
+// encodes the subject.
+//
+// Delivery failures surface as OTP.SEND_FAILED (502), distinct from the 429
+// rate-limit errors.
+//
+// Contacts in E.164 format (e.g. +14155552671) go through Deps.OTPSMSNotifier
+// instead; otpinfra.TwilioNotifier is configured from config.SMS
+// (TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, TWILIO_MESSAGING_SERVICE_SID or
+// TWILIO_FROM_NUMBER):
+//
+//	smsNotifier := otpinfra.NewTwilioNotifier(&cfg.SMS, cfg.Email.FromName, cfg.Auth.OTP.ExpirationTime)
+//
+// # Invitation Delivery
+//
+// InvitationService sends invitation emails through an
+// invitation.InvitationNotifier, injected via Deps.InvitationNotifier. The
+// email links to config.Auth.Invitation.AcceptURL (INVITATION_ACCEPT_URL) with
+// the token as ?token=. invitationinfra ships implementations configured from
+// config.Email:
+//
+//	// SMTP
+//	invitationNotifier := invitationinfra.NewSMTPNotifier(&cfg.Email)
+//
+//	// AWS SES
+//	invitationNotifier := invitationinfra.NewSESNotifier(ses.NewFromConfig(awsCfg), &cfg.Email)
+//
+//	// Development: logs the email and accept URL
+//	invitationNotifier := invitationinfra.NewConsoleNotifier(&cfg.Email)
+//
+// # Password Reset Delivery
+//
+// Reset links go through an auth.PasswordResetNotifier, injected via
+// Deps.PasswordResetNotifier. authinfra.EmailPasswordResetNotifier sends them
+// with any notifx.EmailSender:
+//
+//	resetNotifier := authinfra.NewEmailPasswordResetNotifier(notifxsmtp.NewSMTPProvider(smtpCfg), &cfg.Email)
+//
+// # Rate Limiting
+//
+// RateLimitMiddleware enforces per-route limits with a sliding window kept in
+// Redis (authinfra.RedisRateLimiter), so limits hold across instances. It is
+// mounted globally in setupMiddleware; rules match on the route suffix, so a
+// router prefix such as /api/v1 does not matter:
+//
+//	limiter := auth.NewRateLimitMiddleware(
+//		authinfra.NewRedisRateLimiter(redisClient),
+//		cfg.Auth.RateLimit.FailOpen,
+//		auth.DefaultRateLimitRules(cfg.Auth.RateLimit)...,
+//	)
+//	app.Use(limiter.Handler())
+//
+// Default rules:
+//
+//	login — POST /auth/login, passwordless tenants / signup / login initiate,
+//	        /auth/password/forgot;
+//	        per IP, RATE_LIMIT_LOGIN_REQUESTS per RATE_LIMIT_LOGIN_WINDOW (10/1m)
+//	otp   — passwordless verify endpoints, /resend-otp, /auth/password/login
+//	        and /auth/password/reset;
+//	        per IP, RATE_LIMIT_OTP_REQUESTS per RATE_LIMIT_OTP_WINDOW (5/1m)
+//	auth  — /auth/refresh, /auth/logout, /auth/me; per IP,
+//	        RATE_LIMIT_DEFAULT_REQUESTS per RATE_LIMIT_DEFAULT_WINDOW (60/1m),
+//	        0 disables it
+//
+// The global handler runs before Authenticate, so its rules can only count per
+// IP. Limit(rule) mounts a single rule on a route; placed after Authenticate,
+// RateLimitByUserOrIP counts per user or API key instead of per IP:
+//
+//	jobs.Post("/", authMiddleware.Authenticate(), limiter.Limit(auth.RateLimitRule{
+//		Name: "jobs", Limit: 30, Window: time.Minute, KeyBy: auth.RateLimitByUserOrIP,
+//	}), handler)
+//
+// Every
+// limited response carries X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (seconds); rejections return AUTH.RATE_LIMITED with
+// Retry-After. With RATE_LIMIT_FAIL_OPEN=true (default) requests pass when
+// Redis is unreachable.
+//
+// Per-IP rules use c.IP(). Behind a load balancer set SERVER_PROXY_HEADER
+// (e.g. X-Real-IP) and SERVER_TRUSTED_PROXIES to the balancer's addresses;
+// the header is ignored on requests from anywhere else, and without it all
+// clients share the balancer's bucket.
+//
+// # Idempotency
+//
+// POST /invitations and POST /api-keys accept an Idempotency-Key header
+// (IDEMPOTENCY_ENABLED, default true; requires Redis). Keys are scoped per
+// tenant and caller (user or API key): another caller reusing a key gets a
+// request of its own, never the stored response. Redis only holds a hash of
+// the key and the response sealed with AES-GCM under a key derived from it,
+// so the raw API key secret a replay returns is never stored in clear. Use
+// random keys (e.g. UUIDs). The first request reserves the key and its response is stored for
+// IDEMPOTENCY_TTL (24h); a retry with the same key, method, path and body gets
+// that response back with Idempotent-Replayed: true instead of creating a
+// duplicate. Reusing the key with a different body returns
+// AUTH.IDEMPOTENCY_KEY_REUSED, and a retry while the first request is still
+// running AUTH.IDEMPOTENCY_IN_PROGRESS. 5xx responses are not stored, so they
+// can be retried with the same key. If Redis fails the request is processed
+// without idempotency.
+//
+// Other routes can mount it after Authenticate:
+//
+//	idempotency := auth.NewIdempotencyMiddleware(authinfra.NewRedisIdempotencyStore(redisClient), cfg.Auth.Idempotency.TTL)
+//	jobs.Post("/", authMiddleware.Authenticate(), idempotency.Handler(), handler)
 //
 // # State Management
 //
@@ -613,14 +1739,96 @@
 //
 //	// In-memory (default)
 //	stateMgr := auth.NewInMemoryStateManager(10 * time.Minute)
+//	defer stateMgr.Close()
 //
 //	// Redis (production)
 //	stateMgr := authinfra.NewRedisStateManager(redisClient, 10*time.Minute)
 //
+// The in-memory manager runs a janitor goroutine that evicts expired states
+// every TTL (clamped to 100ms–5m); Close stops it. The IAM container stops it
+// on shutdown from StartBackgroundServices. Expired and unknown states both
+// fail GetStateData with AUTH_INVALID_STATE.
+//
+// States are single-use: GetStateData atomically reads and deletes the state
+// and leaves a "used" marker until it would have expired, so a replayed
+// callback fails with AUTH_STATE_ALREADY_USED instead of being accepted twice
+// (Redis does this in one Lua script, keys oauth_state:<state> and
+// oauth_state_used:<state>).
+//
 // # Background Cleanup
 //
 // Use CleanupService to periodically remove expired tokens and sessions:
 //
 //	cleanup := authinfra.NewCleanupService(tokenRepo, sessionRepo, passwordResetRepo, 1*time.Hour)
 //	go cleanup.Start(ctx)
+//
+// WithExpiredAPIKeyDeactivation(apiKeyRepo) also deactivates API keys past
+// their expires_at on each pass; the container enables it by default.
+// WithTenantExpiry(tenantRepo) moves tenants with a lapsed trial or
+// subscription to EXPIRED; the container adds it when TENANT_EXPIRE_LAPSED=true.
+//
+// In multi-instance deployments set SESSION_CLEANUP_MODE=leader so a Redis lock
+// lets a single instance run each pass, and SESSION_CLEANUP_JITTER to spread
+// passes out:
+//
+//	cleanup := authinfra.NewCleanupService(tokenRepo, sessionRepo, passwordResetRepo, 1*time.Hour,
+//		authinfra.WithLeaderLock(redisClient, 0),
+//		authinfra.WithJitter(30*time.Second),
+//	)
+//
+// With sliding expiration, WithIdleSessionExpiry(idleTimeout) also deletes
+// sessions without activity for longer than idleTimeout; the container adds it
+// when SESSION_SLIDING_EXPIRATION=true.
+//
+// # Transactions
+//
+// Flows that write several repositories run them in one dbx.Transactor unit
+// of work, so a failure midway rolls every write back. Creating an account,
+// through OAuth or POST /auth/passwordless/signup/initiate, saves the user,
+// increments the tenant user count and accepts the invitation together:
+//
+//	err := transactor.WithinTx(ctx, func(ctx context.Context) error {
+//		if err := userRepo.Save(ctx, newUser); err != nil {
+//			return err
+//		}
+//		if err := tenantEntity.AddUser(); err != nil {
+//			return err
+//		}
+//		if err := tenantRepo.Save(ctx, *tenantEntity); err != nil {
+//			return err
+//		}
+//		return invitationRepo.Save(ctx, *inv)
+//	})
+//
+// Postgres repositories take part through the transaction stored in ctx; a
+// nested WithinTx joins the outer one instead of committing on its own.
+//
+// # Transactional Outbox
+//
+// Invitation emails and OTP codes are side effects of a database write. With
+// OUTBOX_ENABLED=true (default) they are stored in outbox_messages inside the
+// transaction of that write, so they exist only if the write commits:
+//
+//	err := outboxSvc.WithinTx(ctx, func(ctx context.Context) error {
+//		if err := invitationRepo.Save(ctx, inv); err != nil {
+//			return err
+//		}
+//		return outboxSvc.Enqueue(ctx, outbox.KindInvitationEmail, email)
+//	})
+//
+// Postgres repositories join the transaction carried by ctx (see dbx.WithTx);
+// the Redis OTP store does not. outboxsrv.Dispatcher, started next to
+// CleanupService, polls every OUTBOX_POLL_INTERVAL and delivers each message
+// through the handler registered for its kind. Delivery is at least once:
+// messages are claimed with FOR UPDATE SKIP LOCKED and hidden for OUTBOX_LEASE,
+// so several instances can run the dispatcher. A failed delivery is retried
+// after OUTBOX_RETRY_BASE_DELAY, doubling up to OUTBOX_RETRY_MAX_DELAY, and is
+// marked FAILED after OUTBOX_MAX_ATTEMPTS. Sent messages are purged after
+// OUTBOX_RETENTION. Handlers drop messages that no longer apply: expired OTP
+// codes and invitations that were revoked or got a new token.
+//
+// OTP messages carry only the OTP's ID; the code is read from the OTP store at
+// delivery and the message is dropped if a newer code replaced it. Payloads
+// are cleared once a message is SENT or FAILED, so invitation links don't
+// outlive their delivery in outbox_messages.
 package iam
@@ -38,6 +38,8 @@ const (
 	OAuthProviderGoogle    OAuthProvider = "GOOGLE"
 	OAuthProviderMicrosoft OAuthProvider = "MICROSOFT"
 	OAuthProviderAuth0     OAuthProvider = "AUTH0"
+	// OAuthProviderSSO is a tenant's own OpenID Connect connection
+	OAuthProviderSSO OAuthProvider = "SSO"
 )
 
 // GetProviderName returns the human-readable provider name
@@ -49,6 +51,8 @@ func (p OAuthProvider) GetProviderName() string {
 		return "Microsoft"
 	case OAuthProviderAuth0:
 		return "Auth0"
+	case OAuthProviderSSO:
+		return "Enterprise SSO"
 	default:
 		return "Unknown"
 	}
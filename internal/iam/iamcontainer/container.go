@@ -3,14 +3,22 @@ package iamcontainer
 import (
 	"context"
 
+	"github.com/Abraxas-365/manifesto/internal/asyncx"
 	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/fsx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey/apikeyapi"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey/apikeyinfra"
 	"github.com/Abraxas-365/manifesto/internal/iam/apikey/apikeysrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit/auditapi"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit/auditinfra"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit/auditsrv"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth/authinfra"
+	"github.com/Abraxas-365/manifesto/internal/iam/dataexport/dataexportapi"
+	"github.com/Abraxas-365/manifesto/internal/iam/dataexport/dataexportsrv"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation/invitationapi"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation/invitationinfra"
@@ -18,10 +26,22 @@ import (
 	"github.com/Abraxas-365/manifesto/internal/iam/otp"
 	"github.com/Abraxas-365/manifesto/internal/iam/otp/otpinfra"
 	"github.com/Abraxas-365/manifesto/internal/iam/otp/otpsrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox/outboxinfra"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox/outboxsrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/role/roleapi"
+	"github.com/Abraxas-365/manifesto/internal/iam/role/roleinfra"
+	"github.com/Abraxas-365/manifesto/internal/iam/role/rolesrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant/tenantapi"
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant/tenantinfra"
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant/tenantsrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userapi"
 	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
 	"github.com/Abraxas-365/manifesto/internal/iam/user/usersrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook/webhookapi"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook/webhookinfra"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook/webhooksrv"
 	"github.com/Abraxas-365/manifesto/internal/logx"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
@@ -41,9 +61,24 @@ type Deps struct {
 	// IAM module has zero knowledge of the concrete notification implementation.
 	OTPNotifier otp.NotificationService
 
-	// InvitationNotifier sends invitation emails when new invitations are created.
+	// OTPSMSNotifier sends OTP codes to E.164 phone numbers (e.g. otpinfra.TwilioNotifier).
+	// If nil, phone-based passwordless login is disabled.
+	OTPSMSNotifier otp.NotificationService
+
+	// InvitationNotifier sends invitation emails on create and resend
+	// (e.g. invitationinfra.SMTPNotifier, SESNotifier or ConsoleNotifier).
 	// If nil, no emails are sent (invitations are still created).
-	InvitationNotifier invitation.NotificationService
+	InvitationNotifier invitation.InvitationNotifier
+
+	// PasswordResetNotifier sends password reset links
+	// (e.g. authinfra.EmailPasswordResetNotifier).
+	// If nil, reset tokens are created but no email is sent.
+	PasswordResetNotifier auth.PasswordResetNotifier
+
+	// ProfilePhotoStorage stores the profile photos fetched from IdPs
+	// (OAUTH_MICROSOFT_FETCH_PHOTO), served from OAUTH_PROFILE_PHOTO_BASE_URL.
+	// If nil, photos are inlined in the user's picture as data URLs.
+	ProfilePhotoStorage fsx.FileSystem
 }
 
 // ---------------------------------------------------------------------------
@@ -56,25 +91,49 @@ type Container struct {
 	// Services — available for cross-module consumption via interfaces
 	UserService       *usersrv.UserService
 	TenantService     *tenantsrv.TenantService
+	SSOService        *tenantsrv.SSOConnectionService
 	InvitationService *invitationsrv.InvitationService
 	APIKeyService     *apikeysrv.APIKeyService
 	OTPService        *otpsrv.OTPService
+	RoleService       *rolesrv.RoleService
+	AuditService      *auditsrv.AuditService
 	TokenService      auth.TokenService
+	SessionService    *auth.SessionService
+	WebhookService    *webhooksrv.WebhookService // nil when webhooks are disabled
+	QuotaService      *tenantsrv.QuotaService    // nil without Redis
+	DataExportService *dataexportsrv.DataExportService
 
 	// Auth handlers — needed by cmd/ to register routes
 	OAuthHandlers        *auth.AuthHandlers
 	PasswordlessHandlers *auth.PasswordlessAuthHandlers
+	PasswordHandlers     *auth.PasswordAuthHandlers
+	SessionHandlers      *auth.SessionHandlers
+	LoginMethodHandlers  *auth.LoginMethodHandlers
+	JWKSHandlers         *auth.JWKSHandlers // nil unless RS256 / ES256 keys are configured
 
 	// API handlers — needed by cmd/ to register routes
 	APIKeyHandlers     *apikeyapi.APIKeyHandlers
 	InvitationHandlers *invitationapi.InvitationHandlers
+	RoleHandlers       *roleapi.RoleHandlers
+	UserHandlers       *userapi.UserHandlers
+	AuditHandlers      *auditapi.AuditHandlers
+	WebhookHandlers    *webhookapi.WebhookHandlers // nil when webhooks are disabled
+	DataExportHandlers *dataexportapi.DataExportHandlers
+	SSOHandlers        *tenantapi.SSOHandlers
 
 	// Middleware — needed by cmd/ to protect route groups
-	AuthMiddleware        *auth.TokenMiddleware
-	UnifiedAuthMiddleware *auth.UnifiedAuthMiddleware
+	AuthMiddleware         *auth.TokenMiddleware
+	UnifiedAuthMiddleware  *auth.UnifiedAuthMiddleware
+	EntitlementMiddleware  *auth.EntitlementMiddleware
+	SubscriptionMiddleware *auth.SubscriptionMiddleware // rejects lapsed tenants; mount after Authenticate
 
 	// Background services
-	CleanupService *authinfra.CleanupService
+	CleanupService   *authinfra.CleanupService
+	OutboxDispatcher *outboxsrv.Dispatcher // nil when the outbox is disabled
+
+	// memoryStates is the in-memory OAuth state manager, nil with Redis. Its
+	// janitor is stopped on shutdown by StartBackgroundServices.
+	memoryStates *auth.InMemoryStateManager
 }
 
 // ---------------------------------------------------------------------------
@@ -91,13 +150,32 @@ func New(deps Deps) *Container {
 
 	tenantRepo := tenantinfra.NewPostgresTenantRepository(deps.DB)
 	tenantConfigRepo := tenantinfra.NewPostgresTenantConfigRepository(deps.DB)
+	ssoConnectionRepo := tenantinfra.NewPostgresSSOConnectionRepository(deps.DB, deps.Cfg.OAuth.SSOSecretKey)
 	userRepo := userinfra.NewPostgresUserRepository(deps.DB)
 	tokenRepo := authinfra.NewPostgresTokenRepository(deps.DB)
 	sessionRepo := authinfra.NewPostgresSessionRepository(deps.DB)
 	passwordResetRepo := authinfra.NewPostgresPasswordResetRepository(deps.DB)
 	invitationRepo := invitationinfra.NewPostgresInvitationRepository(deps.DB)
 	apiKeyRepo := apikeyinfra.NewPostgresAPIKeyRepository(deps.DB)
-	otpRepo := otpinfra.NewPostgresOTPRepository(deps.DB)
+	roleRepo := roleinfra.NewPostgresRoleRepository(deps.DB)
+	auditRepo := auditinfra.NewPostgresAuditRepository(deps.DB)
+	outboxRepo := outboxinfra.NewPostgresOutboxRepository(deps.DB)
+	webhookRepo := webhookinfra.NewPostgresWebhookRepository(deps.DB, deps.Cfg.Webhook.SecretKey)
+
+	// Tenant and user lookups by ID go through Redis; writes invalidate
+	if deps.Cfg.Auth.Cache.Enabled {
+		if deps.Redis != nil {
+			tenantRepo = tenantinfra.NewCachedTenantRepository(tenantRepo, deps.Redis, deps.Cfg.Auth.Cache.TTL)
+			userRepo = userinfra.NewCachedUserRepository(userRepo, deps.Redis, deps.Cfg.Auth.Cache.TTL)
+			logx.Info("  ✅ Tenant and user lookups cached in Redis")
+		} else {
+			logx.Warn("  ⚠️  IAM cache requires Redis, lookups hit Postgres")
+		}
+	}
+
+	if deps.Cfg.OAuth.SSOSecretKey == "" {
+		logx.Warn("  ⚠️  OAUTH_SSO_SECRET_KEY not set, tenant SSO connections are unavailable")
+	}
 
 	// ── Infrastructure services ──────────────────────────────────────────
 
@@ -106,13 +184,53 @@ func New(deps Deps) *Container {
 		stateManager = authinfra.NewRedisStateManager(deps.Redis, deps.Cfg.OAuth.StateManager.TTL)
 		logx.Info("  ✅ Using Redis state manager for OAuth")
 	} else {
-		stateManager = auth.NewInMemoryStateManager(deps.Cfg.OAuth.StateManager.TTL)
+		c.memoryStates = auth.NewInMemoryStateManager(deps.Cfg.OAuth.StateManager.TTL)
+		stateManager = c.memoryStates
 		logx.Warn("  ⚠️  Using in-memory state manager (not recommended for production)")
 	}
 
+	var otpRepo otp.Repository
+	if deps.Cfg.Auth.OTP.Store == "redis" && deps.Redis != nil {
+		otpRepo = otpinfra.NewRedisOTPRepository(deps.Redis)
+		logx.Info("  ✅ Using Redis OTP store")
+	} else {
+		if deps.Cfg.Auth.OTP.Store == "redis" {
+			logx.Warn("  ⚠️  OTP_STORE=redis but no Redis client provided, using Postgres OTP store")
+		}
+		otpRepo = otpinfra.NewPostgresOTPRepository(deps.DB)
+	}
+
 	passwordSvc := authinfra.NewBcryptPasswordService(deps.Cfg.Auth.Password.BcryptCost)
 
-	c.TokenService = auth.NewJWTServiceFromConfig(&deps.Cfg.Auth.JWT)
+	// Unit of work shared by flows that write several repositories at once
+	transactor := dbx.NewTransactor(deps.DB)
+
+	// Invitation emails and OTP codes go through the outbox unless disabled
+	var outboxSvc outbox.Outbox
+	if deps.Cfg.Auth.Outbox.Enabled {
+		outboxSvc = outboxsrv.NewOutboxService(
+			outboxRepo,
+			transactor,
+			&deps.Cfg.Auth.Outbox,
+		)
+		logx.Info("  ✅ Invitation emails and OTP codes delivered through the outbox")
+	}
+
+	signingKey, previousKeys, err := auth.LoadSigningKeys(&deps.Cfg.Auth.JWT)
+	if err != nil {
+		logx.Fatalf("Invalid JWT signing keys: %v", err)
+	}
+	jwtService := auth.NewJWTServiceFromConfig(
+		&deps.Cfg.Auth.JWT,
+		auth.WithStrictAudience(deps.Cfg.Auth.JWT.StrictAudience),
+		auth.WithSigningKeys(signingKey, previousKeys...),
+	)
+	c.TokenService = jwtService
+	logx.Infof("  ✅ JWTs signed with %s (kid %s, %d retired keys accepted)", signingKey.Method.Alg(), signingKey.ID, len(previousKeys))
+	if jwtService.HasPublicKeys() {
+		c.JWKSHandlers = auth.NewJWKSHandlers(jwtService)
+		logx.Info("  ✅ JWKS published at /.well-known/jwks.json")
+	}
 
 	apikey.InitAPIKeyConfig(
 		deps.Cfg.Auth.APIKey.LivePrefix,
@@ -122,6 +240,9 @@ func New(deps Deps) *Container {
 
 	// ── Domain services ──────────────────────────────────────────────────
 
+	// Audit first: the other services record their transitions through it
+	c.AuditService = auditsrv.NewAuditService(auditRepo)
+
 	c.TenantService = tenantsrv.NewTenantService(
 		tenantRepo,
 		tenantConfigRepo,
@@ -129,32 +250,108 @@ func New(deps Deps) *Container {
 		&deps.Cfg.TenantConfig,
 	)
 
-	c.UserService = usersrv.NewUserService(
-		userRepo,
-		tenantRepo,
-		passwordSvc,
-	)
+	c.SSOService = tenantsrv.NewSSOConnectionService(ssoConnectionRepo, tenantRepo)
+
+	// Quota counters live in Redis; without it quotas are not enforced
+	if deps.Redis != nil {
+		c.QuotaService = tenantsrv.NewQuotaService(c.TenantService, tenantinfra.NewRedisQuotaCounter(deps.Redis))
+		logx.Info("  ✅ Tenant quotas enforced with Redis")
+	} else {
+		logx.Warn("  ⚠️  Tenant quotas require Redis, quotas not enforced")
+	}
 
 	c.InvitationService = invitationsrv.NewInvitationService(
 		invitationRepo,
 		userRepo,
 		tenantRepo,
+		roleRepo,
 		deps.InvitationNotifier,
+		c.AuditService,
+		outboxSvc,
 		&deps.Cfg.Auth.Invitation,
+		invitationsrv.WithScopeNormalization(deps.Cfg.Auth.NormalizeScopes),
+	)
+
+	// Bulk imports go through the invitation service; erasure also clears the
+	// user's sessions, tokens, API keys, invitations and OTP codes
+	c.UserService = usersrv.NewUserService(
+		userRepo,
+		tenantRepo,
+		passwordSvc,
+		roleRepo,
+		c.AuditService,
+		c.InvitationService,
+		transactor,
+		invitationRepo,
+		sessionRepo,
+		tokenRepo,
+		passwordResetRepo,
+		otpRepo,
+		apiKeyRepo,
+		usersrv.WithScopeNormalization(deps.Cfg.Auth.NormalizeScopes),
 	)
 
+	var lastUsedThrottle apikey.LastUsedThrottle
+	if deps.Redis != nil {
+		lastUsedThrottle = apikeyinfra.NewRedisLastUsedThrottle(deps.Redis, deps.Cfg.Auth.APIKey.LastUsedInterval)
+		logx.Info("  ✅ API key last-used writes debounced with Redis")
+	}
+
 	c.APIKeyService = apikeysrv.NewAPIKeyService(
 		apiKeyRepo,
 		tenantRepo,
 		userRepo,
+		c.AuditService,
+		lastUsedThrottle,
+	)
+
+	var activityThrottle auth.ActivityThrottle
+	if deps.Redis != nil {
+		activityThrottle = authinfra.NewRedisActivityThrottle(deps.Redis, "session:activity:", deps.Cfg.Auth.Session.ActivityInterval)
+	} else {
+		activityThrottle = auth.NewInMemoryActivityThrottle(deps.Cfg.Auth.Session.ActivityInterval)
+	}
+
+	c.SessionService = auth.NewSessionService(
+		sessionRepo,
+		tokenRepo,
+		c.AuditService,
+		activityThrottle,
+		&deps.Cfg.Auth.Session,
+	)
+
+	c.RoleService = rolesrv.NewRoleService(
+		roleRepo,
+		tenantRepo,
+		&deps.Cfg.TenantConfig,
 	)
 
 	c.OTPService = otpsrv.NewOTPService(
 		otpRepo,
 		deps.OTPNotifier,
+		deps.OTPSMSNotifier,
+		outboxSvc,
 		&deps.Cfg.Auth.OTP,
 	)
 
+	// Webhooks receive every audit event; deliveries are retried by the outbox
+	switch {
+	case !deps.Cfg.Webhook.Enabled:
+	case outboxSvc == nil:
+		logx.Warn("  ⚠️  Webhooks require the outbox (OUTBOX_ENABLED), webhooks disabled")
+	case deps.Cfg.Webhook.SecretKey == "":
+		logx.Warn("  ⚠️  WEBHOOK_SECRET_KEY not set, webhooks disabled")
+	default:
+		c.WebhookService = webhooksrv.NewWebhookService(
+			webhookRepo,
+			outboxSvc,
+			webhooksrv.NewDispatcher(&deps.Cfg.Webhook),
+			&deps.Cfg.Webhook,
+		)
+		c.AuditService.Subscribe(c.WebhookService)
+		logx.Info("  ✅ Webhooks enabled")
+	}
+
 	// ── OAuth providers ──────────────────────────────────────────────────
 
 	oauthServices := make(map[iam.OAuthProvider]auth.OAuthService)
@@ -168,29 +365,51 @@ func New(deps Deps) *Container {
 	}
 
 	if deps.Cfg.OAuth.Microsoft.Enabled {
+		var photoStore auth.ProfilePhotoStore
+		if deps.Cfg.OAuth.Microsoft.FetchPhoto && deps.ProfilePhotoStorage != nil {
+			if deps.Cfg.OAuth.ProfilePhotoBaseURL != "" {
+				photoStore = authinfra.NewFSXProfilePhotoStore(deps.ProfilePhotoStorage, deps.Cfg.OAuth.ProfilePhotoBaseURL)
+			} else {
+				logx.Warn("  ⚠️  OAUTH_PROFILE_PHOTO_BASE_URL not set; Microsoft photos will be inlined as data URLs")
+			}
+		}
+
 		oauthServices[iam.OAuthProviderMicrosoft] = auth.NewMicrosoftOAuthServiceFromConfig(
 			&deps.Cfg.OAuth.Microsoft,
 			stateManager,
+			photoStore,
 		)
 		logx.Info("  ✅ Microsoft OAuth enabled")
 	}
 
-	// ── Audit service ────────────────────────────────────────────────────
+	for i := range deps.Cfg.OAuth.OIDC {
+		oidcCfg := &deps.Cfg.OAuth.OIDC[i]
+		oidcService, err := auth.NewGenericOIDCOAuthServiceFromConfig(context.Background(), oidcCfg, stateManager)
+		if err != nil {
+			logx.Warnf("  ⚠️  OIDC provider %s disabled: %v", oidcCfg.Key, err)
+			continue
+		}
+		oauthServices[oidcService.GetProvider()] = oidcService
+		logx.Infof("  ✅ OIDC provider %s enabled (issuer: %s)", oidcCfg.Key, oidcCfg.IssuerURL)
+	}
 
-	auditService := authinfra.NewLogxAuditService()
+	// Tenant SSO connections are resolved per login on top of the global providers
+	oauthResolver := auth.NewOAuthProviderResolver(oauthServices, ssoConnectionRepo, stateManager)
 
 	// ── Auth handlers ────────────────────────────────────────────────────
 
 	c.OAuthHandlers = auth.NewAuthHandlers(
-		oauthServices,
+		oauthResolver,
 		c.TokenService,
 		userRepo,
 		tenantRepo,
+		tenantConfigRepo,
 		tokenRepo,
 		sessionRepo,
 		stateManager,
 		invitationRepo,
-		auditService,
+		c.AuditService,
+		transactor,
 		deps.Cfg,
 	)
 
@@ -202,35 +421,145 @@ func New(deps Deps) *Container {
 		sessionRepo,
 		invitationRepo,
 		c.OTPService,
-		auditService,
+		c.AuditService,
+		oauthResolver,
+		transactor,
 		deps.Cfg,
 	)
 
+	passwordCfg := deps.Cfg.Auth.Password
+	c.PasswordHandlers = auth.NewPasswordAuthHandlers(
+		userRepo,
+		passwordSvc,
+		user.PasswordPolicy{
+			MinLength:        passwordCfg.MinLength,
+			RequireUppercase: passwordCfg.RequireUppercase,
+			RequireLowercase: passwordCfg.RequireLowercase,
+			RequireDigit:     passwordCfg.RequireDigit,
+			RequireSymbol:    passwordCfg.RequireSymbol,
+		},
+		c.AuditService,
+		tokenRepo,
+		sessionRepo,
+		passwordResetRepo,
+		deps.PasswordResetNotifier,
+		&deps.Cfg.Auth.PasswordReset,
+		c.PasswordlessHandlers,
+	)
+
+	c.LoginMethodHandlers = auth.NewLoginMethodHandlers(userRepo, c.OTPService, c.AuditService)
+
 	// ── API handlers ─────────────────────────────────────────────────────
 
-	c.APIKeyHandlers = apikeyapi.NewAPIKeyHandlers(c.APIKeyService)
-	c.InvitationHandlers = invitationapi.NewInvitationHandlers(c.InvitationService)
+	c.DataExportService = dataexportsrv.NewDataExportService(
+		tenantRepo,
+		userRepo,
+		invitationRepo,
+		apiKeyRepo,
+		sessionRepo,
+		auditRepo,
+		c.AuditService,
+	)
+
+	// Idempotency-Key on invitation and API key creation
+	var idempotency *auth.IdempotencyMiddleware
+	if deps.Cfg.Auth.Idempotency.Enabled {
+		if deps.Redis != nil {
+			idempotency = auth.NewIdempotencyMiddleware(authinfra.NewRedisIdempotencyStore(deps.Redis), deps.Cfg.Auth.Idempotency.TTL)
+			logx.Info("  ✅ Idempotency-Key enabled for invitation and API key creation")
+		} else {
+			logx.Warn("  ⚠️  Idempotency-Key requires Redis, header ignored")
+		}
+	}
+
+	c.APIKeyHandlers = apikeyapi.NewAPIKeyHandlers(c.APIKeyService, idempotency)
+	c.InvitationHandlers = invitationapi.NewInvitationHandlers(c.InvitationService, idempotency)
+	c.RoleHandlers = roleapi.NewRoleHandlers(c.RoleService)
+	c.UserHandlers = userapi.NewUserHandlers(c.UserService)
+	c.AuditHandlers = auditapi.NewAuditHandlers(c.AuditService)
+	c.SessionHandlers = auth.NewSessionHandlers(c.SessionService)
+	c.DataExportHandlers = dataexportapi.NewDataExportHandlers(c.DataExportService)
+	c.SSOHandlers = tenantapi.NewSSOHandlers(c.SSOService)
+	if c.WebhookService != nil {
+		c.WebhookHandlers = webhookapi.NewWebhookHandlers(c.WebhookService)
+	}
 
 	// ── Middleware ────────────────────────────────────────────────────────
 
 	c.AuthMiddleware = auth.NewAuthMiddleware(c.TokenService)
-	c.UnifiedAuthMiddleware = auth.NewAPIKeyMiddleware(c.APIKeyService, c.TokenService)
+	c.UnifiedAuthMiddleware = auth.NewAPIKeyMiddleware(c.APIKeyService, c.TokenService, c.SessionService)
+
+	// A nil *QuotaService must not become a non-nil QuotaChecker
+	var quotaChecker auth.QuotaChecker
+	if c.QuotaService != nil {
+		quotaChecker = c.QuotaService
+	}
+	c.EntitlementMiddleware = auth.NewEntitlementMiddleware(c.TenantService, quotaChecker)
+	c.SubscriptionMiddleware = auth.NewSubscriptionMiddleware(
+		tenantRepo,
+		deps.Cfg.TenantConfig.SubscriptionCacheTTL,
+		deps.Cfg.TenantConfig.SubscriptionAllowedPaths...,
+	)
 
 	// ── Background services ──────────────────────────────────────────────
 
+	cleanupOpts := []authinfra.CleanupOption{
+		authinfra.WithJitter(deps.Cfg.Auth.Session.CleanupJitter),
+		authinfra.WithExpiredAPIKeyDeactivation(apiKeyRepo),
+	}
+	if deps.Cfg.TenantConfig.ExpireLapsed {
+		cleanupOpts = append(cleanupOpts, authinfra.WithTenantExpiry(tenantRepo))
+	}
+	if deps.Cfg.Auth.Session.SlidingExpiration {
+		cleanupOpts = append(cleanupOpts, authinfra.WithIdleSessionExpiry(deps.Cfg.Auth.Session.ExpirationTime))
+		logx.Info("  ✅ Sliding session expiration enabled")
+	}
+	if deps.Cfg.Auth.Session.CleanupMode == "leader" {
+		if deps.Redis != nil {
+			cleanupOpts = append(cleanupOpts, authinfra.WithLeaderLock(deps.Redis, deps.Cfg.Auth.Session.CleanupLockTTL))
+			logx.Info("  ✅ Cleanup runs on a single instance (Redis leader lock)")
+		} else {
+			logx.Warn("  ⚠️  Cleanup leader mode requires Redis, every instance will run cleanup")
+		}
+	}
+
 	c.CleanupService = authinfra.NewCleanupService(
 		tokenRepo,
 		sessionRepo,
 		passwordResetRepo,
 		deps.Cfg.Auth.Session.CleanupInterval,
+		cleanupOpts...,
 	)
 
+	if outboxSvc != nil {
+		c.OutboxDispatcher = outboxsrv.NewDispatcher(outboxRepo, &deps.Cfg.Auth.Outbox)
+		c.OutboxDispatcher.Register(outbox.KindInvitationEmail, c.InvitationService.DeliverInvitationEmail)
+		c.OutboxDispatcher.Register(outbox.KindOTPCode, c.OTPService.DeliverOTP)
+		if c.WebhookService != nil {
+			c.OutboxDispatcher.Register(outbox.KindWebhookEvent, c.WebhookService.FanOutEvent)
+			c.OutboxDispatcher.Register(outbox.KindWebhookDelivery, c.WebhookService.DeliverWebhook)
+		}
+	}
+
 	logx.Info("✅ IAM container initialized")
 	return c
 }
 
-// StartBackgroundServices starts IAM-specific background workers.
-func (c *Container) StartBackgroundServices(ctx context.Context) {
-	go c.CleanupService.Start(ctx)
+// StartBackgroundServices starts IAM-specific background workers, registered
+// in workers so shutdown can wait for them.
+func (c *Container) StartBackgroundServices(ctx context.Context, workers *asyncx.Workers) {
+	workers.Go(ctx, "iam.cleanup", c.CleanupService.Start)
 	logx.Info("  ✅ IAM cleanup service started")
+
+	if c.OutboxDispatcher != nil {
+		workers.Go(ctx, "iam.outbox", c.OutboxDispatcher.Start)
+		logx.Info("  ✅ IAM outbox dispatcher started")
+	}
+
+	if c.memoryStates != nil {
+		workers.Go(ctx, "iam.oauth_states", func(ctx context.Context) {
+			<-ctx.Done()
+			c.memoryStates.Close()
+		})
+	}
 }
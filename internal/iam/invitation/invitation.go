@@ -3,10 +3,12 @@ package invitation
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"math"
 	"net/http"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
 	"slices"
 )
@@ -27,18 +29,19 @@ const (
 
 // Invitation es la entidad que representa una invitación de usuario
 type Invitation struct {
-	ID         string           `db:"id" json:"id"`
-	TenantID   kernel.TenantID  `db:"tenant_id" json:"tenant_id"`
-	Email      string           `db:"email" json:"email"`
-	Token      string           `db:"token" json:"token"`
-	Scopes     []string         `db:"scopes" json:"scopes"` // ✅ Changed from RoleID
-	Status     InvitationStatus `db:"status" json:"status"`
-	InvitedBy  kernel.UserID    `db:"invited_by" json:"invited_by"`
-	ExpiresAt  time.Time        `db:"expires_at" json:"expires_at"`
-	AcceptedAt *time.Time       `db:"accepted_at" json:"accepted_at,omitempty"`
-	AcceptedBy *kernel.UserID   `db:"accepted_by" json:"accepted_by,omitempty"`
-	CreatedAt  time.Time        `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time        `db:"updated_at" json:"updated_at"`
+	ID              string           `db:"id" json:"id"`
+	TenantID        kernel.TenantID  `db:"tenant_id" json:"tenant_id"`
+	Email           string           `db:"email" json:"email"`
+	Token           string           `db:"token" json:"token"`
+	Scopes          []string         `db:"scopes" json:"scopes"`                               // ✅ Changed from RoleID
+	RequestedScopes []string         `db:"requested_scopes" json:"requested_scopes,omitempty"` // antes de normalizar; nil si no cambiaron
+	Status          InvitationStatus `db:"status" json:"status"`
+	InvitedBy       kernel.UserID    `db:"invited_by" json:"invited_by"`
+	ExpiresAt       time.Time        `db:"expires_at" json:"expires_at"`
+	AcceptedAt      *time.Time       `db:"accepted_at" json:"accepted_at,omitempty"`
+	AcceptedBy      *kernel.UserID   `db:"accepted_by" json:"accepted_by,omitempty"`
+	CreatedAt       time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time        `db:"updated_at" json:"updated_at"`
 }
 
 // ============================================================================
@@ -69,6 +72,12 @@ func (i *Invitation) GetScopes() []string {
 	return i.Scopes
 }
 
+// GetRequestedScopes retorna los scopes pedidos antes de normalizar, o nil si
+// la normalización no los cambió; pasan al usuario al aceptar la invitación
+func (i *Invitation) GetRequestedScopes() []string {
+	return i.RequestedScopes
+}
+
 // IsValid verifica si la invitación es válida
 func (i *Invitation) IsValid() bool {
 	return i.Status == InvitationStatusPending && time.Now().Before(i.ExpiresAt)
@@ -116,6 +125,41 @@ func (i *Invitation) Revoke() error {
 	return nil
 }
 
+// Resend renueva una invitación pendiente para reenviarla: extiende su
+// expiración y, si newToken no está vacío, reemplaza el token (invalidando el
+// enlace anterior)
+func (i *Invitation) Resend(newToken string, expiresAt time.Time) error {
+	if !i.CanBeAccepted() {
+		if i.Status == InvitationStatusPending && i.IsExpired() {
+			return ErrInvitationExpired()
+		}
+		return ErrInvitationInvalid().WithDetail("status", string(i.Status))
+	}
+
+	if newToken != "" {
+		i.Token = newToken
+	}
+	i.ExpiresAt = expiresAt
+	i.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// ResendAvailableIn retorna cuánto falta para poder reenviar la invitación.
+// Una invitación pendiente solo se modifica al crearse o reenviarse, por lo
+// que UpdatedAt marca el último envío.
+func (i *Invitation) ResendAvailableIn(cooldown time.Duration) time.Duration {
+	remaining := time.Until(i.UpdatedAt.Add(cooldown))
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// PageCursor retorna la posición de la invitación para la paginación por cursor
+func (i *Invitation) PageCursor() kernel.Cursor {
+	return kernel.Cursor{CreatedAt: i.CreatedAt, ID: i.ID}
+}
+
 // MarkAsExpired marca la invitación como expirada
 func (i *Invitation) MarkAsExpired() {
 	if i.Status == InvitationStatusPending && i.IsExpired() {
@@ -126,12 +170,7 @@ func (i *Invitation) MarkAsExpired() {
 
 // HasScope verifica si la invitación incluye un scope específico
 func (i *Invitation) HasScope(scope string) bool {
-	for _, s := range i.Scopes {
-		if s == scope || s == "*" {
-			return true
-		}
-	}
-	return false
+	return scopes.HasScopeIn(i.Scopes, scope)
 }
 
 // HasAnyScope verifica si la invitación incluye alguno de los scopes
@@ -181,6 +220,26 @@ type CreateInvitationRequest struct {
 	ExpiresIn     *int     `json:"expires_in,omitempty"`     // Días hasta expiración (default: 7)
 }
 
+// CreateInvitationResult es el resultado de crear una invitación. La
+// invitación queda guardada aunque falle el envío del email; en ese caso
+// EmailFailed es true y el admin puede reenviarla más tarde. Con el outbox
+// el email se entrega en background y EmailFailed siempre es false.
+type CreateInvitationResult struct {
+	Invitation  *Invitation
+	EmailFailed bool
+}
+
+// EmailMessage es el payload del outbox para el email de una invitación.
+// Se arma al encolar para que la entrega no dependa del estado del invitador.
+type EmailMessage struct {
+	InvitationID string `json:"invitation_id"`
+	Email        string `json:"email"`
+	Token        string `json:"token"`
+	TenantName   string `json:"tenant_name"`
+	InviterName  string `json:"inviter_name"`
+	AcceptURL    string `json:"accept_url"`
+}
+
 // AcceptInvitationRequest representa la petición para aceptar una invitación
 type AcceptInvitationRequest struct {
 	Token string `json:"token" validate:"required"`
@@ -206,29 +265,18 @@ type InvitationResponseDTO struct {
 	ScopeTemplates []string             `json:"scope_templates,omitempty"`
 }
 
-// InvitationListResponse para listas de invitaciones
-type InvitationListResponse struct {
-	Invitations []InvitationResponse `json:"invitations"`
-	Total       int                  `json:"total"`
-}
-
-// ToDTO convierte InvitationListResponse a InvitationListResponseDTO
-func (ilr *InvitationListResponse) ToDTO() InvitationListResponseDTO {
-	var invitationsDTO []InvitationResponseDTO
-	for _, inv := range ilr.Invitations {
-		invitationsDTO = append(invitationsDTO, inv.ToDTO())
-	}
+// InvitationSearchFilter filtros para listar las invitaciones de un tenant.
+// Los resultados se ordenan del más reciente al más antiguo (created_at, id).
+type InvitationSearchFilter struct {
+	Status *InvitationStatus `json:"status,omitempty"`
+	// Pending limita a invitaciones pendientes que aún no vencieron, aunque
+	// el job de limpieza todavía no las haya marcado como EXPIRED
+	Pending bool `json:"pending,omitempty"`
+	Limit   int  `json:"limit"`
 
-	return InvitationListResponseDTO{
-		Invitations: invitationsDTO,
-		Total:       ilr.Total,
-	}
-}
-
-// InvitationListResponseDTO es la versión DTO de InvitationListResponse
-type InvitationListResponseDTO struct {
-	Invitations []InvitationResponseDTO `json:"invitations"`
-	Total       int                     `json:"total"`
+	// Cursor retoma el listado después de esa invitación; el total sigue
+	// contando todas las invitaciones que cumplen el filtro
+	Cursor *kernel.Cursor `json:"-"`
 }
 
 // RevokeInvitationRequest para revocar una invitación
@@ -284,6 +332,9 @@ var (
 	CodeUserAlreadyExists         = ErrRegistry.Register("USER_ALREADY_EXISTS", errx.TypeConflict, http.StatusConflict, "User already exists in this tenant")
 	CodeInvalidScopeTemplate      = ErrRegistry.Register("INVALID_SCOPE_TEMPLATE", errx.TypeValidation, http.StatusBadRequest, "Scope template not found")
 	CodeInvalidScopes             = ErrRegistry.Register("INVALID_SCOPES", errx.TypeValidation, http.StatusBadRequest, "Invalid scopes")
+	CodeResendCooldown            = ErrRegistry.Register("RESEND_COOLDOWN", errx.TypeBusiness, http.StatusTooManyRequests, "Invitation was sent recently, please wait before resending")
+	CodeSendFailed                = ErrRegistry.Register("SEND_FAILED", errx.TypeExternal, http.StatusBadGateway, "Failed to send invitation email")
+	CodeTooManyPending            = ErrRegistry.Register("TOO_MANY_PENDING", errx.TypeBusiness, http.StatusConflict, "Too many pending invitations for this tenant")
 )
 
 // Helper functions
@@ -322,3 +373,41 @@ func ErrInvalidScopeTemplate() *errx.Error {
 func ErrInvalidScopes() *errx.Error {
 	return ErrRegistry.New(CodeInvalidScopes)
 }
+
+// ErrSendFailed envuelve el error del proveedor de email
+func ErrSendFailed(cause error) *errx.Error {
+	return ErrRegistry.NewWithCause(CodeSendFailed, cause)
+}
+
+// IsSendFailed indica si el error proviene del envío del email
+func IsSendFailed(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeSendFailed.Code
+}
+
+// IsInvitationNotFound indica si el error es ErrInvitationNotFound
+func IsInvitationNotFound(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeInvitationNotFound.Code
+}
+
+// ErrResendCooldown indica que la invitación se envió hace muy poco;
+// retry_after_seconds indica cuánto esperar
+func ErrResendCooldown(retryAfter time.Duration) *errx.Error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return ErrRegistry.New(CodeResendCooldown).WithDetail("retry_after_seconds", seconds)
+}
+
+// RetryAfter retorna la espera indicada por un error ErrResendCooldown
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != CodeResendCooldown.Code {
+		return 0, false
+	}
+	seconds, _ := e.Details["retry_after_seconds"].(int)
+	return time.Duration(seconds) * time.Second, true
+}
+
+func ErrTooManyPendingInvitations() *errx.Error {
+	return ErrRegistry.New(CodeTooManyPending)
+}
@@ -1,22 +1,31 @@
 package invitationapi
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/httpx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/iam/auth"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation/invitationsrv"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
 	"github.com/gofiber/fiber/v2"
 )
 
 // InvitationHandlers maneja las rutas de invitaciones con Fiber
 type InvitationHandlers struct {
-	service *invitationsrv.InvitationService
+	service     *invitationsrv.InvitationService
+	idempotency *auth.IdempotencyMiddleware // nil desactiva Idempotency-Key
 }
 
 // NewInvitationHandlers crea un nuevo handler de invitaciones
-func NewInvitationHandlers(service *invitationsrv.InvitationService) *InvitationHandlers {
+func NewInvitationHandlers(service *invitationsrv.InvitationService, idempotency *auth.IdempotencyMiddleware) *InvitationHandlers {
 	return &InvitationHandlers{
-		service: service,
+		service:     service,
+		idempotency: idempotency,
 	}
 }
 
@@ -25,12 +34,13 @@ func (h *InvitationHandlers) RegisterRoutes(router fiber.Router, authMiddleware
 	invitations := router.Group("/invitations", authMiddleware.Authenticate())
 
 	// Protected routes
-	invitations.Post("/", h.CreateInvitation)
+	invitations.Post("/", h.idempotency.Handler(), h.CreateInvitation)
 	invitations.Get("/", h.GetTenantInvitations)
 	invitations.Get("/pending", h.GetPendingInvitations)
 	invitations.Get("/:id", h.GetInvitationByID)
 	invitations.Delete("/:id", h.DeleteInvitation)
 	invitations.Post("/:id/revoke", h.RevokeInvitation)
+	invitations.Post("/:id/resend", h.ResendInvitation)
 
 	// Public routes
 	public := router.Group("/invitations/public")
@@ -48,10 +58,8 @@ func (h *InvitationHandlers) CreateInvitation(c *fiber.Ctx) error {
 	}
 
 	var req invitation.CreateInvitationRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := httpx.Bind(c, &req); err != nil {
+		return err
 	}
 
 	if authContext.UserID == nil {
@@ -59,17 +67,29 @@ func (h *InvitationHandlers) CreateInvitation(c *fiber.Ctx) error {
 	}
 
 	// Crear invitación
-	inv, err := h.service.CreateInvitation(c.Context(), authContext.TenantID, *authContext.UserID, req)
+	result, err := h.service.CreateInvitation(auth.AuditContext(c), authContext.TenantID, *authContext.UserID, req)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(inv.ToDTO())
+	// La invitación se creó pero el email no salió: el admin puede reenviarla
+	if result.EmailFailed {
+		return c.Status(fiber.StatusPartialContent).JSON(fiber.Map{
+			"error":      "Invitation created but failed to send the invitation email",
+			"message":    "Please try again using the resend option",
+			"invitation": result.Invitation.ToDTO(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result.Invitation.ToDTO())
 }
 
-// GetTenantInvitations obtiene todas las invitaciones del tenant
+// GetTenantInvitations lista las invitaciones del tenant como kernel.Page, de
+// la más reciente a la más antigua.
+//
+// Query params: status, limit, cursor (el next_cursor de la página anterior).
 func (h *InvitationHandlers) GetTenantInvitations(c *fiber.Ctx) error {
 	authContext, ok := auth.GetAuthContext(c)
 	if !ok {
@@ -78,17 +98,23 @@ func (h *InvitationHandlers) GetTenantInvitations(c *fiber.Ctx) error {
 		})
 	}
 
-	invitations, err := h.service.GetTenantInvitations(c.Context(), authContext.TenantID)
+	filter, err := parseListFilter(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.service.GetTenantInvitations(c.Context(), authContext.TenantID, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(invitations.ToDTO())
+	return c.JSON(page)
 }
 
-// GetPendingInvitations obtiene invitaciones pendientes del tenant
+// GetPendingInvitations lista las invitaciones pendientes y vigentes del
+// tenant, con los mismos query params que GetTenantInvitations salvo status
 func (h *InvitationHandlers) GetPendingInvitations(c *fiber.Ctx) error {
 	authContext, ok := auth.GetAuthContext(c)
 	if !ok {
@@ -97,14 +123,45 @@ func (h *InvitationHandlers) GetPendingInvitations(c *fiber.Ctx) error {
 		})
 	}
 
-	invitations, err := h.service.GetPendingInvitations(c.Context(), authContext.TenantID)
+	filter, err := parseListFilter(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.service.GetPendingInvitations(c.Context(), authContext.TenantID, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(invitations.ToDTO())
+	return c.JSON(page)
+}
+
+// parseListFilter lee los query params de los listados de invitaciones
+func parseListFilter(c *fiber.Ctx) (invitation.InvitationSearchFilter, error) {
+	filter := invitation.InvitationSearchFilter{
+		Limit: c.QueryInt("limit", 0),
+	}
+
+	if raw := c.Query("status"); raw != "" {
+		status := invitation.InvitationStatus(strings.ToUpper(raw))
+		switch status {
+		case invitation.InvitationStatusPending, invitation.InvitationStatusAccepted,
+			invitation.InvitationStatusExpired, invitation.InvitationStatusRevoked:
+			filter.Status = &status
+		default:
+			return filter, errx.Validation("invalid status").WithDetail("status", raw)
+		}
+	}
+
+	cursor, err := kernel.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Cursor = cursor
+
+	return filter, nil
 }
 
 // GetInvitationByID obtiene una invitación por ID
@@ -187,7 +244,7 @@ func (h *InvitationHandlers) RevokeInvitation(c *fiber.Ctx) error {
 		})
 	}
 
-	err := h.service.RevokeInvitation(c.Context(), invitationID, authContext.TenantID)
+	err := h.service.RevokeInvitation(auth.AuditContext(c), invitationID, authContext.TenantID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -199,6 +256,46 @@ func (h *InvitationHandlers) RevokeInvitation(c *fiber.Ctx) error {
 	})
 }
 
+// ResendInvitation reenvía una invitación pendiente. Si se envió hace poco
+// responde 429 con Retry-After y retry_after_seconds.
+func (h *InvitationHandlers) ResendInvitation(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	invitationID := c.Params("id")
+	if invitationID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invitation_id is required",
+		})
+	}
+
+	inv, err := h.service.ResendInvitation(auth.AuditContext(c), invitationID, authContext.TenantID)
+	if err != nil {
+		if retryAfter, ok := invitation.RetryAfter(err); ok {
+			seconds := int(retryAfter / time.Second)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":               err.Error(),
+				"retry_after_seconds": seconds,
+			})
+		}
+		if invitation.IsSendFailed(err) {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(inv.ToDTO())
+}
+
 // DeleteInvitation elimina una invitación
 func (h *InvitationHandlers) DeleteInvitation(c *fiber.Ctx) error {
 	authContext, ok := auth.GetAuthContext(c)
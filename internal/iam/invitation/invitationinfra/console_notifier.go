@@ -0,0 +1,46 @@
+package invitationinfra
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+	"github.com/Abraxas-365/manifesto/internal/notifx/notifxconsole"
+)
+
+// ConsoleNotifier logs invitation emails instead of sending them. Intended for
+// development: the accept URL is printed so the flow can be completed locally.
+type ConsoleNotifier struct {
+	sender notifx.EmailSender
+	cfg    *config.EmailConfig
+}
+
+// NewConsoleNotifier creates an invitation notifier that writes to the log
+func NewConsoleNotifier(cfg *config.EmailConfig) *ConsoleNotifier {
+	return &ConsoleNotifier{
+		sender: notifxconsole.NewConsoleProvider(),
+		cfg:    cfg,
+	}
+}
+
+// SendInvitation renders the invitation email and logs it
+func (n *ConsoleNotifier) SendInvitation(ctx context.Context, email, token, tenantName, inviterName, acceptURL string) error {
+	msg, err := buildInvitationEmail(n.cfg, email, tenantName, inviterName, acceptURL)
+	if err != nil {
+		return invitation.ErrSendFailed(err).WithDetail("provider", "console")
+	}
+
+	logx.WithFields(logx.Fields{
+		"email":      email,
+		"tenant":     tenantName,
+		"accept_url": acceptURL,
+	}).Info("invitation email (console)")
+
+	if err := n.sender.SendEmail(ctx, msg); err != nil {
+		return invitation.ErrSendFailed(err).WithDetail("provider", "console")
+	}
+
+	return nil
+}
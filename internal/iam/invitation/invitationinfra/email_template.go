@@ -0,0 +1,78 @@
+package invitationinfra
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+)
+
+var invitationEmailHTML = htmltemplate.Must(htmltemplate.New("invitation_html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <p>{{if .InviterName}}{{.InviterName}} has invited you{{else}}You have been invited{{end}} to join <strong>{{.TenantName}}</strong> on {{.AppName}}.</p>
+  <p><a href="{{.AcceptURL}}" style="display: inline-block; padding: 10px 18px; background: #2563eb; color: #fff; text-decoration: none; border-radius: 4px;">Accept invitation</a></p>
+  <p>If the button doesn't work, copy this link into your browser:<br>{{.AcceptURL}}</p>
+  <p>If you weren't expecting this invitation, you can ignore this email.</p>
+</body>
+</html>`))
+
+var invitationEmailText = texttemplate.Must(texttemplate.New("invitation_text").Parse(`{{if .InviterName}}{{.InviterName}} has invited you{{else}}You have been invited{{end}} to join {{.TenantName}} on {{.AppName}}.
+
+Accept the invitation here:
+{{.AcceptURL}}
+
+If you weren't expecting this invitation, you can ignore this email.
+`))
+
+type invitationEmailData struct {
+	AppName     string
+	TenantName  string
+	InviterName string
+	AcceptURL   string
+}
+
+// buildInvitationEmail renders the invitation email shared by every email notifier
+func buildInvitationEmail(cfg *config.EmailConfig, to, tenantName, inviterName, acceptURL string) (notifx.EmailMessage, error) {
+	data := invitationEmailData{
+		AppName:     cfg.FromName,
+		TenantName:  tenantName,
+		InviterName: inviterName,
+		AcceptURL:   acceptURL,
+	}
+
+	var html, text bytes.Buffer
+	if err := invitationEmailHTML.Execute(&html, data); err != nil {
+		return notifx.EmailMessage{}, err
+	}
+	if err := invitationEmailText.Execute(&text, data); err != nil {
+		return notifx.EmailMessage{}, err
+	}
+
+	return notifx.EmailMessage{
+		From:     formatFrom(cfg),
+		To:       []string{to},
+		ReplyTo:  cfg.ReplyTo,
+		Subject:  invitationSubject(tenantName),
+		HTMLBody: html.String(),
+		TextBody: text.String(),
+	}, nil
+}
+
+// invitationSubject puts the tenant name on a single line: it is tenant
+// controlled and ends up in a mail header
+func invitationSubject(tenantName string) string {
+	tenantName = strings.Join(strings.Fields(tenantName), " ")
+	if tenantName == "" {
+		return "You've been invited"
+	}
+	return "You've been invited to join " + tenantName
+}
+
+// formatFrom returns "Name <address>" when a sender name is configured
+func formatFrom(cfg *config.EmailConfig) string {
+	return notifx.FormatAddress(cfg.FromName, cfg.FromAddress)
+}
@@ -3,7 +3,10 @@ package invitationinfra
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
@@ -23,21 +26,13 @@ func NewPostgresInvitationRepository(db *sqlx.DB) invitation.InvitationRepositor
 	}
 }
 
-// getExecutor returns transaction if present in context, otherwise returns db
-func (r *PostgresInvitationRepository) getExecutor(ctx context.Context) sqlx.ExtContext {
-	if tx, ok := ctx.Value("db_tx").(*sqlx.Tx); ok {
-		return tx
-	}
-	return r.db
-}
-
 // FindByID busca una invitación por ID
 func (r *PostgresInvitationRepository) FindByID(ctx context.Context, id string) (*invitation.Invitation, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		FROM invitations
 		WHERE id = $1`
@@ -57,11 +52,11 @@ func (r *PostgresInvitationRepository) FindByID(ctx context.Context, id string)
 
 // FindByToken busca una invitación por token
 func (r *PostgresInvitationRepository) FindByToken(ctx context.Context, token string) (*invitation.Invitation, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		FROM invitations
 		WHERE token = $1`
@@ -80,11 +75,11 @@ func (r *PostgresInvitationRepository) FindByToken(ctx context.Context, token st
 
 // FindByEmail busca invitaciones por email
 func (r *PostgresInvitationRepository) FindByEmail(ctx context.Context, email string, tenantID kernel.TenantID) ([]*invitation.Invitation, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		FROM invitations
 		WHERE email = $1 AND tenant_id = $2
@@ -108,11 +103,11 @@ func (r *PostgresInvitationRepository) FindByEmail(ctx context.Context, email st
 
 // FindPendingByEmail busca invitaciones pendientes para un email en un tenant
 func (r *PostgresInvitationRepository) FindPendingByEmail(ctx context.Context, email string, tenantID kernel.TenantID) (*invitation.Invitation, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		FROM invitations
 		WHERE email = $1 AND tenant_id = $2 AND status = 'PENDING' AND expires_at > NOW()
@@ -134,11 +129,11 @@ func (r *PostgresInvitationRepository) FindPendingByEmail(ctx context.Context, e
 
 // FindByTenant busca todas las invitaciones de un tenant
 func (r *PostgresInvitationRepository) FindByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*invitation.Invitation, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		FROM invitations
 		WHERE tenant_id = $1
@@ -162,11 +157,11 @@ func (r *PostgresInvitationRepository) FindByTenant(ctx context.Context, tenantI
 
 // FindPendingByTenant busca invitaciones pendientes de un tenant
 func (r *PostgresInvitationRepository) FindPendingByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*invitation.Invitation, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		FROM invitations
 		WHERE tenant_id = $1 AND status = 'PENDING' AND expires_at > NOW()
@@ -188,13 +183,70 @@ func (r *PostgresInvitationRepository) FindPendingByTenant(ctx context.Context,
 	return result, nil
 }
 
+// invitationKeyset pagina los listados por cursor sobre idx_invitations_tenant_keyset
+var invitationKeyset = dbx.Keyset{CreatedAtColumn: "created_at", IDColumn: "id"}
+
+// Search busca invitaciones de un tenant aplicando filtros y paginación por
+// cursor. Retorna la página solicitada y el total que cumple el filtro.
+func (r *PostgresInvitationRepository) Search(ctx context.Context, tenantID kernel.TenantID, filter invitation.InvitationSearchFilter) ([]*invitation.Invitation, int, error) {
+	executor := dbx.Executor(ctx, r.db)
+
+	conditions := []string{"tenant_id = $1"}
+	args := []any{tenantID.String()}
+
+	if filter.Status != nil {
+		args = append(args, string(*filter.Status))
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Pending {
+		conditions = append(conditions, "status = 'PENDING' AND expires_at > NOW()")
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	// El total no depende del cursor: es el de todas las páginas
+	var total int
+	if err := sqlx.GetContext(ctx, executor, &total, `SELECT COUNT(*) FROM invitations WHERE `+where, args...); err != nil {
+		return nil, 0, errx.Wrap(err, "failed to count invitations", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	if after, keysetArgs := invitationKeyset.After(filter.Cursor, args); after != "" {
+		where += " AND " + after
+		args = keysetArgs
+	}
+
+	query := `
+		SELECT
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
+			expires_at, accepted_at, accepted_by, created_at, updated_at
+		FROM invitations
+		WHERE ` + where + `
+		ORDER BY ` + invitationKeyset.OrderBy() +
+		fmt.Sprintf(" LIMIT $%d", len(args)+1)
+
+	var invitations []invitation.Invitation
+	if err := sqlx.SelectContext(ctx, executor, &invitations, query, append(args, filter.Limit)...); err != nil {
+		return nil, 0, errx.Wrap(err, "failed to search invitations", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	// Convertir a slice de punteros
+	result := make([]*invitation.Invitation, len(invitations))
+	for i := range invitations {
+		result[i] = &invitations[i]
+	}
+
+	return result, total, nil
+}
+
 // FindExpired busca invitaciones expiradas
 func (r *PostgresInvitationRepository) FindExpired(ctx context.Context) ([]*invitation.Invitation, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		FROM invitations
 		WHERE status = 'PENDING' AND expires_at < NOW()`
@@ -230,14 +282,14 @@ func (r *PostgresInvitationRepository) Save(ctx context.Context, inv invitation.
 
 // create crea una nueva invitación
 func (r *PostgresInvitationRepository) create(ctx context.Context, inv invitation.Invitation) error {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		INSERT INTO invitations (
-			id, tenant_id, email, token, scopes, status, invited_by,
+			id, tenant_id, email, token, scopes, requested_scopes, status, invited_by,
 			expires_at, accepted_at, accepted_by, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 		)`
 
 	_, err := executor.ExecContext(ctx, query,
@@ -246,6 +298,7 @@ func (r *PostgresInvitationRepository) create(ctx context.Context, inv invitatio
 		inv.Email,
 		inv.Token,
 		pq.Array(inv.Scopes),
+		pq.Array(inv.RequestedScopes),
 		inv.Status,
 		inv.InvitedBy,
 		inv.ExpiresAt,
@@ -272,23 +325,27 @@ func (r *PostgresInvitationRepository) create(ctx context.Context, inv invitatio
 
 // update actualiza una invitación existente
 func (r *PostgresInvitationRepository) update(ctx context.Context, inv invitation.Invitation) error {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		UPDATE invitations SET
 			email = $1,
-			status = $2,
-			scopes = $3,
-			expires_at = $4,
-			accepted_at = $5,
-			accepted_by = $6,
-			updated_at = $7
-		WHERE id = $8`
+			token = $2,
+			status = $3,
+			scopes = $4,
+			requested_scopes = $5,
+			expires_at = $6,
+			accepted_at = $7,
+			accepted_by = $8,
+			updated_at = $9
+		WHERE id = $10`
 
 	result, err := executor.ExecContext(ctx, query,
 		inv.Email,
+		inv.Token,
 		inv.Status,
 		pq.Array(inv.Scopes),
+		pq.Array(inv.RequestedScopes),
 		inv.ExpiresAt,
 		inv.AcceptedAt,
 		inv.AcceptedBy,
@@ -315,7 +372,7 @@ func (r *PostgresInvitationRepository) update(ctx context.Context, inv invitatio
 
 // Delete elimina una invitación
 func (r *PostgresInvitationRepository) Delete(ctx context.Context, id string) error {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `DELETE FROM invitations WHERE id = $1`
 
@@ -339,7 +396,7 @@ func (r *PostgresInvitationRepository) Delete(ctx context.Context, id string) er
 
 // ExistsPendingForEmail verifica si existe una invitación pendiente para un email
 func (r *PostgresInvitationRepository) ExistsPendingForEmail(ctx context.Context, email string, tenantID kernel.TenantID) (bool, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `
 		SELECT EXISTS(
@@ -359,7 +416,7 @@ func (r *PostgresInvitationRepository) ExistsPendingForEmail(ctx context.Context
 
 // invitationExists verifica si una invitación existe por ID
 func (r *PostgresInvitationRepository) invitationExists(ctx context.Context, id string) (bool, error) {
-	executor := r.getExecutor(ctx)
+	executor := dbx.Executor(ctx, r.db)
 
 	query := `SELECT EXISTS(SELECT 1 FROM invitations WHERE id = $1)`
 
@@ -0,0 +1,39 @@
+package invitationinfra
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+	"github.com/Abraxas-365/manifesto/internal/notifx/notifxses"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+)
+
+// SESNotifier sends invitation emails through AWS SES
+type SESNotifier struct {
+	sender notifx.EmailSender
+	cfg    *config.EmailConfig
+}
+
+// NewSESNotifier creates an invitation notifier backed by AWS SES
+func NewSESNotifier(client *ses.Client, cfg *config.EmailConfig) *SESNotifier {
+	return &SESNotifier{
+		sender: notifxses.NewSESProvider(client, formatFrom(cfg)),
+		cfg:    cfg,
+	}
+}
+
+// SendInvitation renders the invitation email and sends it to email
+func (n *SESNotifier) SendInvitation(ctx context.Context, email, token, tenantName, inviterName, acceptURL string) error {
+	msg, err := buildInvitationEmail(n.cfg, email, tenantName, inviterName, acceptURL)
+	if err != nil {
+		return invitation.ErrSendFailed(err).WithDetail("provider", "ses")
+	}
+
+	if err := n.sender.SendEmail(ctx, msg); err != nil {
+		return invitation.ErrSendFailed(err).WithDetail("provider", "ses")
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package invitationinfra
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+	"github.com/Abraxas-365/manifesto/internal/notifx/notifxsmtp"
+)
+
+// SMTPNotifier sends invitation emails through an SMTP server
+type SMTPNotifier struct {
+	sender notifx.EmailSender
+	cfg    *config.EmailConfig
+}
+
+// NewSMTPNotifier creates an invitation notifier using the SMTP settings of
+// config.EmailConfig
+func NewSMTPNotifier(cfg *config.EmailConfig) *SMTPNotifier {
+	return &SMTPNotifier{
+		sender: notifxsmtp.NewSMTPProvider(notifxsmtp.Config{
+			Host:        cfg.SMTPHost,
+			Port:        cfg.SMTPPort,
+			Username:    cfg.SMTPUsername,
+			Password:    cfg.SMTPPassword,
+			FromAddress: cfg.FromAddress,
+		}),
+		cfg: cfg,
+	}
+}
+
+// SendInvitation renders the invitation email and sends it to email
+func (n *SMTPNotifier) SendInvitation(ctx context.Context, email, token, tenantName, inviterName, acceptURL string) error {
+	msg, err := buildInvitationEmail(n.cfg, email, tenantName, inviterName, acceptURL)
+	if err != nil {
+		return invitation.ErrSendFailed(err).WithDetail("provider", "smtp")
+	}
+
+	if err := n.sender.SendEmail(ctx, msg); err != nil {
+		return invitation.ErrSendFailed(err).WithDetail("provider", "smtp")
+	}
+
+	return nil
+}
@@ -3,46 +3,88 @@ package invitationsrv
 
 import (
 	"context"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/config"
 	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
 	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/Abraxas-365/manifesto/internal/iam/role"
 	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
 	"github.com/Abraxas-365/manifesto/internal/iam/user"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
 	"github.com/google/uuid"
 )
 
+// Límites de los listados paginados de invitaciones
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
 // InvitationService proporciona operaciones de negocio para invitaciones
 type InvitationService struct {
-	invitationRepo      invitation.InvitationRepository
-	userRepo            user.UserRepository
-	tenantRepo          tenant.TenantRepository
-	notificationService invitation.NotificationService
-	config              *config.InvitationConfig
+	invitationRepo invitation.InvitationRepository
+	userRepo       user.UserRepository
+	tenantRepo     tenant.TenantRepository
+	roleRepo       role.RoleRepository
+	notifier       invitation.InvitationNotifier
+	auditRecorder  audit.Recorder
+	outbox         outbox.Outbox
+	config         *config.InvitationConfig
+	normalize      bool
+}
+
+// InvitationServiceOption configura opciones opcionales del InvitationService
+type InvitationServiceOption func(*InvitationService)
+
+// WithScopeNormalization elimina, antes de guardar la invitación, los scopes
+// que ya implica un wildcard de la misma lista (ver scopes.Normalize)
+func WithScopeNormalization(enabled bool) InvitationServiceOption {
+	return func(s *InvitationService) {
+		s.normalize = enabled
+	}
 }
 
-// NewInvitationService crea una nueva instancia del servicio de invitaciones
+// NewInvitationService crea una nueva instancia del servicio de invitaciones.
+// outbox puede ser nil, en cuyo caso los emails se envían en la misma request.
 func NewInvitationService(
 	invitationRepo invitation.InvitationRepository,
 	userRepo user.UserRepository,
 	tenantRepo tenant.TenantRepository,
-	notificationService invitation.NotificationService,
+	roleRepo role.RoleRepository,
+	notifier invitation.InvitationNotifier,
+	auditRecorder audit.Recorder,
+	outbox outbox.Outbox,
 	cfg *config.InvitationConfig,
+	opts ...InvitationServiceOption,
 ) *InvitationService {
-	return &InvitationService{
-		invitationRepo:      invitationRepo,
-		userRepo:            userRepo,
-		tenantRepo:          tenantRepo,
-		notificationService: notificationService,
-		config:              cfg,
-	}
+	s := &InvitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		tenantRepo:     tenantRepo,
+		roleRepo:       roleRepo,
+		notifier:       notifier,
+		auditRecorder:  auditRecorder,
+		outbox:         outbox,
+		config:         cfg,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// CreateInvitation crea una nueva invitación
-func (s *InvitationService) CreateInvitation(ctx context.Context, tenantID kernel.TenantID, invitedBy kernel.UserID, req invitation.CreateInvitationRequest) (*invitation.Invitation, error) {
+// CreateInvitation crea una nueva invitación y envía el email. Con outbox el
+// email se encola en la misma transacción que la invitación; sin outbox se
+// envía en línea y, si falla, la invitación se mantiene y el resultado lo
+// indica con EmailFailed.
+func (s *InvitationService) CreateInvitation(ctx context.Context, tenantID kernel.TenantID, invitedBy kernel.UserID, req invitation.CreateInvitationRequest) (*invitation.CreateInvitationResult, error) {
 	// Verificar que el tenant existe
 	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
 	if err != nil {
@@ -81,8 +123,20 @@ func (s *InvitationService) CreateInvitation(ctx context.Context, tenantID kerne
 		return nil, invitation.ErrInvitationAlreadyExists().WithDetail("email", req.Email)
 	}
 
+	// Verificar el límite de invitaciones pendientes del tenant
+	if s.config.MaxPendingPerTenant > 0 {
+		pending, err := s.invitationRepo.FindPendingByTenant(ctx, tenantID)
+		if err != nil {
+			return nil, errx.Wrap(err, "failed to count pending invitations", errx.TypeInternal)
+		}
+		if len(pending) >= s.config.MaxPendingPerTenant {
+			return nil, invitation.ErrTooManyPendingInvitations().
+				WithDetail("max_pending", s.config.MaxPendingPerTenant)
+		}
+	}
+
 	// Determinar scopes
-	resolvedScopes, err := s.resolveScopes(req)
+	resolvedScopes, err := s.resolveScopes(ctx, tenantID, req)
 	if err != nil {
 		return nil, err
 	}
@@ -92,6 +146,11 @@ func (s *InvitationService) CreateInvitation(ctx context.Context, tenantID kerne
 		return nil, err
 	}
 
+	requestedScopes := resolvedScopes
+	if s.normalize {
+		resolvedScopes = scopes.Normalize(resolvedScopes)
+	}
+
 	// Generar token único usando configuración
 	token, err := invitation.GenerateInvitationToken(s.config.TokenByteLength)
 	if err != nil {
@@ -118,19 +177,43 @@ func (s *InvitationService) CreateInvitation(ctx context.Context, tenantID kerne
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-
-	// Guardar invitación
-	if err := s.invitationRepo.Save(ctx, *newInvitation); err != nil {
-		return nil, errx.Wrap(err, "failed to save invitation", errx.TypeInternal)
+	if !slices.Equal(requestedScopes, resolvedScopes) {
+		newInvitation.RequestedScopes = requestedScopes
 	}
 
-	if s.notificationService != nil {
-		if err := s.notificationService.SendInvitation(ctx, req.Email, token, tenantID, invitedBy); err != nil {
-			return nil, errx.Wrap(err, "failed to send invitation email", errx.TypeExternal)
+	// Guardar invitación y encolar su email de forma atómica
+	err = s.withinTx(ctx, func(ctx context.Context) error {
+		if err := s.invitationRepo.Save(ctx, *newInvitation); err != nil {
+			return errx.Wrap(err, "failed to save invitation", errx.TypeInternal)
 		}
+		return s.enqueueInvitationEmail(ctx, newInvitation, tenantEntity, inviterUser)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return newInvitation, nil
+	event := audit.NewEvent(tenantID, audit.ActionInvitationCreated, audit.ResourceInvitation, newInvitation.ID).
+		WithMetadata("email", newInvitation.Email).
+		WithMetadata("scopes", newInvitation.Scopes)
+	if newInvitation.RequestedScopes != nil {
+		event = event.WithMetadata("requested_scopes", newInvitation.RequestedScopes)
+	}
+	event.ActorUserID = &invitedBy
+	s.auditRecorder.Record(ctx, event)
+
+	result := &invitation.CreateInvitationResult{Invitation: newInvitation}
+	if s.outbox != nil {
+		return result, nil
+	}
+	if err := s.sendInvitationEmail(ctx, newInvitation, tenantEntity, inviterUser); err != nil {
+		logx.FromContext(ctx).WithFields(logx.Fields{
+			"invitation_id": newInvitation.ID,
+			"tenant_id":     tenantID,
+		}).Warnf("invitation created but email could not be sent: %v", err)
+		result.EmailFailed = true
+	}
+
+	return result, nil
 }
 
 // GetInvitationByID obtiene una invitación por ID
@@ -192,52 +275,43 @@ func (s *InvitationService) ValidateInvitationToken(ctx context.Context, token s
 	}, nil
 }
 
-// GetTenantInvitations obtiene todas las invitaciones de un tenant
-func (s *InvitationService) GetTenantInvitations(ctx context.Context, tenantID kernel.TenantID) (*invitation.InvitationListResponse, error) {
+// GetTenantInvitations lista las invitaciones de un tenant con paginación por
+// cursor, de la más reciente a la más antigua
+func (s *InvitationService) GetTenantInvitations(ctx context.Context, tenantID kernel.TenantID, filter invitation.InvitationSearchFilter) (*kernel.Page[invitation.InvitationResponseDTO], error) {
 	// Verificar que el tenant existe
 	_, err := s.tenantRepo.FindByID(ctx, tenantID)
 	if err != nil {
 		return nil, tenant.ErrTenantNotFound()
 	}
 
-	invitations, err := s.invitationRepo.FindByTenant(ctx, tenantID)
-	if err != nil {
-		return nil, errx.Wrap(err, "failed to get tenant invitations", errx.TypeInternal)
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
 	}
-
-	var responses []invitation.InvitationResponse
-	for _, inv := range invitations {
-		responses = append(responses, *s.buildInvitationResponse(inv))
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
 	}
 
-	return &invitation.InvitationListResponse{
-		Invitations: responses,
-		Total:       len(responses),
-	}, nil
-}
+	// Una fila extra indica si hay página siguiente
+	limit := filter.Limit
+	filter.Limit++
 
-// GetPendingInvitations obtiene invitaciones pendientes de un tenant
-func (s *InvitationService) GetPendingInvitations(ctx context.Context, tenantID kernel.TenantID) (*invitation.InvitationListResponse, error) {
-	// Verificar que el tenant existe
-	_, err := s.tenantRepo.FindByID(ctx, tenantID)
+	invitations, total, err := s.invitationRepo.Search(ctx, tenantID, filter)
 	if err != nil {
-		return nil, tenant.ErrTenantNotFound()
-	}
-
-	invitations, err := s.invitationRepo.FindPendingByTenant(ctx, tenantID)
-	if err != nil {
-		return nil, errx.Wrap(err, "failed to get pending invitations", errx.TypeInternal)
+		return nil, errx.Wrap(err, "failed to get tenant invitations", errx.TypeInternal)
 	}
 
-	var responses []invitation.InvitationResponse
-	for _, inv := range invitations {
-		responses = append(responses, *s.buildInvitationResponse(inv))
-	}
+	page := kernel.MapPage(kernel.NewPage(invitations, total, limit, (*invitation.Invitation).PageCursor), func(inv *invitation.Invitation) invitation.InvitationResponseDTO {
+		return s.buildInvitationResponse(inv).ToDTO()
+	})
+	return &page, nil
+}
 
-	return &invitation.InvitationListResponse{
-		Invitations: responses,
-		Total:       len(responses),
-	}, nil
+// GetPendingInvitations lista las invitaciones pendientes y vigentes de un
+// tenant, con la misma paginación que GetTenantInvitations
+func (s *InvitationService) GetPendingInvitations(ctx context.Context, tenantID kernel.TenantID, filter invitation.InvitationSearchFilter) (*kernel.Page[invitation.InvitationResponseDTO], error) {
+	filter.Status = nil
+	filter.Pending = true
+	return s.GetTenantInvitations(ctx, tenantID, filter)
 }
 
 // RevokeInvitation revoca una invitación
@@ -258,7 +332,81 @@ func (s *InvitationService) RevokeInvitation(ctx context.Context, invitationID s
 	}
 
 	// Guardar cambios
-	return s.invitationRepo.Save(ctx, *inv)
+	if err := s.invitationRepo.Save(ctx, *inv); err != nil {
+		return err
+	}
+
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionInvitationRevoked, audit.ResourceInvitation, inv.ID).
+		WithMetadata("email", inv.Email))
+	return nil
+}
+
+// ResendInvitation reenvía una invitación pendiente: extiende su expiración,
+// regenera el token si la configuración lo indica y vuelve a notificar.
+// Retorna ErrResendCooldown si se envió hace menos de ResendCooldown. Sin
+// outbox, si el email falla la invitación queda sin cambios.
+func (s *InvitationService) ResendInvitation(ctx context.Context, invitationID string, tenantID kernel.TenantID) (*invitation.Invitation, error) {
+	inv, err := s.invitationRepo.FindByID(ctx, invitationID)
+	if err != nil {
+		return nil, invitation.ErrInvitationNotFound()
+	}
+
+	// Verificar que la invitación pertenece al tenant
+	if inv.TenantID != tenantID {
+		return nil, invitation.ErrInvitationNotFound()
+	}
+
+	if wait := inv.ResendAvailableIn(s.config.ResendCooldown); wait > 0 {
+		return nil, invitation.ErrResendCooldown(wait).WithDetail("invitation_id", inv.ID)
+	}
+
+	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, tenant.ErrTenantNotFound()
+	}
+
+	// El invitador puede haber sido eliminado; el email se envía sin su nombre
+	inviterUser, _ := s.userRepo.FindByID(ctx, inv.InvitedBy, tenantID)
+
+	var newToken string
+	if s.config.RegenerateTokenOnResend {
+		newToken, err = invitation.GenerateInvitationToken(s.config.TokenByteLength)
+		if err != nil {
+			return nil, errx.Wrap(err, "failed to generate invitation token", errx.TypeInternal)
+		}
+	}
+
+	expiresAt := invitation.CalculateExpirationDate(s.config.DefaultExpirationDays, s.config.DefaultExpirationDays)
+	if err := inv.Resend(newToken, expiresAt); err != nil {
+		return nil, err
+	}
+
+	if s.outbox == nil {
+		// Sin outbox el email se envía antes de guardar: si falla, el enlace
+		// anterior sigue vigente y el cooldown no bloquea un nuevo intento
+		if err := s.sendInvitationEmail(ctx, inv, tenantEntity, inviterUser); err != nil {
+			return nil, err
+		}
+		if err := s.invitationRepo.Save(ctx, *inv); err != nil {
+			return nil, errx.Wrap(err, "failed to save invitation", errx.TypeInternal)
+		}
+	} else {
+		err = s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.invitationRepo.Save(ctx, *inv); err != nil {
+				return errx.Wrap(err, "failed to save invitation", errx.TypeInternal)
+			}
+			return s.enqueueInvitationEmail(ctx, inv, tenantEntity, inviterUser)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionInvitationResent, audit.ResourceInvitation, inv.ID).
+		WithMetadata("email", inv.Email).
+		WithMetadata("token_regenerated", newToken != ""))
+
+	return inv, nil
 }
 
 // DeleteInvitation elimina una invitación
@@ -315,22 +463,142 @@ func (s *InvitationService) GetAvailableScopeTemplates() []string {
 // Private Helper Methods
 // ============================================================================
 
+// DeliverInvitationEmail es el handler del outbox para KindInvitationEmail.
+// Descarta el mensaje si la invitación ya no está pendiente o si un reenvío
+// regeneró el token, para no enviar un enlace inválido.
+func (s *InvitationService) DeliverInvitationEmail(ctx context.Context, msg *outbox.Message) error {
+	var email invitation.EmailMessage
+	if err := msg.Decode(&email); err != nil {
+		return err
+	}
+
+	inv, err := s.invitationRepo.FindByID(ctx, email.InvitationID)
+	if err != nil {
+		if invitation.IsInvitationNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !inv.CanBeAccepted() || inv.Token != email.Token {
+		logx.FromContext(ctx).WithField("invitation_id", inv.ID).
+			Info("skipping invitation email: invitation no longer pending or token changed")
+		return nil
+	}
+
+	if s.notifier == nil {
+		return nil
+	}
+	if err := s.notifier.SendInvitation(ctx, email.Email, email.Token, email.TenantName, email.InviterName, email.AcceptURL); err != nil {
+		return invitation.ErrSendFailed(err)
+	}
+	return nil
+}
+
+// withinTx ejecuta fn en una transacción del outbox, o directamente si no
+// hay outbox configurado
+func (s *InvitationService) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.outbox == nil {
+		return fn(ctx)
+	}
+	return s.outbox.WithinTx(ctx, fn)
+}
+
+// enqueueInvitationEmail encola el email de la invitación en el outbox. Sin
+// outbox o sin notificador configurado no hace nada.
+func (s *InvitationService) enqueueInvitationEmail(ctx context.Context, inv *invitation.Invitation, tenantEntity *tenant.Tenant, inviterUser *user.User) error {
+	if s.outbox == nil || s.notifier == nil {
+		return nil
+	}
+
+	email, err := s.buildEmailMessage(inv, tenantEntity, inviterUser)
+	if err != nil {
+		return invitation.ErrSendFailed(err)
+	}
+	return s.outbox.Enqueue(ctx, outbox.KindInvitationEmail, email)
+}
+
+// sendInvitationEmail envía el email con el enlace de aceptación. Sin
+// notificador configurado no hace nada.
+func (s *InvitationService) sendInvitationEmail(ctx context.Context, inv *invitation.Invitation, tenantEntity *tenant.Tenant, inviterUser *user.User) error {
+	if s.notifier == nil {
+		return nil
+	}
+
+	email, err := s.buildEmailMessage(inv, tenantEntity, inviterUser)
+	if err != nil {
+		return invitation.ErrSendFailed(err)
+	}
+
+	if err := s.notifier.SendInvitation(ctx, email.Email, email.Token, email.TenantName, email.InviterName, email.AcceptURL); err != nil {
+		return invitation.ErrSendFailed(err)
+	}
+	return nil
+}
+
+// buildEmailMessage arma los datos del email de una invitación
+func (s *InvitationService) buildEmailMessage(inv *invitation.Invitation, tenantEntity *tenant.Tenant, inviterUser *user.User) (*invitation.EmailMessage, error) {
+	inviterName := ""
+	if inviterUser != nil {
+		inviterName = inviterUser.Name
+		if inviterName == "" {
+			inviterName = inviterUser.Email
+		}
+	}
+
+	acceptURL, err := s.buildAcceptURL(inv.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation.EmailMessage{
+		InvitationID: inv.ID,
+		Email:        inv.Email,
+		Token:        inv.Token,
+		TenantName:   tenantEntity.CompanyName,
+		InviterName:  inviterName,
+		AcceptURL:    acceptURL,
+	}, nil
+}
+
+// buildAcceptURL agrega el token como query param a config.AcceptURL
+func (s *InvitationService) buildAcceptURL(token string) (string, error) {
+	u, err := url.Parse(s.config.AcceptURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 // resolveScopes determina los scopes finales basándose en la request
-func (s *InvitationService) resolveScopes(req invitation.CreateInvitationRequest) ([]string, error) {
+func (s *InvitationService) resolveScopes(ctx context.Context, tenantID kernel.TenantID, req invitation.CreateInvitationRequest) ([]string, error) {
 	// Si se proporcionan scopes directamente, usarlos
 	if len(req.Scopes) > 0 {
 		return req.Scopes, nil
 	}
 
-	// Si se proporciona un template, expandirlo
+	// Si se proporciona un template, expandirlo (global o rol del tenant)
 	if req.ScopeTemplate != nil && *req.ScopeTemplate != "" {
-		scopeList := scopes.GetScopesByGroup(*req.ScopeTemplate)
-		if len(scopeList) == 0 {
-			return nil, invitation.ErrInvalidScopeTemplate().
-				WithDetail("template", *req.ScopeTemplate).
-				WithDetail("available_templates", s.GetAvailableScopeTemplates())
+		if scopeList := scopes.GetScopesByGroup(*req.ScopeTemplate); len(scopeList) > 0 {
+			return scopeList, nil
 		}
-		return scopeList, nil
+
+		if s.roleRepo != nil {
+			tenantRole, err := s.roleRepo.FindByName(ctx, *req.ScopeTemplate, tenantID)
+			if err != nil && !role.IsRoleNotFound(err) {
+				return nil, errx.Wrap(err, "failed to resolve scope template", errx.TypeInternal).
+					WithDetail("template", *req.ScopeTemplate)
+			}
+			if err == nil && len(tenantRole.Scopes) > 0 {
+				return tenantRole.Scopes, nil
+			}
+		}
+
+		return nil, invitation.ErrInvalidScopeTemplate().
+			WithDetail("template", *req.ScopeTemplate).
+			WithDetail("available_templates", s.GetAvailableScopeTemplates())
 	}
 
 	// Default: usar template "viewer" o scopes básicos
@@ -0,0 +1,115 @@
+package invitationsrv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+type memoryInvitations struct {
+	invitation.InvitationRepository
+	invitations map[string]invitation.Invitation
+}
+
+func (r *memoryInvitations) FindByID(_ context.Context, id string) (*invitation.Invitation, error) {
+	inv, ok := r.invitations[id]
+	if !ok {
+		return nil, invitation.ErrInvitationNotFound()
+	}
+	return &inv, nil
+}
+
+func (r *memoryInvitations) Save(_ context.Context, inv invitation.Invitation) error {
+	r.invitations[inv.ID] = inv
+	return nil
+}
+
+type memoryTenants struct{ tenant.TenantRepository }
+
+func (memoryTenants) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	return &tenant.Tenant{ID: id, CompanyName: "Acme"}, nil
+}
+
+// flakyNotifier fails while fail is set and records the tokens it sent
+type flakyNotifier struct {
+	fail bool
+	sent []string
+}
+
+func (n *flakyNotifier) SendInvitation(_ context.Context, _, token, _, _, _ string) error {
+	if n.fail {
+		return errors.New("smtp: connection refused")
+	}
+	n.sent = append(n.sent, token)
+	return nil
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) Record(context.Context, audit.AuditEvent) {}
+
+func TestResendInvitation(t *testing.T) {
+	ctx := context.Background()
+	sentAt := time.Now().Add(-time.Hour)
+	invitations := &memoryInvitations{invitations: map[string]invitation.Invitation{
+		"i1": {
+			ID: "i1", TenantID: "t1", Email: "ana@acme.com", Token: "old-token",
+			Status: invitation.InvitationStatusPending, InvitedBy: "u1",
+			ExpiresAt: time.Now().Add(24 * time.Hour), CreatedAt: sentAt, UpdatedAt: sentAt,
+		},
+	}}
+	notifier := &flakyNotifier{fail: true}
+	s := NewInvitationService(invitations, userinfra.NewInMemoryUserRepository(), memoryTenants{}, nil,
+		notifier, noopRecorder{}, nil, &config.InvitationConfig{
+			TokenByteLength:         16,
+			DefaultExpirationDays:   7,
+			ResendCooldown:          5 * time.Minute,
+			RegenerateTokenOnResend: true,
+			AcceptURL:               "https://app.example.com/accept",
+		})
+
+	if _, err := s.ResendInvitation(ctx, "i1", "other"); !errx.Is(err, invitation.CodeInvitationNotFound) {
+		t.Errorf("resend from another tenant error = %v, want NOT_FOUND", err)
+	}
+
+	// A failed send leaves the invitation as it was: the old link still works
+	// and the cooldown does not block a retry
+	if _, err := s.ResendInvitation(ctx, "i1", "t1"); !errx.Is(err, invitation.CodeSendFailed) {
+		t.Fatalf("failed send error = %v, want SEND_FAILED", err)
+	}
+	if stored := invitations.invitations["i1"]; stored.Token != "old-token" || !stored.UpdatedAt.Equal(sentAt) {
+		t.Fatalf("invitation changed by a failed send: %+v", stored)
+	}
+
+	notifier.fail = false
+	resent, err := s.ResendInvitation(ctx, "i1", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resent.Token == "old-token" || len(notifier.sent) != 1 || notifier.sent[0] != resent.Token {
+		t.Errorf("resent token %q, sent %v", resent.Token, notifier.sent)
+	}
+	if stored := invitations.invitations["i1"]; stored.Token != resent.Token {
+		t.Errorf("stored token %q, want %q", stored.Token, resent.Token)
+	}
+
+	_, err = s.ResendInvitation(ctx, "i1", "t1")
+	if !errx.Is(err, invitation.CodeResendCooldown) {
+		t.Fatalf("resend within the cooldown error = %v, want RESEND_COOLDOWN", err)
+	}
+	if wait, ok := invitation.RetryAfter(err); !ok || wait <= 0 || wait > 5*time.Minute {
+		t.Errorf("RetryAfter = %v, %v", wait, ok)
+	}
+	if len(notifier.sent) != 1 {
+		t.Errorf("sent %d emails, want 1", len(notifier.sent))
+	}
+}
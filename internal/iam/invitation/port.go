@@ -6,10 +6,11 @@ import (
 	"github.com/Abraxas-365/manifesto/internal/kernel"
 )
 
-// NotificationService is a generic interface for sending invitation emails.
-// Implementations can use notifx, console logging, or any other delivery mechanism.
-type NotificationService interface {
-	SendInvitation(ctx context.Context, email string, token string, tenantID kernel.TenantID, invitedBy kernel.UserID) error
+// InvitationNotifier delivers invitation emails. acceptURL already carries the
+// token; it is passed separately for templates that show it as a code.
+// Implementations live in invitationinfra (SMTP, SES, console).
+type InvitationNotifier interface {
+	SendInvitation(ctx context.Context, email, token, tenantName, inviterName, acceptURL string) error
 }
 
 // InvitationRepository define el contrato para la persistencia de invitaciones
@@ -32,6 +33,10 @@ type InvitationRepository interface {
 	// FindPendingByTenant busca invitaciones pendientes de un tenant
 	FindPendingByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*Invitation, error)
 
+	// Search busca invitaciones de un tenant aplicando filtros y paginación
+	// por cursor. Retorna la página y el total que cumple el filtro.
+	Search(ctx context.Context, tenantID kernel.TenantID, filter InvitationSearchFilter) ([]*Invitation, int, error)
+
 	// FindExpired busca invitaciones expiradas
 	FindExpired(ctx context.Context) ([]*Invitation, error)
 
@@ -1,8 +1,12 @@
 package otp
 
 import (
-	"github.com/Abraxas-365/manifesto/internal/errx"
+	"errors"
+	"math"
 	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
 )
 
 var ErrRegistry = errx.NewRegistry("OTP")
@@ -13,10 +17,64 @@ var (
 	CodeOTPAlreadyUsed  = ErrRegistry.Register("OTP_ALREADY_USED", errx.TypeBusiness, http.StatusBadRequest, "OTP code has already been used")
 	CodeTooManyAttempts = ErrRegistry.Register("TOO_MANY_ATTEMPTS", errx.TypeBusiness, http.StatusTooManyRequests, "Too many verification attempts")
 	CodeTooManyRequests = ErrRegistry.Register("TOO_MANY_REQUESTS", errx.TypeBusiness, http.StatusTooManyRequests, "Too many OTP requests")
+	CodeSendFailed      = ErrRegistry.Register("SEND_FAILED", errx.TypeExternal, http.StatusBadGateway, "Failed to send verification code")
+	CodeChannelDisabled = ErrRegistry.Register("CHANNEL_DISABLED", errx.TypeValidation, http.StatusBadRequest, "OTP delivery channel is not configured")
 )
 
 func ErrInvalidOTP() *errx.Error      { return ErrRegistry.New(CodeInvalidOTP) }
 func ErrOTPExpired() *errx.Error      { return ErrRegistry.New(CodeOTPExpired) }
 func ErrOTPAlreadyUsed() *errx.Error  { return ErrRegistry.New(CodeOTPAlreadyUsed) }
 func ErrTooManyAttempts() *errx.Error { return ErrRegistry.New(CodeTooManyAttempts) }
-func ErrTooManyRequests() *errx.Error { return ErrRegistry.New(CodeTooManyRequests) }
+func ErrChannelDisabled() *errx.Error { return ErrRegistry.New(CodeChannelDisabled) }
+
+func ErrSendFailed(cause error) *errx.Error {
+	return ErrRegistry.NewWithCause(CodeSendFailed, cause)
+}
+
+// ErrOTPRateLimited means a new code was requested before the rate limit window
+// elapsed; retry_after_seconds tells the client how long to wait, at least 1s
+func ErrOTPRateLimited(retryAfter time.Duration) *errx.Error {
+	seconds := max(int(math.Ceil(retryAfter.Seconds())), 1)
+	return ErrRegistry.New(CodeTooManyRequests).WithDetail("retry_after_seconds", seconds)
+}
+
+// IsSendFailed reports whether err means the OTP could not be delivered
+func IsSendFailed(err error) bool {
+	return errors.Is(err, CodeSendFailed)
+}
+
+// IsTooManyAttempts reports whether err means the OTP is locked after using
+// all of its verification attempts
+func IsTooManyAttempts(err error) bool {
+	return errors.Is(err, CodeTooManyAttempts)
+}
+
+// IsChannelDisabled reports whether err means no notifier is configured for the contact's channel
+func IsChannelDisabled(err error) bool {
+	return errors.Is(err, CodeChannelDisabled)
+}
+
+// IsExpired reports whether err means the OTP was valid but its time ran out,
+// so the client should request a new one rather than retype it
+func IsExpired(err error) bool {
+	return errors.Is(err, CodeOTPExpired)
+}
+
+// IsInvalid reports whether err means the code does not match
+func IsInvalid(err error) bool {
+	return errors.Is(err, CodeInvalidOTP)
+}
+
+// RetryAfter returns the wait time carried by an ErrOTPRateLimited error
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != CodeTooManyRequests.Code {
+		return 0, false
+	}
+	// A rate-limit error without a usable wait still means "retry later"
+	seconds, ok := e.Details["retry_after_seconds"].(int)
+	if !ok || seconds < 1 {
+		seconds = 1
+	}
+	return time.Duration(seconds) * time.Second, true
+}
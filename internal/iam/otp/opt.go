@@ -2,21 +2,53 @@ package otp
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
 )
 
+// OTPPurpose scopes a code to the flow that issued it: a code is only accepted
+// by the flow with the same purpose, and rate limits and attempts are counted
+// per contact+purpose
 type OTPPurpose string
 
 const (
 	OTPPurposeJobApplication OTPPurpose = "JOB_APPLICATION"
-	OTPPurposeVerification   OTPPurpose = "VERIFICATION"
+	OTPPurposeVerification   OTPPurpose = "VERIFICATION" // Enabling OTP on an existing account
+	OTPPurposeSignup         OTPPurpose = "SIGNUP"
+	OTPPurposeLogin          OTPPurpose = "LOGIN"
 )
 
+// Purposes lists every OTP purpose
+func Purposes() []OTPPurpose {
+	return []OTPPurpose{OTPPurposeVerification, OTPPurposeSignup, OTPPurposeLogin, OTPPurposeJobApplication}
+}
+
+// Channel is the delivery channel of an OTP, derived from its contact
+type Channel string
+
+const (
+	ChannelEmail Channel = "EMAIL"
+	ChannelPhone Channel = "PHONE"
+)
+
+// ChannelFor returns ChannelPhone for E.164 numbers and ChannelEmail otherwise,
+// so email stays the default for every existing flow
+func ChannelFor(contact string) Channel {
+	if kernel.Phone(contact).IsValid() {
+		return ChannelPhone
+	}
+	return ChannelEmail
+}
+
 type OTP struct {
 	ID          string
 	Contact     string // Email or phone
+	Channel     Channel
 	Code        string
 	Purpose     OTPPurpose
 	ExpiresAt   time.Time
@@ -26,8 +58,25 @@ type OTP struct {
 	MaxAttempts int
 }
 
+// CodeMessage is the outbox payload that delivers an OTP code. It references
+// the OTP instead of carrying the code, so the outbox table never holds a
+// usable code; the dispatcher loads it at delivery time. ExpiresAt lets the
+// dispatcher drop codes that expired before they could be delivered.
+type CodeMessage struct {
+	OTPID     string     `json:"otp_id"`
+	Contact   string     `json:"contact"`
+	Purpose   OTPPurpose `json:"purpose"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
 func (o *OTP) IsValid() bool {
-	return time.Now().Before(o.ExpiresAt) && o.VerifiedAt == nil && o.Attempts < o.MaxAttempts
+	return time.Now().Before(o.ExpiresAt) && o.VerifiedAt == nil && !o.AttemptsExhausted()
+}
+
+// AttemptsExhausted reports whether every verification attempt was used; the
+// code can no longer be verified, even with the right value
+func (o *OTP) AttemptsExhausted() bool {
+	return o.Attempts >= o.MaxAttempts
 }
 
 func (o *OTP) IsExpired() bool {
@@ -43,15 +92,51 @@ func (o *OTP) Verify() error {
 	return nil
 }
 
+// Matches compares code with the OTP in constant time. Alphanumeric codes are
+// generated upper-case, so the input is upper-cased before comparing.
+func (o *OTP) Matches(code string) bool {
+	return subtle.ConstantTimeCompare([]byte(o.Code), []byte(strings.ToUpper(code))) == 1
+}
+
 func (o *OTP) IncrementAttempts() {
 	o.Attempts++
 }
 
+// CodeCharset is the set of characters OTP codes are drawn from
+type CodeCharset string
+
+const (
+	CodeCharsetNumeric      CodeCharset = "numeric"      // 0-9 (default)
+	CodeCharsetAlphanumeric CodeCharset = "alphanumeric" // Upper-case letters and digits, without look-alikes
+)
+
+// alphanumericAlphabet leaves out 0/O and 1/I so codes survive being read
+// aloud or retyped
+const alphanumericAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// IsValid reports whether the charset is supported
+func (c CodeCharset) IsValid() bool {
+	return c == CodeCharsetNumeric || c == CodeCharsetAlphanumeric
+}
+
+// MaxCodeLength is the longest code otps.code (VARCHAR(6)) can hold
+const MaxCodeLength = 6
+
+// CodePolicy controls the shape of generated OTP codes
+type CodePolicy struct {
+	Length  int
+	Charset CodeCharset // Empty means numeric
+}
+
 // GenerateOTPCode generates a cryptographically secure random OTP code
-func GenerateOTPCode(length int) (string, error) {
+func GenerateOTPCode(policy CodePolicy) (string, error) {
+	if policy.Charset == CodeCharsetAlphanumeric {
+		return generateFromAlphabet(alphanumericAlphabet, policy.Length)
+	}
+
 	// Calculate max value (10^length - 1)
 	max := new(big.Int)
-	max.Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	max.Exp(big.NewInt(10), big.NewInt(int64(policy.Length)), nil)
 
 	// Generate random number between 0 and max-1
 	n, err := rand.Int(rand.Reader, max)
@@ -60,6 +145,33 @@ func GenerateOTPCode(length int) (string, error) {
 	}
 
 	// Format with leading zeros
-	format := fmt.Sprintf("%%0%dd", length)
+	format := fmt.Sprintf("%%0%dd", policy.Length)
 	return fmt.Sprintf(format, n), nil
 }
+
+// generateFromAlphabet picks length characters uniformly from alphabet
+func generateFromAlphabet(alphabet string, length int) (string, error) {
+	size := big.NewInt(int64(len(alphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// MaskContact oculta un contacto para logs y auditoría: conserva el primer
+// carácter y el dominio de los emails, y los dos últimos dígitos de los
+// teléfonos
+func MaskContact(contact string) string {
+	if at := strings.LastIndexByte(contact, '@'); at > 0 {
+		return contact[:1] + "***" + contact[at:]
+	}
+	if len(contact) > 2 {
+		return "***" + contact[len(contact)-2:]
+	}
+	return "***"
+}
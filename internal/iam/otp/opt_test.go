@@ -0,0 +1,80 @@
+package otp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateOTPCode(t *testing.T) {
+	tests := []struct {
+		policy   CodePolicy
+		alphabet string
+	}{
+		{CodePolicy{Length: 6}, "0123456789"},
+		{CodePolicy{Length: 4, Charset: CodeCharsetNumeric}, "0123456789"},
+		{CodePolicy{Length: 6, Charset: CodeCharsetAlphanumeric}, alphanumericAlphabet},
+	}
+
+	for _, tt := range tests {
+		for range 50 {
+			code, err := GenerateOTPCode(tt.policy)
+			if err != nil {
+				t.Fatalf("GenerateOTPCode(%+v): %v", tt.policy, err)
+			}
+			if len(code) != tt.policy.Length {
+				t.Fatalf("GenerateOTPCode(%+v) = %q, want %d characters", tt.policy, code, tt.policy.Length)
+			}
+			for _, r := range code {
+				if !strings.ContainsRune(tt.alphabet, r) {
+					t.Fatalf("GenerateOTPCode(%+v) = %q, has %q outside %q", tt.policy, code, r, tt.alphabet)
+				}
+			}
+		}
+	}
+
+	// Look-alikes are never generated
+	if strings.ContainsAny(alphanumericAlphabet, "0O1I") {
+		t.Errorf("alphanumeric alphabet %q contains look-alike characters", alphanumericAlphabet)
+	}
+}
+
+func TestOTPMatches(t *testing.T) {
+	tests := []struct {
+		code  string
+		input string
+		want  bool
+	}{
+		{"123456", "123456", true},
+		{"123456", "123457", false},
+		{"123456", "12345", false},
+		{"AB3XK9", "AB3XK9", true},
+		{"AB3XK9", "ab3xk9", true},
+		{"AB3XK9", "Ab3Xk9", true},
+		{"AB3XK9", "AB3XK8", false},
+		{"AB3XK9", "", false},
+	}
+
+	for _, tt := range tests {
+		o := &OTP{Code: tt.code}
+		if got := o.Matches(tt.input); got != tt.want {
+			t.Errorf("OTP{%q}.Matches(%q) = %v, want %v", tt.code, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMaskContact(t *testing.T) {
+	tests := map[string]string{
+		"ana@acme.com":   "a***@acme.com",
+		"a.b@c@acme.com": "a***@acme.com",
+		"+15550100":      "***00",
+		"12":             "***",
+		"":               "***",
+		"@acme.com":      "***om",
+	}
+
+	for contact, want := range tests {
+		if got := MaskContact(contact); got != want {
+			t.Errorf("MaskContact(%q) = %q, want %q", contact, got, want)
+		}
+	}
+}
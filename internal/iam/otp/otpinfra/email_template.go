@@ -0,0 +1,78 @@
+package otpinfra
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+)
+
+const otpEmailSubject = "Your verification code"
+
+var otpEmailHTML = htmltemplate.Must(htmltemplate.New("otp_html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <p>Use the following code to continue signing in to {{.AppName}}:</p>
+  <p style="font-size: 28px; font-weight: bold; letter-spacing: 6px;">{{.Code}}</p>
+  <p>This code expires in {{.ExpiresIn}}. If you didn't request it, you can ignore this email.</p>
+</body>
+</html>`))
+
+var otpEmailText = texttemplate.Must(texttemplate.New("otp_text").Parse(`Use the following code to continue signing in to {{.AppName}}:
+
+{{.Code}}
+
+This code expires in {{.ExpiresIn}}. If you didn't request it, you can ignore this email.
+`))
+
+type otpEmailData struct {
+	AppName   string
+	Code      string
+	ExpiresIn string
+}
+
+// buildOTPEmail renders the verification email shared by every email notifier
+func buildOTPEmail(cfg *config.EmailConfig, to, code string, expiration time.Duration) (notifx.EmailMessage, error) {
+	data := otpEmailData{
+		AppName:   cfg.FromName,
+		Code:      code,
+		ExpiresIn: formatExpiration(expiration),
+	}
+
+	var html, text bytes.Buffer
+	if err := otpEmailHTML.Execute(&html, data); err != nil {
+		return notifx.EmailMessage{}, err
+	}
+	if err := otpEmailText.Execute(&text, data); err != nil {
+		return notifx.EmailMessage{}, err
+	}
+
+	return notifx.EmailMessage{
+		From:     formatFrom(cfg),
+		To:       []string{to},
+		ReplyTo:  cfg.ReplyTo,
+		Subject:  otpEmailSubject,
+		HTMLBody: html.String(),
+		TextBody: text.String(),
+	}, nil
+}
+
+// formatFrom returns "Name <address>" when a sender name is configured
+func formatFrom(cfg *config.EmailConfig) string {
+	return notifx.FormatAddress(cfg.FromName, cfg.FromAddress)
+}
+
+func formatExpiration(d time.Duration) string {
+	if d >= time.Minute && d%time.Minute == 0 {
+		minutes := int(d / time.Minute)
+		if minutes == 1 {
+			return "1 minute"
+		}
+		return strconv.Itoa(minutes) + " minutes"
+	}
+	return d.String()
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/otp"
 	"github.com/jmoiron/sqlx"
@@ -21,15 +22,16 @@ func NewPostgresOTPRepository(db *sqlx.DB) *PostgresOTPRepository {
 // Create inserts a new OTP into the database
 func (r *PostgresOTPRepository) Create(ctx context.Context, o *otp.OTP) error {
 	query := `
-        INSERT INTO otps (id, contact, code, purpose, expires_at, attempts, max_attempts, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        INSERT INTO otps (id, contact, channel, code, purpose, expires_at, attempts, max_attempts, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
     `
 
-	_, err := r.db.ExecContext(
+	_, err := dbx.Executor(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		o.ID,
 		o.Contact,
+		string(o.Channel),
 		o.Code,
 		string(o.Purpose),
 		o.ExpiresAt,
@@ -46,51 +48,10 @@ func (r *PostgresOTPRepository) Create(ctx context.Context, o *otp.OTP) error {
 	return nil
 }
 
-// GetByContactAndCode retrieves an OTP by contact and code
-func (r *PostgresOTPRepository) GetByContactAndCode(ctx context.Context, contact string, code string) (*otp.OTP, error) {
-	query := `
-        SELECT id, contact, code, purpose, expires_at, verified_at, attempts, max_attempts, created_at
-        FROM otps
-        WHERE contact = $1 AND code = $2
-        ORDER BY created_at DESC
-        LIMIT 1
-    `
-
-	var o otp.OTP
-	var verifiedAt sql.NullTime
-	var purposeStr string
-
-	err := r.db.QueryRowContext(ctx, query, contact, code).Scan(
-		&o.ID,
-		&o.Contact,
-		&o.Code,
-		&purposeStr,
-		&o.ExpiresAt,
-		&verifiedAt,
-		&o.Attempts,
-		&o.MaxAttempts, // ✅ Added
-		&o.CreatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, otp.ErrInvalidOTP()
-	}
-	if err != nil {
-		return nil, errx.Wrap(err, "failed to get OTP", errx.TypeInternal)
-	}
-
-	o.Purpose = otp.OTPPurpose(purposeStr)
-	if verifiedAt.Valid {
-		o.VerifiedAt = &verifiedAt.Time
-	}
-
-	return &o, nil
-}
-
 // GetLatestByContact retrieves the most recent OTP for a contact and purpose
 func (r *PostgresOTPRepository) GetLatestByContact(ctx context.Context, contact string, purpose otp.OTPPurpose) (*otp.OTP, error) {
 	query := `
-        SELECT id, contact, code, purpose, expires_at, verified_at, attempts, max_attempts, created_at
+        SELECT id, contact, channel, code, purpose, expires_at, verified_at, attempts, max_attempts, created_at
         FROM otps
         WHERE contact = $1 AND purpose = $2
         ORDER BY created_at DESC
@@ -99,11 +60,12 @@ func (r *PostgresOTPRepository) GetLatestByContact(ctx context.Context, contact
 
 	var o otp.OTP
 	var verifiedAt sql.NullTime
-	var purposeStr string
+	var purposeStr, channelStr string
 
-	err := r.db.QueryRowContext(ctx, query, contact, string(purpose)).Scan(
+	err := dbx.Executor(ctx, r.db).QueryRowxContext(ctx, query, contact, string(purpose)).Scan(
 		&o.ID,
 		&o.Contact,
+		&channelStr,
 		&o.Code,
 		&purposeStr,
 		&o.ExpiresAt,
@@ -114,6 +76,7 @@ func (r *PostgresOTPRepository) GetLatestByContact(ctx context.Context, contact
 	)
 
 	if err == sql.ErrNoRows {
+		logLookup(contact, purpose, nil)
 		return nil, nil // No OTP found is not an error in this case
 	}
 	if err != nil {
@@ -121,10 +84,12 @@ func (r *PostgresOTPRepository) GetLatestByContact(ctx context.Context, contact
 	}
 
 	o.Purpose = otp.OTPPurpose(purposeStr)
+	o.Channel = otp.Channel(channelStr)
 	if verifiedAt.Valid {
 		o.VerifiedAt = &verifiedAt.Time
 	}
 
+	logLookup(contact, purpose, &o)
 	return &o, nil
 }
 
@@ -141,7 +106,7 @@ func (r *PostgresOTPRepository) Update(ctx context.Context, o *otp.OTP) error {
 		verifiedAt = *o.VerifiedAt
 	}
 
-	result, err := r.db.ExecContext(
+	result, err := dbx.Executor(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		verifiedAt,
@@ -166,6 +131,43 @@ func (r *PostgresOTPRepository) Update(ctx context.Context, o *otp.OTP) error {
 	return nil
 }
 
+// IncrementAttempts consumes one verification attempt in a single UPDATE so
+// concurrent verifications can't go past max_attempts
+func (r *PostgresOTPRepository) IncrementAttempts(ctx context.Context, o *otp.OTP) (int, error) {
+	query := `
+        UPDATE otps
+        SET attempts = attempts + 1, updated_at = $1
+        WHERE id = $2 AND attempts < max_attempts
+        RETURNING attempts
+    `
+
+	var attempts int
+	err := dbx.Executor(ctx, r.db).QueryRowxContext(ctx, query, time.Now(), o.ID).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return 0, otp.ErrTooManyAttempts()
+	}
+	if err != nil {
+		return 0, errx.Wrap(err, "failed to increment OTP attempts", errx.TypeInternal)
+	}
+
+	return attempts, nil
+}
+
+// DeleteByContact removes every OTP sent to contact
+func (r *PostgresOTPRepository) DeleteByContact(ctx context.Context, contact string) error {
+	query := `
+        DELETE FROM otps
+        WHERE contact = $1
+    `
+
+	_, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, contact)
+	if err != nil {
+		return errx.Wrap(err, "failed to delete OTPs", errx.TypeInternal)
+	}
+
+	return nil
+}
+
 // DeleteExpired removes all expired OTPs from the database
 func (r *PostgresOTPRepository) DeleteExpired(ctx context.Context) error {
 	query := `
@@ -173,7 +175,7 @@ func (r *PostgresOTPRepository) DeleteExpired(ctx context.Context) error {
         WHERE expires_at < $1
     `
 
-	_, err := r.db.ExecContext(ctx, query, time.Now())
+	_, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, time.Now())
 	if err != nil {
 		return errx.Wrap(err, "failed to delete expired OTPs", errx.TypeInternal)
 	}
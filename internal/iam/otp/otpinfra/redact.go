@@ -0,0 +1,41 @@
+package otpinfra
+
+import (
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// debugEnabled avoids building log fields for lookups when debug logging is off
+func debugEnabled() bool {
+	return logx.GetDefaultLogger().GetLevel().Enabled(logx.LevelDebug)
+}
+
+// otpLogFields describes an OTP lookup without exposing the code or the full
+// contact. No fingerprint of the code is logged: with only 10^6 possible codes
+// any unkeyed hash of one is trivially reversed.
+func otpLogFields(contact string, purpose otp.OTPPurpose, o *otp.OTP) logx.Fields {
+	fields := logx.Fields{
+		"contact": otp.MaskContact(contact),
+		"purpose": purpose,
+	}
+	if o != nil {
+		fields["otp_id"] = o.ID
+		fields["attempts"] = o.Attempts
+		fields["max_attempts"] = o.MaxAttempts
+		fields["expired"] = o.IsExpired()
+		fields["verified"] = o.VerifiedAt != nil
+	}
+	return fields
+}
+
+// logLookup logs the result of an OTP lookup at debug level
+func logLookup(contact string, purpose otp.OTPPurpose, o *otp.OTP) {
+	if !debugEnabled() {
+		return
+	}
+	if o == nil {
+		logx.WithFields(otpLogFields(contact, purpose, nil)).Debug("OTP lookup: no code")
+		return
+	}
+	logx.WithFields(otpLogFields(contact, purpose, o)).Debug("OTP lookup: code found")
+}
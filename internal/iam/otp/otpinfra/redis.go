@@ -0,0 +1,186 @@
+package otpinfra
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/redis/go-redis/v9"
+)
+
+// updateScript persists verified_at only if the key still holds the same OTP
+// (a newer code for the contact may have replaced it)
+var updateScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "id") ~= ARGV[1] then
+	return 0
+end
+redis.call("HSET", KEYS[1], "verified_at", ARGV[2])
+return 1
+`)
+
+// incrementAttemptsScript consumes one attempt unless max_attempts was reached.
+// Returns -1 if the OTP is gone and -2 if no attempts are left.
+var incrementAttemptsScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "id") ~= ARGV[1] then
+	return -1
+end
+local attempts = tonumber(redis.call("HGET", KEYS[1], "attempts"))
+local maxAttempts = tonumber(redis.call("HGET", KEYS[1], "max_attempts"))
+if attempts >= maxAttempts then
+	return -2
+end
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+`)
+
+// RedisOTPRepository stores OTPs as Redis hashes keyed by contact+purpose.
+// Keys expire with the code, so no cleanup job is needed.
+type RedisOTPRepository struct {
+	client *redis.Client
+}
+
+func NewRedisOTPRepository(client *redis.Client) *RedisOTPRepository {
+	return &RedisOTPRepository{client: client}
+}
+
+func otpKey(contact string, purpose otp.OTPPurpose) string {
+	return fmt.Sprintf("otp:%s:%s", purpose, contact)
+}
+
+// Create stores the OTP, replacing any previous code for the same contact+purpose
+func (r *RedisOTPRepository) Create(ctx context.Context, o *otp.OTP) error {
+	key := otpKey(o.Contact, o.Purpose)
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, map[string]any{
+			"id":           o.ID,
+			"contact":      o.Contact,
+			"channel":      string(o.Channel),
+			"code":         o.Code,
+			"purpose":      string(o.Purpose),
+			"expires_at":   o.ExpiresAt.Format(time.RFC3339Nano),
+			"attempts":     o.Attempts,
+			"max_attempts": o.MaxAttempts,
+			"created_at":   o.CreatedAt.Format(time.RFC3339Nano),
+		})
+		pipe.ExpireAt(ctx, key, o.ExpiresAt)
+		return nil
+	})
+	if err != nil {
+		return errx.Wrap(err, "failed to create OTP", errx.TypeInternal)
+	}
+
+	return nil
+}
+
+// GetLatestByContact retrieves the current OTP for a contact and purpose
+func (r *RedisOTPRepository) GetLatestByContact(ctx context.Context, contact string, purpose otp.OTPPurpose) (*otp.OTP, error) {
+	fields, err := r.client.HGetAll(ctx, otpKey(contact, purpose)).Result()
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to get latest OTP", errx.TypeInternal)
+	}
+	if len(fields) == 0 {
+		logLookup(contact, purpose, nil)
+		return nil, nil // No OTP found is not an error in this case
+	}
+
+	o, err := otpFromHash(fields)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to decode OTP", errx.TypeInternal).
+			WithDetail("contact", otp.MaskContact(contact))
+	}
+
+	logLookup(contact, purpose, o)
+	return o, nil
+}
+
+// Update persists the verification timestamp. Attempts are only changed
+// through IncrementAttempts.
+func (r *RedisOTPRepository) Update(ctx context.Context, o *otp.OTP) error {
+	verifiedAt := ""
+	if o.VerifiedAt != nil {
+		verifiedAt = o.VerifiedAt.Format(time.RFC3339Nano)
+	}
+
+	updated, err := updateScript.Run(ctx, r.client, []string{otpKey(o.Contact, o.Purpose)}, o.ID, verifiedAt).Int()
+	if err != nil {
+		return errx.Wrap(err, "failed to update OTP", errx.TypeInternal)
+	}
+	if updated == 0 {
+		return errx.New("OTP not found", errx.TypeNotFound)
+	}
+
+	return nil
+}
+
+// IncrementAttempts consumes one verification attempt atomically
+func (r *RedisOTPRepository) IncrementAttempts(ctx context.Context, o *otp.OTP) (int, error) {
+	attempts, err := incrementAttemptsScript.Run(ctx, r.client, []string{otpKey(o.Contact, o.Purpose)}, o.ID).Int()
+	if err != nil {
+		return 0, errx.Wrap(err, "failed to increment OTP attempts", errx.TypeInternal)
+	}
+
+	switch attempts {
+	case -1:
+		return 0, errx.New("OTP not found", errx.TypeNotFound)
+	case -2:
+		return 0, otp.ErrTooManyAttempts()
+	}
+
+	return attempts, nil
+}
+
+// DeleteByContact removes the code of every purpose sent to contact
+func (r *RedisOTPRepository) DeleteByContact(ctx context.Context, contact string) error {
+	purposes := otp.Purposes()
+	keys := make([]string, len(purposes))
+	for i, purpose := range purposes {
+		keys[i] = otpKey(contact, purpose)
+	}
+
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return errx.Wrap(err, "failed to delete OTPs", errx.TypeInternal)
+	}
+	return nil
+}
+
+// DeleteExpired is a no-op: Redis expires OTP keys on its own
+func (r *RedisOTPRepository) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+func otpFromHash(fields map[string]string) (*otp.OTP, error) {
+	o := &otp.OTP{
+		ID:      fields["id"],
+		Contact: fields["contact"],
+		Channel: otp.Channel(fields["channel"]),
+		Code:    fields["code"],
+		Purpose: otp.OTPPurpose(fields["purpose"]),
+	}
+
+	var err error
+	if o.ExpiresAt, err = time.Parse(time.RFC3339Nano, fields["expires_at"]); err != nil {
+		return nil, err
+	}
+	if o.CreatedAt, err = time.Parse(time.RFC3339Nano, fields["created_at"]); err != nil {
+		return nil, err
+	}
+	if o.Attempts, err = strconv.Atoi(fields["attempts"]); err != nil {
+		return nil, err
+	}
+	if o.MaxAttempts, err = strconv.Atoi(fields["max_attempts"]); err != nil {
+		return nil, err
+	}
+	if raw := fields["verified_at"]; raw != "" {
+		verifiedAt, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, err
+		}
+		o.VerifiedAt = &verifiedAt
+	}
+
+	return o, nil
+}
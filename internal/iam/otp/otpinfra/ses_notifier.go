@@ -0,0 +1,43 @@
+package otpinfra
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+	"github.com/Abraxas-365/manifesto/internal/notifx/notifxses"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+)
+
+// SESNotifier sends OTP codes by email through AWS SES
+type SESNotifier struct {
+	sender     notifx.EmailSender
+	cfg        *config.EmailConfig
+	expiration time.Duration
+}
+
+// NewSESNotifier creates an OTP notifier backed by AWS SES. expiration is the
+// OTP lifetime shown in the email (config.OTPConfig.ExpirationTime).
+func NewSESNotifier(client *ses.Client, cfg *config.EmailConfig, expiration time.Duration) *SESNotifier {
+	return &SESNotifier{
+		sender:     notifxses.NewSESProvider(client, formatFrom(cfg)),
+		cfg:        cfg,
+		expiration: expiration,
+	}
+}
+
+// SendOTP renders the verification email and sends it to contact
+func (n *SESNotifier) SendOTP(ctx context.Context, contact string, code string) error {
+	msg, err := buildOTPEmail(n.cfg, contact, code, n.expiration)
+	if err != nil {
+		return otp.ErrSendFailed(err).WithDetail("provider", "ses")
+	}
+
+	if err := n.sender.SendEmail(ctx, msg); err != nil {
+		return otp.ErrSendFailed(err).WithDetail("provider", "ses")
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package otpinfra
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+	"github.com/Abraxas-365/manifesto/internal/notifx/notifxsmtp"
+)
+
+// SMTPNotifier sends OTP codes by email through an SMTP server
+type SMTPNotifier struct {
+	sender     notifx.EmailSender
+	cfg        *config.EmailConfig
+	expiration time.Duration
+}
+
+// NewSMTPNotifier creates an OTP notifier using the SMTP settings of
+// config.EmailConfig. expiration is the OTP lifetime shown in the email.
+func NewSMTPNotifier(cfg *config.EmailConfig, expiration time.Duration) *SMTPNotifier {
+	return &SMTPNotifier{
+		sender: notifxsmtp.NewSMTPProvider(notifxsmtp.Config{
+			Host:        cfg.SMTPHost,
+			Port:        cfg.SMTPPort,
+			Username:    cfg.SMTPUsername,
+			Password:    cfg.SMTPPassword,
+			FromAddress: cfg.FromAddress,
+		}),
+		cfg:        cfg,
+		expiration: expiration,
+	}
+}
+
+// SendOTP renders the verification email and sends it to contact
+func (n *SMTPNotifier) SendOTP(ctx context.Context, contact string, code string) error {
+	msg, err := buildOTPEmail(n.cfg, contact, code, n.expiration)
+	if err != nil {
+		return otp.ErrSendFailed(err).WithDetail("provider", "smtp")
+	}
+
+	if err := n.sender.SendEmail(ctx, msg); err != nil {
+		return otp.ErrSendFailed(err).WithDetail("provider", "smtp")
+	}
+
+	return nil
+}
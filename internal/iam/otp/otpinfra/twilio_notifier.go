@@ -0,0 +1,72 @@
+package otpinfra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+)
+
+const twilioAPIBaseURL = "https://api.twilio.com/2010-04-01"
+
+// TwilioNotifier sends OTP codes by SMS through the Twilio REST API
+type TwilioNotifier struct {
+	httpClient *http.Client
+	cfg        *config.SMSConfig
+	appName    string
+	expiration time.Duration
+}
+
+// NewTwilioNotifier creates an OTP notifier backed by Twilio. appName is shown
+// in the message body and expiration is the OTP lifetime.
+func NewTwilioNotifier(cfg *config.SMSConfig, appName string, expiration time.Duration) *TwilioNotifier {
+	return &TwilioNotifier{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		cfg:        cfg,
+		appName:    appName,
+		expiration: expiration,
+	}
+}
+
+// SendOTP sends the code to contact, which must be an E.164 phone number
+func (n *TwilioNotifier) SendOTP(ctx context.Context, contact string, code string) error {
+	form := url.Values{}
+	form.Set("To", contact)
+	form.Set("Body", fmt.Sprintf("Your %s verification code is %s. It expires in %s.",
+		n.appName, code, formatExpiration(n.expiration)))
+
+	if n.cfg.TwilioMessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", n.cfg.TwilioMessagingServiceSID)
+	} else {
+		form.Set("From", n.cfg.TwilioFromNumber)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPIBaseURL, n.cfg.TwilioAccountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return otp.ErrSendFailed(err).WithDetail("provider", "twilio")
+	}
+	req.SetBasicAuth(n.cfg.TwilioAccountSID, n.cfg.TwilioAuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return otp.ErrSendFailed(err).WithDetail("provider", "twilio")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return otp.ErrSendFailed(fmt.Errorf("twilio returned status %d: %s", resp.StatusCode, body)).
+			WithDetail("provider", "twilio").
+			WithDetail("status", resp.StatusCode)
+	}
+
+	return nil
+}
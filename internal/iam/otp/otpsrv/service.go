@@ -9,43 +9,70 @@ import (
 	"github.com/Abraxas-365/manifesto/internal/config"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/Abraxas-365/manifesto/internal/logx"
 	"github.com/google/uuid"
 )
 
 type OTPService struct {
-	repo                otp.Repository
-	notificationService otp.NotificationService
-	config              *config.OTPConfig
+	repo      otp.Repository
+	notifiers map[otp.Channel]otp.NotificationService
+	outbox    outbox.Outbox
+	config    *config.OTPConfig
 }
 
+// NewOTPService creates the OTP service. notificationService delivers email
+// codes; smsNotificationService delivers phone codes and may be nil, in which
+// case phone contacts are rejected. With a non-nil outbox codes are delivered
+// in the background instead of during the request.
 func NewOTPService(
 	repo otp.Repository,
 	notificationService otp.NotificationService,
+	smsNotificationService otp.NotificationService,
+	outbox outbox.Outbox,
 	cfg *config.OTPConfig,
 ) *OTPService {
+	notifiers := map[otp.Channel]otp.NotificationService{
+		otp.ChannelEmail: notificationService,
+	}
+	if smsNotificationService != nil {
+		notifiers[otp.ChannelPhone] = smsNotificationService
+	}
+
 	return &OTPService{
-		repo:                repo,
-		notificationService: notificationService,
-		config:              cfg,
+		repo:      repo,
+		notifiers: notifiers,
+		outbox:    outbox,
+		config:    cfg,
 	}
 }
 
-// GenerateOTP creates and sends an OTP
+// GenerateOTP creates and sends an OTP through the notifier of the contact's
+// channel (E.164 phone numbers go by SMS, everything else by email). With the
+// outbox the code is enqueued in the same transaction as the OTP (and as the
+// caller's writes when ctx already carries one); the Redis OTP store does not
+// take part in the transaction.
 func (s *OTPService) GenerateOTP(ctx context.Context, contact string, purpose otp.OTPPurpose) (*otp.OTP, error) {
-	// Rate limiting check
+	channel := otp.ChannelFor(contact)
+	notifier, ok := s.notifiers[channel]
+	if !ok {
+		return nil, otp.ErrChannelDisabled().WithDetail("channel", string(channel))
+	}
+
+	// Rate limiting: one code per contact+purpose per RateLimitWindow, whether
+	// the previous one was used, expired or exhausted
 	existing, _ := s.repo.GetLatestByContact(ctx, contact, purpose)
-	if existing != nil && existing.IsValid() {
-		timeSinceCreation := time.Since(existing.CreatedAt)
-		if timeSinceCreation < s.config.RateLimitWindow {
-			return nil, otp.ErrTooManyRequests().WithDetail(
-				"retry_after",
-				s.config.RateLimitWindow.String(),
-			)
+	if existing != nil {
+		if wait := s.config.RateLimitWindow - time.Since(existing.CreatedAt); wait > 0 {
+			return nil, otp.ErrOTPRateLimited(wait)
 		}
 	}
 
-	// Generate code with configurable length
-	code, err := otp.GenerateOTPCode(s.config.CodeLength)
+	// Generate code with configurable length and charset
+	code, err := otp.GenerateOTPCode(otp.CodePolicy{
+		Length:  s.config.CodeLength,
+		Charset: otp.CodeCharset(s.config.Charset),
+	})
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to generate OTP code", errx.TypeInternal)
 	}
@@ -54,6 +81,7 @@ func (s *OTPService) GenerateOTP(ctx context.Context, contact string, purpose ot
 	newOTP := &otp.OTP{
 		ID:          uuid.NewString(),
 		Contact:     contact,
+		Channel:     channel,
 		Code:        code,
 		Purpose:     purpose,
 		ExpiresAt:   time.Now().UTC().Add(s.config.ExpirationTime),
@@ -62,21 +90,92 @@ func (s *OTPService) GenerateOTP(ctx context.Context, contact string, purpose ot
 		CreatedAt:   time.Now(),
 	}
 
+	if s.outbox != nil {
+		err := s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.repo.Create(ctx, newOTP); err != nil {
+				return errx.Wrap(err, "failed to save OTP", errx.TypeInternal)
+			}
+			return s.outbox.Enqueue(ctx, outbox.KindOTPCode, otp.CodeMessage{
+				OTPID:     newOTP.ID,
+				Contact:   newOTP.Contact,
+				Purpose:   newOTP.Purpose,
+				ExpiresAt: newOTP.ExpiresAt,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		return newOTP, nil
+	}
+
 	// Save OTP
 	if err := s.repo.Create(ctx, newOTP); err != nil {
 		return nil, errx.Wrap(err, "failed to save OTP", errx.TypeInternal)
 	}
 
 	// Send notification
-	if err := s.notificationService.SendOTP(ctx, contact, code); err != nil {
-		return nil, errx.Wrap(err, "failed to send OTP", errx.TypeExternal)
+	if err := notifier.SendOTP(ctx, contact, code); err != nil {
+		if otp.IsSendFailed(err) {
+			return nil, err
+		}
+		return nil, otp.ErrSendFailed(err)
 	}
 
 	return newOTP, nil
 }
 
-// VerifyOTP validates an OTP code. Looks up by contact+purpose (not code)
-// so that attempts are always incremented regardless of whether the code matches.
+// DeliversAsync reports whether codes go through the outbox, in which case
+// GenerateOTP only writes to the database and makes no external call.
+func (s *OTPService) DeliversAsync() bool {
+	return s.outbox != nil
+}
+
+// DeliverOTP is the outbox handler for KindOTPCode. The code is loaded from
+// the OTP store; codes that expired, were used or were replaced by a newer
+// one before they could be delivered are dropped.
+func (s *OTPService) DeliverOTP(ctx context.Context, msg *outbox.Message) error {
+	var code otp.CodeMessage
+	if err := msg.Decode(&code); err != nil {
+		return err
+	}
+
+	logger := logx.FromContext(ctx).WithField("message_id", msg.ID)
+	if time.Now().After(code.ExpiresAt) {
+		logger.Info("skipping expired OTP code")
+		return nil
+	}
+
+	latest, err := s.repo.GetLatestByContact(ctx, code.Contact, code.Purpose)
+	if err != nil {
+		return err
+	}
+	if latest == nil || latest.ID != code.OTPID || !latest.IsValid() {
+		logger.Info("skipping superseded OTP code")
+		return nil
+	}
+
+	channel := otp.ChannelFor(code.Contact)
+	notifier, ok := s.notifiers[channel]
+	if !ok {
+		return otp.ErrChannelDisabled().WithDetail("channel", string(channel))
+	}
+
+	if err := notifier.SendOTP(ctx, code.Contact, latest.Code); err != nil {
+		if otp.IsSendFailed(err) {
+			return err
+		}
+		return otp.ErrSendFailed(err)
+	}
+	return nil
+}
+
+// VerifyOTP validates an OTP code issued for purpose. Looks up by
+// contact+purpose (not code) so that attempts are always incremented
+// regardless of whether the code matches. Codes issued for another purpose are
+// never consulted: they fail like any wrong guess, so a flow cannot be used to
+// probe another flow's live code. The wrong guess that uses the last attempt
+// locks the OTP and returns ErrTooManyAttempts; a correct code on that last
+// attempt still succeeds.
 func (s *OTPService) VerifyOTP(ctx context.Context, contact string, code string, purpose otp.OTPPurpose) (*otp.OTP, error) {
 	// Look up by contact, not by code — this ensures we always find the OTP
 	// entity and can track attempts even when the wrong code is provided.
@@ -93,17 +192,22 @@ func (s *OTPService) VerifyOTP(ctx context.Context, contact string, code string,
 		return nil, otp.ErrOTPAlreadyUsed()
 	}
 
-	if otpEntity.Attempts >= otpEntity.MaxAttempts {
+	if otpEntity.AttemptsExhausted() {
 		return nil, otp.ErrTooManyAttempts()
 	}
 
-	// Always increment attempts before checking the code
-	otpEntity.IncrementAttempts()
+	// Always consume an attempt before checking the code. The increment is
+	// atomic in the repository so concurrent guesses can't exceed MaxAttempts.
+	attempts, err := s.repo.IncrementAttempts(ctx, otpEntity)
+	if err != nil {
+		return nil, err
+	}
+	otpEntity.Attempts = attempts
 
-	if otpEntity.Code != code {
-		// Wrong code — persist the incremented attempt count
-		if err := s.repo.Update(ctx, otpEntity); err != nil {
-			return nil, errx.Wrap(err, "failed to update OTP attempts", errx.TypeInternal)
+	if !otpEntity.Matches(code) {
+		// The last wrong guess locks the code
+		if otpEntity.AttemptsExhausted() {
+			return nil, otp.ErrTooManyAttempts()
 		}
 		remainingAttempts := otpEntity.MaxAttempts - otpEntity.Attempts
 		return nil, otp.ErrInvalidOTP().WithDetail("attempts_remaining", remainingAttempts)
@@ -0,0 +1,171 @@
+package otpsrv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+)
+
+// latestOTPRepo implements the parts of otp.Repository used by DeliverOTP
+type latestOTPRepo struct {
+	otp.Repository
+	latest *otp.OTP
+}
+
+func (r *latestOTPRepo) GetLatestByContact(context.Context, string, otp.OTPPurpose) (*otp.OTP, error) {
+	return r.latest, nil
+}
+
+type recordingNotifier struct {
+	sent []string
+}
+
+func (n *recordingNotifier) SendOTP(_ context.Context, _ string, code string) error {
+	n.sent = append(n.sent, code)
+	return nil
+}
+
+func TestDeliverOTPLoadsCodeFromStore(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Minute)
+
+	current := &otp.OTP{
+		ID:          "otp-2",
+		Contact:     "dev@example.com",
+		Code:        "123456",
+		Purpose:     otp.OTPPurposeVerification,
+		ExpiresAt:   expiresAt,
+		MaxAttempts: 3,
+	}
+	repo := &latestOTPRepo{latest: current}
+	notifier := &recordingNotifier{}
+	svc := NewOTPService(repo, notifier, nil, nil, nil)
+
+	deliver := func(otpID string) {
+		t.Helper()
+		msg, err := outbox.NewMessage("m-"+otpID, outbox.KindOTPCode, otp.CodeMessage{
+			OTPID:     otpID,
+			Contact:   current.Contact,
+			Purpose:   current.Purpose,
+			ExpiresAt: expiresAt,
+		}, 3)
+		if err != nil {
+			t.Fatalf("NewMessage: %v", err)
+		}
+		if err := svc.DeliverOTP(ctx, msg); err != nil {
+			t.Fatalf("DeliverOTP(%s): %v", otpID, err)
+		}
+	}
+
+	deliver("otp-1")
+	if len(notifier.sent) != 0 {
+		t.Fatalf("superseded OTP was sent: %v", notifier.sent)
+	}
+
+	deliver("otp-2")
+	if len(notifier.sent) != 1 || notifier.sent[0] != "123456" {
+		t.Fatalf("sent = %v, want [123456]", notifier.sent)
+	}
+}
+
+// purposeOTPRepo keeps one OTP per purpose, like the OTP stores
+type purposeOTPRepo struct {
+	otp.Repository
+	otps map[otp.OTPPurpose]*otp.OTP
+}
+
+func (r *purposeOTPRepo) GetLatestByContact(_ context.Context, _ string, purpose otp.OTPPurpose) (*otp.OTP, error) {
+	return r.otps[purpose], nil
+}
+
+func (r *purposeOTPRepo) IncrementAttempts(_ context.Context, o *otp.OTP) (int, error) {
+	o.Attempts++
+	return o.Attempts, nil
+}
+
+func (r *purposeOTPRepo) Update(context.Context, *otp.OTP) error { return nil }
+
+func TestVerifyOTPDoesNotRevealOtherPurposeCodes(t *testing.T) {
+	ctx := context.Background()
+	newOTP := func(code string, purpose otp.OTPPurpose) *otp.OTP {
+		return &otp.OTP{Contact: "dev@example.com", Code: code, Purpose: purpose, ExpiresAt: time.Now().Add(time.Minute), MaxAttempts: 3}
+	}
+	login := newOTP("111111", otp.OTPPurposeLogin)
+	signup := newOTP("222222", otp.OTPPurposeSignup)
+	repo := &purposeOTPRepo{otps: map[otp.OTPPurpose]*otp.OTP{
+		otp.OTPPurposeLogin:  login,
+		otp.OTPPurposeSignup: signup,
+	}}
+	svc := NewOTPService(repo, &recordingNotifier{}, nil, nil, nil)
+
+	// The live login code on signup is a plain wrong guess that costs a
+	// signup attempt
+	if _, err := svc.VerifyOTP(ctx, "dev@example.com", "111111", otp.OTPPurposeSignup); !otp.IsInvalid(err) {
+		t.Fatalf("login code on signup: err = %v, want INVALID_OTP", err)
+	}
+	if signup.Attempts != 1 {
+		t.Errorf("signup attempts = %d, want 1", signup.Attempts)
+	}
+
+	// Without a signup code the login code is not consulted either
+	delete(repo.otps, otp.OTPPurposeSignup)
+	for range 5 {
+		if _, err := svc.VerifyOTP(ctx, "dev@example.com", "111111", otp.OTPPurposeSignup); !otp.IsInvalid(err) {
+			t.Fatalf("login code without signup code: err = %v, want INVALID_OTP", err)
+		}
+	}
+	if login.VerifiedAt != nil || login.Attempts != 0 {
+		t.Fatalf("login code touched by signup verification: %+v", login)
+	}
+
+	if _, err := svc.VerifyOTP(ctx, "dev@example.com", "111111", otp.OTPPurposeLogin); err != nil {
+		t.Fatalf("login code on login: %v", err)
+	}
+}
+
+func TestVerifyOTPAttemptBoundary(t *testing.T) {
+	ctx := context.Background()
+	setup := func() (*OTPService, *otp.OTP) {
+		current := &otp.OTP{Contact: "dev@example.com", Code: "123456", Purpose: otp.OTPPurposeLogin, ExpiresAt: time.Now().Add(time.Minute), MaxAttempts: 3}
+		repo := &purposeOTPRepo{otps: map[otp.OTPPurpose]*otp.OTP{otp.OTPPurposeLogin: current}}
+		return NewOTPService(repo, &recordingNotifier{}, nil, nil, nil), current
+	}
+	verify := func(svc *OTPService, code string) error {
+		_, err := svc.VerifyOTP(ctx, "dev@example.com", code, otp.OTPPurposeLogin)
+		return err
+	}
+
+	t.Run("correct code on the last attempt", func(t *testing.T) {
+		svc, current := setup()
+		for i := range 2 {
+			if err := verify(svc, "000000"); otp.IsTooManyAttempts(err) || err == nil {
+				t.Fatalf("wrong guess %d: err = %v, want INVALID_OTP", i+1, err)
+			}
+		}
+		if err := verify(svc, "123456"); err != nil {
+			t.Fatalf("correct code on attempt 3: %v", err)
+		}
+		if current.VerifiedAt == nil || current.Attempts != 3 {
+			t.Fatalf("verified_at = %v, attempts = %d", current.VerifiedAt, current.Attempts)
+		}
+	})
+
+	t.Run("last wrong guess locks the code", func(t *testing.T) {
+		svc, current := setup()
+		for range 2 {
+			_ = verify(svc, "000000")
+		}
+		if err := verify(svc, "000000"); !otp.IsTooManyAttempts(err) {
+			t.Fatalf("wrong guess 3: err = %v, want TOO_MANY_ATTEMPTS", err)
+		}
+		if err := verify(svc, "123456"); !otp.IsTooManyAttempts(err) {
+			t.Fatalf("correct code after lockout: err = %v, want TOO_MANY_ATTEMPTS", err)
+		}
+		if current.IsValid() || current.Attempts != 3 {
+			t.Fatalf("locked OTP: valid = %v, attempts = %d", current.IsValid(), current.Attempts)
+		}
+	})
+}
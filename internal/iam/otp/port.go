@@ -4,10 +4,14 @@ import "context"
 
 type Repository interface {
 	Create(ctx context.Context, otp *OTP) error
-	GetByContactAndCode(ctx context.Context, contact string, code string) (*OTP, error)
 	GetLatestByContact(ctx context.Context, contact string, purpose OTPPurpose) (*OTP, error)
 	Update(ctx context.Context, otp *OTP) error
+	// IncrementAttempts atomically consumes one verification attempt and returns
+	// the new count, or ErrTooManyAttempts once MaxAttempts is reached
+	IncrementAttempts(ctx context.Context, otp *OTP) (int, error)
 	DeleteExpired(ctx context.Context) error
+	// DeleteByContact removes every code sent to contact, whatever its purpose
+	DeleteByContact(ctx context.Context, contact string) error
 }
 
 // NotificationService is a generic interface for sending OTP codes
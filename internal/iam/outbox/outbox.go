@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+// ============================================================================
+// Outbox Message Entity
+// ============================================================================
+
+// Kind identifica el tipo de efecto secundario y el handler que lo entrega
+type Kind string
+
+const (
+	KindInvitationEmail Kind = "INVITATION_EMAIL" // Email con el enlace de una invitación
+	KindOTPCode         Kind = "OTP_CODE"         // Código OTP por email o SMS
+	KindWebhookEvent    Kind = "WEBHOOK_EVENT"    // Evento IAM a repartir entre los endpoints suscritos
+	KindWebhookDelivery Kind = "WEBHOOK_DELIVERY" // Evento IAM hacia un endpoint de un tenant
+)
+
+// MessageStatus define los posibles estados de un mensaje
+type MessageStatus string
+
+const (
+	MessageStatusPending MessageStatus = "PENDING" // Pendiente de entrega o de reintento
+	MessageStatusSent    MessageStatus = "SENT"    // Entregado
+	MessageStatusFailed  MessageStatus = "FAILED"  // Agotó sus intentos
+)
+
+// Message es un efecto secundario guardado en la misma transacción que la
+// escritura de dominio que lo origina. El dispatcher lo entrega después, al
+// menos una vez, reintentando con backoff hasta MaxAttempts.
+type Message struct {
+	ID            string        `db:"id" json:"id"`
+	Kind          Kind          `db:"kind" json:"kind"`
+	Payload       string        `db:"payload" json:"payload"` // JSON serializado
+	Status        MessageStatus `db:"status" json:"status"`
+	Attempts      int           `db:"attempts" json:"attempts"`
+	MaxAttempts   int           `db:"max_attempts" json:"max_attempts"`
+	NextAttemptAt time.Time     `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     *string       `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time     `db:"updated_at" json:"updated_at"`
+	SentAt        *time.Time    `db:"sent_at" json:"sent_at,omitempty"`
+}
+
+// NewMessage crea un mensaje pendiente, listo para entregarse de inmediato
+func NewMessage(id string, kind Kind, payload any, maxAttempts int) (*Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, ErrInvalidPayload().WithDetail("kind", string(kind))
+	}
+
+	now := time.Now()
+	return &Message{
+		ID:            id,
+		Kind:          kind,
+		Payload:       string(data),
+		Status:        MessageStatusPending,
+		MaxAttempts:   maxAttempts,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
+
+// Decode deserializa el payload del mensaje
+func (m *Message) Decode(v any) error {
+	if err := json.Unmarshal([]byte(m.Payload), v); err != nil {
+		return ErrInvalidPayload().
+			WithDetail("message_id", m.ID).
+			WithDetail("kind", string(m.Kind))
+	}
+	return nil
+}
+
+// CanRetry indica si al mensaje le quedan intentos. Attempts ya incluye el
+// intento en curso, que se cuenta al reclamarlo.
+func (m *Message) CanRetry() bool {
+	return m.Attempts < m.MaxAttempts
+}
+
+// RetryDelay calcula el backoff exponencial del próximo intento:
+// base, 2*base, 4*base... sin superar max
+func (m *Message) RetryDelay(base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < m.Attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
+
+// ============================================================================
+// Error Registry
+// ============================================================================
+
+var ErrRegistry = errx.NewRegistry("OUTBOX")
+
+var (
+	CodeInvalidPayload = ErrRegistry.Register("INVALID_PAYLOAD", errx.TypeValidation, http.StatusBadRequest, "Invalid outbox message payload")
+	CodeNoHandler      = ErrRegistry.Register("NO_HANDLER", errx.TypeInternal, http.StatusInternalServerError, "No handler registered for outbox message kind")
+	CodeEnqueueFailed  = ErrRegistry.Register("ENQUEUE_FAILED", errx.TypeInternal, http.StatusInternalServerError, "Failed to enqueue outbox message")
+)
+
+func ErrInvalidPayload() *errx.Error {
+	return ErrRegistry.New(CodeInvalidPayload)
+}
+
+func ErrNoHandler() *errx.Error {
+	return ErrRegistry.New(CodeNoHandler)
+}
+
+// ErrEnqueueFailed envuelve el error al guardar un mensaje en el outbox
+func ErrEnqueueFailed(cause error) *errx.Error {
+	return ErrRegistry.NewWithCause(CodeEnqueueFailed, cause)
+}
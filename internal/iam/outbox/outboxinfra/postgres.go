@@ -0,0 +1,147 @@
+package outboxinfra
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresOutboxRepository implementación de PostgreSQL para outbox.Repository
+type PostgresOutboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresOutboxRepository crea una nueva instancia del repositorio del outbox
+func NewPostgresOutboxRepository(db *sqlx.DB) outbox.Repository {
+	return &PostgresOutboxRepository{
+		db: db,
+	}
+}
+
+// Save guarda un mensaje nuevo
+func (r *PostgresOutboxRepository) Save(ctx context.Context, msg outbox.Message) error {
+	query := `
+		INSERT INTO outbox_messages (
+			id, kind, payload, status, attempts, max_attempts,
+			next_attempt_at, last_error, created_at, updated_at, sent_at
+		) VALUES (
+			:id, :kind, :payload, :status, :attempts, :max_attempts,
+			:next_attempt_at, :last_error, :created_at, :updated_at, :sent_at
+		)`
+
+	_, err := sqlx.NamedExecContext(ctx, dbx.Executor(ctx, r.db), query, msg)
+	if err != nil {
+		return outbox.ErrEnqueueFailed(err).
+			WithDetail("kind", string(msg.Kind))
+	}
+
+	return nil
+}
+
+// ClaimDue reclama mensajes vencidos. FOR UPDATE SKIP LOCKED evita que dos
+// instancias reclamen el mismo mensaje.
+func (r *PostgresOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	query := `
+		UPDATE outbox_messages SET
+			attempts = attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM outbox_messages
+			WHERE status = $3 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING
+			id, kind, payload, status, attempts, max_attempts,
+			next_attempt_at, last_error, created_at, updated_at, sent_at`
+
+	var messages []outbox.Message
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &messages, query, limit, lease.Seconds(), outbox.MessageStatusPending)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to claim outbox messages", errx.TypeInternal)
+	}
+
+	result := make([]*outbox.Message, len(messages))
+	for i := range messages {
+		result[i] = &messages[i]
+	}
+
+	return result, nil
+}
+
+// MarkSent marca un mensaje como entregado y vacía su payload
+func (r *PostgresOutboxRepository) MarkSent(ctx context.Context, id string) error {
+	query := `
+		UPDATE outbox_messages SET
+			status = $1,
+			payload = '{}',
+			sent_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2`
+
+	return r.update(ctx, "failed to mark outbox message as sent", id, query, outbox.MessageStatusSent, id)
+}
+
+// MarkRetry registra el error de un intento y programa el siguiente
+func (r *PostgresOutboxRepository) MarkRetry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE outbox_messages SET
+			last_error = $1,
+			next_attempt_at = $2,
+			updated_at = NOW()
+		WHERE id = $3`
+
+	return r.update(ctx, "failed to schedule outbox message retry", id, query, lastError, nextAttemptAt, id)
+}
+
+// MarkFailed marca un mensaje que agotó sus intentos y vacía su payload
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, id string, lastError string) error {
+	query := `
+		UPDATE outbox_messages SET
+			status = $1,
+			payload = '{}',
+			last_error = $2,
+			updated_at = NOW()
+		WHERE id = $3`
+
+	return r.update(ctx, "failed to mark outbox message as failed", id, query, outbox.MessageStatusFailed, lastError, id)
+}
+
+// DeleteSentBefore elimina los mensajes entregados antes de before
+func (r *PostgresOutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM outbox_messages WHERE status = $1 AND sent_at < $2`
+
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, outbox.MessageStatusSent, before)
+	if err != nil {
+		return 0, errx.Wrap(err, "failed to delete sent outbox messages", errx.TypeInternal)
+	}
+
+	return result.RowsAffected()
+}
+
+// update ejecuta un UPDATE sobre un mensaje y falla si no existe
+func (r *PostgresOutboxRepository) update(ctx context.Context, errMsg, id, query string, args ...any) error {
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return errx.Wrap(err, errMsg, errx.TypeInternal).
+			WithDetail("message_id", id)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errx.Wrap(err, "failed to get rows affected", errx.TypeInternal)
+	}
+
+	if rowsAffected == 0 {
+		return errx.New("outbox message not found", errx.TypeNotFound).
+			WithDetail("message_id", id)
+	}
+
+	return nil
+}
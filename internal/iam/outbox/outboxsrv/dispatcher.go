@@ -0,0 +1,137 @@
+package outboxsrv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+)
+
+// Dispatcher entrega en background los mensajes del outbox. Varias instancias
+// pueden correrlo a la vez: cada mensaje se reclama con un lease, así que la
+// entrega es al menos una vez y los handlers deben tolerar duplicados.
+type Dispatcher struct {
+	repo     outbox.Repository
+	handlers map[outbox.Kind]outbox.Handler
+	mu       sync.RWMutex
+	config   *config.OutboxConfig
+}
+
+// NewDispatcher crea un nuevo dispatcher del outbox
+func NewDispatcher(repo outbox.Repository, cfg *config.OutboxConfig) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		handlers: make(map[outbox.Kind]outbox.Handler),
+		config:   cfg,
+	}
+}
+
+// Register asocia el handler que entrega los mensajes de kind
+func (d *Dispatcher) Register(kind outbox.Kind, handler outbox.Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers[kind] = handler
+}
+
+// Start inicia el dispatcher hasta que ctx se cancele
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Time{}
+	for {
+		d.dispatchDue(ctx)
+
+		// Los mensajes enviados se purgan a lo sumo una vez por hora
+		if time.Since(lastPurge) >= time.Hour {
+			d.purgeSent(ctx)
+			lastPurge = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			logx.Info("Outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchDue entrega lotes de mensajes vencidos hasta vaciar la cola
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := d.repo.ClaimDue(ctx, d.config.BatchSize, d.config.Lease)
+		if err != nil {
+			logx.Errorf("Error claiming outbox messages: %v", err)
+			return
+		}
+
+		for _, msg := range messages {
+			d.deliver(ctx, msg)
+		}
+
+		if len(messages) < d.config.BatchSize {
+			return
+		}
+	}
+}
+
+// deliver ejecuta el handler de un mensaje y registra el resultado
+func (d *Dispatcher) deliver(ctx context.Context, msg *outbox.Message) {
+	d.mu.RLock()
+	handler, ok := d.handlers[msg.Kind]
+	d.mu.RUnlock()
+
+	var err error
+	if ok {
+		err = handler(ctx, msg)
+	} else {
+		err = outbox.ErrNoHandler().WithDetail("kind", string(msg.Kind))
+	}
+
+	if err == nil {
+		if err := d.repo.MarkSent(ctx, msg.ID); err != nil {
+			logx.Errorf("Error marking outbox message %s as sent: %v", msg.ID, err)
+		}
+		return
+	}
+
+	lastError := logx.Redact(err.Error())
+	log := logx.WithFields(logx.Fields{
+		"message_id": msg.ID,
+		"kind":       msg.Kind,
+		"attempts":   msg.Attempts,
+	})
+
+	if !msg.CanRetry() {
+		log.Errorf("Outbox message failed permanently: %s", lastError)
+		if err := d.repo.MarkFailed(ctx, msg.ID, lastError); err != nil {
+			logx.Errorf("Error marking outbox message %s as failed: %v", msg.ID, err)
+		}
+		return
+	}
+
+	delay := msg.RetryDelay(d.config.RetryBaseDelay, d.config.RetryMaxDelay)
+	log.Warnf("Outbox message delivery failed, retrying in %s: %s", delay, lastError)
+	if err := d.repo.MarkRetry(ctx, msg.ID, lastError, time.Now().Add(delay)); err != nil {
+		logx.Errorf("Error scheduling outbox message %s retry: %v", msg.ID, err)
+	}
+}
+
+// purgeSent elimina los mensajes enviados más antiguos que Retention
+func (d *Dispatcher) purgeSent(ctx context.Context) {
+	if d.config.Retention <= 0 {
+		return
+	}
+
+	removed, err := d.repo.DeleteSentBefore(ctx, time.Now().Add(-d.config.Retention))
+	if err != nil {
+		logx.Errorf("Error purging sent outbox messages: %v", err)
+	} else if removed > 0 {
+		logx.Infof("Purged %d sent outbox messages", removed)
+	}
+}
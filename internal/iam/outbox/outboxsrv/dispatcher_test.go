@@ -0,0 +1,112 @@
+package outboxsrv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+)
+
+// fakeRepository registra las transiciones que el dispatcher aplica
+type fakeRepository struct {
+	due     []*outbox.Message
+	sent    []string
+	retried map[string]time.Time
+	failed  []string
+}
+
+func (r *fakeRepository) Save(ctx context.Context, msg outbox.Message) error { return nil }
+
+func (r *fakeRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	n := min(limit, len(r.due))
+	claimed := r.due[:n]
+	r.due = r.due[n:]
+	for _, msg := range claimed {
+		msg.Attempts++
+	}
+	return claimed, nil
+}
+
+func (r *fakeRepository) MarkSent(ctx context.Context, id string) error {
+	r.sent = append(r.sent, id)
+	return nil
+}
+
+func (r *fakeRepository) MarkRetry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	r.retried[id] = nextAttemptAt
+	return nil
+}
+
+func (r *fakeRepository) MarkFailed(ctx context.Context, id string, lastError string) error {
+	r.failed = append(r.failed, id)
+	return nil
+}
+
+func (r *fakeRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestDispatcherDeliveryOutcomes(t *testing.T) {
+	repo := &fakeRepository{
+		retried: make(map[string]time.Time),
+		due: []*outbox.Message{
+			{ID: "ok", Kind: outbox.KindOTPCode, MaxAttempts: 3},
+			{ID: "retry", Kind: outbox.KindInvitationEmail, Attempts: 1, MaxAttempts: 3},
+			{ID: "exhausted", Kind: outbox.KindInvitationEmail, Attempts: 2, MaxAttempts: 3},
+			{ID: "unknown", Kind: "UNKNOWN", MaxAttempts: 3},
+		},
+	}
+
+	d := NewDispatcher(repo, &config.OutboxConfig{
+		BatchSize:      2,
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  time.Minute,
+	})
+	d.Register(outbox.KindOTPCode, func(ctx context.Context, msg *outbox.Message) error { return nil })
+	d.Register(outbox.KindInvitationEmail, func(ctx context.Context, msg *outbox.Message) error {
+		return errors.New("smtp unavailable")
+	})
+
+	start := time.Now()
+	d.dispatchDue(context.Background())
+
+	if len(repo.due) != 0 {
+		t.Fatalf("%d messages left unclaimed", len(repo.due))
+	}
+	if len(repo.sent) != 1 || repo.sent[0] != "ok" {
+		t.Errorf("sent = %v, want [ok]", repo.sent)
+	}
+	if len(repo.failed) != 1 || repo.failed[0] != "exhausted" {
+		t.Errorf("failed = %v, want [exhausted]", repo.failed)
+	}
+
+	// Segundo intento fallido: backoff de 2 * base
+	if next, ok := repo.retried["retry"]; !ok || next.Sub(start) < 2*time.Second {
+		t.Errorf("retry scheduled at %v, want at least 2s from now", next.Sub(start))
+	}
+	if _, ok := repo.retried["unknown"]; !ok {
+		t.Error("message without handler was not retried")
+	}
+}
+
+func TestMessageRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		msg := outbox.Message{Attempts: tt.attempts}
+		if got := msg.RetryDelay(time.Second, 30*time.Second); got != tt.want {
+			t.Errorf("RetryDelay with %d attempts = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
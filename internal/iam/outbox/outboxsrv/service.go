@@ -0,0 +1,45 @@
+package outboxsrv
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/google/uuid"
+)
+
+// OutboxService implementa outbox.Outbox sobre el repositorio del outbox y
+// el dbx.Transactor de la base de datos
+type OutboxService struct {
+	repo       outbox.Repository
+	transactor dbx.Transactor
+	config     *config.OutboxConfig
+}
+
+// NewOutboxService crea una nueva instancia del servicio del outbox
+func NewOutboxService(repo outbox.Repository, transactor dbx.Transactor, cfg *config.OutboxConfig) *OutboxService {
+	return &OutboxService{
+		repo:       repo,
+		transactor: transactor,
+		config:     cfg,
+	}
+}
+
+// WithinTx ejecuta fn en una transacción, o en la que ya lleva ctx
+func (s *OutboxService) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.transactor.WithinTx(ctx, fn)
+}
+
+// Enqueue guarda un mensaje pendiente. Llamado dentro de WithinTx, el mensaje
+// solo existe si la escritura de dominio se confirma.
+func (s *OutboxService) Enqueue(ctx context.Context, kind outbox.Kind, payload any) error {
+	msg, err := outbox.NewMessage(uuid.NewString(), kind, payload, s.config.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	return s.repo.Save(ctx, *msg)
+}
+
+// Verificación en compilación de que implementa la interfaz
+var _ outbox.Outbox = (*OutboxService)(nil)
@@ -0,0 +1,48 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+)
+
+// Outbox encola efectos secundarios de forma atómica con la escritura de
+// dominio que los origina. Los servicios lo reciben como dependencia opcional:
+// sin outbox envían directamente, como antes.
+type Outbox interface {
+	dbx.Transactor
+
+	// Enqueue agrega un mensaje para entrega asíncrona, dentro de la
+	// transacción de ctx si existe
+	Enqueue(ctx context.Context, kind Kind, payload any) error
+}
+
+// Handler entrega un mensaje. Un error programa un reintento; retornar nil
+// lo marca como enviado, también cuando el handler decide descartarlo.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Repository define el contrato para la persistencia del outbox
+type Repository interface {
+	// Save guarda un mensaje nuevo, dentro de la transacción de ctx si existe
+	Save(ctx context.Context, msg Message) error
+
+	// ClaimDue reclama hasta limit mensajes pendientes cuyo próximo intento ya
+	// venció: incrementa sus intentos y los oculta durante lease, de modo que
+	// otra instancia no los entregue a la vez. Si la instancia cae, vuelven a
+	// estar disponibles al vencer el lease.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Message, error)
+
+	// MarkSent marca un mensaje como entregado y vacía su payload
+	MarkSent(ctx context.Context, id string) error
+
+	// MarkRetry registra el error de un intento y programa el siguiente
+	MarkRetry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+
+	// MarkFailed marca un mensaje que agotó sus intentos y vacía su payload
+	MarkFailed(ctx context.Context, id string, lastError string) error
+
+	// DeleteSentBefore elimina los mensajes entregados antes de before y
+	// retorna cuántos eliminó
+	DeleteSentBefore(ctx context.Context, before time.Time) (int64, error)
+}
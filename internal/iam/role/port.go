@@ -0,0 +1,17 @@
+package role
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// RoleRepository defines persistence for tenant-scoped roles
+type RoleRepository interface {
+	FindByID(ctx context.Context, id string, tenantID kernel.TenantID) (*Role, error)
+	FindByName(ctx context.Context, name string, tenantID kernel.TenantID) (*Role, error)
+	FindByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*Role, error)
+	CountByTenant(ctx context.Context, tenantID kernel.TenantID) (int, error)
+	Save(ctx context.Context, r Role) error
+	Delete(ctx context.Context, id string, tenantID kernel.TenantID) error
+}
@@ -0,0 +1,114 @@
+package role
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// ============================================================================
+// Role Entity
+// ============================================================================
+
+// Role is a tenant-defined named bundle of scopes. Roles are layered on top of
+// the global scope templates (scopes.ScopeGroups) and can be used anywhere a
+// scope template name is accepted.
+type Role struct {
+	ID          string          `db:"id" json:"id"`
+	TenantID    kernel.TenantID `db:"tenant_id" json:"tenant_id"`
+	Name        string          `db:"name" json:"name"`
+	Description string          `db:"description" json:"description,omitempty"`
+	Scopes      []string        `db:"scopes" json:"scopes"`
+	CreatedBy   kernel.UserID   `db:"created_by" json:"created_by"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// ============================================================================
+// Domain Methods
+// ============================================================================
+
+// Rename changes the role name
+func (r *Role) Rename(name string) {
+	r.Name = name
+	r.UpdatedAt = time.Now()
+}
+
+// SetScopes replaces the scopes bundled in the role
+func (r *Role) SetScopes(scopes []string) {
+	r.Scopes = scopes
+	r.UpdatedAt = time.Now()
+}
+
+// SetDescription updates the role description
+func (r *Role) SetDescription(description string) {
+	r.Description = description
+	r.UpdatedAt = time.Now()
+}
+
+// ============================================================================
+// DTOs
+// ============================================================================
+
+// CreateRoleRequest is the payload to create a tenant role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2"`
+	Description string   `json:"description,omitempty"`
+	Scopes      []string `json:"scopes" validate:"required,min=1"`
+}
+
+// UpdateRoleRequest is the payload to update a tenant role
+type UpdateRoleRequest struct {
+	Name        *string  `json:"name,omitempty" validate:"omitempty,min=2"`
+	Description *string  `json:"description,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+}
+
+// RoleListResponse lists the roles of a tenant
+type RoleListResponse struct {
+	Roles []Role `json:"roles"`
+	Total int    `json:"total"`
+}
+
+// ============================================================================
+// Error Registry
+// ============================================================================
+
+var ErrRegistry = errx.NewRegistry("ROLE")
+
+var (
+	CodeRoleNotFound      = ErrRegistry.Register("NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Role not found")
+	CodeRoleAlreadyExists = ErrRegistry.Register("ALREADY_EXISTS", errx.TypeConflict, http.StatusConflict, "A role with this name already exists")
+	CodeReservedRoleName  = ErrRegistry.Register("RESERVED_NAME", errx.TypeValidation, http.StatusBadRequest, "Role name is reserved by a global scope template")
+	CodeInvalidScopes     = ErrRegistry.Register("INVALID_SCOPES", errx.TypeValidation, http.StatusBadRequest, "Invalid scopes")
+	CodeMaxRolesReached   = ErrRegistry.Register("MAX_ROLES_REACHED", errx.TypeBusiness, http.StatusForbidden, "Maximum number of custom roles reached")
+)
+
+// Helper functions
+func ErrRoleNotFound() *errx.Error {
+	return ErrRegistry.New(CodeRoleNotFound)
+}
+
+func ErrRoleAlreadyExists() *errx.Error {
+	return ErrRegistry.New(CodeRoleAlreadyExists)
+}
+
+func ErrReservedRoleName() *errx.Error {
+	return ErrRegistry.New(CodeReservedRoleName)
+}
+
+func ErrInvalidScopes() *errx.Error {
+	return ErrRegistry.New(CodeInvalidScopes)
+}
+
+func ErrMaxRolesReached() *errx.Error {
+	return ErrRegistry.New(CodeMaxRolesReached)
+}
+
+// IsRoleNotFound reports whether err means the role does not exist
+func IsRoleNotFound(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeRoleNotFound.Code
+}
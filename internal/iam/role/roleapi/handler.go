@@ -0,0 +1,107 @@
+package roleapi
+
+import (
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/role"
+	"github.com/Abraxas-365/manifesto/internal/iam/role/rolesrv"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RoleHandlers struct {
+	service *rolesrv.RoleService
+}
+
+func NewRoleHandlers(service *rolesrv.RoleService) *RoleHandlers {
+	return &RoleHandlers{service: service}
+}
+
+func (h *RoleHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	roles := router.Group("/roles", authMiddleware.Authenticate())
+
+	roles.Get("/", authMiddleware.RequireAdminOrScope(scopes.ScopeRolesRead), h.ListRoles)
+	roles.Get("/:id", authMiddleware.RequireAdminOrScope(scopes.ScopeRolesRead), h.GetRole)
+	roles.Post("/", authMiddleware.RequireAdminOrScope(scopes.ScopeRolesWrite), h.CreateRole)
+	roles.Put("/:id", authMiddleware.RequireAdminOrScope(scopes.ScopeRolesWrite), h.UpdateRole)
+	roles.Delete("/:id", authMiddleware.RequireAdminOrScope(scopes.ScopeRolesDelete), h.DeleteRole)
+}
+
+func (h *RoleHandlers) CreateRole(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok || authContext.UserID == nil {
+		return iam.ErrUnauthorized()
+	}
+
+	var req role.CreateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	created, err := h.service.CreateRole(c.Context(), authContext.TenantID, *authContext.UserID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+func (h *RoleHandlers) ListRoles(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	response, err := h.service.ListRoles(c.Context(), authContext.TenantID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+func (h *RoleHandlers) GetRole(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	found, err := h.service.GetRole(c.Context(), c.Params("id"), authContext.TenantID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(found)
+}
+
+func (h *RoleHandlers) UpdateRole(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req role.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	updated, err := h.service.UpdateRole(c.Context(), c.Params("id"), authContext.TenantID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(updated)
+}
+
+func (h *RoleHandlers) DeleteRole(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	if err := h.service.DeleteRole(c.Context(), c.Params("id"), authContext.TenantID); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"message": "Role deleted successfully"})
+}
@@ -0,0 +1,180 @@
+package roleinfra
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/role"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresRoleRepository is the PostgreSQL implementation of RoleRepository
+type PostgresRoleRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRoleRepository creates a new role repository
+func NewPostgresRoleRepository(db *sqlx.DB) role.RoleRepository {
+	return &PostgresRoleRepository{
+		db: db,
+	}
+}
+
+// roleDB is the database representation with pq.StringArray for scopes
+type roleDB struct {
+	ID          string         `db:"id"`
+	TenantID    string         `db:"tenant_id"`
+	Name        string         `db:"name"`
+	Description string         `db:"description"`
+	Scopes      pq.StringArray `db:"scopes"`
+	CreatedBy   string         `db:"created_by"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+func (db *roleDB) toDomain() *role.Role {
+	return &role.Role{
+		ID:          db.ID,
+		TenantID:    kernel.TenantID(db.TenantID),
+		Name:        db.Name,
+		Description: db.Description,
+		Scopes:      []string(db.Scopes),
+		CreatedBy:   kernel.UserID(db.CreatedBy),
+		CreatedAt:   db.CreatedAt,
+		UpdatedAt:   db.UpdatedAt,
+	}
+}
+
+const roleColumns = `id, tenant_id, name, description, scopes, created_by, created_at, updated_at`
+
+// FindByID finds a role by ID within a tenant
+func (r *PostgresRoleRepository) FindByID(ctx context.Context, id string, tenantID kernel.TenantID) (*role.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM tenant_roles WHERE id = $1 AND tenant_id = $2`
+
+	var dbRole roleDB
+	err := r.db.GetContext(ctx, &dbRole, query, id, tenantID.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, role.ErrRoleNotFound().WithDetail("role_id", id)
+		}
+		return nil, errx.Wrap(err, "failed to find role by id", errx.TypeInternal).
+			WithDetail("role_id", id).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	return dbRole.toDomain(), nil
+}
+
+// FindByName finds a role by name within a tenant
+func (r *PostgresRoleRepository) FindByName(ctx context.Context, name string, tenantID kernel.TenantID) (*role.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM tenant_roles WHERE name = $1 AND tenant_id = $2`
+
+	var dbRole roleDB
+	err := r.db.GetContext(ctx, &dbRole, query, name, tenantID.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, role.ErrRoleNotFound().WithDetail("name", name)
+		}
+		return nil, errx.Wrap(err, "failed to find role by name", errx.TypeInternal).
+			WithDetail("name", name).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	return dbRole.toDomain(), nil
+}
+
+// FindByTenant lists all roles of a tenant
+func (r *PostgresRoleRepository) FindByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*role.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM tenant_roles WHERE tenant_id = $1 ORDER BY name ASC`
+
+	var dbRoles []roleDB
+	err := r.db.SelectContext(ctx, &dbRoles, query, tenantID.String())
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to find roles by tenant", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	result := make([]*role.Role, len(dbRoles))
+	for i := range dbRoles {
+		result[i] = dbRoles[i].toDomain()
+	}
+
+	return result, nil
+}
+
+// CountByTenant counts the roles of a tenant
+func (r *PostgresRoleRepository) CountByTenant(ctx context.Context, tenantID kernel.TenantID) (int, error) {
+	query := `SELECT COUNT(*) FROM tenant_roles WHERE tenant_id = $1`
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, query, tenantID.String()); err != nil {
+		return 0, errx.Wrap(err, "failed to count roles by tenant", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	return count, nil
+}
+
+// Save creates or updates a role
+func (r *PostgresRoleRepository) Save(ctx context.Context, rl role.Role) error {
+	query := `
+		INSERT INTO tenant_roles (
+			id, tenant_id, name, description, scopes, created_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			scopes = EXCLUDED.scopes,
+			updated_at = EXCLUDED.updated_at
+		WHERE tenant_roles.tenant_id = EXCLUDED.tenant_id`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rl.ID,
+		rl.TenantID.String(),
+		rl.Name,
+		rl.Description,
+		pq.Array(rl.Scopes),
+		rl.CreatedBy.String(),
+		rl.CreatedAt,
+		rl.UpdatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" && pqErr.Constraint == "uq_tenant_roles_name" {
+				return role.ErrRoleAlreadyExists().WithDetail("name", rl.Name)
+			}
+		}
+		return errx.Wrap(err, "failed to save role", errx.TypeInternal).
+			WithDetail("role_id", rl.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a role
+func (r *PostgresRoleRepository) Delete(ctx context.Context, id string, tenantID kernel.TenantID) error {
+	query := `DELETE FROM tenant_roles WHERE id = $1 AND tenant_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, tenantID.String())
+	if err != nil {
+		return errx.Wrap(err, "failed to delete role", errx.TypeInternal).
+			WithDetail("role_id", id)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errx.Wrap(err, "failed to get rows affected", errx.TypeInternal)
+	}
+
+	if rowsAffected == 0 {
+		return role.ErrRoleNotFound().WithDetail("role_id", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package rolesrv
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/role"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/google/uuid"
+)
+
+// RoleService provides self-service management of tenant-scoped roles
+type RoleService struct {
+	roleRepo   role.RoleRepository
+	tenantRepo tenant.TenantRepository
+	config     *config.TenantConfig
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(
+	roleRepo role.RoleRepository,
+	tenantRepo tenant.TenantRepository,
+	cfg *config.TenantConfig,
+) *RoleService {
+	return &RoleService{
+		roleRepo:   roleRepo,
+		tenantRepo: tenantRepo,
+		config:     cfg,
+	}
+}
+
+// CreateRole creates a new named scope bundle for a tenant
+func (s *RoleService) CreateRole(ctx context.Context, tenantID kernel.TenantID, createdBy kernel.UserID, req role.CreateRoleRequest) (*role.Role, error) {
+	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, tenant.ErrTenantNotFound()
+	}
+	if !tenantEntity.IsActive() {
+		return nil, tenant.ErrTenantSuspended()
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.validateName(ctx, tenantID, name, ""); err != nil {
+		return nil, err
+	}
+
+	if err := validateScopes(req.Scopes); err != nil {
+		return nil, err
+	}
+
+	if s.config.MaxCustomRoles > 0 {
+		count, err := s.roleRepo.CountByTenant(ctx, tenantID)
+		if err != nil {
+			return nil, errx.Wrap(err, "failed to count tenant roles", errx.TypeInternal)
+		}
+		if count >= s.config.MaxCustomRoles {
+			return nil, role.ErrMaxRolesReached().WithDetail("max_roles", s.config.MaxCustomRoles)
+		}
+	}
+
+	now := time.Now()
+	newRole := &role.Role{
+		ID:          uuid.NewString(),
+		TenantID:    tenantID,
+		Name:        name,
+		Description: req.Description,
+		Scopes:      req.Scopes,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.roleRepo.Save(ctx, *newRole); err != nil {
+		return nil, err
+	}
+
+	return newRole, nil
+}
+
+// UpdateRole updates the name, description or scopes of a tenant role
+func (s *RoleService) UpdateRole(ctx context.Context, roleID string, tenantID kernel.TenantID, req role.UpdateRoleRequest) (*role.Role, error) {
+	roleEntity, err := s.roleRepo.FindByID(ctx, roleID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name != roleEntity.Name {
+			if err := s.validateName(ctx, tenantID, name, roleEntity.ID); err != nil {
+				return nil, err
+			}
+			roleEntity.Rename(name)
+		}
+	}
+
+	if req.Description != nil {
+		roleEntity.SetDescription(*req.Description)
+	}
+
+	if req.Scopes != nil {
+		if err := validateScopes(req.Scopes); err != nil {
+			return nil, err
+		}
+		roleEntity.SetScopes(req.Scopes)
+	}
+
+	if err := s.roleRepo.Save(ctx, *roleEntity); err != nil {
+		return nil, err
+	}
+
+	return roleEntity, nil
+}
+
+// DeleteRole removes a tenant role. Users that were assigned the role keep
+// their scopes, since roles are expanded at assignment time.
+func (s *RoleService) DeleteRole(ctx context.Context, roleID string, tenantID kernel.TenantID) error {
+	return s.roleRepo.Delete(ctx, roleID, tenantID)
+}
+
+// GetRole returns a tenant role by ID
+func (s *RoleService) GetRole(ctx context.Context, roleID string, tenantID kernel.TenantID) (*role.Role, error) {
+	return s.roleRepo.FindByID(ctx, roleID, tenantID)
+}
+
+// ListRoles returns all roles defined by a tenant
+func (s *RoleService) ListRoles(ctx context.Context, tenantID kernel.TenantID) (*role.RoleListResponse, error) {
+	roles, err := s.roleRepo.FindByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to list tenant roles", errx.TypeInternal)
+	}
+
+	result := make([]role.Role, 0, len(roles))
+	for _, r := range roles {
+		result = append(result, *r)
+	}
+
+	return &role.RoleListResponse{
+		Roles: result,
+		Total: len(result),
+	}, nil
+}
+
+// ============================================================================
+// Private Helper Methods
+// ============================================================================
+
+// validateName ensures the name is not empty, does not shadow a global scope
+// template and is unique within the tenant
+func (s *RoleService) validateName(ctx context.Context, tenantID kernel.TenantID, name string, currentID string) error {
+	if name == "" {
+		return errx.Validation("role name is required")
+	}
+
+	if _, exists := scopes.ScopeGroups[name]; exists {
+		return role.ErrReservedRoleName().WithDetail("name", name)
+	}
+
+	existing, err := s.roleRepo.FindByName(ctx, name, tenantID)
+	if err == nil && existing != nil && existing.ID != currentID {
+		return role.ErrRoleAlreadyExists().WithDetail("name", name)
+	}
+
+	return nil
+}
+
+// validateScopes checks every scope against the scope registry
+func validateScopes(scopesList []string) error {
+	if len(scopesList) == 0 {
+		return role.ErrInvalidScopes().WithDetail("reason", "at least one scope is required")
+	}
+
+	invalidScopes := []string{}
+	for _, scope := range scopesList {
+		if !scopes.ValidateScope(scope) {
+			invalidScopes = append(invalidScopes, scope)
+		}
+	}
+
+	if len(invalidScopes) > 0 {
+		return role.ErrInvalidScopes().WithDetail("invalid_scopes", invalidScopes)
+	}
+
+	return nil
+}
@@ -19,6 +19,7 @@ const (
 	ScopeUsersWrite  = "users:write"
 	ScopeUsersDelete = "users:delete"
 	ScopeUsersInvite = "users:invite"
+	ScopeUsersExport = "users:export"
 
 	// Role management scopes
 	ScopeRolesAll    = "roles:*"
@@ -33,6 +34,7 @@ const (
 	ScopeTenantsWrite  = "tenants:write"
 	ScopeTenantsDelete = "tenants:delete"
 	ScopeTenantsConfig = "tenants:config"
+	ScopeTenantsExport = "tenants:export"
 
 	// API Key scopes
 	ScopeAPIKeysAll    = "api_keys:*"
@@ -41,6 +43,9 @@ const (
 	ScopeAPIKeysDelete = "api_keys:delete"
 	ScopeAPIKeysRevoke = "api_keys:revoke"
 
+	// Token scopes
+	ScopeTokensIntrospect = "tokens:introspect"
+
 	// Settings scopes
 	ScopeSettingsAll   = "settings:*"
 	ScopeSettingsRead  = "settings:read"
@@ -75,6 +80,17 @@ const (
 	ScopeTemplatesRead   = "templates:read"
 	ScopeTemplatesWrite  = "templates:write"
 	ScopeTemplatesDelete = "templates:delete"
+
+	// AI agent scopes
+	ScopeAgentsAll = "agents:*"
+	ScopeAgentsRun = "agents:run"
+
+	// Usage metering scopes
+	ScopeUsageRead = "usage:read"
+
+	// File storage scopes
+	ScopeFilesAll   = "files:*"
+	ScopeFilesWrite = "files:write"
 )
 
 // CommonScopeCategories organizes common scopes by domain
@@ -91,6 +107,7 @@ var CommonScopeCategories = map[string][]string{
 		ScopeUsersWrite,
 		ScopeUsersDelete,
 		ScopeUsersInvite,
+		ScopeUsersExport,
 	},
 	"Roles": {
 		ScopeRolesAll,
@@ -105,6 +122,7 @@ var CommonScopeCategories = map[string][]string{
 		ScopeTenantsWrite,
 		ScopeTenantsDelete,
 		ScopeTenantsConfig,
+		ScopeTenantsExport,
 	},
 	"API Keys": {
 		ScopeAPIKeysAll,
@@ -113,6 +131,9 @@ var CommonScopeCategories = map[string][]string{
 		ScopeAPIKeysDelete,
 		ScopeAPIKeysRevoke,
 	},
+	"Tokens": {
+		ScopeTokensIntrospect,
+	},
 	"Settings": {
 		ScopeSettingsAll,
 		ScopeSettingsRead,
@@ -148,6 +169,17 @@ var CommonScopeCategories = map[string][]string{
 		ScopeTemplatesWrite,
 		ScopeTemplatesDelete,
 	},
+	"Agents": {
+		ScopeAgentsAll,
+		ScopeAgentsRun,
+	},
+	"Usage": {
+		ScopeUsageRead,
+	},
+	"Files": {
+		ScopeFilesAll,
+		ScopeFilesWrite,
+	},
 }
 
 // CommonScopeDescriptions provides human-readable descriptions
@@ -166,6 +198,7 @@ var CommonScopeDescriptions = map[string]string{
 	ScopeUsersWrite:  "Create and edit users",
 	ScopeUsersDelete: "Delete users",
 	ScopeUsersInvite: "Invite new users",
+	ScopeUsersExport: "Export users",
 
 	// Roles
 	ScopeRolesAll:    "Full access to role management",
@@ -180,6 +213,7 @@ var CommonScopeDescriptions = map[string]string{
 	ScopeTenantsWrite:  "Create and edit tenants",
 	ScopeTenantsDelete: "Delete tenants",
 	ScopeTenantsConfig: "Manage tenant configuration",
+	ScopeTenantsExport: "Export all tenant data (GDPR)",
 
 	// API Keys
 	ScopeAPIKeysAll:    "Full access to API key management",
@@ -188,6 +222,9 @@ var CommonScopeDescriptions = map[string]string{
 	ScopeAPIKeysDelete: "Delete API keys",
 	ScopeAPIKeysRevoke: "Revoke API keys",
 
+	// Tokens
+	ScopeTokensIntrospect: "Check access token validity (token introspection)",
+
 	// Settings
 	ScopeSettingsAll:   "Full access to settings",
 	ScopeSettingsRead:  "View settings",
@@ -222,6 +259,17 @@ var CommonScopeDescriptions = map[string]string{
 	ScopeTemplatesRead:   "View templates",
 	ScopeTemplatesWrite:  "Create and edit templates",
 	ScopeTemplatesDelete: "Delete templates",
+
+	// Agents
+	ScopeAgentsAll: "Full access to AI agents",
+	ScopeAgentsRun: "Run AI agents",
+
+	// Usage
+	ScopeUsageRead: "View LLM usage and spend",
+
+	// Files
+	ScopeFilesAll:   "Full access to tenant files",
+	ScopeFilesWrite: "Upload files to chosen keys, replacing existing ones",
 }
 
 // CommonScopeGroups defines common role groupings
@@ -245,6 +293,8 @@ var CommonScopeGroups = map[string][]string{
 		ScopeAPIKeysAll,
 		ScopeTenantsRead,
 		ScopeTenantsConfig,
+		ScopeUsageRead,
+		ScopeFilesAll,
 	},
 	"user_manager": {
 		ScopeUsersAll,
@@ -256,9 +306,11 @@ var CommonScopeGroups = map[string][]string{
 		ScopeReportsAll,
 		ScopeAnalyticsDashboard,
 		ScopeAuditRead,
+		ScopeUsageRead,
 	},
 	"api_admin": {
 		ScopeAPIKeysAll,
+		ScopeTokensIntrospect,
 		ScopeIntegrationsAll,
 	},
 	"settings_admin": {
@@ -270,6 +322,7 @@ var CommonScopeGroups = map[string][]string{
 	"auditor": {
 		ScopeAuditRead,
 		ScopeUsersRead,
+		ScopeUsersExport,
 		ScopeRolesRead,
 		ScopeTenantsRead,
 	},
@@ -0,0 +1,70 @@
+package scopes
+
+import "strings"
+
+// ResolveEffectiveScopes returns the direct scopes plus the scopes of every
+// template (ScopeGroups name), without duplicates and in first-seen order.
+// Unknown template names are ignored.
+func ResolveEffectiveScopes(direct []string, templates []string) []string {
+	seen := make(map[string]struct{}, len(direct))
+	effective := make([]string, 0, len(direct))
+
+	add := func(scope string) {
+		if _, ok := seen[scope]; ok {
+			return
+		}
+		seen[scope] = struct{}{}
+		effective = append(effective, scope)
+	}
+
+	for _, scope := range direct {
+		add(scope)
+	}
+	for _, template := range templates {
+		for _, scope := range ScopeGroups[template] {
+			add(scope)
+		}
+	}
+
+	return effective
+}
+
+// Normalize returns the scopes without duplicates and without the scopes
+// already implied by another scope of the list, in first-seen order: "*"
+// absorbs everything and "jobs:*" absorbs "jobs:read". The input is not
+// modified, so callers keep the raw list.
+func Normalize(scopeList []string) []string {
+	unique := ResolveEffectiveScopes(scopeList, nil)
+
+	normalized := make([]string, 0, len(unique))
+	for i, scope := range unique {
+		implied := false
+		for j, other := range unique {
+			if i != j && HasScopeIn([]string{other}, scope) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			normalized = append(normalized, scope)
+		}
+	}
+
+	return normalized
+}
+
+// HasScopeIn reports whether granted covers required. A granted scope matches
+// when it is equal to required, is ScopeAll ("*"), or is a "<prefix>:*"
+// wildcard and required starts with "<prefix>:" (e.g. "jobs:*" covers
+// "jobs:read"). This is the single implementation every scope check uses.
+func HasScopeIn(granted []string, required string) bool {
+	for _, s := range granted {
+		if s == required || s == ScopeAll {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(s, ":*"); ok && prefix != "" && strings.HasPrefix(required, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package scopes
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHasScopeIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{name: "exact match", granted: []string{"jobs:read"}, required: "jobs:read", want: true},
+		{name: "global wildcard", granted: []string{"*"}, required: "jobs:read", want: true},
+		{name: "prefix wildcard", granted: []string{"jobs:*"}, required: "jobs:read", want: true},
+		{name: "prefix wildcard nested", granted: []string{"jobs:*"}, required: "jobs:runs:cancel", want: true},
+		{name: "prefix wildcard covers itself", granted: []string{"jobs:*"}, required: "jobs:*", want: true},
+		{name: "prefix wildcard other resource", granted: []string{"jobs:*"}, required: "jobsx:read", want: false},
+		{name: "specific does not cover wildcard", granted: []string{"jobs:read"}, required: "jobs:*", want: false},
+		{name: "bare suffix is not a wildcard", granted: []string{":*"}, required: ":read", want: false},
+		{name: "nothing granted", granted: nil, required: "jobs:read", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScopeIn(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasScopeIn(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveEffectiveScopes(t *testing.T) {
+	ScopeGroups["test_group"] = []string{"jobs:read", "users:read"}
+	defer delete(ScopeGroups, "test_group")
+
+	got := ResolveEffectiveScopes([]string{"users:read", "jobs:write"}, []string{"test_group", "unknown_group"})
+	want := []string{"users:read", "jobs:write", "jobs:read"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ResolveEffectiveScopes() = %v, want %v", got, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{name: "star absorbs everything", input: []string{"jobs:read", "*", "candidates:*", "users:write"}, want: []string{"*"}},
+		{name: "wildcard absorbs narrower scope", input: []string{"candidates:read", "candidates:*", "jobs:read"}, want: []string{"candidates:*", "jobs:read"}},
+		{name: "wildcard absorbs nested wildcard", input: []string{"jobs:runs:*", "jobs:*"}, want: []string{"jobs:*"}},
+		{name: "duplicates removed", input: []string{"jobs:read", "users:read", "jobs:read"}, want: []string{"jobs:read", "users:read"}},
+		{name: "similar prefix kept", input: []string{"jobs:*", "jobsx:read"}, want: []string{"jobs:*", "jobsx:read"}},
+		{name: "empty", input: nil, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := slices.Clone(tt.input)
+			if got := Normalize(tt.input); !slices.Equal(got, tt.want) {
+				t.Errorf("Normalize(%v) = %v, want %v", tt.input, got, tt.want)
+			}
+			if !slices.Equal(tt.input, raw) {
+				t.Errorf("Normalize modified its input: %v, want %v", tt.input, raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package scopes
+
+import (
+	"slices"
+	"strings"
+)
+
+// maxSuggestions is how many near-matches Validate returns per invalid scope
+const maxSuggestions = 3
+
+// InvalidScope is a scope that is not defined, with the closest valid scopes
+type InvalidScope struct {
+	Scope       string   `json:"scope"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// ValidationResult splits a scope list into valid and invalid scopes
+type ValidationResult struct {
+	Valid   []string       `json:"valid"`
+	Invalid []InvalidScope `json:"invalid"`
+}
+
+// OK reports whether every scope is valid
+func (r ValidationResult) OK() bool {
+	return len(r.Invalid) == 0
+}
+
+// InvalidScopes returns the names of the invalid scopes
+func (r ValidationResult) InvalidScopes() []string {
+	names := make([]string, 0, len(r.Invalid))
+	for _, invalid := range r.Invalid {
+		names = append(names, invalid.Scope)
+	}
+	return names
+}
+
+// Validate checks every scope and suggests near-matches for the invalid ones,
+// e.g. "user:read" → "users:read". A scope is suggested when its edit
+// distance is at most a third of its length (and at least 1).
+func Validate(scopeList []string) ValidationResult {
+	result := ValidationResult{
+		Valid:   []string{},
+		Invalid: []InvalidScope{},
+	}
+
+	for _, scope := range scopeList {
+		if ValidateScope(scope) {
+			result.Valid = append(result.Valid, scope)
+			continue
+		}
+		result.Invalid = append(result.Invalid, InvalidScope{
+			Scope:       scope,
+			Suggestions: suggest(scope),
+		})
+	}
+
+	return result
+}
+
+// suggest returns the valid scopes closest to an invalid one
+func suggest(scope string) []string {
+	type candidate struct {
+		scope    string
+		distance int
+	}
+
+	needle := strings.ToLower(strings.TrimSpace(scope))
+	threshold := max(len(needle)/3, 1)
+
+	candidates := []candidate{}
+	for _, valid := range GetAllScopes() {
+		if d := levenshtein(needle, valid); d <= threshold {
+			candidates = append(candidates, candidate{scope: valid, distance: d})
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.scope, b.scope)
+	})
+
+	suggestions := []string{}
+	for _, c := range candidates {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		if !slices.Contains(suggestions, c.scope) {
+			suggestions = append(suggestions, c.scope)
+		}
+	}
+	return suggestions
+}
+
+// levenshtein is the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
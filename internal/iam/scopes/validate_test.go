@@ -0,0 +1,23 @@
+package scopes
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	result := Validate([]string{ScopeUsersRead, "user:read", "totally:unrelated"})
+
+	if !slices.Equal(result.Valid, []string{ScopeUsersRead}) {
+		t.Errorf("Valid = %v, want [%s]", result.Valid, ScopeUsersRead)
+	}
+	if result.OK() || !slices.Equal(result.InvalidScopes(), []string{"user:read", "totally:unrelated"}) {
+		t.Fatalf("Invalid = %+v, want user:read and totally:unrelated", result.Invalid)
+	}
+	if got := result.Invalid[0].Suggestions; len(got) == 0 || got[0] != ScopeUsersRead {
+		t.Errorf("suggestions for user:read = %v, want %s first", got, ScopeUsersRead)
+	}
+	if got := result.Invalid[1].Suggestions; len(got) != 0 {
+		t.Errorf("suggestions for totally:unrelated = %v, want none", got)
+	}
+}
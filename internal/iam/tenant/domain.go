@@ -0,0 +1,45 @@
+package tenant
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// ============================================================================
+// Tenant Domain Entity
+// ============================================================================
+
+// TenantDomain es un dominio de email reclamado por un tenant. Solo los
+// dominios verificados se usan para sugerir el tenant a emails nuevos.
+type TenantDomain struct {
+	ID         string          `db:"id" json:"id"`
+	TenantID   kernel.TenantID `db:"tenant_id" json:"tenant_id"`
+	Domain     string          `db:"domain" json:"domain"`
+	VerifiedAt *time.Time      `db:"verified_at" json:"verified_at,omitempty"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
+
+// IsVerified verifica si el dominio fue verificado
+func (d *TenantDomain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// MarkVerified marca el dominio como verificado
+func (d *TenantDomain) MarkVerified() {
+	now := time.Now().UTC()
+	d.VerifiedAt = &now
+}
+
+// NormalizeDomain limpia un dominio para compararlo y valida su forma básica.
+// Retorna "" si no es un dominio válido.
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "@")
+	if domain == "" || !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@ /") ||
+		strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return ""
+	}
+	return domain
+}
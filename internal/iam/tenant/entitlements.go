@@ -0,0 +1,164 @@
+package tenant
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Features & Quotas
+// ============================================================================
+
+// Feature es una funcionalidad que se habilita según el plan del tenant
+type Feature string
+
+const (
+	FeatureAIResumeSearch Feature = "ai_resume_search"
+	FeatureAIAgents       Feature = "ai_agents"
+	FeatureWebhooks       Feature = "webhooks"
+	FeatureSSO            Feature = "sso"
+	FeatureAuditLog       Feature = "audit_log"
+)
+
+// Quota es un límite de consumo mensual del tenant
+type Quota string
+
+const (
+	// QuotaLLMTokensMonthly son los tokens de LLM consumidos en el mes (UTC)
+	QuotaLLMTokensMonthly Quota = "llm_tokens_monthly"
+)
+
+// Prefijos de las claves de configuración del tenant que sobrescriben el
+// plan: "feature.sso" = "true" | "false", "quota.llm_tokens_monthly" = "500000",
+// "model.chat-default" = "gpt-4o"
+const (
+	ConfigFeaturePrefix = "feature."
+	ConfigQuotaPrefix   = "quota."
+	ConfigModelPrefix   = "model."
+)
+
+// planFeatures son las funcionalidades incluidas en cada plan
+var planFeatures = map[SubscriptionPlan][]Feature{
+	PlanTrial:        {FeatureAIResumeSearch, FeatureAIAgents},
+	PlanBasic:        {FeatureAIResumeSearch, FeatureAIAgents, FeatureWebhooks},
+	PlanProfessional: {FeatureAIResumeSearch, FeatureAIAgents, FeatureWebhooks, FeatureSSO, FeatureAuditLog},
+	PlanEnterprise:   {FeatureAIResumeSearch, FeatureAIAgents, FeatureWebhooks, FeatureSSO, FeatureAuditLog},
+}
+
+// PlanFeatures retorna las funcionalidades incluidas en un plan
+func PlanFeatures(plan SubscriptionPlan) []Feature {
+	return planFeatures[plan]
+}
+
+// Entitlements son las funcionalidades, cuotas y modelos efectivos de un
+// tenant: los de su plan con las sobrescrituras de su configuración
+type Entitlements struct {
+	Plan     SubscriptionPlan `json:"plan"`
+	Features map[Feature]bool `json:"features"`
+	Quotas   map[Quota]int64  `json:"quotas"` // Límite por período; 0 = ilimitado
+	// Models mapea alias de modelo ("chat-default") a modelos concretos; los
+	// alias ausentes usan la configuración global (ver modelx)
+	Models map[string]string `json:"models"`
+}
+
+// NewEntitlements combina las funcionalidades del plan y las cuotas y alias
+// de modelo dados con las claves feature.*, quota.* y model.* de la
+// configuración del tenant. Los valores que no se pueden interpretar se
+// ignoran.
+func NewEntitlements(plan SubscriptionPlan, quotas map[Quota]int64, models map[string]string, settings map[string]string) *Entitlements {
+	e := &Entitlements{
+		Plan:     plan,
+		Features: make(map[Feature]bool),
+		Quotas:   make(map[Quota]int64, len(quotas)),
+		Models:   make(map[string]string, len(models)),
+	}
+
+	for _, feature := range PlanFeatures(plan) {
+		e.Features[feature] = true
+	}
+	for quota, limit := range quotas {
+		e.Quotas[quota] = limit
+	}
+	for alias, model := range models {
+		e.Models[alias] = model
+	}
+
+	for key, value := range settings {
+		switch {
+		case strings.HasPrefix(key, ConfigFeaturePrefix):
+			if enabled, err := strconv.ParseBool(value); err == nil {
+				e.Features[Feature(strings.TrimPrefix(key, ConfigFeaturePrefix))] = enabled
+			}
+		case strings.HasPrefix(key, ConfigQuotaPrefix):
+			if limit, err := strconv.ParseInt(value, 10, 64); err == nil && limit >= 0 {
+				e.Quotas[Quota(strings.TrimPrefix(key, ConfigQuotaPrefix))] = limit
+			}
+		case strings.HasPrefix(key, ConfigModelPrefix):
+			if alias, model := strings.TrimPrefix(key, ConfigModelPrefix), strings.TrimSpace(value); alias != "" && model != "" {
+				e.Models[alias] = model
+			}
+		}
+	}
+
+	return e
+}
+
+// HasFeature verifica si el tenant tiene habilitada la funcionalidad
+func (e *Entitlements) HasFeature(feature Feature) bool {
+	return e.Features[feature]
+}
+
+// Model retorna el modelo del alias para el tenant; ok es false si el
+// tenant no lo define y aplica la configuración global
+func (e *Entitlements) Model(alias string) (model string, ok bool) {
+	model, ok = e.Models[alias]
+	return model, ok
+}
+
+// QuotaLimit retorna el límite de la cuota (0 = ilimitado)
+func (e *Entitlements) QuotaLimit(quota Quota) int64 {
+	return e.Quotas[quota]
+}
+
+// QuotaStatus es el consumo de una cuota en el período actual
+type QuotaStatus struct {
+	Quota     Quota     `json:"quota"`
+	Limit     int64     `json:"limit"` // 0 = ilimitado
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"` // -1 si es ilimitada
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// NewQuotaStatus calcula lo restante de una cuota
+func NewQuotaStatus(quota Quota, limit, used int64, resetAt time.Time) QuotaStatus {
+	remaining := int64(-1)
+	if limit > 0 {
+		remaining = max(limit-used, 0)
+	}
+	return QuotaStatus{
+		Quota:     quota,
+		Limit:     limit,
+		Used:      used,
+		Remaining: remaining,
+		ResetAt:   resetAt,
+	}
+}
+
+// IsUnlimited indica si la cuota no tiene límite
+func (s QuotaStatus) IsUnlimited() bool {
+	return s.Limit <= 0
+}
+
+// Exceeded indica si la cuota se agotó
+func (s QuotaStatus) Exceeded() bool {
+	return !s.IsUnlimited() && s.Used >= s.Limit
+}
+
+// QuotaPeriod retorna el período mensual (UTC) que contiene t: su
+// identificador ("2026-01") y el instante en que termina
+func QuotaPeriod(t time.Time) (string, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01"), start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,68 @@
+package tenant
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewEntitlements(t *testing.T) {
+	e := NewEntitlements(PlanBasic, map[Quota]int64{QuotaLLMTokensMonthly: 1000}, map[string]string{
+		"chat-default":  "gpt-4.1-mini",
+		"embed-default": "text-embedding-3-small",
+	}, map[string]string{
+		"feature.sso":              "true",
+		"feature.webhooks":         "false",
+		"feature.audit_log":        "maybe",
+		"quota.llm_tokens_monthly": "5000",
+		"model.chat-default":       "gpt-4.1",
+		"model.chat-fast":          " ",
+		"default_language":         "es",
+	})
+
+	if !e.HasFeature(FeatureAIResumeSearch) {
+		t.Error("expected plan feature ai_resume_search")
+	}
+	if !e.HasFeature(FeatureSSO) {
+		t.Error("expected sso enabled by setting")
+	}
+	if e.HasFeature(FeatureWebhooks) {
+		t.Error("expected webhooks disabled by setting")
+	}
+	if e.HasFeature(FeatureAuditLog) {
+		t.Error("expected invalid setting to be ignored")
+	}
+	if got := e.QuotaLimit(QuotaLLMTokensMonthly); got != 5000 {
+		t.Errorf("QuotaLimit = %d, want 5000", got)
+	}
+	if got, _ := e.Model("chat-default"); got != "gpt-4.1" {
+		t.Errorf("Model(chat-default) = %q, want override gpt-4.1", got)
+	}
+	if got, _ := e.Model("embed-default"); got != "text-embedding-3-small" {
+		t.Errorf("Model(embed-default) = %q, want plan model", got)
+	}
+	if _, ok := e.Model("chat-fast"); ok {
+		t.Error("expected empty model setting to be ignored")
+	}
+}
+
+func TestQuotaStatus(t *testing.T) {
+	status := NewQuotaStatus(QuotaLLMTokensMonthly, 100, 120, time.Time{})
+	if !status.Exceeded() || status.Remaining != 0 {
+		t.Errorf("got exceeded=%v remaining=%d, want true 0", status.Exceeded(), status.Remaining)
+	}
+
+	unlimited := NewQuotaStatus(QuotaLLMTokensMonthly, 0, 1_000_000, time.Time{})
+	if unlimited.Exceeded() || unlimited.Remaining != -1 {
+		t.Errorf("got exceeded=%v remaining=%d, want false -1", unlimited.Exceeded(), unlimited.Remaining)
+	}
+}
+
+func TestQuotaPeriod(t *testing.T) {
+	period, resetAt := QuotaPeriod(time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC))
+	if period != "2026-12" {
+		t.Errorf("period = %q, want 2026-12", period)
+	}
+	if want := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC); !resetAt.Equal(want) {
+		t.Errorf("resetAt = %v, want %v", resetAt, want)
+	}
+}
@@ -2,6 +2,7 @@ package tenant
 
 import (
 	"context"
+	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/kernel"
 )
@@ -13,6 +14,16 @@ type TenantRepository interface {
 	FindActive(ctx context.Context) ([]*Tenant, error)
 	Save(ctx context.Context, t Tenant) error
 	Delete(ctx context.Context, id kernel.TenantID) error
+
+	// ExpireLapsed pasa a EXPIRED los tenants activos o en trial cuyo trial o
+	// suscripción vencieron y retorna sus IDs
+	ExpireLapsed(ctx context.Context) ([]kernel.TenantID, error)
+
+	// FindByEmailDomain busca los tenants activos que tienen el dominio verificado
+	FindByEmailDomain(ctx context.Context, domain string) ([]*Tenant, error)
+	FindDomains(ctx context.Context, tenantID kernel.TenantID) ([]*TenantDomain, error)
+	SaveDomain(ctx context.Context, d TenantDomain) error
+	DeleteDomain(ctx context.Context, tenantID kernel.TenantID, domain string) error
 }
 
 // TenantConfigRepository define el contrato para configuraciones del tenant
@@ -21,3 +32,25 @@ type TenantConfigRepository interface {
 	SaveSetting(ctx context.Context, tenantID kernel.TenantID, key, value string) error
 	DeleteSetting(ctx context.Context, tenantID kernel.TenantID, key string) error
 }
+
+// SSOConnectionRepository define el contrato para las conexiones SSO de los tenants
+type SSOConnectionRepository interface {
+	// FindByTenant retorna la conexión activa del tenant o ErrSSONotConfigured
+	FindByTenant(ctx context.Context, tenantID kernel.TenantID) (*SSOConnection, error)
+	// FindByTenantIncludeInactive retorna la conexión del tenant aunque esté
+	// inactiva, o ErrSSONotConfigured
+	FindByTenantIncludeInactive(ctx context.Context, tenantID kernel.TenantID) (*SSOConnection, error)
+	// FindByEmailDomain retorna la conexión activa del tenant que verificó el
+	// dominio (tenant_domains) o ErrSSONotConfigured
+	FindByEmailDomain(ctx context.Context, domain string) (*SSOConnection, error)
+	Save(ctx context.Context, conn SSOConnection) error
+	Delete(ctx context.Context, tenantID kernel.TenantID) error
+}
+
+// QuotaCounter define el contrato de los contadores de consumo de cuotas
+type QuotaCounter interface {
+	// Usage retorna el consumo acumulado de la clave (0 si no existe)
+	Usage(ctx context.Context, key string) (int64, error)
+	// Add suma amount a la clave y retorna el total; la clave expira en expireAt
+	Add(ctx context.Context, key string, amount int64, expireAt time.Time) (int64, error)
+}
@@ -0,0 +1,72 @@
+package tenant
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// ============================================================================
+// SSO Connection Entity
+// ============================================================================
+
+// SSOConnection es la conexión OpenID Connect propia de un tenant enterprise.
+// Reemplaza a las apps compartidas de Google/Microsoft para ese tenant: el
+// login se hace contra el IdP del tenant con sus credenciales de cliente.
+type SSOConnection struct {
+	ID           string          `db:"id" json:"id"`
+	TenantID     kernel.TenantID `db:"tenant_id" json:"tenant_id"`
+	IssuerURL    string          `db:"issuer_url" json:"issuer_url"`
+	ClientID     string          `db:"client_id" json:"client_id"`
+	ClientSecret string          `db:"client_secret" json:"-"` // Nunca exponer el secreto
+	RedirectURL  string          `db:"redirect_url" json:"redirect_url"`
+	Scopes       []string        `db:"scopes" json:"scopes"`
+	GroupsClaim  string          `db:"groups_claim" json:"groups_claim,omitempty"`
+	IsActive     bool            `db:"is_active" json:"is_active"`
+
+	// EmailDomains son los dominios verificados del tenant (tenant_domains).
+	// Los carga el repositorio; no se guardan con la conexión.
+	EmailDomains []string `db:"email_domains" json:"email_domains"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// AllowsEmail verifica si el email pertenece a uno de los dominios
+// verificados del tenant. Sin dominios verificados no se acepta ninguno: el
+// IdP del tenant no puede autenticar emails de dominios ajenos.
+func (c *SSOConnection) AllowsEmail(email string) bool {
+	domain := EmailDomain(email)
+	if domain == "" {
+		return false
+	}
+
+	for _, d := range c.EmailDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveSSOConnectionRequest crea o reemplaza la conexión SSO del tenant. Un
+// client_secret vacío conserva el secreto ya guardado.
+type SaveSSOConnectionRequest struct {
+	IssuerURL    string   `json:"issuer_url" validate:"required,url"`
+	ClientID     string   `json:"client_id" validate:"required"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url" validate:"required,url"`
+	Scopes       []string `json:"scopes,omitempty"`
+	GroupsClaim  string   `json:"groups_claim,omitempty"`
+	IsActive     *bool    `json:"is_active,omitempty"` // Por defecto true
+}
+
+// EmailDomain retorna el dominio de un email en minúsculas, o "" si no tiene
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
@@ -20,6 +20,7 @@ const (
 	TenantStatusSuspended TenantStatus = "SUSPENDED"
 	TenantStatusCanceled  TenantStatus = "CANCELED"
 	TenantStatusTrial     TenantStatus = "TRIAL"
+	TenantStatusExpired   TenantStatus = "EXPIRED" // Trial o suscripción vencida
 )
 
 // SubscriptionPlan define los planes de suscripción
@@ -77,6 +78,45 @@ func (t *Tenant) IsSubscriptionExpired() bool {
 	return time.Now().After(*t.SubscriptionExpiresAt)
 }
 
+// IsExpired verifica si el trial o la suscripción vencieron, ya sea por
+// estado o por fecha (antes de que el job de expiración cambie el estado)
+func (t *Tenant) IsExpired() bool {
+	return t.Status == TenantStatusExpired || t.IsTrialExpired() || t.IsSubscriptionExpired()
+}
+
+// Expire marca el tenant como expirado si su trial o suscripción vencieron.
+// Retorna false si no cambió.
+func (t *Tenant) Expire() bool {
+	if t.Status != TenantStatusActive && t.Status != TenantStatusTrial {
+		return false
+	}
+	if !t.IsTrialExpired() && !t.IsSubscriptionExpired() {
+		return false
+	}
+	t.Status = TenantStatusExpired
+	t.UpdatedAt = time.Now()
+	return true
+}
+
+// CheckAccess retorna el error que bloquea las peticiones del tenant:
+// ErrTrialExpired o ErrSubscriptionExpired si venció, ErrTenantSuspended si
+// está suspendido o cancelado, nil si puede operar
+func (t *Tenant) CheckAccess() error {
+	switch t.Status {
+	case TenantStatusSuspended, TenantStatusCanceled:
+		return ErrTenantSuspended().WithDetail("status", string(t.Status))
+	}
+
+	if t.IsExpired() {
+		if t.IsTrial() {
+			return ErrTrialExpired().WithDetail("expired_at", t.TrialExpiresAt)
+		}
+		return ErrSubscriptionExpired().WithDetail("expired_at", t.SubscriptionExpiresAt)
+	}
+
+	return nil
+}
+
 // CanAddUser verifica si se puede agregar un nuevo usuario
 func (t *Tenant) CanAddUser() bool {
 	if !t.IsActive() {
@@ -223,6 +263,14 @@ type UpgradePlanRequest struct {
 	NewPlan SubscriptionPlan `json:"new_plan" validate:"required"`
 }
 
+// Claves de configuración del tenant que interpreta el sistema
+const (
+	// ConfigOAuthGroupSync activa ("true") o desactiva ("false") la
+	// sincronización de scopes desde los grupos del IdP. Sin valor rige
+	// OAUTH_GROUP_SYNC_ENABLED.
+	ConfigOAuthGroupSync = "oauth_group_sync"
+)
+
 // SetConfigRequest para establecer una configuración
 type SetConfigRequest struct {
 	Key   string `json:"key" validate:"required"`
@@ -318,15 +366,22 @@ type TenantUsageResponse struct {
 var ErrRegistry = errx.NewRegistry("TENANT")
 
 var (
-	CodeTenantNotFound      = ErrRegistry.Register("NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Tenant not found")
-	CodeTenantAlreadyExists = ErrRegistry.Register("ALREADY_EXISTS", errx.TypeConflict, http.StatusConflict, "Tenant already exists")
-	CodeTenantSuspended     = ErrRegistry.Register("SUSPENDED", errx.TypeBusiness, http.StatusForbidden, "Tenant suspended")
-	CodeTrialExpired        = ErrRegistry.Register("TRIAL_EXPIRED", errx.TypeBusiness, http.StatusPaymentRequired, "Trial period expired")
-	CodeSubscriptionExpired = ErrRegistry.Register("SUBSCRIPTION_EXPIRED", errx.TypeBusiness, http.StatusPaymentRequired, "Subscription expired")
-	CodeMaxUsersReached     = ErrRegistry.Register("MAX_USERS_REACHED", errx.TypeBusiness, http.StatusForbidden, "Maximum users reached")
-	CodeTooManyUsersForPlan = ErrRegistry.Register("TOO_MANY_USERS_FOR_PLAN", errx.TypeBusiness, http.StatusBadRequest, "New plan does not support current user count")
-	CodeTenantHasUsers      = ErrRegistry.Register("TENANT_HAS_USERS", errx.TypeBusiness, http.StatusConflict, "Cannot delete tenant with active users")
-	CodeInvalidPlanUpgrade  = ErrRegistry.Register("INVALID_PLAN_UPGRADE", errx.TypeBusiness, http.StatusBadRequest, "Invalid plan upgrade")
+	CodeTenantNotFound       = ErrRegistry.Register("NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Tenant not found")
+	CodeTenantAlreadyExists  = ErrRegistry.Register("ALREADY_EXISTS", errx.TypeConflict, http.StatusConflict, "Tenant already exists")
+	CodeTenantSuspended      = ErrRegistry.Register("SUSPENDED", errx.TypeBusiness, http.StatusForbidden, "Tenant suspended")
+	CodeTrialExpired         = ErrRegistry.Register("TRIAL_EXPIRED", errx.TypeBusiness, http.StatusPaymentRequired, "Trial period expired")
+	CodeSubscriptionExpired  = ErrRegistry.Register("SUBSCRIPTION_EXPIRED", errx.TypeBusiness, http.StatusPaymentRequired, "Subscription expired")
+	CodeMaxUsersReached      = ErrRegistry.Register("MAX_USERS_REACHED", errx.TypeBusiness, http.StatusForbidden, "Maximum users reached")
+	CodeTooManyUsersForPlan  = ErrRegistry.Register("TOO_MANY_USERS_FOR_PLAN", errx.TypeBusiness, http.StatusBadRequest, "New plan does not support current user count")
+	CodeTenantHasUsers       = ErrRegistry.Register("TENANT_HAS_USERS", errx.TypeBusiness, http.StatusConflict, "Cannot delete tenant with active users")
+	CodeInvalidPlanUpgrade   = ErrRegistry.Register("INVALID_PLAN_UPGRADE", errx.TypeBusiness, http.StatusBadRequest, "Invalid plan upgrade")
+	CodeSSONotConfigured     = ErrRegistry.Register("SSO_NOT_CONFIGURED", errx.TypeNotFound, http.StatusNotFound, "No SSO connection configured for tenant")
+	CodeInvalidSSOConnection = ErrRegistry.Register("INVALID_SSO_CONNECTION", errx.TypeValidation, http.StatusBadRequest, "Invalid SSO connection")
+	CodeInvalidDomain        = ErrRegistry.Register("INVALID_DOMAIN", errx.TypeValidation, http.StatusBadRequest, "Invalid email domain")
+	CodeDomainNotFound       = ErrRegistry.Register("DOMAIN_NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Domain not claimed by tenant")
+	CodeDomainAlreadyClaimed = ErrRegistry.Register("DOMAIN_ALREADY_CLAIMED", errx.TypeConflict, http.StatusConflict, "Domain already verified by another tenant")
+	CodeFeatureNotAvailable  = ErrRegistry.Register("FEATURE_NOT_AVAILABLE", errx.TypeAuthorization, http.StatusForbidden, "Feature not available for tenant plan")
+	CodeQuotaExceeded        = ErrRegistry.Register("QUOTA_EXCEEDED", errx.TypeBusiness, http.StatusTooManyRequests, "Tenant quota exceeded")
 )
 
 // Helper functions
@@ -365,3 +420,31 @@ func ErrTenantHasUsers() *errx.Error {
 func ErrInvalidPlanUpgrade() *errx.Error {
 	return ErrRegistry.New(CodeInvalidPlanUpgrade)
 }
+
+func ErrSSONotConfigured() *errx.Error {
+	return ErrRegistry.New(CodeSSONotConfigured)
+}
+
+func ErrInvalidSSOConnection() *errx.Error {
+	return ErrRegistry.New(CodeInvalidSSOConnection)
+}
+
+func ErrInvalidDomain() *errx.Error {
+	return ErrRegistry.New(CodeInvalidDomain)
+}
+
+func ErrDomainNotFound() *errx.Error {
+	return ErrRegistry.New(CodeDomainNotFound)
+}
+
+func ErrDomainAlreadyClaimed() *errx.Error {
+	return ErrRegistry.New(CodeDomainAlreadyClaimed)
+}
+
+func ErrFeatureNotAvailable() *errx.Error {
+	return ErrRegistry.New(CodeFeatureNotAvailable)
+}
+
+func ErrQuotaExceeded() *errx.Error {
+	return ErrRegistry.New(CodeQuotaExceeded)
+}
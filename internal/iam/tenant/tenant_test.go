@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckAccess(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		tenant Tenant
+		code   string
+	}{
+		{"active", Tenant{Status: TenantStatusActive, SubscriptionPlan: PlanBasic, SubscriptionExpiresAt: &future}, ""},
+		{"trial running", Tenant{Status: TenantStatusTrial, SubscriptionPlan: PlanTrial, TrialExpiresAt: &future}, ""},
+		{"trial lapsed", Tenant{Status: TenantStatusTrial, SubscriptionPlan: PlanTrial, TrialExpiresAt: &past}, CodeTrialExpired.Code},
+		{"subscription lapsed", Tenant{Status: TenantStatusActive, SubscriptionPlan: PlanBasic, SubscriptionExpiresAt: &past}, CodeSubscriptionExpired.Code},
+		{"marked expired", Tenant{Status: TenantStatusExpired, SubscriptionPlan: PlanBasic}, CodeSubscriptionExpired.Code},
+		{"suspended", Tenant{Status: TenantStatusSuspended, SubscriptionPlan: PlanBasic}, CodeTenantSuspended.Code},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tenant.CheckAccess()
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("CheckAccess() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.code) {
+				t.Fatalf("CheckAccess() = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestExpire(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	lapsed := Tenant{Status: TenantStatusActive, SubscriptionPlan: PlanBasic, SubscriptionExpiresAt: &past}
+	if !lapsed.Expire() || lapsed.Status != TenantStatusExpired {
+		t.Errorf("lapsed tenant status = %s, want EXPIRED", lapsed.Status)
+	}
+
+	suspended := Tenant{Status: TenantStatusSuspended, SubscriptionPlan: PlanBasic, SubscriptionExpiresAt: &past}
+	if suspended.Expire() {
+		t.Error("suspended tenant must keep its status")
+	}
+}
+
+func TestSSOConnectionAllowsEmail(t *testing.T) {
+	conn := SSOConnection{EmailDomains: []string{"acme.com"}}
+	for email, want := range map[string]bool{
+		"ana@acme.com":      true,
+		"Ana@ACME.com":      true,
+		"ana@evil.com":      false,
+		"ana@sub.acme.com":  false,
+		"ana@acme.com.evil": false,
+		"acme.com":          false,
+		"":                  false,
+	} {
+		if got := conn.AllowsEmail(email); got != want {
+			t.Errorf("AllowsEmail(%q) = %v, want %v", email, got, want)
+		}
+	}
+
+	// A connection of a tenant without verified domains accepts no one
+	if (&SSOConnection{}).AllowsEmail("ana@acme.com") {
+		t.Error("connection without domains accepted an email")
+	}
+}
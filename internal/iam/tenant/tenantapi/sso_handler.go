@@ -0,0 +1,77 @@
+package tenantapi
+
+import (
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant/tenantsrv"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SSOHandlers struct {
+	service *tenantsrv.SSOConnectionService
+}
+
+func NewSSOHandlers(service *tenantsrv.SSOConnectionService) *SSOHandlers {
+	return &SSOHandlers{service: service}
+}
+
+// RegisterRoutes mounts the SSO connection of the caller's tenant. Changing
+// the IdP decides who can log in to the tenant, so only admins may manage it.
+func (h *SSOHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	sso := router.Group("/tenant/sso", authMiddleware.Authenticate(), authMiddleware.RequireAdmin())
+
+	sso.Get("/", h.GetConnection)
+	sso.Put("/", h.SaveConnection)
+	sso.Delete("/", h.DeleteConnection)
+}
+
+// GetConnection returns the tenant's SSO connection, active or not. The client
+// secret is never returned.
+func (h *SSOHandlers) GetConnection(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	conn, err := h.service.GetConnection(c.Context(), authContext.TenantID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(conn)
+}
+
+// SaveConnection creates or replaces the tenant's SSO connection. The client
+// secret is required on creation; omit it on updates to keep the stored one.
+func (h *SSOHandlers) SaveConnection(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req tenant.SaveSSOConnectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	conn, err := h.service.SaveConnection(c.Context(), authContext.TenantID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(conn)
+}
+
+func (h *SSOHandlers) DeleteConnection(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	if err := h.service.DeleteConnection(c.Context(), authContext.TenantID); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"message": "SSO connection deleted successfully"})
+}
@@ -0,0 +1,113 @@
+package tenantinfra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/redis/go-redis/v9"
+)
+
+const tenantCacheKeyPrefix = "iam:cache:tenant:"
+
+// CachedTenantRepository decora un TenantRepository con una caché de lectura
+// en Redis para FindByID. Toda escritura que pasa por el decorador invalida la
+// entrada cuando la transacción del contexto confirma; el TTL acota lo
+// desactualizado si una escritura se hace por fuera.
+//
+// Los errores de Redis no fallan la operación: se lee de la base de datos.
+type CachedTenantRepository struct {
+	tenant.TenantRepository
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewCachedTenantRepository envuelve repo con la caché
+func NewCachedTenantRepository(repo tenant.TenantRepository, client *redis.Client, ttl time.Duration) tenant.TenantRepository {
+	return &CachedTenantRepository{
+		TenantRepository: repo,
+		client:           client,
+		ttl:              ttl,
+	}
+}
+
+// FindByID busca el tenant en la caché y si no está lo carga y lo guarda
+func (r *CachedTenantRepository) FindByID(ctx context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	key := tenantCacheKeyPrefix + id.String()
+
+	data, err := r.client.Get(ctx, key).Bytes()
+	switch {
+	case err == nil:
+		var t tenant.Tenant
+		if err := json.Unmarshal(data, &t); err == nil {
+			return &t, nil
+		}
+	case !errors.Is(err, redis.Nil):
+		logx.Warnf("tenant cache read failed for %s: %v", id, err)
+	}
+
+	t, err := r.TenantRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(t); err == nil {
+		if err := r.client.Set(ctx, key, data, r.ttl).Err(); err != nil {
+			logx.Warnf("tenant cache write failed for %s: %v", id, err)
+		}
+	}
+
+	return t, nil
+}
+
+// Save guarda el tenant e invalida su entrada (cubre Suspend, Activate,
+// cambios de plan y de estado)
+func (r *CachedTenantRepository) Save(ctx context.Context, t tenant.Tenant) error {
+	if err := r.TenantRepository.Save(ctx, t); err != nil {
+		return err
+	}
+	r.invalidate(ctx, t.ID)
+	return nil
+}
+
+// Delete elimina el tenant e invalida su entrada
+func (r *CachedTenantRepository) Delete(ctx context.Context, id kernel.TenantID) error {
+	if err := r.TenantRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// ExpireLapsed expira los tenants vencidos e invalida sus entradas
+func (r *CachedTenantRepository) ExpireLapsed(ctx context.Context) ([]kernel.TenantID, error) {
+	ids, err := r.TenantRepository.ExpireLapsed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, ids...)
+	return ids, nil
+}
+
+// invalidate elimina las entradas de los tenants cuando la transacción del
+// contexto confirma. Si falla, la entrada expira con el TTL.
+func (r *CachedTenantRepository) invalidate(ctx context.Context, ids ...kernel.TenantID) {
+	if len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = tenantCacheKeyPrefix + id.String()
+	}
+	dbx.AfterCommit(ctx, func(ctx context.Context) {
+		if err := r.client.Del(ctx, keys...).Err(); err != nil {
+			logx.Warnf("tenant cache invalidation failed: %v", err)
+		}
+	})
+}
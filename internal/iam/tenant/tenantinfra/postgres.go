@@ -3,7 +3,9 @@ package tenantinfra
 import (
 	"context"
 	"database/sql"
+	"strings"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
@@ -33,7 +35,7 @@ func (r *PostgresTenantRepository) FindByID(ctx context.Context, id kernel.Tenan
 		WHERE id = $1`
 
 	var t tenant.Tenant
-	err := r.db.GetContext(ctx, &t, query, id.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &t, query, id.String())
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, tenant.ErrTenantNotFound().WithDetail("tenant_id", id.String())
@@ -56,7 +58,7 @@ func (r *PostgresTenantRepository) FindAll(ctx context.Context) ([]*tenant.Tenan
 		ORDER BY company_name ASC`
 
 	var tenants []tenant.Tenant
-	err := r.db.SelectContext(ctx, &tenants, query)
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &tenants, query)
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to find all tenants", errx.TypeInternal)
 	}
@@ -82,7 +84,7 @@ func (r *PostgresTenantRepository) FindActive(ctx context.Context) ([]*tenant.Te
 		ORDER BY company_name ASC`
 
 	var tenants []tenant.Tenant
-	err := r.db.SelectContext(ctx, &tenants, query)
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &tenants, query)
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to find active tenants", errx.TypeInternal)
 	}
@@ -123,7 +125,7 @@ func (r *PostgresTenantRepository) create(ctx context.Context, t tenant.Tenant)
 			:created_at, :updated_at
 		)`
 
-	_, err := r.db.NamedExecContext(ctx, query, t)
+	_, err := sqlx.NamedExecContext(ctx, dbx.Executor(ctx, r.db), query, t)
 	if err != nil {
 		return errx.Wrap(err, "failed to create tenant", errx.TypeInternal).
 			WithDetail("tenant_id", t.ID.String())
@@ -146,7 +148,7 @@ func (r *PostgresTenantRepository) update(ctx context.Context, t tenant.Tenant)
 			updated_at = :updated_at
 		WHERE id = :id`
 
-	result, err := r.db.NamedExecContext(ctx, query, t)
+	result, err := sqlx.NamedExecContext(ctx, dbx.Executor(ctx, r.db), query, t)
 	if err != nil {
 		return errx.Wrap(err, "failed to update tenant", errx.TypeInternal).
 			WithDetail("tenant_id", t.ID.String())
@@ -168,7 +170,7 @@ func (r *PostgresTenantRepository) update(ctx context.Context, t tenant.Tenant)
 func (r *PostgresTenantRepository) Delete(ctx context.Context, id kernel.TenantID) error {
 	query := `DELETE FROM tenants WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id.String())
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, id.String())
 	if err != nil {
 		return errx.Wrap(err, "failed to delete tenant", errx.TypeInternal).
 			WithDetail("tenant_id", id.String())
@@ -186,12 +188,126 @@ func (r *PostgresTenantRepository) Delete(ctx context.Context, id kernel.TenantI
 	return nil
 }
 
+// ExpireLapsed pasa a EXPIRED los tenants cuyo trial o suscripción vencieron.
+// El trial solo cuenta para tenants en trial, igual que Tenant.IsTrialExpired.
+func (r *PostgresTenantRepository) ExpireLapsed(ctx context.Context) ([]kernel.TenantID, error) {
+	query := `
+		UPDATE tenants SET status = 'EXPIRED', updated_at = NOW()
+		WHERE status IN ('ACTIVE', 'TRIAL')
+		AND (
+			(trial_expires_at IS NOT NULL AND trial_expires_at <= NOW()
+				AND (subscription_plan = 'TRIAL' OR status = 'TRIAL'))
+			OR (subscription_expires_at IS NOT NULL AND subscription_expires_at <= NOW())
+		)
+		RETURNING id`
+
+	var ids []kernel.TenantID
+	if err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &ids, query); err != nil {
+		return nil, errx.Wrap(err, "failed to expire lapsed tenants", errx.TypeInternal)
+	}
+
+	return ids, nil
+}
+
+// FindByEmailDomain busca los tenants activos que tienen el dominio verificado
+func (r *PostgresTenantRepository) FindByEmailDomain(ctx context.Context, domain string) ([]*tenant.Tenant, error) {
+	query := `
+		SELECT
+			t.id, t.company_name, t.status, t.subscription_plan,
+			t.max_users, t.current_users, t.trial_expires_at, t.subscription_expires_at,
+			t.created_at, t.updated_at
+		FROM tenants t
+		JOIN tenant_domains d ON d.tenant_id = t.id
+		WHERE d.domain = $1 AND d.verified_at IS NOT NULL AND t.status = 'ACTIVE'
+		ORDER BY t.company_name ASC`
+
+	var tenants []tenant.Tenant
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &tenants, query, strings.ToLower(domain))
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to find tenants by email domain", errx.TypeInternal).
+			WithDetail("domain", domain)
+	}
+
+	// Convertir a slice de punteros
+	result := make([]*tenant.Tenant, len(tenants))
+	for i := range tenants {
+		result[i] = &tenants[i]
+	}
+
+	return result, nil
+}
+
+// FindDomains busca los dominios reclamados por un tenant
+func (r *PostgresTenantRepository) FindDomains(ctx context.Context, tenantID kernel.TenantID) ([]*tenant.TenantDomain, error) {
+	query := `
+		SELECT id, tenant_id, domain, verified_at, created_at
+		FROM tenant_domains
+		WHERE tenant_id = $1
+		ORDER BY domain ASC`
+
+	var domains []tenant.TenantDomain
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &domains, query, tenantID.String())
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to find tenant domains", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	// Convertir a slice de punteros
+	result := make([]*tenant.TenantDomain, len(domains))
+	for i := range domains {
+		result[i] = &domains[i]
+	}
+
+	return result, nil
+}
+
+// SaveDomain guarda o actualiza el dominio reclamado por un tenant
+func (r *PostgresTenantRepository) SaveDomain(ctx context.Context, d tenant.TenantDomain) error {
+	query := `
+		INSERT INTO tenant_domains (id, tenant_id, domain, verified_at, created_at)
+		VALUES (:id, :tenant_id, :domain, :verified_at, :created_at)
+		ON CONFLICT (tenant_id, domain) DO UPDATE
+		SET verified_at = EXCLUDED.verified_at`
+
+	_, err := sqlx.NamedExecContext(ctx, dbx.Executor(ctx, r.db), query, d)
+	if err != nil {
+		return errx.Wrap(err, "failed to save tenant domain", errx.TypeInternal).
+			WithDetail("tenant_id", d.TenantID.String()).
+			WithDetail("domain", d.Domain)
+	}
+
+	return nil
+}
+
+// DeleteDomain elimina un dominio reclamado por un tenant
+func (r *PostgresTenantRepository) DeleteDomain(ctx context.Context, tenantID kernel.TenantID, domain string) error {
+	query := `DELETE FROM tenant_domains WHERE tenant_id = $1 AND domain = $2`
+
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, tenantID.String(), domain)
+	if err != nil {
+		return errx.Wrap(err, "failed to delete tenant domain", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String()).
+			WithDetail("domain", domain)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errx.Wrap(err, "failed to get rows affected", errx.TypeInternal)
+	}
+
+	if rowsAffected == 0 {
+		return tenant.ErrDomainNotFound().WithDetail("domain", domain)
+	}
+
+	return nil
+}
+
 // tenantExists verifica si un tenant existe por ID
 func (r *PostgresTenantRepository) tenantExists(ctx context.Context, id kernel.TenantID) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM tenants WHERE id = $1)`
 
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, query, id.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &exists, query, id.String())
 	if err != nil {
 		return false, errx.Wrap(err, "failed to check tenant existence", errx.TypeInternal).
 			WithDetail("tenant_id", id.String())
@@ -223,7 +339,7 @@ func (r *PostgresTenantConfigRepository) FindByTenant(ctx context.Context, tenan
 		FROM tenant_config 
 		WHERE tenant_id = $1`
 
-	rows, err := r.db.QueryContext(ctx, query, tenantID.String())
+	rows, err := dbx.Executor(ctx, r.db).QueryContext(ctx, query, tenantID.String())
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to find tenant config", errx.TypeInternal).
 			WithDetail("tenant_id", tenantID.String())
@@ -254,7 +370,7 @@ func (r *PostgresTenantConfigRepository) SaveSetting(ctx context.Context, tenant
 		ON CONFLICT (tenant_id, key) DO UPDATE
 		SET value = EXCLUDED.value, updated_at = NOW()`
 
-	_, err := r.db.ExecContext(ctx, query, tenantID.String(), key, value)
+	_, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, tenantID.String(), key, value)
 	if err != nil {
 		return errx.Wrap(err, "failed to save tenant config setting", errx.TypeInternal).
 			WithDetail("tenant_id", tenantID.String()).
@@ -268,7 +384,7 @@ func (r *PostgresTenantConfigRepository) SaveSetting(ctx context.Context, tenant
 func (r *PostgresTenantConfigRepository) DeleteSetting(ctx context.Context, tenantID kernel.TenantID, key string) error {
 	query := `DELETE FROM tenant_config WHERE tenant_id = $1 AND key = $2`
 
-	result, err := r.db.ExecContext(ctx, query, tenantID.String(), key)
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, tenantID.String(), key)
 	if err != nil {
 		return errx.Wrap(err, "failed to delete tenant config setting", errx.TypeInternal).
 			WithDetail("tenant_id", tenantID.String()).
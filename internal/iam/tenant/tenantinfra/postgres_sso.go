@@ -0,0 +1,189 @@
+package tenantinfra
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresSSOConnectionRepository implementación de PostgreSQL para SSOConnectionRepository.
+// El client_secret se guarda cifrado con la clave del servidor (ver ssoSecretCipher).
+type PostgresSSOConnectionRepository struct {
+	db      *sqlx.DB
+	secrets *ssoSecretCipher
+}
+
+// NewPostgresSSOConnectionRepository crea una nueva instancia del repositorio de conexiones SSO.
+// secretKey cifra los client secrets; sin ella las conexiones no se pueden guardar ni leer.
+func NewPostgresSSOConnectionRepository(db *sqlx.DB, secretKey string) tenant.SSOConnectionRepository {
+	return &PostgresSSOConnectionRepository{
+		db:      db,
+		secrets: newSSOSecretCipher(secretKey),
+	}
+}
+
+// ssoConnectionPersistence maneja los arrays de Postgres
+type ssoConnectionPersistence struct {
+	ID           string          `db:"id"`
+	TenantID     kernel.TenantID `db:"tenant_id"`
+	IssuerURL    string          `db:"issuer_url"`
+	ClientID     string          `db:"client_id"`
+	ClientSecret string          `db:"client_secret_encrypted"`
+	RedirectURL  string          `db:"redirect_url"`
+	Scopes       pq.StringArray  `db:"scopes"`
+	GroupsClaim  string          `db:"groups_claim"`
+	EmailDomains pq.StringArray  `db:"email_domains"`
+	IsActive     bool            `db:"is_active"`
+	CreatedAt    time.Time       `db:"created_at"`
+	UpdatedAt    time.Time       `db:"updated_at"`
+}
+
+// toDomain descifra el client_secret y convierte la fila en la entidad. Un
+// secreto vacío (borrado por la migración 011) queda vacío.
+func (r *PostgresSSOConnectionRepository) toDomain(p ssoConnectionPersistence) (*tenant.SSOConnection, error) {
+	secret, err := r.secrets.open(p.ClientSecret, p.TenantID.String())
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to decrypt SSO client secret", errx.TypeInternal).
+			WithDetail("tenant_id", p.TenantID.String())
+	}
+
+	return &tenant.SSOConnection{
+		ID:           p.ID,
+		TenantID:     p.TenantID,
+		IssuerURL:    p.IssuerURL,
+		ClientID:     p.ClientID,
+		ClientSecret: secret,
+		RedirectURL:  p.RedirectURL,
+		Scopes:       p.Scopes,
+		GroupsClaim:  p.GroupsClaim,
+		EmailDomains: p.EmailDomains,
+		IsActive:     p.IsActive,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}, nil
+}
+
+// ssoConnectionSelect carga la conexión junto con los dominios verificados de
+// su tenant, que son los únicos que la conexión puede autenticar
+const ssoConnectionSelect = `
+	SELECT
+		c.id, c.tenant_id, c.issuer_url, c.client_id, c.client_secret_encrypted, c.redirect_url,
+		c.scopes, c.groups_claim, c.is_active, c.created_at, c.updated_at,
+		ARRAY(
+			SELECT d.domain FROM tenant_domains d
+			WHERE d.tenant_id = c.tenant_id AND d.verified_at IS NOT NULL
+			ORDER BY d.domain
+		) AS email_domains
+	FROM tenant_sso_connections c`
+
+// FindByTenant busca la conexión SSO activa de un tenant
+func (r *PostgresSSOConnectionRepository) FindByTenant(ctx context.Context, tenantID kernel.TenantID) (*tenant.SSOConnection, error) {
+	return r.findOne(ctx, ssoConnectionSelect+` WHERE c.tenant_id = $1 AND c.is_active = TRUE`,
+		tenantID.String(), "tenant_id")
+}
+
+// FindByTenantIncludeInactive busca la conexión SSO de un tenant, activa o no
+func (r *PostgresSSOConnectionRepository) FindByTenantIncludeInactive(ctx context.Context, tenantID kernel.TenantID) (*tenant.SSOConnection, error) {
+	return r.findOne(ctx, ssoConnectionSelect+` WHERE c.tenant_id = $1`,
+		tenantID.String(), "tenant_id")
+}
+
+// FindByEmailDomain busca la conexión SSO activa del tenant que verificó el
+// dominio. Un dominio verificado pertenece a un solo tenant, así que un tenant
+// no puede desviar hacia su IdP los emails de otra empresa.
+func (r *PostgresSSOConnectionRepository) FindByEmailDomain(ctx context.Context, domain string) (*tenant.SSOConnection, error) {
+	return r.findOne(ctx, ssoConnectionSelect+`
+		JOIN tenant_domains owner ON owner.tenant_id = c.tenant_id
+		WHERE owner.domain = $1 AND owner.verified_at IS NOT NULL AND c.is_active = TRUE`,
+		strings.ToLower(domain), "domain")
+}
+
+// findOne ejecuta una consulta de una conexión con un único argumento, que se
+// reporta como detalle field del error
+func (r *PostgresSSOConnectionRepository) findOne(ctx context.Context, query, arg, field string) (*tenant.SSOConnection, error) {
+	var p ssoConnectionPersistence
+	err := r.db.GetContext(ctx, &p, query, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, tenant.ErrSSONotConfigured().WithDetail(field, arg)
+		}
+		return nil, errx.Wrap(err, "failed to find SSO connection", errx.TypeInternal).
+			WithDetail(field, arg)
+	}
+
+	return r.toDomain(p)
+}
+
+// Save crea o reemplaza la conexión SSO de un tenant (una por tenant). Los
+// dominios son los del tenant y no se guardan aquí.
+func (r *PostgresSSOConnectionRepository) Save(ctx context.Context, conn tenant.SSOConnection) error {
+	secret, err := r.secrets.seal(conn.ClientSecret, conn.TenantID.String())
+	if err != nil {
+		return errx.Wrap(err, "failed to encrypt SSO client secret", errx.TypeInternal).
+			WithDetail("tenant_id", conn.TenantID.String())
+	}
+
+	query := `
+		INSERT INTO tenant_sso_connections (
+			id, tenant_id, issuer_url, client_id, client_secret_encrypted, redirect_url,
+			scopes, groups_claim, is_active, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			issuer_url = EXCLUDED.issuer_url,
+			client_id = EXCLUDED.client_id,
+			client_secret_encrypted = EXCLUDED.client_secret_encrypted,
+			redirect_url = EXCLUDED.redirect_url,
+			scopes = EXCLUDED.scopes,
+			groups_claim = EXCLUDED.groups_claim,
+			is_active = EXCLUDED.is_active,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err = r.db.ExecContext(ctx, query,
+		conn.ID,
+		conn.TenantID.String(),
+		conn.IssuerURL,
+		conn.ClientID,
+		secret,
+		conn.RedirectURL,
+		pq.Array(conn.Scopes),
+		conn.GroupsClaim,
+		conn.IsActive,
+		conn.CreatedAt,
+		conn.UpdatedAt,
+	)
+	if err != nil {
+		return errx.Wrap(err, "failed to save SSO connection", errx.TypeInternal).
+			WithDetail("tenant_id", conn.TenantID.String())
+	}
+
+	return nil
+}
+
+// Delete elimina la conexión SSO de un tenant
+func (r *PostgresSSOConnectionRepository) Delete(ctx context.Context, tenantID kernel.TenantID) error {
+	query := `DELETE FROM tenant_sso_connections WHERE tenant_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, tenantID.String())
+	if err != nil {
+		return errx.Wrap(err, "failed to delete SSO connection", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errx.Wrap(err, "failed to get rows affected", errx.TypeInternal)
+	}
+
+	if rowsAffected == 0 {
+		return tenant.ErrSSONotConfigured().WithDetail("tenant_id", tenantID.String())
+	}
+
+	return nil
+}
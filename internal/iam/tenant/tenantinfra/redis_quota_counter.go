@@ -0,0 +1,47 @@
+package tenantinfra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisQuotaCounter implementación en Redis del QuotaCounter: cada contador es
+// un entero que expira al terminar su período
+type RedisQuotaCounter struct {
+	client *redis.Client
+}
+
+// NewRedisQuotaCounter crea un nuevo contador de cuotas con Redis
+func NewRedisQuotaCounter(client *redis.Client) tenant.QuotaCounter {
+	return &RedisQuotaCounter{
+		client: client,
+	}
+}
+
+// Usage retorna el consumo acumulado de la clave
+func (c *RedisQuotaCounter) Usage(ctx context.Context, key string) (int64, error) {
+	used, err := c.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read quota usage from Redis: %w", err)
+	}
+	return used, nil
+}
+
+// Add incrementa la clave y fija su expiración en la misma transacción
+func (c *RedisQuotaCounter) Add(ctx context.Context, key string, amount int64, expireAt time.Time) (int64, error) {
+	pipe := c.client.TxPipeline()
+	incr := pipe.IncrBy(ctx, key, amount)
+	pipe.ExpireAt(ctx, key, expireAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment quota usage in Redis: %w", err)
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,32 @@
+package tenantinfra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/testx"
+)
+
+func TestRedisQuotaCounter(t *testing.T) {
+	client := testx.Redis(t)
+	ctx := context.Background()
+	counter := NewRedisQuotaCounter(client)
+	key := "quota:t1:llm_tokens_monthly:2026-03"
+	expireAt := time.Now().Add(time.Hour)
+
+	if used, err := counter.Usage(ctx, key); err != nil || used != 0 {
+		t.Fatalf("Usage of a new key = %d, %v; want 0", used, err)
+	}
+	for _, amount := range []int64{80, 30} {
+		if _, err := counter.Add(ctx, key, amount, expireAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if used, err := counter.Usage(ctx, key); err != nil || used != 110 {
+		t.Errorf("Usage = %d, %v; want 110", used, err)
+	}
+	if ttl := client.TTL(ctx, key).Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL = %v, want the time until expireAt", ttl)
+	}
+}
@@ -0,0 +1,89 @@
+package tenantinfra
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ssoSecretPrefix versiona el formato del client_secret cifrado
+const ssoSecretPrefix = "v1:"
+
+var errSSOSecretKeyMissing = errors.New("SSO secret key is not configured")
+
+// ssoSecretCipher cifra el client_secret de las conexiones SSO con AES-256-GCM.
+// La clave AES es el SHA-256 de la clave del servidor, y el tenant_id va como
+// dato autenticado: un secreto copiado a la fila de otro tenant no descifra.
+type ssoSecretCipher struct {
+	aead cipher.AEAD
+}
+
+// newSSOSecretCipher crea el cifrador. Con una clave vacía el repositorio
+// sigue funcionando pero no puede guardar ni leer secretos.
+func newSSOSecretCipher(key string) *ssoSecretCipher {
+	if key == "" {
+		return &ssoSecretCipher{}
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		// Imposible: la clave siempre mide 32 bytes
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &ssoSecretCipher{aead: aead}
+}
+
+// seal cifra secret para tenantID
+func (c *ssoSecretCipher) seal(secret, tenantID string) (string, error) {
+	if c.aead == nil {
+		return "", errSSOSecretKeyMissing
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), []byte(tenantID))
+	return ssoSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open descifra un secreto guardado por seal para el mismo tenantID. Un
+// secreto vacío (borrado por la migración 011) se retorna vacío.
+func (c *ssoSecretCipher) open(stored, tenantID string) (string, error) {
+	if stored == "" {
+		return "", nil
+	}
+	if c.aead == nil {
+		return "", errSSOSecretKeyMissing
+	}
+
+	encoded, ok := strings.CutPrefix(stored, ssoSecretPrefix)
+	if !ok {
+		return "", errors.New("SSO client secret is not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("SSO client secret is truncated")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(tenantID))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,49 @@
+package tenantinfra
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSSOSecretCipherRoundTrip(t *testing.T) {
+	c := newSSOSecretCipher("server-key")
+
+	stored, err := c.seal("s3cret", "tenant-a")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if strings.Contains(stored, "s3cret") {
+		t.Fatalf("stored secret %q contains the plaintext", stored)
+	}
+
+	got, err := c.open(stored, "tenant-a")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if got != "s3cret" {
+		t.Errorf("open = %q, want %q", got, "s3cret")
+	}
+
+	if _, err := c.open(stored, "tenant-b"); err == nil {
+		t.Error("open with another tenant succeeded, want error")
+	}
+	if _, err := newSSOSecretCipher("other-key").open(stored, "tenant-a"); err == nil {
+		t.Error("open with another key succeeded, want error")
+	}
+	if _, err := c.open("s3cret", "tenant-a"); err == nil {
+		t.Error("open of a plaintext secret succeeded, want error")
+	}
+
+	// Secrets cleared by migration 011 read as empty
+	if got, err := c.open("", "tenant-a"); err != nil || got != "" {
+		t.Errorf("open of a cleared secret = %q, %v; want empty", got, err)
+	}
+}
+
+func TestSSOSecretCipherWithoutKey(t *testing.T) {
+	c := newSSOSecretCipher("")
+
+	if _, err := c.seal("s3cret", "tenant-a"); err != errSSOSecretKeyMissing {
+		t.Errorf("seal error = %v, want errSSOSecretKeyMissing", err)
+	}
+}
@@ -0,0 +1,90 @@
+package tenantsrv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// quotaKeyGrace mantiene el contador un día después de terminar el período,
+// para consultas del consumo del mes anterior
+const quotaKeyGrace = 24 * time.Hour
+
+// QuotaService verifica y registra el consumo de las cuotas mensuales de los
+// tenants. Los límites salen de GetEntitlements y el consumo de un QuotaCounter.
+type QuotaService struct {
+	tenantService *TenantService
+	counter       tenant.QuotaCounter
+	now           func() time.Time
+}
+
+// NewQuotaService crea un nuevo servicio de cuotas
+func NewQuotaService(tenantService *TenantService, counter tenant.QuotaCounter) *QuotaService {
+	return &QuotaService{
+		tenantService: tenantService,
+		counter:       counter,
+		now:           time.Now,
+	}
+}
+
+// GetQuotaStatus devuelve el consumo de la cuota en el período actual
+func (s *QuotaService) GetQuotaStatus(ctx context.Context, tenantID kernel.TenantID, quota tenant.Quota) (tenant.QuotaStatus, error) {
+	entitlements, err := s.tenantService.GetEntitlements(ctx, tenantID)
+	if err != nil {
+		return tenant.QuotaStatus{}, err
+	}
+
+	key, resetAt := s.periodKey(tenantID, quota)
+	used, err := s.counter.Usage(ctx, key)
+	if err != nil {
+		return tenant.QuotaStatus{}, errx.Wrap(err, "failed to read quota usage", errx.TypeInternal).
+			WithDetail("quota", string(quota))
+	}
+
+	return tenant.NewQuotaStatus(quota, entitlements.QuotaLimit(quota), used, resetAt), nil
+}
+
+// CheckQuota devuelve el estado de la cuota, o ErrQuotaExceeded con el mismo
+// estado si ya se agotó
+func (s *QuotaService) CheckQuota(ctx context.Context, tenantID kernel.TenantID, quota tenant.Quota) (tenant.QuotaStatus, error) {
+	status, err := s.GetQuotaStatus(ctx, tenantID, quota)
+	if err != nil {
+		return status, err
+	}
+	if status.Exceeded() {
+		return status, tenant.ErrQuotaExceeded().
+			WithDetail("quota", string(quota)).
+			WithDetail("limit", status.Limit).
+			WithDetail("reset_at", status.ResetAt)
+	}
+	return status, nil
+}
+
+// Consume suma amount al consumo del período. El consumo se registra aunque
+// supere el límite (p. ej. los tokens de una respuesta ya generada); las
+// siguientes llamadas a CheckQuota lo rechazan.
+func (s *QuotaService) Consume(ctx context.Context, tenantID kernel.TenantID, quota tenant.Quota, amount int64) (tenant.QuotaStatus, error) {
+	entitlements, err := s.tenantService.GetEntitlements(ctx, tenantID)
+	if err != nil {
+		return tenant.QuotaStatus{}, err
+	}
+
+	key, resetAt := s.periodKey(tenantID, quota)
+	used, err := s.counter.Add(ctx, key, amount, resetAt.Add(quotaKeyGrace))
+	if err != nil {
+		return tenant.QuotaStatus{}, errx.Wrap(err, "failed to record quota usage", errx.TypeInternal).
+			WithDetail("quota", string(quota))
+	}
+
+	return tenant.NewQuotaStatus(quota, entitlements.QuotaLimit(quota), used, resetAt), nil
+}
+
+// periodKey retorna la clave del contador del período actual y su fin
+func (s *QuotaService) periodKey(tenantID kernel.TenantID, quota tenant.Quota) (string, time.Time) {
+	period, resetAt := tenant.QuotaPeriod(s.now())
+	return fmt.Sprintf("quota:%s:%s:%s", tenantID.String(), quota, period), resetAt
+}
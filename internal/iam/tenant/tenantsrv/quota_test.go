@@ -0,0 +1,72 @@
+package tenantsrv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+type memoryCounter struct{ values map[string]int64 }
+
+func (c *memoryCounter) Usage(_ context.Context, key string) (int64, error) {
+	return c.values[key], nil
+}
+
+func (c *memoryCounter) Add(_ context.Context, key string, amount int64, _ time.Time) (int64, error) {
+	c.values[key] += amount
+	return c.values[key], nil
+}
+
+type noSettings struct{ tenant.TenantConfigRepository }
+
+func (noSettings) FindByTenant(context.Context, kernel.TenantID) (map[string]string, error) {
+	return nil, nil
+}
+
+// unreachableTenants fails like a database that is down
+type unreachableTenants struct{ tenant.TenantRepository }
+
+func (unreachableTenants) FindByID(context.Context, kernel.TenantID) (*tenant.Tenant, error) {
+	return nil, errx.Wrap(errors.New("connection refused"), "failed to find tenant by id", errx.TypeInternal)
+}
+
+func TestQuotaService(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.TenantConfig{LLMTokensMonthlyTrial: 100}
+	counter := &memoryCounter{values: map[string]int64{}}
+	quotas := NewQuotaService(NewTenantService(tenantRepo{}, noSettings{}, nil, cfg), counter)
+	quotas.now = func() time.Time { return time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC) }
+
+	if _, err := quotas.Consume(ctx, "t1", tenant.QuotaLLMTokensMonthly, 80); err != nil {
+		t.Fatal(err)
+	}
+	status, err := quotas.CheckQuota(ctx, "t1", tenant.QuotaLLMTokensMonthly)
+	if err != nil || status.Remaining != 20 {
+		t.Fatalf("CheckQuota = %+v, %v; want 20 remaining", status, err)
+	}
+	if counter.values["quota:t1:llm_tokens_monthly:2026-03"] != 80 {
+		t.Errorf("counters = %v", counter.values)
+	}
+
+	// Usage past the limit is recorded and rejects the next call
+	if status, err := quotas.Consume(ctx, "t1", tenant.QuotaLLMTokensMonthly, 30); err != nil || status.Used != 110 {
+		t.Fatalf("Consume = %+v, %v", status, err)
+	}
+	if _, err := quotas.CheckQuota(ctx, "t1", tenant.QuotaLLMTokensMonthly); !errx.Is(err, tenant.CodeQuotaExceeded) {
+		t.Errorf("CheckQuota error = %v, want QUOTA_EXCEEDED", err)
+	}
+
+	if _, err := quotas.CheckQuota(ctx, "t2", tenant.QuotaLLMTokensMonthly); !errx.Is(err, tenant.CodeTenantNotFound) {
+		t.Errorf("unknown tenant error = %v, want NOT_FOUND", err)
+	}
+	down := NewQuotaService(NewTenantService(unreachableTenants{}, noSettings{}, nil, cfg), counter)
+	if _, err := down.CheckQuota(ctx, "t1", tenant.QuotaLLMTokensMonthly); err == nil || errx.Is(err, tenant.CodeTenantNotFound) {
+		t.Errorf("database error = %v, want an internal error", err)
+	}
+}
@@ -267,6 +267,92 @@ func (s *TenantService) DeleteTenantConfig(ctx context.Context, tenantID kernel.
 	return s.tenantConfigRepo.DeleteSetting(ctx, tenantID, key)
 }
 
+// ClaimDomain registra un dominio de email para el tenant, pendiente de
+// verificación. Reclamarlo de nuevo es idempotente.
+func (s *TenantService) ClaimDomain(ctx context.Context, tenantID kernel.TenantID, domain string) (*tenant.TenantDomain, error) {
+	normalized := tenant.NormalizeDomain(domain)
+	if normalized == "" {
+		return nil, tenant.ErrInvalidDomain().WithDetail("domain", domain)
+	}
+
+	if _, err := s.tenantRepo.FindByID(ctx, tenantID); err != nil {
+		return nil, tenant.ErrTenantNotFound()
+	}
+
+	if existing, err := s.findDomain(ctx, tenantID, normalized); err == nil {
+		return existing, nil
+	}
+
+	d := tenant.TenantDomain{
+		ID:        uuid.NewString(),
+		TenantID:  tenantID,
+		Domain:    normalized,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.tenantRepo.SaveDomain(ctx, d); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// VerifyDomain marca como verificado un dominio reclamado por el tenant. La
+// prueba de propiedad (DNS, email al dominio) se hace fuera de este servicio.
+func (s *TenantService) VerifyDomain(ctx context.Context, tenantID kernel.TenantID, domain string) (*tenant.TenantDomain, error) {
+	d, err := s.findDomain(ctx, tenantID, tenant.NormalizeDomain(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	if d.IsVerified() {
+		return d, nil
+	}
+
+	// Un dominio verificado pertenece a un solo tenant
+	owners, err := s.tenantRepo.FindByEmailDomain(ctx, d.Domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range owners {
+		if owner.ID != tenantID {
+			return nil, tenant.ErrDomainAlreadyClaimed().WithDetail("domain", d.Domain)
+		}
+	}
+
+	d.MarkVerified()
+	if err := s.tenantRepo.SaveDomain(ctx, *d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// GetTenantDomains lista los dominios reclamados por el tenant
+func (s *TenantService) GetTenantDomains(ctx context.Context, tenantID kernel.TenantID) ([]*tenant.TenantDomain, error) {
+	return s.tenantRepo.FindDomains(ctx, tenantID)
+}
+
+// RemoveDomain elimina un dominio reclamado por el tenant
+func (s *TenantService) RemoveDomain(ctx context.Context, tenantID kernel.TenantID, domain string) error {
+	return s.tenantRepo.DeleteDomain(ctx, tenantID, tenant.NormalizeDomain(domain))
+}
+
+// findDomain busca un dominio entre los reclamados por el tenant
+func (s *TenantService) findDomain(ctx context.Context, tenantID kernel.TenantID, domain string) (*tenant.TenantDomain, error) {
+	domains, err := s.tenantRepo.FindDomains(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range domains {
+		if d.Domain == domain {
+			return d, nil
+		}
+	}
+
+	return nil, tenant.ErrDomainNotFound().WithDetail("domain", domain)
+}
+
 // GetTenantStats obtiene estadísticas del tenant
 func (s *TenantService) GetTenantStats(ctx context.Context, tenantID kernel.TenantID) (*tenant.TenantStatsResponse, error) {
 	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
@@ -393,7 +479,95 @@ func (s *TenantService) BulkActivateTenants(ctx context.Context, tenantIDs []ker
 	return result, nil
 }
 
+// MaxConcurrentAgentRuns devuelve el máximo de ejecuciones de agentes
+// simultáneas permitidas por el plan del tenant (0 = sin límite).
+// Se usa como agentx.LimitFunc para los RunLimiter.
+func (s *TenantService) MaxConcurrentAgentRuns(ctx context.Context, tenantID kernel.TenantID) (int, error) {
+	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		if errx.Is(err, tenant.CodeTenantNotFound) {
+			return 0, err
+		}
+		return 0, errx.Wrap(err, "failed to find tenant", errx.TypeInternal)
+	}
+
+	switch tenantEntity.SubscriptionPlan {
+	case tenant.PlanTrial:
+		return s.config.MaxAgentRunsTrial, nil
+	case tenant.PlanBasic:
+		return s.config.MaxAgentRunsBasic, nil
+	case tenant.PlanProfessional:
+		return s.config.MaxAgentRunsProfessional, nil
+	case tenant.PlanEnterprise:
+		return s.config.MaxAgentRunsEnterprise, nil
+	default:
+		return 1, nil
+	}
+}
+
+// GetEntitlements devuelve las funcionalidades, cuotas y alias de modelo
+// efectivos del tenant: los de su plan con las sobrescrituras feature.*,
+// quota.* y model.* de su configuración
+func (s *TenantService) GetEntitlements(ctx context.Context, tenantID kernel.TenantID) (*tenant.Entitlements, error) {
+	// El repositorio ya distingue TENANT_NOT_FOUND de los fallos de la base,
+	// que no deben dejar de cobrar ni ocultarse como un 404
+	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.tenantConfigRepo.FindByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to load tenant config", errx.TypeInternal)
+	}
+
+	quotas := map[tenant.Quota]int64{
+		tenant.QuotaLLMTokensMonthly: s.llmTokensMonthlyForPlan(tenantEntity.SubscriptionPlan),
+	}
+
+	return tenant.NewEntitlements(tenantEntity.SubscriptionPlan, quotas, s.modelAliasesForPlan(tenantEntity.SubscriptionPlan), settings), nil
+}
+
+// ModelAliases devuelve los alias de modelo del tenant: los de su plan con
+// las sobrescrituras model.* de su configuración. Se usa como
+// modelx.TenantAliasesFunc.
+func (s *TenantService) ModelAliases(ctx context.Context, tenantID kernel.TenantID) (map[string]string, error) {
+	entitlements, err := s.GetEntitlements(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return entitlements.Models, nil
+}
+
 // Helper methods
+func (s *TenantService) llmTokensMonthlyForPlan(plan tenant.SubscriptionPlan) int64 {
+	switch plan {
+	case tenant.PlanTrial:
+		return s.config.LLMTokensMonthlyTrial
+	case tenant.PlanBasic:
+		return s.config.LLMTokensMonthlyBasic
+	case tenant.PlanProfessional:
+		return s.config.LLMTokensMonthlyProfessional
+	case tenant.PlanEnterprise:
+		return s.config.LLMTokensMonthlyEnterprise
+	default:
+		return s.config.LLMTokensMonthlyTrial
+	}
+}
+
+func (s *TenantService) modelAliasesForPlan(plan tenant.SubscriptionPlan) map[string]string {
+	switch plan {
+	case tenant.PlanBasic:
+		return s.config.ModelAliasesBasic
+	case tenant.PlanProfessional:
+		return s.config.ModelAliasesProfessional
+	case tenant.PlanEnterprise:
+		return s.config.ModelAliasesEnterprise
+	default:
+		return s.config.ModelAliasesTrial
+	}
+}
+
 func (s *TenantService) getMaxUsersForPlan(plan tenant.SubscriptionPlan) int {
 	switch plan {
 	case tenant.PlanTrial, tenant.PlanBasic:
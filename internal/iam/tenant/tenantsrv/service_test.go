@@ -0,0 +1,53 @@
+package tenantsrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// planTenants returns tenants whose plan is their ID
+type planTenants struct{ tenant.TenantRepository }
+
+func (planTenants) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	if id == "missing" {
+		return nil, tenant.ErrTenantNotFound()
+	}
+	return &tenant.Tenant{ID: id, SubscriptionPlan: tenant.SubscriptionPlan(id)}, nil
+}
+
+func TestMaxConcurrentAgentRuns(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.TenantConfig{MaxAgentRunsTrial: 2, MaxAgentRunsProfessional: 10, MaxAgentRunsEnterprise: 0}
+	svc := NewTenantService(planTenants{}, noSettings{}, nil, cfg)
+
+	tests := []struct {
+		tenantID kernel.TenantID
+		want     int
+	}{
+		{kernel.TenantID(tenant.PlanTrial), 2},
+		{kernel.TenantID(tenant.PlanProfessional), 10},
+		{kernel.TenantID(tenant.PlanEnterprise), 0},
+		{"unknown-plan", 1},
+	}
+	for _, tt := range tests {
+		if got, err := svc.MaxConcurrentAgentRuns(ctx, tt.tenantID); err != nil || got != tt.want {
+			t.Errorf("MaxConcurrentAgentRuns(%s) = %d, %v; want %d", tt.tenantID, got, err, tt.want)
+		}
+	}
+
+	if _, err := svc.MaxConcurrentAgentRuns(ctx, "missing"); !errx.Is(err, tenant.CodeTenantNotFound) {
+		t.Errorf("missing tenant error = %v, want TENANT_NOT_FOUND", err)
+	}
+
+	// A database failure is not reported as a missing tenant
+	down := NewTenantService(unreachableTenants{}, noSettings{}, nil, cfg)
+	_, err := down.MaxConcurrentAgentRuns(ctx, "t1")
+	if e, ok := errx.AsError(err); !ok || e.Type != errx.TypeInternal || errx.Is(err, tenant.CodeTenantNotFound) {
+		t.Errorf("database failure error = %v, want an internal error", err)
+	}
+}
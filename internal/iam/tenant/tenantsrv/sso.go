@@ -0,0 +1,124 @@
+package tenantsrv
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/google/uuid"
+)
+
+// ssoCallbackPath es la ruta del callback de las conexiones SSO
+const ssoCallbackPath = "/auth/callback/sso"
+
+// defaultSSOScopes se usan si la conexión no indica scopes
+var defaultSSOScopes = []string{"openid", "email", "profile"}
+
+// SSOConnectionService administra la conexión SSO propia de cada tenant. El
+// repositorio cifra el client secret; los dominios que la conexión autentica
+// son los verificados del tenant (ver TenantService.VerifyDomain).
+type SSOConnectionService struct {
+	ssoRepo    tenant.SSOConnectionRepository
+	tenantRepo tenant.TenantRepository
+}
+
+// NewSSOConnectionService crea una nueva instancia del servicio de conexiones SSO
+func NewSSOConnectionService(ssoRepo tenant.SSOConnectionRepository, tenantRepo tenant.TenantRepository) *SSOConnectionService {
+	return &SSOConnectionService{
+		ssoRepo:    ssoRepo,
+		tenantRepo: tenantRepo,
+	}
+}
+
+// GetConnection obtiene la conexión SSO del tenant, activa o no
+func (s *SSOConnectionService) GetConnection(ctx context.Context, tenantID kernel.TenantID) (*tenant.SSOConnection, error) {
+	return s.ssoRepo.FindByTenantIncludeInactive(ctx, tenantID)
+}
+
+// SaveConnection crea o reemplaza la conexión SSO del tenant. Al crearla el
+// client secret es obligatorio; al actualizarla, omitirlo conserva el actual.
+func (s *SSOConnectionService) SaveConnection(ctx context.Context, tenantID kernel.TenantID, req tenant.SaveSSOConnectionRequest) (*tenant.SSOConnection, error) {
+	if err := validateSSOConnection(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.tenantRepo.FindByID(ctx, tenantID); err != nil {
+		if errx.Is(err, tenant.CodeTenantNotFound) {
+			return nil, err
+		}
+		return nil, errx.Wrap(err, "failed to find tenant", errx.TypeInternal)
+	}
+
+	now := time.Now().UTC()
+	conn := tenant.SSOConnection{
+		ID:        uuid.NewString(),
+		TenantID:  tenantID,
+		CreatedAt: now,
+	}
+	existing, err := s.ssoRepo.FindByTenantIncludeInactive(ctx, tenantID)
+	switch {
+	case err == nil:
+		conn = *existing
+	case !errx.Is(err, tenant.CodeSSONotConfigured):
+		return nil, err
+	}
+
+	conn.IssuerURL = strings.TrimSuffix(strings.TrimSpace(req.IssuerURL), "/")
+	conn.ClientID = strings.TrimSpace(req.ClientID)
+	conn.RedirectURL = strings.TrimSpace(req.RedirectURL)
+	conn.GroupsClaim = strings.TrimSpace(req.GroupsClaim)
+	conn.Scopes = req.Scopes
+	if len(conn.Scopes) == 0 {
+		conn.Scopes = defaultSSOScopes
+	}
+	if req.ClientSecret != "" {
+		conn.ClientSecret = req.ClientSecret
+	}
+	if conn.ClientSecret == "" {
+		return nil, tenant.ErrInvalidSSOConnection().WithDetail("reason", "client_secret is required")
+	}
+	conn.IsActive = req.IsActive == nil || *req.IsActive
+	conn.UpdatedAt = now
+
+	if err := s.ssoRepo.Save(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	// Releer para devolver los dominios verificados del tenant
+	return s.ssoRepo.FindByTenantIncludeInactive(ctx, tenantID)
+}
+
+// DeleteConnection elimina la conexión SSO del tenant
+func (s *SSOConnectionService) DeleteConnection(ctx context.Context, tenantID kernel.TenantID) error {
+	return s.ssoRepo.Delete(ctx, tenantID)
+}
+
+// validateSSOConnection valida los campos que el discovery no comprueba: el
+// issuer debe ser https y el redirect debe llegar al callback SSO
+func validateSSOConnection(req tenant.SaveSSOConnectionRequest) error {
+	issuer, err := url.Parse(strings.TrimSpace(req.IssuerURL))
+	if err != nil || issuer.Scheme != "https" || issuer.Host == "" {
+		return tenant.ErrInvalidSSOConnection().WithDetail("reason", "issuer_url must be an https URL")
+	}
+
+	redirect, err := url.Parse(strings.TrimSpace(req.RedirectURL))
+	if err != nil || (redirect.Scheme != "https" && redirect.Scheme != "http") || redirect.Host == "" ||
+		!strings.HasSuffix(strings.TrimSuffix(redirect.Path, "/"), ssoCallbackPath) {
+		return tenant.ErrInvalidSSOConnection().WithDetail("reason", "redirect_url must point to "+ssoCallbackPath)
+	}
+
+	if strings.TrimSpace(req.ClientID) == "" {
+		return tenant.ErrInvalidSSOConnection().WithDetail("reason", "client_id is required")
+	}
+
+	if len(req.Scopes) > 0 && !slices.Contains(req.Scopes, "openid") {
+		return tenant.ErrInvalidSSOConnection().WithDetail("reason", "scopes must include openid")
+	}
+
+	return nil
+}
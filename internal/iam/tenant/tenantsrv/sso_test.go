@@ -0,0 +1,109 @@
+package tenantsrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+type tenantRepo struct{ tenant.TenantRepository }
+
+func (tenantRepo) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	if id != "t1" {
+		return nil, tenant.ErrTenantNotFound()
+	}
+	return &tenant.Tenant{ID: id}, nil
+}
+
+// ssoRepo keeps one connection per tenant in memory
+type ssoRepo struct {
+	tenant.SSOConnectionRepository
+	conns map[kernel.TenantID]tenant.SSOConnection
+}
+
+func (r *ssoRepo) FindByTenantIncludeInactive(_ context.Context, tenantID kernel.TenantID) (*tenant.SSOConnection, error) {
+	conn, ok := r.conns[tenantID]
+	if !ok {
+		return nil, tenant.ErrSSONotConfigured()
+	}
+	return &conn, nil
+}
+
+func (r *ssoRepo) Save(_ context.Context, conn tenant.SSOConnection) error {
+	r.conns[conn.TenantID] = conn
+	return nil
+}
+
+func TestSaveSSOConnection(t *testing.T) {
+	ctx := context.Background()
+	repo := &ssoRepo{conns: map[kernel.TenantID]tenant.SSOConnection{}}
+	service := NewSSOConnectionService(repo, tenantRepo{})
+	req := tenant.SaveSSOConnectionRequest{
+		IssuerURL:   "https://acme.okta.com/oauth2/default/",
+		ClientID:    "acme-app",
+		RedirectURL: "https://app.example.com/auth/callback/sso",
+	}
+
+	if _, err := service.SaveConnection(ctx, "t1", req); !errx.Is(err, tenant.CodeInvalidSSOConnection) {
+		t.Fatalf("create without secret error = %v, want INVALID_SSO_CONNECTION", err)
+	}
+
+	req.ClientSecret = "s3cret"
+	created, err := service.SaveConnection(ctx, "t1", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.IssuerURL != "https://acme.okta.com/oauth2/default" || !created.IsActive || len(created.Scopes) != 3 || created.ID == "" {
+		t.Errorf("created connection = %+v", created)
+	}
+
+	// Updating without a secret keeps the stored one and the connection's ID
+	inactive := false
+	req.ClientSecret = ""
+	req.IsActive = &inactive
+	updated, err := service.SaveConnection(ctx, "t1", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ClientSecret != "s3cret" || updated.ID != created.ID || updated.IsActive {
+		t.Errorf("updated connection = %+v", updated)
+	}
+
+	if _, err := service.SaveConnection(ctx, "t2", tenant.SaveSSOConnectionRequest{
+		IssuerURL: req.IssuerURL, ClientID: "x", ClientSecret: "y", RedirectURL: req.RedirectURL,
+	}); !errx.Is(err, tenant.CodeTenantNotFound) {
+		t.Errorf("unknown tenant error = %v, want TENANT_NOT_FOUND", err)
+	}
+}
+
+func TestValidateSSOConnection(t *testing.T) {
+	valid := tenant.SaveSSOConnectionRequest{
+		IssuerURL:   "https://acme.okta.com",
+		ClientID:    "acme-app",
+		RedirectURL: "https://app.example.com/auth/callback/sso",
+	}
+	if err := validateSSOConnection(valid); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+
+	tests := map[string]func(r *tenant.SaveSSOConnectionRequest){
+		"http issuer":       func(r *tenant.SaveSSOConnectionRequest) { r.IssuerURL = "http://acme.okta.com" },
+		"relative issuer":   func(r *tenant.SaveSSOConnectionRequest) { r.IssuerURL = "acme.okta.com" },
+		"other redirect":    func(r *tenant.SaveSSOConnectionRequest) { r.RedirectURL = "https://evil.example.com/steal" },
+		"relative redirect": func(r *tenant.SaveSSOConnectionRequest) { r.RedirectURL = "/auth/callback/sso" },
+		"no client id":      func(r *tenant.SaveSSOConnectionRequest) { r.ClientID = " " },
+		"no openid scope":   func(r *tenant.SaveSSOConnectionRequest) { r.Scopes = []string{"email"} },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := valid
+			mutate(&req)
+			if err := validateSSOConnection(req); !errx.Is(err, tenant.CodeInvalidSSOConnection) {
+				t.Errorf("error = %v, want INVALID_SSO_CONNECTION", err)
+			}
+		})
+	}
+}
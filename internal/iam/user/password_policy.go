@@ -0,0 +1,82 @@
+package user
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Reglas de la política de contraseñas, reportadas en failed_rules
+const (
+	PasswordRuleMinLength = "min_length"
+	PasswordRuleMaxLength = "max_length"
+	PasswordRuleUppercase = "uppercase"
+	PasswordRuleLowercase = "lowercase"
+	PasswordRuleDigit     = "digit"
+	PasswordRuleSymbol    = "symbol"
+)
+
+// PasswordPolicy define los requisitos de una contraseña. MinLength se cuenta
+// en caracteres (runes), no en bytes; el máximo es siempre MaxPasswordBytes
+// bytes.
+type PasswordPolicy struct {
+	MinLength        int  `json:"min_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+}
+
+// DefaultPasswordPolicy solo exige MinPasswordLength caracteres
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: MinPasswordLength}
+}
+
+// FailedRules retorna las reglas que la contraseña no cumple, vacío si es válida
+func (p PasswordPolicy) FailedRules(password string) []string {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	failed := []string{}
+	if utf8.RuneCountInString(password) < max(p.MinLength, 1) {
+		failed = append(failed, PasswordRuleMinLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		failed = append(failed, PasswordRuleMaxLength)
+	}
+	if p.RequireUppercase && !hasUpper {
+		failed = append(failed, PasswordRuleUppercase)
+	}
+	if p.RequireLowercase && !hasLower {
+		failed = append(failed, PasswordRuleLowercase)
+	}
+	if p.RequireDigit && !hasDigit {
+		failed = append(failed, PasswordRuleDigit)
+	}
+	if p.RequireSymbol && !hasSymbol {
+		failed = append(failed, PasswordRuleSymbol)
+	}
+	return failed
+}
+
+// Validate retorna ErrInvalidPassword con las reglas incumplidas y la política
+// en los detalles, o nil si la contraseña es válida
+func (p PasswordPolicy) Validate(password string) error {
+	failed := p.FailedRules(password)
+	if len(failed) == 0 {
+		return nil
+	}
+	return ErrInvalidPassword().
+		WithDetail("failed_rules", failed).
+		WithDetail("policy", p)
+}
@@ -9,12 +9,17 @@ import (
 // UserRepository define el contrato para la persistencia de usuarios
 type UserRepository interface {
 	FindByID(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) (*User, error)
+	FindByIDIncludeDeleted(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) (*User, error)
 	FindByEmail(ctx context.Context, email string, tenantID kernel.TenantID) (*User, error)
+	FindByPhone(ctx context.Context, phone string, tenantID kernel.TenantID) (*User, error)
 	FindByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*User, error)
+	Search(ctx context.Context, tenantID kernel.TenantID, filter UserSearchFilter) ([]*User, int, error)
 	Save(ctx context.Context, u User) error
-	Delete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error
+	Delete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error // Soft delete
+	HardDelete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error
 	ExistsByEmail(ctx context.Context, email string, tenantID kernel.TenantID) (bool, error)
 	FindByEmailAcrossTenants(ctx context.Context, email string) ([]*User, error)
+	FindByPhoneAcrossTenants(ctx context.Context, phone string) ([]*User, error)
 }
 
 // PasswordService define el contrato para el manejo de contraseñas
@@ -1,11 +1,14 @@
 package user
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
 	"github.com/Abraxas-365/manifesto/internal/ptrx"
 	"slices"
@@ -15,6 +18,13 @@ import (
 // User Entity
 // ============================================================================
 
+// MinPasswordLength es el largo mínimo por defecto de una contraseña
+const MinPasswordLength = 8
+
+// MaxPasswordBytes es el largo máximo de una contraseña en bytes: bcrypt no
+// acepta más de 72
+const MaxPasswordBytes = 72
+
 // UserStatus define los posibles estados de un usuario
 type UserStatus string
 
@@ -23,8 +33,32 @@ const (
 	UserStatusInactive  UserStatus = "INACTIVE"
 	UserStatusSuspended UserStatus = "SUSPENDED"
 	UserStatusPending   UserStatus = "PENDING" // Invitado pero no completó onboarding
+	UserStatusDeleted   UserStatus = "DELETED" // Eliminado lógicamente (soft delete)
 )
 
+// ErasureMode define cómo se borran los datos personales de un usuario
+// (derecho al olvido)
+type ErasureMode string
+
+const (
+	// ErasureModeAnonymize conserva la fila con los datos personales borrados,
+	// de modo que lo que la referencia (invitaciones que envió, API keys,
+	// auditoría) sigue siendo válido
+	ErasureModeAnonymize ErasureMode = "ANONYMIZE"
+	// ErasureModeHardDelete elimina la fila; las invitaciones que envió el
+	// usuario se eliminan en cascada y sus API keys quedan sin usuario
+	ErasureModeHardDelete ErasureMode = "HARD_DELETE"
+)
+
+// IsValid indica si el modo es uno de los soportados
+func (m ErasureMode) IsValid() bool {
+	return m == ErasureModeAnonymize || m == ErasureModeHardDelete
+}
+
+// erasedEmailDomain es el dominio de los emails de usuarios anonimizados. El
+// TLD .invalid está reservado, así que nunca recibe correo.
+const erasedEmailDomain = "@erased.invalid"
+
 // User es la entidad rica que representa a un usuario en el sistema
 // User entity
 type User struct {
@@ -38,13 +72,17 @@ type User struct {
 	OAuthProvider   iam.OAuthProvider `db:"oauth_provider" json:"oauth_provider"`
 	OAuthProviderID string            `db:"oauth_provider_id" json:"oauth_provider_id"`
 	OTPEnabled      bool              `db:"otp_enabled" json:"otp_enabled"` // NEW: Track if OTP is enabled
+	Phone           *string           `db:"phone" json:"phone,omitempty"`   // E.164, habilita OTP por SMS
+	PasswordHash    *string           `db:"password_hash" json:"-"`         // nil: sin login con contraseña
 
-	Status        UserStatus `db:"status" json:"status"`
-	Scopes        []string   `db:"scopes" json:"scopes"`
-	EmailVerified bool       `db:"email_verified" json:"email_verified"`
-	LastLoginAt   *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
-	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+	Status          UserStatus `db:"status" json:"status"`
+	Scopes          []string   `db:"scopes" json:"scopes"`
+	RequestedScopes []string   `db:"requested_scopes" json:"requested_scopes,omitempty"` // antes de normalizar; nil si no cambiaron
+	EmailVerified   bool       `db:"email_verified" json:"email_verified"`
+	LastLoginAt     *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 }
 
 // Domain methods
@@ -56,6 +94,11 @@ func (u *User) HasOTP() bool {
 	return u.OTPEnabled
 }
 
+// HasPassword indica si el usuario habilitó el login con contraseña
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != nil && *u.PasswordHash != ""
+}
+
 func (u *User) HasMultipleAuthMethods() bool {
 	return u.HasOAuth() && u.HasOTP()
 }
@@ -68,6 +111,36 @@ func (u *User) CanLoginWithOTP() bool {
 	return u.HasOTP() && u.IsActive() && u.EmailVerified
 }
 
+func (u *User) CanLoginWithPassword() bool {
+	return u.HasPassword() && u.CanLogin()
+}
+
+// SetPassword valida la contraseña contra policy y la hashea, habilitando el
+// login con contraseña para el usuario
+func (u *User) SetPassword(password string, policy PasswordPolicy, passwordSvc PasswordService) error {
+	if err := policy.Validate(password); err != nil {
+		return err
+	}
+
+	hash, err := passwordSvc.HashPassword(password)
+	if err != nil {
+		return errx.Wrap(err, "failed to hash password", errx.TypeInternal)
+	}
+
+	u.PasswordHash = &hash
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+// CheckPassword verifica la contraseña contra el hash guardado. Sin
+// contraseña configurada siempre retorna false.
+func (u *User) CheckPassword(password string, passwordSvc PasswordService) bool {
+	if !u.HasPassword() {
+		return false
+	}
+	return passwordSvc.VerifyPassword(*u.PasswordHash, password)
+}
+
 func (u *User) EnableOTP() {
 	u.OTPEnabled = true
 	u.UpdatedAt = time.Now()
@@ -79,6 +152,34 @@ func (u *User) LinkOAuth(provider iam.OAuthProvider, providerID string) {
 	u.UpdatedAt = time.Now()
 }
 
+// LoginMethods retorna los métodos de login habilitados del usuario
+func (u *User) LoginMethods() LoginMethods {
+	methods := LoginMethods{
+		OTP:      u.HasOTP(),
+		Password: u.HasPassword(),
+	}
+	if u.HasOAuth() {
+		methods.OAuthProvider = u.OAuthProvider
+	}
+	return methods
+}
+
+// UnlinkOAuth desvincula el proveedor OAuth. Falla si es el único método de
+// login que le queda al usuario, para que no pierda el acceso a su cuenta.
+func (u *User) UnlinkOAuth() error {
+	if !u.HasOAuth() {
+		return ErrAuthMethodNotEnabled().WithDetail("method", "oauth")
+	}
+	if !u.HasOTP() && !u.HasPassword() {
+		return ErrLastLoginMethod()
+	}
+
+	u.OAuthProvider = ""
+	u.OAuthProviderID = ""
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
 // ============================================================================
 // Domain Methods
 // ============================================================================
@@ -133,26 +234,61 @@ func (u *User) UpdateProfile(name, picture string) {
 	u.UpdatedAt = time.Now()
 }
 
+// IsDeleted verifica si el usuario fue eliminado lógicamente
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+// Anonymize borra los datos personales del usuario (email, nombre, foto,
+// teléfono y credenciales) y lo deja eliminado lógicamente. El email se
+// reemplaza por uno derivado del ID, que no identifica a la persona y sigue
+// siendo único en el tenant.
+func (u *User) Anonymize() {
+	now := time.Now()
+
+	u.Email = fmt.Sprintf("erased+%s%s", u.ID, erasedEmailDomain)
+	u.Name = "Erased user"
+	u.Picture = nil
+	u.Phone = nil
+	u.PasswordHash = nil
+	u.OAuthProvider = ""
+	u.OAuthProviderID = ""
+	u.OTPEnabled = false
+	u.EmailVerified = false
+	u.Scopes = []string{}
+	u.RequestedScopes = nil
+	u.Status = UserStatusDeleted
+	u.UpdatedAt = now
+	if u.DeletedAt == nil {
+		u.DeletedAt = &now
+	}
+}
+
+// IsErased indica si los datos personales del usuario fueron borrados
+func (u *User) IsErased() bool {
+	return strings.HasSuffix(u.Email, erasedEmailDomain)
+}
+
+// Restore recupera un usuario eliminado lógicamente. Un usuario anonimizado
+// no se puede recuperar.
+func (u *User) Restore() error {
+	if !u.IsDeleted() || u.IsErased() {
+		return ErrInvalidStatus().WithDetail("current_status", u.Status)
+	}
+
+	u.DeletedAt = nil
+	u.Status = UserStatusActive
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
 // ============================================================================
 // Scope Management Methods
 // ============================================================================
 
 // HasScope verifica si el usuario tiene un scope específico
 func (u *User) HasScope(scope string) bool {
-	for _, s := range u.Scopes {
-		// Exact match or wildcard "*"
-		if s == scope || s == "*" {
-			return true
-		}
-		// Wildcard match (e.g., "channels:*" matches "channels:read")
-		if len(s) > 2 && s[len(s)-2:] == ":*" {
-			prefix := s[:len(s)-2]
-			if len(scope) > len(prefix) && scope[:len(prefix)] == prefix && scope[len(prefix)] == ':' {
-				return true
-			}
-		}
-	}
-	return false
+	return scopes.HasScopeIn(u.Scopes, scope)
 }
 
 // IsAdmin verifica si el usuario tiene permisos de administrador
@@ -179,6 +315,7 @@ func (u *User) HasAllScopes(scopes ...string) bool {
 func (u *User) AddScope(scope string) {
 	if !u.HasScope(scope) {
 		u.Scopes = append(u.Scopes, scope)
+		u.RequestedScopes = nil
 		u.UpdatedAt = time.Now()
 	}
 }
@@ -192,15 +329,37 @@ func (u *User) RemoveScope(scope string) {
 		}
 	}
 	u.Scopes = newScopes
+	u.RequestedScopes = nil
 	u.UpdatedAt = time.Now()
 }
 
-// SetScopes establece los scopes del usuario
+// SetScopes establece los scopes del usuario. Como AddScope y RemoveScope,
+// descarta la lista sin normalizar: la lista dada pasa a ser la otorgada.
 func (u *User) SetScopes(scopes []string) {
 	u.Scopes = scopes
+	u.RequestedScopes = nil
 	u.UpdatedAt = time.Now()
 }
 
+// SetNormalizedScopes guarda normalized como scopes efectivos y requested
+// como la lista otorgada, solo si la normalización la cambió
+func (u *User) SetNormalizedScopes(requested, normalized []string) {
+	u.Scopes = normalized
+	u.RequestedScopes = nil
+	if !slices.Equal(requested, normalized) {
+		u.RequestedScopes = requested
+	}
+	u.UpdatedAt = time.Now()
+}
+
+// RawScopes retorna los scopes tal como se otorgaron, antes de normalizar
+func (u *User) RawScopes() []string {
+	if u.RequestedScopes != nil {
+		return u.RequestedScopes
+	}
+	return u.Scopes
+}
+
 // MakeAdmin convierte al usuario en administrador (asigna scope "*")
 func (u *User) MakeAdmin() {
 	u.AddScope("*")
@@ -218,30 +377,39 @@ func (u *User) RevokeAdmin() {
 
 // UserDetailsDTO contiene información básica de un usuario para otros módulos
 type UserDetailsDTO struct {
-	ID            kernel.UserID     `json:"id"`
-	TenantID      kernel.TenantID   `json:"tenant_id"`
-	Name          string            `json:"name"`
-	Email         string            `json:"email"`
-	Picture       *string           `json:"picture,omitempty"`
-	IsActive      bool              `json:"is_active"`
-	Scopes        []string          `json:"scopes"`
-	OAuthProvider iam.OAuthProvider `json:"oauth_provider"`
+	ID              kernel.UserID     `json:"id"`
+	TenantID        kernel.TenantID   `json:"tenant_id"`
+	Name            string            `json:"name"`
+	Email           string            `json:"email"`
+	Picture         *string           `json:"picture,omitempty"`
+	IsActive        bool              `json:"is_active"`
+	Scopes          []string          `json:"scopes"`
+	RequestedScopes []string          `json:"requested_scopes,omitempty"`
+	OAuthProvider   iam.OAuthProvider `json:"oauth_provider"`
 }
 
 // ToDTO convierte la entidad User a UserDetailsDTO
 func (u *User) ToDTO() UserDetailsDTO {
 	return UserDetailsDTO{
-		ID:            u.ID,
-		TenantID:      u.TenantID,
-		Name:          u.Name,
-		Email:         u.Email,
-		Picture:       u.Picture,
-		IsActive:      u.IsActive(),
-		Scopes:        u.Scopes,
-		OAuthProvider: u.OAuthProvider,
+		ID:              u.ID,
+		TenantID:        u.TenantID,
+		Name:            u.Name,
+		Email:           u.Email,
+		Picture:         u.Picture,
+		IsActive:        u.IsActive(),
+		Scopes:          u.Scopes,
+		RequestedScopes: u.RequestedScopes,
+		OAuthProvider:   u.OAuthProvider,
 	}
 }
 
+// LoginMethods son los métodos con los que el usuario puede iniciar sesión
+type LoginMethods struct {
+	OAuthProvider iam.OAuthProvider `json:"oauth_provider,omitempty"` // Vacío si no tiene OAuth vinculado
+	OTP           bool              `json:"otp"`
+	Password      bool              `json:"password"`
+}
+
 // ============================================================================
 // Service DTOs - Para operaciones de la capa de servicio
 // ============================================================================
@@ -262,6 +430,7 @@ type UpdateUserRequest struct {
 	Status        *UserStatus     `json:"status,omitempty"`
 	Scopes        []string        `json:"scopes,omitempty"`         // ✅ Direct scopes to set
 	ScopeTemplate *string         `json:"scope_template,omitempty"` // ✅ Template to apply
+	Phone         *string         `json:"phone,omitempty"`          // E.164; "" lo elimina
 }
 
 // InviteUserRequest para invitar usuarios a un tenant
@@ -271,6 +440,35 @@ type InviteUserRequest struct {
 	ScopeTemplate *string  `json:"scope_template,omitempty"`
 }
 
+// BulkInviteRequest para invitar varios usuarios a la vez
+type BulkInviteRequest struct {
+	Users []InviteUserRequest `json:"users"`
+}
+
+// BulkInviteResult resultado de invitaciones masivas. Successful lista los
+// emails invitados y notificados; EmailFailed, los invitados cuyo email no se
+// pudo enviar (se puede reenviar la invitación); Failed, las filas sin invitación.
+type BulkInviteResult struct {
+	Successful  []string            `json:"successful"`
+	EmailFailed []string            `json:"email_failed"`
+	Failed      []BulkInviteFailure `json:"failed"`
+	Total       int                 `json:"total"`
+}
+
+// BulkInviteFailure es una fila que no se pudo invitar. Row es su posición en
+// la lista (o la fila de datos del CSV), desde 1, de modo que las filas
+// repetidas o sin email se reportan por separado.
+type BulkInviteFailure struct {
+	Row   int    `json:"row"`
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+// PageCursor retorna la posición del usuario para la paginación por cursor
+func (u *User) PageCursor() kernel.Cursor {
+	return kernel.Cursor{CreatedAt: u.CreatedAt, ID: u.ID.String()}
+}
+
 // UserResponse representa la respuesta completa de un usuario
 type UserResponse struct {
 	User User `json:"user"`
@@ -294,6 +492,12 @@ type SuspendUserRequest struct {
 	Reason   string          `json:"reason" validate:"required,min=5"`
 }
 
+// EraseUserRequest para borrar los datos personales de un usuario. Sin modo
+// se anonimiza.
+type EraseUserRequest struct {
+	Mode ErasureMode `json:"mode"`
+}
+
 // ActivateUserRequest para activar un usuario
 type ActivateUserRequest struct {
 	TenantID kernel.TenantID `json:"tenant_id" validate:"required"`
@@ -324,6 +528,29 @@ type UserListResponseDTO struct {
 	Total int               `json:"total"`
 }
 
+// UserSearchFilter define los criterios de búsqueda paginada de usuarios
+type UserSearchFilter struct {
+	Status         *UserStatus `json:"status,omitempty"`
+	Email          string      `json:"email,omitempty"` // Substring, case-insensitive
+	Scope          string      `json:"scope,omitempty"` // Usuarios que tienen este scope
+	HasOAuth       *bool       `json:"has_oauth,omitempty"`
+	HasOTP         *bool       `json:"has_otp,omitempty"`
+	CreatedAfter   *time.Time  `json:"created_after,omitempty"`
+	CreatedBefore  *time.Time  `json:"created_before,omitempty"`
+	IncludeDeleted bool        `json:"include_deleted,omitempty"` // Solo para recuperación administrativa
+	Limit          int         `json:"limit"`
+	Offset         int         `json:"offset"`
+
+	// Cursor retoma la búsqueda después de ese usuario. Los resultados se
+	// ordenan del más reciente al más antiguo (created_at, id); con cursor el
+	// total sigue contando todos los usuarios que cumplen el filtro.
+	Cursor *kernel.Cursor `json:"-"`
+
+	// SkipCount omite el conteo del total, que se retorna en 0. Lo usa
+	// ExportUsers, que recorre todas las páginas y no necesita el total.
+	SkipCount bool `json:"-"`
+}
+
 // ============================================================================
 // Scope Management DTOs
 // ============================================================================
@@ -359,13 +586,47 @@ type ApplyScopeTemplateRequest struct {
 	TemplateName string          `json:"template_name" validate:"required"`
 }
 
+// ScopeChangeOperation es el tipo de cambio de scopes a previsualizar
+type ScopeChangeOperation string
+
+const (
+	ScopeChangeAdd      ScopeChangeOperation = "add"
+	ScopeChangeRemove   ScopeChangeOperation = "remove"
+	ScopeChangeSet      ScopeChangeOperation = "set"
+	ScopeChangeTemplate ScopeChangeOperation = "template"
+)
+
+// PreviewScopeChangeRequest describe un cambio de scopes sin aplicarlo. Scopes
+// se usa con add, remove y set; TemplateName con template.
+type PreviewScopeChangeRequest struct {
+	Operation    ScopeChangeOperation `json:"operation" validate:"required,oneof=add remove set template"`
+	Scopes       []string             `json:"scopes,omitempty"`
+	TemplateName string               `json:"template_name,omitempty"`
+}
+
+// ScopeChangePreview muestra el resultado de un cambio de scopes
+type ScopeChangePreview struct {
+	UserID          kernel.UserID `json:"user_id"`
+	CurrentScopes   []string      `json:"current_scopes"`
+	ResultingScopes []string      `json:"resulting_scopes"`
+	Added           []ScopeDetail `json:"added"`
+	Removed         []ScopeDetail `json:"removed"`
+	Changed         bool          `json:"changed"`
+}
+
+// ValidateScopesRequest para validar una lista de scopes
+type ValidateScopesRequest struct {
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+}
+
 // UserScopesResponse respuesta con los scopes de un usuario
 type UserScopesResponse struct {
-	UserID       kernel.UserID `json:"user_id"`
-	Scopes       []string      `json:"scopes"`
-	ScopeDetails []ScopeDetail `json:"scope_details"`
-	TotalScopes  int           `json:"total_scopes"`
-	IsAdmin      bool          `json:"is_admin"`
+	UserID          kernel.UserID `json:"user_id"`
+	Scopes          []string      `json:"scopes"`
+	RequestedScopes []string      `json:"requested_scopes,omitempty"` // antes de normalizar, si la normalización los cambió
+	ScopeDetails    []ScopeDetail `json:"scope_details"`
+	TotalScopes     int           `json:"total_scopes"`
+	IsAdmin         bool          `json:"is_admin"`
 }
 
 // ScopeTemplateResponse respuesta con detalles de una plantilla
@@ -399,9 +660,14 @@ var (
 	CodeOnboardingRequired   = ErrRegistry.Register("ONBOARDING_REQUIRED", errx.TypeBusiness, http.StatusPreconditionRequired, "Onboarding required")
 	CodeInvalidStatus        = ErrRegistry.Register("INVALID_STATUS", errx.TypeBusiness, http.StatusBadRequest, "Invalid user status for this operation")
 	CodeInvalidScopeTemplate = ErrRegistry.Register("INVALID_SCOPE_TEMPLATE", errx.TypeValidation, http.StatusBadRequest, "Scope template not found")
+	CodeTooManyImportRows    = ErrRegistry.Register("TOO_MANY_IMPORT_ROWS", errx.TypeValidation, http.StatusBadRequest, "Too many users in a single import")
 	CodeInvalidScopes        = ErrRegistry.Register("INVALID_SCOPES", errx.TypeValidation, http.StatusBadRequest, "Invalid scopes")
 	CodeScopeNotFound        = ErrRegistry.Register("SCOPE_NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Scope not found")
 	CodeInsufficientScopes   = ErrRegistry.Register("INSUFFICIENT_SCOPES", errx.TypeAuthorization, http.StatusForbidden, "Insufficient scopes")
+	CodeInvalidPassword      = ErrRegistry.Register("INVALID_PASSWORD", errx.TypeValidation, http.StatusBadRequest, "Password does not meet the requirements")
+	CodeAuthMethodNotEnabled = ErrRegistry.Register("AUTH_METHOD_NOT_ENABLED", errx.TypeBusiness, http.StatusBadRequest, "Authentication method is not enabled")
+	CodeLastLoginMethod      = ErrRegistry.Register("LAST_LOGIN_METHOD", errx.TypeConflict, http.StatusConflict, "Cannot remove the last login method")
+	CodeInvalidErasureMode   = ErrRegistry.Register("INVALID_ERASURE_MODE", errx.TypeValidation, http.StatusBadRequest, "Invalid erasure mode")
 )
 
 // Helper functions
@@ -448,3 +714,23 @@ func ErrScopeNotFound() *errx.Error {
 func ErrInsufficientScopes() *errx.Error {
 	return ErrRegistry.New(CodeInsufficientScopes)
 }
+
+func ErrTooManyImportRows() *errx.Error {
+	return ErrRegistry.New(CodeTooManyImportRows)
+}
+
+func ErrInvalidPassword() *errx.Error {
+	return ErrRegistry.New(CodeInvalidPassword)
+}
+
+func ErrAuthMethodNotEnabled() *errx.Error {
+	return ErrRegistry.New(CodeAuthMethodNotEnabled)
+}
+
+func ErrLastLoginMethod() *errx.Error {
+	return ErrRegistry.New(CodeLastLoginMethod)
+}
+
+func ErrInvalidErasureMode() *errx.Error {
+	return ErrRegistry.New(CodeInvalidErasureMode)
+}
@@ -0,0 +1,178 @@
+package user
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+// reversePasswordService hashes by reversing the password, enough to tell the
+// hash apart from the plaintext
+type reversePasswordService struct{}
+
+func (reversePasswordService) HashPassword(password string) (string, error) {
+	runes := []rune(password)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return "rev:" + string(runes), nil
+}
+
+func (s reversePasswordService) VerifyPassword(hashedPassword, password string) bool {
+	hash, _ := s.HashPassword(password)
+	return hash == hashedPassword
+}
+
+func TestUserPassword(t *testing.T) {
+	svc := reversePasswordService{}
+	u := &User{Status: UserStatusActive, EmailVerified: true}
+
+	if u.HasPassword() || u.CheckPassword("", svc) {
+		t.Fatal("user without password accepts a password")
+	}
+
+	err := u.SetPassword("short", DefaultPasswordPolicy(), svc)
+	var e *errx.Error
+	if !errx.As(err, &e) || e.Code != CodeInvalidPassword.Code {
+		t.Fatalf("SetPassword(short) error = %v, want INVALID_PASSWORD", err)
+	}
+
+	if err := u.SetPassword("long enough", DefaultPasswordPolicy(), svc); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if strings.Contains(*u.PasswordHash, "long enough") {
+		t.Errorf("PasswordHash %q contains the plaintext", *u.PasswordHash)
+	}
+	if !u.CheckPassword("long enough", svc) {
+		t.Error("CheckPassword rejected the right password")
+	}
+	if u.CheckPassword("long enougH", svc) {
+		t.Error("CheckPassword accepted a wrong password")
+	}
+	if !u.CanLoginWithPassword() {
+		t.Error("CanLoginWithPassword = false for an active, verified user with a password")
+	}
+}
+
+func TestUnlinkOAuth(t *testing.T) {
+	u := &User{Status: UserStatusActive}
+
+	var e *errx.Error
+	if err := u.UnlinkOAuth(); !errx.As(err, &e) || e.Code != CodeAuthMethodNotEnabled.Code {
+		t.Fatalf("UnlinkOAuth without OAuth error = %v, want AUTH_METHOD_NOT_ENABLED", err)
+	}
+
+	u.LinkOAuth("GOOGLE", "google-123")
+	if err := u.UnlinkOAuth(); !errx.As(err, &e) || e.Code != CodeLastLoginMethod.Code {
+		t.Fatalf("UnlinkOAuth of the only method error = %v, want LAST_LOGIN_METHOD", err)
+	}
+	if !u.HasOAuth() {
+		t.Fatal("rejected UnlinkOAuth removed the provider")
+	}
+
+	u.EnableOTP()
+	if err := u.UnlinkOAuth(); err != nil {
+		t.Fatalf("UnlinkOAuth with OTP enabled: %v", err)
+	}
+	if methods := u.LoginMethods(); methods.OAuthProvider != "" || !methods.OTP || methods.Password {
+		t.Errorf("LoginMethods after unlink = %+v, want only OTP", methods)
+	}
+}
+
+func TestPasswordPolicyFailedRules(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, RequireUppercase: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"short", []string{PasswordRuleMinLength, PasswordRuleUppercase, PasswordRuleDigit, PasswordRuleSymbol}},
+		{"longenough1", []string{PasswordRuleUppercase, PasswordRuleSymbol}},
+		{"Long enough 1", []string{}},
+		{"Ñandú-añejo9", []string{}},
+		{"Aa1!" + strings.Repeat("x", MaxPasswordBytes-4), []string{}},
+		{"Aa1!" + strings.Repeat("x", MaxPasswordBytes-3), []string{PasswordRuleMaxLength}},
+		{"Aa1!" + strings.Repeat("ñ", MaxPasswordBytes/2-1), []string{PasswordRuleMaxLength}},
+	}
+
+	for _, tt := range tests {
+		if got := policy.FailedRules(tt.password); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("FailedRules(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	var e *errx.Error
+	err := policy.Validate("longenough1")
+	if !errx.As(err, &e) || e.Code != CodeInvalidPassword.Code {
+		t.Fatalf("Validate error = %v, want INVALID_PASSWORD", err)
+	}
+	if failed, _ := e.Details["failed_rules"].([]string); len(failed) != 2 {
+		t.Errorf("failed_rules detail = %v, want the 2 failed rules", e.Details["failed_rules"])
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	phone := "+14155550100"
+	picture := "https://cdn.example.com/ana.png"
+	u := &User{
+		ID:            "u1",
+		Email:         "ana@acme.com",
+		Name:          "Ana",
+		Picture:       &picture,
+		Phone:         &phone,
+		Status:        UserStatusActive,
+		Scopes:        []string{"users:read"},
+		EmailVerified: true,
+	}
+	u.LinkOAuth("GOOGLE", "google-123")
+	if err := u.SetPassword("long enough", DefaultPasswordPolicy(), reversePasswordService{}); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+
+	u.Anonymize()
+
+	if strings.Contains(u.Email, "ana") || u.Name == "Ana" || u.Picture != nil || u.Phone != nil {
+		t.Errorf("personal data left after Anonymize: %+v", u)
+	}
+	if u.HasPassword() || u.HasOAuth() || u.HasOTP() || len(u.Scopes) != 0 {
+		t.Errorf("login methods or scopes left after Anonymize: %+v", u.LoginMethods())
+	}
+	if !u.IsDeleted() || !u.IsErased() {
+		t.Errorf("IsDeleted = %v, IsErased = %v, want both true", u.IsDeleted(), u.IsErased())
+	}
+
+	var e *errx.Error
+	if err := u.Restore(); !errx.As(err, &e) || e.Code != CodeInvalidStatus.Code {
+		t.Fatalf("Restore of an erased user error = %v, want INVALID_STATUS", err)
+	}
+}
+
+func TestRequestedScopes(t *testing.T) {
+	u := &User{}
+
+	u.SetNormalizedScopes([]string{"users:*", "users:read"}, []string{"users:*"})
+	if !slices.Equal(u.Scopes, []string{"users:*"}) || !slices.Equal(u.RawScopes(), []string{"users:*", "users:read"}) {
+		t.Fatalf("scopes = %v raw = %v, want the normalized and the granted list", u.Scopes, u.RawScopes())
+	}
+
+	// An unchanged list keeps no copy
+	u.SetNormalizedScopes([]string{"users:read"}, []string{"users:read"})
+	if u.RequestedScopes != nil || !slices.Equal(u.RawScopes(), []string{"users:read"}) {
+		t.Errorf("requested = %v raw = %v, want no copy when normalization changed nothing", u.RequestedScopes, u.RawScopes())
+	}
+
+	// Setting scopes directly makes them the granted list
+	for name, change := range map[string]func(){
+		"set":    func() { u.SetScopes([]string{"roles:read"}) },
+		"add":    func() { u.AddScope("roles:write") },
+		"remove": func() { u.RemoveScope("users:*") },
+	} {
+		u.SetNormalizedScopes([]string{"users:*", "users:read"}, []string{"users:*"})
+		change()
+		if u.RequestedScopes != nil {
+			t.Errorf("%s kept requested scopes %v", name, u.RequestedScopes)
+		}
+	}
+}
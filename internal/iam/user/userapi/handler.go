@@ -0,0 +1,434 @@
+package userapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/usersrv"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+)
+
+type UserHandlers struct {
+	service *usersrv.UserService
+}
+
+func NewUserHandlers(service *usersrv.UserService) *UserHandlers {
+	return &UserHandlers{service: service}
+}
+
+func (h *UserHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	users := router.Group("/users", authMiddleware.Authenticate())
+
+	users.Get("/search", authMiddleware.RequireAdminOrScope(scopes.ScopeUsersRead), h.SearchUsers)
+	users.Get("/export", authMiddleware.RequireAdminOrScope(scopes.ScopeUsersExport), h.ExportUsers)
+	users.Post("/import", authMiddleware.RequireAdminOrScope(scopes.ScopeUsersInvite), h.ImportUsers)
+	users.Post("/:id/restore", authMiddleware.RequireAdminOrScope(scopes.ScopeUsersWrite), h.RestoreUser)
+	users.Post("/:id/erase", authMiddleware.RequireAdminOrScope(scopes.ScopeUsersDelete), h.EraseUser)
+	users.Post("/:id/scopes/preview", authMiddleware.RequireAdminOrScope(scopes.ScopeUsersWrite), h.PreviewScopeChange)
+	users.Post("/scopes/validate", h.ValidateScopes)
+}
+
+// SearchUsers lists the users of the caller's tenant with filters and cursor
+// pagination, newest first. The response is a kernel.Page; pass its
+// next_cursor back as ?cursor= to get the next page.
+//
+// Query params: status, email, scope, has_oauth, has_otp, created_after,
+// created_before (RFC3339), include_deleted, limit, cursor.
+func (h *UserHandlers) SearchUsers(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	filter, err := parseSearchFilter(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.service.SearchUsers(c.Context(), authContext.TenantID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// mimeTextCSV is the content type of the CSV export and import
+const mimeTextCSV = "text/csv"
+
+// exportFlushEvery is how many rows the export writes between flushes
+const exportFlushEvery = 100
+
+// exportColumns is the header of the CSV export and the field order of each row
+var exportColumns = []string{"email", "name", "status", "scopes", "oauth_provider", "otp_enabled", "last_login_at"}
+
+// exportRow is a user as written by the JSON export
+type exportRow struct {
+	Email         string     `json:"email"`
+	Name          string     `json:"name"`
+	Status        string     `json:"status"`
+	Scopes        []string   `json:"scopes"`
+	OAuthProvider string     `json:"oauth_provider"`
+	OTPEnabled    bool       `json:"otp_enabled"`
+	LastLoginAt   *time.Time `json:"last_login_at"`
+}
+
+// ExportUsers streams every user of the caller's tenant matching the same
+// filters as SearchUsers (limit and cursor are ignored). The format follows
+// the Accept header: text/csv (default) or application/json (a JSON array).
+// Rows are written as they are read so large tenants are never buffered.
+func (h *UserHandlers) ExportUsers(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	filter, err := parseSearchFilter(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.Accepts(mimeTextCSV, fiber.MIMEApplicationJSON)
+	if format == "" {
+		return fiber.ErrNotAcceptable
+	}
+	asJSON := format == fiber.MIMEApplicationJSON
+
+	extension := "csv"
+	if asJSON {
+		extension = "json"
+	}
+	c.Set(fiber.HeaderContentType, format+"; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="users.`+extension+`"`)
+
+	tenantID := authContext.TenantID
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The fiber context is released once the handler returns, so the
+		// export runs on its own context. A disconnected client shows up as a
+		// flush error and stops the iteration.
+		ctx := context.Background()
+		cw := csv.NewWriter(w)
+
+		if asJSON {
+			w.WriteString("[")
+		} else {
+			cw.Write(exportColumns)
+		}
+
+		rows := 0
+		err := h.service.ExportUsers(ctx, tenantID, filter, func(u *user.User) error {
+			if asJSON {
+				data, err := json.Marshal(toExportRow(u))
+				if err != nil {
+					return err
+				}
+				if rows > 0 {
+					w.WriteByte(',')
+				}
+				w.Write(data)
+			} else if err := cw.Write(csvExportRecord(u)); err != nil {
+				return err
+			}
+
+			rows++
+			if rows%exportFlushEvery == 0 {
+				cw.Flush()
+				return w.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			logx.Warnf("user export for tenant %s stopped after %d rows: %v", tenantID, rows, err)
+			return
+		}
+
+		if asJSON {
+			w.WriteString("]\n")
+		}
+		cw.Flush()
+		w.Flush()
+	})
+
+	return nil
+}
+
+func toExportRow(u *user.User) exportRow {
+	return exportRow{
+		Email:         u.Email,
+		Name:          u.Name,
+		Status:        string(u.Status),
+		Scopes:        u.Scopes,
+		OAuthProvider: string(u.OAuthProvider),
+		OTPEnabled:    u.OTPEnabled,
+		LastLoginAt:   u.LastLoginAt,
+	}
+}
+
+func csvExportRecord(u *user.User) []string {
+	lastLogin := ""
+	if u.LastLoginAt != nil {
+		lastLogin = u.LastLoginAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		csvSafe(u.Email),
+		csvSafe(u.Name),
+		string(u.Status),
+		csvSafe(strings.Join(u.Scopes, ";")),
+		csvSafe(string(u.OAuthProvider)),
+		strconv.FormatBool(u.OTPEnabled),
+		lastLogin,
+	}
+}
+
+// csvSafe prefixes a quote to cells that spreadsheets would run as a formula
+// (CSV injection): names and emails are chosen by users.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// RestoreUser recovers a soft-deleted user of the caller's tenant
+func (h *UserHandlers) RestoreUser(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	restored, err := h.service.RestoreUser(c.Context(), kernel.UserID(c.Params("id")), authContext.TenantID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(restored.ToDTO())
+}
+
+// EraseUser erases the personal data of a user of the caller's tenant (right
+// to be forgotten). The body is optional: { "mode": "ANONYMIZE" | "HARD_DELETE" },
+// anonymizing by default.
+func (h *UserHandlers) EraseUser(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req user.EraseUserRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errx.Validation("invalid request body")
+		}
+	}
+
+	if err := h.service.EraseUser(auth.AuditContext(c), kernel.UserID(c.Params("id")), authContext.TenantID, req.Mode); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"message": "User data erased successfully"})
+}
+
+// PreviewScopeChange returns the scopes a user would end up with after an
+// add, remove, set or template change, with the added and removed scopes
+// described, without saving anything
+func (h *UserHandlers) PreviewScopeChange(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req user.PreviewScopeChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("invalid request body")
+	}
+
+	preview, err := h.service.PreviewScopeChange(c.Context(), kernel.UserID(c.Params("id")), authContext.TenantID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(preview)
+}
+
+// ValidateScopes reports which scopes are invalid and suggests near-matches
+// for them. It always responds 200; "invalid" is empty when all are valid.
+func (h *UserHandlers) ValidateScopes(c *fiber.Ctx) error {
+	var req user.ValidateScopesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("invalid request body")
+	}
+	if len(req.Scopes) == 0 {
+		return errx.Validation("no scopes to validate")
+	}
+
+	return c.JSON(h.service.ValidateScopes(req.Scopes))
+}
+
+// ImportUsers invites a list of users to the caller's tenant and reports the
+// outcome per row. The body is JSON ({"users": [...]}) or, with
+// Content-Type text/csv, a CSV with an "email" header column and optional
+// "scopes" (space separated) and "scope_template" columns.
+func (h *UserHandlers) ImportUsers(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok || authContext.UserID == nil {
+		return iam.ErrUnauthorized()
+	}
+
+	var reqs []user.InviteUserRequest
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), mimeTextCSV) {
+		parsed, err := parseInviteCSV(c.Body())
+		if err != nil {
+			return err
+		}
+		reqs = parsed
+	} else {
+		var body user.BulkInviteRequest
+		if err := c.BodyParser(&body); err != nil {
+			return errx.Validation("invalid request body")
+		}
+		reqs = body.Users
+	}
+
+	if len(reqs) == 0 {
+		return errx.Validation("no users to import")
+	}
+
+	result, err := h.service.BulkInviteUsers(auth.AuditContext(c), authContext.TenantID, *authContext.UserID, reqs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
+
+// parseInviteCSV reads invite rows from a CSV whose first row is the header
+func parseInviteCSV(body []byte) ([]user.InviteUserRequest, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errx.Validation("invalid CSV: missing header row")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, errx.Validation("invalid CSV: email column is required")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var reqs []user.InviteUserRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errx.Validation("invalid CSV").WithDetail("reason", err.Error())
+		}
+
+		req := user.InviteUserRequest{
+			Email:  field(record, "email"),
+			Scopes: strings.Fields(field(record, "scopes")),
+		}
+		if template := field(record, "scope_template"); template != "" {
+			req.ScopeTemplate = &template
+		}
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
+
+func parseSearchFilter(c *fiber.Ctx) (user.UserSearchFilter, error) {
+	filter := user.UserSearchFilter{
+		Email: strings.TrimSpace(c.Query("email")),
+		Scope: strings.TrimSpace(c.Query("scope")),
+		Limit: c.QueryInt("limit", 0),
+	}
+
+	if raw := c.Query("status"); raw != "" {
+		status := user.UserStatus(strings.ToUpper(raw))
+		switch status {
+		case user.UserStatusActive, user.UserStatusInactive, user.UserStatusSuspended, user.UserStatusPending:
+			filter.Status = &status
+		default:
+			return filter, errx.Validation("invalid status").WithDetail("status", raw)
+		}
+	}
+
+	var err error
+	if filter.HasOAuth, err = parseOptionalBool(c, "has_oauth"); err != nil {
+		return filter, err
+	}
+	if filter.HasOTP, err = parseOptionalBool(c, "has_otp"); err != nil {
+		return filter, err
+	}
+	includeDeleted, err := parseOptionalBool(c, "include_deleted")
+	if err != nil {
+		return filter, err
+	}
+	filter.IncludeDeleted = includeDeleted != nil && *includeDeleted
+	if filter.CreatedAfter, err = parseOptionalTime(c, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseOptionalTime(c, "created_before"); err != nil {
+		return filter, err
+	}
+	if filter.Cursor, err = kernel.DecodeCursor(c.Query("cursor")); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func parseOptionalBool(c *fiber.Ctx, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, errx.Validation("invalid boolean query param").WithDetail(key, raw)
+	}
+	return &value, nil
+}
+
+func parseOptionalTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, errx.Validation("invalid date, expected RFC3339").WithDetail(key, raw)
+	}
+	return &value, nil
+}
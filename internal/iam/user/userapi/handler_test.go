@@ -0,0 +1,71 @@
+package userapi
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+)
+
+func TestParseInviteCSV(t *testing.T) {
+	body := "Email, Scopes ,scope_template\n" +
+		"a@acme.com,,viewer\n" +
+		" b@acme.com ,users:read reports:view,\n" +
+		"c@acme.com\n" +
+		",,\n"
+
+	reqs, err := parseInviteCSV([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 4 {
+		t.Fatalf("got %d rows, want 4", len(reqs))
+	}
+	if reqs[0].Email != "a@acme.com" || reqs[0].ScopeTemplate == nil || *reqs[0].ScopeTemplate != "viewer" || len(reqs[0].Scopes) != 0 {
+		t.Errorf("row 1 = %+v", reqs[0])
+	}
+	if reqs[1].Email != "b@acme.com" || !slices.Equal(reqs[1].Scopes, []string{"users:read", "reports:view"}) || reqs[1].ScopeTemplate != nil {
+		t.Errorf("row 2 = %+v", reqs[1])
+	}
+	// Short rows and empty emails are kept, so the service reports them by row
+	if reqs[2].Email != "c@acme.com" || reqs[3].Email != "" {
+		t.Errorf("rows 3 and 4 = %+v, %+v", reqs[2], reqs[3])
+	}
+
+	for name, body := range map[string]string{
+		"empty":        "",
+		"no email":     "name,scopes\nAna,users:read\n",
+		"broken quote": "email\n\"a@acme.com\n",
+	} {
+		_, err := parseInviteCSV([]byte(body))
+		if e, ok := errx.AsError(err); !ok || e.Type != errx.TypeValidation {
+			t.Errorf("%s: error = %v, want a validation error", name, err)
+		}
+	}
+}
+
+func TestCSVExportRecordEscapesFormulas(t *testing.T) {
+	record := csvExportRecord(&user.User{
+		Email:  "@SUM(1+1)@acme.com",
+		Name:   "=HYPERLINK(\"https://evil.example\")",
+		Status: user.UserStatusActive,
+		Scopes: []string{"-2+3", "users:read"},
+	})
+
+	want := []string{"'@SUM(1+1)@acme.com", "'=HYPERLINK(\"https://evil.example\")", "ACTIVE", "'-2+3;users:read", "", "false", ""}
+	if !slices.Equal(record, want) {
+		t.Errorf("record = %q, want %q", record, want)
+	}
+
+	for _, cell := range []string{"+1", "\tcmd", "\rcmd"} {
+		if got := csvSafe(cell); got != "'"+cell {
+			t.Errorf("csvSafe(%q) = %q", cell, got)
+		}
+	}
+	for _, cell := range []string{"", "ana@acme.com", "Ana-María"} {
+		if got := csvSafe(cell); got != cell {
+			t.Errorf("csvSafe(%q) = %q, want it unchanged", cell, got)
+		}
+	}
+}
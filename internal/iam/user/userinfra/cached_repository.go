@@ -0,0 +1,117 @@
+package userinfra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/redis/go-redis/v9"
+)
+
+// userCacheKeyPrefix lleva versión: las entradas v1 incluían el hash de la
+// contraseña y no deben leerse como usuarios sin contraseña
+const userCacheKeyPrefix = "iam:cache:user:v2:"
+
+// CachedUserRepository decora un UserRepository con una caché de lectura en
+// Redis para FindByID. Toda escritura que pasa por el decorador invalida la
+// entrada cuando la transacción del contexto confirma (Save cubre cambios de
+// scopes, estado y datos); el TTL acota lo desactualizado si una escritura se
+// hace por fuera.
+//
+// La caché no guarda credenciales: User no serializa el hash de la
+// contraseña, así que los usuarios con contraseña no se cachean (un usuario
+// leído sin hash y guardado después lo perdería).
+// Los errores de Redis no fallan la operación: se lee de la base de datos.
+type CachedUserRepository struct {
+	user.UserRepository
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewCachedUserRepository envuelve repo con la caché
+func NewCachedUserRepository(repo user.UserRepository, client *redis.Client, ttl time.Duration) user.UserRepository {
+	return &CachedUserRepository{
+		UserRepository: repo,
+		client:         client,
+		ttl:            ttl,
+	}
+}
+
+// FindByID busca el usuario en la caché y si no está lo carga y lo guarda
+func (r *CachedUserRepository) FindByID(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) (*user.User, error) {
+	key := userCacheKey(id, tenantID)
+
+	data, err := r.client.Get(ctx, key).Bytes()
+	switch {
+	case err == nil:
+		var cached user.User
+		if err := json.Unmarshal(data, &cached); err == nil {
+			return &cached, nil
+		}
+	case !errors.Is(err, redis.Nil):
+		logx.Warnf("user cache read failed for %s: %v", id, err)
+	}
+
+	u, err := r.UserRepository.FindByID(ctx, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.HasPassword() {
+		return u, nil
+	}
+	if data, err := json.Marshal(u); err == nil {
+		if err := r.client.Set(ctx, key, data, r.ttl).Err(); err != nil {
+			logx.Warnf("user cache write failed for %s: %v", id, err)
+		}
+	}
+
+	return u, nil
+}
+
+// Save guarda el usuario e invalida su entrada
+func (r *CachedUserRepository) Save(ctx context.Context, u user.User) error {
+	if err := r.UserRepository.Save(ctx, u); err != nil {
+		return err
+	}
+	r.invalidate(ctx, u.ID, u.TenantID)
+	return nil
+}
+
+// Delete elimina lógicamente el usuario e invalida su entrada
+func (r *CachedUserRepository) Delete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error {
+	if err := r.UserRepository.Delete(ctx, id, tenantID); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id, tenantID)
+	return nil
+}
+
+// HardDelete elimina el usuario e invalida su entrada
+func (r *CachedUserRepository) HardDelete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error {
+	if err := r.UserRepository.HardDelete(ctx, id, tenantID); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id, tenantID)
+	return nil
+}
+
+// invalidate elimina la entrada del usuario cuando la transacción del
+// contexto confirma, para que una lectura concurrente no vuelva a cachear la
+// fila anterior. Si falla, la entrada expira con el TTL.
+func (r *CachedUserRepository) invalidate(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) {
+	dbx.AfterCommit(ctx, func(ctx context.Context) {
+		if err := r.client.Del(ctx, userCacheKey(id, tenantID)).Err(); err != nil {
+			logx.Warnf("user cache invalidation failed for %s: %v", id, err)
+		}
+	})
+}
+
+func userCacheKey(id kernel.UserID, tenantID kernel.TenantID) string {
+	return userCacheKeyPrefix + tenantID.String() + ":" + id.String()
+}
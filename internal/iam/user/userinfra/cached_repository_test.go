@@ -0,0 +1,101 @@
+package userinfra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/testx"
+)
+
+func TestCachedUserRepositoryNeverCachesPasswords(t *testing.T) {
+	client := testx.Redis(t)
+	ctx := context.Background()
+	inner := NewInMemoryUserRepository()
+	hash := "$2a$10$hash"
+	for _, u := range []user.User{
+		{ID: "u1", TenantID: "t1", Email: "ana@acme.com", Status: user.UserStatusActive, Scopes: []string{"users:read"}},
+		{ID: "u2", TenantID: "t1", Email: "luis@acme.com", Status: user.UserStatusActive, PasswordHash: &hash},
+	} {
+		if err := inner.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := NewCachedUserRepository(inner, client, time.Minute)
+
+	if _, err := repo.FindByID(ctx, "u1", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := repo.FindByID(ctx, "u1", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Email != "ana@acme.com" || len(cached.Scopes) != 1 {
+		t.Errorf("cached user = %+v", cached)
+	}
+
+	// Users with a password are read from the store every time, so saving
+	// one never drops the hash
+	withPassword, err := repo.FindByID(ctx, "u2", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !withPassword.HasPassword() {
+		t.Fatal("password hash lost")
+	}
+	if n := client.Exists(ctx, userCacheKey("u2", "t1")).Val(); n != 0 {
+		t.Errorf("user with a password was cached")
+	}
+}
+
+func TestCachedUserRepositoryInvalidatesAfterCommit(t *testing.T) {
+	client := testx.Redis(t)
+	db := testx.Postgres(t)
+	ctx := context.Background()
+	inner := NewInMemoryUserRepository()
+	u := user.User{ID: "u1", TenantID: "t1", Email: "ana@acme.com", Status: user.UserStatusActive}
+	if err := inner.Save(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewCachedUserRepository(inner, client, time.Minute)
+	key := userCacheKey("u1", "t1")
+
+	if _, err := repo.FindByID(ctx, "u1", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	err := dbx.WithTx(ctx, db, func(ctx context.Context) error {
+		u.Status = user.UserStatusSuspended
+		if err := repo.Save(ctx, u); err != nil {
+			return err
+		}
+		// Until the commit other readers still see the old row
+		if client.Exists(ctx, key).Val() != 1 {
+			t.Error("entry dropped before the commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Exists(ctx, key).Val() != 0 {
+		t.Fatal("entry kept after the commit")
+	}
+
+	suspended, err := repo.FindByID(ctx, "u1", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suspended.Status != user.UserStatusSuspended {
+		t.Errorf("status = %s, want SUSPENDED", suspended.Status)
+	}
+
+	// Outside a transaction the entry is dropped right away
+	if err := repo.Delete(ctx, "u1", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if client.Exists(ctx, key).Val() != 0 {
+		t.Error("entry kept after Delete")
+	}
+}
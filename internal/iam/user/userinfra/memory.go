@@ -0,0 +1,349 @@
+package userinfra
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// InMemoryUserRepository implementación en memoria de UserRepository.
+// Pensada para tests y desarrollo local: replica la semántica del repositorio
+// de PostgreSQL (soft delete, unicidad de email y teléfono por tenant entre
+// usuarios no eliminados y los mismos errores tipados).
+type InMemoryUserRepository struct {
+	users map[memoryUserKey]*user.User
+	mu    sync.RWMutex
+}
+
+// memoryUserKey identifica un usuario igual que la clave (id, tenant_id)
+type memoryUserKey struct {
+	id       kernel.UserID
+	tenantID kernel.TenantID
+}
+
+// NewInMemoryUserRepository crea un nuevo repositorio de usuarios en memoria
+func NewInMemoryUserRepository() *InMemoryUserRepository {
+	return &InMemoryUserRepository{
+		users: make(map[memoryUserKey]*user.User),
+	}
+}
+
+// FindByID busca un usuario por ID y tenant
+func (r *InMemoryUserRepository) FindByID(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[memoryUserKey{id: id, tenantID: tenantID}]
+	if !ok || u.DeletedAt != nil {
+		return nil, user.ErrUserNotFound().WithDetail("user_id", id.String())
+	}
+	return copyUser(u), nil
+}
+
+// FindByIDIncludeDeleted busca un usuario por ID incluyendo los eliminados lógicamente
+func (r *InMemoryUserRepository) FindByIDIncludeDeleted(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[memoryUserKey{id: id, tenantID: tenantID}]
+	if !ok {
+		return nil, user.ErrUserNotFound().WithDetail("user_id", id.String())
+	}
+	return copyUser(u), nil
+}
+
+// FindByEmail busca un usuario por email y tenant
+func (r *InMemoryUserRepository) FindByEmail(ctx context.Context, email string, tenantID kernel.TenantID) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.TenantID == tenantID && u.Email == email && u.DeletedAt == nil {
+			return copyUser(u), nil
+		}
+	}
+	return nil, user.ErrUserNotFound().WithDetail("email", email)
+}
+
+// FindByPhone busca un usuario por teléfono (E.164) y tenant
+func (r *InMemoryUserRepository) FindByPhone(ctx context.Context, phone string, tenantID kernel.TenantID) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.TenantID == tenantID && u.Phone != nil && *u.Phone == phone && u.DeletedAt == nil {
+			return copyUser(u), nil
+		}
+	}
+	return nil, user.ErrUserNotFound().WithDetail("phone", phone)
+}
+
+// FindByEmailAcrossTenants busca los usuarios con este email en todos los tenants
+func (r *InMemoryUserRepository) FindByEmailAcrossTenants(ctx context.Context, email string) ([]*user.User, error) {
+	result := r.filter(func(u *user.User) bool {
+		return u.Email == email && u.DeletedAt == nil
+	})
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// FindByPhoneAcrossTenants busca los usuarios con este teléfono en todos los tenants
+func (r *InMemoryUserRepository) FindByPhoneAcrossTenants(ctx context.Context, phone string) ([]*user.User, error) {
+	return r.filter(func(u *user.User) bool {
+		return u.Phone != nil && *u.Phone == phone && u.DeletedAt == nil
+	}), nil
+}
+
+// FindByTenant busca todos los usuarios de un tenant
+func (r *InMemoryUserRepository) FindByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*user.User, error) {
+	result := r.filter(func(u *user.User) bool {
+		return u.TenantID == tenantID && u.DeletedAt == nil
+	})
+
+	sortByName(result)
+	return result, nil
+}
+
+// Search busca usuarios de un tenant aplicando filtros y paginación.
+// Retorna la página solicitada y el total de usuarios que cumplen el filtro.
+func (r *InMemoryUserRepository) Search(ctx context.Context, tenantID kernel.TenantID, filter user.UserSearchFilter) ([]*user.User, int, error) {
+	matches := r.filter(func(u *user.User) bool {
+		return u.TenantID == tenantID && matchesSearch(u, filter)
+	})
+
+	sortNewestFirst(matches)
+	total := len(matches)
+	if filter.SkipCount {
+		total = 0
+	}
+
+	if filter.Cursor != nil {
+		matches = slices.DeleteFunc(matches, func(u *user.User) bool {
+			return !isBefore(u, *filter.Cursor)
+		})
+	}
+
+	start := min(max(filter.Offset, 0), len(matches))
+	end := min(start+max(filter.Limit, 0), len(matches))
+	return matches[start:end], total, nil
+}
+
+// sortNewestFirst ordena como ORDER BY created_at DESC, id DESC
+func sortNewestFirst(users []*user.User) {
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+}
+
+// isBefore evalúa (created_at, id) < (cursor.CreatedAt, cursor.ID)
+func isBefore(u *user.User, cursor kernel.Cursor) bool {
+	if !u.CreatedAt.Equal(cursor.CreatedAt) {
+		return u.CreatedAt.Before(cursor.CreatedAt)
+	}
+	return u.ID.String() < cursor.ID
+}
+
+// matchesSearch evalúa el filtro de búsqueda igual que el WHERE de PostgreSQL
+func matchesSearch(u *user.User, filter user.UserSearchFilter) bool {
+	if !filter.IncludeDeleted && u.DeletedAt != nil {
+		return false
+	}
+	if filter.Status != nil && u.Status != *filter.Status {
+		return false
+	}
+	if filter.Email != "" && !strings.Contains(strings.ToLower(u.Email), strings.ToLower(filter.Email)) {
+		return false
+	}
+	if filter.Scope != "" && !slices.Contains(u.Scopes, filter.Scope) {
+		return false
+	}
+	if filter.HasOAuth != nil {
+		hasOAuth := u.OAuthProvider != "" && u.OAuthProviderID != ""
+		if hasOAuth != *filter.HasOAuth {
+			return false
+		}
+	}
+	if filter.HasOTP != nil && u.OTPEnabled != *filter.HasOTP {
+		return false
+	}
+	if filter.CreatedAfter != nil && u.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && u.CreatedAt.After(*filter.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// Save guarda o actualiza un usuario
+func (r *InMemoryUserRepository) Save(ctx context.Context, u user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Los índices únicos de PostgreSQL solo aplican a usuarios no eliminados
+	if u.DeletedAt == nil {
+		for key, existing := range r.users {
+			if key.id == u.ID || existing.TenantID != u.TenantID || existing.DeletedAt != nil {
+				continue
+			}
+			if existing.Email == u.Email {
+				return user.ErrUserAlreadyExists().
+					WithDetail("email", u.Email).
+					WithDetail("tenant_id", u.TenantID.String())
+			}
+			if u.Phone != nil && existing.Phone != nil && *existing.Phone == *u.Phone {
+				return user.ErrUserAlreadyExists().
+					WithDetail("phone", u.Phone).
+					WithDetail("tenant_id", u.TenantID.String())
+			}
+		}
+	}
+
+	r.users[memoryUserKey{id: u.ID, tenantID: u.TenantID}] = copyUser(&u)
+	return nil
+}
+
+// Delete marca un usuario como eliminado (soft delete)
+func (r *InMemoryUserRepository) Delete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[memoryUserKey{id: id, tenantID: tenantID}]
+	if !ok || u.DeletedAt != nil {
+		return user.ErrUserNotFound().WithDetail("user_id", id.String())
+	}
+
+	now := time.Now()
+	u.DeletedAt = &now
+	u.Status = user.UserStatusDeleted
+	u.UpdatedAt = now
+	return nil
+}
+
+// HardDelete elimina físicamente un usuario, incluso si ya fue eliminado lógicamente
+func (r *InMemoryUserRepository) HardDelete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryUserKey{id: id, tenantID: tenantID}
+	if _, ok := r.users[key]; !ok {
+		return user.ErrUserNotFound().WithDetail("user_id", id.String())
+	}
+
+	delete(r.users, key)
+	return nil
+}
+
+// ExistsByEmail verifica si existe un usuario con el email dado en el tenant
+func (r *InMemoryUserRepository) ExistsByEmail(ctx context.Context, email string, tenantID kernel.TenantID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.TenantID == tenantID && u.Email == email && u.DeletedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FindByStatus busca usuarios por estado
+func (r *InMemoryUserRepository) FindByStatus(ctx context.Context, status user.UserStatus, tenantID kernel.TenantID) ([]*user.User, error) {
+	result := r.filter(func(u *user.User) bool {
+		return u.TenantID == tenantID && u.Status == status && u.DeletedAt == nil
+	})
+
+	sortByName(result)
+	return result, nil
+}
+
+// FindActiveUsers busca usuarios activos
+func (r *InMemoryUserRepository) FindActiveUsers(ctx context.Context, tenantID kernel.TenantID) ([]*user.User, error) {
+	return r.FindByStatus(ctx, user.UserStatusActive, tenantID)
+}
+
+// CountByTenant cuenta los usuarios de un tenant
+func (r *InMemoryUserRepository) CountByTenant(ctx context.Context, tenantID kernel.TenantID) (int, error) {
+	users := r.filter(func(u *user.User) bool {
+		return u.TenantID == tenantID && u.DeletedAt == nil
+	})
+	return len(users), nil
+}
+
+// FindByOAuthProvider busca un usuario por proveedor OAuth y ID
+func (r *InMemoryUserRepository) FindByOAuthProvider(ctx context.Context, provider string, providerID string, tenantID kernel.TenantID) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.TenantID == tenantID && string(u.OAuthProvider) == provider &&
+			u.OAuthProviderID == providerID && u.DeletedAt == nil {
+			return copyUser(u), nil
+		}
+	}
+	return nil, user.ErrUserNotFound().
+		WithDetail("oauth_provider", provider).
+		WithDetail("oauth_provider_id", providerID)
+}
+
+// filter retorna copias de los usuarios que cumplen la condición
+func (r *InMemoryUserRepository) filter(keep func(u *user.User) bool) []*user.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*user.User, 0)
+	for _, u := range r.users {
+		if keep(u) {
+			result = append(result, copyUser(u))
+		}
+	}
+	return result
+}
+
+// sortByName ordena por nombre y luego por ID, como ORDER BY name ASC, id ASC
+func sortByName(users []*user.User) {
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Name != users[j].Name {
+			return users[i].Name < users[j].Name
+		}
+		return users[i].ID < users[j].ID
+	})
+}
+
+// copyUser copia un usuario para que los llamadores no modifiquen el estado
+// almacenado sin pasar por Save
+func copyUser(u *user.User) *user.User {
+	c := *u
+	c.Scopes = slices.Clone(u.Scopes)
+	c.RequestedScopes = slices.Clone(u.RequestedScopes)
+	c.Picture = copyPtr(u.Picture)
+	c.Phone = copyPtr(u.Phone)
+	c.PasswordHash = copyPtr(u.PasswordHash)
+	c.LastLoginAt = copyPtr(u.LastLoginAt)
+	c.DeletedAt = copyPtr(u.DeletedAt)
+	return &c
+}
+
+// copyPtr copia el valor apuntado, conservando nil
+func copyPtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// Verificación en compilación de que implementa la interfaz
+var _ user.UserRepository = (*InMemoryUserRepository)(nil)
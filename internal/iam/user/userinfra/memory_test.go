@@ -0,0 +1,149 @@
+package userinfra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+func newTestUser(id, tenantID, email, name string) user.User {
+	now := time.Now()
+	return user.User{
+		ID:        kernel.UserID(id),
+		TenantID:  kernel.TenantID(tenantID),
+		Email:     email,
+		Name:      name,
+		Status:    user.UserStatusActive,
+		Scopes:    []string{"users:read"},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func hasCode(err error, want *errx.Error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == want.Code
+}
+
+func TestInMemoryUserRepositoryUniqueEmailPerTenant(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryUserRepository()
+
+	if err := repo.Save(ctx, newTestUser("u1", "t1", "a@acme.com", "Ana")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	err := repo.Save(ctx, newTestUser("u2", "t1", "a@acme.com", "Otra"))
+	if !hasCode(err, user.ErrUserAlreadyExists()) {
+		t.Fatalf("duplicate email in tenant: got %v, want ErrUserAlreadyExists", err)
+	}
+
+	if err := repo.Save(ctx, newTestUser("u3", "t2", "a@acme.com", "Ana")); err != nil {
+		t.Fatalf("same email in another tenant: %v", err)
+	}
+
+	// Tras el soft delete el email queda libre, igual que el índice parcial
+	if err := repo.Delete(ctx, "u1", "t1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Save(ctx, newTestUser("u4", "t1", "a@acme.com", "Ana")); err != nil {
+		t.Fatalf("email reuse after soft delete: %v", err)
+	}
+}
+
+func TestInMemoryUserRepositorySoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryUserRepository()
+
+	if err := repo.Save(ctx, newTestUser("u1", "t1", "a@acme.com", "Ana")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := repo.Delete(ctx, "u1", "t1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := repo.FindByID(ctx, "u1", "t1"); !hasCode(err, user.ErrUserNotFound()) {
+		t.Errorf("FindByID after delete: got %v, want ErrUserNotFound", err)
+	}
+	if err := repo.Delete(ctx, "u1", "t1"); !hasCode(err, user.ErrUserNotFound()) {
+		t.Errorf("second Delete: got %v, want ErrUserNotFound", err)
+	}
+
+	deleted, err := repo.FindByIDIncludeDeleted(ctx, "u1", "t1")
+	if err != nil {
+		t.Fatalf("FindByIDIncludeDeleted: %v", err)
+	}
+	if deleted.Status != user.UserStatusDeleted || deleted.DeletedAt == nil {
+		t.Errorf("deleted user = status %s, deleted_at %v", deleted.Status, deleted.DeletedAt)
+	}
+
+	if err := repo.HardDelete(ctx, "u1", "t1"); err != nil {
+		t.Fatalf("HardDelete: %v", err)
+	}
+	if _, err := repo.FindByIDIncludeDeleted(ctx, "u1", "t1"); !hasCode(err, user.ErrUserNotFound()) {
+		t.Errorf("FindByIDIncludeDeleted after hard delete: got %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestInMemoryUserRepositorySearch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryUserRepository()
+
+	base := time.Now().Add(-time.Hour)
+	for i, u := range []user.User{
+		newTestUser("u1", "t1", "carla@acme.com", "Carla"),
+		newTestUser("u2", "t1", "ana@acme.com", "Ana"),
+		newTestUser("u3", "t1", "beto@other.com", "Beto"),
+		newTestUser("u4", "t2", "dora@acme.com", "Dora"),
+	} {
+		u.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Save(ctx, u); err != nil {
+			t.Fatalf("Save %s: %v", u.ID, err)
+		}
+	}
+
+	page, total, err := repo.Search(ctx, "t1", user.UserSearchFilter{Email: "ACME", Limit: 1})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("total = %d, want 2", total)
+	}
+	if len(page) != 1 || page[0].ID != "u2" {
+		t.Fatalf("page = %v, want [u2]", page)
+	}
+
+	cursor := page[0].PageCursor()
+	page, total, err = repo.Search(ctx, "t1", user.UserSearchFilter{Email: "ACME", Limit: 1, Cursor: &cursor})
+	if err != nil {
+		t.Fatalf("Search after cursor: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("total after cursor = %d, want 2", total)
+	}
+	if len(page) != 1 || page[0].ID != "u1" {
+		t.Errorf("page after cursor = %v, want [u1]", page)
+	}
+}
+
+func TestInMemoryUserRepositoryReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryUserRepository()
+
+	if err := repo.Save(ctx, newTestUser("u1", "t1", "a@acme.com", "Ana")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	found, _ := repo.FindByID(ctx, "u1", "t1")
+	found.Name = "Changed"
+	found.Scopes[0] = "*"
+
+	again, _ := repo.FindByID(ctx, "u1", "t1")
+	if again.Name != "Ana" || again.Scopes[0] != "users:read" {
+		t.Errorf("stored user was modified without Save: %+v", again)
+	}
+}
@@ -3,8 +3,11 @@ package userinfra
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
 	"github.com/Abraxas-365/manifesto/internal/iam"
 	"github.com/Abraxas-365/manifesto/internal/iam/user"
@@ -34,13 +37,17 @@ type userDB struct {
 	Picture         *string        `db:"picture"`
 	Status          string         `db:"status"`
 	Scopes          pq.StringArray `db:"scopes"`
+	RequestedScopes pq.StringArray `db:"requested_scopes"`
 	OAuthProvider   string         `db:"oauth_provider"`
 	OAuthProviderID string         `db:"oauth_provider_id"`
 	EmailVerified   bool           `db:"email_verified"`
 	OTPEnabled      bool           `db:"otp_enabled"`
+	Phone           *string        `db:"phone"`
+	PasswordHash    *string        `db:"password_hash"`
 	LastLoginAt     sql.NullTime   `db:"last_login_at"` // ✅ NOT a pointer
 	CreatedAt       time.Time      `db:"created_at"`    // ✅ Use time.Time directly
 	UpdatedAt       time.Time      `db:"updated_at"`    // ✅ Use time.Time directly
+	DeletedAt       sql.NullTime   `db:"deleted_at"`
 }
 
 // toDomain converts database model to domain model
@@ -53,10 +60,13 @@ func (db *userDB) toDomain() (*user.User, error) {
 		Picture:         db.Picture,
 		Status:          user.UserStatus(db.Status),
 		Scopes:          []string(db.Scopes),
+		RequestedScopes: []string(db.RequestedScopes),
 		OAuthProvider:   iam.OAuthProvider(db.OAuthProvider),
 		OAuthProviderID: db.OAuthProviderID,
 		EmailVerified:   db.EmailVerified,
 		OTPEnabled:      db.OTPEnabled,
+		Phone:           db.Phone,
+		PasswordHash:    db.PasswordHash,
 		CreatedAt:       db.CreatedAt,
 		UpdatedAt:       db.UpdatedAt,
 	}
@@ -65,6 +75,10 @@ func (db *userDB) toDomain() (*user.User, error) {
 		u.LastLoginAt = &db.LastLoginAt.Time
 	}
 
+	if db.DeletedAt.Valid {
+		u.DeletedAt = &db.DeletedAt.Time
+	}
+
 	return u, nil
 }
 
@@ -78,10 +92,13 @@ func fromDomain(u *user.User) *userDB {
 		Picture:         u.Picture,
 		Status:          string(u.Status),
 		Scopes:          pq.StringArray(u.Scopes),
+		RequestedScopes: pq.StringArray(u.RequestedScopes),
 		OAuthProvider:   string(u.OAuthProvider),
 		OAuthProviderID: u.OAuthProviderID,
 		EmailVerified:   u.EmailVerified,
 		OTPEnabled:      u.OTPEnabled,
+		Phone:           u.Phone,
+		PasswordHash:    u.PasswordHash,
 		CreatedAt:       u.CreatedAt,
 		UpdatedAt:       u.UpdatedAt,
 	}
@@ -90,6 +107,10 @@ func fromDomain(u *user.User) *userDB {
 		db.LastLoginAt = sql.NullTime{Time: *u.LastLoginAt, Valid: true}
 	}
 
+	if u.DeletedAt != nil {
+		db.DeletedAt = sql.NullTime{Time: *u.DeletedAt, Valid: true}
+	}
+
 	return db
 }
 
@@ -97,14 +118,14 @@ func fromDomain(u *user.User) *userDB {
 func (r *PostgresUserRepository) FindByID(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) (*user.User, error) {
 	query := `
 		SELECT
-			id, tenant_id, email, name, picture, status, scopes,
-			oauth_provider, oauth_provider_id, email_verified, otp_enabled,
-			last_login_at, created_at, updated_at
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE id = $1 AND tenant_id = $2`
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
 
 	var dbUser userDB
-	err := r.db.GetContext(ctx, &dbUser, query, id.String(), tenantID.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &dbUser, query, id.String(), tenantID.String())
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, user.ErrUserNotFound().WithDetail("user_id", id.String())
@@ -121,14 +142,14 @@ func (r *PostgresUserRepository) FindByID(ctx context.Context, id kernel.UserID,
 func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string, tenantID kernel.TenantID) (*user.User, error) {
 	query := `
 		SELECT
-			id, tenant_id, email, name, picture, status, scopes,
-			oauth_provider, oauth_provider_id, email_verified, otp_enabled,
-			last_login_at, created_at, updated_at
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE email = $1 AND tenant_id = $2`
+		WHERE email = $1 AND tenant_id = $2 AND deleted_at IS NULL`
 
 	var dbUser userDB
-	err := r.db.GetContext(ctx, &dbUser, query, email, tenantID.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &dbUser, query, email, tenantID.String())
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, user.ErrUserNotFound().WithDetail("email", email)
@@ -141,19 +162,43 @@ func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string,
 	return dbUser.toDomain()
 }
 
+// FindByPhone busca un usuario por teléfono (E.164) y tenant
+func (r *PostgresUserRepository) FindByPhone(ctx context.Context, phone string, tenantID kernel.TenantID) (*user.User, error) {
+	query := `
+		SELECT
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
+		FROM users
+		WHERE phone = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+
+	var dbUser userDB
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &dbUser, query, phone, tenantID.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, user.ErrUserNotFound().WithDetail("phone", phone)
+		}
+		return nil, errx.Wrap(err, "failed to find user by phone", errx.TypeInternal).
+			WithDetail("phone", phone).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	return dbUser.toDomain()
+}
+
 // FindByEmailAcrossTenants finds all users with this email across all tenants
 func (r *PostgresUserRepository) FindByEmailAcrossTenants(ctx context.Context, email string) ([]*user.User, error) {
 	query := `
 		SELECT
-			id, tenant_id, email, name, picture, status, scopes,
-			oauth_provider, oauth_provider_id, email_verified, otp_enabled,
-			last_login_at, created_at, updated_at
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE email = $1
+		WHERE email = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC`
 
 	var dbUsers []userDB
-	err := r.db.SelectContext(ctx, &dbUsers, query, email)
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &dbUsers, query, email)
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to find users by email across tenants", errx.TypeInternal).
 			WithDetail("email", email)
@@ -172,19 +217,48 @@ func (r *PostgresUserRepository) FindByEmailAcrossTenants(ctx context.Context, e
 	return result, nil
 }
 
+// FindByPhoneAcrossTenants busca los usuarios con este teléfono en todos los tenants
+func (r *PostgresUserRepository) FindByPhoneAcrossTenants(ctx context.Context, phone string) ([]*user.User, error) {
+	query := `
+		SELECT
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
+		FROM users
+		WHERE phone = $1 AND deleted_at IS NULL`
+
+	var dbUsers []userDB
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &dbUsers, query, phone)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to find users by phone across tenants", errx.TypeInternal).
+			WithDetail("phone", phone)
+	}
+
+	result := make([]*user.User, len(dbUsers))
+	for i := range dbUsers {
+		domainUser, err := dbUsers[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = domainUser
+	}
+
+	return result, nil
+}
+
 // FindByTenant busca todos los usuarios de un tenant
 func (r *PostgresUserRepository) FindByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*user.User, error) {
 	query := `
 		SELECT
-			id, tenant_id, email, name, picture, status, scopes,
-			oauth_provider, oauth_provider_id, email_verified, otp_enabled,
-			last_login_at, created_at, updated_at
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE tenant_id = $1
+		WHERE tenant_id = $1 AND deleted_at IS NULL
 		ORDER BY name ASC`
 
 	var dbUsers []userDB
-	err := r.db.SelectContext(ctx, &dbUsers, query, tenantID.String())
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &dbUsers, query, tenantID.String())
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to find users by tenant", errx.TypeInternal).
 			WithDetail("tenant_id", tenantID.String())
@@ -203,6 +277,109 @@ func (r *PostgresUserRepository) FindByTenant(ctx context.Context, tenantID kern
 	return result, nil
 }
 
+// Search busca usuarios de un tenant aplicando filtros y paginación.
+// Retorna la página solicitada y el total de usuarios que cumplen el filtro.
+func (r *PostgresUserRepository) Search(ctx context.Context, tenantID kernel.TenantID, filter user.UserSearchFilter) ([]*user.User, int, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []any{tenantID.String()}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.Status != nil {
+		addCondition("status = $%d", string(*filter.Status))
+	}
+	if filter.Email != "" {
+		addCondition("email ILIKE $%d", "%"+escapeLike(filter.Email)+"%")
+	}
+	if filter.Scope != "" {
+		addCondition("$%d = ANY(scopes)", filter.Scope)
+	}
+	if filter.HasOAuth != nil {
+		if *filter.HasOAuth {
+			conditions = append(conditions, "(oauth_provider <> '' AND oauth_provider_id <> '')")
+		} else {
+			conditions = append(conditions, "(oauth_provider = '' OR oauth_provider_id = '')")
+		}
+	}
+	if filter.HasOTP != nil {
+		addCondition("otp_enabled = $%d", *filter.HasOTP)
+	}
+	if filter.CreatedAfter != nil {
+		addCondition("created_at >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		addCondition("created_at <= $%d", *filter.CreatedBefore)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	// El conteo usa el mismo WHERE que la consulta de datos, sin el cursor:
+	// el total es el de todas las páginas
+	var total int
+	if !filter.SkipCount {
+		countQuery := `SELECT COUNT(*) FROM users WHERE ` + where
+		if err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &total, countQuery, args...); err != nil {
+			return nil, 0, errx.Wrap(err, "failed to count users", errx.TypeInternal).
+				WithDetail("tenant_id", tenantID.String())
+		}
+	}
+
+	if after, keysetArgs := userKeyset.After(filter.Cursor, args); after != "" {
+		where += " AND " + after
+		args = keysetArgs
+	}
+
+	query := `
+		SELECT
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
+		FROM users
+		WHERE ` + where + `
+		ORDER BY ` + userKeyset.OrderBy()
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+	// Con cursor no hace falta OFFSET: el keyset ya salta las páginas previas
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	var dbUsers []userDB
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &dbUsers, query, args...)
+	if err != nil {
+		return nil, 0, errx.Wrap(err, "failed to search users", errx.TypeInternal).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	result := make([]*user.User, len(dbUsers))
+	for i := range dbUsers {
+		domainUser, err := dbUsers[i].toDomain()
+		if err != nil {
+			return nil, 0, err
+		}
+		result[i] = domainUser
+	}
+
+	return result, total, nil
+}
+
+// userKeyset pagina las búsquedas por cursor sobre idx_users_tenant_keyset
+var userKeyset = dbx.Keyset{CreatedAtColumn: "created_at", IDColumn: "id"}
+
+// escapeLike escapa los comodines de LIKE en un valor provisto por el usuario
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
 // Save guarda o actualiza un usuario
 func (r *PostgresUserRepository) Save(ctx context.Context, u user.User) error {
 	exists, err := r.userExists(ctx, u.ID, u.TenantID)
@@ -220,14 +397,14 @@ func (r *PostgresUserRepository) Save(ctx context.Context, u user.User) error {
 func (r *PostgresUserRepository) create(ctx context.Context, u user.User) error {
 	query := `
 		INSERT INTO users (
-			id, tenant_id, email, name, picture, status, scopes,
-			oauth_provider, oauth_provider_id, email_verified, otp_enabled,
-			last_login_at, created_at, updated_at
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
 		)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query,
 		u.ID.String(),
 		u.TenantID.String(),
 		u.Email,
@@ -235,13 +412,17 @@ func (r *PostgresUserRepository) create(ctx context.Context, u user.User) error
 		u.Picture,
 		u.Status,
 		pq.Array(u.Scopes),
+		pq.Array(u.RequestedScopes),
 		u.OAuthProvider,
 		u.OAuthProviderID,
 		u.EmailVerified,
 		u.OTPEnabled,
+		u.Phone,
+		u.PasswordHash,
 		u.LastLoginAt,
 		u.CreatedAt,
 		u.UpdatedAt,
+		u.DeletedAt,
 	)
 
 	if err != nil {
@@ -251,6 +432,11 @@ func (r *PostgresUserRepository) create(ctx context.Context, u user.User) error
 					WithDetail("email", u.Email).
 					WithDetail("tenant_id", u.TenantID.String())
 			}
+			if pqErr.Code == "23505" && pqErr.Constraint == "uq_users_phone_tenant" {
+				return user.ErrUserAlreadyExists().
+					WithDetail("phone", u.Phone).
+					WithDetail("tenant_id", u.TenantID.String())
+			}
 		}
 		return errx.Wrap(err, "failed to create user", errx.TypeInternal).
 			WithDetail("user_id", u.ID.String()).
@@ -269,26 +455,34 @@ func (r *PostgresUserRepository) update(ctx context.Context, u user.User) error
 			picture = $3,
 			status = $4,
 			scopes = $5,
-			oauth_provider = $6,
-			oauth_provider_id = $7,
-			email_verified = $8,
-			otp_enabled = $9,
-			last_login_at = $10,
-			updated_at = $11
-		WHERE id = $12 AND tenant_id = $13`
-
-	result, err := r.db.ExecContext(ctx, query,
+			requested_scopes = $6,
+			oauth_provider = $7,
+			oauth_provider_id = $8,
+			email_verified = $9,
+			otp_enabled = $10,
+			phone = $11,
+			password_hash = $12,
+			last_login_at = $13,
+			updated_at = $14,
+			deleted_at = $15
+		WHERE id = $16 AND tenant_id = $17`
+
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query,
 		u.Email,
 		u.Name,
 		u.Picture,
 		u.Status,
 		pq.Array(u.Scopes),
+		pq.Array(u.RequestedScopes),
 		u.OAuthProvider,
 		u.OAuthProviderID,
 		u.EmailVerified,
 		u.OTPEnabled,
+		u.Phone,
+		u.PasswordHash,
 		u.LastLoginAt,
 		u.UpdatedAt,
+		u.DeletedAt,
 		u.ID.String(),
 		u.TenantID.String(),
 	)
@@ -300,6 +494,11 @@ func (r *PostgresUserRepository) update(ctx context.Context, u user.User) error
 					WithDetail("email", u.Email).
 					WithDetail("tenant_id", u.TenantID.String())
 			}
+			if pqErr.Code == "23505" && pqErr.Constraint == "uq_users_phone_tenant" {
+				return user.ErrUserAlreadyExists().
+					WithDetail("phone", u.Phone).
+					WithDetail("tenant_id", u.TenantID.String())
+			}
 		}
 		return errx.Wrap(err, "failed to update user", errx.TypeInternal).
 			WithDetail("user_id", u.ID.String())
@@ -317,11 +516,16 @@ func (r *PostgresUserRepository) update(ctx context.Context, u user.User) error
 	return nil
 }
 
-// Delete elimina un usuario
+// Delete marca un usuario como eliminado (soft delete)
 func (r *PostgresUserRepository) Delete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error {
-	query := `DELETE FROM users WHERE id = $1 AND tenant_id = $2`
+	query := `
+		UPDATE users SET
+			deleted_at = NOW(),
+			status = $1,
+			updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL`
 
-	result, err := r.db.ExecContext(ctx, query, id.String(), tenantID.String())
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, user.UserStatusDeleted, id.String(), tenantID.String())
 	if err != nil {
 		return errx.Wrap(err, "failed to delete user", errx.TypeInternal).
 			WithDetail("user_id", id.String()).
@@ -340,12 +544,60 @@ func (r *PostgresUserRepository) Delete(ctx context.Context, id kernel.UserID, t
 	return nil
 }
 
+// HardDelete elimina físicamente un usuario, incluso si ya fue eliminado lógicamente
+func (r *PostgresUserRepository) HardDelete(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) error {
+	query := `DELETE FROM users WHERE id = $1 AND tenant_id = $2`
+
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, id.String(), tenantID.String())
+	if err != nil {
+		return errx.Wrap(err, "failed to hard delete user", errx.TypeInternal).
+			WithDetail("user_id", id.String()).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errx.Wrap(err, "failed to get rows affected", errx.TypeInternal)
+	}
+
+	if rowsAffected == 0 {
+		return user.ErrUserNotFound().WithDetail("user_id", id.String())
+	}
+
+	return nil
+}
+
+// FindByIDIncludeDeleted busca un usuario por ID incluyendo los eliminados lógicamente.
+// Pensado para flujos de recuperación administrativos.
+func (r *PostgresUserRepository) FindByIDIncludeDeleted(ctx context.Context, id kernel.UserID, tenantID kernel.TenantID) (*user.User, error) {
+	query := `
+		SELECT
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
+		FROM users
+		WHERE id = $1 AND tenant_id = $2`
+
+	var dbUser userDB
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &dbUser, query, id.String(), tenantID.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, user.ErrUserNotFound().WithDetail("user_id", id.String())
+		}
+		return nil, errx.Wrap(err, "failed to find user by id", errx.TypeInternal).
+			WithDetail("user_id", id.String()).
+			WithDetail("tenant_id", tenantID.String())
+	}
+
+	return dbUser.toDomain()
+}
+
 // ExistsByEmail verifica si existe un usuario con el email dado en el tenant
 func (r *PostgresUserRepository) ExistsByEmail(ctx context.Context, email string, tenantID kernel.TenantID) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND tenant_id = $2)`
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND tenant_id = $2 AND deleted_at IS NULL)`
 
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, query, email, tenantID.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &exists, query, email, tenantID.String())
 	if err != nil {
 		return false, errx.Wrap(err, "failed to check user existence by email", errx.TypeInternal).
 			WithDetail("email", email).
@@ -360,7 +612,7 @@ func (r *PostgresUserRepository) userExists(ctx context.Context, id kernel.UserI
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND tenant_id = $2)`
 
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, query, id.String(), tenantID.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &exists, query, id.String(), tenantID.String())
 	if err != nil {
 		return false, errx.Wrap(err, "failed to check user existence", errx.TypeInternal).
 			WithDetail("user_id", id.String()).
@@ -374,15 +626,15 @@ func (r *PostgresUserRepository) userExists(ctx context.Context, id kernel.UserI
 func (r *PostgresUserRepository) FindByStatus(ctx context.Context, status user.UserStatus, tenantID kernel.TenantID) ([]*user.User, error) {
 	query := `
 		SELECT
-			id, tenant_id, email, name, picture, status, scopes,
-			oauth_provider, oauth_provider_id, email_verified, otp_enabled,
-			last_login_at, created_at, updated_at
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE status = $1 AND tenant_id = $2
+		WHERE status = $1 AND tenant_id = $2 AND deleted_at IS NULL
 		ORDER BY name ASC`
 
 	var dbUsers []userDB
-	err := r.db.SelectContext(ctx, &dbUsers, query, status, tenantID.String())
+	err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &dbUsers, query, status, tenantID.String())
 	if err != nil {
 		return nil, errx.Wrap(err, "failed to find users by status", errx.TypeInternal).
 			WithDetail("status", string(status)).
@@ -408,10 +660,10 @@ func (r *PostgresUserRepository) FindActiveUsers(ctx context.Context, tenantID k
 
 // CountByTenant cuenta los usuarios de un tenant
 func (r *PostgresUserRepository) CountByTenant(ctx context.Context, tenantID kernel.TenantID) (int, error) {
-	query := `SELECT COUNT(*) FROM users WHERE tenant_id = $1`
+	query := `SELECT COUNT(*) FROM users WHERE tenant_id = $1 AND deleted_at IS NULL`
 
 	var count int
-	err := r.db.GetContext(ctx, &count, query, tenantID.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &count, query, tenantID.String())
 	if err != nil {
 		return 0, errx.Wrap(err, "failed to count users by tenant", errx.TypeInternal).
 			WithDetail("tenant_id", tenantID.String())
@@ -424,14 +676,14 @@ func (r *PostgresUserRepository) CountByTenant(ctx context.Context, tenantID ker
 func (r *PostgresUserRepository) FindByOAuthProvider(ctx context.Context, provider string, providerID string, tenantID kernel.TenantID) (*user.User, error) {
 	query := `
 		SELECT
-			id, tenant_id, email, name, picture, status, scopes,
-			oauth_provider, oauth_provider_id, email_verified, otp_enabled,
-			last_login_at, created_at, updated_at
+			id, tenant_id, email, name, picture, status, scopes, requested_scopes,
+			oauth_provider, oauth_provider_id, email_verified, otp_enabled, phone, password_hash,
+			last_login_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE oauth_provider = $1 AND oauth_provider_id = $2 AND tenant_id = $3`
+		WHERE oauth_provider = $1 AND oauth_provider_id = $2 AND tenant_id = $3 AND deleted_at IS NULL`
 
 	var dbUser userDB
-	err := r.db.GetContext(ctx, &dbUser, query, provider, providerID, tenantID.String())
+	err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &dbUser, query, provider, providerID, tenantID.String())
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, user.ErrUserNotFound().
@@ -2,21 +2,70 @@ package usersrv
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/Abraxas-365/manifesto/internal/dbx"
 	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/iam/role"
 	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
 	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
 	"github.com/Abraxas-365/manifesto/internal/iam/user"
 	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
 	"github.com/google/uuid"
 )
 
+// Límites de paginación para la búsqueda de usuarios
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// maxBulkInviteRows limita las filas de una importación masiva
+const maxBulkInviteRows = 500
+
+// InvitationCreator crea invitaciones; lo implementa InvitationService y
+// BulkInviteUsers lo usa para que cada fila siga el camino normal
+type InvitationCreator interface {
+	CreateInvitation(ctx context.Context, tenantID kernel.TenantID, invitedBy kernel.UserID, req invitation.CreateInvitationRequest) (*invitation.CreateInvitationResult, error)
+}
+
 // UserService proporciona operaciones de negocio para usuarios
 type UserService struct {
-	userRepo    user.UserRepository
-	tenantRepo  tenant.TenantRepository
-	passwordSvc user.PasswordService
+	userRepo          user.UserRepository
+	tenantRepo        tenant.TenantRepository
+	passwordSvc       user.PasswordService
+	roleRepo          role.RoleRepository
+	auditRecorder     audit.Recorder
+	invitationCreator InvitationCreator
+	normalizeScopes   bool
+
+	// Borrado de datos personales (EraseUser)
+	transactor     dbx.Transactor
+	invitationRepo invitation.InvitationRepository
+	sessionRepo    auth.SessionRepository
+	tokenRepo      auth.TokenRepository
+	resetTokenRepo auth.PasswordResetRepository
+	otpRepo        otp.Repository
+	apiKeyRepo     apikey.APIKeyRepository
+}
+
+// UserServiceOption configura opciones opcionales del UserService
+type UserServiceOption func(*UserService)
+
+// WithScopeNormalization elimina, antes de guardar, los scopes que ya implica
+// un wildcard de la misma lista (ver scopes.Normalize)
+func WithScopeNormalization(enabled bool) UserServiceOption {
+	return func(s *UserService) {
+		s.normalizeScopes = enabled
+	}
 }
 
 // NewUserService crea una nueva instancia del servicio de usuarios
@@ -24,12 +73,37 @@ func NewUserService(
 	userRepo user.UserRepository,
 	tenantRepo tenant.TenantRepository,
 	passwordSvc user.PasswordService,
+	roleRepo role.RoleRepository,
+	auditRecorder audit.Recorder,
+	invitationCreator InvitationCreator,
+	transactor dbx.Transactor,
+	invitationRepo invitation.InvitationRepository,
+	sessionRepo auth.SessionRepository,
+	tokenRepo auth.TokenRepository,
+	resetTokenRepo auth.PasswordResetRepository,
+	otpRepo otp.Repository,
+	apiKeyRepo apikey.APIKeyRepository,
+	opts ...UserServiceOption,
 ) *UserService {
-	return &UserService{
-		userRepo:    userRepo,
-		tenantRepo:  tenantRepo,
-		passwordSvc: passwordSvc,
+	s := &UserService{
+		userRepo:          userRepo,
+		tenantRepo:        tenantRepo,
+		passwordSvc:       passwordSvc,
+		roleRepo:          roleRepo,
+		auditRecorder:     auditRecorder,
+		invitationCreator: invitationCreator,
+		transactor:        transactor,
+		invitationRepo:    invitationRepo,
+		sessionRepo:       sessionRepo,
+		tokenRepo:         tokenRepo,
+		resetTokenRepo:    resetTokenRepo,
+		otpRepo:           otpRepo,
+		apiKeyRepo:        apiKeyRepo,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // CreateUser crea un nuevo usuario
@@ -59,7 +133,7 @@ func (s *UserService) CreateUser(ctx context.Context, req user.CreateUserRequest
 	}
 
 	// Determinar scopes
-	scopes, err := s.resolveScopes(req)
+	scopes, err := s.resolveScopes(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -76,11 +150,11 @@ func (s *UserService) CreateUser(ctx context.Context, req user.CreateUserRequest
 		Email:         req.Email,
 		Name:          req.Name,
 		Status:        user.UserStatusPending, // Pendiente hasta completar onboarding
-		Scopes:        scopes,
-		EmailVerified: false, // Se verificará después
+		EmailVerified: false,                  // Se verificará después
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
+	newUser.SetNormalizedScopes(scopes, s.normalize(scopes))
 
 	// Guardar usuario
 	if err := s.userRepo.Save(ctx, *newUser); err != nil {
@@ -139,6 +213,63 @@ func (s *UserService) GetUsersByTenant(ctx context.Context, tenantID kernel.Tena
 	}, nil
 }
 
+// SearchUsers busca usuarios de un tenant con filtros y paginación por cursor,
+// del más reciente al más antiguo
+func (s *UserService) SearchUsers(ctx context.Context, tenantID kernel.TenantID, filter user.UserSearchFilter) (*kernel.Page[user.UserDetailsDTO], error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	// Una fila extra indica si hay página siguiente
+	limit := filter.Limit
+	filter.Limit++
+
+	users, total, err := s.userRepo.Search(ctx, tenantID, filter)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to search users", errx.TypeInternal)
+	}
+
+	page := kernel.MapPage(kernel.NewPage(users, total, limit, (*user.User).PageCursor), (*user.User).ToDTO)
+	return &page, nil
+}
+
+// ExportUsers recorre todos los usuarios del tenant que cumplen el filtro y
+// llama a fn por cada uno, paginando de a maxSearchLimit por cursor (keyset)
+// para no cargar el tenant completo en memoria. Las páginas no cuentan el
+// total. Limit, Offset y Cursor del filtro se ignoran. Si fn retorna error
+// (p. ej. el cliente se desconectó) el recorrido se detiene.
+func (s *UserService) ExportUsers(ctx context.Context, tenantID kernel.TenantID, filter user.UserSearchFilter, fn func(*user.User) error) error {
+	filter.Limit = maxSearchLimit
+	filter.Offset = 0
+	filter.Cursor = nil
+	filter.SkipCount = true
+
+	for {
+		users, _, err := s.userRepo.Search(ctx, tenantID, filter)
+		if err != nil {
+			return errx.Wrap(err, "failed to export users", errx.TypeInternal)
+		}
+
+		for _, u := range users {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+
+		if len(users) < filter.Limit {
+			return nil
+		}
+		cursor := users[len(users)-1].PageCursor()
+		filter.Cursor = &cursor
+	}
+}
+
 // UpdateUser actualiza un usuario
 func (s *UserService) UpdateUser(ctx context.Context, userID kernel.UserID, req user.UpdateUserRequest, updaterID kernel.UserID) (*user.User, error) {
 	userEntity, err := s.userRepo.FindByID(ctx, userID, req.TenantID)
@@ -164,25 +295,41 @@ func (s *UserService) UpdateUser(ctx context.Context, userID kernel.UserID, req
 		}
 	}
 
+	// Actualizar teléfono (habilita login por SMS)
+	if req.Phone != nil {
+		if *req.Phone == "" {
+			userEntity.Phone = nil
+		} else if !kernel.Phone(*req.Phone).IsValid() {
+			return nil, errx.Validation("phone must be in E.164 format").WithDetail("phone", *req.Phone)
+		} else {
+			phone := *req.Phone
+			userEntity.Phone = &phone
+		}
+	}
+
 	// Actualizar scopes si se proporcionaron
+	scopesChanged := false
 	if req.Scopes != nil && len(req.Scopes) > 0 {
 		if err := s.validateScopes(req.Scopes); err != nil {
 			return nil, err
 		}
 		userEntity.SetScopes(req.Scopes)
+		scopesChanged = true
 	}
 
 	// Aplicar scope template si se proporciona
 	if req.ScopeTemplate != nil && *req.ScopeTemplate != "" {
-		scopes := scopes.GetScopesByGroup(*req.ScopeTemplate)
-		if len(scopes) == 0 {
-			return nil, user.ErrInvalidScopeTemplate().
-				WithDetail("template", *req.ScopeTemplate).
-				WithDetail("available_templates", s.GetAvailableScopeTemplates())
+		scopes, err := s.resolveScopeTemplate(ctx, req.TenantID, *req.ScopeTemplate)
+		if err != nil {
+			return nil, err
 		}
 		userEntity.SetScopes(scopes)
+		scopesChanged = true
 	}
 
+	if scopesChanged {
+		s.normalizeUserScopes(userEntity)
+	}
 	userEntity.UpdatedAt = time.Now()
 
 	// Guardar cambios
@@ -204,7 +351,12 @@ func (s *UserService) ActivateUser(ctx context.Context, userID kernel.UserID, te
 		return err
 	}
 
-	return s.userRepo.Save(ctx, *userEntity)
+	if err := s.userRepo.Save(ctx, *userEntity); err != nil {
+		return err
+	}
+
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionUserActivated, audit.ResourceUser, userID.String()))
+	return nil
 }
 
 // SuspendUser suspende un usuario
@@ -218,32 +370,296 @@ func (s *UserService) SuspendUser(ctx context.Context, userID kernel.UserID, ten
 		return err
 	}
 
-	return s.userRepo.Save(ctx, *userEntity)
+	if err := s.userRepo.Save(ctx, *userEntity); err != nil {
+		return err
+	}
+
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionUserSuspended, audit.ResourceUser, userID.String()).
+		WithMetadata("reason", reason))
+	return nil
 }
 
-// DeleteUser elimina un usuario
+// DeleteUser elimina lógicamente un usuario (soft delete). En una transacción
+// revoca sus sesiones y refresh tokens, que la fila eliminada ya no borra en
+// cascada, y descuenta al usuario del tenant.
 func (s *UserService) DeleteUser(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID) error {
 	// Verificar que el usuario existe
-	_, err := s.userRepo.FindByID(ctx, userID, tenantID)
+	if _, err := s.userRepo.FindByID(ctx, userID, tenantID); err != nil {
+		if errx.Is(err, user.CodeUserNotFound) {
+			return user.ErrUserNotFound()
+		}
+		return errx.Wrap(err, "failed to find user", errx.TypeInternal)
+	}
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, userID, tenantID); err != nil {
+			return err
+		}
+		if err := s.sessionRepo.RevokeAllUserSessions(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.tokenRepo.RevokeAllUserTokens(ctx, userID); err != nil {
+			return err
+		}
+
+		// Decrementar contador de usuarios del tenant
+		tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		tenantEntity.RemoveUser()
+		return s.tenantRepo.Save(ctx, *tenantEntity)
+	})
+	if err != nil {
+		return errx.Wrap(err, "failed to delete user", errx.TypeInternal).
+			WithDetail("user_id", userID.String())
+	}
+
+	return nil
+}
+
+// EraseUser borra los datos personales de un usuario (derecho al olvido). En
+// una transacción revoca sus sesiones, refresh tokens y tokens de reset,
+// elimina las invitaciones dirigidas a su email y sus códigos OTP, y según
+// mode anonimiza la fila o la elimina (ver user.ErasureMode; sin modo se
+// anonimiza). También aplica a usuarios eliminados lógicamente. Los códigos
+// OTP guardados en Redis se borran fuera de la transacción.
+func (s *UserService) EraseUser(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, mode user.ErasureMode) error {
+	if mode == "" {
+		mode = user.ErasureModeAnonymize
+	}
+	if !mode.IsValid() {
+		return user.ErrInvalidErasureMode().WithDetail("mode", mode)
+	}
+
+	userEntity, err := s.userRepo.FindByIDIncludeDeleted(ctx, userID, tenantID)
 	if err != nil {
 		return user.ErrUserNotFound()
 	}
+	if userEntity.IsErased() {
+		return user.ErrInvalidStatus().WithDetail("reason", "user already erased")
+	}
 
-	// Eliminar usuario
-	if err := s.userRepo.Delete(ctx, userID, tenantID); err != nil {
-		return errx.Wrap(err, "failed to delete user", errx.TypeInternal)
+	// Los eliminados lógicamente ya no cuentan en el tenant
+	counted := !userEntity.IsDeleted()
+
+	// Los OTP no tienen tenant: solo se borran los de contactos que ninguna
+	// otra cuenta usa, para no invalidar códigos de otros tenants
+	contacts, err := s.unsharedContacts(ctx, userEntity)
+	if err != nil {
+		return errx.Wrap(err, "failed to erase user", errx.TypeInternal).
+			WithDetail("user_id", userID.String())
 	}
 
-	// Decrementar contador de usuarios del tenant
-	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
-	if err == nil {
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.RevokeAllUserSessions(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.tokenRepo.RevokeAllUserTokens(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.resetTokenRepo.RevokeAllUserResetTokens(ctx, userID); err != nil {
+			return err
+		}
+
+		// Las API keys del usuario siguen autenticando aunque él ya no exista
+		keys, err := s.apiKeyRepo.FindByUser(ctx, userID, tenantID)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := s.apiKeyRepo.Delete(ctx, key.ID, tenantID); err != nil {
+				return err
+			}
+		}
+
+		// Las invitaciones guardan el email, cualquiera sea su estado
+		invitations, err := s.invitationRepo.FindByEmail(ctx, userEntity.Email, tenantID)
+		if err != nil {
+			return err
+		}
+		for _, inv := range invitations {
+			if err := s.invitationRepo.Delete(ctx, inv.ID); err != nil {
+				return err
+			}
+		}
+
+		for _, contact := range contacts {
+			if err := s.otpRepo.DeleteByContact(ctx, contact); err != nil {
+				return err
+			}
+		}
+
+		if mode == user.ErasureModeHardDelete {
+			if err := s.userRepo.HardDelete(ctx, userID, tenantID); err != nil {
+				return err
+			}
+		} else {
+			userEntity.Anonymize()
+			if err := s.userRepo.Save(ctx, *userEntity); err != nil {
+				return err
+			}
+		}
+
+		if !counted {
+			return nil
+		}
+		tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+		if err != nil {
+			return err
+		}
 		tenantEntity.RemoveUser()
-		s.tenantRepo.Save(ctx, *tenantEntity)
+		return s.tenantRepo.Save(ctx, *tenantEntity)
+	})
+	if err != nil {
+		return errx.Wrap(err, "failed to erase user", errx.TypeInternal).
+			WithDetail("user_id", userID.String()).
+			WithDetail("mode", mode)
 	}
 
+	s.auditRecorder.Record(ctx, audit.NewEvent(tenantID, audit.ActionUserErased, audit.ResourceUser, userID.String()).
+		WithMetadata("mode", mode))
 	return nil
 }
 
+// unsharedContacts retorna el email y el teléfono del usuario que no usa
+// ninguna otra cuenta, de este u otro tenant
+func (s *UserService) unsharedContacts(ctx context.Context, u *user.User) ([]string, error) {
+	var contacts []string
+
+	others, err := s.userRepo.FindByEmailAcrossTenants(ctx, u.Email)
+	if err != nil {
+		return nil, err
+	}
+	if !usedByOthers(others, u.ID) {
+		contacts = append(contacts, u.Email)
+	}
+
+	if u.Phone != nil && *u.Phone != "" {
+		others, err := s.userRepo.FindByPhoneAcrossTenants(ctx, *u.Phone)
+		if err != nil {
+			return nil, err
+		}
+		if !usedByOthers(others, u.ID) {
+			contacts = append(contacts, *u.Phone)
+		}
+	}
+
+	return contacts, nil
+}
+
+// usedByOthers indica si alguna cuenta distinta de userID está en users
+func usedByOthers(users []*user.User, userID kernel.UserID) bool {
+	for _, other := range users {
+		if other.ID != userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoreUser recupera un usuario eliminado lógicamente
+func (s *UserService) RestoreUser(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID) (*user.User, error) {
+	userEntity, err := s.userRepo.FindByIDIncludeDeleted(ctx, userID, tenantID)
+	if err != nil {
+		if errx.Is(err, user.CodeUserNotFound) {
+			return nil, user.ErrUserNotFound()
+		}
+		return nil, errx.Wrap(err, "failed to find user", errx.TypeInternal)
+	}
+
+	tenantEntity, err := s.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		if errx.Is(err, tenant.CodeTenantNotFound) {
+			return nil, tenant.ErrTenantNotFound()
+		}
+		return nil, errx.Wrap(err, "failed to find tenant", errx.TypeInternal)
+	}
+
+	if err := userEntity.Restore(); err != nil {
+		return nil, err
+	}
+
+	// Incrementar contador de usuarios del tenant
+	if err := tenantEntity.AddUser(); err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Save(ctx, *userEntity); err != nil {
+			return err
+		}
+		return s.tenantRepo.Save(ctx, *tenantEntity)
+	})
+	if err != nil {
+		// Save retorna ErrUserAlreadyExists si el email fue reutilizado por otro usuario
+		if errx.Is(err, user.CodeUserAlreadyExists) {
+			return nil, err
+		}
+		return nil, errx.Wrap(err, "failed to restore user", errx.TypeInternal).
+			WithDetail("user_id", userID.String())
+	}
+
+	return userEntity, nil
+}
+
+// ============================================================================
+// Bulk Import Methods
+// ============================================================================
+
+// BulkInviteUsers invita varios usuarios al tenant. Cada fila pasa por la
+// creación normal de invitaciones (permisos del invitador, usuario o
+// invitación existente, límite de pendientes, validación de scopes) y un
+// fallo solo afecta a su fila. Las invitaciones creadas cuyo email falló se
+// reportan en EmailFailed, no como exitosas.
+func (s *UserService) BulkInviteUsers(ctx context.Context, tenantID kernel.TenantID, inviterID kernel.UserID, reqs []user.InviteUserRequest) (*user.BulkInviteResult, error) {
+	if len(reqs) > maxBulkInviteRows {
+		return nil, user.ErrTooManyImportRows().
+			WithDetail("max_rows", maxBulkInviteRows).
+			WithDetail("rows", len(reqs))
+	}
+
+	result := &user.BulkInviteResult{
+		Successful:  []string{},
+		EmailFailed: []string{},
+		Failed:      []user.BulkInviteFailure{},
+		Total:       len(reqs),
+	}
+
+	for i, req := range reqs {
+		email := strings.TrimSpace(req.Email)
+		if email == "" {
+			result.Failed = append(result.Failed, user.BulkInviteFailure{Row: i + 1, Email: req.Email, Error: "email is required"})
+			continue
+		}
+
+		created, err := s.invitationCreator.CreateInvitation(ctx, tenantID, inviterID, invitation.CreateInvitationRequest{
+			Email:         email,
+			Scopes:        req.Scopes,
+			ScopeTemplate: req.ScopeTemplate,
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, user.BulkInviteFailure{Row: i + 1, Email: email, Error: err.Error()})
+			continue
+		}
+
+		if created.EmailFailed {
+			result.EmailFailed = append(result.EmailFailed, email)
+			continue
+		}
+		result.Successful = append(result.Successful, email)
+	}
+
+	logx.FromContext(ctx).WithFields(logx.Fields{
+		"rows":         result.Total,
+		"successful":   len(result.Successful),
+		"email_failed": len(result.EmailFailed),
+		"failed":       len(result.Failed),
+	}).Info("bulk user import finished")
+
+	return result, nil
+}
+
 // ============================================================================
 // Scope Management Methods
 // ============================================================================
@@ -260,6 +676,8 @@ func (s *UserService) AddScopesToUser(ctx context.Context, userID kernel.UserID,
 		return err
 	}
 
+	previousScopes := s.editRawScopes(userEntity)
+
 	// Agregar scopes (evitando duplicados)
 	for _, scope := range scopes {
 		if !userEntity.HasScope(scope) {
@@ -267,7 +685,8 @@ func (s *UserService) AddScopesToUser(ctx context.Context, userID kernel.UserID,
 		}
 	}
 
-	return s.userRepo.Save(ctx, *userEntity)
+	requestedScopes := s.normalizeUserScopes(userEntity)
+	return s.saveScopeChange(ctx, userEntity, previousScopes, requestedScopes)
 }
 
 // RemoveScopesFromUser remueve scopes de un usuario
@@ -277,12 +696,15 @@ func (s *UserService) RemoveScopesFromUser(ctx context.Context, userID kernel.Us
 		return user.ErrUserNotFound()
 	}
 
+	previousScopes := s.editRawScopes(userEntity)
+
 	// Remover scopes
 	for _, scope := range scopes {
 		userEntity.RemoveScope(scope)
 	}
 
-	return s.userRepo.Save(ctx, *userEntity)
+	requestedScopes := s.normalizeUserScopes(userEntity)
+	return s.saveScopeChange(ctx, userEntity, previousScopes, requestedScopes)
 }
 
 // SetUserScopes establece los scopes de un usuario (reemplaza los existentes)
@@ -297,8 +719,10 @@ func (s *UserService) SetUserScopes(ctx context.Context, userID kernel.UserID, t
 		return err
 	}
 
+	previousScopes := slices.Clone(userEntity.Scopes)
 	userEntity.SetScopes(scopes)
-	return s.userRepo.Save(ctx, *userEntity)
+	requestedScopes := s.normalizeUserScopes(userEntity)
+	return s.saveScopeChange(ctx, userEntity, previousScopes, requestedScopes)
 }
 
 // ApplyScopeTemplateToUser aplica una plantilla de scopes a un usuario
@@ -308,40 +732,104 @@ func (s *UserService) ApplyScopeTemplateToUser(ctx context.Context, userID kerne
 		return user.ErrUserNotFound()
 	}
 
-	scopes := scopes.GetScopesByGroup(templateName)
-	if len(scopes) == 0 {
-		return user.ErrInvalidScopeTemplate().
-			WithDetail("template", templateName).
-			WithDetail("available_templates", s.GetAvailableScopeTemplates())
+	scopes, err := s.resolveScopeTemplate(ctx, tenantID, templateName)
+	if err != nil {
+		return err
 	}
 
+	previousScopes := slices.Clone(userEntity.Scopes)
 	userEntity.SetScopes(scopes)
-	return s.userRepo.Save(ctx, *userEntity)
+	requestedScopes := s.normalizeUserScopes(userEntity)
+	return s.saveScopeChange(ctx, userEntity, previousScopes, requestedScopes)
 }
 
-// GetUserScopes obtiene los scopes de un usuario
-func (s *UserService) GetUserScopes(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID) (*user.UserScopesResponse, error) {
+// PreviewScopeChange calcula el resultado de un cambio de scopes sin
+// persistirlo, para que el administrador lo confirme antes de aplicarlo
+func (s *UserService) PreviewScopeChange(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID, req user.PreviewScopeChangeRequest) (*user.ScopeChangePreview, error) {
 	userEntity, err := s.userRepo.FindByID(ctx, userID, tenantID)
 	if err != nil {
 		return nil, user.ErrUserNotFound()
 	}
 
-	// Construir información detallada de scopes
-	scopeDetails := make([]user.ScopeDetail, 0, len(userEntity.Scopes))
+	currentScopes := s.editRawScopes(userEntity)
+
+	// Aplicar el cambio sobre la entidad cargada, igual que las operaciones reales
+	switch req.Operation {
+	case user.ScopeChangeAdd:
+		if err := s.validateScopes(req.Scopes); err != nil {
+			return nil, err
+		}
+		for _, scope := range req.Scopes {
+			if !userEntity.HasScope(scope) {
+				userEntity.AddScope(scope)
+			}
+		}
+	case user.ScopeChangeRemove:
+		for _, scope := range req.Scopes {
+			userEntity.RemoveScope(scope)
+		}
+	case user.ScopeChangeSet:
+		if err := s.validateScopes(req.Scopes); err != nil {
+			return nil, err
+		}
+		userEntity.SetScopes(req.Scopes)
+	case user.ScopeChangeTemplate:
+		templateScopes, err := s.resolveScopeTemplate(ctx, tenantID, req.TemplateName)
+		if err != nil {
+			return nil, err
+		}
+		userEntity.SetScopes(templateScopes)
+	default:
+		return nil, errx.Validation("invalid scope change operation").
+			WithDetail("operation", req.Operation).
+			WithDetail("allowed", []user.ScopeChangeOperation{user.ScopeChangeAdd, user.ScopeChangeRemove, user.ScopeChangeSet, user.ScopeChangeTemplate})
+	}
+
+	s.normalizeUserScopes(userEntity)
+
+	added := []string{}
 	for _, scope := range userEntity.Scopes {
-		scopeDetails = append(scopeDetails, user.ScopeDetail{
-			Name:        scope,
-			Description: scopes.GetScopeDescription(scope),
-			Category:    scopes.GetScopeCategory(scope),
-		})
+		if !slices.Contains(currentScopes, scope) && !slices.Contains(added, scope) {
+			added = append(added, scope)
+		}
+	}
+	removed := []string{}
+	for _, scope := range currentScopes {
+		if !slices.Contains(userEntity.Scopes, scope) && !slices.Contains(removed, scope) {
+			removed = append(removed, scope)
+		}
+	}
+
+	return &user.ScopeChangePreview{
+		UserID:          userID,
+		CurrentScopes:   currentScopes,
+		ResultingScopes: userEntity.Scopes,
+		Added:           toScopeDetails(added),
+		Removed:         toScopeDetails(removed),
+		Changed:         len(added) > 0 || len(removed) > 0,
+	}, nil
+}
+
+// ValidateScopes valida una lista de scopes y sugiere los más parecidos para
+// los inválidos
+func (s *UserService) ValidateScopes(scopesl []string) scopes.ValidationResult {
+	return scopes.Validate(scopesl)
+}
+
+// GetUserScopes obtiene los scopes de un usuario
+func (s *UserService) GetUserScopes(ctx context.Context, userID kernel.UserID, tenantID kernel.TenantID) (*user.UserScopesResponse, error) {
+	userEntity, err := s.userRepo.FindByID(ctx, userID, tenantID)
+	if err != nil {
+		return nil, user.ErrUserNotFound()
 	}
 
 	return &user.UserScopesResponse{
-		UserID:       userID,
-		Scopes:       userEntity.Scopes,
-		ScopeDetails: scopeDetails,
-		TotalScopes:  len(userEntity.Scopes),
-		IsAdmin:      userEntity.IsAdmin(),
+		UserID:          userID,
+		Scopes:          userEntity.Scopes,
+		RequestedScopes: userEntity.RequestedScopes,
+		ScopeDetails:    toScopeDetails(userEntity.Scopes),
+		TotalScopes:     len(userEntity.Scopes),
+		IsAdmin:         userEntity.IsAdmin(),
 	}, nil
 }
 
@@ -352,8 +840,10 @@ func (s *UserService) MakeUserAdmin(ctx context.Context, userID kernel.UserID, t
 		return user.ErrUserNotFound()
 	}
 
+	previousScopes := s.editRawScopes(userEntity)
 	userEntity.MakeAdmin()
-	return s.userRepo.Save(ctx, *userEntity)
+	requestedScopes := s.normalizeUserScopes(userEntity)
+	return s.saveScopeChange(ctx, userEntity, previousScopes, requestedScopes)
 }
 
 // RevokeUserAdmin revoca permisos de administrador
@@ -363,8 +853,10 @@ func (s *UserService) RevokeUserAdmin(ctx context.Context, userID kernel.UserID,
 		return user.ErrUserNotFound()
 	}
 
+	previousScopes := s.editRawScopes(userEntity)
 	userEntity.RevokeAdmin()
-	return s.userRepo.Save(ctx, *userEntity)
+	requestedScopes := s.normalizeUserScopes(userEntity)
+	return s.saveScopeChange(ctx, userEntity, previousScopes, requestedScopes)
 }
 
 // GetAvailableScopeTemplates retorna las plantillas de scopes disponibles
@@ -383,19 +875,10 @@ func (s *UserService) GetScopeTemplateDetails(templateName string) (*user.ScopeT
 		return nil, user.ErrInvalidScopeTemplate().WithDetail("template", templateName)
 	}
 
-	scopeDetails := make([]user.ScopeDetail, 0, len(scopesl))
-	for _, scope := range scopesl {
-		scopeDetails = append(scopeDetails, user.ScopeDetail{
-			Name:        scope,
-			Description: scopes.GetScopeDescription(scope),
-			Category:    scopes.GetScopeCategory(scope),
-		})
-	}
-
 	return &user.ScopeTemplateResponse{
 		TemplateName: templateName,
 		Scopes:       scopesl,
-		ScopeDetails: scopeDetails,
+		ScopeDetails: toScopeDetails(scopesl),
 		TotalScopes:  len(scopesl),
 	}, nil
 }
@@ -427,8 +910,53 @@ func (s *UserService) GetAllAvailableScopes() *user.AvailableScopesResponse {
 // Private Helper Methods
 // ============================================================================
 
+// saveScopeChange persiste el usuario y audita el cambio si sus scopes
+// variaron. requestedScopes es la lista antes de normalizar; se registra en el
+// evento cuando la normalización la cambió.
+func (s *UserService) saveScopeChange(ctx context.Context, userEntity *user.User, previousScopes, requestedScopes []string) error {
+	if err := s.userRepo.Save(ctx, *userEntity); err != nil {
+		return err
+	}
+
+	if !slices.Equal(previousScopes, userEntity.Scopes) {
+		event := audit.NewEvent(userEntity.TenantID, audit.ActionUserScopesChanged, audit.ResourceUser, userEntity.ID.String()).
+			WithMetadata("previous_scopes", previousScopes).
+			WithMetadata("scopes", userEntity.Scopes)
+		if requestedScopes != nil && !slices.Equal(requestedScopes, userEntity.Scopes) {
+			event = event.WithMetadata("requested_scopes", requestedScopes)
+		}
+		s.auditRecorder.Record(ctx, event)
+	}
+	return nil
+}
+
+// normalize aplica scopes.Normalize si la normalización está habilitada
+func (s *UserService) normalize(scopeList []string) []string {
+	if !s.normalizeScopes {
+		return scopeList
+	}
+	return scopes.Normalize(scopeList)
+}
+
+// editRawScopes prepara un cambio de scopes: deja en Scopes la lista sin
+// normalizar, para que el cambio se aplique sobre lo otorgado (quitar "jobs:*"
+// devuelve los "jobs:..." que absorbía), y retorna los scopes efectivos previos
+func (s *UserService) editRawScopes(userEntity *user.User) []string {
+	previous := slices.Clone(userEntity.Scopes)
+	userEntity.SetScopes(slices.Clone(userEntity.RawScopes()))
+	return previous
+}
+
+// normalizeUserScopes normaliza los scopes del usuario, guarda la lista sin
+// normalizar en RequestedScopes si cambió y la retorna
+func (s *UserService) normalizeUserScopes(userEntity *user.User) []string {
+	requested := userEntity.Scopes
+	userEntity.SetNormalizedScopes(requested, s.normalize(requested))
+	return requested
+}
+
 // resolveScopes determina los scopes finales basándose en la request
-func (s *UserService) resolveScopes(req user.CreateUserRequest) ([]string, error) {
+func (s *UserService) resolveScopes(ctx context.Context, req user.CreateUserRequest) ([]string, error) {
 	// Si se proporcionan scopes directamente, usarlos
 	if len(req.Scopes) > 0 {
 		return req.Scopes, nil
@@ -436,13 +964,7 @@ func (s *UserService) resolveScopes(req user.CreateUserRequest) ([]string, error
 
 	// Si se proporciona un template, expandirlo
 	if req.ScopeTemplate != nil && *req.ScopeTemplate != "" {
-		scopes := scopes.GetScopesByGroup(*req.ScopeTemplate)
-		if len(scopes) == 0 {
-			return nil, user.ErrInvalidScopeTemplate().
-				WithDetail("template", *req.ScopeTemplate).
-				WithDetail("available_templates", s.GetAvailableScopeTemplates())
-		}
-		return scopes, nil
+		return s.resolveScopeTemplate(ctx, req.TenantID, *req.ScopeTemplate)
 	}
 
 	// Default: usar template "viewer" o scopes básicos
@@ -451,6 +973,28 @@ func (s *UserService) resolveScopes(req user.CreateUserRequest) ([]string, error
 	return defaultScopes, nil
 }
 
+// resolveScopeTemplate expande un template global o un rol personalizado del tenant
+func (s *UserService) resolveScopeTemplate(ctx context.Context, tenantID kernel.TenantID, templateName string) ([]string, error) {
+	if scopeList := scopes.GetScopesByGroup(templateName); len(scopeList) > 0 {
+		return scopeList, nil
+	}
+
+	if s.roleRepo != nil {
+		tenantRole, err := s.roleRepo.FindByName(ctx, templateName, tenantID)
+		if err != nil && !role.IsRoleNotFound(err) {
+			return nil, errx.Wrap(err, "failed to resolve scope template", errx.TypeInternal).
+				WithDetail("template", templateName)
+		}
+		if err == nil && len(tenantRole.Scopes) > 0 {
+			return tenantRole.Scopes, nil
+		}
+	}
+
+	return nil, user.ErrInvalidScopeTemplate().
+		WithDetail("template", templateName).
+		WithDetail("available_templates", s.GetAvailableScopeTemplates())
+}
+
 // validateScopes valida que los scopes sean válidos
 func (s *UserService) validateScopes(scopesl []string) error {
 	if len(scopesl) == 0 {
@@ -458,18 +1002,26 @@ func (s *UserService) validateScopes(scopesl []string) error {
 	}
 
 	// Validar cada scope
-	invalidScopes := []string{}
-	for _, scope := range scopesl {
-		if !scopes.ValidateScope(scope) {
-			invalidScopes = append(invalidScopes, scope)
-		}
-	}
-
-	if len(invalidScopes) > 0 {
+	result := scopes.Validate(scopesl)
+	if !result.OK() {
 		return user.ErrInvalidScopes().
-			WithDetail("invalid_scopes", invalidScopes).
+			WithDetail("invalid_scopes", result.InvalidScopes()).
+			WithDetail("suggestions", result.Invalid).
 			WithDetail("hint", "Use GetAllAvailableScopes() to see valid scopes")
 	}
 
 	return nil
 }
+
+// toScopeDetails agrega descripción y categoría a cada scope
+func toScopeDetails(scopesl []string) []user.ScopeDetail {
+	details := make([]user.ScopeDetail, 0, len(scopesl))
+	for _, scope := range scopesl {
+		details = append(details, user.ScopeDetail{
+			Name:        scope,
+			Description: scopes.GetScopeDescription(scope),
+			Category:    scopes.GetScopeCategory(scope),
+		})
+	}
+	return details
+}
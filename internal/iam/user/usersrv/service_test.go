@@ -0,0 +1,456 @@
+package usersrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/apikey"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/invitation"
+	"github.com/Abraxas-365/manifesto/internal/iam/otp"
+	"github.com/Abraxas-365/manifesto/internal/iam/tenant"
+	"github.com/Abraxas-365/manifesto/internal/iam/user"
+	"github.com/Abraxas-365/manifesto/internal/iam/user/userinfra"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+type directTx struct{}
+
+func (directTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type memoryTenants struct {
+	tenant.TenantRepository
+	tenants map[kernel.TenantID]tenant.Tenant
+	saveErr error
+}
+
+func (r *memoryTenants) FindByID(_ context.Context, id kernel.TenantID) (*tenant.Tenant, error) {
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, tenant.ErrTenantNotFound()
+	}
+	return &t, nil
+}
+
+func (r *memoryTenants) Save(_ context.Context, t tenant.Tenant) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.tenants[t.ID] = t
+	return nil
+}
+
+type noInvitations struct {
+	invitation.InvitationRepository
+}
+
+func (noInvitations) FindByEmail(context.Context, string, kernel.TenantID) ([]*invitation.Invitation, error) {
+	return nil, nil
+}
+
+// revokedCredentials records whose sessions and tokens were revoked
+type revokedCredentials struct {
+	auth.SessionRepository
+	auth.TokenRepository
+	auth.PasswordResetRepository
+	users      []kernel.UserID
+	tokenUsers []kernel.UserID
+}
+
+func (r *revokedCredentials) RevokeAllUserSessions(_ context.Context, userID kernel.UserID) error {
+	r.users = append(r.users, userID)
+	return nil
+}
+
+func (r *revokedCredentials) RevokeAllUserTokens(_ context.Context, userID kernel.UserID) error {
+	r.tokenUsers = append(r.tokenUsers, userID)
+	return nil
+}
+
+func (r *revokedCredentials) RevokeAllUserResetTokens(context.Context, kernel.UserID) error {
+	return nil
+}
+
+type deletedOTPs struct {
+	otp.Repository
+	contacts []string
+}
+
+func (r *deletedOTPs) DeleteByContact(_ context.Context, contact string) error {
+	r.contacts = append(r.contacts, contact)
+	return nil
+}
+
+type memoryAPIKeys struct {
+	apikey.APIKeyRepository
+	keys map[string]apikey.APIKey
+}
+
+func (r *memoryAPIKeys) FindByUser(_ context.Context, userID kernel.UserID, tenantID kernel.TenantID) ([]*apikey.APIKey, error) {
+	var keys []*apikey.APIKey
+	for _, key := range r.keys {
+		if key.TenantID == tenantID && key.UserID != nil && *key.UserID == userID {
+			keys = append(keys, &key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryAPIKeys) Delete(_ context.Context, id string, _ kernel.TenantID) error {
+	delete(r.keys, id)
+	return nil
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) Record(context.Context, audit.AuditEvent) {}
+
+func TestEraseUser(t *testing.T) {
+	ctx := context.Background()
+	phone := "+15550100"
+	users := userinfra.NewInMemoryUserRepository()
+	for _, u := range []user.User{
+		{ID: "u1", TenantID: "t1", Email: "ana@acme.com", Phone: &phone, Status: user.UserStatusActive},
+		{ID: "u2", TenantID: "t1", Email: "luis@acme.com", Status: user.UserStatusActive},
+		{ID: "u3", TenantID: "t2", Email: "ana@acme.com", Status: user.UserStatusActive}, // same person, other tenant
+	} {
+		if err := users.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tenants := &memoryTenants{tenants: map[kernel.TenantID]tenant.Tenant{"t1": {ID: "t1", CurrentUsers: 2}}}
+	credentials := &revokedCredentials{}
+	otps := &deletedOTPs{}
+	u1, u2 := kernel.UserID("u1"), kernel.UserID("u2")
+	keys := &memoryAPIKeys{keys: map[string]apikey.APIKey{
+		"k1": {ID: "k1", TenantID: "t1", UserID: &u1},
+		"k2": {ID: "k2", TenantID: "t1", UserID: &u2},
+		"k3": {ID: "k3", TenantID: "t1"},
+	}}
+	s := NewUserService(users, tenants, nil, nil, noopRecorder{}, nil, directTx{}, noInvitations{},
+		credentials, credentials, credentials, otps, keys)
+
+	if err := s.EraseUser(ctx, "u1", "t1", "PURGE"); !errx.Is(err, user.CodeInvalidErasureMode) {
+		t.Fatalf("invalid mode error = %v, want INVALID_ERASURE_MODE", err)
+	}
+
+	if err := s.EraseUser(ctx, "u1", "t1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	erased, err := users.FindByIDIncludeDeleted(ctx, "u1", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !erased.IsErased() || erased.Phone != nil {
+		t.Errorf("user not anonymized: %+v", erased)
+	}
+	if !slices.Equal(credentials.users, []kernel.UserID{"u1"}) {
+		t.Errorf("revoked sessions of %v, want u1", credentials.users)
+	}
+	if _, ok := keys.keys["k1"]; ok {
+		t.Error("API key of the erased user still exists")
+	}
+	if len(keys.keys) != 2 {
+		t.Errorf("other API keys deleted: %v", keys.keys)
+	}
+	// The email is still used by u3 in t2, so its codes are kept
+	if !slices.Equal(otps.contacts, []string{phone}) {
+		t.Errorf("deleted OTPs of %v, want only the phone", otps.contacts)
+	}
+	if n := tenants.tenants["t1"].CurrentUsers; n != 1 {
+		t.Errorf("tenant user count = %d, want 1", n)
+	}
+
+	if err := s.EraseUser(ctx, "u1", "t1", user.ErasureModeHardDelete); !errx.Is(err, user.CodeInvalidStatus) {
+		t.Errorf("erasing twice error = %v, want INVALID_STATUS", err)
+	}
+}
+
+// brokenUsers fails every lookup with err
+type brokenUsers struct {
+	user.UserRepository
+	err error
+}
+
+func (r brokenUsers) FindByID(context.Context, kernel.UserID, kernel.TenantID) (*user.User, error) {
+	return nil, r.err
+}
+
+func (r brokenUsers) FindByIDIncludeDeleted(context.Context, kernel.UserID, kernel.TenantID) (*user.User, error) {
+	return nil, r.err
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	ctx := context.Background()
+	users := userinfra.NewInMemoryUserRepository()
+	if err := users.Save(ctx, user.User{ID: "u1", TenantID: "t1", Email: "ana@acme.com", Status: user.UserStatusActive}); err != nil {
+		t.Fatal(err)
+	}
+	tenants := &memoryTenants{tenants: map[kernel.TenantID]tenant.Tenant{
+		"t1": {ID: "t1", Status: tenant.TenantStatusActive, CurrentUsers: 1, MaxUsers: 5},
+	}}
+	credentials := &revokedCredentials{}
+	s := NewUserService(users, tenants, nil, nil, noopRecorder{}, nil, directTx{}, noInvitations{},
+		credentials, credentials, credentials, nil, nil)
+
+	if err := s.DeleteUser(ctx, "u1", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.FindByID(ctx, "u1", "t1"); !errx.Is(err, user.CodeUserNotFound) {
+		t.Errorf("deleted user lookup error = %v, want NOT_FOUND", err)
+	}
+	if !slices.Equal(credentials.users, []kernel.UserID{"u1"}) || !slices.Equal(credentials.tokenUsers, []kernel.UserID{"u1"}) {
+		t.Errorf("revoked sessions of %v and tokens of %v, want u1", credentials.users, credentials.tokenUsers)
+	}
+	if n := tenants.tenants["t1"].CurrentUsers; n != 0 {
+		t.Errorf("tenant user count after delete = %d, want 0", n)
+	}
+	if err := s.DeleteUser(ctx, "u1", "t1"); !errx.Is(err, user.CodeUserNotFound) {
+		t.Errorf("deleting twice error = %v, want NOT_FOUND", err)
+	}
+
+	restored, err := s.RestoreUser(ctx, "u1", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.IsDeleted() || restored.Status != user.UserStatusActive {
+		t.Errorf("restored user = %+v, want active", restored)
+	}
+	if n := tenants.tenants["t1"].CurrentUsers; n != 1 {
+		t.Errorf("tenant user count after restore = %d, want 1", n)
+	}
+	if _, err := s.RestoreUser(ctx, "u1", "t1"); !errx.Is(err, user.CodeInvalidStatus) {
+		t.Errorf("restoring an active user error = %v, want INVALID_STATUS", err)
+	}
+
+	if _, err := s.RestoreUser(ctx, "missing", "t1"); !errx.Is(err, user.CodeUserNotFound) {
+		t.Errorf("restoring a missing user error = %v, want NOT_FOUND", err)
+	}
+
+	if err := s.DeleteUser(ctx, "u1", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	tenants.saveErr = errors.New("connection reset")
+	_, err = s.RestoreUser(ctx, "u1", "t1")
+	if e, ok := errx.AsError(err); !ok || e.Type != errx.TypeInternal {
+		t.Errorf("restore with a failing tenant save = %v, want an internal error", err)
+	}
+
+	// Only a missing user is reported as not found
+	broken := NewUserService(brokenUsers{err: errors.New("connection reset")}, tenants, nil, nil, noopRecorder{}, nil, directTx{}, noInvitations{},
+		credentials, credentials, credentials, nil, nil)
+	for name, err := range map[string]error{
+		"delete":  broken.DeleteUser(ctx, "u1", "t1"),
+		"restore": err2(broken.RestoreUser(ctx, "u1", "t1")),
+	} {
+		if e, ok := errx.AsError(err); !ok || e.Type != errx.TypeInternal {
+			t.Errorf("%s with a failing repository = %v, want an internal error", name, err)
+		}
+	}
+}
+
+// err2 returns the error of a two-value call
+func err2[T any](_ T, err error) error { return err }
+
+// scriptedInvitations fails the emails in failing and skips sending the ones in
+// unsent, like CreateInvitation without the outbox
+type scriptedInvitations struct {
+	failing map[string]error
+	unsent  map[string]bool
+	created []string
+}
+
+func (c *scriptedInvitations) CreateInvitation(_ context.Context, _ kernel.TenantID, _ kernel.UserID, req invitation.CreateInvitationRequest) (*invitation.CreateInvitationResult, error) {
+	if err := c.failing[req.Email]; err != nil {
+		return nil, err
+	}
+	c.created = append(c.created, req.Email)
+	return &invitation.CreateInvitationResult{
+		Invitation:  &invitation.Invitation{Email: req.Email},
+		EmailFailed: c.unsent[req.Email],
+	}, nil
+}
+
+func TestBulkInviteUsers(t *testing.T) {
+	ctx := context.Background()
+	creator := &scriptedInvitations{
+		failing: map[string]error{"dup@acme.com": invitation.ErrInvitationAlreadyExists()},
+		unsent:  map[string]bool{"nomail@acme.com": true},
+	}
+	s := NewUserService(userinfra.NewInMemoryUserRepository(), nil, nil, nil, noopRecorder{}, creator, directTx{}, noInvitations{},
+		nil, nil, nil, nil, nil)
+
+	result, err := s.BulkInviteUsers(ctx, "t1", "admin", []user.InviteUserRequest{
+		{Email: " ana@acme.com "},
+		{Email: ""},
+		{Email: "dup@acme.com"},
+		{Email: "nomail@acme.com"},
+		{Email: " "},
+		{Email: "dup@acme.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(result.Successful, []string{"ana@acme.com"}) {
+		t.Errorf("successful = %v", result.Successful)
+	}
+	if !slices.Equal(result.EmailFailed, []string{"nomail@acme.com"}) {
+		t.Errorf("email failed = %v, want the invitation whose email was not sent", result.EmailFailed)
+	}
+	// Empty and repeated emails are reported once per row
+	var rows []int
+	for _, f := range result.Failed {
+		rows = append(rows, f.Row)
+	}
+	if !slices.Equal(rows, []int{2, 3, 5, 6}) {
+		t.Errorf("failed rows = %v, want [2 3 5 6]", rows)
+	}
+	if result.Total != 6 {
+		t.Errorf("total = %d, want 6", result.Total)
+	}
+
+	tooMany := make([]user.InviteUserRequest, maxBulkInviteRows+1)
+	if _, err := s.BulkInviteUsers(ctx, "t1", "admin", tooMany); !errx.Is(err, user.CodeTooManyImportRows) {
+		t.Errorf("oversized import error = %v, want TOO_MANY_IMPORT_ROWS", err)
+	}
+}
+
+// recordingSearch records the filters ExportUsers pages with
+type recordingSearch struct {
+	*userinfra.InMemoryUserRepository
+	filters []user.UserSearchFilter
+}
+
+func (r *recordingSearch) Search(ctx context.Context, tenantID kernel.TenantID, filter user.UserSearchFilter) ([]*user.User, int, error) {
+	r.filters = append(r.filters, filter)
+	return r.InMemoryUserRepository.Search(ctx, tenantID, filter)
+}
+
+func TestExportUsersPagesByCursor(t *testing.T) {
+	ctx := context.Background()
+	repo := &recordingSearch{InMemoryUserRepository: userinfra.NewInMemoryUserRepository()}
+	created := time.Now()
+	for i := range maxSearchLimit + 50 {
+		u := user.User{
+			ID:        kernel.UserID(fmt.Sprintf("u%03d", i)),
+			TenantID:  "t1",
+			Email:     fmt.Sprintf("user%03d@acme.com", i),
+			Status:    user.UserStatusActive,
+			CreatedAt: created.Add(time.Duration(i) * time.Second),
+		}
+		if err := repo.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewUserService(repo, nil, nil, nil, noopRecorder{}, nil, directTx{}, noInvitations{}, nil, nil, nil, nil, nil)
+
+	var seen []kernel.UserID
+	err := s.ExportUsers(ctx, "t1", user.UserSearchFilter{Offset: 7, Limit: 3}, func(u *user.User) error {
+		seen = append(seen, u.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != maxSearchLimit+50 || seen[0] != "u149" || seen[len(seen)-1] != "u000" {
+		t.Fatalf("exported %d users from %s to %s", len(seen), seen[0], seen[len(seen)-1])
+	}
+	if len(repo.filters) != 2 {
+		t.Fatalf("searched %d pages, want 2", len(repo.filters))
+	}
+	for i, f := range repo.filters {
+		if !f.SkipCount || f.Offset != 0 || f.Limit != maxSearchLimit {
+			t.Errorf("page %d filter = %+v, want keyset pages without a count", i, f)
+		}
+	}
+	if repo.filters[1].Cursor == nil || repo.filters[1].Cursor.ID != "u050" {
+		t.Errorf("second page cursor = %+v, want after u050", repo.filters[1].Cursor)
+	}
+
+	// A failing callback stops the export
+	stop := errors.New("client gone")
+	calls := 0
+	err = s.ExportUsers(ctx, "t1", user.UserSearchFilter{}, func(*user.User) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("export after a failed write = %v after %d rows", err, calls)
+	}
+}
+
+func TestScopeNormalizationKeepsRequestedScopes(t *testing.T) {
+	ctx := context.Background()
+	users := userinfra.NewInMemoryUserRepository()
+	if err := users.Save(ctx, user.User{ID: "u1", TenantID: "t1", Email: "ana@acme.com", Status: user.UserStatusActive, Scopes: []string{"users:read"}}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewUserService(users, nil, nil, nil, noopRecorder{}, nil, directTx{}, noInvitations{}, nil, nil, nil, nil, nil,
+		WithScopeNormalization(true))
+
+	steps := []struct {
+		name          string
+		change        func() error
+		wantScopes    []string
+		wantRequested []string
+	}{
+		{
+			name:          "set",
+			change:        func() error { return s.SetUserScopes(ctx, "u1", "t1", []string{"users:*", "users:read", "roles:read"}) },
+			wantScopes:    []string{"users:*", "roles:read"},
+			wantRequested: []string{"users:*", "users:read", "roles:read"},
+		},
+		{
+			name:       "remove the wildcard",
+			change:     func() error { return s.RemoveScopesFromUser(ctx, "u1", "t1", []string{"users:*"}) },
+			wantScopes: []string{"users:read", "roles:read"},
+		},
+		{
+			name:          "make admin",
+			change:        func() error { return s.MakeUserAdmin(ctx, "u1", "t1") },
+			wantScopes:    []string{"*"},
+			wantRequested: []string{"users:read", "roles:read", "*"},
+		},
+		{
+			name:       "revoke admin",
+			change:     func() error { return s.RevokeUserAdmin(ctx, "u1", "t1") },
+			wantScopes: []string{"users:read", "roles:read"},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		saved, err := users.FindByID(ctx, "u1", "t1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(saved.Scopes, step.wantScopes) || !slices.Equal(saved.RequestedScopes, step.wantRequested) {
+			t.Errorf("%s: scopes = %v requested = %v, want %v and %v", step.name, saved.Scopes, saved.RequestedScopes, step.wantScopes, step.wantRequested)
+		}
+	}
+
+	// The preview applies the change to the granted list too
+	if err := s.SetUserScopes(ctx, "u1", "t1", []string{"users:*", "users:read"}); err != nil {
+		t.Fatal(err)
+	}
+	preview, err := s.PreviewScopeChange(ctx, "u1", "t1", user.PreviewScopeChangeRequest{Operation: user.ScopeChangeRemove, Scopes: []string{"users:*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(preview.CurrentScopes, []string{"users:*"}) || !slices.Equal(preview.ResultingScopes, []string{"users:read"}) {
+		t.Errorf("preview = %v -> %v, want users:* -> users:read", preview.CurrentScopes, preview.ResultingScopes)
+	}
+}
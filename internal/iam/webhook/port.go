@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// WebhookRepository persiste los endpoints de los tenants y sus entregas.
+// Las escrituras usan la transacción de ctx si existe, de modo que una entrega
+// y su mensaje del outbox se guardan juntos.
+type WebhookRepository interface {
+	SaveEndpoint(ctx context.Context, endpoint Endpoint) error
+	FindEndpoint(ctx context.Context, id string, tenantID kernel.TenantID) (*Endpoint, error)
+	FindEndpointsByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*Endpoint, error)
+	// FindSubscribedEndpoints retorna los endpoints activos del tenant
+	// suscritos a action, directamente o con EventAll
+	FindSubscribedEndpoints(ctx context.Context, tenantID kernel.TenantID, action audit.Action) ([]*Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id string, tenantID kernel.TenantID) error
+	CountEndpoints(ctx context.Context, tenantID kernel.TenantID) (int, error)
+
+	SaveDelivery(ctx context.Context, delivery Delivery) error
+	FindDelivery(ctx context.Context, id string) (*Delivery, error)
+	// FindDeliveries lista las entregas de un endpoint, de la más reciente a la más antigua
+	FindDeliveries(ctx context.Context, endpointID string, tenantID kernel.TenantID, limit, offset int) ([]*Delivery, error)
+}
@@ -0,0 +1,249 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// EventAll suscribe un endpoint a todas las acciones de la taxonomía
+const EventAll audit.Action = "*"
+
+// Headers de cada entrega
+const (
+	HeaderSignature = "X-Signature"        // "sha256=" + HMAC-SHA256 hex del body con el secreto del endpoint
+	HeaderEvent     = "X-Webhook-Event"    // Acción del evento, p. ej. "user.created"
+	HeaderDelivery  = "X-Webhook-Delivery" // ID de la entrega, igual en cada reintento
+)
+
+// ============================================================================
+// Endpoint Entity
+// ============================================================================
+
+// Endpoint es una URL de un tenant que recibe los eventos del ciclo de vida
+// IAM a los que está suscrito. Los eventos son las acciones de auditoría: los
+// mismos eventos alimentan el log de auditoría y los webhooks.
+type Endpoint struct {
+	ID         string          `db:"id" json:"id"`
+	TenantID   kernel.TenantID `db:"tenant_id" json:"tenant_id"`
+	URL        string          `db:"url" json:"url"`
+	Secret     string          `db:"-" json:"-"`
+	EventTypes []audit.Action  `db:"-" json:"event_types"`
+	IsActive   bool            `db:"is_active" json:"is_active"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Subscribes indica si el endpoint recibe la acción
+func (e *Endpoint) Subscribes(action audit.Action) bool {
+	return e.IsActive && (slices.Contains(e.EventTypes, EventAll) || slices.Contains(e.EventTypes, action))
+}
+
+// Sign firma body con el secreto del endpoint, con el formato de X-Signature
+func (e *Endpoint) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(e.Secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ============================================================================
+// Delivery Entity
+// ============================================================================
+
+// DeliveryStatus define los posibles estados de una entrega
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "PENDING"   // Pendiente de envío o de reintento
+	DeliveryStatusSucceeded DeliveryStatus = "SUCCEEDED" // El endpoint respondió 2xx
+	DeliveryStatusFailed    DeliveryStatus = "FAILED"    // Agotó sus intentos
+)
+
+// Delivery registra el envío de un evento a un endpoint. El payload se guarda
+// para que cada reintento envíe exactamente el mismo body firmado.
+type Delivery struct {
+	ID             string          `db:"id" json:"id"`
+	EndpointID     string          `db:"endpoint_id" json:"endpoint_id"`
+	TenantID       kernel.TenantID `db:"tenant_id" json:"tenant_id"`
+	EventID        string          `db:"event_id" json:"event_id"`
+	EventType      audit.Action    `db:"event_type" json:"event_type"`
+	Payload        string          `db:"payload" json:"-"`
+	Status         DeliveryStatus  `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	ResponseStatus *int            `db:"response_status" json:"response_status,omitempty"`
+	LastError      *string         `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at,omitempty"`
+}
+
+// RecordAttempt registra el resultado de un intento. responseStatus es 0
+// cuando no hubo respuesta. Con err nil la entrega queda SUCCEEDED; con error
+// vuelve a PENDING, o queda FAILED si final.
+func (d *Delivery) RecordAttempt(attempt, responseStatus int, err error, final bool) {
+	now := time.Now()
+	d.Attempts = attempt
+	d.UpdatedAt = now
+	d.ResponseStatus = nil
+	if responseStatus != 0 {
+		d.ResponseStatus = &responseStatus
+	}
+
+	if err == nil {
+		d.Status = DeliveryStatusSucceeded
+		d.LastError = nil
+		d.DeliveredAt = &now
+		return
+	}
+
+	lastError := err.Error()
+	d.LastError = &lastError
+	d.Status = DeliveryStatusPending
+	if final {
+		d.Status = DeliveryStatusFailed
+	}
+}
+
+// ============================================================================
+// Payload
+// ============================================================================
+
+// Payload es el body JSON de una entrega
+type Payload struct {
+	ID        string          `json:"id"` // ID del evento de auditoría, para deduplicar
+	Type      audit.Action    `json:"type"`
+	TenantID  kernel.TenantID `json:"tenant_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      PayloadData     `json:"data"`
+}
+
+// PayloadData describe el recurso afectado y quién originó el evento
+type PayloadData struct {
+	ResourceType  string         `json:"resource_type"`
+	ResourceID    string         `json:"resource_id"`
+	ActorUserID   *kernel.UserID `json:"actor_user_id,omitempty"`
+	ActorAPIKeyID *string        `json:"actor_api_key_id,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+}
+
+// NewPayload arma el body de un evento de auditoría. IP y user agent no se
+// envían fuera del sistema.
+func NewPayload(event audit.AuditEvent) Payload {
+	return Payload{
+		ID:        event.ID,
+		Type:      event.Action,
+		TenantID:  event.TenantID,
+		CreatedAt: event.CreatedAt,
+		Data: PayloadData{
+			ResourceType:  event.ResourceType,
+			ResourceID:    event.ResourceID,
+			ActorUserID:   event.ActorUserID,
+			ActorAPIKeyID: event.ActorAPIKeyID,
+			Metadata:      event.Metadata,
+		},
+	}
+}
+
+// ============================================================================
+// DTOs
+// ============================================================================
+
+// CreateEndpointRequest registra un endpoint
+type CreateEndpointRequest struct {
+	URL        string         `json:"url" validate:"required,url"`
+	EventTypes []audit.Action `json:"event_types" validate:"required,min=1"`
+}
+
+// UpdateEndpointRequest cambia la URL, las suscripciones o el estado de un
+// endpoint; los campos nil no cambian
+type UpdateEndpointRequest struct {
+	URL        *string        `json:"url,omitempty"`
+	EventTypes []audit.Action `json:"event_types,omitempty"`
+	IsActive   *bool          `json:"is_active,omitempty"`
+}
+
+// CreateEndpointResponse incluye el secreto de firma, que solo se muestra al crear
+type CreateEndpointResponse struct {
+	Endpoint Endpoint `json:"endpoint"`
+	Secret   string   `json:"secret"`
+	Message  string   `json:"message"`
+}
+
+// DeliveryListResponse es el historial paginado de entregas de un endpoint,
+// de la más reciente a la más antigua
+type DeliveryListResponse struct {
+	Deliveries []Delivery `json:"deliveries"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+}
+
+// ============================================================================
+// Error Registry
+// ============================================================================
+
+var ErrRegistry = errx.NewRegistry("WEBHOOK")
+
+var (
+	CodeEndpointNotFound  = ErrRegistry.Register("ENDPOINT_NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Webhook endpoint not found")
+	CodeDeliveryNotFound  = ErrRegistry.Register("DELIVERY_NOT_FOUND", errx.TypeNotFound, http.StatusNotFound, "Webhook delivery not found")
+	CodeInvalidURL        = ErrRegistry.Register("INVALID_URL", errx.TypeValidation, http.StatusBadRequest, "Invalid webhook URL")
+	CodeInvalidEventType  = ErrRegistry.Register("INVALID_EVENT_TYPE", errx.TypeValidation, http.StatusBadRequest, "Unknown webhook event type")
+	CodeTooManyEndpoints  = ErrRegistry.Register("TOO_MANY_ENDPOINTS", errx.TypeBusiness, http.StatusConflict, "Maximum number of webhook endpoints reached")
+	CodeDeliveryFailed    = ErrRegistry.Register("DELIVERY_FAILED", errx.TypeExternal, http.StatusBadGateway, "Webhook delivery failed")
+	CodeAddressNotAllowed = ErrRegistry.Register("ADDRESS_NOT_ALLOWED", errx.TypeValidation, http.StatusBadRequest, "Webhook URL resolves to a private address")
+	CodeSecretMissing     = ErrRegistry.Register("SECRET_MISSING", errx.TypeBusiness, http.StatusConflict, "Webhook endpoint has no signing secret, recreate it")
+)
+
+// IsEndpointNotFound indica si err es ErrEndpointNotFound
+func IsEndpointNotFound(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeEndpointNotFound.Code
+}
+
+// IsDeliveryNotFound indica si err es ErrDeliveryNotFound
+func IsDeliveryNotFound(err error) bool {
+	var e *errx.Error
+	return errx.As(err, &e) && e.Code == CodeDeliveryNotFound.Code
+}
+
+func ErrEndpointNotFound() *errx.Error {
+	return ErrRegistry.New(CodeEndpointNotFound)
+}
+
+func ErrInvalidURL() *errx.Error {
+	return ErrRegistry.New(CodeInvalidURL)
+}
+
+func ErrInvalidEventType() *errx.Error {
+	return ErrRegistry.New(CodeInvalidEventType)
+}
+
+func ErrTooManyEndpoints() *errx.Error {
+	return ErrRegistry.New(CodeTooManyEndpoints)
+}
+
+// ErrDeliveryFailed envuelve el error de un intento de entrega
+func ErrDeliveryFailed(cause error) *errx.Error {
+	return ErrRegistry.NewWithCause(CodeDeliveryFailed, cause)
+}
+
+func ErrDeliveryNotFound() *errx.Error {
+	return ErrRegistry.New(CodeDeliveryNotFound)
+}
+
+func ErrAddressNotAllowed() *errx.Error {
+	return ErrRegistry.New(CodeAddressNotAllowed)
+}
+
+// ErrSecretMissing se retorna al activar un endpoint cuyo secreto borró la
+// migración 021
+func ErrSecretMissing() *errx.Error {
+	return ErrRegistry.New(CodeSecretMissing)
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+)
+
+func TestEndpointSign(t *testing.T) {
+	endpoint := Endpoint{Secret: "whsec_test"}
+	body := []byte(`{"type":"user.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := endpoint.Sign(body); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+	if other := (&Endpoint{Secret: "other"}).Sign(body); other == want {
+		t.Error("different secrets produced the same signature")
+	}
+}
+
+func TestEndpointSubscribes(t *testing.T) {
+	endpoint := Endpoint{IsActive: true, EventTypes: []audit.Action{audit.ActionAccountCreated}}
+
+	if !endpoint.Subscribes(audit.ActionAccountCreated) {
+		t.Error("subscribed action not delivered")
+	}
+	if endpoint.Subscribes(audit.ActionUserSuspended) {
+		t.Error("unsubscribed action delivered")
+	}
+
+	all := Endpoint{IsActive: true, EventTypes: []audit.Action{EventAll}}
+	if !all.Subscribes(audit.ActionAPIKeyRevoked) {
+		t.Error("wildcard endpoint missed an action")
+	}
+
+	all.IsActive = false
+	if all.Subscribes(audit.ActionAPIKeyRevoked) {
+		t.Error("inactive endpoint subscribed")
+	}
+}
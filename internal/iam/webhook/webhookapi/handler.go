@@ -0,0 +1,111 @@
+package webhookapi
+
+import (
+	"github.com/Abraxas-365/manifesto/internal/iam"
+	"github.com/Abraxas-365/manifesto/internal/iam/auth"
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook/webhooksrv"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandlers struct {
+	service *webhooksrv.WebhookService
+}
+
+func NewWebhookHandlers(service *webhooksrv.WebhookService) *WebhookHandlers {
+	return &WebhookHandlers{service: service}
+}
+
+func (h *WebhookHandlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	webhooks := router.Group("/webhooks", authMiddleware.Authenticate())
+
+	webhooks.Post("/", authMiddleware.RequireAdminOrScope(scopes.ScopeIntegrationsWrite), h.CreateEndpoint)
+	webhooks.Get("/", authMiddleware.RequireAdminOrScope(scopes.ScopeIntegrationsRead), h.GetEndpoints)
+	webhooks.Put("/:id", authMiddleware.RequireAdminOrScope(scopes.ScopeIntegrationsWrite), h.UpdateEndpoint)
+	webhooks.Delete("/:id", authMiddleware.RequireAdminOrScope(scopes.ScopeIntegrationsDelete), h.DeleteEndpoint)
+	webhooks.Get("/:id/deliveries", authMiddleware.RequireAdminOrScope(scopes.ScopeIntegrationsRead), h.GetDeliveries)
+}
+
+// CreateEndpoint registers an endpoint. The signing secret is only returned here.
+func (h *WebhookHandlers) CreateEndpoint(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req webhook.CreateEndpointRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	response, err := h.service.CreateEndpoint(c.Context(), authContext.TenantID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (h *WebhookHandlers) GetEndpoints(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	endpoints, err := h.service.GetTenantEndpoints(c.Context(), authContext.TenantID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"endpoints": endpoints})
+}
+
+func (h *WebhookHandlers) UpdateEndpoint(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req webhook.UpdateEndpointRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(c.Context(), authContext.TenantID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(endpoint)
+}
+
+func (h *WebhookHandlers) DeleteEndpoint(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	if err := h.service.DeleteEndpoint(c.Context(), authContext.TenantID, c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"message": "Webhook endpoint deleted successfully"})
+}
+
+// GetDeliveries lists the delivery history of an endpoint, newest first.
+//
+// Query params: limit, offset.
+func (h *WebhookHandlers) GetDeliveries(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	response, err := h.service.GetDeliveries(c.Context(), authContext.TenantID, c.Params("id"), c.QueryInt("limit", 0), c.QueryInt("offset", 0))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,257 @@
+package webhookinfra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/dbx"
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresWebhookRepository implementación de PostgreSQL para WebhookRepository.
+// El secreto de firma se guarda cifrado con la clave del servidor (ver webhookSecretCipher).
+type PostgresWebhookRepository struct {
+	db      *sqlx.DB
+	secrets *webhookSecretCipher
+}
+
+// NewPostgresWebhookRepository crea una nueva instancia del repositorio de webhooks.
+// secretKey cifra los secretos de firma; sin ella los endpoints no se pueden guardar ni leer.
+func NewPostgresWebhookRepository(db *sqlx.DB, secretKey string) webhook.WebhookRepository {
+	return &PostgresWebhookRepository{
+		db:      db,
+		secrets: newWebhookSecretCipher(secretKey),
+	}
+}
+
+const endpointColumns = `id, tenant_id, url, secret_encrypted, event_types, is_active, created_at, updated_at`
+
+// endpointDB es la representación de un endpoint en la tabla webhook_endpoints
+type endpointDB struct {
+	ID         string          `db:"id"`
+	TenantID   kernel.TenantID `db:"tenant_id"`
+	URL        string          `db:"url"`
+	Secret     string          `db:"secret_encrypted"`
+	EventTypes pq.StringArray  `db:"event_types"`
+	IsActive   bool            `db:"is_active"`
+	CreatedAt  time.Time       `db:"created_at"`
+	UpdatedAt  time.Time       `db:"updated_at"`
+}
+
+// SaveEndpoint inserta o actualiza un endpoint
+func (r *PostgresWebhookRepository) SaveEndpoint(ctx context.Context, endpoint webhook.Endpoint) error {
+	eventTypes := make(pq.StringArray, len(endpoint.EventTypes))
+	for i, action := range endpoint.EventTypes {
+		eventTypes[i] = string(action)
+	}
+
+	query := `
+		INSERT INTO webhook_endpoints (` + endpointColumns + `)
+		VALUES (:id, :tenant_id, :url, :secret_encrypted, :event_types, :is_active, :created_at, :updated_at)
+		ON CONFLICT (id) DO UPDATE SET
+			url = EXCLUDED.url,
+			event_types = EXCLUDED.event_types,
+			is_active = EXCLUDED.is_active,
+			updated_at = EXCLUDED.updated_at`
+
+	secret, err := r.secrets.seal(endpoint.Secret, endpoint.ID)
+	if err != nil {
+		return errx.Wrap(err, "failed to encrypt webhook secret", errx.TypeInternal).
+			WithDetail("endpoint_id", endpoint.ID)
+	}
+
+	_, err = sqlx.NamedExecContext(ctx, dbx.Executor(ctx, r.db), query, endpointDB{
+		ID:         endpoint.ID,
+		TenantID:   endpoint.TenantID,
+		URL:        endpoint.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		IsActive:   endpoint.IsActive,
+		CreatedAt:  endpoint.CreatedAt,
+		UpdatedAt:  endpoint.UpdatedAt,
+	})
+	if err != nil {
+		return errx.Wrap(err, "failed to save webhook endpoint", errx.TypeInternal).
+			WithDetail("endpoint_id", endpoint.ID)
+	}
+
+	return nil
+}
+
+// FindEndpoint busca un endpoint del tenant por ID
+func (r *PostgresWebhookRepository) FindEndpoint(ctx context.Context, id string, tenantID kernel.TenantID) (*webhook.Endpoint, error) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoints WHERE id = $1 AND tenant_id = $2`
+
+	var row endpointDB
+	if err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &row, query, id, tenantID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, webhook.ErrEndpointNotFound().WithDetail("endpoint_id", id)
+		}
+		return nil, errx.Wrap(err, "failed to find webhook endpoint", errx.TypeInternal).
+			WithDetail("endpoint_id", id)
+	}
+
+	return r.toDomain(row)
+}
+
+// FindEndpointsByTenant lista los endpoints del tenant, del más reciente al más antiguo
+func (r *PostgresWebhookRepository) FindEndpointsByTenant(ctx context.Context, tenantID kernel.TenantID) ([]*webhook.Endpoint, error) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoints WHERE tenant_id = $1 ORDER BY created_at DESC`
+	return r.selectEndpoints(ctx, query, tenantID.String())
+}
+
+// FindSubscribedEndpoints retorna los endpoints activos suscritos a action o a todas
+func (r *PostgresWebhookRepository) FindSubscribedEndpoints(ctx context.Context, tenantID kernel.TenantID, action audit.Action) ([]*webhook.Endpoint, error) {
+	query := `
+		SELECT ` + endpointColumns + `
+		FROM webhook_endpoints
+		WHERE tenant_id = $1 AND is_active = true AND event_types && $2`
+	return r.selectEndpoints(ctx, query, tenantID.String(), pq.StringArray{string(action), string(webhook.EventAll)})
+}
+
+func (r *PostgresWebhookRepository) selectEndpoints(ctx context.Context, query string, args ...any) ([]*webhook.Endpoint, error) {
+	var rows []endpointDB
+	if err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &rows, query, args...); err != nil {
+		return nil, errx.Wrap(err, "failed to list webhook endpoints", errx.TypeInternal)
+	}
+
+	endpoints := make([]*webhook.Endpoint, len(rows))
+	for i := range rows {
+		endpoint, err := r.toDomain(rows[i])
+		if err != nil {
+			return nil, err
+		}
+		endpoints[i] = endpoint
+	}
+	return endpoints, nil
+}
+
+// DeleteEndpoint elimina un endpoint; sus entregas se eliminan en cascada
+func (r *PostgresWebhookRepository) DeleteEndpoint(ctx context.Context, id string, tenantID kernel.TenantID) error {
+	query := `DELETE FROM webhook_endpoints WHERE id = $1 AND tenant_id = $2`
+
+	result, err := dbx.Executor(ctx, r.db).ExecContext(ctx, query, id, tenantID.String())
+	if err != nil {
+		return errx.Wrap(err, "failed to delete webhook endpoint", errx.TypeInternal).
+			WithDetail("endpoint_id", id)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errx.Wrap(err, "failed to get rows affected", errx.TypeInternal)
+	}
+	if rows == 0 {
+		return webhook.ErrEndpointNotFound().WithDetail("endpoint_id", id)
+	}
+
+	return nil
+}
+
+// CountEndpoints cuenta los endpoints del tenant
+func (r *PostgresWebhookRepository) CountEndpoints(ctx context.Context, tenantID kernel.TenantID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM webhook_endpoints WHERE tenant_id = $1`
+	if err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &count, query, tenantID.String()); err != nil {
+		return 0, errx.Wrap(err, "failed to count webhook endpoints", errx.TypeInternal)
+	}
+	return count, nil
+}
+
+// toDomain descifra el secreto y convierte la fila en la entidad. Un secreto
+// vacío (borrado por la migración 021) queda vacío.
+func (r *PostgresWebhookRepository) toDomain(row endpointDB) (*webhook.Endpoint, error) {
+	secret, err := r.secrets.open(row.Secret, row.ID)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to decrypt webhook secret", errx.TypeInternal).
+			WithDetail("endpoint_id", row.ID)
+	}
+
+	eventTypes := make([]audit.Action, len(row.EventTypes))
+	for i, action := range row.EventTypes {
+		eventTypes[i] = audit.Action(action)
+	}
+
+	return &webhook.Endpoint{
+		ID:         row.ID,
+		TenantID:   row.TenantID,
+		URL:        row.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		IsActive:   row.IsActive,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}, nil
+}
+
+// ============================================================================
+// Deliveries
+// ============================================================================
+
+const deliveryColumns = `id, endpoint_id, tenant_id, event_id, event_type, payload, status,
+	attempts, response_status, last_error, created_at, updated_at, delivered_at`
+
+// SaveDelivery inserta una entrega o registra el resultado de un intento
+func (r *PostgresWebhookRepository) SaveDelivery(ctx context.Context, delivery webhook.Delivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES (
+			:id, :endpoint_id, :tenant_id, :event_id, :event_type, :payload, :status,
+			:attempts, :response_status, :last_error, :created_at, :updated_at, :delivered_at
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			response_status = EXCLUDED.response_status,
+			last_error = EXCLUDED.last_error,
+			updated_at = EXCLUDED.updated_at,
+			delivered_at = EXCLUDED.delivered_at`
+
+	_, err := sqlx.NamedExecContext(ctx, dbx.Executor(ctx, r.db), query, delivery)
+	if err != nil {
+		return errx.Wrap(err, "failed to save webhook delivery", errx.TypeInternal).
+			WithDetail("delivery_id", delivery.ID)
+	}
+
+	return nil
+}
+
+// FindDelivery busca una entrega por ID
+func (r *PostgresWebhookRepository) FindDelivery(ctx context.Context, id string) (*webhook.Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	var delivery webhook.Delivery
+	if err := sqlx.GetContext(ctx, dbx.Executor(ctx, r.db), &delivery, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, webhook.ErrDeliveryNotFound().WithDetail("delivery_id", id)
+		}
+		return nil, errx.Wrap(err, "failed to find webhook delivery", errx.TypeInternal).
+			WithDetail("delivery_id", id)
+	}
+
+	return &delivery, nil
+}
+
+// FindDeliveries lista las entregas de un endpoint, de la más reciente a la más antigua
+func (r *PostgresWebhookRepository) FindDeliveries(ctx context.Context, endpointID string, tenantID kernel.TenantID, limit, offset int) ([]*webhook.Delivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1 AND tenant_id = $2
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	var deliveries []*webhook.Delivery
+	if err := sqlx.SelectContext(ctx, dbx.Executor(ctx, r.db), &deliveries, query, endpointID, tenantID.String(), limit, offset); err != nil {
+		return nil, errx.Wrap(err, "failed to list webhook deliveries", errx.TypeInternal).
+			WithDetail("endpoint_id", endpointID)
+	}
+
+	return deliveries, nil
+}
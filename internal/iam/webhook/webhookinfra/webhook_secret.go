@@ -0,0 +1,90 @@
+package webhookinfra
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// webhookSecretPrefix versiona el formato del secreto de firma cifrado
+const webhookSecretPrefix = "v1:"
+
+var errWebhookSecretKeyMissing = errors.New("webhook secret key is not configured")
+
+// webhookSecretCipher cifra el secreto de firma de los endpoints con
+// AES-256-GCM, igual que tenantinfra cifra los client secrets de SSO. La clave
+// AES es el SHA-256 de la clave del servidor, y el ID del endpoint va como
+// dato autenticado: un secreto copiado a otro endpoint no descifra.
+type webhookSecretCipher struct {
+	aead cipher.AEAD
+}
+
+// newWebhookSecretCipher crea el cifrador. Con una clave vacía el repositorio
+// no puede guardar ni leer endpoints.
+func newWebhookSecretCipher(key string) *webhookSecretCipher {
+	if key == "" {
+		return &webhookSecretCipher{}
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		// Imposible: la clave siempre mide 32 bytes
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &webhookSecretCipher{aead: aead}
+}
+
+// seal cifra secret para endpointID
+func (c *webhookSecretCipher) seal(secret, endpointID string) (string, error) {
+	if c.aead == nil {
+		return "", errWebhookSecretKeyMissing
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), []byte(endpointID))
+	return webhookSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open descifra un secreto guardado por seal para el mismo endpointID. Un
+// secreto vacío (borrado por la migración 021) se retorna vacío.
+func (c *webhookSecretCipher) open(stored, endpointID string) (string, error) {
+	if stored == "" {
+		return "", nil
+	}
+	if c.aead == nil {
+		return "", errWebhookSecretKeyMissing
+	}
+
+	encoded, ok := strings.CutPrefix(stored, webhookSecretPrefix)
+	if !ok {
+		return "", errors.New("webhook secret is not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("webhook secret is truncated")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(endpointID))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,45 @@
+package webhookinfra
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWebhookSecretCipherRoundTrip(t *testing.T) {
+	c := newWebhookSecretCipher("server-key")
+
+	stored, err := c.seal("whsec_abc", "endpoint-a")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if strings.Contains(stored, "whsec_abc") {
+		t.Fatalf("stored secret %q contains the plaintext", stored)
+	}
+
+	got, err := c.open(stored, "endpoint-a")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if got != "whsec_abc" {
+		t.Errorf("open = %q, want %q", got, "whsec_abc")
+	}
+
+	if _, err := c.open(stored, "endpoint-b"); err == nil {
+		t.Error("open for another endpoint succeeded, want error")
+	}
+	if _, err := newWebhookSecretCipher("other-key").open(stored, "endpoint-a"); err == nil {
+		t.Error("open with another key succeeded, want error")
+	}
+	if _, err := c.open("whsec_abc", "endpoint-a"); err == nil {
+		t.Error("open of a plaintext secret succeeded, want error")
+	}
+
+	// Secrets cleared by migration 021 read as empty
+	if got, err := c.open("", "endpoint-a"); err != nil || got != "" {
+		t.Errorf("open of a cleared secret = %q, %v; want empty", got, err)
+	}
+
+	if _, err := newWebhookSecretCipher("").seal("whsec_abc", "endpoint-a"); err != errWebhookSecretKeyMissing {
+		t.Errorf("seal without key error = %v, want errWebhookSecretKeyMissing", err)
+	}
+}
@@ -0,0 +1,107 @@
+package webhooksrv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook"
+)
+
+// maxResponseBody es lo que se lee de la respuesta antes de cerrarla
+const maxResponseBody = 64 << 10
+
+// Dispatcher envía las entregas a los endpoints: un POST con el payload JSON
+// firmado con HMAC-SHA256 en X-Signature. Los reintentos los programa el outbox.
+type Dispatcher struct {
+	client *http.Client
+}
+
+// NewDispatcher crea el dispatcher. Salvo con AllowPrivateNetworks, las
+// conexiones a direcciones loopback, privadas o link-local se rechazan al
+// conectar, de modo que un endpoint no pueda apuntar a servicios internos
+// aunque su DNS cambie después de registrarlo.
+func NewDispatcher(cfg *config.WebhookConfig) *Dispatcher {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = denyPrivateAddress
+	}
+
+	return &Dispatcher{
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// Sin Proxy: un proxy conectaría por nosotros y saltaría el control
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConnsPerHost: 2,
+			},
+			// Un redirect podría llevar a una URL que no se validó
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send hace un intento de entrega. Retorna el status HTTP (0 si no hubo
+// respuesta) y un error si el endpoint no respondió 2xx.
+func (d *Dispatcher) Send(ctx context.Context, endpoint *webhook.Endpoint, delivery *webhook.Delivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, webhook.ErrInvalidURL().WithDetail("endpoint_id", endpoint.ID)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "manifesto-webhooks/1.0")
+	req.Header.Set(webhook.HeaderSignature, endpoint.Sign(body))
+	req.Header.Set(webhook.HeaderEvent, string(delivery.EventType))
+	req.Header.Set(webhook.HeaderDelivery, delivery.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, webhook.ErrDeliveryFailed(err).WithDetail("endpoint_id", endpoint.ID)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, webhook.ErrDeliveryFailed(fmt.Errorf("endpoint responded %d", resp.StatusCode)).
+			WithDetail("endpoint_id", endpoint.ID)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// denyPrivateAddress rechaza conexiones a direcciones no públicas. Se ejecuta
+// con la IP ya resuelta, así que cubre también los nombres DNS.
+func denyPrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return webhook.ErrAddressNotAllowed().WithDetail("address", host)
+	}
+	return nil
+}
+
+// isPublicIP indica si ip es una dirección unicast pública
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
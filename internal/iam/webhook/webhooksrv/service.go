@@ -0,0 +1,372 @@
+package webhooksrv
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/google/uuid"
+)
+
+// Límites de paginación del historial de entregas
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// EventMessage es el payload del mensaje KindWebhookEvent: el body del
+// evento ya serializado, para que todos los endpoints reciban los mismos bytes
+type EventMessage struct {
+	TenantID kernel.TenantID `json:"tenant_id"`
+	EventID  string          `json:"event_id"`
+	Action   audit.Action    `json:"action"`
+	Payload  string          `json:"payload"`
+}
+
+// DeliveryMessage es el payload del mensaje KindWebhookDelivery. Solo lleva
+// el ID: el body firmado se lee de la entrega al enviarla.
+type DeliveryMessage struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// WebhookService administra los endpoints de los tenants y les entrega los
+// eventos de auditoría a los que están suscritos. Implementa audit.Subscriber:
+// cada evento registrado se encola en el outbox, y al procesarlo se crea una
+// entrega por endpoint suscrito, que el outbox envía con reintentos.
+type WebhookService struct {
+	repo       webhook.WebhookRepository
+	outbox     outbox.Outbox
+	dispatcher *Dispatcher
+	config     *config.WebhookConfig
+}
+
+// NewWebhookService crea una nueva instancia del servicio de webhooks
+func NewWebhookService(
+	repo webhook.WebhookRepository,
+	outbox outbox.Outbox,
+	dispatcher *Dispatcher,
+	cfg *config.WebhookConfig,
+) *WebhookService {
+	return &WebhookService{
+		repo:       repo,
+		outbox:     outbox,
+		dispatcher: dispatcher,
+		config:     cfg,
+	}
+}
+
+// ============================================================================
+// Endpoints
+// ============================================================================
+
+// CreateEndpoint registra un endpoint y genera su secreto de firma, que solo
+// se retorna en esta respuesta
+func (s *WebhookService) CreateEndpoint(ctx context.Context, tenantID kernel.TenantID, req webhook.CreateEndpointRequest) (*webhook.CreateEndpointResponse, error) {
+	if err := s.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountEndpoints(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= s.config.MaxEndpointsPerTenant {
+		return nil, webhook.ErrTooManyEndpoints().WithDetail("max_endpoints", s.config.MaxEndpointsPerTenant)
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	endpoint := webhook.Endpoint{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.SaveEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	return &webhook.CreateEndpointResponse{
+		Endpoint: endpoint,
+		Secret:   secret,
+		Message:  "Store this secret securely. It will not be shown again.",
+	}, nil
+}
+
+// GetTenantEndpoints lista los endpoints del tenant
+func (s *WebhookService) GetTenantEndpoints(ctx context.Context, tenantID kernel.TenantID) ([]*webhook.Endpoint, error) {
+	return s.repo.FindEndpointsByTenant(ctx, tenantID)
+}
+
+// UpdateEndpoint cambia la URL, las suscripciones o el estado de un endpoint
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, tenantID kernel.TenantID, id string, req webhook.UpdateEndpointRequest) (*webhook.Endpoint, error) {
+	endpoint, err := s.repo.FindEndpoint(ctx, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := s.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		if err := validateEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+		endpoint.EventTypes = req.EventTypes
+	}
+	if req.IsActive != nil {
+		// Los endpoints cuyo secreto borró la migración 021 no se reactivan
+		if *req.IsActive && endpoint.Secret == "" {
+			return nil, webhook.ErrSecretMissing().WithDetail("endpoint_id", endpoint.ID)
+		}
+		endpoint.IsActive = *req.IsActive
+	}
+	endpoint.UpdatedAt = time.Now()
+
+	if err := s.repo.SaveEndpoint(ctx, *endpoint); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint elimina un endpoint y su historial de entregas. Las entregas
+// que seguían en el outbox se descartan.
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, tenantID kernel.TenantID, id string) error {
+	return s.repo.DeleteEndpoint(ctx, id, tenantID)
+}
+
+// GetDeliveries lista el historial de entregas de un endpoint del tenant
+func (s *WebhookService) GetDeliveries(ctx context.Context, tenantID kernel.TenantID, endpointID string, limit, offset int) (*webhook.DeliveryListResponse, error) {
+	if _, err := s.repo.FindEndpoint(ctx, endpointID, tenantID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+	offset = max(offset, 0)
+
+	deliveries, err := s.repo.FindDeliveries(ctx, endpointID, tenantID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &webhook.DeliveryListResponse{
+		Deliveries: make([]webhook.Delivery, 0, len(deliveries)),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	for _, d := range deliveries {
+		response.Deliveries = append(response.Deliveries, *d)
+	}
+	return response, nil
+}
+
+// ============================================================================
+// Delivery
+// ============================================================================
+
+// Notify implementa audit.Subscriber: encola el evento en el outbox, en la
+// transacción de ctx si existe. No consulta la base de datos: los endpoints
+// suscritos se buscan después, en FanOutEvent, fuera de la petición (los
+// logins también registran eventos). Los errores se registran en el log y no
+// se propagan.
+func (s *WebhookService) Notify(ctx context.Context, event audit.AuditEvent) {
+	if event.TenantID.IsEmpty() {
+		return
+	}
+
+	body, err := json.Marshal(webhook.NewPayload(event))
+	if err != nil {
+		logx.Errorf("Error encoding webhook payload for %s: %v", event.Action, err)
+		return
+	}
+
+	msg := EventMessage{
+		TenantID: event.TenantID,
+		EventID:  event.ID,
+		Action:   event.Action,
+		Payload:  string(body),
+	}
+	if err := s.outbox.Enqueue(ctx, outbox.KindWebhookEvent, msg); err != nil {
+		logx.WithFields(logx.Fields{
+			"tenant_id": event.TenantID,
+			"event":     event.Action,
+		}).Errorf("Error enqueuing webhook event: %v", err)
+	}
+}
+
+// FanOutEvent es el handler del outbox para KindWebhookEvent: crea una entrega
+// por cada endpoint suscrito a la acción y la encola. Todas las entregas se
+// guardan en una sola transacción, así un reintento no las duplica.
+func (s *WebhookService) FanOutEvent(ctx context.Context, msg *outbox.Message) error {
+	var event EventMessage
+	if err := msg.Decode(&event); err != nil {
+		return err
+	}
+
+	endpoints, err := s.repo.FindSubscribedEndpoints(ctx, event.TenantID, event.Action)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	return s.outbox.WithinTx(ctx, func(ctx context.Context) error {
+		for _, endpoint := range endpoints {
+			now := time.Now()
+			delivery := webhook.Delivery{
+				ID:         uuid.NewString(),
+				EndpointID: endpoint.ID,
+				TenantID:   endpoint.TenantID,
+				EventID:    event.EventID,
+				EventType:  event.Action,
+				Payload:    event.Payload,
+				Status:     webhook.DeliveryStatusPending,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+
+			if err := s.repo.SaveDelivery(ctx, delivery); err != nil {
+				return err
+			}
+			if err := s.outbox.Enqueue(ctx, outbox.KindWebhookDelivery, DeliveryMessage{DeliveryID: delivery.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeliverWebhook es el handler del outbox para KindWebhookDelivery. Registra
+// el resultado de cada intento en la entrega; un error hace que el outbox
+// reintente hasta agotar sus intentos, y el último deja la entrega FAILED.
+func (s *WebhookService) DeliverWebhook(ctx context.Context, msg *outbox.Message) error {
+	var payload DeliveryMessage
+	if err := msg.Decode(&payload); err != nil {
+		return err
+	}
+
+	logger := logx.FromContext(ctx).WithField("delivery_id", payload.DeliveryID)
+
+	delivery, err := s.repo.FindDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		if webhook.IsDeliveryNotFound(err) {
+			logger.Info("skipping webhook delivery of a deleted endpoint")
+			return nil
+		}
+		return err
+	}
+	if delivery.Status != webhook.DeliveryStatusPending {
+		// Entregada por un intento anterior cuyo resultado no llegó al outbox
+		return nil
+	}
+
+	endpoint, err := s.repo.FindEndpoint(ctx, delivery.EndpointID, delivery.TenantID)
+	if err != nil {
+		if webhook.IsEndpointNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !endpoint.IsActive || endpoint.Secret == "" {
+		delivery.RecordAttempt(msg.Attempts, 0, webhook.ErrDeliveryFailed(errors.New("endpoint disabled")), true)
+		return s.repo.SaveDelivery(ctx, *delivery)
+	}
+
+	status, sendErr := s.dispatcher.Send(ctx, endpoint, delivery)
+	delivery.RecordAttempt(msg.Attempts, status, sendErr, !msg.CanRetry())
+	if err := s.repo.SaveDelivery(ctx, *delivery); err != nil {
+		logger.Errorf("Error recording webhook delivery attempt: %v", err)
+	}
+
+	return sendErr
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// validateURL exige https y un host público. Con AllowPrivateNetworks también
+// se aceptan http y hosts internos, para desarrollo.
+func (s *WebhookService) validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return webhook.ErrInvalidURL().WithDetail("url", raw)
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !s.config.AllowPrivateNetworks {
+			return webhook.ErrInvalidURL().WithDetail("reason", "url must use https")
+		}
+	default:
+		return webhook.ErrInvalidURL().WithDetail("url", raw)
+	}
+
+	if s.config.AllowPrivateNetworks {
+		return nil
+	}
+
+	// Las IPs literales se rechazan ya; los nombres se controlan al conectar
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return webhook.ErrAddressNotAllowed().WithDetail("address", host)
+	}
+	if host == "localhost" {
+		return webhook.ErrAddressNotAllowed().WithDetail("address", host)
+	}
+	return nil
+}
+
+// validateEventTypes exige al menos una acción, todas de la taxonomía de
+// auditoría o EventAll
+func validateEventTypes(eventTypes []audit.Action) error {
+	if len(eventTypes) == 0 {
+		return webhook.ErrInvalidEventType().WithDetail("reason", "at least one event type is required")
+	}
+	for _, action := range eventTypes {
+		if action != webhook.EventAll && !action.IsKnown() {
+			return webhook.ErrInvalidEventType().WithDetail("event_type", string(action))
+		}
+	}
+	return nil
+}
+
+// generateSecret genera el secreto de firma de un endpoint
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
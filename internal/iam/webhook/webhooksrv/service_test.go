@@ -0,0 +1,175 @@
+package webhooksrv
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/config"
+	"github.com/Abraxas-365/manifesto/internal/iam/audit"
+	"github.com/Abraxas-365/manifesto/internal/iam/outbox"
+	"github.com/Abraxas-365/manifesto/internal/iam/webhook"
+	"github.com/Abraxas-365/manifesto/internal/kernel"
+)
+
+// deliveryRepo implementa las partes de webhook.WebhookRepository usadas por DeliverWebhook
+type deliveryRepo struct {
+	webhook.WebhookRepository
+	endpoint *webhook.Endpoint
+	delivery *webhook.Delivery
+}
+
+func (r *deliveryRepo) FindDelivery(context.Context, string) (*webhook.Delivery, error) {
+	d := *r.delivery
+	return &d, nil
+}
+
+func (r *deliveryRepo) FindEndpoint(context.Context, string, kernel.TenantID) (*webhook.Endpoint, error) {
+	return r.endpoint, nil
+}
+
+func (r *deliveryRepo) SaveDelivery(_ context.Context, d webhook.Delivery) error {
+	r.delivery = &d
+	return nil
+}
+
+func TestDeliverWebhookSignsAndRecordsStatus(t *testing.T) {
+	var gotSignature, gotBody string
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotSignature = r.Header.Get(webhook.HeaderSignature)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	endpoint := &webhook.Endpoint{ID: "e1", TenantID: "t1", URL: server.URL, Secret: "whsec_test", IsActive: true}
+	repo := &deliveryRepo{
+		endpoint: endpoint,
+		delivery: &webhook.Delivery{
+			ID:         "d1",
+			EndpointID: "e1",
+			TenantID:   "t1",
+			EventType:  audit.ActionAccountCreated,
+			Payload:    `{"type":"user.created"}`,
+			Status:     webhook.DeliveryStatusPending,
+		},
+	}
+	cfg := &config.WebhookConfig{Timeout: 5 * time.Second, AllowPrivateNetworks: true}
+	svc := NewWebhookService(repo, nil, NewDispatcher(cfg), cfg)
+
+	msg, err := outbox.NewMessage("m1", outbox.KindWebhookDelivery, DeliveryMessage{DeliveryID: "d1"}, 2)
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+
+	// First attempt fails: the outbox retries and the delivery stays pending
+	msg.Attempts = 1
+	if err := svc.DeliverWebhook(context.Background(), msg); err == nil {
+		t.Fatal("DeliverWebhook succeeded on a 500")
+	}
+	if repo.delivery.Status != webhook.DeliveryStatusPending || *repo.delivery.ResponseStatus != 500 {
+		t.Errorf("after 500: status %s, response %v", repo.delivery.Status, repo.delivery.ResponseStatus)
+	}
+
+	if gotBody != `{"type":"user.created"}` {
+		t.Errorf("body = %q", gotBody)
+	}
+	if gotSignature != endpoint.Sign([]byte(gotBody)) {
+		t.Errorf("signature = %q, want %q", gotSignature, endpoint.Sign([]byte(gotBody)))
+	}
+
+	status = http.StatusNoContent
+	msg.Attempts = 2
+	if err := svc.DeliverWebhook(context.Background(), msg); err != nil {
+		t.Fatalf("DeliverWebhook: %v", err)
+	}
+	if repo.delivery.Status != webhook.DeliveryStatusSucceeded || repo.delivery.Attempts != 2 || repo.delivery.DeliveredAt == nil {
+		t.Errorf("after 204: %+v", repo.delivery)
+	}
+}
+
+func TestDispatcherRejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback endpoint")
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(&config.WebhookConfig{Timeout: 5 * time.Second})
+	endpoint := &webhook.Endpoint{ID: "e1", URL: server.URL, Secret: "s"}
+
+	if _, err := dispatcher.Send(context.Background(), endpoint, &webhook.Delivery{ID: "d1", Payload: "{}"}); err == nil {
+		t.Error("Send to a loopback address succeeded")
+	}
+}
+
+// memoryOutbox guarda los mensajes encolados sin transacción
+type memoryOutbox struct {
+	messages []*outbox.Message
+}
+
+func (o *memoryOutbox) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (o *memoryOutbox) Enqueue(_ context.Context, kind outbox.Kind, payload any) error {
+	msg, err := outbox.NewMessage("m", kind, payload, 3)
+	if err != nil {
+		return err
+	}
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// fanOutRepo implementa las partes de webhook.WebhookRepository usadas por FanOutEvent
+type fanOutRepo struct {
+	webhook.WebhookRepository
+	endpoints  []*webhook.Endpoint
+	deliveries []webhook.Delivery
+}
+
+func (r *fanOutRepo) FindSubscribedEndpoints(context.Context, kernel.TenantID, audit.Action) ([]*webhook.Endpoint, error) {
+	return r.endpoints, nil
+}
+
+func (r *fanOutRepo) SaveDelivery(_ context.Context, d webhook.Delivery) error {
+	r.deliveries = append(r.deliveries, d)
+	return nil
+}
+
+func TestNotifyFansOutThroughTheOutbox(t *testing.T) {
+	ctx := context.Background()
+	queue := &memoryOutbox{}
+	// Notify must not touch the repository: a nil one panics if it does
+	svc := NewWebhookService(nil, queue, nil, &config.WebhookConfig{})
+
+	svc.Notify(ctx, audit.AuditEvent{ID: "ev1", TenantID: "t1", Action: audit.ActionAccountCreated})
+	svc.Notify(ctx, audit.AuditEvent{ID: "ev2", Action: audit.ActionAccountCreated}) // no tenant
+	if len(queue.messages) != 1 || queue.messages[0].Kind != outbox.KindWebhookEvent {
+		t.Fatalf("enqueued %+v, want one webhook event", queue.messages)
+	}
+
+	repo := &fanOutRepo{endpoints: []*webhook.Endpoint{{ID: "e1", TenantID: "t1"}, {ID: "e2", TenantID: "t1"}}}
+	svc = NewWebhookService(repo, queue, nil, &config.WebhookConfig{})
+	event := queue.messages[0]
+	queue.messages = nil
+
+	if err := svc.FanOutEvent(ctx, event); err != nil {
+		t.Fatalf("FanOutEvent: %v", err)
+	}
+	if len(repo.deliveries) != 2 || len(queue.messages) != 2 {
+		t.Fatalf("got %d deliveries and %d messages, want 2 each", len(repo.deliveries), len(queue.messages))
+	}
+	for i, d := range repo.deliveries {
+		if d.EventID != "ev1" || d.EndpointID != repo.endpoints[i].ID || d.Status != webhook.DeliveryStatusPending {
+			t.Errorf("delivery %d = %+v", i, d)
+		}
+		if queue.messages[i].Kind != outbox.KindWebhookDelivery {
+			t.Errorf("message %d kind = %s", i, queue.messages[i].Kind)
+		}
+	}
+}
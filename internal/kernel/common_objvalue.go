@@ -1,5 +1,7 @@
 package kernel
 
+import "regexp"
+
 type Email string
 
 type Phone string
@@ -7,3 +9,10 @@ type Phone string
 type FirstName string
 
 type LastName string
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// IsValid reports whether the phone number is in E.164 format (e.g. +14155552671)
+func (p Phone) IsValid() bool {
+	return e164Pattern.MatchString(string(p))
+}
@@ -1,5 +1,11 @@
 package kernel
 
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/iam/scopes"
+)
+
 // ============================================================================
 // Context Types - Tipos para context.Context
 // ============================================================================
@@ -12,6 +18,9 @@ type AuthContext struct {
 	Name     string   `json:"name"`
 	Scopes   []string `json:"scopes"`
 	IsAPIKey bool     `json:"is_api_key"`
+	// SessionID es la sesión de login del access token; vacío en API keys
+	// y en tokens emitidos antes de que las sesiones se vincularan a tokens
+	SessionID string `json:"session_id,omitempty"`
 }
 
 // ============================================================================
@@ -32,20 +41,7 @@ func (ac *AuthContext) IsValid() bool {
 
 // HasScope verifica si el contexto tiene un scope específico
 func (ac *AuthContext) HasScope(scope string) bool {
-	for _, s := range ac.Scopes {
-		// Exact match or wildcard "*"
-		if s == scope || s == "*" {
-			return true
-		}
-		// Wildcard match (e.g., "channels:*" matches "channels:read")
-		if len(s) > 2 && s[len(s)-2:] == ":*" {
-			prefix := s[:len(s)-2]
-			if len(scope) > len(prefix) && scope[:len(prefix)] == prefix && scope[len(prefix)] == ':' {
-				return true
-			}
-		}
-	}
-	return false
+	return scopes.HasScopeIn(ac.Scopes, scope)
 }
 
 // IsAdmin verifica si el contexto tiene permisos de administrador
@@ -92,3 +88,17 @@ const (
 	// RequestIDKey es la clave para almacenar el ID de la petición
 	RequestIDKey ContextKey = "request_id"
 )
+
+// WithAuthContext devuelve una copia de ctx que lleva authContext, para que
+// las capas sin acceso a Fiber (p. ej. el metering de LLM) sepan en nombre de
+// qué tenant y usuario se ejecuta la operación
+func WithAuthContext(ctx context.Context, authContext *AuthContext) context.Context {
+	return context.WithValue(ctx, AuthContextKey, authContext)
+}
+
+// AuthContextFrom devuelve el AuthContext guardado con WithAuthContext; ok es
+// false si ctx no lleva uno válido
+func AuthContextFrom(ctx context.Context) (*AuthContext, bool) {
+	authContext, ok := ctx.Value(AuthContextKey).(*AuthContext)
+	return authContext, ok && authContext != nil && authContext.IsValid()
+}
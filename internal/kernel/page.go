@@ -0,0 +1,85 @@
+package kernel
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+)
+
+// ============================================================================
+// Cursor pagination
+// ============================================================================
+
+// Page is the response of list endpoints: a page of items, the total number
+// of items matching the filters and the cursor of the next page, omitted on
+// the last one. Clients pass next_cursor back as ?cursor= to continue.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage builds a page from items fetched with limit+1 rows: the extra row
+// only proves there is a next page and is dropped. cursorOf gives the
+// position of an item, so the cursor points after the last item returned.
+func NewPage[T any](items []T, total, limit int, cursorOf func(T) Cursor) Page[T] {
+	page := Page[T]{Items: items, Total: total}
+	if limit > 0 && len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = cursorOf(page.Items[limit-1]).Encode()
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+// MapPage converts the items of a page, typically from entities to DTOs
+func MapPage[T, U any](page Page[T], fn func(T) U) Page[U] {
+	items := make([]U, len(page.Items))
+	for i, item := range page.Items {
+		items[i] = fn(item)
+	}
+	return Page[U]{Items: items, Total: page.Total, NextCursor: page.NextCursor}
+}
+
+// Cursor is a keyset position: the creation time and ID of the last item of
+// a page. Lists are ordered newest first, by created_at and then id, so the
+// next page holds the items strictly before it.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+var (
+	pageErrors = errx.NewRegistry("PAGINATION")
+
+	ErrInvalidCursor = pageErrors.Register("INVALID_CURSOR", errx.TypeValidation, http.StatusBadRequest, "Invalid pagination cursor")
+)
+
+// DecodeCursor parses a cursor produced by Encode. An empty string is the
+// first page and returns nil.
+func DecodeCursor(raw string) (*Cursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, pageErrors.New(ErrInvalidCursor)
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return nil, pageErrors.New(ErrInvalidCursor)
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,48 @@
+package kernel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC), ID: "u1"}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("decoded = %+v, want %+v", decoded, cursor)
+	}
+
+	if decoded, err := DecodeCursor(""); decoded != nil || err != nil {
+		t.Errorf("DecodeCursor(\"\") = %v, %v; want nil, nil", decoded, err)
+	}
+	for _, raw := range []string{"not base64!", "e30"} { // "e30" is {}
+		if _, err := DecodeCursor(raw); err == nil {
+			t.Errorf("DecodeCursor(%q) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursorOf := func(id string) Cursor { return Cursor{CreatedAt: at, ID: id} }
+
+	page := NewPage([]string{"c", "b", "a"}, 5, 2, cursorOf)
+	if len(page.Items) != 2 || page.Total != 5 {
+		t.Fatalf("page = %+v", page)
+	}
+	if next, _ := DecodeCursor(page.NextCursor); next == nil || next.ID != "b" {
+		t.Errorf("next cursor = %+v, want after b", next)
+	}
+
+	last := NewPage([]string{"a"}, 5, 2, cursorOf)
+	if last.NextCursor != "" {
+		t.Errorf("last page has next cursor %q", last.NextCursor)
+	}
+	if empty := NewPage[string](nil, 0, 2, cursorOf); empty.Items == nil {
+		t.Error("empty page items = nil, want []")
+	}
+}
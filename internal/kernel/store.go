@@ -1,7 +1,7 @@
 package kernel
 
-// Page represents pagination metadata
-type Page struct {
+// PageInfo represents offset pagination metadata
+type PageInfo struct {
 	Number int `json:"page"`      // Current page number (1-based)
 	Size   int `json:"page_size"` // Number of records per page
 	Total  int `json:"total"`     // Total number of records
@@ -10,9 +10,9 @@ type Page struct {
 
 // Paginated is a generic container for paginated data with metadata
 type Paginated[T any] struct {
-	Items []T  `json:"items"`      // The paginated items
-	Page  Page `json:"pagination"` // Pagination metadata
-	Empty bool `json:"empty"`      // Whether the result contains any items
+	Items []T      `json:"items"`      // The paginated items
+	Page  PageInfo `json:"pagination"` // Pagination metadata
+	Empty bool     `json:"empty"`      // Whether the result contains any items
 }
 
 // NewPaginated creates a new paginated result with calculated fields
@@ -24,7 +24,7 @@ func NewPaginated[T any](items []T, page, size, total int) Paginated[T] {
 
 	return Paginated[T]{
 		Items: items,
-		Page: Page{
+		Page: PageInfo{
 			Number: page,
 			Size:   size,
 			Total:  total,
@@ -26,6 +26,17 @@ func GetDefaultLogger() *Logger {
 	return defaultLogger
 }
 
+// DefaultRedactor returns the redactor of the default logger
+func DefaultRedactor() *Redactor {
+	return defaultLogger.Redactor()
+}
+
+// Redact masks credentials and sensitive key/value pairs in free text (e.g.
+// an error message) using the default logger's redactor
+func Redact(s string) string {
+	return defaultLogger.Redactor().String(s)
+}
+
 // SetLevel sets the log level for the default logger
 func SetLevel(level Level) {
 	defaultLogger.SetLevel(level)
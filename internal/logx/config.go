@@ -40,6 +40,10 @@ type Config struct {
 
 	// Output is where to write logs (defaults to os.Stdout)
 	Output *os.File
+
+	// RedactKeys are extra field names (besides DefaultRedactKeys) whose
+	// values are masked by the Redactor, e.g. "ssn" or "iban"
+	RedactKeys []string
 }
 
 // DefaultConfig returns the default configuration
@@ -104,5 +108,14 @@ func LoadFromEnv() *Config {
 		}
 	}
 
+	// LOG_REDACT_KEYS (comma separated)
+	if keys := os.Getenv("LOG_REDACT_KEYS"); keys != "" {
+		for _, key := range strings.Split(keys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				config.RedactKeys = append(config.RedactKeys, key)
+			}
+		}
+	}
+
 	return config
 }
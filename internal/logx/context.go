@@ -0,0 +1,49 @@
+package logx
+
+import "context"
+
+// LocalsKey is the key under which request-scoped fields are stored in the
+// Fiber locals. Fiber locals are fasthttp user values, which the request
+// context (c.Context()) exposes through Value, so FromContext finds them in
+// any context derived from it.
+const LocalsKey = "logx_fields"
+
+// fieldsKey is the context key of fields attached with NewContext
+type fieldsKey struct{}
+
+// NewContext returns a copy of ctx carrying fields, merged over the fields
+// ctx already carries. Every entry obtained through FromContext includes them.
+func NewContext(ctx context.Context, fields Fields) context.Context {
+	merged := ContextFields(ctx)
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// ContextFields returns a copy of the fields carried by ctx: the request
+// fields from the Fiber locals plus those added with NewContext
+func ContextFields(ctx context.Context) Fields {
+	fields := make(Fields)
+	if ctx == nil {
+		return fields
+	}
+	if locals, ok := ctx.Value(LocalsKey).(Fields); ok {
+		for k, v := range locals {
+			fields[k] = v
+		}
+	}
+	if attached, ok := ctx.Value(fieldsKey{}).(Fields); ok {
+		for k, v := range attached {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// FromContext returns an entry of the default logger pre-filled with the
+// fields carried by ctx (request_id, tenant_id, user_id, ...). With a context
+// that carries nothing it behaves like a plain entry.
+func FromContext(ctx context.Context) *Entry {
+	return newEntry(defaultLogger).WithContext(ctx)
+}
@@ -0,0 +1,25 @@
+package logx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContextCarriesFields(t *testing.T) {
+	// Fiber locals reach services as values of the request context
+	ctx := context.WithValue(context.Background(), LocalsKey, Fields{"request_id": "req-1", "tenant_id": "t1"})
+	ctx = NewContext(ctx, Fields{"job": "import"})
+
+	entry := FromContext(ctx).WithField("tenant_id", "override")
+
+	want := map[string]interface{}{"request_id": "req-1", "job": "import", "tenant_id": "override"}
+	for k, v := range want {
+		if entry.fields[k] != v {
+			t.Errorf("field %s = %v, want %v", k, entry.fields[k], v)
+		}
+	}
+
+	if fields := ContextFields(context.Background()); len(fields) != 0 {
+		t.Errorf("empty context carries fields: %v", fields)
+	}
+}
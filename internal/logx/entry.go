@@ -45,9 +45,15 @@ func (e *Entry) WithError(err error) *Entry {
 	return e
 }
 
-// WithContext adds context (chainable)
+// WithContext adds context and the fields it carries (see FromContext).
+// Fields already set on the entry take precedence. (chainable)
 func (e *Entry) WithContext(ctx context.Context) *Entry {
 	e.ctx = ctx
+	for k, v := range ContextFields(ctx) {
+		if _, ok := e.fields[k]; !ok {
+			e.fields[k] = v
+		}
+	}
 	return e
 }
 
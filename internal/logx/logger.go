@@ -17,6 +17,7 @@ type Logger struct {
 	mu        sync.Mutex
 	writer    io.Writer
 	exitFunc  func(int)
+	redactor  *Redactor
 }
 
 // NewLogger creates a new logger with the given config
@@ -45,9 +46,15 @@ func NewLogger(config *Config) *Logger {
 		formatter: formatter,
 		writer:    writer,
 		exitFunc:  os.Exit,
+		redactor:  NewRedactor(config.RedactKeys...),
 	}
 }
 
+// Redactor returns the redactor built from the logger's RedactKeys
+func (l *Logger) Redactor() *Redactor {
+	return l.redactor
+}
+
 // SetLevel sets the log level
 func (l *Logger) SetLevel(level Level) {
 	l.mu.Lock()
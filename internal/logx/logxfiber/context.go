@@ -0,0 +1,55 @@
+package logxfiber
+
+import (
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ContextLogger returns a middleware that stores the request fields
+// (request_id) in the Fiber locals, so logx.FromContext(c.Context()) and any
+// context derived from it log them on every line. Register it after the
+// request ID middleware; auth middleware adds tenant_id and user_id with
+// AddFields once the caller is known.
+func ContextLogger(requestIDHeader string) fiber.Handler {
+	if requestIDHeader == "" {
+		requestIDHeader = fiber.HeaderXRequestID
+	}
+
+	return func(c *fiber.Ctx) error {
+		fields := logx.Fields{}
+		if id := requestID(c, requestIDHeader); id != "" {
+			fields["request_id"] = id
+		}
+		c.Locals(logx.LocalsKey, fields)
+		return c.Next()
+	}
+}
+
+// AddFields merges fields into the request fields, so they are included by
+// every later logx.FromContext call of this request
+func AddFields(c *fiber.Ctx, fields logx.Fields) {
+	merged := logx.Fields{}
+	if current, ok := c.Locals(logx.LocalsKey).(logx.Fields); ok {
+		for k, v := range current {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	c.Locals(logx.LocalsKey, merged)
+}
+
+// Logger returns a logx entry carrying the request fields
+func Logger(c *fiber.Ctx) *logx.Entry {
+	return logx.FromContext(c.Context())
+}
+
+// requestID reads the request ID sent by the client or, failing that, the one
+// the request ID middleware generated and set on the response
+func requestID(c *fiber.Ctx, header string) string {
+	if id := c.Get(header); id != "" {
+		return id
+	}
+	return c.GetRespHeader(header)
+}
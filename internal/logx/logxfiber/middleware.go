@@ -0,0 +1,162 @@
+// Package logxfiber provides the Fiber request logging middleware built on
+// logx. It lives outside logx so the logger itself does not depend on Fiber.
+package logxfiber
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/logx"
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultMaxBodySize caps how many bytes of a body are logged
+const defaultMaxBodySize = 4096
+
+// Identity extracts the tenant and user of an authenticated request. It runs
+// after the handler, so locals set by auth middleware are available.
+type Identity func(c *fiber.Ctx) (tenantID, userID string)
+
+// Config configures the request logger
+type Config struct {
+	// Redactor masks sensitive headers and body fields. Defaults to
+	// logx.DefaultRedactor() (DefaultRedactKeys plus LOG_REDACT_KEYS).
+	Redactor *logx.Redactor
+
+	// Identity resolves tenant_id and user_id for the record. Optional.
+	Identity Identity
+
+	// RequestIDHeader is the header holding the request ID (default X-Request-ID)
+	RequestIDHeader string
+
+	// LogHeaders adds the (redacted) request headers to the record
+	LogHeaders bool
+
+	// LogBodies adds the (redacted) request and response bodies to the record
+	LogBodies bool
+
+	// MaxBodySize caps the logged body size in bytes (default 4096)
+	MaxBodySize int
+
+	// Skip excludes requests from logging (e.g. health checks)
+	Skip func(c *fiber.Ctx) bool
+}
+
+// New returns a middleware that writes one structured record per request:
+// request_id, method, path, status, latency, tenant_id and user_id, plus the
+// redacted headers and bodies when enabled. 5xx are logged as errors, 4xx as
+// warnings and the rest as info.
+func New(cfg Config) fiber.Handler {
+	if cfg.Redactor == nil {
+		cfg.Redactor = logx.DefaultRedactor()
+	}
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = fiber.HeaderXRequestID
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultMaxBodySize
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			return c.Next()
+		}
+
+		start := time.Now()
+		chainErr := c.Next()
+
+		// The error handler has not run yet, so the status of a failed
+		// request is taken from the error itself
+		status := c.Response().StatusCode()
+		if chainErr != nil {
+			status = fiber.StatusInternalServerError
+			var appErr *errx.Error
+			var fiberErr *fiber.Error
+			if errx.As(chainErr, &appErr) {
+				status = appErr.HTTPStatus
+			} else if errx.As(chainErr, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		fields := logx.Fields{
+			"request_id": requestID(c, cfg.RequestIDHeader),
+			"method":     c.Method(),
+			"path":       c.Path(),
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"ip":         c.IP(),
+		}
+
+		if cfg.Identity != nil {
+			tenantID, userID := cfg.Identity(c)
+			if tenantID != "" {
+				fields["tenant_id"] = tenantID
+			}
+			if userID != "" {
+				fields["user_id"] = userID
+			}
+		}
+
+		if cfg.LogHeaders {
+			headers := make(map[string]string)
+			c.Request().Header.VisitAll(func(key, value []byte) {
+				headers[string(key)] = string(value)
+			})
+			fields["headers"] = cfg.Redactor.Headers(headers)
+		}
+
+		if cfg.LogBodies {
+			// Reading a streamed body would consume it (e.g. POST /files/upload
+			// or GET /users/export)
+			if c.Request().IsBodyStream() {
+				if c.Request().Header.ContentLength() != 0 {
+					fields["request_body"] = "(stream)"
+				}
+			} else if body := c.Body(); len(body) > 0 {
+				fields["request_body"] = redactBody(cfg, c.Get(fiber.HeaderContentType), body)
+			}
+			if c.Response().IsBodyStream() {
+				fields["response_body"] = "(stream)"
+			} else if body := c.Response().Body(); len(body) > 0 {
+				fields["response_body"] = redactBody(cfg, string(c.Response().Header.ContentType()), body)
+			}
+		}
+
+		if chainErr != nil {
+			fields["error"] = cfg.Redactor.String(chainErr.Error())
+		}
+
+		entry := logx.WithFields(fields)
+		msg := c.Method() + " " + c.Path()
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			entry.Error(msg)
+		case status >= fiber.StatusBadRequest:
+			entry.Warn(msg)
+		default:
+			entry.Info(msg)
+		}
+
+		return chainErr
+	}
+}
+
+// redactBody redacts a JSON body field by field and any other body as free
+// text, truncated to MaxBodySize
+func redactBody(cfg Config, contentType string, body []byte) interface{} {
+	if strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) && len(body) <= cfg.MaxBodySize {
+		return cfg.Redactor.JSON(body)
+	}
+
+	truncated := len(body) > cfg.MaxBodySize
+	if truncated {
+		body = body[:cfg.MaxBodySize]
+	}
+	text := cfg.Redactor.String(string(body))
+	if truncated {
+		text += "...(truncated)"
+	}
+	return text
+}
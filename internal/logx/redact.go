@@ -0,0 +1,151 @@
+package logx
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces sensitive values in logs
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are the field names always treated as sensitive. A key
+// matches when it equals one of these or contains it as a "_" separated part
+// (e.g. "refresh_token", "new_password").
+var DefaultRedactKeys = []string{"password", "code", "token", "secret"}
+
+// DefaultRedactHeaders are the HTTP headers whose values are never logged
+var DefaultRedactHeaders = []string{"Authorization", "X-API-Key", "Cookie", "Set-Cookie"}
+
+// bearerPattern matches bearer credentials embedded in free text
+var bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+
+// Redactor masks sensitive values in fields, headers, JSON bodies and free
+// text before they are logged
+type Redactor struct {
+	keys    map[string]struct{}
+	headers map[string]struct{}
+	pattern *regexp.Regexp
+}
+
+// NewRedactor creates a Redactor for DefaultRedactKeys plus extraKeys.
+// Keys are case-insensitive.
+func NewRedactor(extraKeys ...string) *Redactor {
+	r := &Redactor{
+		keys:    make(map[string]struct{}),
+		headers: make(map[string]struct{}),
+	}
+	for _, key := range append(append([]string{}, DefaultRedactKeys...), extraKeys...) {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			r.keys[key] = struct{}{}
+		}
+	}
+	for _, header := range DefaultRedactHeaders {
+		r.headers[strings.ToLower(header)] = struct{}{}
+	}
+
+	// key=value and "key":"value" occurrences in free text (error messages)
+	names := make([]string, 0, len(r.keys))
+	for key := range r.keys {
+		names = append(names, regexp.QuoteMeta(key))
+	}
+	r.pattern = regexp.MustCompile(`(?i)("?\b(?:[a-z0-9]+_)*(?:` + strings.Join(names, "|") + `)(?:_[a-z0-9]+)*"?\s*[:=]\s*"?)([^"&\s,}]+)`)
+
+	return r
+}
+
+// Keys returns the sensitive field names of the redactor
+func (r *Redactor) Keys() []string {
+	keys := make([]string, 0, len(r.keys))
+	for key := range r.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// IsSensitiveKey reports whether a field with this name must be redacted
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for _, part := range strings.FieldsFunc(key, func(c rune) bool { return c == '_' || c == '-' || c == '.' }) {
+		if _, ok := r.keys[part]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSensitiveHeader reports whether an HTTP header value must be redacted
+func (r *Redactor) IsSensitiveHeader(name string) bool {
+	_, ok := r.headers[strings.ToLower(name)]
+	return ok
+}
+
+// Fields returns a copy of fields with sensitive values redacted
+func (r *Redactor) Fields(fields Fields) Fields {
+	if len(fields) == 0 {
+		return fields
+	}
+	redacted := make(Fields, len(fields))
+	for key, value := range fields {
+		if r.IsSensitiveKey(key) {
+			redacted[key] = RedactedValue
+			continue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// Headers returns a copy of headers with sensitive values redacted
+func (r *Redactor) Headers(headers map[string]string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if r.IsSensitiveHeader(name) {
+			redacted[name] = RedactedValue
+			continue
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// JSON redacts the sensitive fields of a JSON document at any depth. Bodies
+// that are not valid JSON are redacted as free text.
+func (r *Redactor) JSON(body []byte) interface{} {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return r.String(string(body))
+	}
+	return r.value(doc)
+}
+
+func (r *Redactor) value(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			if r.IsSensitiveKey(key) {
+				typed[key] = RedactedValue
+				continue
+			}
+			typed[key] = r.value(value)
+		}
+		return typed
+	case []interface{}:
+		for i, value := range typed {
+			typed[i] = r.value(value)
+		}
+		return typed
+	default:
+		return v
+	}
+}
+
+// String masks bearer credentials and key=value / "key":"value" pairs of
+// sensitive keys in free text such as error messages
+func (r *Redactor) String(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+RedactedValue)
+	return r.pattern.ReplaceAllString(s, "${1}"+RedactedValue)
+}
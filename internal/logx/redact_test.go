@@ -0,0 +1,73 @@
+package logx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedactorJSON(t *testing.T) {
+	r := NewRedactor("ssn")
+
+	body := []byte(`{"email":"a@acme.com","password":"hunter2","ssn":"123","session":{"refresh_token":"abc","id":"s1"},"items":[{"code":"999"}]}`)
+	out, err := json.Marshal(r.JSON(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := string(out)
+	for _, secret := range []string{"hunter2", `"123"`, "abc", "999"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("redacted body still contains %s: %s", secret, got)
+		}
+	}
+	for _, kept := range []string{"a@acme.com", `"s1"`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("redacted body lost %s: %s", kept, got)
+		}
+	}
+}
+
+func TestRedactorHeaders(t *testing.T) {
+	r := NewRedactor()
+
+	got := r.Headers(map[string]string{
+		"Authorization": "Bearer xyz",
+		"x-api-key":     "key",
+		"Cookie":        "sid=1",
+		"Accept":        "application/json",
+	})
+
+	for _, name := range []string{"Authorization", "x-api-key", "Cookie"} {
+		if got[name] != RedactedValue {
+			t.Errorf("header %s = %q, want redacted", name, got[name])
+		}
+	}
+	if got["Accept"] != "application/json" {
+		t.Errorf("Accept = %q, want unchanged", got["Accept"])
+	}
+}
+
+func TestRedactorString(t *testing.T) {
+	r := NewRedactor()
+
+	tests := []struct {
+		in     string
+		secret string
+	}{
+		{"invalid header: Bearer eyJhbGciOi.payload.sig", "eyJhbGciOi"},
+		{"callback failed: code=4/0AX4 state=xyz", "4/0AX4"},
+		{`decode {"access_token":"tok123"}`, "tok123"},
+	}
+
+	for _, tt := range tests {
+		got := r.String(tt.in)
+		if strings.Contains(got, tt.secret) {
+			t.Errorf("String(%q) = %q, still contains %q", tt.in, got, tt.secret)
+		}
+	}
+
+	if got := r.String("user not found"); got != "user not found" {
+		t.Errorf("String changed a message without secrets: %q", got)
+	}
+}
@@ -1,5 +1,7 @@
 package notifx
 
+import "net/mail"
+
 // EmailMessage represents an email to be sent.
 type EmailMessage struct {
 	From        string       `json:"from"`
@@ -27,3 +29,12 @@ type SendResult struct {
 	Success   bool   `json:"success"`
 	Error     string `json:"error,omitempty"`
 }
+
+// FormatAddress returns "Name <address>", RFC 2047 encoding the name if
+// needed, or just address when name is empty.
+func FormatAddress(name, address string) string {
+	if name == "" {
+		return address
+	}
+	return (&mail.Address{Name: name, Address: address}).String()
+}
@@ -0,0 +1,9 @@
+package notifxsmtp
+
+import "github.com/Abraxas-365/manifesto/internal/errx"
+
+var smtpErrors = errx.NewRegistry("NOTIFX_SMTP")
+
+var (
+	ErrSendFailed = smtpErrors.Register("SEND_FAILED", errx.TypeExternal, 500, "SMTP send email failed")
+)
@@ -0,0 +1,153 @@
+package notifxsmtp
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+	"github.com/google/uuid"
+)
+
+// Config holds the SMTP server settings.
+type Config struct {
+	Host     string
+	Port     int
+	Username string // empty disables authentication
+	Password string
+
+	// FromAddress is the envelope sender, and the From header when a message
+	// sets none.
+	FromAddress string
+}
+
+// SMTPProvider implements notifx.EmailSender and notifx.BulkEmailSender using an SMTP server.
+type SMTPProvider struct {
+	cfg Config
+}
+
+// NewSMTPProvider creates a new SMTP email provider.
+func NewSMTPProvider(cfg Config) *SMTPProvider {
+	return &SMTPProvider{cfg: cfg}
+}
+
+// SendEmail sends a single email via SMTP.
+func (p *SMTPProvider) SendEmail(ctx context.Context, msg notifx.EmailMessage, opts ...notifx.Option) error {
+	if msg.From == "" {
+		msg.From = p.cfg.FromAddress
+	}
+
+	addr := net.JoinHostPort(p.cfg.Host, strconv.Itoa(p.cfg.Port))
+
+	var auth smtp.Auth
+	if p.cfg.Username != "" {
+		auth = smtp.PlainAuth("", p.cfg.Username, p.cfg.Password, p.cfg.Host)
+	}
+
+	recipients := make([]string, 0, len(msg.To)+len(msg.CC)+len(msg.BCC))
+	for _, list := range [][]string{msg.To, msg.CC, msg.BCC} {
+		for _, rcpt := range list {
+			recipients = append(recipients, sanitizeHeader(rcpt))
+		}
+	}
+
+	// net/smtp has no context support; run the send so ctx cancellation
+	// at least stops the caller from waiting
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, p.cfg.FromAddress, recipients, buildMIMEMessage(msg))
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case err = <-done:
+	}
+	if err != nil {
+		return smtpErrors.NewWithCause(ErrSendFailed, err).
+			WithDetail("to", msg.To).
+			WithDetail("subject", msg.Subject)
+	}
+
+	return nil
+}
+
+// SendBulkEmail sends multiple emails individually via SMTP.
+func (p *SMTPProvider) SendBulkEmail(ctx context.Context, msgs []notifx.EmailMessage, opts ...notifx.Option) ([]notifx.SendResult, error) {
+	results := make([]notifx.SendResult, len(msgs))
+
+	for i, msg := range msgs {
+		to := ""
+		if len(msg.To) > 0 {
+			to = msg.To[0]
+		}
+
+		err := p.SendEmail(ctx, msg, opts...)
+		results[i] = notifx.SendResult{
+			To:      to,
+			Success: err == nil,
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+
+	return results, nil
+}
+
+// buildMIMEMessage builds a multipart/alternative message with text and HTML
+// parts. Header values are stripped of CR/LF so message fields (e.g. a tenant
+// name in the subject) cannot inject headers, and the subject is RFC 2047
+// encoded so non-ASCII text survives.
+func buildMIMEMessage(msg notifx.EmailMessage) []byte {
+	boundary := uuid.NewString()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", sanitizeHeader(msg.From))
+	fmt.Fprintf(&b, "To: %s\r\n", joinAddresses(msg.To))
+	if len(msg.CC) > 0 {
+		fmt.Fprintf(&b, "Cc: %s\r\n", joinAddresses(msg.CC))
+	}
+	if msg.ReplyTo != "" {
+		fmt.Fprintf(&b, "Reply-To: %s\r\n", sanitizeHeader(msg.ReplyTo))
+	}
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", sanitizeHeader(msg.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.TextBody)
+	b.WriteString("\r\n")
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	b.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.HTMLBody)
+	b.WriteString("\r\n")
+
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	return []byte(b.String())
+}
+
+var headerReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// sanitizeHeader removes line breaks from a header value
+func sanitizeHeader(value string) string {
+	return headerReplacer.Replace(value)
+}
+
+func joinAddresses(addresses []string) string {
+	sanitized := make([]string, len(addresses))
+	for i, a := range addresses {
+		sanitized[i] = sanitizeHeader(a)
+	}
+	return strings.Join(sanitized, ", ")
+}
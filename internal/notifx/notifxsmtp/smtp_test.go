@@ -0,0 +1,49 @@
+package notifxsmtp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/manifesto/internal/notifx"
+)
+
+func headers(t *testing.T, raw []byte) []string {
+	t.Helper()
+	head, _, ok := strings.Cut(string(raw), "\r\n\r\n")
+	if !ok {
+		t.Fatal("message has no header/body separator")
+	}
+	return strings.Split(head, "\r\n")
+}
+
+func TestBuildMIMEMessageStripsHeaderInjection(t *testing.T) {
+	raw := buildMIMEMessage(notifx.EmailMessage{
+		From:    "noreply@example.com",
+		To:      []string{"user@example.com\r\nBcc: victim@example.com"},
+		Subject: "Join Acme\r\nBcc: attacker@example.com",
+	})
+
+	for _, h := range headers(t, raw) {
+		if strings.HasPrefix(h, "Bcc:") {
+			t.Fatalf("injected header found: %q", h)
+		}
+	}
+}
+
+func TestBuildMIMEMessageEncodesNonASCIISubject(t *testing.T) {
+	raw := buildMIMEMessage(notifx.EmailMessage{
+		From:    "noreply@example.com",
+		To:      []string{"user@example.com"},
+		Subject: "Únete a Compañía",
+	})
+
+	for _, h := range headers(t, raw) {
+		if subject, ok := strings.CutPrefix(h, "Subject: "); ok {
+			if !strings.HasPrefix(subject, "=?UTF-8?q?") {
+				t.Errorf("subject not RFC 2047 encoded: %q", subject)
+			}
+			return
+		}
+	}
+	t.Fatal("no Subject header")
+}
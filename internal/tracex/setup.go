@@ -0,0 +1,55 @@
+package tracex
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Config configures the OTLP exporter installed by Setup
+type Config struct {
+	ServiceName string
+	// Endpoint is the OTLP/HTTP collector host:port
+	Endpoint string
+	Insecure bool
+	// SampleRatio is the fraction of new traces sampled (0..1). Incoming
+	// sampled parents are always followed.
+	SampleRatio float64
+}
+
+// Setup installs an SDK tracer provider that batches spans to an OTLP/HTTP
+// collector and the W3C trace-context propagator. The returned function
+// flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, errx.Wrap(err, "failed to create OTLP trace exporter", errx.TypeExternal).
+			WithDetail("endpoint", cfg.Endpoint)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		SetTracerProvider(nil)
+		return provider.Shutdown(ctx)
+	}, nil
+}
@@ -0,0 +1,79 @@
+// Package tracex wraps OpenTelemetry tracing behind a process-wide tracer that
+// is a no-op until Setup (or SetTracerProvider) installs a real provider, so
+// instrumented code costs nothing when tracing is disabled.
+package tracex
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName identifies this module's spans
+const instrumentationName = "github.com/Abraxas-365/manifesto"
+
+// LocalsKey is the Fiber locals key holding the trace.SpanContext of the HTTP
+// server span. Handlers pass c.Context() (the fasthttp request) to services,
+// which exposes locals through Value, so Start nests spans under the server
+// span even though that context does not carry the span itself.
+const LocalsKey = "tracex_span"
+
+var (
+	tracer  atomic.Value // trace.Tracer
+	enabled atomic.Bool
+)
+
+func init() {
+	tracer.Store(noop.NewTracerProvider().Tracer(instrumentationName))
+}
+
+// SetTracerProvider installs provider as the source of every span. Passing
+// nil restores the no-op tracer.
+func SetTracerProvider(provider trace.TracerProvider) {
+	if provider == nil {
+		tracer.Store(noop.NewTracerProvider().Tracer(instrumentationName))
+		enabled.Store(false)
+		return
+	}
+	tracer.Store(provider.Tracer(instrumentationName))
+	enabled.Store(true)
+}
+
+// Enabled reports whether spans are being recorded
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Tracer returns the current tracer
+func Tracer() trace.Tracer {
+	return tracer.Load().(trace.Tracer)
+}
+
+// Start starts a span as a child of the span carried by ctx, or of the HTTP
+// server span stored in the Fiber locals. When tracing is disabled it returns
+// ctx unchanged and a non-recording span.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if !Enabled() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if parent, ok := ctx.Value(LocalsKey).(trace.SpanContext); ok && parent.IsValid() {
+			ctx = trace.ContextWithSpanContext(ctx, parent)
+		}
+	}
+
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records err on the span (if any) and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,73 @@
+// Package tracexfiber provides the Fiber middleware that opens the HTTP server
+// span every tracex span of a request nests under.
+package tracexfiber
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Abraxas-365/manifesto/internal/errx"
+	"github.com/Abraxas-365/manifesto/internal/tracex"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a middleware that starts a server span per request, continuing
+// the trace of an incoming traceparent header. The span is stored in the
+// Fiber locals (tracex.LocalsKey) and in c.UserContext(). When tracing is
+// disabled it only calls the next handler.
+func New() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !tracex.Enabled() {
+			return c.Next()
+		}
+
+		carrier := propagation.MapCarrier{}
+		c.Request().Header.VisitAll(func(key, value []byte) {
+			carrier[strings.ToLower(string(key))] = string(value)
+		})
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+
+		ctx, span := tracex.Tracer().Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.Locals(tracex.LocalsKey, span.SpanContext())
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// Name the span after the matched route to keep cardinality low
+		span.SetName(c.Method() + " " + c.Route().Path)
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var appErr *errx.Error
+			var fiberErr *fiber.Error
+			if errx.As(err, &appErr) {
+				status = appErr.HTTPStatus
+			} else if errx.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+
+		return err
+	}
+}
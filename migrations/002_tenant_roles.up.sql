@@ -0,0 +1,23 @@
+
+-- ============================================================================
+-- TENANT ROLES (Custom named scope bundles per tenant)
+-- ============================================================================
+
+CREATE TABLE tenant_roles (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_tenant_roles_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT uq_tenant_roles_name UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_tenant_roles_tenant_id ON tenant_roles(tenant_id);
+
+CREATE TRIGGER update_tenant_roles_updated_at BEFORE UPDATE ON tenant_roles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
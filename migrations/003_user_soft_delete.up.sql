@@ -0,0 +1,19 @@
+
+-- ============================================================================
+-- USERS: Soft delete
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_user_status;
+ALTER TABLE users ADD CONSTRAINT chk_user_status
+    CHECK (status IN ('ACTIVE', 'INACTIVE', 'SUSPENDED', 'PENDING', 'DELETED'));
+
+-- Email uniqueness only applies to non-deleted users, so a deleted user's
+-- email can be invited again
+ALTER TABLE users DROP CONSTRAINT IF EXISTS uq_users_email_tenant;
+CREATE UNIQUE INDEX uq_users_email_tenant ON users(email, tenant_id) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_users_deleted_at ON users(deleted_at);
+
+COMMENT ON COLUMN users.deleted_at IS 'Soft delete timestamp; NULL for live users';
@@ -0,0 +1,19 @@
+-- ============================================================================
+-- OTP: delivery channel (email or phone)
+-- ============================================================================
+
+ALTER TABLE otps ADD COLUMN channel VARCHAR(10) NOT NULL DEFAULT 'EMAIL';
+ALTER TABLE otps ADD CONSTRAINT chk_otp_channel CHECK (channel IN ('EMAIL', 'PHONE'));
+
+COMMENT ON COLUMN otps.channel IS 'Delivery channel derived from contact: EMAIL or PHONE (E.164)';
+
+-- ============================================================================
+-- USERS: phone number for SMS OTP login
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN phone VARCHAR(16);
+
+CREATE UNIQUE INDEX uq_users_phone_tenant ON users(phone, tenant_id)
+    WHERE phone IS NOT NULL AND deleted_at IS NULL;
+
+COMMENT ON COLUMN users.phone IS 'E.164 phone number used for passwordless SMS login';
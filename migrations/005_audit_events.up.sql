@@ -0,0 +1,27 @@
+-- ============================================================================
+-- AUDIT EVENTS (append-only log of authentication and IAM transitions)
+-- ============================================================================
+
+-- No foreign keys: audit rows must outlive the users, keys and invitations
+-- they reference.
+CREATE TABLE audit_events (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    actor_user_id VARCHAR(255),
+    actor_api_key_id VARCHAR(255),
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_events_tenant_created ON audit_events(tenant_id, created_at DESC);
+CREATE INDEX idx_audit_events_tenant_action ON audit_events(tenant_id, action);
+CREATE INDEX idx_audit_events_resource ON audit_events(tenant_id, resource_type, resource_id);
+CREATE INDEX idx_audit_events_actor_user ON audit_events(actor_user_id);
+
+COMMENT ON TABLE audit_events IS 'Append-only audit log of authentication and IAM events';
+COMMENT ON COLUMN audit_events.action IS 'Audited transition, e.g. auth.login_succeeded, api_key.revoked';
@@ -0,0 +1,10 @@
+-- ============================================================================
+-- SESSION-BOUND REFRESH TOKENS
+-- ============================================================================
+
+-- Links each refresh token to the login session it was issued for, so a single
+-- session can be signed out. Tokens issued before this migration have no
+-- session and keep working until they expire.
+ALTER TABLE refresh_tokens ADD COLUMN session_id VARCHAR(255);
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
@@ -0,0 +1,26 @@
+-- ============================================================================
+-- TENANT SSO CONNECTIONS
+-- ============================================================================
+
+-- Per-tenant OpenID Connect connection used instead of the shared Google /
+-- Microsoft apps. One connection per tenant; email_domains lets the login page
+-- route an email to its tenant's IdP.
+CREATE TABLE tenant_sso_connections (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255) NOT NULL,
+    issuer_url TEXT NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL,
+    redirect_url TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT ARRAY['openid', 'email', 'profile'],
+    groups_claim VARCHAR(255) NOT NULL DEFAULT '',
+    email_domains TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_tenant_sso_connections_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT uq_tenant_sso_connections_tenant UNIQUE (tenant_id)
+);
+
+CREATE INDEX idx_tenant_sso_connections_email_domains ON tenant_sso_connections USING GIN (email_domains);
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- TENANT EMAIL DOMAINS
+-- ============================================================================
+
+-- Email domains claimed by tenants. Once verified (verified_at set) a domain
+-- belongs to a single tenant and is used to suggest that tenant to new email
+-- addresses of the domain.
+CREATE TABLE tenant_domains (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255) NOT NULL,
+    domain VARCHAR(255) NOT NULL,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_tenant_domains_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT uq_tenant_domains_tenant_domain UNIQUE (tenant_id, domain)
+);
+
+CREATE UNIQUE INDEX uq_tenant_domains_verified_domain ON tenant_domains(domain) WHERE verified_at IS NOT NULL;
+CREATE INDEX idx_tenant_domains_domain ON tenant_domains(domain);
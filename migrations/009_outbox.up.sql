@@ -0,0 +1,25 @@
+-- ============================================================================
+-- TRANSACTIONAL OUTBOX
+-- ============================================================================
+
+-- Side effects (invitation emails, OTP codes) written in the same transaction
+-- as the domain change that produces them. A background dispatcher delivers
+-- them at least once, retrying with backoff until max_attempts.
+CREATE TABLE outbox_messages (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    kind VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'PENDING',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+
+    CONSTRAINT chk_outbox_messages_status CHECK (status IN ('PENDING', 'SENT', 'FAILED'))
+);
+
+CREATE INDEX idx_outbox_messages_due ON outbox_messages(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_outbox_messages_sent ON outbox_messages(sent_at) WHERE status = 'SENT';
@@ -0,0 +1,9 @@
+-- ============================================================================
+-- REFRESH TOKEN REVOCATION REASON
+-- ============================================================================
+
+-- Records why a refresh token was revoked. Only a ROTATED token presented again
+-- is treated as theft (all of the user's tokens are revoked); SIGNED_OUT tokens
+-- (logout, session sign-out or eviction) are just rejected. Tokens revoked
+-- before this migration have no reason and are rejected without escalation.
+ALTER TABLE refresh_tokens ADD COLUMN revoked_reason VARCHAR(32);
@@ -0,0 +1,13 @@
+-- ============================================================================
+-- ENCRYPTED TENANT SSO CLIENT SECRETS
+-- ============================================================================
+
+-- SSO client secrets are now stored AES-GCM encrypted with the server key
+-- (OAUTH_SSO_SECRET_KEY) instead of in plaintext. Secrets saved before this
+-- migration can't be decrypted: they are cleared and their connections
+-- deactivated until the tenant saves the secret again.
+ALTER TABLE tenant_sso_connections RENAME COLUMN client_secret TO client_secret_encrypted;
+
+UPDATE tenant_sso_connections
+SET client_secret_encrypted = '', is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+WHERE client_secret_encrypted NOT LIKE 'v1:%';
@@ -0,0 +1,8 @@
+-- ============================================================================
+-- USERS: optional password for credential login
+-- ============================================================================
+
+-- NULL means the user has no password and can only log in with OAuth or OTP
+ALTER TABLE users ADD COLUMN password_hash VARCHAR(255);
+
+COMMENT ON COLUMN users.password_hash IS 'bcrypt hash for POST /auth/password/login; NULL disables password login';
@@ -0,0 +1,15 @@
+-- ============================================================================
+-- PASSWORD RESET TOKENS: hashed tokens scoped to the user's tenant
+-- ============================================================================
+
+-- Tokens were stored in clear and no endpoint consumed them yet; drop them
+-- rather than keep working reset links in the table
+DELETE FROM password_reset_tokens;
+
+-- token now holds the hex SHA-256 of the value sent by email
+ALTER TABLE password_reset_tokens ADD COLUMN tenant_id VARCHAR(255) NOT NULL;
+
+CREATE INDEX idx_password_reset_tokens_user_created ON password_reset_tokens(user_id, created_at);
+
+COMMENT ON COLUMN password_reset_tokens.token IS 'Hex SHA-256 of the reset token; the token itself is only sent by email';
+COMMENT ON COLUMN password_reset_tokens.tenant_id IS 'Tenant of user_id, used to load the user on POST /auth/password/reset';
@@ -0,0 +1,45 @@
+-- ============================================================================
+-- WEBHOOKS
+-- ============================================================================
+
+-- Tenant endpoints that receive IAM lifecycle events. event_types holds audit
+-- actions (e.g. 'user.created') or '*' for all of them. The secret signs each
+-- body with HMAC-SHA256 and is only shown to the tenant when created.
+CREATE TABLE webhook_endpoints (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_webhook_endpoints_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_endpoints_tenant ON webhook_endpoints(tenant_id) WHERE is_active = TRUE;
+
+-- One row per event and endpoint. The outbox retries the delivery; this table
+-- records its outcome. payload is TEXT, not JSONB, so retries send the exact
+-- bytes that were signed.
+CREATE TABLE webhook_deliveries (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    endpoint_id VARCHAR(255) NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'PENDING',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED'))
+);
+
+CREATE INDEX idx_webhook_deliveries_endpoint_created ON webhook_deliveries(endpoint_id, created_at DESC);
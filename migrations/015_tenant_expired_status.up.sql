@@ -0,0 +1,8 @@
+-- ============================================================================
+-- TENANTS: Expired status
+-- ============================================================================
+
+-- The expiry job moves tenants whose trial or subscription lapsed to EXPIRED
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS chk_tenant_status;
+ALTER TABLE tenants ADD CONSTRAINT chk_tenant_status
+    CHECK (status IN ('ACTIVE', 'SUSPENDED', 'CANCELED', 'TRIAL', 'EXPIRED'));
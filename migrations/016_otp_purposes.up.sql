@@ -0,0 +1,10 @@
+-- ============================================================================
+-- OTPS: Purpose-specific codes
+-- ============================================================================
+
+-- Signup and login codes get their own purpose so one cannot complete the other
+ALTER TABLE otps DROP CONSTRAINT IF EXISTS chk_otp_purpose;
+ALTER TABLE otps ADD CONSTRAINT chk_otp_purpose
+    CHECK (purpose IN ('JOB_APPLICATION', 'VERIFICATION', 'SIGNUP', 'LOGIN'));
+
+COMMENT ON COLUMN otps.purpose IS 'Purpose of the OTP (JOB_APPLICATION, VERIFICATION, SIGNUP, LOGIN)';
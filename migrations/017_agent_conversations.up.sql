@@ -0,0 +1,17 @@
+-- ============================================================================
+-- AGENT CONVERSATIONS
+-- ============================================================================
+
+-- Messages of persistent agent conversations (memoryxpg.Store), one row per
+-- message in the order they were added. message holds the whole llm.Message,
+-- tool calls and tool results included. tenant_id has no foreign key: memoryx
+-- does not depend on IAM tenants.
+CREATE TABLE agent_conversation_messages (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    conversation_id VARCHAR(255) NOT NULL,
+    message JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_agent_conversation_messages_conversation ON agent_conversation_messages(tenant_id, conversation_id, id);
@@ -0,0 +1,10 @@
+-- ============================================================================
+-- KEYSET PAGINATION
+-- ============================================================================
+
+-- Cursor pagination (dbx.Keyset) filters by tenant and seeks to
+-- (created_at, id) < cursor, newest first. These indexes serve both the seek
+-- and the ORDER BY created_at DESC, id DESC, so deep pages never scan the
+-- rows before them the way OFFSET did.
+CREATE INDEX idx_users_tenant_keyset ON users(tenant_id, created_at, id);
+CREATE INDEX idx_invitations_tenant_keyset ON invitations(tenant_id, created_at, id);
@@ -0,0 +1,23 @@
+-- ============================================================================
+-- LLM USAGE
+-- ============================================================================
+
+-- Token usage and cost of LLM and embedding calls (usagexpg.Store), one row
+-- per call made on behalf of a tenant. cost_usd is priced when the call is
+-- recorded, so later price changes do not rewrite past spend. tenant_id has no
+-- foreign key: usagex does not depend on IAM tenants.
+CREATE TABLE llm_usage (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255),
+    operation VARCHAR(20) NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cached_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_llm_usage_tenant_created ON llm_usage(tenant_id, created_at);
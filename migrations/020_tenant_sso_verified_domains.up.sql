@@ -0,0 +1,16 @@
+-- ============================================================================
+-- TENANT SSO CONNECTIONS ROUTED BY VERIFIED DOMAINS
+-- ============================================================================
+
+-- SSO connections no longer keep their own email_domains: any tenant could list
+-- another company's domain there. A connection now accepts, and is routed to
+-- by, the tenant's verified domains in tenant_domains. The listed domains are
+-- kept as unverified claims so tenants can verify the ones they own.
+INSERT INTO tenant_domains (tenant_id, domain)
+SELECT DISTINCT c.tenant_id, lower(d.domain)
+FROM tenant_sso_connections c, unnest(c.email_domains) AS d(domain)
+WHERE d.domain <> ''
+ON CONFLICT (tenant_id, domain) DO NOTHING;
+
+DROP INDEX IF EXISTS idx_tenant_sso_connections_email_domains;
+ALTER TABLE tenant_sso_connections DROP COLUMN email_domains;
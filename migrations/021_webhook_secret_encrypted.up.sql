@@ -0,0 +1,13 @@
+-- ============================================================================
+-- ENCRYPTED WEBHOOK SIGNING SECRETS
+-- ============================================================================
+
+-- Webhook signing secrets are now stored AES-GCM encrypted with the server key
+-- (WEBHOOK_SECRET_KEY) instead of in plaintext. Secrets saved before this
+-- migration can't be encrypted here: they are cleared and their endpoints
+-- deactivated. Tenants recreate those endpoints to get a new secret.
+ALTER TABLE webhook_endpoints RENAME COLUMN secret TO secret_encrypted;
+
+UPDATE webhook_endpoints
+SET secret_encrypted = '', is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+WHERE secret_encrypted NOT LIKE 'v1:%';
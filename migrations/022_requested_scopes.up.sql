@@ -0,0 +1,13 @@
+-- ============================================================================
+-- REQUESTED SCOPES
+-- ============================================================================
+
+-- With IAM_NORMALIZE_SCOPES the scopes a wildcard of the same list implies
+-- are dropped before saving. requested_scopes keeps the list as it was
+-- granted, so narrower scopes come back when the wildcard is removed. NULL
+-- means normalization left the list unchanged.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS requested_scopes TEXT[];
+ALTER TABLE invitations ADD COLUMN IF NOT EXISTS requested_scopes TEXT[];
+
+COMMENT ON COLUMN users.requested_scopes IS 'Scopes as granted, before normalization; NULL when equal to scopes';
+COMMENT ON COLUMN invitations.requested_scopes IS 'Scopes as requested, before normalization; NULL when equal to scopes';